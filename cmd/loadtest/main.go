@@ -0,0 +1,376 @@
+// Command loadtest drives the snipo HTTP API with concurrent clients and
+// reports per-operation latency percentiles.
+//
+// Usage:
+//
+//	loadtest -url http://localhost:8080 -token $SNIPO_TOKEN -concurrency 16 -duration 30s
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// operation is one kind of request issued by the load generator
+type operation struct {
+	name   string
+	weight int
+	run    func(ctx context.Context, c *client, r *rand.Rand) (int, error)
+}
+
+// client wraps the HTTP client with base URL and authentication
+type client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+
+	mu      sync.Mutex
+	created []string
+}
+
+// stats collects latency samples for one operation
+type stats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+	statuses  map[int]int
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the snipo server")
+	token := flag.String("token", os.Getenv("SNIPO_TOKEN"), "API token (defaults to $SNIPO_TOKEN)")
+	concurrency := flag.Int("concurrency", 8, "number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run")
+	requests := flag.Int("requests", 0, "stop after this many requests in total (0 = use -duration)")
+	writeRatio := flag.Int("write", 10, "percentage of requests that create snippets (0-100)")
+	cleanup := flag.Bool("cleanup", true, "delete snippets created during the run")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed for the request mix")
+	flag.Parse()
+
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "Error: -token (or SNIPO_TOKEN) is required")
+		os.Exit(1)
+	}
+	if *concurrency < 1 || *writeRatio < 0 || *writeRatio > 100 {
+		fmt.Fprintln(os.Stderr, "Error: -concurrency must be >= 1 and -write between 0 and 100")
+		os.Exit(1)
+	}
+
+	c := &client{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimRight(*baseURL, "/"),
+		token:   *token,
+	}
+
+	readWeight := 100 - *writeRatio
+	ops := []operation{
+		{name: "list", weight: readWeight * 4 / 10, run: opList},
+		{name: "list_filtered", weight: readWeight * 2 / 10, run: opListFiltered},
+		{name: "search", weight: readWeight * 2 / 10, run: opSearch},
+		{name: "get", weight: readWeight - readWeight*8/10, run: opGet},
+		{name: "create", weight: *writeRatio, run: opCreate},
+	}
+
+	results := make(map[string]*stats, len(ops))
+	for _, op := range ops {
+		results[op.name] = &stats{statuses: make(map[int]int)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		remaining = make(chan struct{}, max(*requests, 1))
+	)
+	if *requests > 0 {
+		for i := 0; i < *requests; i++ {
+			remaining <- struct{}{}
+		}
+		close(remaining)
+	}
+
+	fmt.Printf("load testing %s with %d workers for %s (write %d%%)\n", c.baseURL, *concurrency, durationLabel(*duration, *requests), *writeRatio)
+
+	start := time.Now()
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(*seed + int64(worker)))
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				if *requests > 0 {
+					if _, ok := <-remaining; !ok {
+						return
+					}
+				}
+
+				op := pick(ops, r)
+				began := time.Now()
+				status, err := op.run(ctx, c, r)
+				elapsed := time.Since(began)
+
+				// Requests cut off by the deadline are not meaningful samples
+				if ctx.Err() != nil && err != nil {
+					return
+				}
+				results[op.name].record(elapsed, status, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report(os.Stdout, ops, results, elapsed)
+
+	if *cleanup {
+		deleted := c.cleanup()
+		if deleted > 0 {
+			fmt.Printf("\ncleaned up %d snippets\n", deleted)
+		}
+	}
+}
+
+func durationLabel(d time.Duration, requests int) string {
+	if requests > 0 {
+		return fmt.Sprintf("%d requests (max %s)", requests, d)
+	}
+	return d.String()
+}
+
+func pick(ops []operation, r *rand.Rand) operation {
+	total := 0
+	for _, op := range ops {
+		total += op.weight
+	}
+	n := r.Intn(max(total, 1))
+	for _, op := range ops {
+		if n < op.weight {
+			return op
+		}
+		n -= op.weight
+	}
+	return ops[0]
+}
+
+func (s *stats) record(d time.Duration, status int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, d)
+	if status != 0 {
+		s.statuses[status]++
+	}
+	if err != nil {
+		s.errors++
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return sorted[idx]
+}
+
+func report(w io.Writer, ops []operation, results map[string]*stats, elapsed time.Duration) {
+	_, _ = fmt.Fprintf(w, "\n%-14s %8s %7s %10s %10s %10s %10s  %s\n", "operation", "requests", "errors", "p50", "p95", "p99", "max", "statuses")
+
+	var all []time.Duration
+	totalErrors := 0
+	for _, op := range ops {
+		s := results[op.name]
+		if len(s.latencies) == 0 {
+			continue
+		}
+		sorted := append([]time.Duration(nil), s.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		all = append(all, sorted...)
+		totalErrors += s.errors
+
+		_, _ = fmt.Fprintf(w, "%-14s %8d %7d %10s %10s %10s %10s  %s\n",
+			op.name, len(sorted), s.errors,
+			round(percentile(sorted, 50)), round(percentile(sorted, 95)),
+			round(percentile(sorted, 99)), round(sorted[len(sorted)-1]),
+			formatStatuses(s.statuses))
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	_, _ = fmt.Fprintf(w, "%-14s %8d %7d %10s %10s %10s\n", "total", len(all), totalErrors,
+		round(percentile(all, 50)), round(percentile(all, 95)), round(percentile(all, 99)))
+
+	if elapsed > 0 {
+		_, _ = fmt.Fprintf(w, "\nthroughput: %.1f req/s over %s\n", float64(len(all))/elapsed.Seconds(), round(elapsed))
+	}
+}
+
+func formatStatuses(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d=%d", code, statuses[code]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d > time.Second:
+		return d.Round(time.Millisecond)
+	case d > time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// Operations
+
+var (
+	loadLanguages = []string{"go", "python", "javascript", "rust", "sql", "bash"}
+	loadTerms     = []string{"request", "config", "parse", "handler", "cache", "token"}
+)
+
+func opList(ctx context.Context, c *client, r *rand.Rand) (int, error) {
+	return c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/snippets?page=%d&limit=20", r.Intn(5)+1), nil, nil)
+}
+
+func opListFiltered(ctx context.Context, c *client, r *rand.Rand) (int, error) {
+	q := url.Values{}
+	q.Set("language", loadLanguages[r.Intn(len(loadLanguages))])
+	q.Set("sort", "created_at")
+	q.Set("order", "desc")
+	if r.Intn(2) == 0 {
+		q.Set("favorite", "true")
+	}
+	return c.do(ctx, http.MethodGet, "/api/v1/snippets?"+q.Encode(), nil, nil)
+}
+
+func opSearch(ctx context.Context, c *client, r *rand.Rand) (int, error) {
+	q := url.Values{}
+	q.Set("q", loadTerms[r.Intn(len(loadTerms))])
+	return c.do(ctx, http.MethodGet, "/api/v1/snippets/search?"+q.Encode(), nil, nil)
+}
+
+func opGet(ctx context.Context, c *client, r *rand.Rand) (int, error) {
+	c.mu.Lock()
+	var id string
+	if len(c.created) > 0 {
+		id = c.created[r.Intn(len(c.created))]
+	}
+	c.mu.Unlock()
+
+	if id == "" {
+		return opList(ctx, c, r)
+	}
+	return c.do(ctx, http.MethodGet, "/api/v1/snippets/"+id, nil, nil)
+}
+
+func opCreate(ctx context.Context, c *client, r *rand.Rand) (int, error) {
+	lang := loadLanguages[r.Intn(len(loadLanguages))]
+	term := loadTerms[r.Intn(len(loadTerms))]
+	body := map[string]any{
+		"title":       fmt.Sprintf("loadtest %s %d", term, r.Int63()),
+		"description": "created by cmd/loadtest",
+		"content":     fmt.Sprintf("// %s example\n%s\n", term, strings.Repeat("x := 1\n", r.Intn(50)+1)),
+		"language":    lang,
+		"tags":        []string{"loadtest"},
+	}
+
+	var out struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	status, err := c.do(ctx, http.MethodPost, "/api/v1/snippets", body, &out)
+	if err == nil && out.Data.ID != "" {
+		c.mu.Lock()
+		c.created = append(c.created, out.Data.ID)
+		c.mu.Unlock()
+	}
+	return status, err
+}
+
+// do sends a request and decodes a JSON response into out when non-nil.
+// Non-2xx statuses are reported as errors.
+func (c *client) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+		return resp.StatusCode, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// cleanup deletes snippets created during the run
+func (c *client) cleanup() int {
+	c.mu.Lock()
+	ids := c.created
+	c.created = nil
+	c.mu.Unlock()
+
+	deleted := 0
+	for _, id := range ids {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if _, err := c.do(ctx, http.MethodDelete, "/api/v1/snippets/"+id, nil, nil); err == nil {
+			deleted++
+		}
+		cancel()
+	}
+	return deleted
+}
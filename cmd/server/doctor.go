@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/storage"
+)
+
+// minSessionSecretBits is the entropy below which the session secret is reported as weak
+const minSessionSecretBits = 128
+
+// doctorStatus is the outcome of a single self-test check
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "OK"
+	doctorWarn doctorStatus = "WARN"
+	doctorFail doctorStatus = "FAIL"
+	doctorSkip doctorStatus = "SKIP"
+)
+
+// doctorResult is one line of the doctor report
+type doctorResult struct {
+	Name   string
+	Status doctorStatus
+	Detail string
+}
+
+// doctorReport collects check results in the order they ran
+type doctorReport struct {
+	results []doctorResult
+}
+
+func (r *doctorReport) add(name string, status doctorStatus, format string, args ...any) {
+	r.results = append(r.results, doctorResult{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *doctorReport) count(status doctorStatus) int {
+	n := 0
+	for _, res := range r.results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *doctorReport) print(w io.Writer) {
+	for _, res := range r.results {
+		_, _ = fmt.Fprintf(w, "[%-4s] %-16s %s\n", res.Status, res.Name, res.Detail)
+	}
+	_, _ = fmt.Fprintf(w, "\n%d ok, %d warnings, %d failures, %d skipped\n",
+		r.count(doctorOK), r.count(doctorWarn), r.count(doctorFail), r.count(doctorSkip))
+}
+
+// runDoctor validates the configuration and environment and prints a report.
+// It exits non-zero if any check fails.
+func runDoctor() {
+	fmt.Printf("snipo doctor %s (commit: %s)\n\n", Version, Commit)
+
+	report := &doctorReport{}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg, err := config.Load()
+	if err != nil {
+		report.add("config", doctorFail, "%v", err)
+		report.print(os.Stdout)
+		os.Exit(1)
+	}
+	report.add("config", doctorOK, "loaded (listen %s)", cfg.Server.Addr())
+
+	checkDoctorAuth(report, cfg)
+	checkDoctorDatabase(ctx, report, cfg)
+	checkDoctorS3(ctx, report, cfg)
+
+	report.print(os.Stdout)
+	if report.count(doctorFail) > 0 {
+		os.Exit(1)
+	}
+}
+
+func checkDoctorAuth(report *doctorReport, cfg *config.Config) {
+	if cfg.Auth.Disabled {
+		report.add("authentication", doctorWarn, "disabled (SNIPO_DISABLE_AUTH=true); only safe behind a trusted auth proxy")
+	} else if cfg.Auth.MasterPasswordHash != "" {
+		report.add("authentication", doctorOK, "using pre-hashed master password")
+	} else {
+		report.add("authentication", doctorWarn, "using plain text master password; consider SNIPO_MASTER_PASSWORD_HASH")
+	}
+
+	if cfg.Auth.SessionSecretGenerated {
+		report.add("session secret", doctorWarn, "SNIPO_SESSION_SECRET not set; sessions will not survive restarts")
+		return
+	}
+
+	if n := len(cfg.Auth.PreviousSessionSecrets); n > 0 {
+		report.add("session secret rotation", doctorWarn, "%d previous secret(s) still accepted; drop them once GET /api/v1/auth/sessions shows no sessions left under them", n)
+	}
+
+	bits := config.SecretEntropyBits(cfg.Auth.SessionSecret)
+	if bits < minSessionSecretBits {
+		report.add("session secret", doctorFail, "weak secret (~%.0f bits, want >= %d); generate with: openssl rand -hex 32", bits, minSessionSecretBits)
+		return
+	}
+	report.add("session secret", doctorOK, "~%.0f bits of entropy", bits)
+}
+
+func checkDoctorDatabase(ctx context.Context, report *doctorReport, cfg *config.Config) {
+	// Avoid creating a fresh database as a side effect of running the doctor
+	if _, err := os.Stat(cfg.Database.Path); errors.Is(err, os.ErrNotExist) {
+		report.add("database", doctorWarn, "%s does not exist yet; it will be created on first start", cfg.Database.Path)
+		report.add("schema", doctorSkip, "no database")
+		report.add("integrity", doctorSkip, "no database")
+		report.add("search index", doctorSkip, "no database")
+		return
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db, err := database.New(database.Config{
+		Path:            cfg.Database.Path,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+		JournalMode:     cfg.Database.JournalMode,
+		SynchronousMode: cfg.Database.SynchronousMode,
+	}, logger)
+	if err != nil {
+		report.add("database", doctorFail, "%v", err)
+		return
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	report.add("database", doctorOK, "opened %s", cfg.Database.Path)
+
+	current, err := db.SchemaVersion(ctx)
+	latest := database.LatestVersion()
+	switch {
+	case err != nil:
+		report.add("schema", doctorFail, "%v", err)
+	case current < latest:
+		report.add("schema", doctorWarn, "version %d, %d pending migration(s) will run on start", current, latest-current)
+	case current > latest:
+		report.add("schema", doctorFail, "version %d is newer than this binary supports (%d); upgrade snipo", current, latest)
+	default:
+		report.add("schema", doctorOK, "version %d (up to date)", current)
+	}
+
+	problems, err := db.IntegrityCheck(ctx)
+	switch {
+	case err != nil:
+		report.add("integrity", doctorFail, "%v", err)
+	case len(problems) > 0:
+		report.add("integrity", doctorFail, "%d problem(s), first: %s", len(problems), problems[0])
+	default:
+		report.add("integrity", doctorOK, "sqlite integrity_check passed")
+	}
+
+	if current == 0 {
+		report.add("search index", doctorSkip, "schema not initialized")
+		return
+	}
+	if err := db.CheckFTSIntegrity(ctx); err != nil {
+		report.add("search index", doctorFail, "%v", err)
+		return
+	}
+	report.add("search index", doctorOK, "full-text index integrity check passed")
+}
+
+func checkDoctorS3(ctx context.Context, report *doctorReport, cfg *config.Config) {
+	if !cfg.S3.Enabled {
+		report.add("s3", doctorSkip, "not enabled")
+		return
+	}
+
+	s3Storage, err := storage.New(ctx, cfg.S3)
+	if err != nil {
+		report.add("s3", doctorFail, "%v", err)
+		return
+	}
+
+	// Round-trip a small test object to prove credentials can write, read and delete
+	key := fmt.Sprintf("doctor/snipo-doctor-%d.txt", time.Now().UnixNano())
+	payload := []byte("snipo doctor test object\n")
+
+	if err := s3Storage.Upload(ctx, key, payload, "text/plain"); err != nil {
+		report.add("s3", doctorFail, "upload to bucket %q failed: %v", s3Storage.GetBucket(), err)
+		return
+	}
+
+	got, err := s3Storage.Download(ctx, key)
+	if err != nil {
+		report.add("s3", doctorFail, "download of test object failed: %v", err)
+		return
+	}
+
+	if err := s3Storage.Delete(ctx, key); err != nil {
+		report.add("s3", doctorWarn, "test object %s could not be deleted: %v", key, err)
+		return
+	}
+
+	if !bytes.Equal(got, payload) {
+		report.add("s3", doctorFail, "test object content mismatch")
+		return
+	}
+	report.add("s3", doctorOK, "%s bucket %q is writable, readable and deletable", s3Storage.Provider(), s3Storage.GetBucket())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/sitegen"
+	"github.com/MohamedElashri/snipo/internal/web"
+)
+
+// runExportSite renders all public snippets into a static HTML site
+func runExportSite() {
+	fs := flag.NewFlagSet("export-site", flag.ExitOnError)
+	title := fs.String("title", "Snipo", "site title")
+	clean := fs.Bool("clean", false, "remove previously generated pages from the directory first")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: snipo export-site [--title T] [--clean] <dir>")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:])
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	dir := fs.Arg(0)
+
+	logger := setupLogger()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.New(database.Config{
+		Path:            cfg.Database.Path,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+		JournalMode:     cfg.Database.JournalMode,
+		SynchronousMode: cfg.Database.SynchronousMode,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Error("failed to close database", "error", err)
+		}
+	}()
+
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		logger.Error("failed to run migrations", "error", err)
+		os.Exit(1)
+	}
+
+	if *clean {
+		// Only remove what export-site generates so hand-added files (e.g. CNAME) survive
+		for _, name := range []string{"snippets", "tags", "assets", "index.html", "search.json"} {
+			if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+				logger.Error("failed to clean output directory", "path", name, "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	snippetService := services.NewSnippetService(repository.NewSnippetRepository(db.DB), logger).
+		WithTagRepo(repository.NewTagRepository(db.DB)).
+		WithFileRepo(repository.NewSnippetFileRepository(db.DB)).
+		WithSettingsRepo(repository.NewSettingsRepository(db.DB))
+
+	siteService := services.NewSiteExportService(snippetService, logger)
+	if assets, err := web.SiteAssets(); err != nil {
+		logger.Warn("syntax highlighting assets unavailable", "error", err)
+	} else {
+		siteService.WithAssets(assets)
+	}
+
+	start := time.Now()
+	result, err := siteService.Export(ctx, sitegen.DirWriter(dir), models.SiteExportOptions{Title: *title})
+	if err != nil {
+		logger.Error("failed to export site", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Exported %d public snippets and %d tags to %s in %s\n",
+		result.Snippets, result.Tags, dir, time.Since(start).Round(time.Millisecond))
+}
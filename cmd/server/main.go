@@ -13,8 +13,14 @@ import (
 	"github.com/MohamedElashri/snipo/internal/api"
 	"github.com/MohamedElashri/snipo/internal/api/middleware"
 	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/captcha"
+	"github.com/MohamedElashri/snipo/internal/cluster"
 	"github.com/MohamedElashri/snipo/internal/config"
 	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/logging"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
 )
 
 // Build-time variables
@@ -23,6 +29,9 @@ var (
 	Commit  = "unknown"
 )
 
+// bootstrapTokenName names the API token provisioned from SNIPO_BOOTSTRAP_TOKEN
+const bootstrapTokenName = "SNIPO_BOOTSTRAP_TOKEN"
+
 func main() {
 	// Check for subcommands
 	if len(os.Args) > 1 {
@@ -38,9 +47,15 @@ func main() {
 			checkHealth()
 		case "hash-password":
 			hashPassword()
+		case "doctor":
+			runDoctor()
+		case "seed":
+			runSeed()
+		case "export-site":
+			runExportSite()
 		default:
 			fmt.Printf("Unknown command: %s\n", os.Args[1])
-			fmt.Println("Available commands: serve, migrate, version, health, hash-password")
+			fmt.Println("Available commands: serve, migrate, version, health, hash-password, doctor, seed, export-site")
 			os.Exit(1)
 		}
 	} else {
@@ -63,6 +78,12 @@ func runServer() {
 
 	// Configure proxy trust setting
 	middleware.TrustProxy = cfg.Server.TrustProxy
+	middleware.TrustedProxies = cfg.Server.TrustedProxies
+
+	// Let the captcha provider's widget through the web UI's CSP
+	if cfg.Captcha.Enabled() {
+		middleware.CaptchaSources = captcha.Sources(cfg.Captcha.Provider)
+	}
 
 	// Security warnings
 	if cfg.Auth.Disabled {
@@ -75,17 +96,27 @@ func runServer() {
 			"use_case_3", "For development/testing purposes only")
 		logger.Warn("⚠️  NEVER expose this configuration directly to the internet ⚠️")
 	} else if cfg.Auth.SessionSecretGenerated {
-		logger.Warn("SECURITY WARNING: SNIPO_SESSION_SECRET not set - using auto-generated secret",
+		logger.Warn("SECURITY WARNING: SNIPO_SESSION_SECRET not set - using auto-generated secret, sessions end on restart",
 			"recommendation", "Set SNIPO_SESSION_SECRET environment variable for production. Generate with: openssl rand -hex 32")
 	}
 
+	// A static replica opens the database read-only; in LiteFS mode the
+	// role can change at runtime and LiteFS itself rejects replica writes
+	node := cluster.New(cfg.Cluster, cfg.Database.Path, cfg.Server.Port)
+	if node.Role() != cluster.RoleStandalone {
+		logger.Info("multi-instance mode", "role", node.Role(), "mode", cfg.Cluster.Role, "primary", node.PrimaryURL(), "replica_writes", cfg.Cluster.ReplicaWrites)
+	}
+
 	// Connect to database
 	db, err := database.New(database.Config{
 		Path:            cfg.Database.Path,
 		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
 		BusyTimeout:     cfg.Database.BusyTimeout,
 		JournalMode:     cfg.Database.JournalMode,
 		SynchronousMode: cfg.Database.SynchronousMode,
+		ReadOnly:        cfg.Cluster.Role == "replica",
 	}, logger)
 	if err != nil {
 		logger.Error("failed to connect to database", "error", err)
@@ -97,13 +128,41 @@ func runServer() {
 		}
 	}()
 
-	// Run migrations
+	// Run migrations; replicas receive the schema from the primary
 	ctx := context.Background()
-	if err := db.Migrate(ctx); err != nil {
+	if node.IsReplica() {
+		logger.Info("skipping migrations on replica")
+	} else if err := db.Migrate(ctx); err != nil {
 		logger.Error("failed to run migrations", "error", err)
 		os.Exit(1)
 	}
 
+	// Provision the bootstrap admin token, so automation has API access
+	// before anyone logs in. Only its hash is stored; the value is never
+	// logged. Unsetting the variable deletes the token.
+	if !node.IsReplica() {
+		tokenRepo := repository.NewTokenRepository(db.DB)
+		if cfg.Auth.BootstrapToken != "" {
+			changed, err := tokenRepo.EnsureToken(ctx, bootstrapTokenName, cfg.Auth.BootstrapToken, models.PermissionAdmin)
+			if err != nil {
+				logger.Error("failed to provision bootstrap token", "error", err)
+				os.Exit(1)
+			}
+			if changed {
+				logger.Info("bootstrap API token provisioned", "name", bootstrapTokenName)
+			}
+		} else {
+			deleted, err := tokenRepo.DeleteProvisioned(ctx)
+			if err != nil {
+				logger.Error("failed to delete bootstrap token", "error", err)
+				os.Exit(1)
+			}
+			if deleted {
+				logger.Info("bootstrap API token deleted, SNIPO_BOOTSTRAP_TOKEN is no longer set")
+			}
+		}
+	}
+
 	// Create auth service
 	// Use pre-hashed password if available, otherwise use plain password
 	masterPasswordForAuth := cfg.Auth.MasterPasswordHash
@@ -118,17 +177,20 @@ func runServer() {
 		cfg.Auth.SessionDuration,
 		logger,
 		cfg.Auth.Disabled,
-	)
+	).WithPreviousSessionSecrets(cfg.Auth.PreviousSessionSecrets...)
+	if len(cfg.Auth.PreviousSessionSecrets) > 0 {
+		logger.Info("session secret rotation in progress", "previous_secrets", len(cfg.Auth.PreviousSessionSecrets))
+	}
 
-	// Start session cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
-		for range ticker.C {
-			if err := authService.CleanupExpiredSessions(); err != nil {
-				logger.Warn("failed to cleanup sessions", "error", err)
-			}
-		}
-	}()
+	// Background workers are owned by the lifecycle manager so they can be
+	// cancelled and drained before the database is closed. Periodic work
+	// only runs while this instance may write.
+	lc := lifecycle.New(logger).WithWriterCheck(node.IsWriter)
+
+	// Start session cleanup worker
+	_ = lc.Every("session-cleanup", 1*time.Hour, func(ctx context.Context) error {
+		return authService.CleanupExpiredSessions(ctx)
+	})
 
 	// Create router
 	router := api.NewRouter(api.RouterConfig{
@@ -141,7 +203,10 @@ func runServer() {
 		RateLimit:          cfg.Auth.RateLimit,
 		RateLimitWindow:    int(cfg.Auth.RateLimitWindow.Seconds()),
 		MaxFilesPerSnippet: cfg.Server.MaxFilesPerSnippet,
+		MaxPinnedSnippets:  cfg.Server.MaxPinnedSnippets,
 		S3Config:           &cfg.S3,
+		Lifecycle:          lc,
+		Cluster:            node,
 	})
 
 	// Create server
@@ -151,6 +216,7 @@ func runServer() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  120 * time.Second,
+		ConnState:    lc.TrackConn,
 	}
 
 	// Start server in goroutine
@@ -167,14 +233,19 @@ func runServer() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("shutting down server...")
+	logger.Info("shutting down server...", "active_connections", lc.ActiveConnections())
 
-	// Graceful shutdown with timeout
+	// Graceful shutdown with timeout: drain in-flight requests first, then
+	// stop background workers, then let the deferred DB close run
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("server forced to shutdown", "error", err)
+		logger.Error("server forced to shutdown", "error", err, "active_connections", lc.ActiveConnections())
+	}
+
+	if err := lc.Shutdown(ctx); err != nil {
+		logger.Error("background workers did not stop cleanly", "error", err)
 	}
 
 	logger.Info("server stopped")
@@ -192,6 +263,8 @@ func runMigrations() {
 	db, err := database.New(database.Config{
 		Path:            cfg.Database.Path,
 		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
 		BusyTimeout:     cfg.Database.BusyTimeout,
 		JournalMode:     cfg.Database.JournalMode,
 		SynchronousMode: cfg.Database.SynchronousMode,
@@ -264,30 +337,18 @@ func hashPassword() {
 	fmt.Println("\nNote: Remove SNIPO_MASTER_PASSWORD if you're using SNIPO_MASTER_PASSWORD_HASH")
 }
 
+// setupLogger logs to stdout, SNIPO_LOG_FILE and SNIPO_LOG_SYSLOG as
+// configured. Outputs that fail to open are reported, and logging falls
+// back to stdout rather than stopping the server.
 func setupLogger() *slog.Logger {
-	logLevel := os.Getenv("SNIPO_LOG_LEVEL")
-	logFormat := os.Getenv("SNIPO_LOG_FORMAT")
-
-	var level slog.Level
-	switch logLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
-
-	opts := &slog.HandlerOptions{Level: level}
-
-	var handler slog.Handler
-	if logFormat == "text" {
-		handler = slog.NewTextHandler(os.Stdout, opts)
-	} else {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+	cfg := config.LoadLogging()
+	handler, _, err := logging.NewHandler(cfg)
+	if err != nil {
+		handler, _, _ = logging.NewHandler(config.LoggingConfig{Level: cfg.Level, Format: cfg.Format, Stdout: true})
+		logger := slog.New(middleware.NewContextHandler(handler))
+		logger.Error("failed to set up log outputs, logging to stdout only", "error", err)
+		return logger
 	}
 
-	return slog.New(handler)
+	return slog.New(middleware.NewContextHandler(handler))
 }
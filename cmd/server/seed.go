@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// seedLanguage describes how to generate content for one language
+type seedLanguage struct {
+	name      string
+	extension string
+	body      func(r *rand.Rand, noun string) string
+}
+
+var seedLanguages = []seedLanguage{
+	{"go", "go", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("package main\n\nimport \"fmt\"\n\n// %s handles %s\nfunc %s(items []string) int {\n\tcount := 0\n\tfor _, item := range items {\n\t\tif item != \"\" {\n\t\t\tcount++\n\t\t}\n\t}\n\tfmt.Println(\"processed\", count)\n\treturn count\n}\n",
+			seedTitleCase(noun), noun, seedTitleCase(noun))
+	}},
+	{"python", "py", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("def process_%s(items):\n    \"\"\"Process %s items.\"\"\"\n    result = []\n    for item in items:\n        if item:\n            result.append(item.strip())\n    return result\n\n\nif __name__ == \"__main__\":\n    print(process_%s([\"a\", \"b\", \"%d\"]))\n",
+			noun, noun, noun, r.Intn(1000))
+	}},
+	{"javascript", "js", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("export async function fetch%s(id) {\n  const res = await fetch(`/api/%s/${id}`);\n  if (!res.ok) {\n    throw new Error(`request failed: ${res.status}`);\n  }\n  return res.json();\n}\n\nconst retries = %d;\n",
+			seedTitleCase(noun), noun, r.Intn(5)+1)
+	}},
+	{"typescript", "ts", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("export interface %s {\n  id: string;\n  name: string;\n  createdAt: Date;\n}\n\nexport function is%s(value: unknown): value is %s {\n  return typeof value === \"object\" && value !== null && \"id\" in value;\n}\n",
+			seedTitleCase(noun), seedTitleCase(noun), seedTitleCase(noun))
+	}},
+	{"rust", "rs", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("#[derive(Debug, Clone)]\npub struct %s {\n    pub id: u64,\n    pub name: String,\n}\n\nimpl %s {\n    pub fn new(id: u64, name: &str) -> Self {\n        Self { id, name: name.to_string() }\n    }\n}\n",
+			seedTitleCase(noun), seedTitleCase(noun))
+	}},
+	{"sql", "sql", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("SELECT id, name, created_at\nFROM %ss\nWHERE created_at > datetime('now', '-%d days')\nORDER BY created_at DESC\nLIMIT %d;\n",
+			noun, r.Intn(30)+1, (r.Intn(10)+1)*10)
+	}},
+	{"bash", "sh", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("#!/usr/bin/env bash\nset -euo pipefail\n\n# Rotate %s logs older than %d days\nfind /var/log/%s -name '*.log' -mtime +%d -delete\necho \"done\"\n",
+			noun, r.Intn(14)+1, noun, r.Intn(14)+1)
+	}},
+	{"yaml", "yaml", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("name: %s\nreplicas: %d\nimage: ghcr.io/example/%s:latest\nresources:\n  limits:\n    memory: %dMi\n",
+			noun, r.Intn(5)+1, noun, (r.Intn(8)+1)*128)
+	}},
+	{"dockerfile", "Dockerfile", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("FROM alpine:3.%d\nWORKDIR /app\nCOPY . .\nRUN apk add --no-cache ca-certificates\nENTRYPOINT [\"/app/%s\"]\n",
+			r.Intn(5)+16, noun)
+	}},
+	{"markdown", "md", func(r *rand.Rand, noun string) string {
+		return fmt.Sprintf("# %s notes\n\n- Step one: configure %s\n- Step two: verify output\n\n```bash\nmake %s\n```\n",
+			seedTitleCase(noun), noun, noun)
+	}},
+}
+
+var (
+	seedVerbs = []string{"Parse", "Validate", "Cache", "Retry", "Stream", "Format", "Encode", "Sync", "Paginate", "Debounce", "Migrate", "Deploy"}
+	seedNouns = []string{"user", "config", "request", "token", "invoice", "event", "session", "report", "webhook", "upload", "queue", "metric"}
+	seedTags  = []string{"backend", "frontend", "devops", "database", "snippet", "util", "api", "testing", "security", "performance", "cli", "docs", "k8s", "aws", "linux", "regex", "async", "http", "json", "auth"}
+
+	seedFolderTree = map[string][]string{
+		"Work":      {"API", "Infrastructure", "Scripts"},
+		"Personal":  {"Dotfiles", "Experiments"},
+		"Reference": {"SQL", "Regex", "Git"},
+		"Archive":   nil,
+	}
+)
+
+// seedOptions controls fixture generation
+type seedOptions struct {
+	count int
+	seed  int64
+	wipe  bool
+}
+
+// runSeed populates the database with generated fixtures for development and testing
+func runSeed() {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := fs.Int("count", 100, "number of snippets to generate")
+	seed := fs.Int64("seed", 1, "random seed for reproducible content")
+	wipe := fs.Bool("wipe", false, "delete existing snippets, tags and folders, with everything tied to them, first")
+	_ = fs.Parse(os.Args[2:])
+
+	if *count < 0 {
+		fmt.Println("Error: --count must not be negative")
+		os.Exit(1)
+	}
+
+	logger := setupLogger()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.New(database.Config{
+		Path:            cfg.Database.Path,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+		JournalMode:     cfg.Database.JournalMode,
+		SynchronousMode: cfg.Database.SynchronousMode,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Error("failed to close database", "error", err)
+		}
+	}()
+
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		logger.Error("failed to run migrations", "error", err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	created, err := seedDatabase(ctx, db.DB, seedOptions{count: *count, seed: *seed, wipe: *wipe})
+	if err != nil {
+		logger.Error("failed to seed database", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("seed completed", "snippets", created, "seed", *seed, "duration", time.Since(start).String())
+}
+
+// seedDatabase generates opts.count snippets with tags, folders, files and history.
+// Content is fully determined by opts.seed; IDs and timestamps are not.
+func seedDatabase(ctx context.Context, db *sql.DB, opts seedOptions) (int, error) {
+	r := rand.New(rand.NewSource(opts.seed))
+
+	// The service logs every create; keep seed output to a summary
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	snippetRepo := repository.NewSnippetRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	folderRepo := repository.NewFolderRepository(db)
+	fileRepo := repository.NewSnippetFileRepository(db)
+	historyRepo := repository.NewHistoryRepository(db)
+
+	snippetService := services.NewSnippetService(snippetRepo, quiet).
+		WithTagRepo(tagRepo).
+		WithFolderRepo(folderRepo).
+		WithFileRepo(fileRepo)
+
+	if opts.wipe {
+		if err := database.ClearLibrary(ctx, db); err != nil {
+			return 0, fmt.Errorf("failed to wipe data: %w", err)
+		}
+	}
+
+	folderIDs, err := seedFolders(ctx, folderRepo)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < opts.count; i++ {
+		input := generateSeedSnippet(r, folderIDs)
+
+		snippet, err := snippetService.Create(ctx, input)
+		if err != nil {
+			return i, fmt.Errorf("failed to create snippet %d: %w", i+1, err)
+		}
+
+		if r.Intn(10) == 0 {
+			if _, err := snippetRepo.ToggleFavorite(ctx, snippet.ID); err != nil {
+				return i, fmt.Errorf("failed to favorite snippet: %w", err)
+			}
+		}
+
+		// Simulate a few edits so history views have data
+		revisions := r.Intn(4)
+		for rev := 0; rev < revisions; rev++ {
+			if _, err := historyRepo.CreateHistory(ctx, snippet, "update", "seed", ""); err != nil {
+				return i, fmt.Errorf("failed to create history: %w", err)
+			}
+			input.Content += fmt.Sprintf("\n// revision %d\n", rev+1)
+			input.Files = nil
+			updated, err := snippetRepo.Update(ctx, snippet.ID, input)
+			if err != nil {
+				return i, fmt.Errorf("failed to update snippet: %w", err)
+			}
+			snippet = updated
+		}
+
+		if (i+1)%500 == 0 {
+			fmt.Printf("seeded %d/%d snippets\n", i+1, opts.count)
+		}
+	}
+
+	return opts.count, nil
+}
+
+func seedFolders(ctx context.Context, folderRepo *repository.FolderRepository) ([]int64, error) {
+	// Reuse folders left by a previous seed run instead of duplicating them
+	existing, err := folderRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	byKey := make(map[string]int64)
+	for _, f := range existing {
+		var parent int64
+		if f.ParentID != nil {
+			parent = *f.ParentID
+		}
+		byKey[fmt.Sprintf("%d/%s", parent, f.Name)] = f.ID
+	}
+
+	create := func(name string, parentID *int64, sortOrder int) (int64, error) {
+		var parent int64
+		if parentID != nil {
+			parent = *parentID
+		}
+		if id, ok := byKey[fmt.Sprintf("%d/%s", parent, name)]; ok {
+			return id, nil
+		}
+		folder, err := folderRepo.Create(ctx, &models.FolderInput{Name: name, ParentID: parentID, SortOrder: sortOrder})
+		if err != nil {
+			return 0, fmt.Errorf("failed to create folder %s: %w", name, err)
+		}
+		return folder.ID, nil
+	}
+
+	// Iterate in a fixed order so folder assignment is reproducible
+	roots := []string{"Work", "Personal", "Reference", "Archive"}
+
+	var ids []int64
+	for i, name := range roots {
+		parentID, err := create(name, nil, i)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, parentID)
+
+		for j, child := range seedFolderTree[name] {
+			id, err := create(child, &parentID, j)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func generateSeedSnippet(r *rand.Rand, folderIDs []int64) *models.SnippetInput {
+	lang := seedLanguages[r.Intn(len(seedLanguages))]
+	noun := seedNouns[r.Intn(len(seedNouns))]
+	verb := seedVerbs[r.Intn(len(seedVerbs))]
+
+	input := &models.SnippetInput{
+		Title:       fmt.Sprintf("%s %s (%s)", verb, noun, lang.name),
+		Description: fmt.Sprintf("%s %s data using %s. Generated fixture.", verb, noun, lang.name),
+		Content:     lang.body(r, noun),
+		Language:    lang.name,
+		IsPublic:    r.Intn(5) == 0,
+		IsArchived:  r.Intn(20) == 0,
+	}
+
+	// Roughly a quarter of snippets get additional files
+	if r.Intn(4) == 0 {
+		input.Files = []models.SnippetFileInput{{
+			Filename: seedFilename(noun, lang),
+			Content:  input.Content,
+			Language: lang.name,
+		}}
+		extra := r.Intn(3) + 1
+		for k := 0; k < extra; k++ {
+			other := seedLanguages[r.Intn(len(seedLanguages))]
+			input.Files = append(input.Files, models.SnippetFileInput{
+				Filename: fmt.Sprintf("%d_%s", k+1, seedFilename(noun, other)),
+				Content:  other.body(r, noun),
+				Language: other.name,
+			})
+		}
+	}
+
+	tagCount := r.Intn(5)
+	seen := make(map[string]bool)
+	for k := 0; k < tagCount; k++ {
+		tag := seedTags[r.Intn(len(seedTags))]
+		if !seen[tag] {
+			seen[tag] = true
+			input.Tags = append(input.Tags, tag)
+		}
+	}
+
+	if len(folderIDs) > 0 && r.Intn(10) < 7 {
+		id := folderIDs[r.Intn(len(folderIDs))]
+		input.FolderID = &id
+	}
+
+	return input
+}
+
+func seedFilename(noun string, lang seedLanguage) string {
+	if lang.extension == "Dockerfile" {
+		return "Dockerfile"
+	}
+	return noun + "." + lang.extension
+}
+
+func seedTitleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
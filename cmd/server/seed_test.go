@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestSeedDatabase(t *testing.T) {
+	ctx := testutil.TestContext()
+	db := testutil.TestDB(t)
+	db.SetMaxOpenConns(1) // Keep the in-memory database on one connection
+
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			t.Fatalf("failed to run %q: %v", query, err)
+		}
+		return n
+	}
+
+	created, err := seedDatabase(ctx, db, seedOptions{count: 20, seed: 7})
+	if err != nil || created != 20 {
+		t.Fatalf("expected 20 snippets seeded, got %d (%v)", created, err)
+	}
+	if n := count("SELECT COUNT(*) FROM snippets"); n != 20 {
+		t.Errorf("expected 20 snippets, got %d", n)
+	}
+	if count("SELECT COUNT(*) FROM folders") == 0 || count("SELECT COUNT(*) FROM tags") == 0 || count("SELECT COUNT(*) FROM snippet_files") == 0 {
+		t.Error("expected folders, tags and files seeded")
+	}
+
+	// The same seed generates the same content
+	again := testutil.TestDB(t)
+	if _, err := seedDatabase(ctx, again, seedOptions{count: 20, seed: 7}); err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+	var want, got string
+	_ = db.QueryRowContext(ctx, "SELECT group_concat(title || content, '|') FROM (SELECT title, content FROM snippets ORDER BY title, content)").Scan(&want)
+	_ = again.QueryRowContext(ctx, "SELECT group_concat(title || content, '|') FROM (SELECT title, content FROM snippets ORDER BY title, content)").Scan(&got)
+	if want == "" || want != got {
+		t.Error("expected the same content for the same seed")
+	}
+
+	// Wiping clears everything tied to the old snippets, search included,
+	// without relying on foreign keys to cascade
+	if _, err := db.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("failed to disable foreign keys: %v", err)
+	}
+	var snippetID string
+	_ = db.QueryRowContext(ctx, "SELECT id FROM snippets LIMIT 1").Scan(&snippetID)
+	for _, q := range []string{
+		"INSERT INTO tag_aliases (alias, tag_id) SELECT 'seed-alias', MIN(id) FROM tags",
+		"INSERT INTO snippet_links (source_id, target_id, relation) VALUES ('" + snippetID + "', '" + snippetID + "', 'related')",
+		"INSERT INTO share_views (snippet_id, day, client) VALUES ('" + snippetID + "', '2026-01-01', 'browser')",
+		"INSERT INTO snippet_usage (snippet_id, event) VALUES ('" + snippetID + "', 'view')",
+	} {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			t.Fatalf("failed to insert %q: %v", q, err)
+		}
+	}
+	if _, err := seedDatabase(ctx, db, seedOptions{count: 5, seed: 8, wipe: true}); err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+	if n := count("SELECT COUNT(*) FROM snippets"); n != 5 {
+		t.Errorf("expected only the 5 new snippets, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM snippets_fts_docsize"); n != 5 {
+		t.Errorf("expected only the new snippets indexed, got %d", n)
+	}
+	for _, table := range []string{"tag_aliases", "snippet_links", "share_views", "snippet_usage"} {
+		if n := count("SELECT COUNT(*) FROM " + table); n != 0 {
+			t.Errorf("expected %s cleared, got %d rows", table, n)
+		}
+	}
+	if n := count("SELECT COUNT(*) FROM snippet_history WHERE snippet_id NOT IN (SELECT id FROM snippets)"); n != 0 {
+		t.Errorf("expected no history of wiped snippets, got %d rows", n)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// folderAccess returns the folders the request's API token is limited to,
+// or nil when the request may reach every folder
+func folderAccess(r *http.Request) models.FolderAccess {
+	if token := middleware.GetTokenFromContext(r.Context()); token != nil {
+		return token.FolderAccess
+	}
+	return nil
+}
+
+// snippetFolderIDs returns the IDs of the folders holding a snippet
+func snippetFolderIDs(snippet *models.Snippet) []int64 {
+	ids := make([]int64, len(snippet.Folders))
+	for i, folder := range snippet.Folders {
+		ids[i] = folder.ID
+	}
+	return ids
+}
+
+// allowSnippet checks that a token limited to folders holds role on one of
+// the snippet's folders, writing the error response when it does not.
+// Snippets the token cannot view are reported as missing.
+func (h *SnippetHandler) allowSnippet(w http.ResponseWriter, r *http.Request, id, role string) bool {
+	access := folderAccess(r)
+	if access == nil {
+		return true
+	}
+
+	snippet, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return false
+		}
+		InternalError(w, r)
+		return false
+	}
+
+	folderIDs := snippetFolderIDs(snippet)
+	if !access.Allows(folderIDs, models.RoleViewer) {
+		NotFound(w, r, "Snippet not found")
+		return false
+	}
+	if !access.Allows(folderIDs, role) {
+		Error(w, r, http.StatusForbidden, "INSUFFICIENT_PERMISSIONS", "This token can only view snippets in this folder")
+		return false
+	}
+	return true
+}
+
+// allowTargetFolder checks that a token limited to folders saves a snippet
+// into a folder it edits, writing the error response when it does not
+func allowTargetFolder(w http.ResponseWriter, r *http.Request, input *models.SnippetInput) bool {
+	access := folderAccess(r)
+	if access == nil {
+		return true
+	}
+	if input.FolderID == nil || !access.Allows([]int64{*input.FolderID}, models.RoleEditor) {
+		Error(w, r, http.StatusForbidden, "INSUFFICIENT_PERMISSIONS", "This token can only save snippets into folders it edits")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// AdminHandler handles maintenance endpoints
+type AdminHandler struct {
+	integrity contracts.IntegrityService
+	version   string
+	commit    string
+	startTime time.Time
+	updates   contracts.UpdateChecker
+	usage     contracts.APIUsage
+	telemetry contracts.Telemetry
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(integrity contracts.IntegrityService) *AdminHandler {
+	return &AdminHandler{integrity: integrity, startTime: time.Now()}
+}
+
+// WithBuild sets the version and commit reported by System
+func (h *AdminHandler) WithBuild(version, commit string) *AdminHandler {
+	h.version = version
+	h.commit = commit
+	return h
+}
+
+// WithUpdates reports the update checker's latest result in System
+func (h *AdminHandler) WithUpdates(updates contracts.UpdateChecker) *AdminHandler {
+	h.updates = updates
+	return h
+}
+
+// WithUsage enables the API usage report
+func (h *AdminHandler) WithUsage(usage contracts.APIUsage) *AdminHandler {
+	h.usage = usage
+	return h
+}
+
+// WithTelemetry enables the telemetry preview
+func (h *AdminHandler) WithTelemetry(telemetry contracts.Telemetry) *AdminHandler {
+	h.telemetry = telemetry
+	return h
+}
+
+// Verify handles GET /api/v1/admin/verify
+// Re-hashes every snippet and reports checksum mismatches. With repair=true,
+// snippets that have no checksum yet get one.
+func (h *AdminHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	repair := r.URL.Query().Get("repair")
+	report, err := h.integrity.Verify(r.Context(), repair == "true" || repair == "1")
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, report)
+}
+
+// SystemInfo describes the running instance
+type SystemInfo struct {
+	Version       string               `json:"version"`
+	Commit        string               `json:"commit,omitempty"`
+	GoVersion     string               `json:"go_version"`
+	Platform      string               `json:"platform"`
+	StartedAt     time.Time            `json:"started_at"`
+	Uptime        string               `json:"uptime"`
+	SchemaVersion int                  `json:"schema_version"`
+	UpdateCheck   bool                 `json:"update_check"` // Whether the update checker is enabled
+	Update        *models.UpdateStatus `json:"update,omitempty"`
+}
+
+// System handles GET /api/v1/admin/system
+// Reports the build, runtime and, when the update checker is enabled,
+// whether a newer release is available.
+func (h *AdminHandler) System(w http.ResponseWriter, r *http.Request) {
+	info := SystemInfo{
+		Version:       h.version,
+		Commit:        h.commit,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		StartedAt:     h.startTime.UTC(),
+		Uptime:        time.Since(h.startTime).Round(time.Second).String(),
+		SchemaVersion: database.LatestVersion(),
+		UpdateCheck:   h.updates != nil,
+	}
+	if h.updates != nil {
+		info.Update = h.updates.Status()
+	}
+	OK(w, r, info)
+}
+
+// Telemetry handles GET /api/v1/admin/telemetry
+// Shows the anonymous report exactly as it would be sent, and whether
+// telemetry is enabled. Viewing it never sends anything.
+func (h *AdminHandler) Telemetry(w http.ResponseWriter, r *http.Request) {
+	if h.telemetry == nil {
+		NotFound(w, r, "Telemetry is not available")
+		return
+	}
+
+	status, err := h.telemetry.Preview(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, status)
+}
+
+// Usage handles GET /api/v1/admin/usage
+// Query params: from and to (YYYY-MM-DD, UTC; the last 30 days by default),
+// token_id (0 for browser sessions) and format (json or csv). JSON gives
+// totals per day, token and route; CSV gives the daily counts.
+func (h *AdminHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		NotFound(w, r, "API usage reporting is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Format must be json or csv")
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_DATE", "Dates must be formatted as YYYY-MM-DD")
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, 1-models.DefaultAPIUsageDays)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_DATE", "Dates must be formatted as YYYY-MM-DD")
+			return
+		}
+		from = t
+	}
+	if from.After(to) || to.Sub(from) >= models.MaxAPIUsageDays*24*time.Hour {
+		Error(w, r, http.StatusBadRequest, "INVALID_RANGE", "The period must not end before it starts and must span at most 365 days")
+		return
+	}
+
+	filter := models.APIUsageFilter{From: from.Format(time.DateOnly), To: to.Format(time.DateOnly)}
+	if v := query.Get("token_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid token ID")
+			return
+		}
+		filter.TokenID = &id
+	}
+
+	if format == "csv" {
+		entries, err := h.usage.Entries(r.Context(), filter)
+		if err != nil {
+			InternalError(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"snipo-api-usage-"+filter.From+"-"+filter.To+".csv\"")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"day", "token_id", "token_name", "method", "route", "requests", "errors", "rate_limited"})
+		for _, e := range entries {
+			_ = cw.Write([]string{
+				e.Day,
+				strconv.FormatInt(e.TokenID, 10),
+				e.TokenName,
+				e.Method,
+				e.Route,
+				strconv.Itoa(e.Requests),
+				strconv.Itoa(e.Errors),
+				strconv.Itoa(e.RateLimited),
+			})
+		}
+		cw.Flush()
+		return
+	}
+
+	report, err := h.usage.Report(r.Context(), filter)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, report)
+}
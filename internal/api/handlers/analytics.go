@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// ShareReferrerHeader carries the share page's own referrer. The page
+// loads the snippet with fetch, whose Referer is the share page itself.
+const ShareReferrerHeader = "X-Share-Referrer"
+
+// recordShareView counts a view of a public snippet, if analytics are on
+func recordShareView(analytics contracts.ShareAnalytics, r *http.Request, snippetID string) {
+	if analytics == nil {
+		return
+	}
+	referrer := r.Header.Get(ShareReferrerHeader)
+	if referrer == "" {
+		referrer = r.Referer()
+	}
+	analytics.Record(snippetID, referrer, r.UserAgent(), middleware.ClientIP(r), r.Host)
+}
+
+// Analytics handles GET /api/v1/snippets/{id}/analytics
+// Query params: days (default 30, max 365)
+// Summarizes the public views of the snippet per day, referrer and client.
+func (h *SnippetHandler) Analytics(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if h.analytics == nil {
+		NotFound(w, r, "Share analytics are disabled")
+		return
+	}
+
+	days := models.DefaultAnalyticsDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 1 || n > models.MaxAnalyticsDays {
+			Error(w, r, http.StatusBadRequest, "INVALID_DAYS", "Days must be between 1 and 365")
+			return
+		}
+		days = n
+	}
+
+	analytics, err := h.analytics.Get(r.Context(), id, days)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, analytics)
+}
@@ -3,21 +3,39 @@ package handlers
 import (
 	"fmt"
 	"net/http"
-	"strings"
+	"strconv"
 
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
 	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/i18n"
+	"github.com/MohamedElashri/snipo/internal/models"
 )
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authService *auth.Service
+	authService contracts.Authenticator
+	audit       contracts.LoginAudit
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService *auth.Service) *AuthHandler {
+func NewAuthHandler(authService contracts.Authenticator) *AuthHandler {
 	return &AuthHandler{authService: authService}
 }
 
+// WithAudit enables recording of login attempts
+func (h *AuthHandler) WithAudit(audit contracts.LoginAudit) *AuthHandler {
+	h.audit = audit
+	return h
+}
+
+// recordLogin stores a login attempt when auditing is enabled
+func (h *AuthHandler) recordLogin(r *http.Request, success bool, ip, reason string) {
+	if h.audit != nil {
+		h.audit.Record(r.Context(), success, ip, r.UserAgent(), reason)
+	}
+}
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Password string `json:"password"`
@@ -42,19 +60,22 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get client IP for rate limiting
-	clientIP := getClientIPForAuth(r)
+	// Get client IP for rate limiting, from proxy headers only as trusted
+	// for every other rate limit
+	clientIP := middleware.ClientIP(r)
 
 	// Verify password with progressive delay enforcement
 	valid, delay := h.authService.VerifyPasswordWithDelay(req.Password, clientIP)
 	if delay > 0 {
+		h.recordLogin(r, false, clientIP, models.LoginReasonRateLimited)
 		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(delay.Seconds())+1))
 		Error(w, r, http.StatusTooManyRequests, "RATE_LIMITED",
-			fmt.Sprintf("Too many failed attempts. Please wait %d seconds.", int(delay.Seconds())+1))
+			i18n.Sprintf(i18n.FromRequest(r), "Too many failed attempts. Please wait %d seconds.", int(delay.Seconds())+1))
 		return
 	}
 
 	if !valid {
+		h.recordLogin(r, false, clientIP, models.LoginReasonInvalidPassword)
 		Error(w, r, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid password")
 		return
 	}
@@ -66,6 +87,8 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordLogin(r, true, clientIP, "")
+
 	// Set session cookie
 	h.authService.SetSessionCookie(w, token)
 
@@ -75,29 +98,6 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getClientIPForAuth extracts client IP for authentication rate limiting
-func getClientIPForAuth(r *http.Request) string {
-	// Check X-Forwarded-For header (if behind proxy)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
-		}
-	}
-
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
-	}
-	return ip
-}
-
 // Logout handles POST /api/v1/auth/logout
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	token := auth.GetSessionFromRequest(r)
@@ -124,6 +124,63 @@ func (h *AuthHandler) Check(w http.ResponseWriter, r *http.Request) {
 	OK(w, r, map[string]bool{"authenticated": true})
 }
 
+// Events handles GET /api/v1/auth/events
+func (h *AuthHandler) Events(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		NotFound(w, r, "Login auditing is not enabled")
+		return
+	}
+
+	filter := models.LoginEventFilter{Limit: 50}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > 500 {
+			Error(w, r, http.StatusBadRequest, "INVALID_LIMIT", "Limit must be between 1 and 500")
+			return
+		}
+		filter.Limit = l
+	}
+	if failed := r.URL.Query().Get("failed"); failed == "true" || failed == "1" {
+		filter.FailedOnly = true
+	}
+	filter.IPAddress = r.URL.Query().Get("ip")
+
+	events, err := h.audit.List(r.Context(), filter)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, events)
+}
+
+// Sessions handles GET /api/v1/auth/sessions
+// Counts active sessions by session secret, to follow a secret rotation.
+func (h *AuthHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.authService.SessionStats(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, stats)
+}
+
+// ReissueSessions handles POST /api/v1/auth/sessions/reissue
+// Gives every browser session a new token, under the current session
+// secret, on its next request.
+func (h *AuthHandler) ReissueSessions(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.authService.ReissueSessions(r.Context()); err != nil {
+		InternalError(w, r)
+		return
+	}
+	stats, err := h.authService.SessionStats(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, stats)
+}
+
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password"`
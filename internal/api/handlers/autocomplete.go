@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// AutocompleteHandler serves type-ahead suggestions for tags, folders and
+// languages
+type AutocompleteHandler struct {
+	autocomplete contracts.Autocomplete
+}
+
+// NewAutocompleteHandler creates a new autocomplete handler
+func NewAutocompleteHandler(autocomplete contracts.Autocomplete) *AutocompleteHandler {
+	return &AutocompleteHandler{autocomplete: autocomplete}
+}
+
+// Suggest handles GET /api/v1/autocomplete
+// Query params: kind (tag, folder or language), q, limit (default 10, max 50)
+func (h *AutocompleteHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if !models.IsAutocompleteKind(kind) {
+		Error(w, r, http.StatusBadRequest, "INVALID_KIND", "Kind must be tag, folder or language")
+		return
+	}
+	query := r.URL.Query().Get("q")
+	if utf8.RuneCountInString(query) > maxLookupQuery {
+		Error(w, r, http.StatusBadRequest, "QUERY_TOO_LONG", "Query must be at most 100 characters")
+		return
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	suggestions, err := h.autocomplete.Suggest(r.Context(), kind, query, limit)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, suggestions)
+}
@@ -1,49 +1,109 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/jobs"
 	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/services"
 )
 
 // BackupHandler handles backup-related HTTP requests
 type BackupHandler struct {
-	backupSvc *services.BackupService
-	s3SyncSvc *services.S3SyncService // May be nil if S3 is not configured
+	backupSvc contracts.BackupService
+	s3SyncSvc contracts.S3SyncService // May be nil if S3 is not configured
+	jobs      contracts.JobQueue      // May be nil, which disables async imports
 }
 
 // NewBackupHandler creates a new backup handler
-func NewBackupHandler(backupSvc *services.BackupService, s3SyncSvc *services.S3SyncService) *BackupHandler {
+func NewBackupHandler(backupSvc contracts.BackupService, s3SyncSvc contracts.S3SyncService) *BackupHandler {
 	return &BackupHandler{
 		backupSvc: backupSvc,
 		s3SyncSvc: s3SyncSvc,
 	}
 }
 
+// WithJobs enables async imports through the job queue
+func (h *BackupHandler) WithJobs(queue contracts.JobQueue) *BackupHandler {
+	h.jobs = queue
+	return h
+}
+
 // Export handles GET /api/v1/backup/export
-// Query params: format (json|zip), password (optional)
+// Query params: format (json|zip|markdown), password (optional), redact
+// (optional, masks with the redaction rules). The markdown bundle is a ZIP
+// of notes for other tools and cannot be imported; it is always masked.
 func (h *BackupHandler) Export(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, exportOptions(r), "")
+}
+
+// ExportFolder handles GET /api/v1/folders/{id}/export
+// Takes the same query params as Export and exports only the folder's
+// snippets, with the tags and folders they use.
+func (h *BackupHandler) ExportFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid folder ID")
+		return
+	}
+	opts := exportOptions(r)
+	opts.FolderID = id
+	h.export(w, r, opts, "Folder not found")
+}
+
+// ExportTag handles GET /api/v1/tags/{id}/export
+// Takes the same query params as Export and exports only the snippets with
+// the tag, including those with a tag implying it.
+func (h *BackupHandler) ExportTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid tag ID")
+		return
+	}
+	opts := exportOptions(r)
+	opts.TagID = id
+	h.export(w, r, opts, "Tag not found")
+}
+
+// exportOptions reads the export query params, defaulting to JSON
+func exportOptions(r *http.Request) models.ExportOptions {
 	opts := models.ExportOptions{
 		Format:   r.URL.Query().Get("format"),
 		Password: r.URL.Query().Get("password"),
+		Redact:   r.URL.Query().Get("redact") == "true",
 	}
-
 	if opts.Format == "" {
 		opts.Format = "json"
 	}
+	return opts
+}
 
+// export writes a backup as a file download, answering 404 with notFound
+// when the folder or tag it is scoped to does not exist
+func (h *BackupHandler) export(w http.ResponseWriter, r *http.Request, opts models.ExportOptions, notFound string) {
 	content, filename, err := h.backupSvc.Export(r.Context(), opts)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, notFound)
+			return
+		}
 		Error(w, r, http.StatusInternalServerError, "BACKUP_FAILED", err.Error())
 		return
 	}
 
 	// Determine content type
 	contentType := "application/json"
-	if opts.Format == "zip" {
+	if opts.Format == "zip" || opts.Format == "markdown" {
 		contentType = "application/zip"
 	}
 	if opts.Password != "" {
@@ -57,7 +117,12 @@ func (h *BackupHandler) Export(w http.ResponseWriter, r *http.Request) {
 }
 
 // Import handles POST /api/v1/backup/import
-// Form data: file (multipart), strategy (replace|merge|skip), password (optional)
+// Form data: file (multipart), strategy (replace|merge|skip), password (optional),
+// async (optional), report (optional, json|csv). With async=true the file is
+// validated, the restore runs as a background job and the response is 202 with
+// the job; follow it via /api/v1/jobs/{id} or /api/v1/jobs/{id}/events. With
+// report set, the per-item import report is sent as a file download instead
+// of the result.
 func (h *BackupHandler) Import(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form (max 50MB)
 	if err := r.ParseMultipartForm(50 << 20); err != nil {
@@ -91,25 +156,150 @@ func (h *BackupHandler) Import(w http.ResponseWriter, r *http.Request) {
 		opts.Strategy = "merge"
 	}
 
-	result, err := h.backupSvc.Import(r.Context(), content, opts)
+	report := r.FormValue("report")
+	if report != "" && report != "json" && report != "csv" {
+		Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Report format must be json or csv")
+		return
+	}
+
+	data, err := h.backupSvc.Decode(content, opts.Password)
 	if err != nil {
-		if err == services.ErrDecryptionFailed {
-			Error(w, r, http.StatusBadRequest, "DECRYPTION_FAILED", "Failed to decrypt backup - wrong password?")
+		importError(w, r, err)
+		return
+	}
+
+	if async := r.FormValue("async"); async == "true" || async == "1" {
+		if h.jobs == nil {
+			Error(w, r, http.StatusServiceUnavailable, "JOBS_UNAVAILABLE", "Background jobs are not available")
 			return
 		}
-		if err == services.ErrInvalidBackupFormat {
-			Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Invalid backup file format")
+
+		job, err := h.jobs.Enqueue(r.Context(), services.BackupImportJob, services.BackupImportPayload{
+			Data:     data,
+			Strategy: opts.Strategy,
+		})
+		if err != nil {
+			InternalError(w, r)
 			return
 		}
-		Error(w, r, http.StatusInternalServerError, "IMPORT_FAILED", err.Error())
+
+		w.Header().Set("Location", apiPath(r, "/jobs/"+job.ID))
+		Success(w, r, http.StatusAccepted, job)
+		return
+	}
+
+	result, err := h.backupSvc.Restore(r.Context(), data, opts, nil)
+	if err != nil {
+		importError(w, r, err)
+		return
+	}
+
+	if report != "" {
+		writeImportReport(w, result.Items, report, "snipo-import-report")
 		return
 	}
 
 	OK(w, r, result)
 }
 
+// ImportReport handles GET /api/v1/backup/import/{job}/report
+// Query params: format (json|csv, default json). Downloads the per-item
+// report of a finished async import.
+func (h *BackupHandler) ImportReport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Format must be json or csv")
+		return
+	}
+
+	if h.jobs == nil {
+		Error(w, r, http.StatusServiceUnavailable, "JOBS_UNAVAILABLE", "Background jobs are not available")
+		return
+	}
+
+	job, err := h.jobs.Get(r.Context(), chi.URLParam(r, "job"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			NotFound(w, r, "Import job not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	if job.Kind != services.BackupImportJob {
+		NotFound(w, r, "Import job not found")
+		return
+	}
+	if job.Status != models.JobStatusSucceeded {
+		Error(w, r, http.StatusConflict, "REPORT_UNAVAILABLE", "The import has not finished successfully")
+		return
+	}
+
+	var result models.ImportResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	writeImportReport(w, result.Items, format, "snipo-import-report-"+job.ID)
+}
+
+// writeImportReport sends import items as a JSON or CSV download
+func writeImportReport(w http.ResponseWriter, items []models.ImportItem, format, name string) {
+	if items == nil {
+		items = []models.ImportItem{}
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+name+".csv\"")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"kind", "line", "source_id", "title", "action", "new_id", "reason"})
+		for _, item := range items {
+			_ = cw.Write([]string{
+				item.Kind,
+				strconv.Itoa(item.Line),
+				item.SourceID,
+				item.Title,
+				item.Action,
+				item.NewID,
+				item.Reason,
+			})
+		}
+		cw.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+".json\"")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(items)
+}
+
+// importError maps backup decoding and restore errors to responses
+func importError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrDecryptionFailed):
+		Error(w, r, http.StatusBadRequest, "DECRYPTION_FAILED", "Failed to decrypt backup - wrong password?")
+	case errors.Is(err, services.ErrCorruptBackup):
+		Error(w, r, http.StatusBadRequest, "CORRUPT_BACKUP", "Backup file is corrupt or truncated")
+	case errors.Is(err, services.ErrUnsupportedBackupVersion):
+		Error(w, r, http.StatusBadRequest, "UNSUPPORTED_VERSION", err.Error())
+	case errors.Is(err, services.ErrInvalidBackupFormat):
+		Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Invalid backup file format")
+	default:
+		Error(w, r, http.StatusInternalServerError, "IMPORT_FAILED", err.Error())
+	}
+}
+
 // S3Sync handles POST /api/v1/backup/s3/sync
 // Body: { "format": "json|zip", "password": "optional" }
+// Query params: async (optional). With async=true the backup is created and
+// uploaded by a background job, and the response is 202 with the job.
 func (h *BackupHandler) S3Sync(w http.ResponseWriter, r *http.Request) {
 	if h.s3SyncSvc == nil {
 		Error(w, r, http.StatusServiceUnavailable, "S3_NOT_CONFIGURED", "S3 storage is not configured")
@@ -121,6 +311,33 @@ func (h *BackupHandler) S3Sync(w http.ResponseWriter, r *http.Request) {
 		// Use defaults if no body
 		opts.Format = "json"
 	}
+	if opts.Format == "markdown" {
+		Error(w, r, http.StatusBadRequest, "INVALID_FORMAT", "Markdown bundles cannot be restored; sync a json or zip backup")
+		return
+	}
+
+	if async := r.URL.Query().Get("async"); async == "true" || async == "1" {
+		if h.jobs == nil {
+			Error(w, r, http.StatusServiceUnavailable, "JOBS_UNAVAILABLE", "Background jobs are not available")
+			return
+		}
+
+		payload, err := h.s3SyncSvc.PrepareSync(r.Context(), opts)
+		if err != nil {
+			Error(w, r, http.StatusInternalServerError, "SYNC_FAILED", err.Error())
+			return
+		}
+
+		job, err := h.jobs.Enqueue(r.Context(), services.S3SyncJob, payload)
+		if err != nil {
+			InternalError(w, r)
+			return
+		}
+
+		w.Header().Set("Location", apiPath(r, "/jobs/"+job.ID))
+		Success(w, r, http.StatusAccepted, job)
+		return
+	}
 
 	result, err := h.s3SyncSvc.SyncToS3(r.Context(), opts)
 	if err != nil {
@@ -144,7 +361,30 @@ func (h *BackupHandler) S3List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	OK(w, r, backups)
+	OKList(w, r, backups)
+}
+
+// S3Preview handles GET /api/v1/backup/s3/preview
+// Query params: key (required)
+func (h *BackupHandler) S3Preview(w http.ResponseWriter, r *http.Request) {
+	if h.s3SyncSvc == nil {
+		Error(w, r, http.StatusServiceUnavailable, "S3_NOT_CONFIGURED", "S3 storage is not configured")
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_KEY", "Backup key is required")
+		return
+	}
+
+	preview, err := h.s3SyncSvc.PreviewBackup(r.Context(), key)
+	if err != nil {
+		Error(w, r, http.StatusInternalServerError, "PREVIEW_FAILED", err.Error())
+		return
+	}
+
+	OK(w, r, preview)
 }
 
 // S3Restore handles POST /api/v1/backup/s3/restore
@@ -217,6 +457,9 @@ func (h *BackupHandler) S3Status(w http.ResponseWriter, r *http.Request) {
 	status := map[string]interface{}{
 		"enabled": h.s3SyncSvc != nil,
 	}
+	if h.s3SyncSvc != nil {
+		status["provider"] = h.s3SyncSvc.Provider()
+	}
 
 	OK(w, r, status)
 }
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+// updateGolden rewrites the golden backups from the current exporter:
+// go test ./internal/api/handlers -run TestBackupRoundTrip -update
+var updateGolden = flag.Bool("update", false, "rewrite golden backup files")
+
+// newRoundTripBackupService wires a backup service over a fresh database
+// the way the router does for the parts a backup covers
+func newRoundTripBackupService(t *testing.T) *services.BackupService {
+	t.Helper()
+	db := testutil.TestDB(t)
+	tagRepo := repository.NewTagRepository(db)
+	folderRepo := repository.NewFolderRepository(db)
+	fileRepo := repository.NewSnippetFileRepository(db)
+	logger := testutil.TestLogger()
+	service := services.NewSnippetService(repository.NewSnippetRepository(db), logger).
+		WithTagRepo(tagRepo).
+		WithFolderRepo(folderRepo).
+		WithFileRepo(fileRepo).
+		WithMetadataRepo(repository.NewMetadataRepository(db))
+	return services.NewBackupService(db, service, tagRepo, folderRepo, fileRepo, logger)
+}
+
+// TestBackupRoundTrip imports each backup into an empty database, exports
+// it again and compares the result with the golden file, byte for byte.
+// The current format must survive the round trip unchanged; older ones
+// must upgrade to the golden export without losing data.
+func TestBackupRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		golden string
+	}{
+		{"current format", "backup_v2.json", "backup_v2.json"},
+		{"version 1", "backup_v1.json", "backup_v1_restored.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testutil.TestContext()
+			input, err := os.ReadFile(filepath.Join("testdata", tt.input))
+			if err != nil {
+				t.Fatalf("failed to read backup: %v", err)
+			}
+
+			backupSvc := newRoundTripBackupService(t)
+			result, err := backupSvc.Import(ctx, input, models.ImportOptions{Strategy: "merge"})
+			if err != nil {
+				t.Fatalf("failed to import backup: %v", err)
+			}
+			if len(result.Errors) > 0 || len(result.ChecksumMismatches) > 0 {
+				t.Fatalf("expected a clean import, got %+v", result)
+			}
+
+			exported, _, err := backupSvc.Export(ctx, models.ExportOptions{Format: "json"})
+			if err != nil {
+				t.Fatalf("failed to export backup: %v", err)
+			}
+
+			goldenPath := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, exported, 0o644); err != nil {
+					t.Fatalf("failed to write golden file: %v", err)
+				}
+			}
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(exported, golden) {
+				t.Errorf("export differs from %s; data was lost or changed on the way:\n%s", tt.golden, lineDiff(string(golden), string(exported)))
+			}
+
+			// Exporting what was restored from the export changes nothing
+			again := newRoundTripBackupService(t)
+			if _, err := again.Import(ctx, exported, models.ImportOptions{Strategy: "merge"}); err != nil {
+				t.Fatalf("failed to import export: %v", err)
+			}
+			reexported, _, err := again.Export(ctx, models.ExportOptions{Format: "json"})
+			if err != nil {
+				t.Fatalf("failed to export backup: %v", err)
+			}
+			if !bytes.Equal(reexported, exported) {
+				t.Errorf("second round trip changed the export:\n%s", lineDiff(string(exported), string(reexported)))
+			}
+		})
+	}
+}
+
+// TestBackupExport_AllSnippets checks that exports are not cut off at a
+// list's page size and include archived snippets
+func TestBackupExport_AllSnippets(t *testing.T) {
+	ctx := testutil.TestContext()
+	backupSvc := newRoundTripBackupService(t)
+
+	backup := models.BackupData{Version: services.BackupVersion}
+	for i := range 150 {
+		backup.Snippets = append(backup.Snippets, models.Snippet{
+			Title:      fmt.Sprintf("Snippet %03d", i),
+			Content:    "x",
+			Language:   "go",
+			IsArchived: i%10 == 0,
+		})
+	}
+	content, _ := json.Marshal(backup)
+	if _, err := backupSvc.Import(ctx, content, models.ImportOptions{Strategy: "merge"}); err != nil {
+		t.Fatalf("failed to import backup: %v", err)
+	}
+
+	exported, _, err := backupSvc.Export(ctx, models.ExportOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("failed to export backup: %v", err)
+	}
+	var data models.BackupData
+	if err := json.Unmarshal(exported, &data); err != nil {
+		t.Fatalf("failed to unmarshal export: %v", err)
+	}
+	titles := make(map[string]bool)
+	for _, s := range data.Snippets {
+		titles[s.Title] = true
+	}
+	if len(data.Snippets) != 150 || len(titles) != 150 {
+		t.Errorf("expected all 150 snippets exported once, got %d (%d distinct)", len(data.Snippets), len(titles))
+	}
+}
+
+// lineDiff lists the lines of want and got that differ, for failure
+// messages; both are indented JSON, so lines rarely shift
+func lineDiff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "line %d:\n  - %s\n  + %s\n", i+1, w, g)
+		}
+	}
+	return b.String()
+}
+
+// TestBackupImport_VersionNegotiation checks which backup format versions
+// an import accepts, and that refusals say why
+func TestBackupImport_VersionNegotiation(t *testing.T) {
+	handler := NewBackupHandler(newRoundTripBackupService(t), nil)
+
+	tests := []struct {
+		version string
+		status  int
+		code    string
+		message string
+	}{
+		{"1.0", http.StatusOK, "", ""},
+		{services.BackupVersion, http.StatusOK, "", ""},
+		{"2.7", http.StatusOK, "", ""}, // Newer minor versions only add fields
+		{"3.0", http.StatusBadRequest, "UNSUPPORTED_VERSION", "newer than this server supports"},
+		{"0.9", http.StatusBadRequest, "UNSUPPORTED_VERSION", "older than this server supports"},
+		{"latest", http.StatusBadRequest, "INVALID_FORMAT", ""},
+		{"", http.StatusBadRequest, "INVALID_FORMAT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			backup, _ := json.Marshal(models.BackupData{
+				Version:  tt.version,
+				Snippets: []models.Snippet{{Title: "Snippet " + tt.version, Content: "x", Language: "go"}},
+			})
+
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			fw, _ := mw.CreateFormFile("file", "backup.json")
+			_, _ = fw.Write(backup)
+			_ = mw.Close()
+
+			req := withRequestID(httptest.NewRequest(http.MethodPost, "/api/v1/backup/import", body))
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			handler.Import(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.code == "" {
+				return
+			}
+			var resp struct {
+				Error struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Error.Code != tt.code || !strings.Contains(resp.Error.Message, tt.message) {
+				t.Errorf("expected %s mentioning %q, got %+v", tt.code, tt.message, resp.Error)
+			}
+		})
+	}
+}
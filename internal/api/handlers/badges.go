@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// maxBadgeLabel is the longest label a badge may be given, in characters
+const maxBadgeLabel = 50
+
+// BadgeHandler serves SVG status badges for embedding in READMEs
+type BadgeHandler struct {
+	badges contracts.Badges
+	maxAge time.Duration
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(badges contracts.Badges) *BadgeHandler {
+	return &BadgeHandler{badges: badges}
+}
+
+// WithPublicCache sets how long caches may keep badges
+func (h *BadgeHandler) WithPublicCache(maxAge time.Duration) *BadgeHandler {
+	h.maxAge = maxAge
+	return h
+}
+
+// Count handles GET /api/v1/badges/count.svg
+// Query params: label, color (shields.io name or hex)
+func (h *BadgeHandler) Count(w http.ResponseWriter, r *http.Request) {
+	badge, err := h.badges.Count(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	h.write(w, r, badge)
+}
+
+// Languages handles GET /api/v1/badges/languages.svg
+// Query params: top (default 3, max 5), label, color
+func (h *BadgeHandler) Languages(w http.ResponseWriter, r *http.Request) {
+	top := 3
+	if t, err := strconv.Atoi(r.URL.Query().Get("top")); err == nil && t > 0 && t <= 5 {
+		top = t
+	}
+
+	badge, err := h.badges.Languages(r.Context(), top)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	h.write(w, r, badge)
+}
+
+// write applies the label and colour overrides and writes the badge
+func (h *BadgeHandler) write(w http.ResponseWriter, r *http.Request, badge *models.Badge) {
+	query := r.URL.Query()
+	if label := query.Get("label"); label != "" {
+		if utf8.RuneCountInString(label) > maxBadgeLabel {
+			Error(w, r, http.StatusBadRequest, "INVALID_LABEL", "Labels must be at most 50 characters")
+			return
+		}
+		badge.Label = label
+	}
+	if color := query.Get("color"); color != "" {
+		hex, ok := services.BadgeColor(color)
+		if !ok {
+			Error(w, r, http.StatusBadRequest, "INVALID_COLOR", "Colors must be a shields.io color name or a hex color")
+			return
+		}
+		badge.Color = hex
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", publicCacheControl(h.maxAge))
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(services.RenderBadge(badge))
+}
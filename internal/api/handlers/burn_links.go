@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// BurnLinkHandler handles burn-after-read share links
+type BurnLinkHandler struct {
+	links contracts.BurnLinks
+}
+
+// NewBurnLinkHandler creates a new burn link handler
+func NewBurnLinkHandler(links contracts.BurnLinks) *BurnLinkHandler {
+	return &BurnLinkHandler{links: links}
+}
+
+// Create handles POST /api/v1/snippets/{id}/burn-links
+// Returns the link with its token and share page URL; neither can be
+// retrieved again.
+func (h *BurnLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.BurnLinkInput
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &input); err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+			return
+		}
+	}
+
+	link, err := h.links.Create(r.Context(), id, &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrSnippetNotFound):
+			NotFound(w, r, "Snippet not found")
+		case errors.Is(err, services.ErrSnippetNotShareable):
+			Error(w, r, http.StatusConflict, "SNIPPET_NOT_SHAREABLE", "Snippets that require a check-out cannot be shared")
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	link.URL = scheme(r) + "://" + r.Host + "/b/" + url.PathEscape(link.Token)
+	Created(w, r, link)
+}
+
+// List handles GET /api/v1/snippets/{id}/burn-links
+// Lists the snippet's links that were not opened yet.
+func (h *BurnLinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	links, err := h.links.List(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, links)
+}
+
+// Delete handles DELETE /api/v1/snippets/{id}/burn-links/{link_id}
+func (h *BurnLinkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	linkID, err := strconv.ParseInt(chi.URLParam(r, "link_id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_LINK_ID", "Invalid link ID")
+		return
+	}
+
+	if err := h.links.Revoke(r.Context(), chi.URLParam(r, "id"), linkID); err != nil {
+		if errors.Is(err, services.ErrBurnLinkNotFound) {
+			NotFound(w, r, "Link not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	NoContent(w)
+}
+
+// Reveal handles POST /api/v1/burn/{token}
+// Shows the snippet once and burns the link. It is a POST so that link
+// previews and prefetching, which only GET the share page, cannot use up
+// the link.
+func (h *BurnLinkHandler) Reveal(w http.ResponseWriter, r *http.Request) {
+	snippet, err := h.links.Reveal(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, services.ErrBurnLinkNotFound) {
+			NotFound(w, r, "This link was already opened or has expired")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	OKSnippet(w, r, snippet)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// publicCacheControl is the Cache-Control value for public snippet responses.
+// Without a max age, caches must revalidate every time.
+func publicCacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return "public, no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
+
+// snippetETag is a weak validator for one representation of a snippet; it
+// changes whenever the snippet is updated
+func snippetETag(snippet *models.Snippet, representation string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", representation, snippet.ID, snippet.UpdatedAt.UnixNano())))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// checkPublicCache sets caching headers for a public snippet response and
+// answers conditional requests. It returns true when the client's copy is
+// current and a 304 has been written. Responses to signed URLs may hold a
+// private snippet, so they are never stored.
+func checkPublicCache(w http.ResponseWriter, r *http.Request, snippet *models.Snippet, representation string, maxAge time.Duration) bool {
+	if middleware.SignedSnippetID(r.Context()) != "" {
+		w.Header().Set("Cache-Control", "private, no-store")
+		return false
+	}
+
+	etag := snippetETag(snippet, representation)
+	modified := snippet.UpdatedAt.UTC().Truncate(time.Second)
+
+	w.Header().Set("Cache-Control", publicCacheControl(maxAge))
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110)
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || modified.IsZero() || modified.After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/captcha"
+)
+
+// CaptchaHandler tells the browser how to show the captcha widget
+type CaptchaHandler struct {
+	widget        *captcha.Widget
+	loginRequired func() bool
+}
+
+// NewCaptchaHandler creates a captcha handler. A nil widget means no
+// captcha is configured; loginRequired reports whether logging in
+// currently needs one.
+func NewCaptchaHandler(widget *captcha.Widget, loginRequired func() bool) *CaptchaHandler {
+	return &CaptchaHandler{widget: widget, loginRequired: loginRequired}
+}
+
+// CaptchaResponse is the captcha widget and where it is needed
+type CaptchaResponse struct {
+	*captcha.Widget
+	Login   bool `json:"login"`   // Logging in needs a captcha right now
+	Reports bool `json:"reports"` // Abuse reports always need one
+}
+
+// Get handles GET /api/v1/captcha
+// Responds 204 No Content when no captcha is configured.
+func (h *CaptchaHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if h.widget == nil {
+		NoContent(w)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	OK(w, r, CaptchaResponse{
+		Widget:  h.widget,
+		Login:   h.loginRequired != nil && h.loginRequired(),
+		Reports: true,
+	})
+}
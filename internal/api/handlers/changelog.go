@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/changelog"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+)
+
+// ChangelogHandler serves the release notes embedded in the binary
+type ChangelogHandler struct {
+	authService contracts.Authenticator
+	version     string
+}
+
+// NewChangelogHandler creates a new changelog handler for the running version
+func NewChangelogHandler(authService contracts.Authenticator, version string) *ChangelogHandler {
+	return &ChangelogHandler{authService: authService, version: version}
+}
+
+// ChangelogResponse is the release notes up to the running version
+type ChangelogResponse struct {
+	Version  string              `json:"version"`
+	Releases []changelog.Release `json:"releases"`
+	Seen     *string             `json:"seen,omitempty"` // Version the session has seen the notes of, for browser sessions
+	New      []changelog.Release `json:"new,omitempty"`  // Releases since Seen, to show after an upgrade
+}
+
+// List handles GET /api/v1/changelog
+// Lists the release notes up to the running version, newest first, or
+// only those newer than ?since=. For browser sessions it also returns the
+// releases the session has not seen yet.
+func (h *ChangelogHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp := ChangelogResponse{Version: h.version}
+	if since := r.URL.Query().Get("since"); since != "" {
+		resp.Releases = changelog.Between(since, h.version)
+	} else {
+		resp.Releases = changelog.Releases(h.version)
+	}
+	if resp.Releases == nil {
+		resp.Releases = []changelog.Release{}
+	}
+
+	if seen, ok := h.authService.ChangelogSeen(auth.GetSessionFromRequest(r)); ok {
+		resp.Seen = &seen
+		resp.New = changelog.Between(seen, h.version)
+	}
+	OK(w, r, resp)
+}
+
+// MarkSeen handles POST /api/v1/changelog/seen
+// Records that the session has seen the release notes of the running
+// version; new sessions start from the latest session's marker.
+func (h *ChangelogHandler) MarkSeen(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.SetChangelogSeen(auth.GetSessionFromRequest(r), h.version); err != nil {
+		Error(w, r, http.StatusBadRequest, "NO_SESSION", "Release notes are only tracked for browser sessions")
+		return
+	}
+	NoContent(w)
+}
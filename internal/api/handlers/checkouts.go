@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// checkoutRequired answers a request for the content of a snippet the
+// caller has not checked out
+func checkoutRequired(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, http.StatusForbidden, "CHECKOUT_REQUIRED", "This snippet must be checked out first")
+}
+
+// withholdContent blanks the content of listed snippets the caller has not
+// checked out
+func (h *SnippetHandler) withholdContent(r *http.Request, snippets []models.Snippet) {
+	for i := range snippets {
+		h.service.WithholdContent(r.Context(), &snippets[i])
+	}
+}
+
+// Checkout handles POST /api/v1/snippets/{id}/checkout
+// Checks out a snippet that requires it, recording the reason. Responds
+// 409 while someone else holds the check-out.
+func (h *SnippetHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if !h.allowSnippet(w, r, id, models.RoleViewer) {
+		return
+	}
+
+	var input models.CheckoutInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	checkout, err := h.service.Checkout(r.Context(), id, &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrSnippetNotFound):
+			NotFound(w, r, "Snippet not found")
+		case errors.Is(err, services.ErrCheckoutNotRequired):
+			Error(w, r, http.StatusConflict, "CHECKOUT_NOT_REQUIRED", "This snippet does not require a check-out")
+		case errors.Is(err, services.ErrSnippetCheckedOut):
+			Error(w, r, http.StatusConflict, "SNIPPET_CHECKED_OUT", "Checked out by "+checkout.Holder+" until "+checkout.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"))
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	OK(w, r, checkout)
+}
+
+// Checkin handles POST /api/v1/snippets/{id}/checkin
+func (h *SnippetHandler) Checkin(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if !h.allowSnippet(w, r, id, models.RoleViewer) {
+		return
+	}
+
+	var input models.CheckinInput
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &input); err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+			return
+		}
+	}
+
+	checkout, err := h.service.Checkin(r.Context(), id, &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrSnippetNotFound):
+			NotFound(w, r, "Snippet not found")
+		case errors.Is(err, services.ErrNotCheckedOut):
+			Error(w, r, http.StatusConflict, "NOT_CHECKED_OUT", "You have not checked out this snippet")
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	OK(w, r, checkout)
+}
+
+// ListCheckouts handles GET /api/v1/snippets/{id}/checkouts
+// Returns the snippet's check-outs, newest first, as its audit trail.
+func (h *SnippetHandler) ListCheckouts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if !h.allowSnippet(w, r, id, models.RoleViewer) {
+		return
+	}
+
+	checkouts, err := h.service.ListCheckouts(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, checkouts)
+}
+
+// CheckoutTrail handles GET /api/v1/admin/checkouts
+// Returns the newest check-outs of every snippet, deleted ones included.
+func (h *SnippetHandler) CheckoutTrail(w http.ResponseWriter, r *http.Request) {
+	checkouts, err := h.service.ListCheckouts(r.Context(), "")
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, checkouts)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/ogimage"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// CodeImage handles GET /api/v1/snippets/{id}/image.png
+// Renders the snippet as a syntax highlighted PNG, for sharing in chats
+// that do not render code.
+// Query params: theme (default dark), width (pixels, 400-2000, default
+// 1000), line_numbers (default true)
+func (h *SnippetHandler) CodeImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	opts := services.CodeImageOptions{Theme: ogimage.DefaultTheme, LineNumbers: true}
+	if theme := query.Get("theme"); theme != "" {
+		if _, ok := ogimage.Themes[theme]; !ok {
+			Error(w, r, http.StatusBadRequest, "INVALID_THEME", "Theme must be one of: "+strings.Join(ogimage.ThemeNames(), ", "))
+			return
+		}
+		opts.Theme = theme
+	}
+	if width := query.Get("width"); width != "" {
+		n, err := strconv.Atoi(width)
+		if err != nil || n < ogimage.MinWidth || n > ogimage.MaxWidth {
+			Error(w, r, http.StatusBadRequest, "INVALID_WIDTH", "Width must be between 400 and 2000 pixels")
+			return
+		}
+		opts.Width = n
+	}
+	if lineNumbers := query.Get("line_numbers"); lineNumbers == "false" || lineNumbers == "0" {
+		opts.LineNumbers = false
+	}
+
+	if !h.allowSnippet(w, r, id, models.RoleViewer) {
+		return
+	}
+
+	image, err := h.service.CodeImage(r.Context(), id, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(image)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+
+	"github.com/MohamedElashri/snipo/internal/collab"
+)
+
+const (
+	// collabReadTimeout closes connections that stop sending; editors ping
+	// every 30 seconds
+	collabReadTimeout = 90 * time.Second
+	// collabWriteTimeout closes connections that stop reading
+	collabWriteTimeout = 10 * time.Second
+	// collabMaxMessage bounds a message from an editor
+	collabMaxMessage = 1 << 20
+)
+
+// CollabHandler handles collaborative editing connections
+type CollabHandler struct {
+	hub *collab.Hub
+}
+
+// NewCollabHandler creates a new collaborative editing handler
+func NewCollabHandler(hub *collab.Hub) *CollabHandler {
+	return &CollabHandler{hub: hub}
+}
+
+// Connect handles GET /api/v1/snippets/{id}/collab?file_id=
+// Upgrades to a WebSocket over which editors of the snippet's content
+// (file_id omitted or 0) or of one of its files exchange edits.
+func (h *CollabHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	key := collab.Key{SnippetID: chi.URLParam(r, "id")}
+	if key.SnippetID == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if v := r.URL.Query().Get("file_id"); v != "" {
+		fileID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || fileID < 0 {
+			Error(w, r, http.StatusBadRequest, "INVALID_FILE_ID", "Invalid file ID")
+			return
+		}
+		key.FileID = fileID
+	}
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		Error(w, r, http.StatusUpgradeRequired, "UPGRADE_REQUIRED", "WebSocket upgrade required")
+		return
+	}
+
+	if err := h.hub.Check(r.Context(), key); err != nil {
+		if errors.Is(err, collab.ErrNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	server := websocket.Server{
+		Handshake: checkCollabOrigin,
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = collabMaxMessage
+			_ = h.hub.Serve(ctx, key, &collabConn{ws: ws})
+		},
+	}
+	server.ServeHTTP(hijacker{w}, r)
+}
+
+// checkCollabOrigin refuses connections opened by pages of other sites;
+// clients other than browsers send no Origin
+func checkCollabOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return errors.New("cross-origin connection")
+	}
+	config.Origin = u
+	return nil
+}
+
+// collabConn carries JSON messages over a WebSocket
+type collabConn struct {
+	ws *websocket.Conn
+}
+
+func (c *collabConn) Receive(msg *collab.Message) error {
+	_ = c.ws.SetReadDeadline(time.Now().Add(collabReadTimeout))
+	return websocket.JSON.Receive(c.ws, msg)
+}
+
+func (c *collabConn) Send(msg *collab.Message) error {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+	return websocket.JSON.Send(c.ws, msg)
+}
+
+func (c *collabConn) Close() error {
+	return c.ws.Close()
+}
+
+// hijacker exposes Hijack of a response writer wrapped by middleware
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
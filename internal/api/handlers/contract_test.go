@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
 	"github.com/MohamedElashri/snipo/internal/models"
 )
 
@@ -366,3 +367,65 @@ func TestContract_FieldTypes(t *testing.T) {
 		}
 	}
 }
+
+// TestContract_V2Envelope validates that v2 lists and errors share one envelope
+func TestContract_V2Envelope(t *testing.T) {
+	handler, repo := setupTagHandler(t)
+	if _, err := repo.Create(context.Background(), &models.TagInput{Name: "v2", Color: "#000000"}); err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+
+	get := func(version int) map[string]json.RawMessage {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/tags", nil)
+		req = withRequestID(req)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ContextKeyAPIVersion, version))
+		w := httptest.NewRecorder()
+		handler.List(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return body
+	}
+
+	if _, ok := get(1)["pagination"]; ok {
+		t.Error("Expected v1 tag list to stay unpaginated")
+	}
+
+	v2 := get(2)
+	var pagination struct {
+		Page       int `json:"page"`
+		Total      int `json:"total"`
+		TotalPages int `json:"total_pages"`
+	}
+	if err := json.Unmarshal(v2["pagination"], &pagination); err != nil {
+		t.Fatalf("Expected v2 pagination: %v", err)
+	}
+	if pagination.Page != 1 || pagination.Total != 1 || pagination.TotalPages != 1 {
+		t.Errorf("Unexpected v2 pagination: %+v", pagination)
+	}
+	var meta testMeta
+	if err := json.Unmarshal(v2["meta"], &meta); err != nil || meta.Version != "2.0" {
+		t.Errorf("Expected v2 meta with version 2.0, got %s", v2["meta"])
+	}
+
+	// Errors carry meta in v2
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/tags/x", nil)
+	req = withRequestID(req)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextKeyAPIVersion, 2))
+	w := httptest.NewRecorder()
+	NotFound(w, req, "")
+	var errBody struct {
+		Error ErrorDetail `json:"error"`
+		Meta  *testMeta   `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil {
+		t.Fatalf("Failed to parse error response: %v", err)
+	}
+	if errBody.Meta == nil || errBody.Meta.Version != "2.0" || errBody.Error.RequestID == "" {
+		t.Errorf("Expected v2 error with meta and request ID, got %s", w.Body.String())
+	}
+}
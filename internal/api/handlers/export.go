@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ExportHandler handles publishing exports
+type ExportHandler struct {
+	siteSvc contracts.SiteExporter
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(siteSvc contracts.SiteExporter) *ExportHandler {
+	return &ExportHandler{siteSvc: siteSvc}
+}
+
+// Site handles POST /api/v1/export/site
+// Returns a zip archive of a static HTML site containing all public snippets.
+// Body (optional): {"title": "My Snippets"}
+func (h *ExportHandler) Site(w http.ResponseWriter, r *http.Request) {
+	var opts models.SiteExportOptions
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &opts); err != nil && !errors.Is(err, io.EOF) {
+			Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+			return
+		}
+	}
+	if len(opts.Title) > 200 {
+		Error(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "Title must be 200 characters or less")
+		return
+	}
+
+	content, filename, err := h.siteSvc.ExportZip(r.Context(), opts)
+	if err != nil {
+		Error(w, r, http.StatusInternalServerError, "EXPORT_FAILED", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
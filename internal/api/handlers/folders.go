@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/MohamedElashri/snipo/internal/contracts"
 	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/validation"
@@ -14,19 +18,32 @@ import (
 
 // FolderHandler handles folder-related HTTP requests
 type FolderHandler struct {
-	repo *repository.FolderRepository
+	repo     contracts.FolderRepository
+	snippets contracts.SnippetService
 }
 
 // NewFolderHandler creates a new folder handler
-func NewFolderHandler(repo *repository.FolderRepository) *FolderHandler {
+func NewFolderHandler(repo contracts.FolderRepository) *FolderHandler {
 	return &FolderHandler{repo: repo}
 }
 
+// WithSnippets sets the snippet service that applies auto-archive rules
+func (h *FolderHandler) WithSnippets(snippets contracts.SnippetService) *FolderHandler {
+	h.snippets = snippets
+	return h
+}
+
 // List handles GET /api/v1/folders
 func (h *FolderHandler) List(w http.ResponseWriter, r *http.Request) {
 	// Check if tree format is requested
 	tree := r.URL.Query().Get("tree") == "true"
 
+	// Tokens limited to folders get a flat list of the folders they reach
+	access := folderAccess(r)
+	if access != nil {
+		tree = false
+	}
+
 	var folders []models.Folder
 	var err error
 
@@ -40,6 +57,11 @@ func (h *FolderHandler) List(w http.ResponseWriter, r *http.Request) {
 		InternalError(w, r)
 		return
 	}
+	if access != nil {
+		folders = slices.DeleteFunc(folders, func(f models.Folder) bool {
+			return !access.Allows([]int64{f.ID}, models.RoleViewer)
+		})
+	}
 
 	// Get snippet counts for each folder (only for flat list)
 	if !tree {
@@ -51,7 +73,7 @@ func (h *FolderHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	OK(w, r, folders)
+	OKList(w, r, folders)
 }
 
 // Create handles POST /api/v1/folders
@@ -64,12 +86,18 @@ func (h *FolderHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if input.Name == "" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name is required"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Code: validation.CodeFolderNameRequired, Message: "Name is required"}})
 		return
 	}
 
 	if len(input.Name) > 100 {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name must be 100 characters or less"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.TooLong("name", validation.CodeFolderNameTooLong, "Name must be 100 characters or less", 100, len(input.Name))})
+		return
+	}
+
+	errs := append(validation.ValidateFolderStyle(&input), validation.ValidateFolderArchiveRule(&input)...)
+	if errs = append(errs, validation.ValidateFolderSlug(&input)...); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
 		return
 	}
 
@@ -78,7 +106,7 @@ func (h *FolderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		_, err := h.repo.GetByID(r.Context(), *input.ParentID)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
-				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Parent folder not found"}})
+				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentNotFound, Message: "Parent folder not found"}})
 				return
 			}
 			InternalError(w, r)
@@ -86,8 +114,17 @@ func (h *FolderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := h.resolveSlug(r.Context(), &input, nil); err != nil {
+		InternalError(w, r)
+		return
+	}
+
 	folder, err := h.repo.Create(r.Context(), &input)
 	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			Error(w, r, http.StatusConflict, "SLUG_TAKEN", "Another folder already uses this slug")
+			return
+		}
 		InternalError(w, r)
 		return
 	}
@@ -103,6 +140,11 @@ func (h *FolderHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !folderAccess(r).Allows([]int64{id}, models.RoleViewer) {
+		NotFound(w, r, "Folder not found")
+		return
+	}
+
 	folder, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
@@ -138,26 +180,32 @@ func (h *FolderHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if input.Name == "" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name is required"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Code: validation.CodeFolderNameRequired, Message: "Name is required"}})
 		return
 	}
 
 	if len(input.Name) > 100 {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name must be 100 characters or less"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.TooLong("name", validation.CodeFolderNameTooLong, "Name must be 100 characters or less", 100, len(input.Name))})
+		return
+	}
+
+	errs := append(validation.ValidateFolderStyle(&input), validation.ValidateFolderArchiveRule(&input)...)
+	if errs = append(errs, validation.ValidateFolderSlug(&input)...); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
 		return
 	}
 
 	// Validate parent exists if provided and not self-referencing
 	if input.ParentID != nil {
 		if *input.ParentID == id {
-			ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Folder cannot be its own parent"}})
+			ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentIsSelf, Message: "Folder cannot be its own parent"}})
 			return
 		}
 
 		_, err := h.repo.GetByID(r.Context(), *input.ParentID)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
-				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Parent folder not found"}})
+				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentNotFound, Message: "Parent folder not found"}})
 				return
 			}
 			InternalError(w, r)
@@ -165,12 +213,30 @@ func (h *FolderHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	existing, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, "Folder not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	if err := h.resolveSlug(r.Context(), &input, existing); err != nil {
+		InternalError(w, r)
+		return
+	}
+
 	folder, err := h.repo.Update(r.Context(), id, &input)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			NotFound(w, r, "Folder not found")
 			return
 		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			Error(w, r, http.StatusConflict, "SLUG_TAKEN", "Another folder already uses this slug")
+			return
+		}
 		InternalError(w, r)
 		return
 	}
@@ -178,6 +244,39 @@ func (h *FolderHandler) Update(w http.ResponseWriter, r *http.Request) {
 	OK(w, r, folder)
 }
 
+// resolveSlug derives a unique slug from the folder's name when it is made
+// public without one, so its collection always has an address. existing is
+// nil for new folders.
+func (h *FolderHandler) resolveSlug(ctx context.Context, input *models.FolderInput, existing *models.Folder) error {
+	public := input.IsPublic != nil && *input.IsPublic
+	slug := input.Slug
+	var id int64
+	if existing != nil {
+		id = existing.ID
+		if input.IsPublic == nil {
+			public = existing.IsPublic
+		}
+		if slug == nil {
+			slug = existing.Slug
+		}
+	}
+	if !public || (slug != nil && *slug != "") {
+		return nil
+	}
+
+	base := validation.FolderSlug(input.Name)
+	taken, err := h.repo.SlugsWithPrefix(ctx, base, id)
+	if err != nil {
+		return err
+	}
+	derived := base
+	for n := 2; taken[derived]; n++ {
+		derived = fmt.Sprintf("%s-%d", base, n)
+	}
+	input.Slug = &derived
+	return nil
+}
+
 // Delete handles DELETE /api/v1/folders/{id}
 func (h *FolderHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -199,6 +298,48 @@ func (h *FolderHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	NoContent(w)
 }
 
+// Collection handles GET /api/v1/public/folders/{id}
+// Lists a public folder's published snippets by folder ID or slug, without
+// authentication. Private folders are reported as not found.
+func (h *FolderHandler) Collection(w http.ResponseWriter, r *http.Request) {
+	ref := chi.URLParam(r, "id")
+	var folder *models.Folder
+	var err error
+	if id, parseErr := strconv.ParseInt(ref, 10, 64); parseErr == nil {
+		folder, err = h.repo.GetByID(r.Context(), id)
+	} else {
+		folder, err = h.repo.GetBySlug(r.Context(), ref)
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		InternalError(w, r)
+		return
+	}
+	if folder == nil || !folder.IsPublic || folder.Slug == nil || h.snippets == nil {
+		NotFound(w, r, "Collection not found")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := h.snippets.ListPublicInFolder(r.Context(), folder.ID, page, limit)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, models.Collection{
+		Folder: models.CollectionFolder{
+			ID:    folder.ID,
+			Name:  folder.Name,
+			Slug:  *folder.Slug,
+			Icon:  folder.Icon,
+			Color: folder.Color,
+		},
+		Snippets:   list.Data,
+		Pagination: list.Pagination,
+	})
+}
+
 // MoveRequest represents a request to move a folder
 type MoveRequest struct {
 	ParentID *int64 `json:"parent_id"`
@@ -220,7 +361,7 @@ func (h *FolderHandler) Move(w http.ResponseWriter, r *http.Request) {
 
 	// Validate not moving to self
 	if req.ParentID != nil && *req.ParentID == id {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Folder cannot be its own parent"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentIsSelf, Message: "Folder cannot be its own parent"}})
 		return
 	}
 
@@ -229,7 +370,7 @@ func (h *FolderHandler) Move(w http.ResponseWriter, r *http.Request) {
 		_, err := h.repo.GetByID(r.Context(), *req.ParentID)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
-				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Parent folder not found"}})
+				ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentNotFound, Message: "Parent folder not found"}})
 				return
 			}
 			InternalError(w, r)
@@ -245,7 +386,7 @@ func (h *FolderHandler) Move(w http.ResponseWriter, r *http.Request) {
 		}
 		// Check for circular reference error
 		if err.Error() == "cannot move folder: would create circular reference" {
-			ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Message: "Cannot move folder: would create circular reference"}})
+			ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "parent_id", Code: validation.CodeParentCycle, Message: "Cannot move folder: would create circular reference"}})
 			return
 		}
 		InternalError(w, r)
@@ -254,3 +395,30 @@ func (h *FolderHandler) Move(w http.ResponseWriter, r *http.Request) {
 
 	OK(w, r, folder)
 }
+
+// AutoArchivePreview handles GET /api/v1/folders/auto-archive
+// Reports the snippets the folders' auto-archive rules would archive now.
+func (h *FolderHandler) AutoArchivePreview(w http.ResponseWriter, r *http.Request) {
+	h.autoArchive(w, r, true)
+}
+
+// AutoArchive handles POST /api/v1/folders/auto-archive
+// Applies the auto-archive rules now instead of waiting for the scheduler.
+func (h *FolderHandler) AutoArchive(w http.ResponseWriter, r *http.Request) {
+	h.autoArchive(w, r, false)
+}
+
+func (h *FolderHandler) autoArchive(w http.ResponseWriter, r *http.Request, dryRun bool) {
+	if h.snippets == nil {
+		NotFound(w, r, "Auto-archive is not enabled")
+		return
+	}
+
+	report, err := h.snippets.AutoArchive(r.Context(), dryRun)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, report)
+}
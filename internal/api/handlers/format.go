@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response formats offered through the Accept header
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatText = "text"
+)
+
+// formatMediaTypes maps accepted media types to response formats
+var formatMediaTypes = map[string]string{
+	"application/json":   formatJSON,
+	"application/yaml":   formatYAML,
+	"application/x-yaml": formatYAML,
+	"text/yaml":          formatYAML,
+	"text/plain":         formatText,
+	"application/*":      formatJSON,
+	"*/*":                formatJSON,
+}
+
+// negotiateFormat picks the response format the Accept header prefers among
+// JSON, YAML and, when allowText is set, plain text. Wildcards match JSON,
+// which is also the default when nothing supported is listed.
+func negotiateFormat(r *http.Request, allowText bool) string {
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := formatMediaTypes[mediaType]
+		if !ok || (format == formatText && !allowText) {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		// Ties go to the type listed first
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// varyAccept marks a response as depending on the Accept header
+func varyAccept(w http.ResponseWriter) {
+	for _, v := range w.Header().Values("Vary") {
+		if strings.EqualFold(v, "Accept") {
+			return
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
+
+// writeYAML sends body as YAML. It goes through JSON first so keys and
+// omitted fields match the JSON responses exactly.
+func writeYAML(w http.ResponseWriter, status int, body interface{}) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// JSON is valid YAML; decoding it into a node keeps the key order, and
+	// clearing the styles drops the JSON quoting and braces
+	var node yaml.Node
+	if err := yaml.Unmarshal(encoded, &node); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	clearStyles(&node)
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(out.Bytes())
+}
+
+func clearStyles(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyles(child)
+	}
+}
+
+// writeText sends content as plain text
+func writeText(w http.ResponseWriter, status int, content string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(content))
+}
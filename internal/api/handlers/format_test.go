@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept    string
+		allowText bool
+		want      string
+	}{
+		{"", true, formatJSON},
+		{"*/*", true, formatJSON},
+		{"application/json", true, formatJSON},
+		{"application/yaml", false, formatYAML},
+		{"text/yaml", false, formatYAML},
+		{"text/plain", true, formatText},
+		{"text/plain", false, formatJSON},
+		{"text/plain;q=0.5, */*", true, formatJSON},
+		{"application/json;q=0.5, application/yaml", true, formatYAML},
+		{"text/html, application/yaml;q=0.9, */*;q=0.8", true, formatYAML},
+		{"application/yaml;q=0", true, formatJSON},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := negotiateFormat(req, tt.allowText); got != tt.want {
+			t.Errorf("Accept %q (text %v): expected %s, got %s", tt.accept, tt.allowText, tt.want, got)
+		}
+	}
+}
+
+func TestSnippetHandler_GetFormats(t *testing.T) {
+	handler, repo := setupSnippetHandler(t)
+	snippet, err := repo.Create(context.Background(), &models.SnippetInput{
+		Title:    "Formats",
+		Content:  "#!/bin/sh\necho \"hi\"\n",
+		Language: "bash",
+	})
+	if err != nil {
+		t.Fatalf("failed to create snippet: %v", err)
+	}
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/snippets/"+snippet.ID, nil)
+		req.Header.Set("Accept", accept)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", snippet.ID)
+		req = withRequestID(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		w := httptest.NewRecorder()
+		handler.Get(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Accept %s: expected status 200, got %d", accept, w.Code)
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %s: expected Vary: Accept, got %q", accept, w.Header().Get("Vary"))
+		}
+		return w
+	}
+
+	w := get("text/plain")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain, got %s", ct)
+	}
+	if w.Body.String() != snippet.Content {
+		t.Errorf("expected just the content, got %q", w.Body.String())
+	}
+
+	w = get("application/yaml")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/yaml") {
+		t.Errorf("expected application/yaml, got %s", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"data:\n", "  title: Formats\n", "  content: |\n    #!/bin/sh\n    echo \"hi\"\n", "meta:\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected YAML to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	w = get("application/json")
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %s", ct)
+	}
+}
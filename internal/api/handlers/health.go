@@ -6,7 +6,12 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/MohamedElashri/snipo/internal/cache"
+	"github.com/MohamedElashri/snipo/internal/cluster"
 	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
 )
 
 // HealthHandler handles health check requests
@@ -16,6 +21,10 @@ type HealthHandler struct {
 	version   string
 	commit    string
 	features  *config.FeatureFlags
+	cache     *repository.ReadCache
+	stmts     *repository.Statements
+	updates   contracts.UpdateChecker
+	cluster   *cluster.Node
 }
 
 // NewHealthHandler creates a new health handler
@@ -29,16 +38,51 @@ func NewHealthHandler(db *sql.DB, version, commit string, features *config.Featu
 	}
 }
 
+// WithCache reports the read cache's hit metrics
+func (h *HealthHandler) WithCache(c *repository.ReadCache) *HealthHandler {
+	h.cache = c
+	return h
+}
+
+// WithStatements reports the prepared statement cache's metrics
+func (h *HealthHandler) WithStatements(stmts *repository.Statements) *HealthHandler {
+	h.stmts = stmts
+	return h
+}
+
+// WithUpdates reports the update checker's latest result
+func (h *HealthHandler) WithUpdates(updates contracts.UpdateChecker) *HealthHandler {
+	h.updates = updates
+	return h
+}
+
+// WithCluster reports the instance's role and, on replicas, its primary
+func (h *HealthHandler) WithCluster(node *cluster.Node) *HealthHandler {
+	h.cluster = node
+	return h
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string            `json:"status"`
-	Version   string            `json:"version"`
-	Commit    string            `json:"commit,omitempty"`
-	Uptime    string            `json:"uptime"`
-	Checks    map[string]string `json:"checks"`
-	Memory    MemoryStats       `json:"memory"`
-	Features  *FeatureFlags     `json:"features,omitempty"`
-	Timestamp string            `json:"timestamp"`
+	Status    string                `json:"status"`
+	Version   string                `json:"version"`
+	Commit    string                `json:"commit,omitempty"`
+	Role      string                `json:"role"`
+	Primary   string                `json:"primary,omitempty"`
+	Uptime    string                `json:"uptime"`
+	Checks    map[string]string     `json:"checks"`
+	Memory    MemoryStats           `json:"memory"`
+	Database  DatabaseStats         `json:"database"`
+	Features  *FeatureFlags         `json:"features,omitempty"`
+	Cache     map[string]CacheStats `json:"cache,omitempty"`
+	Update    *models.UpdateStatus  `json:"update,omitempty"`
+	Timestamp string                `json:"timestamp"`
+}
+
+// CacheStats represents read cache metrics for one kind of data
+type CacheStats struct {
+	cache.Stats
+	HitRatio float64 `json:"hit_ratio"`
 }
 
 // FeatureFlags represents enabled features
@@ -47,6 +91,25 @@ type FeatureFlags struct {
 	S3Sync         bool `json:"s3_sync"`
 	APITokens      bool `json:"api_tokens"`
 	BackupRestore  bool `json:"backup_restore"`
+	PasteAPI       bool `json:"paste_api"`
+	VaultSync      bool `json:"vault_sync"`
+	Collab         bool `json:"collab"`
+	Badges         bool `json:"badges"`
+}
+
+// DatabaseStats represents connection pool and prepared statement metrics.
+// A growing wait count means requests queue for a connection; raise
+// SNIPO_DB_MAX_CONNS or look for slow queries.
+type DatabaseStats struct {
+	MaxOpenConnections int                        `json:"max_open_connections"`
+	OpenConnections    int                        `json:"open_connections"`
+	InUse              int                        `json:"in_use"`
+	Idle               int                        `json:"idle"`
+	WaitCount          int64                      `json:"wait_count"`
+	WaitDurationMs     int64                      `json:"wait_duration_ms"`
+	MaxIdleClosed      int64                      `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64                      `json:"max_idle_time_closed"`
+	Statements         *repository.StatementStats `json:"statements,omitempty"`
 }
 
 // MemoryStats represents memory statistics
@@ -74,10 +137,14 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
+	pool := h.db.Stats()
+
 	response := HealthResponse{
 		Status:  status,
 		Version: h.version,
 		Commit:  h.commit,
+		Role:    string(h.cluster.Role()),
+		Primary: h.cluster.PrimaryURL(),
 		Uptime:  time.Since(h.startTime).Round(time.Second).String(),
 		Checks:  checks,
 		Memory: MemoryStats{
@@ -86,9 +153,31 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 			Sys:        m.Sys / 1024 / 1024,
 			NumGC:      m.NumGC,
 		},
+		Database: DatabaseStats{
+			MaxOpenConnections: pool.MaxOpenConnections,
+			OpenConnections:    pool.OpenConnections,
+			InUse:              pool.InUse,
+			Idle:               pool.Idle,
+			WaitCount:          pool.WaitCount,
+			WaitDurationMs:     pool.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      pool.MaxIdleClosed,
+			MaxIdleTimeClosed:  pool.MaxIdleTimeClosed,
+			Statements:         h.stmts.Stats(),
+		},
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 
+	if stats := h.cache.Stats(); stats != nil {
+		response.Cache = make(map[string]CacheStats, len(stats))
+		for name, s := range stats {
+			response.Cache[name] = CacheStats{Stats: s, HitRatio: s.HitRatio()}
+		}
+	}
+
+	if h.updates != nil {
+		response.Update = h.updates.Status()
+	}
+
 	// Add feature flags if available
 	if h.features != nil {
 		response.Features = &FeatureFlags{
@@ -96,6 +185,10 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 			S3Sync:         h.features.S3Sync,
 			APITokens:      h.features.APITokens,
 			BackupRestore:  h.features.BackupRestore,
+			PasteAPI:       h.features.PasteAPI,
+			VaultSync:      h.features.VaultSync,
+			Collab:         h.features.Collab,
+			Badges:         h.features.Badges,
 		}
 	}
 
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// IconHandler serves the folder icon catalog
+type IconHandler struct{}
+
+// NewIconHandler creates a new icon handler
+func NewIconHandler() *IconHandler {
+	return &IconHandler{}
+}
+
+// List handles GET /api/v1/icons
+func (h *IconHandler) List(w http.ResponseWriter, r *http.Request) {
+	OKList(w, r, validation.GetFolderIcons())
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/urlimport"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ImportHandler handles importing snippets from external sources
+type ImportHandler struct {
+	urlSvc contracts.URLImporter
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(urlSvc contracts.URLImporter) *ImportHandler {
+	return &ImportHandler{urlSvc: urlSvc}
+}
+
+// URL handles POST /api/v1/import/url
+// Fetches a page (StackOverflow answer, blog post, raw gist, ...), extracts its
+// code blocks and creates a snippet with source_url set to the page.
+func (h *ImportHandler) URL(w http.ResponseWriter, r *http.Request) {
+	var input models.URLImportInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(input.URL) == "" {
+		ValidationErrors(w, r, validation.ValidationErrors{{Field: "url", Code: validation.CodeURLRequired, Message: "URL is required"}})
+		return
+	}
+
+	snippet, err := h.urlSvc.Import(r.Context(), input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		case errors.Is(err, urlimport.ErrInvalidURL):
+			Error(w, r, http.StatusBadRequest, "INVALID_URL", "Only absolute http and https URLs can be imported")
+		case errors.Is(err, urlimport.ErrBlockedAddress):
+			Error(w, r, http.StatusBadRequest, "BLOCKED_ADDRESS", "URL resolves to a private or reserved address")
+		case errors.Is(err, urlimport.ErrTooLarge):
+			Error(w, r, http.StatusRequestEntityTooLarge, "TOO_LARGE", "The page is too large to import")
+		case errors.Is(err, urlimport.ErrNoCode):
+			Error(w, r, http.StatusUnprocessableEntity, "NO_CODE_FOUND", "No code blocks were found at this URL")
+		case errors.Is(err, services.ErrBlockOutOfRange):
+			Error(w, r, http.StatusUnprocessableEntity, "BLOCK_OUT_OF_RANGE", "The requested code block does not exist")
+		case errors.Is(err, urlimport.ErrTooManyHops):
+			Error(w, r, http.StatusBadGateway, "FETCH_FAILED", "Too many redirects")
+		case errors.Is(err, urlimport.ErrFetchFailed):
+			Error(w, r, http.StatusBadGateway, "FETCH_FAILED", "Failed to fetch the URL")
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	Created(w, r, snippet)
+}
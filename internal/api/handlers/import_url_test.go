@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+	"github.com/MohamedElashri/snipo/internal/urlimport"
+)
+
+func setupImportHandler(t *testing.T, allowPrivate bool) *ImportHandler {
+	t.Helper()
+	db := testutil.TestDB(t)
+	snippetSvc := services.NewSnippetService(repository.NewSnippetRepository(db), testutil.TestLogger()).
+		WithTagRepo(repository.NewTagRepository(db)).
+		WithFileRepo(repository.NewSnippetFileRepository(db)).
+		WithMaxFiles(10)
+	fetcher := urlimport.NewFetcher(urlimport.Config{AllowPrivate: allowPrivate})
+	return NewImportHandler(services.NewURLImportService(fetcher, snippetSvc, testutil.TestLogger()))
+}
+
+func postImport(handler *ImportHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/url", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.URL(rec, withRequestID(req))
+	return rec
+}
+
+func TestImportHandler_URL(t *testing.T) {
+	page := `<html><head><title>Two snippets</title></head><body>
+<pre><code class="language-python">print("one")</code></pre>
+<pre><code class="language-go">fmt.Println("two")</code></pre>
+</body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	handler := setupImportHandler(t, true)
+
+	rec := postImport(handler, `{"url": "`+srv.URL+`/post", "tags": ["imported"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data models.Snippet `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	snippet := resp.Data
+	if snippet.Title != "Two snippets" {
+		t.Errorf("expected page title, got %q", snippet.Title)
+	}
+	if snippet.SourceURL == nil || *snippet.SourceURL != srv.URL+"/post" {
+		t.Errorf("expected source_url to be set, got %v", snippet.SourceURL)
+	}
+	if len(snippet.Files) != 2 || snippet.Files[0].Filename != "block-1.py" || snippet.Files[1].Language != "go" {
+		t.Errorf("unexpected files: %+v", snippet.Files)
+	}
+
+	// Importing a single block produces a single-file snippet
+	rec = postImport(handler, `{"url": "`+srv.URL+`/post", "block": 2, "title": "Just Go"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.Title != "Just Go" || resp.Data.Language != "go" {
+		t.Errorf("unexpected snippet: %+v", resp.Data)
+	}
+
+	rec = postImport(handler, `{"url": "`+srv.URL+`/post", "block": 5}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for missing block, got %d", rec.Code)
+	}
+}
+
+func TestImportHandler_URLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<p>nothing to see</p>"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		body         string
+		status       int
+		code         string
+	}{
+		{"missing url", false, `{}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"bad scheme", false, `{"url": "file:///etc/passwd"}`, http.StatusBadRequest, "INVALID_URL"},
+		{"loopback", false, `{"url": "` + srv.URL + `"}`, http.StatusBadRequest, "BLOCKED_ADDRESS"},
+		{"metadata", false, `{"url": "http://169.254.169.254/latest/meta-data/"}`, http.StatusBadRequest, "BLOCKED_ADDRESS"},
+		{"no code", true, `{"url": "` + srv.URL + `"}`, http.StatusUnprocessableEntity, "NO_CODE_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postImport(setupImportHandler(t, tt.allowPrivate), tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.code) {
+				t.Errorf("expected error code %s, got %s", tt.code, rec.Body.String())
+			}
+		})
+	}
+}
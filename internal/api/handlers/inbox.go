@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// maxInboxBodySize bounds raw text sent to the inbox: the 1MB content
+// limit plus room for a trailing newline
+const maxInboxBodySize = 1024*1024 + 2
+
+// InboxHandler handles the clipboard inbox endpoints
+type InboxHandler struct {
+	inbox contracts.Inbox
+}
+
+// NewInboxHandler creates a new inbox handler
+func NewInboxHandler(inbox contracts.Inbox) *InboxHandler {
+	return &InboxHandler{inbox: inbox}
+}
+
+// Add handles POST /api/v1/inbox
+// The request body is the raw text; query param: source (optional label)
+func (h *InboxHandler) Add(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			Error(w, r, http.StatusRequestEntityTooLarge, "CONTENT_TOO_LARGE", "Content must be less than 1MB")
+			return
+		}
+		Error(w, r, http.StatusBadRequest, "READ_ERROR", "Failed to read request body")
+		return
+	}
+
+	item, err := h.inbox.Add(r.Context(), string(body), r.URL.Query().Get("source"))
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	Created(w, r, item)
+}
+
+// List handles GET /api/v1/inbox
+func (h *InboxHandler) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.inbox.List(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, items)
+}
+
+// Delete handles DELETE /api/v1/inbox/{id}
+func (h *InboxHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid inbox item ID")
+		return
+	}
+
+	if err := h.inbox.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrInboxItemNotFound) {
+			NotFound(w, r, "Inbox item not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
+
+// Promote handles POST /api/v1/inbox/{id}/promote
+// The JSON body is optional; without a title the first line of the text is used
+func (h *InboxHandler) Promote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid inbox item ID")
+		return
+	}
+
+	var input models.InboxPromoteInput
+	if err := DecodeJSON(r, &input); err != nil && !errors.Is(err, io.EOF) {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	snippet, err := h.inbox.Promote(r.Context(), id, &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrInboxItemNotFound):
+			NotFound(w, r, "Inbox item not found")
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	Created(w, r, snippet)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// jobHeartbeatInterval keeps idle event streams open through proxies
+const jobHeartbeatInterval = 15 * time.Second
+
+// JobHandler handles background job status endpoints
+type JobHandler struct {
+	queue contracts.JobQueue
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(queue contracts.JobQueue) *JobHandler {
+	return &JobHandler{queue: queue}
+}
+
+// List handles GET /api/v1/jobs
+// Query params: kind, status (pending|running|succeeded|failed), limit (default 50, max 200)
+func (h *JobHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter := models.JobFilter{
+		Kind:   r.URL.Query().Get("kind"),
+		Status: models.JobStatus(r.URL.Query().Get("status")),
+		Limit:  50,
+	}
+
+	switch filter.Status {
+	case "", models.JobStatusPending, models.JobStatusRunning, models.JobStatusSucceeded, models.JobStatusFailed:
+	default:
+		Error(w, r, http.StatusBadRequest, "INVALID_STATUS", "status must be pending, running, succeeded or failed")
+		return
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 200 {
+			Error(w, r, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 200")
+			return
+		}
+		filter.Limit = n
+	}
+
+	list, err := h.queue.List(r.Context(), filter)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, list)
+}
+
+// Get handles GET /api/v1/jobs/{id}
+// Returns the job's status, progress and, once finished, its result.
+func (h *JobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			NotFound(w, r, "Job not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, job)
+}
+
+// Events handles GET /api/v1/jobs/{id}/events
+// Streams Server-Sent Events: "progress" with the job state on every update
+// and a final "done" event carrying the result, after which the stream ends.
+// If the server stops running the job (shutdown), the stream ends without
+// "done" and clients should reconnect.
+func (h *JobHandler) Events(w http.ResponseWriter, r *http.Request) {
+	job, updates, cancel, err := h.queue.Subscribe(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			NotFound(w, r, "Job not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	defer cancel()
+
+	// The stream lives as long as the job, past the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, job models.Job) error {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if job.Done() {
+		_ = send("done", job)
+		return
+	}
+	if err := send("progress", job); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(jobHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case update, ok := <-updates:
+			if !ok {
+				if final, err := h.queue.Get(r.Context(), job.ID); err == nil && final.Done() {
+					_ = send("done", final)
+				}
+				return
+			}
+			if err := send("progress", update); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/languages"
+)
+
+// LanguageHandler serves the snippet language catalog
+type LanguageHandler struct{}
+
+// NewLanguageHandler creates a new language handler
+func NewLanguageHandler() *LanguageHandler {
+	return &LanguageHandler{}
+}
+
+// List handles GET /api/v1/languages
+func (h *LanguageHandler) List(w http.ResponseWriter, r *http.Request) {
+	OKList(w, r, languages.List())
+}
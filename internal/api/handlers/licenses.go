@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// LicenseHandler serves the snippet license catalog
+type LicenseHandler struct{}
+
+// NewLicenseHandler creates a new license handler
+func NewLicenseHandler() *LicenseHandler {
+	return &LicenseHandler{}
+}
+
+// List handles GET /api/v1/licenses
+func (h *LicenseHandler) List(w http.ResponseWriter, r *http.Request) {
+	OKList(w, r, validation.GetLicenses())
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ListLinks handles GET /api/v1/snippets/{id}/links
+// Returns the snippet's links and the links pointing to it.
+func (h *SnippetHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	links, err := h.service.Links(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, links)
+}
+
+// CreateLink handles POST /api/v1/snippets/{id}/links
+func (h *SnippetHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.LinkInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	link, err := h.service.AddLink(r.Context(), id, &input)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	Created(w, r, link)
+}
+
+// DeleteLink handles DELETE /api/v1/snippets/{id}/links/{link_id}
+func (h *SnippetHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	linkID, err := strconv.ParseInt(chi.URLParam(r, "link_id"), 10, 64)
+	if err != nil || linkID <= 0 {
+		Error(w, r, http.StatusBadRequest, "INVALID_LINK_ID", "Invalid link ID")
+		return
+	}
+
+	if err := h.service.DeleteLink(r.Context(), id, linkID); err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrLinkNotFound) {
+			NotFound(w, r, "Link not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
+
+// ResolveLinks handles POST /api/v1/resolve-links
+// Maps wiki-style [[Title]] references to snippet IDs, optionally creating
+// stubs for unknown titles.
+func (h *SnippetHandler) ResolveLinks(w http.ResponseWriter, r *http.Request) {
+	var input models.ResolveLinksInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	result, err := h.service.ResolveLinks(r.Context(), &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, result)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/i18n"
+)
+
+// LocaleHandler serves the locales server messages are available in
+type LocaleHandler struct{}
+
+// NewLocaleHandler creates a new locale handler
+func NewLocaleHandler() *LocaleHandler {
+	return &LocaleHandler{}
+}
+
+// LocaleList is the response of GET /api/v1/locales
+type LocaleList struct {
+	Default string        `json:"default"`
+	Current string        `json:"current"` // Negotiated from Accept-Language
+	Locales []i18n.Locale `json:"locales"`
+}
+
+// List handles GET /api/v1/locales
+func (h *LocaleHandler) List(w http.ResponseWriter, r *http.Request) {
+	OK(w, r, LocaleList{
+		Default: i18n.Default,
+		Current: i18n.ForResponse(w, r),
+		Locales: i18n.Locales(),
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// AcquireLock handles POST /api/v1/snippets/{id}/lock
+// Takes the snippet's edit lock, or renews it when the body carries the
+// lock's ID. Responds 409 while another editor holds it.
+func (h *SnippetHandler) AcquireLock(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.LockInput
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &input); err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+			return
+		}
+	}
+
+	lock, err := h.service.AcquireLock(r.Context(), id, &input)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrSnippetLocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, int(time.Until(lock.ExpiresAt).Seconds())+1)))
+			Error(w, r, http.StatusConflict, "SNIPPET_LOCKED", "Another editor is editing this snippet")
+			return
+		}
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, lock)
+}
+
+// ReleaseLock handles DELETE /api/v1/snippets/{id}/lock?lock_id=
+func (h *SnippetHandler) ReleaseLock(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	lockID := r.URL.Query().Get("lock_id")
+	if lockID == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_LOCK_ID", "Lock ID is required")
+		return
+	}
+
+	if err := h.service.ReleaseLock(r.Context(), id, lockID); err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// NotificationHandler handles the notification center and notification
+// channel endpoints
+type NotificationHandler struct {
+	mailer contracts.Mailer
+	center contracts.NotificationCenter
+}
+
+// NewNotificationHandler creates a new notification handler.
+// mailer may be nil when SMTP is not configured.
+func NewNotificationHandler(mailer contracts.Mailer) *NotificationHandler {
+	return &NotificationHandler{mailer: mailer}
+}
+
+// WithCenter sets the notification center served by the list and
+// mark-read endpoints
+func (h *NotificationHandler) WithCenter(center contracts.NotificationCenter) *NotificationHandler {
+	h.center = center
+	return h
+}
+
+// List handles GET /api/v1/notifications
+// Query params: unread (true to list only unread), limit (default 50, max 200)
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h.center == nil {
+		NotFound(w, r, "Notification center is not enabled")
+		return
+	}
+
+	filter := models.NotificationFilter{Limit: 50}
+	if unread := r.URL.Query().Get("unread"); unread == "true" || unread == "1" {
+		filter.UnreadOnly = true
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 200 {
+			Error(w, r, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 200")
+			return
+		}
+		filter.Limit = n
+	}
+
+	list, err := h.center.List(r.Context(), filter)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, list)
+}
+
+// UnreadCount handles GET /api/v1/notifications/unread-count
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	if h.center == nil {
+		NotFound(w, r, "Notification center is not enabled")
+		return
+	}
+
+	count, err := h.center.CountUnread(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, map[string]int{"unread": count})
+}
+
+// MarkRead handles POST /api/v1/notifications/{id}/read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	if h.center == nil {
+		NotFound(w, r, "Notification center is not enabled")
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid notification ID")
+		return
+	}
+
+	notification, err := h.center.MarkRead(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			NotFound(w, r, "Notification not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, notification)
+}
+
+// MarkAllRead handles POST /api/v1/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	if h.center == nil {
+		NotFound(w, r, "Notification center is not enabled")
+		return
+	}
+
+	marked, err := h.center.MarkAllRead(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, map[string]int64{"marked": marked})
+}
+
+// TestEmail sends a test message to the configured recipients
+func (h *NotificationHandler) TestEmail(w http.ResponseWriter, r *http.Request) {
+	if h.mailer == nil {
+		Error(w, r, http.StatusServiceUnavailable, "SMTP_NOT_CONFIGURED", "Email notifications are not configured")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	event := notify.Event{
+		Type:    notify.EventTest,
+		Title:   "Test email",
+		Message: "This is a test message from Snipo. Email notifications are working.",
+		Time:    time.Now().UTC(),
+	}
+	if err := h.mailer.Notify(ctx, event); err != nil {
+		Error(w, r, http.StatusBadGateway, "EMAIL_FAILED", err.Error())
+		return
+	}
+
+	OK(w, r, map[string]any{
+		"sent":       true,
+		"recipients": h.mailer.Recipients(),
+	})
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// MaxPasteSize matches the snippet content limit
+const MaxPasteSize = 1024 * 1024
+
+// PasteTag is attached to snippets created through the paste API
+const PasteTag = "paste"
+
+// pasteExtensions maps common file extensions to snippet languages
+var pasteExtensions = map[string]string{
+	"js": "javascript", "ts": "typescript", "py": "python", "go": "go", "rs": "rust",
+	"java": "java", "c": "c", "h": "c", "cpp": "cpp", "cs": "csharp", "php": "php",
+	"rb": "ruby", "swift": "swift", "kt": "kotlin", "scala": "scala", "html": "html",
+	"css": "css", "scss": "scss", "json": "json", "yaml": "yaml", "yml": "yaml",
+	"xml": "xml", "md": "markdown", "sql": "sql", "sh": "bash", "bash": "bash",
+	"ps1": "powershell", "toml": "toml", "ini": "ini", "lua": "lua", "pl": "perl",
+	"r": "r", "hs": "haskell", "ex": "elixir", "clj": "clojure", "graphql": "graphql",
+	"proto": "protobuf", "tf": "terraform", "txt": "plaintext",
+}
+
+// PasteHandler implements a hastebin/pastebin compatible API on top of snippets
+type PasteHandler struct {
+	service        contracts.SnippetService
+	publicCacheAge time.Duration
+	analytics      contracts.ShareAnalytics
+}
+
+// NewPasteHandler creates a new paste handler
+func NewPasteHandler(service contracts.SnippetService) *PasteHandler {
+	return &PasteHandler{service: service}
+}
+
+// WithShareAnalytics counts views of public documents
+func (h *PasteHandler) WithShareAnalytics(analytics contracts.ShareAnalytics) *PasteHandler {
+	h.analytics = analytics
+	return h
+}
+
+// WithPublicCache lets caches keep documents for maxAge. Zero makes them
+// revalidate on every request.
+func (h *PasteHandler) WithPublicCache(maxAge time.Duration) *PasteHandler {
+	h.publicCacheAge = maxAge
+	return h
+}
+
+// pasteResponse mirrors the hastebin create/get response (no envelope)
+type pasteResponse struct {
+	Key  string `json:"key"`
+	Data string `json:"data,omitempty"`
+}
+
+// Create handles POST /documents
+// The raw request body becomes a public snippet. Form posts (field "data",
+// "content" or ix-style "f:1") are accepted too and answered with the raw URL
+// as plain text. Optional query parameters: title, lang, filename.
+func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxPasteSize+64*1024)
+
+	content, isForm, err := readPasteContent(r)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			Error(w, r, http.StatusRequestEntityTooLarge, "PASTE_TOO_LARGE", "Paste must be less than 1MB")
+			return
+		}
+		Error(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read paste content")
+		return
+	}
+	if strings.TrimSpace(content) == "" {
+		Error(w, r, http.StatusBadRequest, "EMPTY_PASTE", "Paste content is required")
+		return
+	}
+
+	q := r.URL.Query()
+	input := &models.SnippetInput{
+		Title:    pasteTitle(q.Get("title"), content),
+		Content:  content,
+		Language: pasteLanguage(q.Get("lang"), q.Get("filename")),
+		Tags:     []string{PasteTag},
+		IsPublic: true,
+	}
+
+	snippet, err := h.service.Create(r.Context(), input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	if isForm {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "%s://%s/raw/%s\n", scheme(r), r.Host, snippet.ID)
+		return
+	}
+
+	JSON(w, http.StatusOK, pasteResponse{Key: snippet.ID})
+}
+
+// Get handles GET /documents/{key}
+func (h *PasteHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key, snippet, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	setRobotsTag(w, snippet)
+	if checkPublicCache(w, r, snippet, "document", h.publicCacheAge) {
+		return
+	}
+	JSON(w, http.StatusOK, pasteResponse{Key: key, Data: pasteBody(snippet)})
+}
+
+// Raw handles GET /raw/{key}
+func (h *PasteHandler) Raw(w http.ResponseWriter, r *http.Request) {
+	_, snippet, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	setRobotsTag(w, snippet)
+	if checkPublicCache(w, r, snippet, "raw", h.publicCacheAge) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, pasteBody(snippet))
+}
+
+// lookup resolves {key} (an optional extension is ignored, as in hastebin)
+// to a public snippet, or any snippet through a signed URL, writing a JSON
+// error when not found
+func (h *PasteHandler) lookup(w http.ResponseWriter, r *http.Request) (string, *models.Snippet, bool) {
+	key := chi.URLParam(r, "key")
+	key = strings.TrimSuffix(key, path.Ext(key))
+	if key == "" {
+		JSON(w, http.StatusNotFound, map[string]string{"message": "Document not found."})
+		return "", nil, false
+	}
+
+	var snippet *models.Snippet
+	var err error
+	if middleware.SignedSnippetID(r.Context()) == key {
+		snippet, err = h.service.GetShared(r.Context(), key)
+	} else {
+		snippet, err = h.service.GetByIDPublic(r.Context(), key)
+		if err == nil {
+			recordShareView(h.analytics, r, snippet.ID)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			JSON(w, http.StatusNotFound, map[string]string{"message": "Document not found."})
+			return "", nil, false
+		}
+		InternalError(w, r)
+		return "", nil, false
+	}
+	return key, snippet, true
+}
+
+// pasteFormFields are the form fields checked for paste content, in order
+var pasteFormFields = []string{"data", "content", "f:1"}
+
+// readPasteContent extracts the paste from the raw body or a form field.
+// Many clients (e.g. curl -d) label a raw body as form-encoded, so such bodies
+// are only treated as forms when they contain a known field.
+func readPasteContent(r *http.Request) (string, bool, error) {
+	ct := r.Header.Get("Content-Type")
+	if strings.HasPrefix(ct, "multipart/form-data") {
+		if err := r.ParseMultipartForm(MaxPasteSize); err != nil {
+			return "", true, err
+		}
+		for _, field := range pasteFormFields {
+			if v := r.FormValue(field); v != "" {
+				return v, true, nil
+			}
+		}
+		// The paste may be sent as a file upload
+		for _, field := range append(pasteFormFields, "file") {
+			if f, _, err := r.FormFile(field); err == nil {
+				defer func() {
+					_ = f.Close()
+				}()
+				b, err := io.ReadAll(f)
+				return string(b), true, err
+			}
+		}
+		return "", true, nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", false, err
+	}
+	if strings.HasPrefix(ct, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(b)); err == nil {
+			for _, field := range pasteFormFields {
+				if v := values.Get(field); v != "" {
+					return v, true, nil
+				}
+			}
+		}
+	}
+	return string(b), false, nil
+}
+
+// pasteTitle uses the given title or the first non-empty line of the content
+func pasteTitle(title, content string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		for _, line := range strings.Split(content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				title = line
+				break
+			}
+		}
+	}
+	if !utf8.ValidString(title) || title == "" {
+		title = "Paste " + time.Now().UTC().Format("2006-01-02 15:04")
+	}
+	if utf8.RuneCountInString(title) > 80 {
+		title = string([]rune(title)[:77]) + "..."
+	}
+	return title
+}
+
+// pasteLanguage picks a language from an explicit name or a filename extension
+func pasteLanguage(lang, filename string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" && filename != "" {
+		lang = strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+	}
+	if mapped, ok := pasteExtensions[lang]; ok {
+		return mapped
+	}
+	for _, allowed := range validation.GetAllowedLanguages() {
+		if lang == allowed {
+			return lang
+		}
+	}
+	return "plaintext"
+}
+
+// pasteBody returns the primary content of a snippet
+func pasteBody(snippet *models.Snippet) string {
+	if len(snippet.Files) > 0 {
+		return snippet.Files[0].Content
+	}
+	return snippet.Content
+}
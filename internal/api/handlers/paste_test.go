@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func setupPasteHandler(t *testing.T) *PasteHandler {
+	t.Helper()
+	db := testutil.TestDB(t)
+	service := services.NewSnippetService(repository.NewSnippetRepository(db), testutil.TestLogger()).
+		WithTagRepo(repository.NewTagRepository(db)).
+		WithFileRepo(repository.NewSnippetFileRepository(db)).
+		WithMaxFiles(10)
+	return NewPasteHandler(service)
+}
+
+func TestPasteHandler_CreateAndRaw(t *testing.T) {
+	handler := setupPasteHandler(t)
+
+	body := "package main\n\nfunc main() {}\n"
+	req := httptest.NewRequest(http.MethodPost, "/documents?filename=main.go", strings.NewReader(body))
+	req = withRequestID(req)
+	rec := httptest.NewRecorder()
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var created pasteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Key == "" {
+		t.Fatal("expected a key in the response")
+	}
+
+	// hastebin clients may append an extension to the key
+	req = httptest.NewRequest(http.MethodGet, "/raw/"+created.Key+".go", nil)
+	req = withChiURLParams(withRequestID(req), map[string]string{"key": created.Key + ".go"})
+	rec = httptest.NewRecorder()
+	handler.Raw(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != body {
+		t.Errorf("raw content mismatch: got %q", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain, got %q", ct)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/documents/"+created.Key, nil)
+	req = withChiURLParams(withRequestID(req), map[string]string{"key": created.Key})
+	rec = httptest.NewRecorder()
+	handler.Get(rec, req)
+
+	var doc pasteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if doc.Key != created.Key || doc.Data != body {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
+
+func TestPasteHandler_CreateForm(t *testing.T) {
+	handler := setupPasteHandler(t)
+
+	form := url.Values{"f:1": {"echo hello"}}
+	req := httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = withRequestID(req)
+	rec := httptest.NewRecorder()
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); !strings.HasPrefix(got, "http://example.com/raw/") {
+		t.Errorf("expected raw URL in plain-text response, got %q", got)
+	}
+}
+
+func TestPasteHandler_CreateEmpty(t *testing.T) {
+	handler := setupPasteHandler(t)
+
+	req := withRequestID(httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader("  \n")))
+	rec := httptest.NewRecorder()
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestPasteHandler_RawNotFound(t *testing.T) {
+	handler := setupPasteHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/raw/missing", nil)
+	req = withChiURLParams(withRequestID(req), map[string]string{"key": "missing"})
+	rec := httptest.NewRecorder()
+	handler.Raw(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestPasteLanguage(t *testing.T) {
+	tests := []struct {
+		lang, filename, want string
+	}{
+		{"", "script.py", "python"},
+		{"GO", "", "go"},
+		{"yml", "", "yaml"},
+		{"", "notes", "plaintext"},
+		{"brainfuck", "", "plaintext"},
+	}
+	for _, tt := range tests {
+		if got := pasteLanguage(tt.lang, tt.filename); got != tt.want {
+			t.Errorf("pasteLanguage(%q, %q) = %q, want %q", tt.lang, tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestPasteHandler_RawCaching(t *testing.T) {
+	handler := setupPasteHandler(t).WithPublicCache(5 * time.Minute)
+
+	req := httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader("echo cached"))
+	rec := httptest.NewRecorder()
+	handler.Create(rec, withRequestID(req))
+	var created pasteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	raw := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/raw/"+created.Key, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		req = withChiURLParams(withRequestID(req), map[string]string{"key": created.Key})
+		rec := httptest.NewRecorder()
+		handler.Raw(rec, req)
+		return rec
+	}
+
+	rec = raw("", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=300" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+	etag, lastModified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected validators, got ETag %q, Last-Modified %q", etag, lastModified)
+	}
+
+	if rec := raw("If-None-Match", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+	if rec := raw("If-None-Match", `W/"other"`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for other ETag, got %d", rec.Code)
+	}
+	if rec := raw("If-Modified-Since", lastModified); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when not modified since, got %d", rec.Code)
+	}
+
+	// Documents are a separate representation with their own ETag
+	req = httptest.NewRequest(http.MethodGet, "/documents/"+created.Key, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.Get(rec, withChiURLParams(withRequestID(req), map[string]string{"key": created.Key}))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected raw ETag not to match the document, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+)
+
+// maxLookupQuery bounds the length of quick-open and autocomplete queries
+// in characters
+const maxLookupQuery = 100
+
+// QuickOpenHandler serves fuzzy title and filename lookups for quick
+// navigation
+type QuickOpenHandler struct {
+	quickOpen contracts.QuickOpen
+}
+
+// NewQuickOpenHandler creates a new quick-open handler
+func NewQuickOpenHandler(quickOpen contracts.QuickOpen) *QuickOpenHandler {
+	return &QuickOpenHandler{quickOpen: quickOpen}
+}
+
+// Search handles GET /api/v1/quick-open
+// Query params: q, limit (default 20, max 50)
+func (h *QuickOpenHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if utf8.RuneCountInString(query) > maxLookupQuery {
+		Error(w, r, http.StatusBadRequest, "QUERY_TOO_LONG", "Query must be at most 100 characters")
+		return
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	results, err := h.quickOpen.Search(r.Context(), query, limit)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, results)
+}
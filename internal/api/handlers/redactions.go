@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// RedactionHandler manages redaction rules
+type RedactionHandler struct {
+	redactions contracts.Redactions
+}
+
+// NewRedactionHandler creates a new redaction rule handler
+func NewRedactionHandler(redactions contracts.Redactions) *RedactionHandler {
+	return &RedactionHandler{redactions: redactions}
+}
+
+// List handles GET /api/v1/redactions
+func (h *RedactionHandler) List(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.redactions.List(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, rules)
+}
+
+// Create handles POST /api/v1/redactions
+func (h *RedactionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.RedactionRuleInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.redactions.Create(r.Context(), &input)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	Created(w, r, rule)
+}
+
+// Get handles GET /api/v1/redactions/{id}
+func (h *RedactionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := redactionID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.redactions.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, rule)
+}
+
+// Update handles PUT /api/v1/redactions/{id}
+func (h *RedactionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := redactionID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.RedactionRuleInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.redactions.Update(r.Context(), id, &input)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, rule)
+}
+
+// Delete handles DELETE /api/v1/redactions/{id}
+func (h *RedactionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := redactionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.redactions.Delete(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	NoContent(w)
+}
+
+// Preview handles POST /api/v1/redactions/preview
+// Returns the content as the enabled rules would mask it when shared.
+func (h *RedactionHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var input models.RedactionPreviewInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	preview, err := h.redactions.Preview(r.Context(), input.Content)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OK(w, r, preview)
+}
+
+func (h *RedactionHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrs validation.ValidationErrors
+	switch {
+	case errors.Is(err, services.ErrRedactionRuleNotFound):
+		NotFound(w, r, "Redaction rule not found")
+	case errors.As(err, &validationErrs):
+		ValidationErrors(w, r, validationErrs)
+	default:
+		InternalError(w, r)
+	}
+}
+
+// redactionID parses the {id} URL parameter, writing an error if it is invalid
+func redactionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid redaction rule ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ReportHandler handles abuse reports and the moderation queue
+type ReportHandler struct {
+	moderation contracts.Moderation
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(moderation contracts.Moderation) *ReportHandler {
+	return &ReportHandler{moderation: moderation}
+}
+
+// Challenge handles GET /s/{id}/report/challenge
+// Responds with a challenge to solve before reporting, or 204 No Content
+// when reports do not need one.
+func (h *ReportHandler) Challenge(w http.ResponseWriter, r *http.Request) {
+	challenge := h.moderation.Challenge()
+	if challenge == nil {
+		NoContent(w)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	OK(w, r, challenge)
+}
+
+// Report handles POST /s/{id}/report
+// {id} may also be a slug. Responds 202 whether or not the same address
+// had already reported the snippet.
+func (h *ReportHandler) Report(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.ReportInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	if err := h.moderation.Report(r.Context(), id, middleware.ClientIP(r), &input); err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrSnippetNotFound):
+			NotFound(w, r, "Snippet not found")
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	Success(w, r, http.StatusAccepted, map[string]string{"status": "received"})
+}
+
+// List handles GET /api/v1/reports
+// Query params: status (open (default), unpublished, dismissed or all), limit (default 50, max 200)
+func (h *ReportHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter := models.ReportFilter{Status: r.URL.Query().Get("status"), Limit: 50}
+	switch filter.Status {
+	case "", "all", models.ReportOpen, models.ReportUnpublished, models.ReportDismissed:
+	default:
+		Error(w, r, http.StatusBadRequest, "INVALID_STATUS", "Status must be open, unpublished, dismissed or all")
+		return
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		filter.Limit = l
+	}
+
+	reports, err := h.moderation.List(r.Context(), filter)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, reports)
+}
+
+// Unpublish handles POST /api/v1/reports/{id}/unpublish
+// Makes the reported snippet private and closes all open reports about it.
+func (h *ReportHandler) Unpublish(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.moderation.Unpublish)
+}
+
+// Dismiss handles POST /api/v1/reports/{id}/dismiss
+// Closes all open reports about the snippet, which stays public.
+func (h *ReportHandler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.moderation.Dismiss)
+}
+
+func (h *ReportHandler) resolve(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id int64) (*models.SnippetReport, error)) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid report ID")
+		return
+	}
+
+	report, err := action(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrReportNotFound) {
+			NotFound(w, r, "Report not found")
+			return
+		}
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, report)
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/i18n"
+	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/validation"
 )
 
@@ -77,6 +81,7 @@ type ListResponse struct {
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
+	Meta  *Meta       `json:"meta,omitempty"` // Always set in v2
 }
 
 // ErrorDetail contains error details
@@ -94,10 +99,32 @@ func getMeta(r *http.Request) *Meta {
 	return &Meta{
 		RequestID: requestID,
 		Timestamp: time.Now().UTC(),
-		Version:   APIVersion,
+		Version:   apiVersion(r),
 	}
 }
 
+// isV2 reports whether the request came in under /api/v2
+func isV2(r *http.Request) bool {
+	return middleware.GetAPIVersion(r.Context()) == 2
+}
+
+// apiVersion is the meta.version reported for a request
+func apiVersion(r *http.Request) string {
+	if isV2(r) {
+		return middleware.APIVersion2
+	}
+	return APIVersion
+}
+
+// apiPath returns an API path under the version prefix the request used,
+// e.g. apiPath(r, "/jobs/abc") is "/api/v2/jobs/abc" for a v2 request
+func apiPath(r *http.Request, path string) string {
+	if isV2(r) {
+		return "/api/v2" + path
+	}
+	return "/api/v1" + path
+}
+
 // buildPaginationLinks generates navigation links for pagination
 func buildPaginationLinks(r *http.Request, page, limit, total int) *PaginationLinks {
 	baseURL := fmt.Sprintf("%s://%s%s", scheme(r), r.Host, r.URL.Path)
@@ -161,7 +188,18 @@ func Success(w http.ResponseWriter, r *http.Request, status int, data interface{
 		Data: data,
 		Meta: getMeta(r),
 	}
-	JSON(w, status, response)
+	writeEnvelope(w, r, status, response)
+}
+
+// writeEnvelope sends a success envelope as JSON, or as YAML when the
+// Accept header asks for it
+func writeEnvelope(w http.ResponseWriter, r *http.Request, status int, envelope interface{}) {
+	varyAccept(w)
+	if negotiateFormat(r, false) == formatYAML {
+		writeYAML(w, status, envelope)
+		return
+	}
+	JSON(w, status, envelope)
 }
 
 // SuccessList sends a standardized list response with pagination
@@ -182,31 +220,50 @@ func SuccessList(w http.ResponseWriter, r *http.Request, data interface{}, page,
 		},
 		Meta: getMeta(r),
 	}
-	JSON(w, http.StatusOK, response)
+	writeEnvelope(w, r, http.StatusOK, response)
 }
 
-// Error sends an error response
+// Error sends an error response, with the message translated to the
+// request's Accept-Language
 func Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
-	JSON(w, status, ErrorResponse{
+	locale := i18n.ForResponse(w, r)
+	response := ErrorResponse{
 		Error: ErrorDetail{
 			Code:    code,
-			Message: message,
+			Message: i18n.T(locale, message),
 		},
-	})
+	}
+	if isV2(r) {
+		response.Meta = getMeta(r)
+		response.Error.RequestID = response.Meta.RequestID
+	}
+	JSON(w, status, response)
 }
 
-// ValidationErrors sends a validation error response
+// ValidationErrors sends a validation error response. Messages are translated
+// to the request's Accept-Language; codes and params stay as they are.
 func ValidationErrors(w http.ResponseWriter, r *http.Request, errors validation.ValidationErrors) {
+	locale := i18n.ForResponse(w, r)
+	details := make([]validation.ValidationError, len(errors))
+	for i, e := range errors {
+		e.Message = i18n.T(locale, e.Message)
+		details[i] = e
+	}
+
 	meta := getMeta(r)
-	JSON(w, http.StatusBadRequest, ErrorResponse{
+	response := ErrorResponse{
 		Error: ErrorDetail{
 			Code:      "VALIDATION_ERROR",
-			Message:   "Invalid request payload",
-			Details:   errors,
+			Message:   i18n.T(locale, "Invalid request payload"),
+			Details:   details,
 			RequestID: meta.RequestID,
 			Timestamp: meta.Timestamp,
 		},
-	})
+	}
+	if isV2(r) {
+		response.Meta = meta
+	}
+	JSON(w, http.StatusBadRequest, response)
 }
 
 // NotFound sends a 404 response
@@ -227,8 +284,13 @@ func Forbidden(w http.ResponseWriter, r *http.Request) {
 	Error(w, r, http.StatusForbidden, "FORBIDDEN", "Access denied")
 }
 
-// InternalError sends a 500 response
+// InternalError sends a 500 response, or a 504 when the request ran out of
+// its time budget, since the cancelled queries are then what failed
 func InternalError(w http.ResponseWriter, r *http.Request) {
+	if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		Error(w, r, http.StatusGatewayTimeout, "REQUEST_TIMEOUT", "The request took too long")
+		return
+	}
 	Error(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred")
 }
 
@@ -246,3 +308,42 @@ func NoContent(w http.ResponseWriter) {
 func OK(w http.ResponseWriter, r *http.Request, data interface{}) {
 	Success(w, r, http.StatusOK, data)
 }
+
+// OKSnippet sends a snippet, or just its content when the Accept header
+// prefers text/plain
+func OKSnippet(w http.ResponseWriter, r *http.Request, snippet *models.Snippet) {
+	if negotiateFormat(r, true) == formatText {
+		varyAccept(w)
+		if snippet.ContentWithheld {
+			checkoutRequired(w, r)
+			return
+		}
+		writeText(w, http.StatusOK, snippet.Content)
+		return
+	}
+	OK(w, r, snippet)
+}
+
+// OKList sends a 200 response for a list that is returned in full. v1 sends
+// the bare list like OK; v2 sends the same shape as paginated lists, with
+// everything on one page.
+func OKList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	if !isV2(r) {
+		OK(w, r, items)
+		return
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+	writeEnvelope(w, r, http.StatusOK, ListResponse{
+		Data: items,
+		Pagination: &Pagination{
+			Page:       1,
+			Limit:      len(items),
+			Total:      len(items),
+			TotalPages: 1,
+		},
+		Meta: getMeta(r),
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// DefaultRobotsTxt lets crawlers reach share pages, public collections and
+// what they load, and keeps them out of everything else
+const DefaultRobotsTxt = `User-agent: *
+Allow: /s/
+Allow: /c/
+Allow: /static/
+Allow: /api/v1/snippets/public/
+Allow: /api/v1/public/folders/
+Disallow: /
+`
+
+// RobotsHandler serves robots.txt
+type RobotsHandler struct {
+	content string
+}
+
+// NewRobotsHandler creates a robots.txt handler serving content, or
+// DefaultRobotsTxt when it is empty
+func NewRobotsHandler(content string) *RobotsHandler {
+	if content == "" {
+		content = DefaultRobotsTxt
+	}
+	return &RobotsHandler{content: content}
+}
+
+// Serve handles GET /robots.txt
+func (h *RobotsHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(h.content))
+}
+
+// setRobotsTag asks search engines not to index a public snippet response
+// when the snippet is flagged noindex
+func setRobotsTag(w http.ResponseWriter, snippet *models.Snippet) {
+	if snippet.NoIndex {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+}
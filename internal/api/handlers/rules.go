@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// RuleHandler manages automation rules
+type RuleHandler struct {
+	rules contracts.Rules
+}
+
+// NewRuleHandler creates a new rule handler
+func NewRuleHandler(rules contracts.Rules) *RuleHandler {
+	return &RuleHandler{rules: rules}
+}
+
+// List handles GET /api/v1/rules
+func (h *RuleHandler) List(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.rules.List(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, rules)
+}
+
+// Create handles POST /api/v1/rules
+func (h *RuleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.RuleInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.rules.Create(r.Context(), &input)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	Created(w, r, rule)
+}
+
+// Get handles GET /api/v1/rules/{id}
+func (h *RuleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.rules.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, rule)
+}
+
+// Update handles PUT /api/v1/rules/{id}
+func (h *RuleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.RuleInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	rule, err := h.rules.Update(r.Context(), id, &input)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, rule)
+}
+
+// Delete handles DELETE /api/v1/rules/{id}
+func (h *RuleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.rules.Delete(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	NoContent(w)
+}
+
+// Runs handles GET /api/v1/rules/{id}/runs
+// Query params: limit (default 50, max 200)
+func (h *RuleHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+
+	runs, err := h.rules.Runs(r.Context(), id, limit)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OKList(w, r, runs)
+}
+
+func (h *RuleHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrs validation.ValidationErrors
+	switch {
+	case errors.Is(err, services.ErrRuleNotFound):
+		NotFound(w, r, "Rule not found")
+	case errors.As(err, &validationErrs):
+		ValidationErrors(w, r, validationErrs)
+	default:
+		InternalError(w, r)
+	}
+}
+
+// ruleID parses the {id} URL parameter, writing an error if it is invalid
+func ruleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid rule ID")
+		return 0, false
+	}
+	return id, true
+}
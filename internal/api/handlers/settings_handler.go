@@ -2,22 +2,32 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/MohamedElashri/snipo/internal/contracts"
 	"github.com/MohamedElashri/snipo/internal/models"
-	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
 )
 
 // SettingsHandler handles settings related endpoints
 type SettingsHandler struct {
-	repo *repository.SettingsRepository
+	repo     contracts.SettingsRepository
+	snippets contracts.SnippetService
 }
 
 // NewSettingsHandler creates a new settings handler
-func NewSettingsHandler(repo *repository.SettingsRepository) *SettingsHandler {
+func NewSettingsHandler(repo contracts.SettingsRepository) *SettingsHandler {
 	return &SettingsHandler{repo: repo}
 }
 
+// WithSnippets sets the snippet service used to check the announcement snippet
+func (h *SettingsHandler) WithSnippets(snippets contracts.SnippetService) *SettingsHandler {
+	h.snippets = snippets
+	return h
+}
+
 // Get retrieves application settings
 func (h *SettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
 	settings, err := h.repo.Get(r.Context())
@@ -37,7 +47,28 @@ func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Basic validation could go here
+	if input.Validation != nil {
+		if errs := validation.ValidateRules(input.Validation); errs.HasErrors() {
+			ValidationErrors(w, r, errs)
+			return
+		}
+	}
+
+	// The announcement must point at an existing snippet
+	if input.AnnouncementSnippetID != nil && *input.AnnouncementSnippetID != "" && h.snippets != nil {
+		if _, err := h.snippets.GetByID(r.Context(), *input.AnnouncementSnippetID); err != nil {
+			if errors.Is(err, services.ErrSnippetNotFound) {
+				ValidationErrors(w, r, validation.ValidationErrors{{
+					Field:   "announcement_snippet_id",
+					Code:    validation.CodeAnnouncementNotFound,
+					Message: "Announcement snippet not found",
+				}})
+				return
+			}
+			InternalError(w, r)
+			return
+		}
+	}
 
 	updated, err := h.repo.Update(r.Context(), &input)
 	if err != nil {
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// PreviewImage handles GET /s/{id}/preview.png
+// Draws the link preview card of a published snippet (by ID or slug), the
+// og:image of its share page. Fetching it does not count a view.
+func (h *SnippetHandler) PreviewImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	snippet, err := h.service.SharePreview(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	setRobotsTag(w, snippet)
+	if checkPublicCache(w, r, snippet, "preview.png", h.publicCacheAge) {
+		return
+	}
+
+	image, err := services.PreviewImage(snippet)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(image)
+}
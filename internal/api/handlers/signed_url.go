@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// SignedURL handles POST /api/v1/snippets/{id}/signed-url
+// Returns a URL that fetches the snippet without credentials, even when it
+// is private, until it expires. Meant for CI jobs and scripts that should
+// not hold a long-lived API token.
+func (h *SnippetHandler) SignedURL(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if h.signer == nil {
+		NotFound(w, r, "Signed URLs are not available")
+		return
+	}
+
+	var input models.SignedURLInput
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &input); err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+			return
+		}
+	}
+	if errs := validation.ValidateSignedURLInput(&input); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
+		return
+	}
+
+	// Only sign URLs for snippets that exist, so the ID in the URL is
+	// always the snippet's real one
+	snippet, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+	if snippet.RequiresCheckout {
+		Error(w, r, http.StatusConflict, "SNIPPET_NOT_SHAREABLE", "Snippets that require a check-out cannot be shared")
+		return
+	}
+
+	expires := time.Now().Add(time.Duration(input.TTL) * time.Second).Truncate(time.Second)
+	query := url.Values{
+		"expires":   {fmt.Sprint(expires.Unix())},
+		"signature": {h.signer.SignSnippetURL(snippet.ID, expires)},
+	}.Encode()
+	base := scheme(r) + "://" + r.Host
+
+	result := models.SignedURL{
+		URL:       base + "/api/v1/snippets/public/" + url.PathEscape(snippet.ID) + "?" + query,
+		ExpiresAt: expires.UTC(),
+	}
+	if h.rawURLs {
+		result.RawURL = base + "/raw/" + url.PathEscape(snippet.ID) + "?" + query
+	}
+	OK(w, r, result)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// SnapshotHandler serves the daily library snapshots
+type SnapshotHandler struct {
+	snapshots contracts.Snapshots
+}
+
+// NewSnapshotHandler creates a new snapshot handler
+func NewSnapshotHandler(snapshots contracts.Snapshots) *SnapshotHandler {
+	return &SnapshotHandler{snapshots: snapshots}
+}
+
+// List handles GET /api/v1/snapshots
+// Query params: limit (default 60, max 400)
+func (h *SnapshotHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 60
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 400 {
+		limit = l
+	}
+
+	snapshots, err := h.snapshots.List(r.Context(), limit)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	OKList(w, r, snapshots)
+}
+
+// Take handles POST /api/v1/snapshots
+// Takes today's snapshot now, replacing one already taken today.
+func (h *SnapshotHandler) Take(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.snapshots.Take(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	Created(w, r, snapshot)
+}
+
+// Get handles GET /api/v1/snapshots/{day}
+// Returns the library as of day: the latest snapshot taken on or before it.
+func (h *SnapshotHandler) Get(w http.ResponseWriter, r *http.Request) {
+	day := chi.URLParam(r, "day")
+	if !validDay(w, r, day) {
+		return
+	}
+
+	snapshot, err := h.snapshots.AsOf(r.Context(), day)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, snapshot)
+}
+
+// Diff handles GET /api/v1/snapshots/diff
+// Query params: from (required), to (default today). Compares the library
+// as of each day.
+func (h *SnapshotHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = time.Now().UTC().Format(time.DateOnly)
+	}
+	if from == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_DATE", "The from date is required")
+		return
+	}
+	if !validDay(w, r, from) || !validDay(w, r, to) {
+		return
+	}
+	if from > to {
+		Error(w, r, http.StatusBadRequest, "INVALID_RANGE", "The period must not end before it starts")
+		return
+	}
+
+	diff, err := h.snapshots.Diff(r.Context(), from, to)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	OK(w, r, diff)
+}
+
+func (h *SnapshotHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrSnapshotNotFound) {
+		NotFound(w, r, "No snapshot was taken on or before that day")
+		return
+	}
+	InternalError(w, r)
+}
+
+// validDay checks that day is formatted as YYYY-MM-DD, writing the error
+// response when it is not
+func validDay(w http.ResponseWriter, r *http.Request, day string) bool {
+	if _, err := time.Parse(time.DateOnly, day); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_DATE", "Dates must be formatted as YYYY-MM-DD")
+		return false
+	}
+	return true
+}
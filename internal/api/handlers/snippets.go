@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/MohamedElashri/snipo/internal/api/middleware"
+	"github.com/MohamedElashri/snipo/internal/contracts"
 	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/services"
 	"github.com/MohamedElashri/snipo/internal/validation"
@@ -15,14 +21,39 @@ import (
 
 // SnippetHandler handles snippet-related HTTP requests
 type SnippetHandler struct {
-	service *services.SnippetService
+	service        contracts.SnippetService
+	publicCacheAge time.Duration
+	signer         contracts.URLSigner
+	rawURLs        bool
+	analytics      contracts.ShareAnalytics
 }
 
 // NewSnippetHandler creates a new snippet handler
-func NewSnippetHandler(service *services.SnippetService) *SnippetHandler {
+func NewSnippetHandler(service contracts.SnippetService) *SnippetHandler {
 	return &SnippetHandler{service: service}
 }
 
+// WithPublicCache lets caches keep public snippet responses for maxAge.
+// Zero makes them revalidate on every request.
+func (h *SnippetHandler) WithPublicCache(maxAge time.Duration) *SnippetHandler {
+	h.publicCacheAge = maxAge
+	return h
+}
+
+// WithURLSigner enables signed URLs. rawURLs adds a /raw link to them, for
+// when the paste API serves that route.
+func (h *SnippetHandler) WithURLSigner(signer contracts.URLSigner, rawURLs bool) *SnippetHandler {
+	h.signer = signer
+	h.rawURLs = rawURLs
+	return h
+}
+
+// WithShareAnalytics counts views of public snippets
+func (h *SnippetHandler) WithShareAnalytics(analytics contracts.ShareAnalytics) *SnippetHandler {
+	h.analytics = analytics
+	return h
+}
+
 // List handles GET /api/v1/snippets
 func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
 	filter := models.DefaultSnippetFilter()
@@ -48,6 +79,14 @@ func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
 		filter.Language = lang
 	}
 
+	if license := r.URL.Query().Get("license"); license != "" {
+		filter.License = license
+	}
+
+	if state := r.URL.Query().Get("review_state"); models.IsReviewState(state) {
+		filter.ReviewState = state
+	}
+
 	if fav := r.URL.Query().Get("favorite"); fav != "" {
 		isFav := fav == "true" || fav == "1"
 		filter.IsFavorite = &isFav
@@ -74,6 +113,15 @@ func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Tags by name or alias (tags=javascript,js)
+	if tags := r.URL.Query().Get("tags"); tags != "" {
+		for _, name := range strings.Split(tags, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.TagNames = append(filter.TagNames, name)
+			}
+		}
+	}
+
 	if folderID := r.URL.Query().Get("folder_id"); folderID != "" {
 		if id, err := strconv.ParseInt(folderID, 10, 64); err == nil && id > 0 {
 			filter.FolderID = id
@@ -90,6 +138,18 @@ func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Custom metadata filters (metadata.ticket=JIRA-123)
+	for param, values := range r.URL.Query() {
+		key := strings.TrimPrefix(param, "metadata.")
+		if key == param || key == "" || len(values) == 0 {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
+
 	if sortBy := r.URL.Query().Get("sort"); sortBy != "" {
 		filter.SortBy = sortBy
 	}
@@ -98,12 +158,18 @@ func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
 		filter.SortOrder = order
 	}
 
+	if access := folderAccess(r); access != nil {
+		filter.AccessFolderIDs = access.FolderIDs(models.RoleViewer)
+	}
+
 	result, err := h.service.List(r.Context(), filter)
 	if err != nil {
 		InternalError(w, r)
 		return
 	}
 
+	h.withholdContent(r, result.Data)
+
 	// Use SuccessList to include pagination metadata
 	SuccessList(w, r, result.Data, result.Pagination.Page, result.Pagination.Limit, result.Pagination.Total)
 }
@@ -115,6 +181,9 @@ func (h *SnippetHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
 		return
 	}
+	if !allowTargetFolder(w, r, &input) {
+		return
+	}
 
 	snippet, err := h.service.Create(r.Context(), &input)
 	if err != nil {
@@ -124,6 +193,10 @@ func (h *SnippetHandler) Create(w http.ResponseWriter, r *http.Request) {
 			ValidationErrors(w, r, validationErrs)
 			return
 		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			Error(w, r, http.StatusConflict, "SLUG_TAKEN", "Another snippet already uses this slug")
+			return
+		}
 		InternalError(w, r)
 		return
 	}
@@ -148,8 +221,81 @@ func (h *SnippetHandler) Get(w http.ResponseWriter, r *http.Request) {
 		InternalError(w, r)
 		return
 	}
+	if !folderAccess(r).Allows(snippetFolderIDs(snippet), models.RoleViewer) {
+		NotFound(w, r, "Snippet not found")
+		return
+	}
 
-	OK(w, r, snippet)
+	h.service.TrackView(id)
+	h.service.WithholdContent(r.Context(), snippet)
+	OKSnippet(w, r, snippet)
+}
+
+// Download handles GET /api/v1/snippets/{id}/download
+// Sends the snippet as an attachment: the raw file for single-file
+// snippets, a ZIP archive of the files otherwise.
+func (h *SnippetHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	if !h.allowSnippet(w, r, id, models.RoleViewer) {
+		return
+	}
+
+	download, err := h.service.Download(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	// The RFC 2231 form keeps non-ASCII names intact
+	w.Header().Set("Content-Type", download.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": download.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	// Headers are already sent, so a write error can only truncate the body
+	_ = download.Write(w)
+}
+
+// PDF handles GET /api/v1/snippets/{id}/pdf
+// Renders the snippet for printing: a metadata header, then every file
+// with line numbers and syntax highlighting.
+func (h *SnippetHandler) PDF(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	doc, err := h.service.PDF(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_ = doc.Write(w)
 }
 
 // Update handles PUT /api/v1/snippets/{id}
@@ -165,6 +311,9 @@ func (h *SnippetHandler) Update(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
 		return
 	}
+	if !h.allowSnippet(w, r, id, models.RoleEditor) || !allowTargetFolder(w, r, &input) {
+		return
+	}
 
 	snippet, err := h.service.Update(r.Context(), id, &input)
 	if err != nil {
@@ -172,11 +321,19 @@ func (h *SnippetHandler) Update(w http.ResponseWriter, r *http.Request) {
 			NotFound(w, r, "Snippet not found")
 			return
 		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
 		var validationErrs validation.ValidationErrors
 		if errors.As(err, &validationErrs) {
 			ValidationErrors(w, r, validationErrs)
 			return
 		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			Error(w, r, http.StatusConflict, "SLUG_TAKEN", "Another snippet already uses this slug")
+			return
+		}
 		InternalError(w, r)
 		return
 	}
@@ -191,6 +348,9 @@ func (h *SnippetHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
 		return
 	}
+	if !h.allowSnippet(w, r, id, models.RoleEditor) {
+		return
+	}
 
 	err := h.service.Delete(r.Context(), id)
 	if err != nil {
@@ -223,9 +383,49 @@ func (h *SnippetHandler) ToggleFavorite(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.service.WithholdContent(r.Context(), snippet)
+	OK(w, r, snippet)
+}
+
+// TogglePin handles POST /api/v1/snippets/{id}/pin
+func (h *SnippetHandler) TogglePin(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	snippet, err := h.service.TogglePin(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		if errors.Is(err, services.ErrPinLimitReached) {
+			Error(w, r, http.StatusConflict, "PIN_LIMIT_REACHED",
+				"At most "+strconv.Itoa(h.service.MaxPinned())+" snippets can be pinned; unpin one first")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	h.service.WithholdContent(r.Context(), snippet)
 	OK(w, r, snippet)
 }
 
+// ListPinned handles GET /api/v1/snippets/pinned
+func (h *SnippetHandler) ListPinned(w http.ResponseWriter, r *http.Request) {
+	snippets, err := h.service.ListPinned(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	h.withholdContent(r, snippets)
+	OKList(w, r, snippets)
+}
+
 // ToggleArchive handles POST /api/v1/snippets/{id}/archive
 func (h *SnippetHandler) ToggleArchive(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -244,6 +444,7 @@ func (h *SnippetHandler) ToggleArchive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.service.WithholdContent(r.Context(), snippet)
 	OK(w, r, snippet)
 }
 
@@ -261,6 +462,10 @@ func (h *SnippetHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 			NotFound(w, r, "Snippet not found")
 			return
 		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
 		InternalError(w, r)
 		return
 	}
@@ -268,11 +473,43 @@ func (h *SnippetHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 	Created(w, r, snippet)
 }
 
+// RecordUsage handles POST /api/v1/snippets/{id}/usage
+// Records a view or copy of the snippet for the frecency sort.
+func (h *SnippetHandler) RecordUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.UsageInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	if err := h.service.RecordUsage(r.Context(), id, &input); err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
+
 // Search handles GET /api/v1/snippets/search
 func (h *SnippetHandler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		OK(w, r, []models.Snippet{})
+		OKList(w, r, []models.Snippet{})
 		return
 	}
 
@@ -289,10 +526,34 @@ func (h *SnippetHandler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	OK(w, r, snippets)
+	h.withholdContent(r, snippets)
+	OKList(w, r, snippets)
+}
+
+// GetBySlug handles GET /api/v1/snippets/by-slug/{slug}
+func (h *SnippetHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_SLUG", "Snippet slug is required")
+		return
+	}
+
+	snippet, err := h.service.GetBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	h.service.WithholdContent(r.Context(), snippet)
+	OKSnippet(w, r, snippet)
 }
 
 // GetPublic handles GET /api/v1/snippets/public/{id}
+// {id} may also be a slug, so /s/{slug} share links resolve through this endpoint.
 func (h *SnippetHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
@@ -300,7 +561,18 @@ func (h *SnippetHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	snippet, err := h.service.GetByIDPublic(r.Context(), id)
+	var snippet *models.Snippet
+	var err error
+	signed := middleware.SignedSnippetID(r.Context()) == id
+	if signed {
+		// A signed URL grants access to the snippet even when private
+		snippet, err = h.service.GetShared(r.Context(), id)
+	} else {
+		snippet, err = h.service.GetByIDPublic(r.Context(), id)
+		if errors.Is(err, services.ErrSnippetNotFound) && validation.IsValidSlug(id) {
+			snippet, err = h.service.GetBySlugPublic(r.Context(), id)
+		}
+	}
 	if err != nil {
 		if errors.Is(err, services.ErrSnippetNotFound) {
 			NotFound(w, r, "Snippet not found")
@@ -309,8 +581,32 @@ func (h *SnippetHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
 		InternalError(w, r)
 		return
 	}
+	if !signed {
+		recordShareView(h.analytics, r, snippet.ID)
+	}
 
-	OK(w, r, snippet)
+	varyAccept(w)
+	setRobotsTag(w, snippet)
+	if checkPublicCache(w, r, snippet, negotiateFormat(r, true), h.publicCacheAge) {
+		return
+	}
+	OKSnippet(w, r, snippet)
+}
+
+// Announcement handles GET /api/v1/announcement
+// Responds with 204 No Content when no announcement is shown.
+func (h *SnippetHandler) Announcement(w http.ResponseWriter, r *http.Request) {
+	announcement, err := h.service.Announcement(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+	if announcement == nil {
+		NoContent(w)
+		return
+	}
+
+	OK(w, r, announcement)
 }
 
 // GetHistory handles GET /api/v1/snippets/{id}/history
@@ -335,11 +631,106 @@ func (h *SnippetHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 			NotFound(w, r, "Snippet not found")
 			return
 		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
 		InternalError(w, r)
 		return
 	}
 
-	OK(w, r, history)
+	OKList(w, r, history)
+}
+
+// SearchHistory handles GET /api/v1/snippets/{id}/history/search
+// Query params: q, regex (true to treat q as a regular expression),
+// context (lines around each match, default 2, max 10), limit (matching
+// versions, default 20, max 100), from and to (YYYY-MM-DD, UTC), removed
+// (true for only versions of text the snippet no longer contains)
+func (h *SnippetHandler) SearchHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+	opts, ok := historySearchOptions(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.SearchHistory(r.Context(), id, opts)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrSnippetNotFound):
+			NotFound(w, r, "Snippet not found")
+		case errors.Is(err, services.ErrCheckoutRequired):
+			checkoutRequired(w, r)
+		case errors.As(err, &validationErrs):
+			ValidationErrors(w, r, validationErrs)
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	OKList(w, r, results)
+}
+
+// SearchAllHistory handles GET /api/v1/history/search
+// Takes the same query params as SearchHistory and searches the history of
+// every snippet, to recover text edited out of them.
+func (h *SnippetHandler) SearchAllHistory(w http.ResponseWriter, r *http.Request) {
+	opts, ok := historySearchOptions(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.SearchAllHistory(r.Context(), opts)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, results)
+}
+
+// historySearchOptions reads the history search query params, writing an
+// error if the dates are invalid
+func historySearchOptions(w http.ResponseWriter, r *http.Request) (models.HistorySearchOptions, bool) {
+	query := r.URL.Query()
+	opts := models.HistorySearchOptions{
+		Query:   query.Get("q"),
+		Regex:   query.Get("regex") == "true",
+		Context: models.DefaultHistorySearchContext,
+		Limit:   20,
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+		Removed: query.Get("removed") == "true",
+	}
+	if c, err := strconv.Atoi(query.Get("context")); err == nil {
+		opts.Context = c
+	}
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 100 {
+		opts.Limit = l
+	}
+
+	for _, day := range []string{opts.From, opts.To} {
+		if _, err := time.Parse(time.DateOnly, day); day != "" && err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_DATE", "Dates must be formatted as YYYY-MM-DD")
+			return opts, false
+		}
+	}
+	if opts.From != "" && opts.To != "" && opts.From > opts.To {
+		Error(w, r, http.StatusBadRequest, "INVALID_RANGE", "The period must not end before it starts")
+		return opts, false
+	}
+	return opts, true
 }
 
 // RestoreFromHistory handles POST /api/v1/snippets/{id}/history/{history_id}/restore
@@ -368,9 +759,87 @@ func (h *SnippetHandler) RestoreFromHistory(w http.ResponseWriter, r *http.Reque
 			NotFound(w, r, "Snippet not found")
 			return
 		}
+		if errors.Is(err, services.ErrCheckoutRequired) {
+			checkoutRequired(w, r)
+			return
+		}
 		Error(w, r, http.StatusBadRequest, "RESTORE_FAILED", err.Error())
 		return
 	}
 
 	OK(w, r, snippet)
 }
+
+// SubmitForReview handles POST /api/v1/snippets/{id}/review/submit
+func (h *SnippetHandler) SubmitForReview(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.SubmitForReview, "Only draft snippets can be submitted for review")
+}
+
+// Approve handles POST /api/v1/snippets/{id}/review/approve
+func (h *SnippetHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Approve, "Only snippets pending review can be approved")
+}
+
+// Reject handles POST /api/v1/snippets/{id}/review/reject
+func (h *SnippetHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Reject, "Only snippets pending review can be rejected")
+}
+
+// review applies a review action with the optional comment in the request
+// body. stateMessage explains a conflict with the snippet's current state.
+func (h *SnippetHandler) review(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id, comment string) (*models.Snippet, error), stateMessage string) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	var input models.ReviewInput
+	if err := DecodeJSON(r, &input); err != nil && !errors.Is(err, io.EOF) {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	snippet, err := action(r.Context(), id, input.Comment)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		if errors.Is(err, services.ErrReviewState) {
+			Error(w, r, http.StatusConflict, "INVALID_REVIEW_STATE", stateMessage)
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	h.service.WithholdContent(r.Context(), snippet)
+	OK(w, r, snippet)
+}
+
+// ListReviews handles GET /api/v1/snippets/{id}/reviews
+func (h *SnippetHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		Error(w, r, http.StatusBadRequest, "MISSING_ID", "Snippet ID is required")
+		return
+	}
+
+	reviews, err := h.service.ListReviews(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			NotFound(w, r, "Snippet not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, reviews)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// tagFromURL loads the tag named by the {id} URL parameter, writing an
+// error response when it is invalid or missing
+func (h *TagHandler) tagFromURL(w http.ResponseWriter, r *http.Request) (*models.Tag, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid tag ID")
+		return nil, false
+	}
+
+	tag, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, "Tag not found")
+			return nil, false
+		}
+		InternalError(w, r)
+		return nil, false
+	}
+	return tag, true
+}
+
+// ListAliases handles GET /api/v1/tags/{id}/aliases
+func (h *TagHandler) ListAliases(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	aliases, err := h.repo.ListAliases(r.Context(), tag.ID)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, aliases)
+}
+
+// AddAlias handles POST /api/v1/tags/{id}/aliases
+func (h *TagHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.TagAliasInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+
+	input.Alias = strings.TrimSpace(input.Alias)
+	if errs := validation.ValidateTagAlias(input.Alias); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
+		return
+	}
+
+	alias, err := h.repo.AddAlias(r.Context(), tag.ID, input.Alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			Error(w, r, http.StatusConflict, "TAG_EXISTS", "A tag or alias with this name already exists")
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, "Tag not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	Created(w, r, alias)
+}
+
+// DeleteAlias handles DELETE /api/v1/tags/{id}/aliases/{alias}
+func (h *TagHandler) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteAlias(r.Context(), tag.ID, chi.URLParam(r, "alias")); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, "Alias not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
+
+// ListImplied handles GET /api/v1/tags/{id}/implies
+func (h *TagHandler) ListImplied(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	implied, err := h.repo.ListImplied(r.Context(), tag.ID)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OKList(w, r, implied)
+}
+
+// AddImplied handles POST /api/v1/tags/{id}/implies. It responds with the
+// tags the tag now implies.
+func (h *TagHandler) AddImplied(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.TagImplicationInput
+	if err := DecodeJSON(r, &input); err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON payload")
+		return
+	}
+	if input.TagID <= 0 {
+		ValidationErrors(w, r, validation.ValidationErrors{{Field: "tag_id", Code: validation.CodeTagRequired, Message: "Implied tag is required"}})
+		return
+	}
+
+	if err := h.repo.AddImplication(r.Context(), tag.ID, input.TagID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrImplicationCycle):
+			Error(w, r, http.StatusConflict, "TAG_IMPLICATION_CYCLE", "The implied tag already implies this tag")
+		case errors.Is(err, repository.ErrNotFound):
+			NotFound(w, r, "Tag not found")
+		default:
+			InternalError(w, r)
+		}
+		return
+	}
+
+	implied, err := h.repo.ListImplied(r.Context(), tag.ID)
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	Created(w, r, implied)
+}
+
+// DeleteImplied handles DELETE /api/v1/tags/{id}/implies/{implied_id}
+func (h *TagHandler) DeleteImplied(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	impliedID, err := strconv.ParseInt(chi.URLParam(r, "implied_id"), 10, 64)
+	if err != nil {
+		Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid tag ID")
+		return
+	}
+
+	if err := h.repo.DeleteImplication(r.Context(), tag.ID, impliedID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			NotFound(w, r, "Tag implication not found")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	NoContent(w)
+}
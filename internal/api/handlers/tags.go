@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/MohamedElashri/snipo/internal/contracts"
 	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/validation"
@@ -14,11 +15,11 @@ import (
 
 // TagHandler handles tag-related HTTP requests
 type TagHandler struct {
-	repo *repository.TagRepository
+	repo contracts.TagRepository
 }
 
 // NewTagHandler creates a new tag handler
-func NewTagHandler(repo *repository.TagRepository) *TagHandler {
+func NewTagHandler(repo contracts.TagRepository) *TagHandler {
 	return &TagHandler{repo: repo}
 }
 
@@ -38,7 +39,12 @@ func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	OK(w, r, tags)
+	OKList(w, r, tags)
+}
+
+// Palette handles GET /api/v1/tags/palette
+func (h *TagHandler) Palette(w http.ResponseWriter, r *http.Request) {
+	OKList(w, r, validation.GetTagPalette())
 }
 
 // Create handles POST /api/v1/tags
@@ -51,18 +57,18 @@ func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if input.Name == "" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name is required"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Code: validation.CodeTagRequired, Message: "Name is required"}})
 		return
 	}
 
 	if len(input.Name) > 50 {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name must be 50 characters or less"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.TooLong("name", validation.CodeTagTooLong, "Name must be 50 characters or less", 50, len(input.Name))})
 		return
 	}
 
-	// Set default color if not provided
-	if input.Color == "" {
-		input.Color = "#6366f1"
+	if errs := validation.ValidateTagColor(&input); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
+		return
 	}
 
 	// Check if tag already exists
@@ -71,6 +77,10 @@ func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, http.StatusConflict, "TAG_EXISTS", "A tag with this name already exists")
 		return
 	}
+	if _, err := h.repo.ResolveAlias(r.Context(), input.Name); err == nil {
+		Error(w, r, http.StatusConflict, "TAG_EXISTS", "An alias with this name already exists")
+		return
+	}
 
 	tag, err := h.repo.Create(r.Context(), &input)
 	if err != nil {
@@ -124,12 +134,12 @@ func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if input.Name == "" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name is required"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Code: validation.CodeTagRequired, Message: "Name is required"}})
 		return
 	}
 
 	if len(input.Name) > 50 {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name must be 50 characters or less"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.TooLong("name", validation.CodeTagTooLong, "Name must be 50 characters or less", 50, len(input.Name))})
 		return
 	}
 
@@ -139,10 +149,14 @@ func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, http.StatusConflict, "TAG_EXISTS", "A tag with this name already exists")
 		return
 	}
+	if _, err := h.repo.ResolveAlias(r.Context(), input.Name); err == nil {
+		Error(w, r, http.StatusConflict, "TAG_EXISTS", "An alias with this name already exists")
+		return
+	}
 
-	// Set default color if not provided
-	if input.Color == "" {
-		input.Color = "#6366f1"
+	if errs := validation.ValidateTagColor(&input); errs.HasErrors() {
+		ValidationErrors(w, r, errs)
+		return
 	}
 
 	tag, err := h.repo.Update(r.Context(), id, &input)
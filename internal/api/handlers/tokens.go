@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
-	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/contracts"
 	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/validation"
@@ -16,13 +17,13 @@ import (
 
 // TokenHandler handles API token-related HTTP requests
 type TokenHandler struct {
-	repo         *repository.TokenRepository
-	settingsRepo *repository.SettingsRepository
-	authService  *auth.Service
+	repo         contracts.TokenRepository
+	settingsRepo contracts.SettingsRepository
+	authService  contracts.Authenticator
 }
 
 // NewTokenHandler creates a new token handler
-func NewTokenHandler(repo *repository.TokenRepository, settingsRepo *repository.SettingsRepository, authService *auth.Service) *TokenHandler {
+func NewTokenHandler(repo contracts.TokenRepository, settingsRepo contracts.SettingsRepository, authService contracts.Authenticator) *TokenHandler {
 	return &TokenHandler{
 		repo:         repo,
 		settingsRepo: settingsRepo,
@@ -38,7 +39,12 @@ func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	OK(w, r, map[string]interface{}{"data": tokens})
+	// v1 nests the list in a second data field; kept for existing clients
+	if !isV2(r) {
+		OK(w, r, map[string]interface{}{"data": tokens})
+		return
+	}
+	OKList(w, r, tokens)
 }
 
 // Create handles POST /api/v1/tokens
@@ -65,23 +71,45 @@ func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if input.Name == "" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name is required"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Code: validation.CodeTokenNameRequired, Message: "Name is required"}})
 		return
 	}
 
 	if len(input.Name) > 100 {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "name", Message: "Name must be 100 characters or less"}})
+		ValidationErrors(w, r, validation.ValidationErrors{validation.TooLong("name", validation.CodeTokenNameTooLong, "Name must be 100 characters or less", 100, len(input.Name))})
+		return
+	}
+
+	// Validate permissions (a level and/or scopes such as "read,backup:run")
+	permissions, err := models.NormalizePermissions(input.Permissions)
+	if err != nil {
+		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "permissions", Code: validation.CodeTokenPermissionInvalid, Message: "Permissions must be 'read', 'write', 'admin', the roles 'viewer' or 'editor', or a comma-separated list with scopes (" + strings.Join(models.KnownScopes(), ", ") + ")"}})
 		return
 	}
+	input.Permissions = permissions
 
-	// Validate permissions
-	if input.Permissions != "" && input.Permissions != "read" && input.Permissions != "write" && input.Permissions != "admin" {
-		ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "permissions", Message: "Permissions must be 'read', 'write', or 'admin'"}})
+	// Optional restrictions on where the token may be used from
+	var errs validation.ValidationErrors
+	if input.AllowedIPs, err = models.NormalizeAllowedIPs(input.AllowedIPs); err != nil {
+		errs = append(errs, validation.ValidationError{Field: "allowed_ips", Code: validation.CodeTokenAllowedIPInvalid, Message: "Allowed IPs must be at most 50 IP addresses or CIDR ranges"})
+	}
+	if input.AllowedReferrers, err = models.NormalizeAllowedReferrers(input.AllowedReferrers); err != nil {
+		errs = append(errs, validation.ValidationError{Field: "allowed_referrers", Code: validation.CodeTokenAllowedReferrerInvalid, Message: "Allowed referrers must be at most 50 hosts, such as dash.example.com or https://*.example.com"})
+	}
+	if input.Folders, err = models.NormalizeTokenFolders(input.Permissions, input.Folders); err != nil {
+		errs = append(errs, validation.ValidationError{Field: "folders", Code: validation.CodeTokenFoldersInvalid, Message: "Folders must be at most 50 folder IDs with the role 'viewer' or 'editor', on a token with read or write permissions; editor folders need write"})
+	}
+	if errs.HasErrors() {
+		ValidationErrors(w, r, errs)
 		return
 	}
 
 	token, err := h.repo.Create(r.Context(), &input)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ValidationErrors(w, r, validation.ValidationErrors{validation.ValidationError{Field: "folders", Code: validation.CodeTokenFoldersInvalid, Message: "Folder not found"}})
+			return
+		}
 		InternalError(w, r)
 		return
 	}
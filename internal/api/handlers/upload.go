@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// maxUploadFileSize is the largest uploaded file, matching the content limit
+const maxUploadFileSize = 1 << 20
+
+// uploadMemory is how much of a multipart upload is held in memory; the rest
+// is spooled to temporary files
+const uploadMemory = 8 << 20
+
+// Upload handles POST /api/v1/snippets/upload
+// Multipart form: one or more files in "files" (or "file") and optional
+// title, description, tags (comma-separated or repeated), folder_id and
+// is_public fields. Each file's language is detected from its name, and the
+// title defaults to the first file's name.
+func (h *SnippetHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	maxFiles := h.service.MaxFiles()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxFiles+1)*maxUploadFileSize)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			Error(w, r, http.StatusRequestEntityTooLarge, "UPLOAD_TOO_LARGE", "Upload is too large")
+			return
+		}
+		Error(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse form data")
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	headers := append(r.MultipartForm.File["files"], r.MultipartForm.File["file"]...)
+	if len(headers) == 0 {
+		Error(w, r, http.StatusBadRequest, "MISSING_FILE", "No files provided")
+		return
+	}
+	if len(headers) > maxFiles {
+		Error(w, r, http.StatusBadRequest, "TOO_MANY_FILES", "Too many files for one snippet")
+		return
+	}
+
+	input := models.SnippetInput{
+		Title:       strings.TrimSpace(r.FormValue("title")),
+		Description: r.FormValue("description"),
+	}
+	if public := r.FormValue("is_public"); public == "true" || public == "1" {
+		input.IsPublic = true
+	}
+	if folder := r.FormValue("folder_id"); folder != "" {
+		id, err := strconv.ParseInt(folder, 10, 64)
+		if err != nil {
+			Error(w, r, http.StatusBadRequest, "INVALID_ID", "Invalid folder ID")
+			return
+		}
+		input.FolderID = &id
+	}
+	for _, value := range r.MultipartForm.Value["tags"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				input.Tags = append(input.Tags, tag)
+			}
+		}
+	}
+
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			Error(w, r, http.StatusBadRequest, "READ_ERROR", "Failed to read uploaded file")
+			return
+		}
+		// One byte over the limit is enough for validation to reject the file
+		content, err := io.ReadAll(io.LimitReader(file, maxUploadFileSize+1))
+		_ = file.Close()
+		if err != nil {
+			Error(w, r, http.StatusBadRequest, "READ_ERROR", "Failed to read uploaded file")
+			return
+		}
+		if !utf8.Valid(content) || strings.ContainsRune(string(content), 0) {
+			Error(w, r, http.StatusUnsupportedMediaType, "BINARY_FILE", "Only text files can be uploaded")
+			return
+		}
+
+		// Browsers may send a relative path for files from a dropped folder
+		name := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+		if name == "." || name == "/" {
+			name = ""
+		}
+		input.Files = append(input.Files, models.SnippetFileInput{
+			Filename: name,
+			Content:  string(content),
+			Language: languages.Detect(name),
+		})
+	}
+	input.Language = input.Files[0].Language
+	if input.Title == "" {
+		input.Title = input.Files[0].Filename
+	}
+
+	snippet, err := h.service.Create(r.Context(), &input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			ValidationErrors(w, r, validationErrs)
+			return
+		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			Error(w, r, http.StatusConflict, "SLUG_TAKEN", "Another snippet already uses this slug")
+			return
+		}
+		InternalError(w, r)
+		return
+	}
+
+	Created(w, r, snippet)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// VaultHandler handles Obsidian vault sync requests
+type VaultHandler struct {
+	vaultSvc contracts.VaultSync // May be nil if no vault is configured
+	jobs     contracts.JobQueue  // May be nil, which disables async syncs
+}
+
+// NewVaultHandler creates a new vault handler
+func NewVaultHandler(vaultSvc contracts.VaultSync) *VaultHandler {
+	return &VaultHandler{vaultSvc: vaultSvc}
+}
+
+// WithJobs enables async syncs through the job queue
+func (h *VaultHandler) WithJobs(queue contracts.JobQueue) *VaultHandler {
+	h.jobs = queue
+	return h
+}
+
+// Status handles GET /api/v1/vault/status
+func (h *VaultHandler) Status(w http.ResponseWriter, r *http.Request) {
+	if h.vaultSvc == nil {
+		OK(w, r, models.VaultStatus{Enabled: false})
+		return
+	}
+
+	status, err := h.vaultSvc.Status(r.Context())
+	if err != nil {
+		InternalError(w, r)
+		return
+	}
+
+	OK(w, r, status)
+}
+
+// Sync handles POST /api/v1/vault/sync
+// Query params: async (optional). With async=true the sync runs as a
+// background job and the response is 202 with the job.
+func (h *VaultHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	if h.vaultSvc == nil {
+		Error(w, r, http.StatusServiceUnavailable, "VAULT_NOT_CONFIGURED", "Vault sync is not configured")
+		return
+	}
+
+	if async := r.URL.Query().Get("async"); async == "true" || async == "1" {
+		if h.jobs == nil {
+			Error(w, r, http.StatusServiceUnavailable, "JOBS_UNAVAILABLE", "Background jobs are not available")
+			return
+		}
+
+		job, err := h.jobs.Enqueue(r.Context(), services.VaultSyncJob, nil)
+		if err != nil {
+			InternalError(w, r)
+			return
+		}
+
+		w.Header().Set("Location", apiPath(r, "/jobs/"+job.ID))
+		Success(w, r, http.StatusAccepted, job)
+		return
+	}
+
+	result, err := h.vaultSvc.Sync(r.Context())
+	if err != nil {
+		Error(w, r, http.StatusBadGateway, "SYNC_FAILED", err.Error())
+		return
+	}
+
+	OK(w, r, result)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+	"github.com/MohamedElashri/snipo/internal/vault"
+)
+
+// setupVaultHandler creates a vault handler syncing with a temporary local
+// vault, returning the synced folder's directory
+func setupVaultHandler(t *testing.T, conflicts string) (*VaultHandler, *services.SnippetService, string) {
+	t.Helper()
+	db := testutil.TestDB(t)
+	logger := testutil.TestLogger()
+	folderRepo := repository.NewFolderRepository(db)
+	snippetSvc := services.NewSnippetService(repository.NewSnippetRepository(db), logger).
+		WithTagRepo(repository.NewTagRepository(db)).
+		WithFolderRepo(folderRepo).
+		WithFileRepo(repository.NewSnippetFileRepository(db)).
+		WithMaxFiles(10)
+
+	root := t.TempDir()
+	v, err := vault.NewLocal(root, "Snipo")
+	if err != nil {
+		t.Fatalf("NewLocal failed: %v", err)
+	}
+	svc := services.NewVaultSyncService(v, snippetSvc, folderRepo, repository.NewVaultNoteRepository(db), logger).
+		WithConflicts(conflicts)
+	return NewVaultHandler(svc), snippetSvc, filepath.Join(root, "Snipo")
+}
+
+func syncVault(t *testing.T, handler *VaultHandler) models.VaultSyncResult {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/vault/sync", nil)
+	rec := httptest.NewRecorder()
+	handler.Sync(rec, withRequestID(req))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data models.VaultSyncResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Data.Errors) > 0 {
+		t.Fatalf("sync reported errors: %v", resp.Data.Errors)
+	}
+	return resp.Data
+}
+
+// writeNote writes a note into the vault with the given modification time
+func writeNote(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readNote(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatalf("failed to read note %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestVaultHandler_Sync(t *testing.T) {
+	handler, snippetSvc, dir := setupVaultHandler(t, models.VaultConflictNewest)
+	ctx := context.Background()
+	later := time.Now().Add(time.Hour)
+
+	// A snippet becomes a note
+	snippet, err := snippetSvc.Create(ctx, &models.SnippetInput{
+		Title:    "Hello",
+		Content:  "fmt.Println(\"hello\")",
+		Language: "go",
+		Tags:     []string{"go"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	result := syncVault(t, handler)
+	if result.NotesWritten != 1 {
+		t.Fatalf("expected 1 note written, got %+v", result)
+	}
+	note := readNote(t, dir, "Hello.md")
+	if !strings.Contains(note, "id: "+snippet.ID) || !strings.Contains(note, "```go\nfmt.Println(\"hello\")\n```") {
+		t.Errorf("unexpected note:\n%s", note)
+	}
+
+	// Nothing changed on either side
+	if result := syncVault(t, handler); result.Unchanged != 1 || result.NotesWritten != 0 {
+		t.Errorf("expected an unchanged sync, got %+v", result)
+	}
+
+	// Editing the note updates the snippet
+	writeNote(t, dir, "Hello.md", strings.Replace(note, `"hello"`, `"edited"`, 1), later)
+	if result := syncVault(t, handler); result.SnippetsUpdated != 1 {
+		t.Fatalf("expected 1 snippet updated, got %+v", result)
+	}
+	updated, _ := snippetSvc.GetByID(ctx, snippet.ID)
+	if !strings.Contains(updated.Files[0].Content, "edited") || len(updated.Tags) != 1 {
+		t.Errorf("expected the note's edit to reach the snippet, got %+v", updated)
+	}
+
+	// A new note in a directory becomes a snippet in a matching folder
+	writeNote(t, dir, "Scripts/backup.md", "---\ntags: [shell, ops/backup]\n---\n# Backup\n\nNightly backup.\n\n```sh\nrsync -a src dst\n```\n", later)
+	result = syncVault(t, handler)
+	if result.SnippetsCreated != 1 {
+		t.Fatalf("expected 1 snippet created, got %+v", result)
+	}
+	list, _ := snippetSvc.Search(ctx, "rsync", 10)
+	if len(list) != 1 {
+		t.Fatalf("expected the imported snippet, got %d", len(list))
+	}
+	created, _ := snippetSvc.GetByID(ctx, list[0].ID)
+	if created.Title != "Backup" || created.Language != "bash" || created.Description != "Nightly backup." {
+		t.Errorf("unexpected snippet: %+v", created)
+	}
+	if len(created.Folders) != 1 || created.Folders[0].Name != "Scripts" {
+		t.Errorf("expected the Scripts folder, got %+v", created.Folders)
+	}
+	if len(created.Tags) != 2 || created.Tags[0].Name != "ops-backup" {
+		t.Errorf("expected sanitized tags, got %+v", created.Tags)
+	}
+
+	// Notes without code are left alone
+	writeNote(t, dir, "Ideas.md", "# Ideas\n\nJust prose.\n", later)
+	if result := syncVault(t, handler); len(result.Skipped) != 1 || result.SnippetsCreated != 0 {
+		t.Errorf("expected the prose note to be skipped, got %+v", result)
+	}
+
+	// Deleting a note deletes its snippet
+	if err := os.Remove(filepath.Join(dir, "Scripts", "backup.md")); err != nil {
+		t.Fatal(err)
+	}
+	if result := syncVault(t, handler); result.SnippetsDeleted != 1 {
+		t.Errorf("expected 1 snippet deleted, got %+v", result)
+	}
+	if _, err := snippetSvc.GetByID(ctx, created.ID); err == nil {
+		t.Error("expected the snippet to be deleted")
+	}
+
+	// Deleting a snippet deletes its note
+	if err := snippetSvc.Delete(ctx, snippet.ID); err != nil {
+		t.Fatal(err)
+	}
+	if result := syncVault(t, handler); result.NotesDeleted != 1 {
+		t.Errorf("expected 1 note deleted, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Hello.md")); !os.IsNotExist(err) {
+		t.Error("expected the note to be deleted")
+	}
+}
+
+func TestVaultHandler_SyncConflict(t *testing.T) {
+	handler, snippetSvc, dir := setupVaultHandler(t, models.VaultConflictNewest)
+	ctx := context.Background()
+
+	snippet, err := snippetSvc.Create(ctx, &models.SnippetInput{Title: "Query", Content: "SELECT 1;", Language: "sql"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	syncVault(t, handler)
+	note := readNote(t, dir, "Query.md")
+
+	// Both sides change; the snippet is newer than the note
+	writeNote(t, dir, "Query.md", strings.Replace(note, "SELECT 1;", "SELECT 2;", 1), time.Now().Add(-time.Hour))
+	if _, err := snippetSvc.Update(ctx, snippet.ID, &models.SnippetInput{Title: "Query", Content: "SELECT 3;", Language: "sql"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	result := syncVault(t, handler)
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Winner != models.VaultConflictSnipo {
+		t.Fatalf("expected snipo to win the conflict, got %+v", result)
+	}
+	if note := readNote(t, dir, "Query.md"); !strings.Contains(note, "SELECT 3;") {
+		t.Errorf("expected the snippet to overwrite the note, got:\n%s", note)
+	}
+	if result := syncVault(t, handler); len(result.Conflicts) != 0 || result.Unchanged != 1 {
+		t.Errorf("expected the conflict to be settled, got %+v", result)
+	}
+}
+
+func TestVaultHandler_NotConfigured(t *testing.T) {
+	handler := NewVaultHandler(nil)
+
+	rec := httptest.NewRecorder()
+	handler.Sync(rec, withRequestID(httptest.NewRequest(http.MethodPost, "/api/v1/vault/sync", nil)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Status(rec, withRequestID(httptest.NewRequest(http.MethodGet, "/api/v1/vault/status", nil)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":false`) {
+		t.Errorf("expected a disabled status, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
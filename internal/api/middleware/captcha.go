@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/MohamedElashri/snipo/internal/captcha"
+)
+
+// CaptchaHeader carries the token of a solved captcha
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaSources are origins added to the web UI's Content-Security-Policy
+// so the captcha provider's widget can load. Empty without a captcha.
+var CaptchaSources []string
+
+// CaptchaVerifier checks captcha tokens, returning captcha.ErrFailed for
+// rejected ones
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// RequireCaptcha refuses requests without a valid captcha token in the
+// X-Captcha-Token header while required returns true. A nil required
+// always asks for one.
+func RequireCaptcha(verifier CaptchaVerifier, required func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if required != nil && !required(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(CaptchaHeader)
+			if token == "" {
+				writeError(w, r, http.StatusForbidden, "CAPTCHA_REQUIRED", "Please solve the captcha")
+				return
+			}
+			if err := verifier.Verify(r.Context(), token, getClientIP(r)); err != nil {
+				if errors.Is(err, captcha.ErrFailed) {
+					writeError(w, r, http.StatusForbidden, "CAPTCHA_FAILED", "Captcha verification failed")
+					return
+				}
+				writeError(w, r, http.StatusBadGateway, "CAPTCHA_UNAVAILABLE", "Captcha could not be verified, try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
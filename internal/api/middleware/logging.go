@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextHandler adds the request ID to log records made with a request
+// context (logger.InfoContext and friends), so every line logged while
+// serving a request can be correlated with it
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h to add request IDs from the context
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle adds the request ID, if any and the record has none yet, and
+// passes the record on
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := GetRequestID(ctx); requestID != "" && !hasRequestID(record) {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// hasRequestID reports whether a record already carries a request ID, as
+// access log lines do
+func hasRequestID(record slog.Record) bool {
+	found := false
+	record.Attrs(func(a slog.Attr) bool {
+		found = a.Key == "request_id"
+		return !found
+	})
+	return found
+}
+
+// WithAttrs keeps the wrapper on derived handlers
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper on derived handlers
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -1,11 +1,25 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/captcha"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/testutil"
 )
 
 func TestRequestID(t *testing.T) {
@@ -63,6 +77,237 @@ func TestRequestID_PreExisting(t *testing.T) {
 	}
 }
 
+func TestRequestID_InvalidClientID(t *testing.T) {
+	invalid := []string{
+		"has spaces",
+		"line\nbreak",
+		"<script>",
+		strings.Repeat("a", maxRequestIDLength+1),
+	}
+
+	for _, id := range invalid {
+		handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Request-ID", id)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("X-Request-ID"); got == id || len(got) != 36 {
+			t.Errorf("expected %q to be replaced by a UUID, got %q", id, got)
+		}
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	ctx := context.WithValue(context.Background(), ContextKeyRequestID, "req-42")
+	logger.InfoContext(ctx, "with request")
+	logger.Info("without request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d", len(lines))
+	}
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["request_id"] != "req-42" || first["component"] != "test" {
+		t.Errorf("expected request ID and attrs, got %v", first)
+	}
+	if _, ok := second["request_id"]; ok {
+		t.Errorf("expected no request ID without a request context, got %v", second)
+	}
+}
+
+func TestRequireAuth_JSONError(t *testing.T) {
+	authService := auth.NewService(nil, "password", "secret", time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)), false)
+	handler := RequireAuth(authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/snippets", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ContextKeyRequestID, "req-7"))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON error: %v", err)
+	}
+	if body.Error.Code != "UNAUTHORIZED" || body.Error.RequestID != "req-7" {
+		t.Errorf("unexpected error body: %+v", body.Error)
+	}
+}
+
+func TestRequireAuth_Redirects(t *testing.T) {
+	authService := auth.NewService(nil, "password", "secret", time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)), false)
+	handler := RequireAuth(authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	for path, want := range map[string]string{"/": "/login", "/lite": "/lite/login", "/lite/s/abc": "/lite/login", "/literal": "/login"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != want {
+			t.Errorf("%s: expected a redirect to %s, got %d %s", path, want, rr.Code, rr.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRequireAuth_Actor(t *testing.T) {
+	db := testutil.TestDB(t)
+	tokenRepo := repository.NewTokenRepository(db)
+	token, err := tokenRepo.Create(context.Background(), &models.APITokenInput{Name: "ci-bot", Permissions: "write"})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	var actor auth.Actor
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = auth.ActorFromContext(r.Context())
+	})
+
+	authService := auth.NewService(db, "password", "secret", time.Hour, testutil.TestLogger(), false)
+	req := httptest.NewRequest("GET", "/api/v1/snippets", nil)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	RequireAuthWithTokenRepo(authService, tokenRepo)(next).ServeHTTP(httptest.NewRecorder(), req)
+	if actor.Name != "ci-bot" || actor.IP != "192.0.2.1" {
+		t.Errorf("expected the token's name and client IP, got %+v", actor)
+	}
+
+	// Without authentication the caller is anonymous
+	actor = auth.Actor{Name: "stale"}
+	disabled := auth.NewService(db, "", "secret", time.Hour, testutil.TestLogger(), true)
+	RequireAuth(disabled)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/snippets", nil))
+	if actor.Name != "" || actor.IP != "192.0.2.1" {
+		t.Errorf("expected an anonymous actor, got %+v", actor)
+	}
+}
+
+func TestRequireAuth_TokenRestrictions(t *testing.T) {
+	db := testutil.TestDB(t)
+	tokenRepo := repository.NewTokenRepository(db)
+	token, err := tokenRepo.Create(context.Background(), &models.APITokenInput{
+		Name:             "dashboard",
+		AllowedIPs:       []string{"192.0.2.0/24", "2001:db8::/32"},
+		AllowedReferrers: []string{"https://dash.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	authService := auth.NewService(db, "password", "secret", time.Hour, testutil.TestLogger(), false)
+	handler := RequireAuthWithTokenRepo(authService, tokenRepo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	request := func(remoteAddr, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/snippets", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-API-Key", token.Token)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, remoteAddr := range []string{"192.0.2.10:1234", "[2001:db8::10]:1234"} {
+		if rr := request(remoteAddr, "https://dash.example.com"); rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 from the allowed address and site, got %d", remoteAddr, rr.Code)
+		}
+	}
+	for _, tc := range []struct{ remoteAddr, origin string }{
+		{"198.51.100.1:1234", "https://dash.example.com"},
+		{"[2001:db9::10]:1234", "https://dash.example.com"},
+		{"192.0.2.10:1234", "https://evil.example.net"},
+		{"192.0.2.10:1234", ""},
+	} {
+		rr := request(tc.remoteAddr, tc.origin)
+		if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "TOKEN_NOT_ALLOWED") {
+			t.Errorf("%s %q: expected 403 TOKEN_NOT_ALLOWED, got %d: %s", tc.remoteAddr, tc.origin, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestRequireAuth_SessionRotation(t *testing.T) {
+	db := testutil.TestDB(t)
+	ctx := context.Background()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	old := auth.NewService(db, "password", "old-secret", time.Hour, testutil.TestLogger(), false)
+	token, err := old.CreateSession()
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Without the old secret its sessions are gone
+	if auth.NewService(db, "password", "new-secret", time.Hour, testutil.TestLogger(), false).ValidateSession(token) {
+		t.Fatal("expected the session rejected without the old secret")
+	}
+
+	rotated := auth.NewService(db, "password", "new-secret", time.Hour, testutil.TestLogger(), false).
+		WithPreviousSessionSecrets("old-secret")
+	if !rotated.ValidateSession(token) {
+		t.Fatal("expected the session accepted during rotation")
+	}
+	stats, err := rotated.SessionStats(ctx)
+	if err != nil {
+		t.Fatalf("SessionStats failed: %v", err)
+	}
+	if stats.Active != 1 || stats.CurrentSecret != 1 || stats.PreviousSecrets != 0 {
+		t.Errorf("expected the session moved to the current secret, got %+v", stats)
+	}
+
+	// Re-issuance hands the browser a new cookie
+	if n, err := rotated.ReissueSessions(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 session marked, got %d, %v", n, err)
+	}
+	req := httptest.NewRequest("GET", "/api/v1/snippets", nil)
+	req.AddCookie(&http.Cookie{Name: "snipo_session", Value: token})
+	rr := httptest.NewRecorder()
+	RequireAuth(rotated)(next).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var reissued string
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "snipo_session" {
+			reissued = c.Value
+		}
+	}
+	if reissued == "" || reissued == token {
+		t.Fatalf("expected a new session cookie, got %q", reissued)
+	}
+	if !rotated.ValidateSession(reissued) {
+		t.Error("expected the reissued session valid")
+	}
+
+	// A bearer session token is validated without re-issuance
+	if _, err := rotated.ReissueSessions(ctx); err != nil {
+		t.Fatalf("ReissueSessions failed: %v", err)
+	}
+	req = httptest.NewRequest("GET", "/api/v1/snippets", nil)
+	req.Header.Set("Authorization", "Bearer "+reissued)
+	rr = httptest.NewRecorder()
+	RequireAuth(rotated)(next).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || len(rr.Result().Cookies()) != 0 {
+		t.Errorf("expected 200 without a cookie, got %d %v", rr.Code, rr.Result().Cookies())
+	}
+}
+
 func TestGetRequestID(t *testing.T) {
 	// Test with request ID in context
 	ctx := context.WithValue(context.Background(), ContextKeyRequestID, "test-id-123")
@@ -191,12 +436,14 @@ func TestResponseWriter(t *testing.T) {
 }
 
 func TestGetClientIP(t *testing.T) {
+	internal := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("127.0.0.1/32")}
 	tests := []struct {
 		name       string
 		remoteAddr string
 		xff        string
 		xri        string
 		trustProxy bool
+		trusted    []netip.Prefix // nil keeps the loopback default
 		expected   string
 	}{
 		{
@@ -205,13 +452,71 @@ func TestGetClientIP(t *testing.T) {
 			trustProxy: false,
 			expected:   "192.168.1.100",
 		},
+		{
+			name:       "ipv6 direct connection",
+			remoteAddr: "[2001:db8::1]:12345",
+			trustProxy: false,
+			expected:   "2001:db8::1",
+		},
 		{
 			name:       "xff with trust",
-			remoteAddr: "10.0.0.1:12345",
-			xff:        "203.0.113.1, 198.51.100.1",
+			remoteAddr: "127.0.0.1:12345",
+			xff:        "203.0.113.1",
 			trustProxy: true,
 			expected:   "203.0.113.1",
 		},
+		{
+			name:       "xff from a peer that is not a proxy",
+			remoteAddr: "192.168.1.20:12345",
+			xff:        "203.0.113.1",
+			trustProxy: true,
+			expected:   "192.168.1.20",
+		},
+		{
+			name:       "xff spoofed by the client",
+			remoteAddr: "127.0.0.1:12345",
+			xff:        "203.0.113.1, 198.51.100.1",
+			trustProxy: true,
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "xff through internal proxies",
+			remoteAddr: "127.0.0.1:12345",
+			xff:        "203.0.113.1, 2001:db8::7, 10.0.0.2, 127.0.0.1",
+			trustProxy: true,
+			trusted:    internal,
+			expected:   "2001:db8::7",
+		},
+		{
+			name:       "xff with a private hop spoofed by a lan client",
+			remoteAddr: "127.0.0.1:12345",
+			xff:        "10.0.0.5, 192.168.1.20",
+			trustProxy: true,
+			expected:   "192.168.1.20",
+		},
+		{
+			name:       "xff with a proxy hop spoofed by a lan client",
+			remoteAddr: "10.0.0.2:12345",
+			xff:        "10.0.0.5, 192.168.1.20, 10.0.0.3",
+			trustProxy: true,
+			trusted:    internal,
+			expected:   "192.168.1.20",
+		},
+		{
+			name:       "xff of proxy hops only",
+			remoteAddr: "10.0.0.2:12345",
+			xff:        "10.0.0.5, 127.0.0.1",
+			trustProxy: true,
+			trusted:    internal,
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "xff with a hop that is not an address",
+			remoteAddr: "127.0.0.1:12345",
+			xff:        "192.168.1.20, garbage, 127.0.0.1",
+			trustProxy: true,
+			expected:   "127.0.0.1",
+		},
 		{
 			name:       "xff without trust",
 			remoteAddr: "192.168.1.100:12345",
@@ -221,19 +526,29 @@ func TestGetClientIP(t *testing.T) {
 		},
 		{
 			name:       "x-real-ip with trust",
-			remoteAddr: "10.0.0.1:12345",
+			remoteAddr: "[::1]:12345",
 			xri:        "203.0.113.5",
 			trustProxy: true,
 			expected:   "203.0.113.5",
 		},
+		{
+			name:       "x-real-ip from a peer that is not a proxy",
+			remoteAddr: "10.0.0.1:12345",
+			xri:        "203.0.113.5",
+			trustProxy: true,
+			expected:   "10.0.0.1",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Set trust proxy setting
-			oldTrust := TrustProxy
+			oldTrust, oldTrusted := TrustProxy, TrustedProxies
 			TrustProxy = tt.trustProxy
-			defer func() { TrustProxy = oldTrust }()
+			if tt.trusted != nil {
+				TrustedProxies = tt.trusted
+			}
+			defer func() { TrustProxy, TrustedProxies = oldTrust, oldTrusted }()
 
 			req := httptest.NewRequest("GET", "/test", nil)
 			req.RemoteAddr = tt.remoteAddr
@@ -251,3 +566,208 @@ func TestGetClientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIVersions(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(APIVersions)
+	r.Use(SecurityHeaders)
+	r.Get("/api/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path+" "+chi.URLParam(r, "id"))
+	})
+	r.Get("/api/v1/fail", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "NOT_FOUND", "missing")
+	})
+
+	tests := []struct {
+		path    string
+		version string
+		body    string
+	}{
+		{"/api/v1/items/a", APIVersion, "/api/v1/items/a a"},
+		{"/api/v2/items/b", APIVersion2, "/api/v2/items/b b"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rr.Code != http.StatusOK || rr.Body.String() != tt.body {
+			t.Errorf("%s: expected 200 %q, got %d %q", tt.path, tt.body, rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("X-API-Version"); got != tt.version {
+			t.Errorf("%s: expected X-API-Version %s, got %s", tt.path, tt.version, got)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v2/fail", nil))
+	var body struct {
+		Meta *struct {
+			Version string `json:"version"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Meta == nil || body.Meta.Version != APIVersion2 {
+		t.Errorf("expected v2 middleware errors to carry meta, got %s", rr.Body.String())
+	}
+}
+
+// fakeCaptcha accepts the token "ok" and fails to reach the provider for "down"
+type fakeCaptcha struct{}
+
+func (fakeCaptcha) Verify(_ context.Context, token, _ string) error {
+	switch token {
+	case "ok":
+		return nil
+	case "down":
+		return io.ErrUnexpectedEOF
+	}
+	return captcha.ErrFailed
+}
+
+func TestRequireCaptcha(t *testing.T) {
+	required := true
+	handler := RequireCaptcha(fakeCaptcha{}, func(*http.Request) bool { return required })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		token string
+		code  int
+		error string
+	}{
+		{"", http.StatusForbidden, "CAPTCHA_REQUIRED"},
+		{"wrong", http.StatusForbidden, "CAPTCHA_FAILED"},
+		{"down", http.StatusBadGateway, "CAPTCHA_UNAVAILABLE"},
+		{"ok", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		if tt.token != "" {
+			req.Header.Set(CaptchaHeader, tt.token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tt.code || !strings.Contains(rr.Body.String(), tt.error) {
+			t.Errorf("token %q: expected %d %s, got %d %s", tt.token, tt.code, tt.error, rr.Code, rr.Body.String())
+		}
+	}
+
+	required = false
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected no captcha needed, got %d", rr.Code)
+	}
+}
+
+func TestSecurityHeaders_CaptchaSources(t *testing.T) {
+	CaptchaSources = captcha.Sources(captcha.ProviderTurnstile)
+	defer func() { CaptchaSources = nil }()
+
+	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/login", nil))
+	allowed := map[string]bool{}
+	for _, directive := range strings.Split(rr.Header().Get("Content-Security-Policy"), "; ") {
+		name, sources, _ := strings.Cut(directive, " ")
+		allowed[name] = strings.Contains(sources, "https://challenges.cloudflare.com")
+	}
+	for _, name := range []string{"script-src", "style-src", "frame-src", "connect-src"} {
+		if !allowed[name] {
+			t.Errorf("expected %s to allow the provider", name)
+		}
+	}
+	if allowed["img-src"] {
+		t.Error("expected img-src unchanged")
+	}
+}
+
+// usageHits collects recorded API usage hits
+type usageHits []models.APIUsageHit
+
+func (u *usageHits) Record(hit models.APIUsageHit) { *u = append(*u, hit) }
+
+func TestUsage(t *testing.T) {
+	var hits usageHits
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-API-Key") != "" {
+				token := &models.APIToken{ID: 7, Name: "ci"}
+				r = r.WithContext(context.WithValue(r.Context(), ContextKeyAPIToken, token))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Use(Usage(&hits))
+	r.Route("/api/v1/snippets", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/snippets/", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/snippets/abc", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil),
+	} {
+		req.Header.Set("X-API-Key", "key")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/snippets/abc", nil))
+
+	want := usageHits{
+		{TokenID: 7, TokenName: "ci", Method: http.MethodGet, Route: "/api/v1/snippets", Status: http.StatusOK},
+		{TokenID: 7, TokenName: "ci", Method: http.MethodGet, Route: "/api/v1/snippets/{id}", Status: http.StatusTooManyRequests},
+		{Method: http.MethodGet, Route: "/api/v1/snippets/{id}", Status: http.StatusTooManyRequests},
+	}
+	if len(hits) != len(want) {
+		t.Fatalf("expected %d hits, got %+v", len(want), hits)
+	}
+	for i := range want {
+		if hits[i] != want[i] {
+			t.Errorf("hit %d: expected %+v, got %+v", i, want[i], hits[i])
+		}
+	}
+}
+
+func TestLogger_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+	r := chi.NewRouter()
+	r.Use(RequestID)
+	r.Use(Logger(logger))
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, withActor(r, "ci"))
+		})
+	}).Get("/api/v1/snippets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/snippets/abc123", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if n := strings.Count(line, `"request_id"`); n != 1 {
+		t.Errorf("expected the request ID once, got %d times: %s", n, line)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log line: %v", err)
+	}
+	want := map[string]any{
+		"request_id": "req-1",
+		"path":       "/api/v1/snippets/abc123",
+		"route":      "/api/v1/snippets/{id}",
+		"principal":  "ci",
+		"status":     float64(http.StatusOK),
+		"bytes":      float64(5),
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("expected %s %v, got %v", key, value, entry[key])
+		}
+	}
+}
@@ -10,11 +10,22 @@ import (
 
 // Permission levels
 const (
-	PermissionRead  = "read"
-	PermissionWrite = "write"
-	PermissionAdmin = "admin"
+	PermissionRead  = models.PermissionRead
+	PermissionWrite = models.PermissionWrite
+	PermissionAdmin = models.PermissionAdmin
 )
 
+// Granular scopes for administrative endpoints
+const (
+	ScopeSettingsRead  = models.ScopeSettingsRead
+	ScopeSettingsWrite = models.ScopeSettingsWrite
+	ScopeBackupRun     = models.ScopeBackupRun
+	ScopeTokensManage  = models.ScopeTokensManage
+)
+
+// folderScopedKey marks routes that check folder access themselves
+const folderScopedKey contextKey = "folder_scoped"
+
 // GetTokenFromContext retrieves the API token from context
 func GetTokenFromContext(ctx context.Context) *models.APIToken {
 	if token, ok := ctx.Value(ContextKeyAPIToken).(*models.APIToken); ok {
@@ -38,7 +49,13 @@ func CheckPermission(required string) func(http.Handler) http.Handler {
 
 			// Check if token has required permission
 			if !hasPermission(token.Permissions, required) {
-				http.Error(w, `{"error":{"code":"INSUFFICIENT_PERMISSIONS","message":"Token does not have required permissions"}}`, http.StatusForbidden)
+				writeError(w, r, http.StatusForbidden, "INSUFFICIENT_PERMISSIONS", "Token does not have required permissions")
+				return
+			}
+
+			// Tokens limited to folders only reach routes that enforce it
+			if token.FolderAccess != nil && r.Context().Value(folderScopedKey) == nil {
+				writeError(w, r, http.StatusForbidden, "FOLDER_RESTRICTED", "This token is limited to folders and cannot use this endpoint")
 				return
 			}
 
@@ -47,24 +64,19 @@ func CheckPermission(required string) func(http.Handler) http.Handler {
 	}
 }
 
-// hasPermission checks if the token's permission level is sufficient
-func hasPermission(tokenPermission, required string) bool {
-	// Admin has all permissions
-	if tokenPermission == PermissionAdmin {
-		return true
-	}
-
-	// Write has read + write permissions
-	if tokenPermission == PermissionWrite {
-		return required == PermissionRead || required == PermissionWrite
-	}
-
-	// Read only has read permission
-	if tokenPermission == PermissionRead {
-		return required == PermissionRead
-	}
+// FolderScoped marks a route as enforcing folder access itself (see
+// models.FolderAccess), so tokens limited to folders may use it. It must
+// come before the permission check; every other route refuses those
+// tokens.
+func FolderScoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), folderScopedKey, true)))
+	})
+}
 
-	return false
+// hasPermission checks if the token's permission list satisfies the required level or scope
+func hasPermission(tokenPermission, required string) bool {
+	return models.HasPermission(tokenPermission, required)
 }
 
 // RequireRead is a convenience middleware for read operations
@@ -82,6 +94,11 @@ func RequireAdmin(next http.Handler) http.Handler {
 	return CheckPermission(PermissionAdmin)(next)
 }
 
+// RequireScope returns middleware that requires a granular scope (admin tokens always pass)
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return CheckPermission(scope)
+}
+
 // PermissionByMethod returns middleware that checks permission based on HTTP method
 // GET = read, POST/PUT/PATCH/DELETE = write
 func PermissionByMethod(next http.Handler) http.Handler {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/MohamedElashri/snipo/internal/models"
@@ -133,6 +134,19 @@ func TestHasPermission(t *testing.T) {
 		{PermissionRead, PermissionWrite, false},
 		{PermissionRead, PermissionAdmin, false},
 		{"invalid", PermissionRead, false},
+
+		// Scope lists
+		{"read,backup:run", PermissionRead, true},
+		{"read,backup:run", ScopeBackupRun, true},
+		{"read,backup:run", PermissionWrite, false},
+		{"read,backup:run", ScopeTokensManage, false},
+		{"read,backup:run", ScopeSettingsWrite, false},
+		{"settings:write", ScopeSettingsRead, true},
+		{"settings:read", ScopeSettingsWrite, false},
+		{"write,tokens:manage", ScopeTokensManage, true},
+		{"write,tokens:manage", PermissionAdmin, false},
+		{PermissionAdmin, ScopeBackupRun, true},
+		{PermissionWrite, ScopeBackupRun, false},
 	}
 
 	for _, tt := range tests {
@@ -187,3 +201,94 @@ func TestConvenienceMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireScope(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name         string
+		scope        string
+		tokenPerm    string
+		expectStatus int
+	}{
+		{"backup token can run backups", ScopeBackupRun, "read,backup:run", http.StatusOK},
+		{"backup token cannot manage tokens", ScopeTokensManage, "read,backup:run", http.StatusForbidden},
+		{"backup token cannot change settings", ScopeSettingsWrite, "read,backup:run", http.StatusForbidden},
+		{"write token cannot run backups", ScopeBackupRun, PermissionWrite, http.StatusForbidden},
+		{"admin token has every scope", ScopeTokensManage, PermissionAdmin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &models.APIToken{ID: 1, Name: "test", Permissions: tt.tokenPerm}
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req = req.WithContext(context.WithValue(req.Context(), ContextKeyAPIToken, token))
+
+			rr := httptest.NewRecorder()
+			RequireScope(tt.scope)(testHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d", tt.expectStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		expectErr bool
+	}{
+		{"", PermissionRead, false},
+		{"write", PermissionWrite, false},
+		{"backup:run, read", "read,backup:run", false},
+		{"read,write,backup:run,backup:run", "write,backup:run", false},
+		{"admin,backup:run", PermissionAdmin, false},
+		{"tokens:manage", ScopeTokensManage, false},
+		{"superuser", "", true},
+		{"read,backup:all", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := models.NormalizePermissions(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("NormalizePermissions(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckPermission_FolderScoped(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	token := &models.APIToken{ID: 1, Permissions: PermissionWrite, FolderAccess: models.FolderAccess{1: models.RoleEditor}}
+	req := httptest.NewRequest("GET", "/test", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ContextKeyAPIToken, token))
+
+	rr := httptest.NewRecorder()
+	RequireRead(ok).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "FOLDER_RESTRICTED") {
+		t.Errorf("expected a folder-limited token refused on unmarked routes, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	FolderScoped(RequireRead(ok)).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected a folder-limited token allowed on folder-scoped routes, got %d", rr.Code)
+	}
+}
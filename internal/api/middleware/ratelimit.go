@@ -1,169 +1,346 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/MohamedElashri/snipo/internal/i18n"
 )
 
-// APIRateLimiter implements rate limiting for API endpoints with proper headers
-type APIRateLimiter struct {
-	requests      map[string][]time.Time // key = IP or token ID
-	mu            sync.RWMutex
-	readLimit     int // requests per hour for read operations
-	writeLimit    int // requests per hour for write operations
-	adminLimit    int // requests per hour for admin operations
-	window        time.Duration
+// RateLimitRule configures rate limiting for a group of routes. Rules with
+// different names count requests separately.
+type RateLimitRule struct {
+	Name   string
+	Limit  int           // Requests allowed per window
+	Window time.Duration // Sliding window length
+	// Burst switches the rule to a token bucket holding up to Burst
+	// requests, refilled at Limit per Window. Zero uses a sliding window.
+	Burst int
+	// Key identifies the client; default is ClientKey
+	Key func(r *http.Request) string
 }
 
-// RateLimitConfig holds rate limit configuration
-type RateLimitConfig struct {
-	ReadLimit  int           // default: 1000 req/hour
-	WriteLimit int           // default: 500 req/hour
-	AdminLimit int           // default: 100 req/hour
-	Window     time.Duration // default: 1 hour
+// ClientKey identifies a client by API token, falling back to the IP
+// address for session-based auth
+func ClientKey(r *http.Request) string {
+	if token := GetTokenFromContext(r.Context()); token != nil {
+		return fmt.Sprintf("token:%d", token.ID)
+	}
+	return IPKey(r)
 }
 
-// NewAPIRateLimiter creates a new API rate limiter with permission-based limits
-func NewAPIRateLimiter(config RateLimitConfig) *APIRateLimiter {
-	if config.ReadLimit == 0 {
-		config.ReadLimit = 1000
+// IPKey identifies a client by IP address
+func IPKey(r *http.Request) string {
+	return "ip:" + getClientIP(r)
+}
+
+// rateLimitStore records recent requests per rule and client. It backs
+// every rate limiter, so all limited routes behave and respond alike.
+type rateLimitStore struct {
+	mu        sync.Mutex
+	requests  map[string][]time.Time
+	buckets   map[string]*tokenBucket
+	maxWindow time.Duration // Longest window of any rule, for cleanup
+}
+
+// tokenBucket holds the tokens left for a burst rule
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // When tokens was last refilled
+}
+
+// newRateLimitStore creates a store and starts its cleanup goroutine
+func newRateLimitStore() *rateLimitStore {
+	s := &rateLimitStore{
+		requests: make(map[string][]time.Time),
+		buckets:  make(map[string]*tokenBucket),
 	}
-	if config.WriteLimit == 0 {
-		config.WriteLimit = 500
+	go s.cleanup()
+	return s
+}
+
+// rateLimitState describes a client's standing after a request
+type rateLimitState struct {
+	allowed   bool
+	limit     int
+	remaining int
+	reset     time.Time // When the client is back to its full allowance
+	retry     time.Time // When a rejected request may be retried
+}
+
+// take counts a request against key under rule unless the limit is reached
+func (s *rateLimitStore) take(rule RateLimitRule, key string, now time.Time) rateLimitState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.Window > s.maxWindow {
+		s.maxWindow = rule.Window
 	}
-	if config.AdminLimit == 0 {
-		config.AdminLimit = 100
+	if rule.Burst > 0 {
+		return s.takeToken(rule, key, now)
 	}
-	if config.Window == 0 {
-		config.Window = time.Hour
+
+	var recent []time.Time
+	for _, t := range s.requests[key] {
+		if now.Sub(t) < rule.Window {
+			recent = append(recent, t)
+		}
+	}
+
+	state := rateLimitState{allowed: len(recent) < rule.Limit, limit: rule.Limit}
+	if state.allowed {
+		recent = append(recent, now)
+	}
+	s.requests[key] = recent
+
+	state.remaining = max(0, rule.Limit-len(recent))
+	state.reset = now.Add(rule.Window)
+	if len(recent) > 0 {
+		state.reset = recent[0].Add(rule.Window)
 	}
+	state.retry = state.reset
+	return state
+}
+
+// takeToken spends a token from key's bucket, refilling it first for the
+// time since the last request. Callers hold s.mu.
+func (s *rateLimitStore) takeToken(rule RateLimitRule, key string, now time.Time) rateLimitState {
+	burst := float64(rule.Burst)
+	perToken := rule.Window / time.Duration(max(1, rule.Limit))
 
-	rl := &APIRateLimiter{
-		requests:   make(map[string][]time.Time),
-		readLimit:  config.ReadLimit,
-		writeLimit: config.WriteLimit,
-		adminLimit: config.AdminLimit,
-		window:     config.Window,
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(burst, b.tokens+float64(elapsed)/float64(perToken))
+		b.last = now
 	}
 
-	// Start cleanup goroutine
-	go rl.cleanup()
+	state := rateLimitState{allowed: b.tokens >= 1, limit: rule.Burst}
+	if state.allowed {
+		b.tokens--
+	}
 
-	return rl
+	state.remaining = int(b.tokens)
+	state.reset = now.Add(time.Duration((burst - b.tokens) * float64(perToken)))
+	state.retry = now.Add(time.Duration((1 - b.tokens) * float64(perToken)))
+	return state
 }
 
-// RateLimitByPermission returns middleware that rate limits based on permission level
-func (rl *APIRateLimiter) RateLimitByPermission(permission string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Determine the limit based on permission
-			var limit int
-			switch permission {
-			case PermissionAdmin:
-				limit = rl.adminLimit
-			case PermissionWrite:
-				limit = rl.writeLimit
-			case PermissionRead:
-				limit = rl.readLimit
-			default:
-				limit = rl.readLimit
+// cleanup periodically removes old entries to prevent memory leaks
+func (s *rateLimitStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for key, times := range s.requests {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= s.maxWindow {
+				delete(s.requests, key)
+			}
+		}
+		// An idle bucket has refilled by the end of its window
+		for key, b := range s.buckets {
+			if now.Sub(b.last) >= s.maxWindow {
+				delete(s.buckets, key)
 			}
+		}
+		s.mu.Unlock()
+	}
+}
 
-			// Get identifier (token ID or IP)
-			identifier := rl.getIdentifier(r)
+// limit returns middleware enforcing every rule; a request must pass all of
+// them. Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix time) for the rule closest to its limit; rejected
+// requests get a 429 with Retry-After and the JSON error envelope.
+func (s *rateLimitStore) limit(rules ...RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			now := time.Now()
 
-			rl.mu.Lock()
-
-			// Clean old requests for this identifier
-			var recent []time.Time
-			for _, t := range rl.requests[identifier] {
-				if now.Sub(t) < rl.window {
-					recent = append(recent, t)
+			var state rateLimitState
+			for i, rule := range rules {
+				key := rule.Key
+				if key == nil {
+					key = ClientKey
+				}
+				ruleState := s.take(rule, rule.Name+":"+key(r), now)
+				if i == 0 || !ruleState.allowed || ruleState.remaining < state.remaining {
+					state = ruleState
+				}
+				if !ruleState.allowed {
+					break
 				}
 			}
 
-			// Check if limit is exceeded
-			if len(recent) >= limit {
-				rl.mu.Unlock()
-				retryAfter := int(rl.window.Seconds())
-				reset := now.Add(rl.window).Unix()
-				
-				// Set rate limit headers
-				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset))
-				w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
-				
-				http.Error(w, `{"error":{"code":"RATE_LIMIT_EXCEEDED","message":"Rate limit exceeded. Please try again later."}}`, http.StatusTooManyRequests)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(state.limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(state.remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(state.reset.Unix(), 10))
+
+			if !state.allowed {
+				retryAfter := int(math.Ceil(state.retry.Sub(now).Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, retryAfter)))
+				writeError(w, r, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded. Please try again later.")
 				return
 			}
 
-			// Add current request
-			rl.requests[identifier] = append(recent, now)
-			
-			// Calculate remaining after adding this request
-			remaining := limit - len(rl.requests[identifier])
-			
-			// Set rate limit headers
-			reset := now.Add(rl.window).Unix()
-			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", max(0, remaining)))
-			w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset))
-			
-			rl.mu.Unlock()
-
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// getIdentifier returns a unique identifier for rate limiting
-// Uses token ID if available (for more accurate per-user limits), otherwise IP
-func (rl *APIRateLimiter) getIdentifier(r *http.Request) string {
-	// Try to get token from context
-	token := GetTokenFromContext(r.Context())
-	if token != nil {
-		return fmt.Sprintf("token:%d", token.ID)
+// writeError sends the API's JSON error envelope from middleware, which
+// cannot use the handlers package
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	type errorDetail struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
 	}
 
-	// Fall back to IP address (for session-based auth)
-	return "ip:" + getClientIP(r)
+	type meta struct {
+		RequestID string    `json:"request_id"`
+		Timestamp time.Time `json:"timestamp"`
+		Version   string    `json:"version"`
+	}
+	body := struct {
+		Error errorDetail `json:"error"`
+		Meta  *meta       `json:"meta,omitempty"`
+	}{
+		Error: errorDetail{Code: code, Message: i18n.T(i18n.ForResponse(w, r), message), RequestID: GetRequestID(r.Context())},
+	}
+	// v2 responses always carry meta, errors included
+	if GetAPIVersion(r.Context()) == 2 {
+		body.Meta = &meta{RequestID: body.Error.RequestID, Timestamp: time.Now().UTC(), Version: APIVersion2}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
 }
 
-// cleanup periodically removes old entries to prevent memory leaks
-func (rl *APIRateLimiter) cleanup() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
-	
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for identifier, times := range rl.requests {
-			var recent []time.Time
-			for _, t := range times {
-				if now.Sub(t) < rl.window {
-					recent = append(recent, t)
-				}
-			}
-			if len(recent) == 0 {
-				delete(rl.requests, identifier)
-			} else {
-				rl.requests[identifier] = recent
-			}
-		}
-		rl.mu.Unlock()
+// RateLimiter limits requests per client IP to a fixed number per window
+type RateLimiter struct {
+	store *rateLimitStore
+	rule  RateLimitRule
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		store: newRateLimitStore(),
+		rule:  RateLimitRule{Name: "ip", Limit: limit, Window: window, Key: IPKey},
+	}
+}
+
+// Middleware returns the rate limiting middleware
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return rl.store.limit(rl.rule)(next)
+}
+
+// APIRateLimiter implements rate limiting for API endpoints with proper headers
+type APIRateLimiter struct {
+	store       *rateLimitStore
+	readLimit   int // requests per window for read operations
+	writeLimit  int // requests per window for write operations
+	adminLimit  int // requests per window for admin operations
+	ipLimit     int // requests per window per IP across all tokens; 0 disables
+	window      time.Duration
+	publicLimit int // requests per minute per IP on public routes
+	publicBurst int // requests a client may make at once on public routes
+}
+
+// RateLimitConfig holds rate limit configuration
+type RateLimitConfig struct {
+	ReadLimit   int           // default: 1000 req/hour
+	WriteLimit  int           // default: 500 req/hour
+	AdminLimit  int           // default: 100 req/hour
+	IPLimit     int           // default: 0 (no per-IP limit on authenticated routes)
+	Window      time.Duration // default: 1 hour
+	PublicLimit int           // default: 60 req/minute
+	PublicBurst int           // default: 20
+}
+
+// NewAPIRateLimiter creates a new API rate limiter with permission-based limits
+func NewAPIRateLimiter(config RateLimitConfig) *APIRateLimiter {
+	if config.ReadLimit == 0 {
+		config.ReadLimit = 1000
+	}
+	if config.WriteLimit == 0 {
+		config.WriteLimit = 500
+	}
+	if config.AdminLimit == 0 {
+		config.AdminLimit = 100
+	}
+	if config.Window == 0 {
+		config.Window = time.Hour
+	}
+	if config.PublicLimit == 0 {
+		config.PublicLimit = 60
+	}
+	if config.PublicBurst == 0 {
+		config.PublicBurst = 20
+	}
+
+	return &APIRateLimiter{
+		store:       newRateLimitStore(),
+		readLimit:   config.ReadLimit,
+		writeLimit:  config.WriteLimit,
+		adminLimit:  config.AdminLimit,
+		ipLimit:     config.IPLimit,
+		window:      config.Window,
+		publicLimit: config.PublicLimit,
+		publicBurst: config.PublicBurst,
 	}
 }
 
-// max returns the maximum of two integers
-func max(a, b int) int {
-	if a > b {
-		return a
+// Limit returns middleware applying custom rules, for routes that need a
+// limit of their own
+func (rl *APIRateLimiter) Limit(rules ...RateLimitRule) func(http.Handler) http.Handler {
+	return rl.store.limit(rules...)
+}
+
+// RateLimitPublic limits unauthenticated routes per IP with a token bucket,
+// so clients can burst briefly but not sustain more than the public limit
+func (rl *APIRateLimiter) RateLimitPublic(next http.Handler) http.Handler {
+	return rl.Limit(RateLimitRule{
+		Name:   "public",
+		Limit:  rl.publicLimit,
+		Window: time.Minute,
+		Burst:  rl.publicBurst,
+		Key:    IPKey,
+	})(next)
+}
+
+// RateLimitByPermission returns middleware that rate limits based on
+// permission level. All permission levels share one request count per
+// client; the level only sets the limit. When an IP limit is configured,
+// requests also count against the client IP, so spreading requests over
+// several tokens from one address does not multiply the allowance.
+func (rl *APIRateLimiter) RateLimitByPermission(permission string) func(http.Handler) http.Handler {
+	var limit int
+	switch permission {
+	case PermissionAdmin:
+		limit = rl.adminLimit
+	case PermissionWrite:
+		limit = rl.writeLimit
+	default:
+		limit = rl.readLimit
+	}
+
+	rules := []RateLimitRule{{Name: "api", Limit: limit, Window: rl.window}}
+	if rl.ipLimit > 0 {
+		rules = append(rules, RateLimitRule{Name: "api-ip", Limit: rl.ipLimit, Window: rl.window, Key: IPKey})
 	}
-	return b
+	return rl.Limit(rules...)
 }
 
 // RateLimitRead is a convenience function for read operation rate limiting
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -273,3 +274,175 @@ func TestAPIRateLimiter_DefaultConfig(t *testing.T) {
 		t.Errorf("expected default window 1h, got %v", rl.window)
 	}
 }
+
+func TestRateLimiter_JSONResponse(t *testing.T) {
+	rl := NewRateLimiter(2, time.Minute)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/auth/login", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rr.Code)
+		}
+		if remaining := rr.Header().Get("X-RateLimit-Remaining"); remaining != strconv.Itoa(1-i) {
+			t.Errorf("request %d: expected X-RateLimit-Remaining %d, got %s", i+1, 1-i, remaining)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ContextKeyRequestID, "req-1"))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON response, got %s", ct)
+	}
+	retryAfter, _ := strconv.Atoi(rr.Header().Get("Retry-After"))
+	if retryAfter < 59 || retryAfter > 60 {
+		t.Errorf("expected Retry-After near the window, got %d", retryAfter)
+	}
+	if rr.Header().Get("X-RateLimit-Limit") != "2" || rr.Header().Get("X-RateLimit-Reset") == "" {
+		t.Errorf("expected rate limit headers, got %v", rr.Header())
+	}
+
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if body.Error.Code != "RATE_LIMIT_EXCEEDED" || body.Error.RequestID != "req-1" {
+		t.Errorf("unexpected error body: %+v", body.Error)
+	}
+}
+
+func TestAPIRateLimiter_CustomRule(t *testing.T) {
+	rl := NewAPIRateLimiter(RateLimitConfig{ReadLimit: 5, Window: time.Minute})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	strict := rl.Limit(RateLimitRule{Name: "strict", Limit: 1, Window: time.Minute, Key: IPKey})(ok)
+	read := rl.RateLimitRead(ok)
+
+	serve := func(h http.Handler) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+		return rr.Code
+	}
+
+	if code := serve(strict); code != http.StatusOK {
+		t.Fatalf("expected first request to pass, got %d", code)
+	}
+	if code := serve(strict); code != http.StatusTooManyRequests {
+		t.Errorf("expected custom rule to limit, got %d", code)
+	}
+	// Other rules keep their own counts
+	if code := serve(read); code != http.StatusOK {
+		t.Errorf("expected read limit to be unaffected, got %d", code)
+	}
+}
+
+func TestRateLimitStore_TokenBucket(t *testing.T) {
+	s := &rateLimitStore{requests: map[string][]time.Time{}, buckets: map[string]*tokenBucket{}}
+	rule := RateLimitRule{Name: "public", Limit: 60, Window: time.Minute, Burst: 3}
+	now := time.Now()
+
+	// The full burst is available at once
+	for i := 0; i < 3; i++ {
+		if state := s.take(rule, "ip:a", now); !state.allowed || state.remaining != 2-i {
+			t.Fatalf("request %d: expected allowed with %d remaining, got %+v", i+1, 2-i, state)
+		}
+	}
+	state := s.take(rule, "ip:a", now)
+	if state.allowed {
+		t.Fatal("expected burst to be exhausted")
+	}
+	if wait := state.retry.Sub(now); wait != time.Second {
+		t.Errorf("expected retry after one token (1s), got %v", wait)
+	}
+	if full := state.reset.Sub(now); full != 3*time.Second {
+		t.Errorf("expected bucket full after 3s, got %v", full)
+	}
+
+	// Tokens refill at Limit per Window, never beyond the burst
+	if state := s.take(rule, "ip:a", now.Add(time.Second)); !state.allowed {
+		t.Error("expected a refilled token after 1s")
+	}
+	if state := s.take(rule, "ip:a", now.Add(time.Hour)); !state.allowed || state.remaining != 2 {
+		t.Errorf("expected bucket capped at burst, got %+v", state)
+	}
+
+	// Other clients have their own bucket
+	if state := s.take(rule, "ip:b", now); !state.allowed {
+		t.Error("expected a separate bucket per key")
+	}
+}
+
+func TestAPIRateLimiter_IPLimitAcrossTokens(t *testing.T) {
+	rl := NewAPIRateLimiter(RateLimitConfig{ReadLimit: 5, IPLimit: 3, Window: time.Minute})
+	handler := rl.RateLimitRead(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(tokenID int64, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = remoteAddr
+		token := &models.APIToken{ID: tokenID, Permissions: PermissionRead}
+		req = req.WithContext(context.WithValue(req.Context(), ContextKeyAPIToken, token))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Each token is under its own limit, but the IP runs out
+	for i := int64(1); i <= 3; i++ {
+		if rr := serve(i, "192.0.2.10:1234"); rr.Code != http.StatusOK {
+			t.Fatalf("token %d: expected 200, got %d", i, rr.Code)
+		}
+	}
+	rr := serve(4, "192.0.2.10:1234")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected IP limit to apply across tokens, got %d", rr.Code)
+	}
+	if limit := rr.Header().Get("X-RateLimit-Limit"); limit != "3" {
+		t.Errorf("expected headers for the IP rule, got limit %s", limit)
+	}
+
+	// The same token from another address is still allowed
+	if rr := serve(1, "192.0.2.20:1234"); rr.Code != http.StatusOK {
+		t.Errorf("expected other IP to pass, got %d", rr.Code)
+	}
+}
+
+func TestAPIRateLimiter_Public(t *testing.T) {
+	rl := NewAPIRateLimiter(RateLimitConfig{PublicLimit: 60, PublicBurst: 2})
+	handler := rl.RateLimitPublic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/s/abc", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/s/abc", nil))
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the burst, got %d", rr.Code)
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("expected Retry-After 1, got %s", retryAfter)
+	}
+}
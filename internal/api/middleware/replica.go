@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/cluster"
+)
+
+// forwardedHeader marks requests a replica forwarded, so a node that is
+// itself a replica (such as during a LiteFS failover) does not forward them on
+const forwardedHeader = "X-Snipo-Forwarded"
+
+// contextKeyPrimary holds the primary URL a request is proxied to
+const contextKeyPrimary contextKey = "primary_url"
+
+// ReplicaWrites sends writes arriving at a replica to the primary: proxied
+// (the default), redirected with 307, or rejected with 503 depending on
+// mode. Reads and CORS preflights are served locally. WebSocket upgrades
+// count as writes since collaborative editing saves through them. It must
+// run before SecurityHeaders and CORS so proxied responses carry only the
+// primary's headers.
+func ReplicaWrites(node *cluster.Node, mode string, logger *slog.Logger) func(http.Handler) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			target, _ := pr.In.Context().Value(contextKeyPrimary).(*url.URL)
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Header.Set(forwardedHeader, "1")
+			// Keep the client's host so cookies and origin checks still match
+			pr.Out.Host = pr.In.Host
+			// The primary logs the request under the same ID
+			pr.Out.Header.Set("X-Request-ID", GetRequestID(pr.In.Context()))
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Warn("failed to forward write to primary", "error", err, "request_id", GetRequestID(r.Context()))
+			writeError(w, r, http.StatusBadGateway, "PRIMARY_UNAVAILABLE", "The primary instance is unavailable")
+		},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isWrite(r) || !node.IsReplica() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if mode == "reject" {
+				writeError(w, r, http.StatusServiceUnavailable, "READ_ONLY_REPLICA", "This instance is a read-only replica")
+				return
+			}
+			primary := node.PrimaryURL()
+			if primary == "" || r.Header.Get(forwardedHeader) != "" {
+				writeError(w, r, http.StatusServiceUnavailable, "PRIMARY_UNAVAILABLE", "The primary instance is unavailable")
+				return
+			}
+
+			if mode == "redirect" {
+				http.Redirect(w, r, primary+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+
+			target, err := url.Parse(primary)
+			if err != nil {
+				writeError(w, r, http.StatusServiceUnavailable, "PRIMARY_UNAVAILABLE", "The primary instance is unavailable")
+				return
+			}
+			// The proxy copies the primary's request ID header
+			w.Header().Del("X-Request-ID")
+			proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKeyPrimary, target)))
+		})
+	}
+}
+
+// isWrite reports whether a request may change data
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/cluster"
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+func TestReplicaWrites(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var forwarded *http.Request
+	var forwardedBody string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded, forwardedBody = r, string(body)
+		w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("from primary"))
+	}))
+	defer primary.Close()
+
+	local := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("local"))
+	})
+	newHandler := func(role, mode string) http.Handler {
+		node := cluster.New(config.ClusterConfig{Role: role, PrimaryURL: primary.URL}, "snipo.db", 8080)
+		return RequestID(ReplicaWrites(node, mode, logger)(local))
+	}
+
+	t.Run("reads served locally", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler("replica", "proxy").ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/snippets", nil))
+		if rr.Body.String() != "local" {
+			t.Errorf("expected local response, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("primary serves writes", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler("primary", "proxy").ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/snippets", nil))
+		if rr.Body.String() != "local" {
+			t.Errorf("expected local response, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/snippets?x=1", strings.NewReader(`{"title":"t"}`))
+		req.Header.Set("X-Request-ID", "req-1")
+		rr := httptest.NewRecorder()
+		newHandler("replica", "proxy").ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusCreated || rr.Body.String() != "from primary" {
+			t.Fatalf("expected the primary's response, got %d %q", rr.Code, rr.Body.String())
+		}
+		if forwarded.URL.RequestURI() != "/api/v1/snippets?x=1" || forwardedBody != `{"title":"t"}` {
+			t.Errorf("unexpected forwarded request %s %q", forwarded.URL.RequestURI(), forwardedBody)
+		}
+		if forwarded.Host != req.Host || forwarded.Header.Get(forwardedHeader) == "" {
+			t.Errorf("expected the client host and forwarded marker, got host %q", forwarded.Host)
+		}
+		if ids := rr.Result().Header.Values("X-Request-ID"); len(ids) != 1 || ids[0] != "req-1" {
+			t.Errorf("expected the request ID once, got %q", ids)
+		}
+	})
+
+	t.Run("no forwarding loops", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/snippets/abc", nil)
+		req.Header.Set(forwardedHeader, "1")
+		rr := httptest.NewRecorder()
+		newHandler("replica", "proxy").ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", rr.Code)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler("replica", "redirect").ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/api/v1/snippets/abc", nil))
+		if rr.Code != http.StatusTemporaryRedirect || rr.Header().Get("Location") != primary.URL+"/api/v1/snippets/abc" {
+			t.Errorf("expected redirect to the primary, got %d %q", rr.Code, rr.Header().Get("Location"))
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/collab/abc", nil)
+		req.Header.Set("Upgrade", "websocket")
+		rr := httptest.NewRecorder()
+		newHandler("replica", "reject").ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "READ_ONLY_REPLICA") {
+			t.Errorf("expected READ_ONLY_REPLICA, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Timeout gives a request a time budget. Its context is cancelled when the
+// budget runs out, which stops the database queries it is running; if the
+// handler then has not responded, the client gets a 504. When budgets are
+// nested the shorter one applies, so routes can tighten the group's budget.
+// WebSocket upgrades and event streams are left alone, as are all requests
+// when budget is zero.
+func Timeout(budget time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if budget <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isStream(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writeError(w, r, http.StatusGatewayTimeout, "REQUEST_TIMEOUT", "The request took too long")
+			}
+		})
+	}
+}
+
+// isStream reports whether a request opens a long-lived stream
+func isStream(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// timeoutWriter records whether the handler started its response
+type timeoutWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.wroteHeader = true
+	return tw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
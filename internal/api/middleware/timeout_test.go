@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	// Stands in for a query that runs until it is cancelled
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		Timeout(10*time.Millisecond)(slow).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/snippets", nil))
+		if rr.Code != http.StatusGatewayTimeout || !strings.Contains(rr.Body.String(), "REQUEST_TIMEOUT") {
+			t.Errorf("expected 504 REQUEST_TIMEOUT, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("shorter route budget wins", func(t *testing.T) {
+		var remaining time.Duration
+		handler := Timeout(time.Hour)(Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, _ := r.Context().Deadline()
+			remaining = time.Until(deadline)
+		})))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if remaining <= 0 || remaining > time.Minute {
+			t.Errorf("expected the route budget, got %s left", remaining)
+		}
+	})
+
+	t.Run("handler response kept", func(t *testing.T) {
+		handler := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			<-r.Context().Done()
+		}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusInternalServerError || rr.Body.Len() != 0 {
+			t.Errorf("expected the handler's response alone, got %d %q", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("streams exempt", func(t *testing.T) {
+		var hasDeadline bool
+		handler := Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc/events", nil)
+		req.Header.Set("Accept", "text/event-stream")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if hasDeadline {
+			t.Error("expected no deadline on an event stream")
+		}
+	})
+}
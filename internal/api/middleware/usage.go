@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// UsageRecorder counts answered API requests
+type UsageRecorder interface {
+	Record(hit models.APIUsageHit)
+}
+
+// Usage counts each request by API token and route pattern once it has
+// been answered. It must run after authentication, so the token is known,
+// and before Timeout, so timed out requests count as errors. Requests
+// that match no route are not counted.
+func Usage(recorder UsageRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if recorder == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil || rctx.RoutePattern() == "" {
+				return
+			}
+			hit := models.APIUsageHit{
+				Method: r.Method,
+				Route:  rctx.RoutePattern(),
+				Status: wrapped.statusCode,
+			}
+			if token, ok := r.Context().Value(ContextKeyAPIToken).(*models.APIToken); ok && token != nil {
+				hit.TokenID = token.ID
+				hit.TokenName = token.Name
+			}
+			recorder.Record(hit)
+		})
+	}
+}
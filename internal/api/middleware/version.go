@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ContextKeyAPIVersion is the context key for the requested API major version
+const ContextKeyAPIVersion contextKey = "api_version"
+
+// APIVersion2 is the version reported for /api/v2 requests
+const APIVersion2 = "2.0"
+
+// APIVersions serves /api/v2 with the routes registered under /api/v1. The
+// request keeps its /api/v2 URL; handlers call GetAPIVersion to shape the
+// response. It must run before routing.
+func APIVersions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if r.URL.RawPath != "" {
+			path = r.URL.RawPath
+		}
+
+		if rest, ok := strings.CutPrefix(path, "/api/v2/"); ok {
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				rctx.RoutePath = "/api/v1/" + rest
+			}
+			r = r.WithContext(context.WithValue(r.Context(), ContextKeyAPIVersion, 2))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetAPIVersion returns the API major version of the request, 1 unless it
+// came in under /api/v2
+func GetAPIVersion(ctx context.Context) int {
+	if version, ok := ctx.Value(ContextKeyAPIVersion).(int); ok {
+		return version
+	}
+	return 1
+}
+
+// apiVersionString is the X-API-Version and meta.version value for a request
+func apiVersionString(ctx context.Context) string {
+	if GetAPIVersion(ctx) == 2 {
+		return APIVersion2
+	}
+	return APIVersion
+}
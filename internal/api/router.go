@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"net/http"
@@ -11,10 +12,20 @@ import (
 	"github.com/MohamedElashri/snipo/internal/api/handlers"
 	"github.com/MohamedElashri/snipo/internal/api/middleware"
 	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/captcha"
+	"github.com/MohamedElashri/snipo/internal/collab"
+	"github.com/MohamedElashri/snipo/internal/cluster"
 	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
 	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/services"
 	"github.com/MohamedElashri/snipo/internal/storage"
+	"github.com/MohamedElashri/snipo/internal/vault"
+	"github.com/MohamedElashri/snipo/internal/urlimport"
 	"github.com/MohamedElashri/snipo/internal/web"
 )
 
@@ -23,162 +34,629 @@ type RouterConfig struct {
 	DB                 *sql.DB
 	Logger             *slog.Logger
 	AuthService        *auth.Service
-	Config             *config.Config // Full application config
+	Config             *config.Config // Full application config (required)
 	Version            string
 	Commit             string
 	RateLimit          int
 	RateLimitWindow    int // in seconds
 	MaxFilesPerSnippet int
+	MaxPinnedSnippets  int
 	S3Config           *config.S3Config
+	Lifecycle          *lifecycle.Manager // Owns background workers (optional)
+	Cluster            *cluster.Node      // Role when instances share a replicated database (optional)
 }
 
-// NewRouter creates and configures the HTTP router
+// NewRouter creates and configures the HTTP router. cfg.Config is required.
 func NewRouter(cfg RouterConfig) http.Handler {
+	if cfg.Config == nil {
+		panic("api: RouterConfig.Config is required")
+	}
 	r := chi.NewRouter()
 
 	// Global middleware (order matters!)
 	r.Use(middleware.RequestID)              // Generate request IDs first
+	r.Use(middleware.APIVersions)            // Serve /api/v2 from the v1 routes
 	r.Use(middleware.Recovery(cfg.Logger))   // Catch panics
 	r.Use(middleware.Logger(cfg.Logger))     // Log requests (includes request ID)
+	if cfg.Cluster != nil {
+		r.Use(middleware.ReplicaWrites(cfg.Cluster, cfg.Config.Cluster.ReplicaWrites, cfg.Logger)) // Send writes on replicas to the primary
+	}
 	r.Use(middleware.SecurityHeaders)        // Security headers (includes X-API-Version)
 	
 	// Use configured CORS
-	allowedOrigins := []string{"*"} // default
-	if cfg.Config != nil {
-		allowedOrigins = cfg.Config.API.AllowedOrigins
-	}
-	r.Use(middleware.CORS(allowedOrigins)) // CORS handling
+	r.Use(middleware.CORS(cfg.Config.API.AllowedOrigins)) // CORS handling
 
 	// Rate limiting for auth endpoints
-	authRateLimiter := middleware.NewRateLimiter(cfg.RateLimit, 60*1000*1000*1000) // 1 minute in nanoseconds
+	authWindow := time.Duration(cfg.RateLimitWindow) * time.Second
+	if authWindow <= 0 {
+		authWindow = time.Minute
+	}
+	authRateLimiter := middleware.NewRateLimiter(cfg.RateLimit, authWindow)
 
 	// API rate limiter with permission-based limits (use config values or defaults)
-	readLimit, writeLimit, adminLimit := 1000, 500, 100
-	if cfg.Config != nil {
-		readLimit = cfg.Config.API.RateLimitRead
-		writeLimit = cfg.Config.API.RateLimitWrite
-		adminLimit = cfg.Config.API.RateLimitAdmin
-	}
-	apiRateLimiter := middleware.NewAPIRateLimiter(middleware.RateLimitConfig{
-		ReadLimit:  readLimit,
-		WriteLimit: writeLimit,
-		AdminLimit: adminLimit,
+	rateLimitConfig := middleware.RateLimitConfig{
+		ReadLimit:  1000,
+		WriteLimit: 500,
+		AdminLimit: 100,
 		Window:     time.Hour,
-	})
+	}
+	rateLimitConfig.ReadLimit = cfg.Config.API.RateLimitRead
+	rateLimitConfig.WriteLimit = cfg.Config.API.RateLimitWrite
+	rateLimitConfig.AdminLimit = cfg.Config.API.RateLimitAdmin
+	rateLimitConfig.IPLimit = cfg.Config.API.RateLimitIP
+	rateLimitConfig.PublicLimit = cfg.Config.API.RateLimitPublic
+	rateLimitConfig.PublicBurst = cfg.Config.API.RateLimitPublicBurst
+	apiRateLimiter := middleware.NewAPIRateLimiter(rateLimitConfig)
+
+	// In-process cache for hot reads (settings, tag and folder lists, snippets)
+	var readCache *repository.ReadCache
+	if cfg.Config.Cache.Enabled {
+		readCache = repository.NewReadCache(cfg.Config.Cache.Snippets, cfg.Config.Cache.TTL)
+	}
+
+	// Titles and filenames for quick-open, kept in memory and updated on writes.
+	// Writes made through another instance are only seen on reload, so with
+	// several instances it reloads as often as the read cache expires.
+	quickOpenTTL := 10 * time.Minute
+	if cfg.Cluster.Role() != cluster.RoleStandalone {
+		quickOpenTTL = cfg.Config.Cache.TTL
+	}
+	quickOpenIndex := repository.NewQuickOpenIndex(cfg.DB, quickOpenTTL)
+
+	// Prepared statements for hot queries, closed before the database is
+	var statements *repository.Statements
+	if cfg.Config.Database.StatementCache > 0 {
+		statements = repository.NewStatements(cfg.DB, cfg.Config.Database.StatementCache)
+		if cfg.Lifecycle != nil {
+			_ = cfg.Lifecycle.Go("statements-close", func(ctx context.Context) error {
+				<-ctx.Done()
+				return statements.Close()
+			})
+		}
+	}
 
 	// Create repositories
-	snippetRepo := repository.NewSnippetRepository(cfg.DB)
-	tagRepo := repository.NewTagRepository(cfg.DB)
-	folderRepo := repository.NewFolderRepository(cfg.DB)
-	tokenRepo := repository.NewTokenRepository(cfg.DB)
-	fileRepo := repository.NewSnippetFileRepository(cfg.DB)
-	settingsRepo := repository.NewSettingsRepository(cfg.DB)
+	snippetRepo := repository.NewSnippetRepository(cfg.DB).WithCache(readCache).WithQuickOpen(quickOpenIndex).WithStatements(statements)
+	tagRepo := repository.NewTagRepository(cfg.DB).WithCache(readCache).WithStatements(statements)
+	folderRepo := repository.NewFolderRepository(cfg.DB).WithCache(readCache)
+	tokenRepo := repository.NewTokenRepository(cfg.DB).WithWriterCheck(cfg.Cluster.IsWriter)
+	fileRepo := repository.NewSnippetFileRepository(cfg.DB).WithQuickOpen(quickOpenIndex)
+	settingsRepo := repository.NewSettingsRepository(cfg.DB).WithCache(readCache)
 	historyRepo := repository.NewHistoryRepository(cfg.DB)
+	metadataRepo := repository.NewMetadataRepository(cfg.DB)
+	linkRepo := repository.NewLinkRepository(cfg.DB)
+	lockRepo := repository.NewLockRepository(cfg.DB)
+	checkoutRepo := repository.NewCheckoutRepository(cfg.DB)
+
+	// Notification center, plus the outgoing channels (webhook, email and
+	// the event broker)
+	notificationService := newNotificationService(cfg)
+	broker := newEventBroker(cfg)
+	notifier, mailer := newNotifier(cfg, notificationService, broker)
 
 	// Create services
 	snippetService := services.NewSnippetService(snippetRepo, cfg.Logger).
 		WithTagRepo(tagRepo).
 		WithFolderRepo(folderRepo).
 		WithFileRepo(fileRepo).
+		WithMetadataRepo(metadataRepo).
+		WithLinkRepo(linkRepo).
+		WithLockRepo(lockRepo).
+		WithCheckoutRepo(checkoutRepo).
 		WithHistoryRepo(historyRepo).
 		WithSettingsRepo(settingsRepo).
-		WithMaxFiles(cfg.MaxFilesPerSnippet)
+		WithMaxFiles(cfg.MaxFilesPerSnippet).
+		WithAutoSlugs(cfg.Config.Server.AutoSlugs).
+		WithLifecycle(cfg.Lifecycle)
+	if cfg.MaxPinnedSnippets > 0 {
+		snippetService.WithMaxPinned(cfg.MaxPinnedSnippets)
+	}
+	if cfg.Config.Alerts.PublicViewAlert {
+		snippetService.WithShareNotifier(notifier)
+	}
+	// Automation rules run before every snippet create and update
+	ruleService := services.NewRuleService(repository.NewRuleRepository(cfg.DB), cfg.Logger)
+	snippetService.WithSaveHook(ruleService)
+	// Redaction rules mask snippets wherever they are shared or exported
+	redactionService := services.NewRedactionService(repository.NewRedactionRepository(cfg.DB), cfg.Logger)
+	snippetService.WithRedactions(redactionService)
+	// Review and import events are for the notification center only
+	snippetService.WithReviewNotifier(notificationService)
+	snippetService.WithPublishNotifier(notifier)
+	// Snippet lifecycle events go to the event broker only
+	if broker != nil {
+		snippetService.WithEventNotifier(broker)
+	}
+
+	// Scheduled publishing, folder auto-archive rules and usage pruning
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("snippet-publish", time.Minute, snippetService.PublishDue)
+		_ = cfg.Lifecycle.Every("folder-auto-archive", time.Hour, snippetService.RunAutoArchive)
+		_ = cfg.Lifecycle.Every("snippet-usage-prune", 24*time.Hour, snippetService.PruneUsage)
+	}
+
+	// Collaborative editing (opt-in): documents are saved every few seconds
+	// and written back to their snippets every half minute and on shutdown
+	var collabHandler *handlers.CollabHandler
+	if cfg.Config.Features.Collab {
+		hub := collab.NewHub(snippetService, repository.NewCollabRepository(cfg.DB), cfg.Logger)
+		collabHandler = handlers.NewCollabHandler(hub)
+		if cfg.Lifecycle != nil {
+			_ = cfg.Lifecycle.Every("collab-save", 5*time.Second, hub.Save)
+			_ = cfg.Lifecycle.Every("collab-materialize", 30*time.Second, hub.Materialize)
+			_ = cfg.Lifecycle.Go("collab-close", func(ctx context.Context) error {
+				<-ctx.Done()
+				hub.Close(context.WithoutCancel(ctx))
+				return nil
+			})
+		}
+	}
 
 	// Create backup service
-	backupService := services.NewBackupService(cfg.DB, snippetService, tagRepo, folderRepo, fileRepo, cfg.Logger)
+	backupService := services.NewBackupService(cfg.DB, snippetService, tagRepo, folderRepo, fileRepo, cfg.Logger).
+		WithAppVersion(cfg.Version).
+		WithCache(readCache).
+		WithQuickOpen(quickOpenIndex).
+		WithNotifier(notificationService)
+	if cfg.Config.Backup.SafetySnapshots {
+		backupService.WithSafetySnapshots(cfg.Config.Backup.SafetySnapshotDir, cfg.Config.Backup.SafetySnapshotKeep)
+	}
 
-	// Create S3 sync service if configured
+	// Background job queue for long-running operations
+	jobQueue := jobs.NewQueue(repository.NewJobRepository(cfg.DB), cfg.Lifecycle, cfg.Logger)
+	jobQueue.Register(services.BackupImportJob, backupService.RunImportJob, jobs.RetryPolicy{MaxAttempts: 3, Backoff: 30 * time.Second})
+
+	// Create S3 sync service if object storage is configured
 	var s3SyncService *services.S3SyncService
 	if cfg.S3Config != nil && cfg.S3Config.Enabled {
-		s3Storage, err := storage.NewS3Storage(storage.S3Config{
-			Endpoint:        cfg.S3Config.Endpoint,
-			AccessKeyID:     cfg.S3Config.AccessKeyID,
-			SecretAccessKey: cfg.S3Config.SecretAccessKey,
-			Bucket:          cfg.S3Config.Bucket,
-			Region:          cfg.S3Config.Region,
-			UseSSL:          cfg.S3Config.UseSSL,
-		})
+		objectStore, err := storage.New(context.Background(), *cfg.S3Config)
 		if err != nil {
-			cfg.Logger.Warn("failed to initialize S3 storage", "error", err)
+			cfg.Logger.Warn("failed to initialize object storage", "provider", cfg.S3Config.Provider, "error", err)
 		} else {
-			s3SyncService = services.NewS3SyncService(s3Storage, backupService, cfg.Logger)
-			cfg.Logger.Info("S3 storage initialized", "bucket", cfg.S3Config.Bucket)
+			s3SyncService = services.NewS3SyncService(objectStore, backupService, cfg.Logger).
+				WithLifecycle(cfg.Lifecycle).
+				WithRetention(cfg.S3Config.Retention)
+			if cfg.Config.Alerts.BackupReports {
+				s3SyncService.WithNotifier(notifier)
+			}
+			jobQueue.Register(services.S3SyncJob, s3SyncService.RunSyncJob, jobs.RetryPolicy{MaxAttempts: 5, Backoff: time.Minute})
+			cfg.Logger.Info("object storage initialized", "provider", objectStore.Provider(), "bucket", objectStore.GetBucket())
 		}
 	}
 
+	// Create the Obsidian vault sync service if a vault is configured
+	var vaultSyncService *services.VaultSyncService
+	if cfg.Config.Vault.Enabled() {
+		v, err := vault.New(cfg.Config.Vault)
+		if err != nil {
+			cfg.Logger.Warn("failed to initialize vault", "error", err)
+		} else {
+			vaultSyncService = services.NewVaultSyncService(v, snippetService, folderRepo, repository.NewVaultNoteRepository(cfg.DB), cfg.Logger).
+				WithConflicts(cfg.Config.Vault.Conflicts).
+				WithSchedule(cfg.Config.Vault.Schedule)
+			jobQueue.Register(services.VaultSyncJob, vaultSyncService.RunSyncJob, jobs.RetryPolicy{MaxAttempts: 3, Backoff: time.Minute})
+			cfg.Logger.Info("vault sync initialized", "vault", v.Location(), "conflicts", cfg.Config.Vault.Conflicts)
+		}
+	}
+
+	if err := jobQueue.Start(context.Background()); err != nil {
+		cfg.Logger.Warn("failed to start job queue", "error", err)
+	}
+
+	// Scheduled backups are queued like manual syncs, so they get retries
+	// and show up in the job list
+	if s3SyncService != nil && cfg.S3Config.Schedule > 0 && cfg.Lifecycle != nil {
+		opts := models.ExportOptions{Format: cfg.S3Config.ScheduleFormat, Password: cfg.S3Config.SchedulePassword}
+		_ = cfg.Lifecycle.Every("scheduled-backup", cfg.S3Config.Schedule, func(ctx context.Context) error {
+			payload, err := s3SyncService.PrepareSync(ctx, opts)
+			if err != nil {
+				return err
+			}
+			_, err = jobQueue.Enqueue(ctx, services.S3SyncJob, payload)
+			return err
+		})
+		cfg.Logger.Info("scheduled backups enabled", "interval", cfg.S3Config.Schedule, "retention", cfg.S3Config.Retention)
+	}
+
+	if vaultSyncService != nil && cfg.Config.Vault.Schedule > 0 && cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("vault-sync", cfg.Config.Vault.Schedule, func(ctx context.Context) error {
+			_, err := jobQueue.Enqueue(ctx, services.VaultSyncJob, nil)
+			return err
+		})
+		cfg.Logger.Info("scheduled vault sync enabled", "interval", cfg.Config.Vault.Schedule)
+	}
+
+	// Warn when the database grows past the configured quota
+	if cfg.Config.Alerts.DBSizeWarnMB > 0 && cfg.Lifecycle != nil {
+		monitor := services.NewStorageMonitor(cfg.DB, cfg.Config.Alerts.DBSizeWarnMB, notifier, cfg.Logger)
+		_ = cfg.Lifecycle.Every("quota-check", 6*time.Hour, monitor.Check)
+	}
+
+	// Check GitHub for newer releases once a day (opt-in; nothing is
+	// requested when disabled)
+	var updateChecker *services.UpdateChecker
+	if cfg.Config.Updates.Check && cfg.Lifecycle != nil {
+		updateChecker = services.NewUpdateChecker(cfg.Version, cfg.Config.Updates.Repository, cfg.Logger)
+		if cfg.Config.Updates.Notify {
+			updateChecker.WithNotifier(notifier)
+		}
+		_ = cfg.Lifecycle.Go("update-check", updateChecker.Check)
+		_ = cfg.Lifecycle.Every("update-check", 24*time.Hour, updateChecker.Check)
+		cfg.Logger.Info("update checker enabled", "repository", cfg.Config.Updates.Repository)
+	}
+
+	// Anonymous telemetry (opt-in): the report can always be previewed,
+	// but is only sent, once a week, when enabled
+	telemetry := services.NewTelemetryService(cfg.Version, snippetRepo, telemetryFeatures(cfg.Config), cfg.Logger).
+		WithBlocked(cfg.Config.Telemetry.Blocked)
+	if cfg.Config.Telemetry.Enabled && cfg.Lifecycle != nil {
+		telemetry.WithURL(cfg.Config.Telemetry.URL)
+		_ = cfg.Lifecycle.Go("telemetry", telemetry.Send)
+		_ = cfg.Lifecycle.Every("telemetry", 7*24*time.Hour, telemetry.Send)
+		cfg.Logger.Info("telemetry enabled", "url", cfg.Config.Telemetry.URL)
+	}
+
+	// Count public snippet views per day, referrer and client
+	var shareAnalytics contracts.ShareAnalytics
+	if cfg.Config.Server.ShareAnalytics {
+		analyticsService := services.NewShareAnalyticsService(repository.NewShareAnalyticsRepository(cfg.DB), snippetService, cfg.Logger).
+			WithLifecycle(cfg.Lifecycle).
+			WithVisitorIPs(cfg.Config.Server.ShareAnalyticsIPs).
+			WithRetention(cfg.Config.Server.ShareAnalyticsRetention)
+		if cfg.Lifecycle != nil {
+			_ = cfg.Lifecycle.Every("share-analytics-prune", 24*time.Hour, analyticsService.Prune)
+		}
+		shareAnalytics = analyticsService
+	}
+
+	// Record a daily manifest of the library for as-of views and diffs
+	snapshotService := services.NewSnapshotService(repository.NewSnapshotRepository(cfg.DB), snippetRepo, fileRepo, cfg.Logger)
+	snapshotService.WithRetention(cfg.Config.Backup.LibrarySnapshotRetention)
+	if cfg.Config.Backup.LibrarySnapshots && cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("library-snapshot", time.Hour, snapshotService.TakeDaily)
+		_ = cfg.Lifecycle.Every("library-snapshot-prune", 24*time.Hour, snapshotService.Prune)
+	}
+
+	// Count API requests per day, token and route for the usage report
+	apiUsage := services.NewAPIUsageService(repository.NewAPIUsageRepository(cfg.DB), cfg.Logger).
+		WithLifecycle(cfg.Lifecycle)
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Go("api-usage", apiUsage.Run)
+		_ = cfg.Lifecycle.Every("api-usage-prune", 24*time.Hour, apiUsage.Prune)
+	}
+
 	// Create handlers
-	snippetHandler := handlers.NewSnippetHandler(snippetService)
+	snippetHandler := handlers.NewSnippetHandler(snippetService).
+		WithPublicCache(cfg.Config.Server.PublicCacheMaxAge).
+		WithURLSigner(cfg.AuthService, cfg.Config.Features.PasteAPI).
+		WithShareAnalytics(shareAnalytics)
 	tagHandler := handlers.NewTagHandler(tagRepo)
-	folderHandler := handlers.NewFolderHandler(folderRepo)
+	folderHandler := handlers.NewFolderHandler(folderRepo).WithSnippets(snippetService)
+	iconHandler := handlers.NewIconHandler()
+	licenseHandler := handlers.NewLicenseHandler()
+	languageHandler := handlers.NewLanguageHandler()
+	localeHandler := handlers.NewLocaleHandler()
+	adminHandler := handlers.NewAdminHandler(services.NewIntegrityService(snippetRepo, fileRepo, cfg.Logger)).
+		WithBuild(cfg.Version, cfg.Commit).
+		WithUsage(apiUsage)
 	tokenHandler := handlers.NewTokenHandler(tokenRepo, settingsRepo, cfg.AuthService)
-	authHandler := handlers.NewAuthHandler(cfg.AuthService)
+	loginAudit := newLoginAuditService(cfg, notifier)
+	authHandler := handlers.NewAuthHandler(cfg.AuthService).WithAudit(loginAudit)
 	
 	// Create health handler with feature flags
-	var featureFlags *config.FeatureFlags
-	if cfg.Config != nil {
-		featureFlags = &cfg.Config.Features
+	healthHandler := handlers.NewHealthHandler(cfg.DB, cfg.Version, cfg.Commit, &cfg.Config.Features).WithCache(readCache).WithStatements(statements).WithCluster(cfg.Cluster)
+	if updateChecker != nil {
+		healthHandler.WithUpdates(updateChecker)
+		adminHandler.WithUpdates(updateChecker)
 	}
-	healthHandler := handlers.NewHealthHandler(cfg.DB, cfg.Version, cfg.Commit, featureFlags)
+	adminHandler.WithTelemetry(telemetry)
+	changelogHandler := handlers.NewChangelogHandler(cfg.AuthService, cfg.Version)
 	
-	backupHandler := handlers.NewBackupHandler(backupService, s3SyncService)
-	settingsHandler := handlers.NewSettingsHandler(settingsRepo)
+	// Optional services reach the handlers as nil interfaces, not interfaces
+	// holding nil pointers, so the handlers' nil checks see them as missing
+	var s3Sync contracts.S3SyncService
+	if s3SyncService != nil {
+		s3Sync = s3SyncService
+	}
+	var vaultSync contracts.VaultSync
+	if vaultSyncService != nil {
+		vaultSync = vaultSyncService
+	}
+	var mailSender contracts.Mailer
+	if mailer != nil {
+		mailSender = mailer
+	}
+
+	jobHandler := handlers.NewJobHandler(jobQueue)
+	backupHandler := handlers.NewBackupHandler(backupService, s3Sync).WithJobs(jobQueue)
+	vaultHandler := handlers.NewVaultHandler(vaultSync).WithJobs(jobQueue)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo).WithSnippets(snippetService)
+	notificationHandler := handlers.NewNotificationHandler(mailSender).WithCenter(notificationService)
+	inboxService := services.NewInboxService(repository.NewInboxRepository(cfg.DB), snippetService, cfg.Logger).
+		WithMaxItems(cfg.Config.Server.MaxInboxItems)
+	inboxHandler := handlers.NewInboxHandler(inboxService)
+	reportService := services.NewReportService(repository.NewReportRepository(cfg.DB), snippetService, cfg.Logger).
+		WithNotifier(notifier).
+		WithLifecycle(cfg.Lifecycle)
+	reportService.WithChallenge(cfg.Config.API.ReportChallengeBits)
+	reportHandler := handlers.NewReportHandler(reportService)
+	reportRateLimiter := middleware.NewRateLimiter(cfg.Config.API.RateLimitReports, time.Hour)
+	burnLinkService := services.NewBurnLinkService(repository.NewBurnLinkRepository(cfg.DB), snippetService, cfg.Logger)
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("burn-links-cleanup", 24*time.Hour, burnLinkService.CleanupExpired)
+	}
+	burnLinkHandler := handlers.NewBurnLinkHandler(burnLinkService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	redactionHandler := handlers.NewRedactionHandler(redactionService)
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotService)
+	quickOpenHandler := handlers.NewQuickOpenHandler(services.NewQuickOpenService(quickOpenIndex))
+	autocompleteHandler := handlers.NewAutocompleteHandler(services.NewAutocompleteService(tagRepo, folderRepo, snippetRepo))
+
+	// Optional hCaptcha or Turnstile: always needed for abuse reports, and
+	// for logging in once failed attempts from all IPs pile up
+	var captchaWidget *captcha.Widget
+	captchaLoginRequired := func() bool { return false }
+	loginCaptcha := func(next http.Handler) http.Handler { return next }
+	reportCaptcha := loginCaptcha
+	if cfg.Config.Captcha.Enabled() {
+		verifier, err := captcha.New(cfg.Config.Captcha.Provider, cfg.Config.Captcha.SiteKey, cfg.Config.Captcha.SecretKey)
+		if err != nil {
+			cfg.Logger.Error("captcha disabled", "error", err)
+		} else {
+			loginAfter := cfg.Config.Captcha.LoginAfter
+			captchaWidget = verifier.Widget()
+			captchaLoginRequired = func() bool { return cfg.AuthService.RecentFailedLogins() >= loginAfter }
+			loginCaptcha = middleware.RequireCaptcha(verifier, func(*http.Request) bool { return captchaLoginRequired() })
+			reportCaptcha = middleware.RequireCaptcha(verifier, nil)
+		}
+	}
+	captchaHandler := handlers.NewCaptchaHandler(captchaWidget, captchaLoginRequired)
+
+	siteExportService := services.NewSiteExportService(snippetService, cfg.Logger)
+	if assets, err := web.SiteAssets(); err != nil {
+		cfg.Logger.Warn("static site export will not include syntax highlighting", "error", err)
+	} else {
+		siteExportService.WithAssets(assets)
+	}
+	exportHandler := handlers.NewExportHandler(siteExportService)
+	pasteHandler := handlers.NewPasteHandler(snippetService).
+		WithPublicCache(cfg.Config.Server.PublicCacheMaxAge).
+		WithShareAnalytics(shareAnalytics)
+
+	importCfg := cfg.Config.Import
+	fetcher := urlimport.NewFetcher(urlimport.Config{
+		Timeout:      importCfg.URLTimeout,
+		MaxBytes:     importCfg.URLMaxBytes,
+		AllowPrivate: importCfg.URLAllowPrivate,
+		UserAgent:    "Snipo/" + cfg.Version + " (URL import)",
+	})
+	importHandler := handlers.NewImportHandler(services.NewURLImportService(fetcher, snippetService, cfg.Logger))
+
+	// Time budgets: API requests get the request budget, and search and list
+	// routes the shorter search budget, after which their queries are
+	// cancelled and the client gets a 504
+	requestTimeout, searchTimeout := cfg.Config.Server.RequestTimeout, cfg.Config.Server.SearchTimeout
+	searchBudget := middleware.Timeout(searchTimeout)
 
 	// Public routes (no auth required)
 	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
+
 		// Health checks
 		r.Get("/health", healthHandler.Health)
 		r.Get("/ping", healthHandler.Ping)
 
+		// Crawler rules; share pages of noindex snippets also send X-Robots-Tag
+		r.Get("/robots.txt", handlers.NewRobotsHandler(cfg.Config.Server.RobotsTxt).Serve)
+
 		// OpenAPI specification
 		r.Get("/api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
 			http.ServeFile(w, r, "docs/openapi.yaml")
 		})
 
-		// Public snippet access
-		r.Get("/api/v1/snippets/public/{id}", snippetHandler.GetPublic)
+		// Public snippet access; signed URLs also reach private snippets
+		r.With(apiRateLimiter.RateLimitPublic, middleware.SignedURL(cfg.AuthService, "id")).Get("/api/v1/snippets/public/{id}", snippetHandler.GetPublic)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/announcement", snippetHandler.Announcement)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/public/folders/{id}", folderHandler.Collection) // Public folder listing (ID or slug)
+
+		// Link preview card of share pages, their og:image
+		r.With(apiRateLimiter.RateLimitPublic).Get("/s/{id}/preview.png", snippetHandler.PreviewImage)
+
+		// Locales for translated messages (the login page needs them too)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/locales", localeHandler.List)
+
+		// Abuse reports from share pages; the challenge is only needed with
+		// SNIPO_REPORT_CHALLENGE_BITS set
+		r.With(apiRateLimiter.RateLimitPublic).Get("/s/{id}/report/challenge", reportHandler.Challenge)
+		r.With(apiRateLimiter.RateLimitPublic, reportRateLimiter.Middleware, reportCaptcha).Post("/s/{id}/report", reportHandler.Report)
+
+		// Captcha widget settings for the login and share pages
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/captcha", captchaHandler.Get)
+
+		// Burn-after-read links: revealing the snippet uses up the link
+		r.With(apiRateLimiter.RateLimitPublic).Post("/api/v1/burn/{token}", burnLinkHandler.Reveal)
 
 		// Auth endpoints (with rate limiting)
 		r.Group(func(r chi.Router) {
 			r.Use(authRateLimiter.Middleware)
-			r.Post("/api/v1/auth/login", authHandler.Login)
+			r.With(loginCaptcha).Post("/api/v1/auth/login", authHandler.Login)
 		})
 
 		r.Post("/api/v1/auth/logout", authHandler.Logout)
 		r.Get("/api/v1/auth/check", authHandler.Check)
 	})
 
+	// README badges of public snippet counts (opt-in)
+	if cfg.Config.Features.Badges {
+		badgeHandler := handlers.NewBadgeHandler(services.NewBadgeService(snippetRepo)).
+			WithPublicCache(cfg.Config.Server.PublicCacheMaxAge)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/badges/count.svg", badgeHandler.Count)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/api/v1/badges/languages.svg", badgeHandler.Languages)
+	}
+
+	// Pastebin/hastebin compatible API (opt-in)
+	if cfg.Config.Features.PasteAPI {
+		r.With(apiRateLimiter.RateLimitPublic, middleware.SignedURL(cfg.AuthService, "key")).Get("/raw/{key}", pasteHandler.Raw)
+		r.With(apiRateLimiter.RateLimitPublic, middleware.SignedURL(cfg.AuthService, "key")).Get("/documents/{key}", pasteHandler.Get)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.BasicAuthAsToken)
+			r.Use(middleware.RequireAuthWithSettings(cfg.AuthService, tokenRepo, settingsRepo))
+			r.Use(middleware.Usage(apiUsage))
+			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/documents", pasteHandler.Create)
+		})
+	}
+
 	// Protected routes (auth required + rate limiting)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequireAuthWithSettings(cfg.AuthService, tokenRepo, settingsRepo))
+		r.Use(middleware.Usage(apiUsage)) // Before Timeout, so timeouts count as errors
+		r.Use(middleware.Timeout(requestTimeout))
 
 		// Auth management (protected, requires any auth)
 		r.Post("/api/v1/auth/change-password", authHandler.ChangePassword)
 
-		// Settings management (admin only)
+		// Login audit log (admin only)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/auth/events", authHandler.Events)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/auth/sessions", authHandler.Sessions)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Post("/api/v1/auth/sessions/reissue", authHandler.ReissueSessions)
+
+		// Release notes of the running version and the session's "what's new" marker
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/changelog", changelogHandler.List)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Post("/api/v1/changelog/seen", changelogHandler.MarkSeen)
+
+		// Notification center (read to list, write to mark as read)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/notifications", notificationHandler.List)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/notifications/unread-count", notificationHandler.UnreadCount)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/notifications/read-all", notificationHandler.MarkAllRead)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/notifications/{id}/read", notificationHandler.MarkRead)
+
+		// Clipboard inbox (read to list, write to add, discard and promote)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/inbox", inboxHandler.List)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/inbox", inboxHandler.Add)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/api/v1/inbox/{id}", inboxHandler.Delete)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/inbox/{id}/promote", inboxHandler.Promote)
+
+		// Fuzzy title and filename lookup for quick navigation
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/api/v1/quick-open", quickOpenHandler.Search)
+
+		// Type-ahead suggestions for tags, folders and languages
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/api/v1/autocomplete", autocompleteHandler.Suggest)
+
+		// Search of every snippet's history, to recover text edited out
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/api/v1/history/search", snippetHandler.SearchAllHistory)
+
+		// Wiki-style [[Title]] resolution (write, as it may create stubs)
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/resolve-links", snippetHandler.ResolveLinks)
+
+		// Notification channels (admin only)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Post("/api/v1/notifications/test-email", notificationHandler.TestEmail)
+
+		// Moderation queue for reported public snippets (admin only)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/reports", reportHandler.List)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Post("/api/v1/reports/{id}/unpublish", reportHandler.Unpublish)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Post("/api/v1/reports/{id}/dismiss", reportHandler.Dismiss)
+
+		// Automation rules and their run logs (admin only)
+		r.Route("/api/v1/rules", func(r chi.Router) {
+			r.Use(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin)
+			r.Get("/", ruleHandler.List)
+			r.Post("/", ruleHandler.Create)
+			r.Get("/{id}", ruleHandler.Get)
+			r.Put("/{id}", ruleHandler.Update)
+			r.Delete("/{id}", ruleHandler.Delete)
+			r.Get("/{id}/runs", ruleHandler.Runs)
+		})
+
+		// Redaction rules for shared and exported snippets (admin only)
+		r.Route("/api/v1/redactions", func(r chi.Router) {
+			r.Use(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin)
+			r.Get("/", redactionHandler.List)
+			r.Post("/", redactionHandler.Create)
+			r.Post("/preview", redactionHandler.Preview)
+			r.Get("/{id}", redactionHandler.Get)
+			r.Put("/{id}", redactionHandler.Update)
+			r.Delete("/{id}", redactionHandler.Delete)
+		})
+
+		// Daily library snapshots (taking one on demand is admin only)
+		r.Route("/api/v1/snapshots", func(r chi.Router) {
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", snapshotHandler.List)
+			r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Post("/", snapshotHandler.Take)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/diff", snapshotHandler.Diff)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/{day}", snapshotHandler.Get)
+		})
+
+		// Integrity verification (admin only)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/admin/verify", adminHandler.Verify)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/admin/system", adminHandler.System)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/admin/usage", adminHandler.Usage)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/admin/telemetry", adminHandler.Telemetry)
+		r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitAdmin).Get("/api/v1/admin/checkouts", snippetHandler.CheckoutTrail)
+
+		// Settings management (admin or settings scopes)
 		r.Route("/api/v1/settings", func(r chi.Router) {
-			r.Use(middleware.RequireAdmin)
 			r.Use(apiRateLimiter.RateLimitAdmin)
-			r.Get("/", settingsHandler.Get)
-			r.Put("/", settingsHandler.Update)
+			r.With(middleware.RequireScope(middleware.ScopeSettingsRead)).Get("/", settingsHandler.Get)
+			r.With(middleware.RequireScope(middleware.ScopeSettingsWrite)).Put("/", settingsHandler.Update)
 		})
 
-		// Snippet CRUD (read for GET, write for modifications)
+		// Snippet CRUD (read for GET, write for modifications). Routes marked
+		// FolderScoped check folder access, so tokens limited to folders
+		// can use them.
 		r.Route("/api/v1/snippets", func(r chi.Router) {
-			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", snippetHandler.List)
-			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/", snippetHandler.Create)
-			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/search", snippetHandler.Search)
+			r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/", snippetHandler.List)
+			r.With(middleware.FolderScoped, middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/", snippetHandler.Create)
+			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/upload", snippetHandler.Upload)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/search", snippetHandler.Search)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/pinned", snippetHandler.ListPinned)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/by-slug/{slug}", snippetHandler.GetBySlug)
 
 			r.Route("/{id}", func(r chi.Router) {
-				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", snippetHandler.Get)
-				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Put("/", snippetHandler.Update)
-				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/", snippetHandler.Delete)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", snippetHandler.Get)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/download", snippetHandler.Download)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/pdf", snippetHandler.PDF)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/image.png", snippetHandler.CodeImage)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/analytics", snippetHandler.Analytics)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitWrite).Post("/signed-url", snippetHandler.SignedURL)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/burn-links", burnLinkHandler.List)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/burn-links", burnLinkHandler.Create)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/burn-links/{link_id}", burnLinkHandler.Delete)
+				r.With(middleware.FolderScoped, middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Put("/", snippetHandler.Update)
+				r.With(middleware.FolderScoped, middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/", snippetHandler.Delete)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/favorite", snippetHandler.ToggleFavorite)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/pin", snippetHandler.TogglePin)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/archive", snippetHandler.ToggleArchive)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/duplicate", snippetHandler.Duplicate)
+				// Reading clients report copies for the frecency sort
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitWrite).Post("/usage", snippetHandler.RecordUsage)
 				
+				// Links to other snippets and backlinks
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/links", snippetHandler.ListLinks)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/links", snippetHandler.CreateLink)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/links/{link_id}", snippetHandler.DeleteLink)
+
+				// Advisory edit locks, renewed by the editor while it is open
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/lock", snippetHandler.AcquireLock)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/lock", snippetHandler.ReleaseLock)
+
+				// Check-outs of snippets that require one before showing their content
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitWrite).Post("/checkout", snippetHandler.Checkout)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitWrite).Post("/checkin", snippetHandler.Checkin)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/checkouts", snippetHandler.ListCheckouts)
+				if collabHandler != nil {
+					r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Get("/collab", collabHandler.Connect)
+				}
+
 				// History routes
 				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/history", snippetHandler.GetHistory)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/history/search", snippetHandler.SearchHistory)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/history/{history_id}/restore", snippetHandler.RestoreFromHistory)
+
+				// Review workflow: authors submit, admins approve or reject
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/reviews", snippetHandler.ListReviews)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/review/submit", snippetHandler.SubmitForReview)
+				r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitWrite).Post("/review/approve", snippetHandler.Approve)
+				r.With(middleware.RequireAdmin, apiRateLimiter.RateLimitWrite).Post("/review/reject", snippetHandler.Reject)
 			})
 		})
 
@@ -186,30 +664,46 @@ func NewRouter(cfg RouterConfig) http.Handler {
 		r.Route("/api/v1/tags", func(r chi.Router) {
 			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", tagHandler.List)
 			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/", tagHandler.Create)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/palette", tagHandler.Palette)
 
 			r.Route("/{id}", func(r chi.Router) {
 				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", tagHandler.Get)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Put("/", tagHandler.Update)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/", tagHandler.Delete)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/aliases", tagHandler.ListAliases)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/aliases", tagHandler.AddAlias)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/aliases/{alias}", tagHandler.DeleteAlias)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/implies", tagHandler.ListImplied)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/implies", tagHandler.AddImplied)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/implies/{implied_id}", tagHandler.DeleteImplied)
+				r.With(middleware.RequireScope(middleware.ScopeBackupRun), apiRateLimiter.RateLimitAdmin).Get("/export", backupHandler.ExportTag)
 			})
 		})
 
 		// Folder CRUD (read for GET, write for modifications)
 		r.Route("/api/v1/folders", func(r chi.Router) {
-			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", folderHandler.List)
+			r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", folderHandler.List)
 			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/", folderHandler.Create)
+			r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/auto-archive", folderHandler.AutoArchivePreview)
+			r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/auto-archive", folderHandler.AutoArchive)
 
 			r.Route("/{id}", func(r chi.Router) {
-				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", folderHandler.Get)
+				r.With(middleware.FolderScoped, middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/", folderHandler.Get)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Put("/", folderHandler.Update)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Delete("/", folderHandler.Delete)
 				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Put("/move", folderHandler.Move)
+				r.With(middleware.RequireScope(middleware.ScopeBackupRun), apiRateLimiter.RateLimitAdmin).Get("/export", backupHandler.ExportFolder)
 			})
 		})
 
-		// API Token management (admin only)
+		// Folder icon catalog
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/icons", iconHandler.List)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/licenses", licenseHandler.List)
+		r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/api/v1/languages", languageHandler.List)
+
+		// API Token management (admin or tokens:manage scope)
 		r.Route("/api/v1/tokens", func(r chi.Router) {
-			r.Use(middleware.RequireAdmin)
+			r.Use(middleware.RequireScope(middleware.ScopeTokensManage))
 			r.Use(apiRateLimiter.RateLimitAdmin)
 			r.Get("/", tokenHandler.List)
 			r.Post("/", tokenHandler.Create)
@@ -220,20 +714,45 @@ func NewRouter(cfg RouterConfig) http.Handler {
 			})
 		})
 
-		// Backup & Restore (admin only)
+		// Backup & Restore (admin or backup:run scope)
 		r.Route("/api/v1/backup", func(r chi.Router) {
-			r.Use(middleware.RequireAdmin)
+			r.Use(middleware.RequireScope(middleware.ScopeBackupRun))
 			r.Use(apiRateLimiter.RateLimitAdmin)
 			r.Get("/export", backupHandler.Export)
 			r.Post("/import", backupHandler.Import)
+			r.Get("/import/{job}/report", backupHandler.ImportReport)
 
 			// S3 operations
 			r.Get("/s3/status", backupHandler.S3Status)
 			r.Post("/s3/sync", backupHandler.S3Sync)
 			r.Get("/s3/list", backupHandler.S3List)
+			r.Get("/s3/preview", backupHandler.S3Preview)
 			r.Post("/s3/restore", backupHandler.S3Restore)
 			r.Delete("/s3/delete", backupHandler.S3Delete)
 		})
+
+		// Obsidian vault sync (admin or backup:run scope)
+		r.Route("/api/v1/vault", func(r chi.Router) {
+			r.Use(middleware.RequireScope(middleware.ScopeBackupRun))
+			r.Use(apiRateLimiter.RateLimitAdmin)
+			r.Get("/status", vaultHandler.Status)
+			r.Post("/sync", vaultHandler.Sync)
+		})
+
+		// Background job status (admin or backup:run scope; read limits so polling is cheap)
+		r.Route("/api/v1/jobs", func(r chi.Router) {
+			r.Use(middleware.RequireScope(middleware.ScopeBackupRun))
+			r.Use(apiRateLimiter.RateLimitRead)
+			r.Get("/", jobHandler.List)
+			r.Get("/{id}", jobHandler.Get)
+			r.Get("/{id}/events", jobHandler.Events)
+		})
+
+		// Import snippets from a web page
+		r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/api/v1/import/url", importHandler.URL)
+
+		// Publishing exports (admin or backup:run scope)
+		r.With(middleware.RequireScope(middleware.ScopeBackupRun), apiRateLimiter.RateLimitAdmin).Post("/api/v1/export/site", exportHandler.Site)
 	})
 
 	// Web UI routes
@@ -241,14 +760,165 @@ func NewRouter(cfg RouterConfig) http.Handler {
 	if err != nil {
 		cfg.Logger.Error("failed to create web handler", "error", err)
 	} else {
+		webHandler.WithPublicCache(cfg.Config.Server.PublicCacheMaxAge).WithNoIndex(snippetService).WithSharePreviews(snippetService)
+
 		// Static files
 		r.Handle("/static/*", web.StaticHandler())
 
 		// Web pages
 		r.Get("/", webHandler.Index)
 		r.Get("/login", webHandler.Login)
-		r.Get("/s/{id}", webHandler.PublicSnippet) // Public snippet share page
+		r.With(apiRateLimiter.RateLimitPublic).Get("/s/{id}", webHandler.PublicSnippet) // Public snippet share page (ID or slug)
+		r.With(apiRateLimiter.RateLimitPublic).Get("/b/{token}", webHandler.BurnSnippet) // Burn-after-read share page
+		r.With(apiRateLimiter.RateLimitPublic).Get("/c/{slug}", webHandler.PublicCollection) // Public folder collection page
+	}
+
+	// Lite UI: server-rendered pages without JavaScript, same authentication
+	liteHandler, err := web.NewLiteHandler(snippetService, cfg.AuthService, settingsRepo)
+	if err != nil {
+		cfg.Logger.Error("failed to create lite web handler", "error", err)
+	} else {
+		liteHandler.WithAudit(loginAudit).WithCaptchaGate(captchaLoginRequired)
+
+		r.Route("/lite", func(r chi.Router) {
+			r.Get("/login", liteHandler.Login)
+			r.With(authRateLimiter.Middleware).Post("/login", liteHandler.LoginSubmit)
+			r.Post("/logout", liteHandler.Logout)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireAuthWithSettings(cfg.AuthService, tokenRepo, settingsRepo))
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead, searchBudget).Get("/", liteHandler.List)
+				r.With(middleware.RequireRead, apiRateLimiter.RateLimitRead).Get("/s/{id}", liteHandler.View)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitRead).Get("/new", liteHandler.New)
+				r.With(middleware.RequireWrite, apiRateLimiter.RateLimitWrite).Post("/new", liteHandler.Create)
+			})
+		})
 	}
 
 	return r
 }
+
+// newNotificationService builds the notification center and schedules pruning
+func newNotificationService(cfg RouterConfig) *services.NotificationService {
+	center := services.NewNotificationService(repository.NewNotificationRepository(cfg.DB), cfg.Logger)
+	center.WithRetention(cfg.Config.Alerts.NotificationRetention)
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("notifications-prune", 24*time.Hour, center.Prune)
+	}
+	return center
+}
+
+// newEventBroker connects the configured MQTT or NATS publisher, or
+// returns nil when none is set. The connection is opened on the first event
+// and closed on shutdown.
+func newEventBroker(cfg RouterConfig) notify.Publisher {
+	if cfg.Config.Events.BrokerURL == "" {
+		return nil
+	}
+
+	broker, err := notify.NewBroker(cfg.Config.Events.BrokerURL, cfg.Config.Events.TopicPrefix)
+	if err != nil {
+		cfg.Logger.Warn("event publishing disabled", "error", err)
+		return nil
+	}
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Go("event-broker-close", func(ctx context.Context) error {
+			<-ctx.Done()
+			return broker.Close()
+		})
+	}
+	cfg.Logger.Info("event publishing enabled", "prefix", cfg.Config.Events.TopicPrefix)
+	return broker
+}
+
+// newNotifier builds the notification channels: the notification center,
+// which always records events, and the configured webhook, email and event
+// broker channels. It also returns the SMTP notifier (if any) for test
+// emails.
+func newNotifier(cfg RouterConfig, center notify.Notifier, broker notify.Publisher) (notify.Notifier, *notify.SMTP) {
+	var (
+		notifiers = notify.Multi{center}
+		mailer    *notify.SMTP
+	)
+	if cfg.Config.Alerts.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhook(cfg.Config.Alerts.WebhookURL, cfg.Config.Alerts.WebhookSecret))
+	}
+	if smtpCfg := cfg.Config.SMTP; smtpCfg.Host != "" {
+		m, err := notify.NewSMTP(notify.SMTPConfig{
+			Host:     smtpCfg.Host,
+			Port:     smtpCfg.Port,
+			Username: smtpCfg.Username,
+			Password: smtpCfg.Password,
+			From:     smtpCfg.From,
+			To:       smtpCfg.To,
+			TLSMode:  smtpCfg.TLSMode,
+		})
+		if err != nil {
+			cfg.Logger.Warn("email notifications disabled", "error", err)
+		} else {
+			mailer = m
+			notifiers = append(notifiers, m)
+			cfg.Logger.Info("email notifications enabled", "host", smtpCfg.Host, "recipients", len(smtpCfg.To))
+		}
+	}
+	if broker != nil {
+		notifiers = append(notifiers, broker)
+	}
+
+	return notifiers, mailer
+}
+
+// newLoginAuditService builds the login audit service and wires its alerts
+func newLoginAuditService(cfg RouterConfig, notifier notify.Notifier) *services.LoginAuditService {
+	audit := services.NewLoginAuditService(repository.NewLoginEventRepository(cfg.DB), cfg.Logger).
+		WithLifecycle(cfg.Lifecycle)
+
+	alerts := cfg.Config.Alerts
+	audit.WithFailureAlert(alerts.FailedLoginThreshold, alerts.FailedLoginWindow).
+		WithNewIPAlert(alerts.NewIPAlert).
+		WithRetention(alerts.LoginEventRetention)
+
+	audit.WithNotifier(notifier)
+
+	if cfg.Lifecycle != nil {
+		_ = cfg.Lifecycle.Every("login-events-prune", 24*time.Hour, audit.Prune)
+	}
+
+	return audit
+}
+
+// telemetryFeatures names the optional features turned on, for the
+// telemetry report. Only whether a feature is on is reported, never its
+// settings.
+func telemetryFeatures(c *config.Config) []string {
+	flags := []struct {
+		name string
+		on   bool
+	}{
+		{"public_snippets", c.Features.PublicSnippets},
+		{"s3_sync", c.Features.S3Sync},
+		{"api_tokens", c.Features.APITokens},
+		{"backup_restore", c.Features.BackupRestore},
+		{"paste_api", c.Features.PasteAPI},
+		{"vault_sync", c.Features.VaultSync},
+		{"collab", c.Features.Collab},
+		{"badges", c.Features.Badges},
+		{"auth_disabled", c.Auth.Disabled},
+		{"read_cache", c.Cache.Enabled},
+		{"share_analytics", c.Server.ShareAnalytics},
+		{"update_check", c.Updates.Check},
+		{"captcha", c.Captcha.Enabled()},
+		{"email", c.SMTP.Host != ""},
+		{"webhook", c.Alerts.WebhookURL != ""},
+		{"events", c.Events.BrokerURL != ""},
+		{"cluster", c.Cluster.Role != "" && c.Cluster.Role != "standalone"},
+	}
+
+	var features []string
+	for _, f := range flags {
+		if f.on {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
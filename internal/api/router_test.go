@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/cluster"
+	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+// syncBuffer collects log output written by background workers
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestRouter_ReplicaReadsDoNotWrite serves reads on a replica whose database
+// rejects writes: view counts, usage and share analytics must not be
+// written, so nothing fails in the background
+func TestRouter_ReplicaReadsDoNotWrite(t *testing.T) {
+	t.Setenv("SNIPO_MASTER_PASSWORD", "replica-test-password")
+	t.Setenv("SNIPO_SESSION_SECRET", "replica-test-session-secret-0123")
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "snipo.db")
+	t.Setenv("SNIPO_DB_PATH", dbPath)
+	t.Setenv("SNIPO_SAFETY_SNAPSHOT_DIR", filepath.Join(dir, "snapshots"))
+	t.Setenv("SNIPO_CLUSTER_ROLE", "replica")
+	t.Setenv("SNIPO_PRIMARY_URL", "http://primary.invalid")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	ctx := context.Background()
+	dbConfig := database.Config{
+		Path:            dbPath,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+		JournalMode:     cfg.Database.JournalMode,
+		SynchronousMode: cfg.Database.SynchronousMode,
+	}
+
+	// The primary's data, as replicated
+	primary, err := database.New(dbConfig, testutil.TestLogger())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := primary.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	snippet, err := repository.NewSnippetRepository(primary.DB).Create(ctx, &models.SnippetInput{Title: "Shared", Content: "echo hi", Language: "bash", IsPublic: true})
+	if err != nil {
+		t.Fatalf("failed to create snippet: %v", err)
+	}
+	token, err := repository.NewTokenRepository(primary.DB).Create(ctx, &models.APITokenInput{Name: "reader", Permissions: models.PermissionRead})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	_ = primary.Close()
+
+	logs := &syncBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	dbConfig.ReadOnly = true
+	db, err := database.New(dbConfig, logger)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	node := cluster.New(cfg.Cluster, dbPath, cfg.Server.Port)
+	lc := lifecycle.New(logger).WithWriterCheck(node.IsWriter)
+	router := NewRouter(RouterConfig{
+		DB:                 db.DB,
+		Logger:             logger,
+		AuthService:        auth.NewService(db.DB, cfg.Auth.MasterPassword, cfg.Auth.SessionSecret, cfg.Auth.SessionDuration, logger, false),
+		Config:             cfg,
+		RateLimit:          cfg.Auth.RateLimit,
+		RateLimitWindow:    int(cfg.Auth.RateLimitWindow.Seconds()),
+		MaxFilesPerSnippet: cfg.Server.MaxFilesPerSnippet,
+		MaxPinnedSnippets:  cfg.Server.MaxPinnedSnippets,
+		Lifecycle:          lc,
+		Cluster:            node,
+	})
+
+	for _, path := range []string{
+		"/api/v1/snippets/" + snippet.ID,
+		"/api/v1/snippets/public/" + snippet.ID,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", token.Token)
+		req.Header.Set("Referer", "https://news.example.com/")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected status 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Writes run in the background and would fail there; shutting down
+	// would cancel them first, so give them time to run instead
+	defer func() { _ = lc.Shutdown(ctx) }()
+	for deadline := time.Now().Add(200 * time.Millisecond); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if out := logs.String(); strings.Contains(out, "failed") {
+			t.Fatalf("expected no writes attempted, got:\n%s", out)
+		}
+	}
+}
+
+// TestRouter_RequiresConfig refuses to build a router without the
+// application config, instead of failing on the first setting read
+func TestRouter_RequiresConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic without a config")
+		}
+	}()
+	NewRouter(RouterConfig{DB: testutil.TestDB(t), Logger: testutil.TestLogger()})
+}
@@ -0,0 +1,25 @@
+package auth
+
+import "context"
+
+// ActorSession names callers signed in to the web interface
+const ActorSession = "session"
+
+// Actor identifies who made a request, for records such as snippet history
+type Actor struct {
+	Name string // API token name, ActorSession, or empty when authentication is off
+	IP   string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of a request, the zero Actor outside one
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
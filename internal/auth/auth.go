@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
@@ -45,6 +47,7 @@ type Service struct {
 	db                 *sql.DB
 	masterPasswordHash string
 	sessionSecret      string
+	previousSecrets    []string // Older secrets still accepted during a rotation
 	sessionDuration    time.Duration
 	logger             *slog.Logger
 	failedAttempts     *FailedLoginTracker
@@ -117,6 +120,20 @@ func (t *FailedLoginTracker) GetDelay(ip string) time.Duration {
 	return requiredDelay - elapsed
 }
 
+// RecentFailures returns the failed attempts from all IPs in the last hour
+func (t *FailedLoginTracker) RecentFailures() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	total := 0
+	for _, attempt := range t.attempts {
+		if time.Since(attempt.lastFail) <= time.Hour {
+			total += attempt.count
+		}
+	}
+	return total
+}
+
 // cleanup removes old entries periodically
 func (t *FailedLoginTracker) cleanup() {
 	ticker := time.NewTicker(10 * time.Minute)
@@ -175,6 +192,19 @@ func NewService(db *sql.DB, masterPassword, sessionSecret string, sessionDuratio
 	}
 }
 
+// WithPreviousSessionSecrets keeps sessions created under older secrets
+// valid while SNIPO_SESSION_SECRET is rotated. Such sessions move to the
+// current secret the next time they are used.
+func (s *Service) WithPreviousSessionSecrets(secrets ...string) *Service {
+	s.previousSecrets = nil
+	for _, secret := range secrets {
+		if secret != "" && secret != s.sessionSecret {
+			s.previousSecrets = append(s.previousSecrets, secret)
+		}
+	}
+	return s
+}
+
 // IsAuthDisabled returns whether authentication is disabled
 func (s *Service) IsAuthDisabled() bool {
 	return s.authDisabled
@@ -208,6 +238,12 @@ func (s *Service) VerifyPasswordWithDelay(password, clientIP string) (bool, time
 	return false, 0
 }
 
+// RecentFailedLogins returns the failed logins from all IPs in the last
+// hour, which a distributed attack keeps high even as each IP stays slow
+func (s *Service) RecentFailedLogins() int {
+	return s.failedAttempts.RecentFailures()
+}
+
 // UpdatePassword updates the master password (in-memory only, resets on restart)
 // For persistent password storage, this would need to be stored in the database
 func (s *Service) UpdatePassword(newPassword string) error {
@@ -220,8 +256,16 @@ func (s *Service) UpdatePassword(newPassword string) error {
 	return nil
 }
 
-// CreateSession creates a new session and returns the session token
+// CreateSession creates a new session and returns the session token. The
+// session starts with the release notes marker of the latest session, so
+// "what's new" covers what changed since the last login.
 func (s *Service) CreateSession() (string, error) {
+	var seen string
+	_ = s.db.QueryRow("SELECT changelog_seen FROM sessions WHERE changelog_seen != '' ORDER BY created_at DESC, rowid DESC LIMIT 1").Scan(&seen)
+	return s.createSession(time.Now().Add(s.sessionDuration), seen)
+}
+
+func (s *Service) createSession(expiresAt time.Time, changelogSeen string) (string, error) {
 	// Generate random token
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -229,9 +273,6 @@ func (s *Service) CreateSession() (string, error) {
 	}
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
 
-	// Hash token for storage
-	tokenHash := hashToken(token)
-
 	// Generate session ID
 	idBytes := make([]byte, 16)
 	if _, err := rand.Read(idBytes); err != nil {
@@ -239,13 +280,10 @@ func (s *Service) CreateSession() (string, error) {
 	}
 	sessionID := hex.EncodeToString(idBytes)
 
-	// Calculate expiry
-	expiresAt := time.Now().Add(s.sessionDuration)
-
-	// Store session
+	// Store session, keyed by the token's hash under the current secret
 	_, err := s.db.Exec(
-		"INSERT INTO sessions (id, token_hash, expires_at) VALUES (?, ?, ?)",
-		sessionID, tokenHash, expiresAt,
+		"INSERT INTO sessions (id, token_hash, key_id, expires_at, changelog_seen) VALUES (?, ?, ?, ?, ?)",
+		sessionID, hashSessionToken(s.sessionSecret, token), sessionKeyID(s.sessionSecret), expiresAt, changelogSeen,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to create session: %w", err)
@@ -255,43 +293,158 @@ func (s *Service) CreateSession() (string, error) {
 	return token, nil
 }
 
+// session is a stored session found by its token
+type session struct {
+	id      string
+	keyID   string
+	expires time.Time
+	reissue bool
+	seen    string // Release notes marker
+}
+
+// tokenHashes returns the hashes a session token may be stored under: one
+// per accepted secret, then the plain SHA-256 used before sessions were
+// tied to the secret
+func (s *Service) tokenHashes(token string) []any {
+	hashes := []any{hashSessionToken(s.sessionSecret, token)}
+	for _, secret := range s.previousSecrets {
+		hashes = append(hashes, hashSessionToken(secret, token))
+	}
+	return append(hashes, hashToken(token))
+}
+
+// findSession looks up an unexpired session by token, moving it to the
+// current secret when it was stored under another one
+func (s *Service) findSession(token string) (*session, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	hashes := s.tokenHashes(token)
+	var sess session
+	err := s.db.QueryRow(
+		"SELECT id, key_id, expires_at, reissue, changelog_seen FROM sessions WHERE token_hash IN (?"+strings.Repeat(", ?", len(hashes)-1)+")",
+		hashes...,
+	).Scan(&sess.id, &sess.keyID, &sess.expires, &sess.reissue, &sess.seen)
+	if err != nil {
+		return nil, false
+	}
+
+	if time.Now().After(sess.expires) {
+		// Clean up expired session
+		_, _ = s.db.Exec("DELETE FROM sessions WHERE id = ?", sess.id)
+		return nil, false
+	}
+
+	if current := sessionKeyID(s.sessionSecret); sess.keyID != current {
+		if _, err := s.db.Exec("UPDATE sessions SET token_hash = ?, key_id = ? WHERE id = ?", hashes[0], current, sess.id); err != nil {
+			s.logger.Warn("failed to move session to the current secret", "session_id", sess.id, "error", err)
+		} else {
+			sess.keyID = current
+		}
+	}
+	return &sess, true
+}
+
 // ValidateSession checks if a session token is valid
 func (s *Service) ValidateSession(token string) bool {
-	if token == "" {
-		return false
+	_, ok := s.findSession(token)
+	return ok
+}
+
+// reissueGrace is how long a replaced session token keeps working, for
+// requests the browser sent before it received the new cookie
+const reissueGrace = time.Minute
+
+// ValidateSessionReissue checks a session token like ValidateSession. When
+// the session was marked for re-issuance it is replaced by a new one with
+// the same expiry, whose token is returned as reissued.
+func (s *Service) ValidateSessionReissue(token string) (valid bool, reissued string) {
+	sess, ok := s.findSession(token)
+	if !ok || !sess.reissue {
+		return ok, ""
 	}
 
-	tokenHash := hashToken(token)
+	newToken, err := s.createSession(sess.expires, sess.seen)
+	if err != nil {
+		s.logger.Warn("failed to reissue session", "session_id", sess.id, "error", err)
+		return true, ""
+	}
+	graceEnd := time.Now().Add(reissueGrace)
+	if sess.expires.Before(graceEnd) {
+		graceEnd = sess.expires
+	}
+	_, _ = s.db.Exec("UPDATE sessions SET reissue = 0, expires_at = ? WHERE id = ?", graceEnd, sess.id)
+	return true, newToken
+}
 
-	var expiresAt time.Time
-	err := s.db.QueryRow(
-		"SELECT expires_at FROM sessions WHERE token_hash = ?",
-		tokenHash,
-	).Scan(&expiresAt)
+// ReissueSessions marks all active sessions for re-issuance: each browser
+// gets a new session token, under the current secret, on its next request.
+// It returns how many sessions were marked.
+func (s *Service) ReissueSessions(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE sessions SET reissue = 1 WHERE expires_at > ?", time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark sessions: %w", err)
+	}
+	marked, _ := result.RowsAffected()
+	s.logger.Info("sessions marked for reissue", "count", marked)
+	return marked, nil
+}
+
+// SessionStats counts active sessions by the secret they are stored under
+type SessionStats struct {
+	Active          int `json:"active"`
+	CurrentSecret   int `json:"current_secret"`
+	PreviousSecrets int `json:"previous_secrets"` // Under an older secret, or from before secrets were used; dropping those secrets ends them
+	PendingReissue  int `json:"pending_reissue"`
+	SecretsAccepted int `json:"secrets_accepted"`
+}
 
+// SessionStats reports how far a secret rotation has progressed
+func (s *Service) SessionStats(ctx context.Context) (*SessionStats, error) {
+	stats := &SessionStats{SecretsAccepted: 1 + len(s.previousSecrets)}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(key_id = ?), 0), COALESCE(SUM(reissue), 0) FROM sessions WHERE expires_at > ?`,
+		sessionKeyID(s.sessionSecret), time.Now(),
+	).Scan(&stats.Active, &stats.CurrentSecret, &stats.PendingReissue)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
 	}
+	stats.PreviousSecrets = stats.Active - stats.CurrentSecret
+	return stats, nil
+}
 
-	if time.Now().After(expiresAt) {
-		// Clean up expired session
-		_, _ = s.db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
-		return false
+// ChangelogSeen returns the version whose release notes a session has
+// seen, "" if none. ok is false when the session does not exist.
+func (s *Service) ChangelogSeen(token string) (version string, ok bool) {
+	sess, ok := s.findSession(token)
+	if !ok {
+		return "", false
 	}
+	return sess.seen, true
+}
 
-	return true
+// SetChangelogSeen records that a session has seen the release notes up
+// to version
+func (s *Service) SetChangelogSeen(token, version string) error {
+	sess, ok := s.findSession(token)
+	if !ok {
+		return ErrSessionExpired
+	}
+	_, err := s.db.Exec("UPDATE sessions SET changelog_seen = ? WHERE id = ?", version, sess.id)
+	return err
 }
 
 // InvalidateSession removes a session
 func (s *Service) InvalidateSession(token string) error {
-	tokenHash := hashToken(token)
-	_, err := s.db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	hashes := s.tokenHashes(token)
+	_, err := s.db.Exec("DELETE FROM sessions WHERE token_hash IN (?"+strings.Repeat(", ?", len(hashes)-1)+")", hashes...)
 	return err
 }
 
 // CleanupExpiredSessions removes all expired sessions
-func (s *Service) CleanupExpiredSessions() error {
-	result, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now())
+func (s *Service) CleanupExpiredSessions(ctx context.Context) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at < ?", time.Now())
 	if err != nil {
 		return err
 	}
@@ -352,12 +505,27 @@ func GetSessionFromRequest(r *http.Request) string {
 	return ""
 }
 
-// hashToken creates a SHA256 hash of the token
+// hashToken creates a SHA256 hash of the token, which is how sessions were
+// stored before they were tied to the session secret
 func hashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
 }
 
+// hashSessionToken creates the HMAC-SHA256 of a session token under a
+// session secret, so changing the secret ends the sessions made with it
+func hashSessionToken(secret, token string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sessionKeyID identifies a session secret without revealing it
+func sessionKeyID(secret string) string {
+	sum := sha256.Sum256([]byte("snipo-session-key:" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
 // HashPassword creates an Argon2id hash of a password
 func HashPassword(password string) (string, error) {
 	salt := make([]byte, 16)
@@ -0,0 +1,130 @@
+// Package cache provides a small in-process read cache.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats reports how well a cache is doing
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// HitRatio returns the share of lookups served from the cache
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// LRU is a size-bounded cache whose entries also expire after a TTL. The
+// least recently used entry is evicted when it is full. It is safe for
+// concurrent use.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[K]*list.Element
+	order    *list.List // Front is most recently used
+	hits     uint64
+	misses   uint64
+	now      func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// New creates a cache holding up to capacity entries for ttl each.
+// A zero ttl keeps entries until they are evicted or invalidated.
+func New[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: max(1, capacity),
+		ttl:      ttl,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value cached for key, if present and not expired
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if e.expires.IsZero() || c.now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			c.hits++
+			return e.value, true
+		}
+		c.remove(el)
+	}
+
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Set caches value for key, evicting the least recently used entry if full
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = &entry[K, V]{key: key, value: value, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Purge removes every entry. Hit and miss counts are kept.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Stats returns hit and miss counts and the number of entries
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// remove unlinks an element; callers hold c.mu
+func (c *LRU[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
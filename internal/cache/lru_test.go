@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	c := New[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Reading "a" makes "b" the least recently used
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1, got %d, %v", v, ok)
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be kept")
+	}
+	if stats := c.Stats(); stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLRU_TTL(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a before the TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to expire")
+	}
+	if stats := c.Stats(); stats.Entries != 0 {
+		t.Errorf("expected expired entry to be removed, got %d entries", stats.Entries)
+	}
+}
+
+func TestLRU_Invalidate(t *testing.T) {
+	c := New[string, int](10, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+	c.Purge()
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected purge to remove b")
+	}
+
+	stats := c.Stats()
+	if stats.Misses != 2 || stats.HitRatio() != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
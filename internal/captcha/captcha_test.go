@@ -0,0 +1,61 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		got = map[string]string{}
+		for key := range r.PostForm {
+			got[key] = r.PostForm.Get(key)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("response") == "good" {
+			_, _ = w.Write([]byte(`{"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	v, err := New(ProviderHCaptcha, "site", "secret")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	v.WithVerifyURL(server.URL)
+
+	if err := v.Verify(context.Background(), "good", "203.0.113.7"); err != nil {
+		t.Fatalf("expected the token accepted, got %v", err)
+	}
+	if got["secret"] != "secret" || got["sitekey"] != "site" || got["remoteip"] != "203.0.113.7" {
+		t.Errorf("unexpected form: %v", got)
+	}
+
+	if err := v.Verify(context.Background(), "bad", ""); !errors.Is(err, ErrFailed) {
+		t.Errorf("expected ErrFailed, got %v", err)
+	}
+	if err := v.Verify(context.Background(), "", ""); !errors.Is(err, ErrFailed) {
+		t.Errorf("expected ErrFailed for an empty token, got %v", err)
+	}
+
+	server.Close()
+	if err := v.Verify(context.Background(), "good", ""); err == nil || errors.Is(err, ErrFailed) {
+		t.Errorf("expected a transport error, got %v", err)
+	}
+}
+
+func TestNew_UnknownProvider(t *testing.T) {
+	if _, err := New("recaptcha", "site", "secret"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if IsProvider("recaptcha") || !IsProvider(ProviderTurnstile) {
+		t.Error("unexpected IsProvider result")
+	}
+}
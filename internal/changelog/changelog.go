@@ -0,0 +1,140 @@
+// Package changelog holds Snipo's release notes, embedded from
+// changelog.json so every binary knows what changed up to its version.
+// Changes not yet in a tagged release are listed under "unreleased"; the
+// entry is renamed to the version when it is tagged.
+package changelog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Unreleased is the version of changes not in a tagged release yet
+const Unreleased = "unreleased"
+
+// Change types
+const (
+	Added    = "added"
+	Changed  = "changed"
+	Fixed    = "fixed"
+	Removed  = "removed"
+	Security = "security"
+)
+
+// Release is a version's release notes
+type Release struct {
+	Version string   `json:"version"`
+	Date    string   `json:"date,omitempty"` // YYYY-MM-DD; empty while unreleased
+	Changes []Change `json:"changes"`
+}
+
+// Change is one entry of release notes
+type Change struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+//go:embed changelog.json
+var changelogJSON []byte
+
+// releases are the embedded release notes, newest first
+var releases []Release
+
+func init() {
+	if err := json.Unmarshal(changelogJSON, &releases); err != nil {
+		panic("changelog: invalid changelog.json: " + err.Error())
+	}
+}
+
+// Releases returns the release notes up to and including version, newest
+// first. Development builds (any version that is not a release number,
+// such as "dev") get all of them, including unreleased changes.
+func Releases(version string) []Release {
+	var result []Release
+	for _, r := range releases {
+		if Compare(r.Version, version) <= 0 {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Between returns the release notes newer than seen, up to and including
+// version, newest first: what changed since a user last looked. An empty
+// seen gives the latest release only.
+func Between(seen, version string) []Release {
+	all := Releases(version)
+	if seen == "" {
+		if len(all) > 1 {
+			all = all[:1]
+		}
+		return all
+	}
+
+	var result []Release
+	for _, r := range all {
+		if Compare(r.Version, seen) > 0 {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Compare compares two versions, returning -1, 0 or 1. Versions are
+// release numbers such as "1.4.2" or "v1.4.2-rc.1", where a pre-release
+// comes before its release. Anything else, such as "dev" or "unreleased",
+// is newer than every release number and equal to each other.
+func Compare(a, b string) int {
+	na, pa, oka := parse(a)
+	nb, pb, okb := parse(b)
+	switch {
+	case !oka && !okb:
+		return 0
+	case !oka:
+		return 1
+	case !okb:
+		return -1
+	}
+
+	for i := range na {
+		if na[i] != nb[i] {
+			if na[i] < nb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
+	case pa < pb:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// parse splits a release number into major, minor and patch, and its
+// pre-release suffix. Missing minor and patch numbers are zero.
+func parse(v string) (numbers [3]int, pre string, ok bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, pre, _ = strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return numbers, "", false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, "", false
+		}
+		numbers[i] = n
+	}
+	return numbers, pre, true
+}
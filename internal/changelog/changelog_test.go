@@ -0,0 +1,88 @@
+package changelog
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.2.0", "1.2", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.0-rc.1", "1.2.0", -1},
+		{"1.2.0-rc.2", "1.2.0-rc.1", 1},
+		{"dev", "1.2.0", 1},
+		{"1.2.0", Unreleased, -1},
+		{"dev", Unreleased, 0},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	saved := releases
+	defer func() { releases = saved }()
+	releases = []Release{
+		{Version: Unreleased},
+		{Version: "1.2.0"},
+		{Version: "1.1.0"},
+		{Version: "1.0.0"},
+	}
+
+	versions := func(rs []Release) []string {
+		var v []string
+		for _, r := range rs {
+			v = append(v, r.Version)
+		}
+		return v
+	}
+	tests := []struct {
+		seen, version string
+		want          []string
+	}{
+		{"1.0.0", "1.2.0", []string{"1.2.0", "1.1.0"}},
+		{"1.2.0", "1.2.0", nil},
+		{"", "1.1.0", []string{"1.1.0"}},
+		{"1.1.0", "dev", []string{Unreleased, "1.2.0"}},
+		{"dev", "dev", nil},
+	}
+	for _, tt := range tests {
+		got := versions(Between(tt.seen, tt.version))
+		if len(got) != len(tt.want) {
+			t.Errorf("Between(%q, %q) = %v, want %v", tt.seen, tt.version, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Between(%q, %q) = %v, want %v", tt.seen, tt.version, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if got := versions(Releases("1.1.0")); len(got) != 2 || got[0] != "1.1.0" {
+		t.Errorf("Releases(1.1.0) = %v", got)
+	}
+}
+
+func TestEmbeddedChangelog(t *testing.T) {
+	types := map[string]bool{Added: true, Changed: true, Fixed: true, Removed: true, Security: true}
+	for i, r := range releases {
+		if r.Version == "" || len(r.Changes) == 0 {
+			t.Errorf("release %d has no version or changes", i)
+		}
+		if i > 0 && Compare(releases[i-1].Version, r.Version) <= 0 {
+			t.Errorf("release %s is not newer than %s", releases[i-1].Version, r.Version)
+		}
+		for _, c := range r.Changes {
+			if !types[c.Type] || c.Text == "" {
+				t.Errorf("release %s has an invalid change %+v", r.Version, c)
+			}
+		}
+	}
+}
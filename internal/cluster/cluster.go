@@ -0,0 +1,136 @@
+// Package cluster tracks whether this instance may write to the database
+// when several instances share one replicated SQLite database.
+package cluster
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+// Role is what an instance does with writes
+type Role string
+
+const (
+	RoleStandalone Role = "standalone" // The only instance
+	RolePrimary    Role = "primary"    // Takes writes for its replicas
+	RoleReplica    Role = "replica"    // Serves reads and forwards writes
+)
+
+// leaseFile is the file LiteFS creates in its mount while another node
+// holds the primary lease. It contains the primary's hostname.
+const leaseFile = ".primary"
+
+// leaseCheckInterval bounds how often the LiteFS lease file is read
+const leaseCheckInterval = time.Second
+
+// Node reports this instance's role. With a static role it never changes;
+// in LiteFS mode it follows the lease, so a replica that is promoted starts
+// taking writes without a restart.
+type Node struct {
+	role       Role   // Static role; empty in LiteFS mode
+	primaryURL string // Configured primary URL
+	leasePath  string
+	port       int
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	leaseRole Role
+	leaseURL  string
+}
+
+// New creates the node for a cluster configuration. In LiteFS mode the lease
+// file is looked up next to the database at dbPath, and without a configured
+// primary URL writes go to http://<primary hostname>:<port>.
+func New(cfg config.ClusterConfig, dbPath string, port int) *Node {
+	n := &Node{primaryURL: cfg.PrimaryURL, port: port}
+	switch cfg.Role {
+	case "primary":
+		n.role = RolePrimary
+	case "replica":
+		n.role = RoleReplica
+	case "litefs":
+		n.leasePath = filepath.Join(filepath.Dir(dbPath), leaseFile)
+	default:
+		n.role = RoleStandalone
+	}
+	return n
+}
+
+// Role returns the current role. A nil node is standalone.
+func (n *Node) Role() Role {
+	if n == nil {
+		return RoleStandalone
+	}
+	if n.role != "" {
+		return n.role
+	}
+	role, _ := n.lease()
+	return role
+}
+
+// IsReplica reports whether writes must go to another instance
+func (n *Node) IsReplica() bool {
+	return n.Role() == RoleReplica
+}
+
+// IsWriter reports whether this instance may write to the database
+func (n *Node) IsWriter() bool {
+	return !n.IsReplica()
+}
+
+// PrimaryURL returns the base URL writes are forwarded to, or "" when this
+// instance is not a replica or the primary is unknown
+func (n *Node) PrimaryURL() string {
+	if n == nil {
+		return ""
+	}
+	if n.role != "" {
+		if n.role != RoleReplica {
+			return ""
+		}
+		return n.primaryURL
+	}
+	_, url := n.lease()
+	return url
+}
+
+// lease reads the LiteFS lease file, at most once per leaseCheckInterval
+func (n *Node) lease() (Role, string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.checkedAt.IsZero() && time.Since(n.checkedAt) < leaseCheckInterval {
+		return n.leaseRole, n.leaseURL
+	}
+	n.checkedAt = time.Now()
+
+	data, err := os.ReadFile(n.leasePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// No other node holds the lease, so this one does
+		n.leaseRole, n.leaseURL = RolePrimary, ""
+	case err != nil:
+		// Keep the last known role rather than flapping on a read error;
+		// before the first successful read, assume a replica so that no
+		// write reaches a database that may be read-only
+		if n.leaseRole == "" {
+			n.leaseRole = RoleReplica
+		}
+	default:
+		n.leaseRole = RoleReplica
+		n.leaseURL = n.primaryURL
+		if holder := strings.TrimSpace(string(data)); n.leaseURL == "" && holder != "" {
+			n.leaseURL = "http://" + net.JoinHostPort(holder, strconv.Itoa(n.port))
+		}
+	}
+	return n.leaseRole, n.leaseURL
+}
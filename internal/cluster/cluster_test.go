@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+func TestNode_StaticRoles(t *testing.T) {
+	var nilNode *Node
+	if nilNode.Role() != RoleStandalone || nilNode.IsReplica() || nilNode.PrimaryURL() != "" {
+		t.Error("expected a nil node to be a standalone writer")
+	}
+
+	primary := New(config.ClusterConfig{Role: "primary", PrimaryURL: "http://primary:8080"}, "data/snipo.db", 8080)
+	if primary.Role() != RolePrimary || !primary.IsWriter() || primary.PrimaryURL() != "" {
+		t.Errorf("unexpected primary: role %s, url %q", primary.Role(), primary.PrimaryURL())
+	}
+
+	replica := New(config.ClusterConfig{Role: "replica", PrimaryURL: "http://primary:8080"}, "data/snipo.db", 8080)
+	if !replica.IsReplica() || replica.PrimaryURL() != "http://primary:8080" {
+		t.Errorf("unexpected replica: role %s, url %q", replica.Role(), replica.PrimaryURL())
+	}
+}
+
+func TestNode_LiteFSLease(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "snipo.db")
+
+	n := New(config.ClusterConfig{Role: "litefs"}, dbPath, 8080)
+	if n.Role() != RolePrimary {
+		t.Fatalf("expected primary without a lease file, got %s", n.Role())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, leaseFile), []byte("node-a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The role is cached between checks
+	if n.Role() != RolePrimary {
+		t.Errorf("expected the cached role, got %s", n.Role())
+	}
+
+	n.checkedAt = n.checkedAt.Add(-leaseCheckInterval)
+	if !n.IsReplica() {
+		t.Fatalf("expected replica while another node holds the lease, got %s", n.Role())
+	}
+	if url := n.PrimaryURL(); url != "http://node-a:8080" {
+		t.Errorf("expected the lease holder's URL, got %q", url)
+	}
+
+	configured := New(config.ClusterConfig{Role: "litefs", PrimaryURL: "https://primary.internal"}, dbPath, 8080)
+	if url := configured.PrimaryURL(); url != "https://primary.internal" {
+		t.Errorf("expected the configured URL, got %q", url)
+	}
+}
@@ -0,0 +1,292 @@
+// Package collab lets several editors change a snippet at once. Each open
+// snippet file is a text document kept as a replicated growable array
+// (RGA), a sequence CRDT: editors apply their own edits immediately and
+// exchange them through a Hub, and every copy converges to the same text
+// whatever order concurrent edits arrive in.
+package collab
+
+import (
+	"errors"
+	"slices"
+	"unicode/utf8"
+)
+
+// BaseSite is the site of the characters a document starts with
+const BaseSite = "base"
+
+// MaxChars bounds the characters a document holds, deleted ones included
+const MaxChars = 4 << 20
+
+var (
+	// ErrInvalidOp is returned for an edit that is malformed
+	ErrInvalidOp = errors.New("invalid edit")
+	// ErrUnknownChar is returned for an insert after a character the
+	// document does not have
+	ErrUnknownChar = errors.New("edit refers to an unknown character")
+	// ErrDocumentFull is returned for an insert that would exceed MaxChars
+	ErrDocumentFull = errors.New("document is too large")
+)
+
+// ID identifies a character: the site (editor session) that inserted it and
+// the site's Lamport clock at the time. The zero ID stands for the start of
+// the document.
+type ID struct {
+	Site  string `json:"site"`
+	Clock uint64 `json:"clock"`
+}
+
+// after reports whether id sorts after o: later clocks first, ties broken by site
+func (id ID) after(o ID) bool {
+	if id.Clock != o.Clock {
+		return id.Clock > o.Clock
+	}
+	return id.Site > o.Site
+}
+
+// Op is an edit. An insert places Text after the character After, its
+// characters taking consecutive clocks from ID. A delete removes the
+// characters in Delete; deleted characters stay as tombstones so that later
+// edits can still refer to them.
+type Op struct {
+	ID     ID     `json:"id"`
+	After  ID     `json:"after"`
+	Text   string `json:"text,omitempty"`
+	Delete []ID   `json:"delete,omitempty"`
+}
+
+type char struct {
+	id      ID
+	r       rune
+	deleted bool
+}
+
+// Doc is one copy of a document. It is not safe for concurrent use.
+type Doc struct {
+	chars []char
+	known map[ID]struct{}
+	clock uint64
+}
+
+// NewDoc returns a document holding text
+func NewDoc(text string) *Doc {
+	d := &Doc{known: map[ID]struct{}{}}
+	if text != "" {
+		_ = d.Apply(Op{ID: ID{Site: BaseSite, Clock: 1}, Text: text})
+	}
+	return d
+}
+
+// Text returns the document's visible text
+func (d *Doc) Text() string {
+	buf := make([]byte, 0, len(d.chars))
+	for _, c := range d.chars {
+		if !c.deleted {
+			buf = utf8.AppendRune(buf, c.r)
+		}
+	}
+	return string(buf)
+}
+
+// Clock returns the highest clock the document has seen
+func (d *Doc) Clock() uint64 {
+	return d.clock
+}
+
+// Apply integrates an edit. Applying an insert twice has no further effect,
+// nor has deleting a character twice or deleting an unknown one.
+func (d *Doc) Apply(op Op) error {
+	if op.Text == "" {
+		if op.ID != (ID{}) || op.After != (ID{}) {
+			return ErrInvalidOp
+		}
+		d.delete(op.Delete)
+		return nil
+	}
+	if len(op.Delete) > 0 {
+		return ErrInvalidOp
+	}
+	return d.insert(op)
+}
+
+func (d *Doc) insert(op Op) error {
+	if op.ID.Site == "" || op.ID.Clock == 0 || !utf8.ValidString(op.Text) {
+		return ErrInvalidOp
+	}
+	if _, ok := d.known[op.ID]; ok {
+		return nil
+	}
+	n := utf8.RuneCountInString(op.Text)
+	if op.ID.Clock+uint64(n) < op.ID.Clock {
+		return ErrInvalidOp
+	}
+	if len(d.chars)+n > MaxChars {
+		return ErrDocumentFull
+	}
+
+	pos := 0
+	if op.After != (ID{}) {
+		i := d.index(op.After)
+		if i < 0 {
+			return ErrUnknownChar
+		}
+		// A character is always inserted after ones its site has seen
+		if op.ID.Clock <= op.After.Clock {
+			return ErrInvalidOp
+		}
+		pos = i + 1
+	}
+	// Skip concurrent inserts at the same place that win over this one,
+	// along with the characters inserted after them
+	for pos < len(d.chars) && d.chars[pos].id.after(op.ID) {
+		pos++
+	}
+
+	chars := make([]char, 0, n)
+	id := op.ID
+	for _, r := range op.Text {
+		if _, ok := d.known[id]; ok {
+			return ErrInvalidOp
+		}
+		chars = append(chars, char{id: id, r: r})
+		id.Clock++
+	}
+	for _, c := range chars {
+		d.known[c.id] = struct{}{}
+	}
+	d.chars = slices.Insert(d.chars, pos, chars...)
+	d.clock = max(d.clock, id.Clock-1)
+	return nil
+}
+
+func (d *Doc) delete(ids []ID) {
+	if len(ids) == 0 {
+		return
+	}
+	remove := make(map[ID]struct{}, len(ids))
+	for _, id := range ids {
+		remove[id] = struct{}{}
+	}
+	for i := range d.chars {
+		if _, ok := remove[d.chars[i].id]; ok {
+			d.chars[i].deleted = true
+		}
+	}
+}
+
+// index returns the position of a character among all characters, or -1
+func (d *Doc) index(id ID) int {
+	if _, ok := d.known[id]; !ok {
+		return -1
+	}
+	for i, c := range d.chars {
+		if c.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert inserts text at a position of the visible text as site, returning
+// the edit to send to other copies
+func (d *Doc) Insert(site string, pos int, text string) (Op, error) {
+	op := Op{ID: ID{Site: site, Clock: d.clock + 1}, Text: text}
+	if pos > 0 {
+		i := d.visible(pos - 1)
+		if i < 0 {
+			return Op{}, ErrInvalidOp
+		}
+		op.After = d.chars[i].id
+	}
+	return op, d.Apply(op)
+}
+
+// Delete removes n characters from a position of the visible text,
+// returning the edit to send to other copies
+func (d *Doc) Delete(pos, n int) (Op, error) {
+	var op Op
+	for ; n > 0; n-- {
+		i := d.visible(pos)
+		if i < 0 {
+			return Op{}, ErrInvalidOp
+		}
+		d.chars[i].deleted = true
+		op.Delete = append(op.Delete, d.chars[i].id)
+	}
+	return op, nil
+}
+
+// visible returns the position among all characters of the n-th visible
+// one, or -1
+func (d *Doc) visible(n int) int {
+	for i, c := range d.chars {
+		if c.deleted {
+			continue
+		}
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return -1
+}
+
+// State is a serialized document. Runs list the characters in document
+// order, grouped into characters inserted together by one site.
+type State struct {
+	Clock uint64 `json:"clock"`
+	Runs  []Run  `json:"runs"`
+}
+
+// Run is a stretch of characters whose clocks count up from Clock
+type Run struct {
+	Site    string `json:"site"`
+	Clock   uint64 `json:"clock"`
+	Text    string `json:"text"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// State serializes the document
+func (d *Doc) State() State {
+	state := State{Clock: d.clock, Runs: []Run{}}
+	var run *Run
+	var next ID
+	var text []byte
+	flush := func() {
+		if run != nil {
+			run.Text = string(text)
+			state.Runs = append(state.Runs, *run)
+		}
+	}
+	for _, c := range d.chars {
+		if run == nil || c.id != next || c.deleted != run.Deleted {
+			flush()
+			run = &Run{Site: c.id.Site, Clock: c.id.Clock, Deleted: c.deleted}
+			text = text[:0]
+		}
+		text = utf8.AppendRune(text, c.r)
+		next = ID{Site: c.id.Site, Clock: c.id.Clock + 1}
+	}
+	flush()
+	return state
+}
+
+// FromState restores a serialized document
+func FromState(state State) (*Doc, error) {
+	d := &Doc{known: map[ID]struct{}{}, clock: state.Clock}
+	for _, run := range state.Runs {
+		if run.Site == "" || run.Clock == 0 || !utf8.ValidString(run.Text) {
+			return nil, ErrInvalidOp
+		}
+		id := ID{Site: run.Site, Clock: run.Clock}
+		for _, r := range run.Text {
+			if _, ok := d.known[id]; ok || len(d.chars) >= MaxChars {
+				return nil, ErrInvalidOp
+			}
+			d.known[id] = struct{}{}
+			d.chars = append(d.chars, char{id: id, r: r, deleted: run.Deleted})
+			d.clock = max(d.clock, id.Clock)
+			id.Clock++
+		}
+	}
+	return d, nil
+}
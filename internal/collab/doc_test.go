@@ -0,0 +1,164 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestDoc_ConcurrentEditsConverge(t *testing.T) {
+	a, b, c := NewDoc("hello world"), NewDoc("hello world"), NewDoc("hello world")
+
+	// Each site edits its copy without seeing the others
+	var ops [3][]Op
+	edit := func(i int) func(Op, error) {
+		return func(op Op, err error) {
+			if err != nil {
+				t.Fatalf("edit failed: %v", err)
+			}
+			ops[i] = append(ops[i], op)
+		}
+	}
+	edit(0)(a.Insert("a", 5, ","))
+	edit(0)(a.Delete(6, 5))
+	edit(1)(b.Insert("b", 5, " there"))
+	edit(1)(b.Insert("b", 0, "> "))
+	edit(2)(c.Insert("c", 5, "!"))
+	edit(2)(c.Delete(0, 1))
+
+	deliver := func(doc *Doc, from ...[]Op) {
+		for _, batch := range from {
+			for _, op := range batch {
+				if err := doc.Apply(op); err != nil {
+					t.Fatalf("apply failed: %v", err)
+				}
+			}
+		}
+	}
+	deliver(a, ops[2], ops[1])
+	deliver(b, ops[0], ops[2])
+	deliver(c, ops[1], ops[0])
+
+	if a.Text() != b.Text() || b.Text() != c.Text() {
+		t.Fatalf("copies diverged: %q, %q, %q", a.Text(), b.Text(), c.Text())
+	}
+	// Concurrent inserts at one place order by clock, then site: c, b, a
+	if want := "> ello! there,d"; a.Text() != want {
+		t.Errorf("expected %q, got %q", want, a.Text())
+	}
+
+	// Redelivered edits change nothing
+	deliver(a, ops[0], ops[1])
+	if a.Text() != b.Text() {
+		t.Errorf("redelivery changed the text to %q", a.Text())
+	}
+}
+
+func TestDoc_RandomEditsConverge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sites := []string{"a", "b", "c"}
+	docs := []*Doc{NewDoc("base"), NewDoc("base"), NewDoc("base")}
+
+	// Edits reach the other copies in the order they were made, as through
+	// the hub, but each copy catches up at its own pace
+	var log []Op
+	seen := make([]int, len(docs))
+	catchUp := func(i, upTo int) {
+		for ; seen[i] < upTo; seen[i]++ {
+			if err := docs[i].Apply(log[seen[i]]); err != nil {
+				t.Fatalf("apply failed: %v", err)
+			}
+		}
+	}
+
+	for round := 0; round < 500; round++ {
+		i := rng.Intn(len(docs))
+		catchUp(i, seen[i]+rng.Intn(len(log)-seen[i]+1))
+
+		doc := docs[i]
+		n := len([]rune(doc.Text()))
+		var op Op
+		var err error
+		if n > 0 && rng.Intn(3) == 0 {
+			pos := rng.Intn(n)
+			op, err = doc.Delete(pos, 1+rng.Intn(min(3, n-pos)))
+		} else {
+			op, err = doc.Insert(sites[i], rng.Intn(n+1), string(rune('a'+rng.Intn(26))))
+		}
+		if err != nil {
+			t.Fatalf("edit failed: %v", err)
+		}
+		log = append(log, op)
+	}
+	for i := range docs {
+		catchUp(i, len(log))
+	}
+
+	for i := 1; i < len(docs); i++ {
+		if docs[i].Text() != docs[0].Text() {
+			t.Fatalf("copies diverged: %q and %q", docs[0].Text(), docs[i].Text())
+		}
+	}
+}
+
+func TestDoc_State(t *testing.T) {
+	doc := NewDoc("héllo")
+	if _, err := doc.Insert("x", 5, " wörld"); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if _, err := doc.Delete(1, 1); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	data, err := json.Marshal(doc.State())
+	if err != nil {
+		t.Fatalf("failed to marshal state: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("failed to unmarshal state: %v", err)
+	}
+	if len(state.Runs) != 4 {
+		t.Errorf("expected 4 runs, got %+v", state.Runs)
+	}
+
+	restored, err := FromState(state)
+	if err != nil {
+		t.Fatalf("failed to restore state: %v", err)
+	}
+	if restored.Text() != "hllo wörld" || restored.Clock() != doc.Clock() {
+		t.Errorf("expected restored text and clock, got %q and %d", restored.Text(), restored.Clock())
+	}
+
+	// Edits made against the original apply to the restored copy
+	op, _ := doc.Insert("x", 10, "!")
+	if err := restored.Apply(op); err != nil || restored.Text() != doc.Text() {
+		t.Errorf("expected %q after applying, got %q (%v)", doc.Text(), restored.Text(), err)
+	}
+}
+
+func TestDoc_InvalidOps(t *testing.T) {
+	doc := NewDoc("abc")
+	tests := []struct {
+		name string
+		op   Op
+		want error
+	}{
+		{"missing site", Op{ID: ID{Clock: 9}, Text: "x"}, ErrInvalidOp},
+		{"unknown anchor", Op{ID: ID{Site: "x", Clock: 9}, After: ID{Site: "y", Clock: 1}, Text: "x"}, ErrUnknownChar},
+		{"clock behind anchor", Op{ID: ID{Site: "x", Clock: 2}, After: ID{Site: BaseSite, Clock: 3}, Text: "x"}, ErrInvalidOp},
+		{"insert and delete", Op{ID: ID{Site: "x", Clock: 9}, Text: "x", Delete: []ID{{Site: BaseSite, Clock: 1}}}, ErrInvalidOp},
+		{"already applied", Op{ID: ID{Site: BaseSite, Clock: 3}, After: ID{Site: BaseSite, Clock: 2}, Text: "c"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := doc.Apply(tt.op); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if doc.Text() != "abc" {
+		t.Errorf("invalid edits changed the text to %q", doc.Text())
+	}
+}
@@ -0,0 +1,402 @@
+package collab
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ErrNotFound is returned by a Source for a snippet or file that does not exist
+var ErrNotFound = errors.New("document not found")
+
+// Message types exchanged with editors
+const (
+	MessageSync  = "sync"  // Server: the document's state and the editor's site
+	MessageOps   = "ops"   // Both ways: edits to apply
+	MessagePeers = "peers" // Server: how many editors have the document open
+	MessagePing  = "ping"  // Editor: keeps the connection alive
+	MessagePong  = "pong"  // Server: answers a ping
+	MessageError = "error" // Server: the connection is about to close
+)
+
+// Message is one frame of the sync protocol
+type Message struct {
+	Type  string `json:"type"`
+	Site  string `json:"site,omitempty"`
+	State *State `json:"state,omitempty"`
+	Ops   []Op   `json:"ops,omitempty"`
+	Peers int    `json:"peers,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Conn carries messages to and from one editor. Receive and Send are
+// called from different goroutines.
+type Conn interface {
+	Receive(msg *Message) error
+	Send(msg *Message) error
+	Close() error
+}
+
+// Key names a document: a snippet's content (FileID 0) or one of its files
+type Key struct {
+	SnippetID string
+	FileID    int64
+}
+
+// Source reads and writes the text behind documents
+type Source interface {
+	// CollabText returns the stored text of a snippet file, or ErrNotFound
+	CollabText(ctx context.Context, snippetID string, fileID int64) (string, error)
+	// MaterializeCollab stores the text of a document in its snippet file
+	MaterializeCollab(ctx context.Context, snippetID string, fileID int64, text string) error
+}
+
+// Store saves document state between materializations
+type Store interface {
+	GetDocument(ctx context.Context, snippetID string, fileID int64) (*models.CollabDocument, error)
+	SaveDocument(ctx context.Context, doc *models.CollabDocument) error
+	DeleteDocument(ctx context.Context, snippetID string, fileID int64) error
+}
+
+// sendQueue bounds the messages waiting for a slow editor, who is
+// disconnected when it fills up
+const sendQueue = 256
+
+// Hub keeps the documents that editors have open. Edits are relayed to the
+// other editors of a document as they arrive; Save persists document state
+// and Materialize writes the text back to the snippet, both meant to run
+// periodically. A document is materialized and dropped from memory when
+// its last editor leaves.
+type Hub struct {
+	source Source
+	store  Store
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	rooms map[Key]*room
+}
+
+type room struct {
+	key     Key
+	mu      sync.Mutex
+	doc     *Doc
+	base    string // Text last read from or written to the source
+	dirty   bool   // State changed since last saved
+	members map[*member]struct{}
+}
+
+type member struct {
+	conn   Conn
+	site   string
+	out    chan *Message
+	done   chan struct{}
+	closer sync.Once
+}
+
+// NewHub creates a hub reading and writing text through source and saving
+// document state in store
+func NewHub(source Source, store Store, logger *slog.Logger) *Hub {
+	return &Hub{source: source, store: store, logger: logger, rooms: map[Key]*room{}}
+}
+
+// Check returns ErrNotFound unless the document can be opened
+func (h *Hub) Check(ctx context.Context, key Key) error {
+	_, err := h.source.CollabText(ctx, key.SnippetID, key.FileID)
+	return err
+}
+
+// Serve lets an editor work on a document until the connection ends
+func (h *Hub) Serve(ctx context.Context, key Key, conn Conn) error {
+	rm, m, err := h.join(ctx, key, conn)
+	if err != nil {
+		_ = conn.Send(&Message{Type: MessageError, Error: err.Error()})
+		_ = conn.Close()
+		return err
+	}
+	defer h.leave(context.WithoutCancel(ctx), rm, m)
+	go m.write()
+
+	for {
+		var msg Message
+		if err := conn.Receive(&msg); err != nil {
+			return nil
+		}
+		switch msg.Type {
+		case MessageOps:
+			// The connection closes once the error is sent
+			if err := h.apply(rm, m, msg.Ops); err != nil {
+				m.send(&Message{Type: MessageError, Error: err.Error()})
+			}
+		case MessagePing:
+			m.send(&Message{Type: MessagePong})
+		}
+	}
+}
+
+func (h *Hub) join(ctx context.Context, key Key, conn Conn) (*room, *member, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rm := h.rooms[key]
+	if rm == nil {
+		var err error
+		if rm, err = h.load(ctx, key); err != nil {
+			return nil, nil, err
+		}
+		h.rooms[key] = rm
+	}
+
+	m := &member{conn: conn, site: newSite(), out: make(chan *Message, sendQueue), done: make(chan struct{})}
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.members[m] = struct{}{}
+	state := rm.doc.State()
+	m.send(&Message{Type: MessageSync, Site: m.site, State: &state})
+	rm.broadcastPeers()
+	return rm, m, nil
+}
+
+// load opens a document, recovering edits saved but not yet materialized
+// as long as the snippet has not changed since
+func (h *Hub) load(ctx context.Context, key Key) (*room, error) {
+	text, err := h.source.CollabText(ctx, key.SnippetID, key.FileID)
+	if err != nil {
+		return nil, err
+	}
+	rm := &room{key: key, doc: NewDoc(text), base: text, members: map[*member]struct{}{}}
+
+	saved, err := h.store.GetDocument(ctx, key.SnippetID, key.FileID)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil && saved.BaseHash == hashText(text) {
+		var state State
+		if err := json.Unmarshal([]byte(saved.State), &state); err != nil {
+			h.logger.WarnContext(ctx, "discarding unreadable collab state", "snippet_id", key.SnippetID, "file_id", key.FileID, "error", err)
+		} else if doc, err := FromState(state); err != nil {
+			h.logger.WarnContext(ctx, "discarding invalid collab state", "snippet_id", key.SnippetID, "file_id", key.FileID, "error", err)
+		} else {
+			rm.doc = doc
+		}
+	}
+	return rm, nil
+}
+
+func (h *Hub) leave(ctx context.Context, rm *room, m *member) {
+	m.close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rm.mu.Lock()
+	delete(rm.members, m)
+	empty := len(rm.members) == 0
+	if !empty {
+		rm.broadcastPeers()
+	}
+	rm.mu.Unlock()
+
+	if empty {
+		h.materialize(ctx, rm)
+		delete(h.rooms, rm.key)
+	}
+}
+
+// apply integrates an editor's edits and relays them to the other editors.
+// Edits applied before an invalid one are still relayed.
+func (h *Hub) apply(rm *room, from *member, ops []Op) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	var err error
+	applied := ops
+	for i, op := range ops {
+		if op.Text != "" && op.ID.Site != from.site {
+			err = ErrInvalidOp
+		} else {
+			err = rm.doc.Apply(op)
+		}
+		if err != nil {
+			applied = ops[:i]
+			break
+		}
+	}
+	if len(applied) == 0 {
+		return err
+	}
+
+	rm.dirty = true
+	for m := range rm.members {
+		if m != from {
+			m.send(&Message{Type: MessageOps, Ops: applied})
+		}
+	}
+	return err
+}
+
+// Save persists the state of documents changed since last saved
+func (h *Hub) Save(ctx context.Context) error {
+	for _, rm := range h.openRooms() {
+		rm.mu.Lock()
+		h.save(ctx, rm)
+		rm.mu.Unlock()
+	}
+	return nil
+}
+
+// Materialize writes the text of open documents back to their snippets
+func (h *Hub) Materialize(ctx context.Context) error {
+	for _, rm := range h.openRooms() {
+		h.materialize(ctx, rm)
+	}
+	return nil
+}
+
+// Close materializes open documents and disconnects their editors. It is
+// called on shutdown.
+func (h *Hub) Close(ctx context.Context) {
+	for _, rm := range h.openRooms() {
+		h.materialize(ctx, rm)
+		rm.mu.Lock()
+		for m := range rm.members {
+			m.close()
+		}
+		rm.mu.Unlock()
+	}
+}
+
+func (h *Hub) openRooms() []*room {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, rm := range h.rooms {
+		rooms = append(rooms, rm)
+	}
+	return rooms
+}
+
+// materialize stores a document's text in its snippet file. When the file
+// was changed some other way since, the document starts over from the
+// file's text instead and its editors are sent the new state.
+func (h *Hub) materialize(ctx context.Context, rm *room) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	key := rm.key
+
+	current, err := h.source.CollabText(ctx, key.SnippetID, key.FileID)
+	if errors.Is(err, ErrNotFound) {
+		for m := range rm.members {
+			m.send(&Message{Type: MessageError, Error: err.Error()})
+			m.close()
+		}
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to read snippet for collab", "snippet_id", key.SnippetID, "file_id", key.FileID, "error", err)
+		return
+	}
+
+	if current != rm.base {
+		rm.doc = NewDoc(current)
+		rm.base = current
+		rm.dirty = false
+		if err := h.store.DeleteDocument(ctx, key.SnippetID, key.FileID); err != nil {
+			h.logger.WarnContext(ctx, "failed to delete collab state", "snippet_id", key.SnippetID, "file_id", key.FileID, "error", err)
+		}
+		state := rm.doc.State()
+		for m := range rm.members {
+			m.send(&Message{Type: MessageSync, Site: m.site, State: &state})
+		}
+		return
+	}
+
+	if text := rm.doc.Text(); text != rm.base {
+		if err := h.source.MaterializeCollab(ctx, key.SnippetID, key.FileID, text); err != nil {
+			h.logger.WarnContext(ctx, "failed to materialize collab document", "snippet_id", key.SnippetID, "file_id", key.FileID, "error", err)
+		} else {
+			rm.base = text
+			rm.dirty = true
+		}
+	}
+	h.save(ctx, rm)
+}
+
+// save persists a room's state if it changed; the caller holds rm.mu
+func (h *Hub) save(ctx context.Context, rm *room) {
+	if !rm.dirty {
+		return
+	}
+	state, err := json.Marshal(rm.doc.State())
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to encode collab state", "snippet_id", rm.key.SnippetID, "error", err)
+		return
+	}
+	doc := &models.CollabDocument{SnippetID: rm.key.SnippetID, FileID: rm.key.FileID, State: string(state), BaseHash: hashText(rm.base)}
+	if err := h.store.SaveDocument(ctx, doc); err != nil {
+		h.logger.WarnContext(ctx, "failed to save collab state", "snippet_id", rm.key.SnippetID, "file_id", rm.key.FileID, "error", err)
+		return
+	}
+	rm.dirty = false
+}
+
+// broadcastPeers tells the editors how many have the document open; the
+// caller holds rm.mu
+func (rm *room) broadcastPeers() {
+	for m := range rm.members {
+		m.send(&Message{Type: MessagePeers, Peers: len(rm.members)})
+	}
+}
+
+// send queues a message, disconnecting the editor if it is not keeping up
+func (m *member) send(msg *Message) {
+	select {
+	case <-m.done:
+	case m.out <- msg:
+	default:
+		m.close()
+	}
+}
+
+func (m *member) write() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case msg := <-m.out:
+			if err := m.conn.Send(msg); err != nil {
+				m.close()
+				return
+			}
+			if msg.Type == MessageError {
+				m.close()
+				return
+			}
+		}
+	}
+}
+
+func (m *member) close() {
+	m.closer.Do(func() {
+		close(m.done)
+		_ = m.conn.Close()
+	})
+}
+
+// newSite returns a random site ID for an editor
+func newSite() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,6 +4,10 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -12,13 +16,24 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	S3       S3Config
-	Logging  LoggingConfig
-	API      APIConfig
-	Features FeatureFlags
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Auth      AuthConfig
+	S3        S3Config
+	Backup    BackupConfig
+	Cache     CacheConfig
+	Logging   LoggingConfig
+	API       APIConfig
+	Features  FeatureFlags
+	Alerts    AlertConfig
+	SMTP      SMTPConfig
+	Import    ImportConfig
+	Vault     VaultConfig
+	Captcha   CaptchaConfig
+	Updates   UpdateConfig
+	Telemetry TelemetryConfig
+	Events    EventConfig
+	Cluster   ClusterConfig
 }
 
 // ServerConfig holds HTTP server settings
@@ -27,14 +42,29 @@ type ServerConfig struct {
 	Port               int
 	ReadTimeout        time.Duration
 	WriteTimeout       time.Duration
+	RequestTimeout     time.Duration // Budget for API requests; their queries are cancelled when it runs out
+	SearchTimeout      time.Duration // Shorter budget for search and list requests
 	TrustProxy         bool
+	TrustedProxies     []netip.Prefix // Reverse proxies whose X-Forwarded-For hops are skipped
 	MaxFilesPerSnippet int
+	MaxPinnedSnippets  int
+	AutoSlugs          bool          // Derive unique slugs from titles for new snippets
+	PublicCacheMaxAge  time.Duration // How long caches may keep public snippet responses
+	MaxInboxItems      int           // Inbox capacity; the oldest items are dropped beyond it
+	RobotsTxt          string        // Content of robots.txt; empty serves the default
+
+	ShareAnalytics          bool          // Count public snippet views per day, referrer and client type
+	ShareAnalyticsIPs       bool          // Also store viewer IPs, to count unique visitors
+	ShareAnalyticsRetention time.Duration // How long view counts (and IPs) are kept
 }
 
 // DatabaseConfig holds SQLite settings
 type DatabaseConfig struct {
 	Path            string
 	MaxOpenConns    int
+	MaxIdleConns    int           // Connections kept open while idle
+	ConnMaxIdleTime time.Duration // Close connections idle this long (0 = never)
+	StatementCache  int           // Prepared statements kept for hot queries (0 = none)
 	BusyTimeout     int
 	JournalMode     string
 	SynchronousMode string
@@ -46,27 +76,70 @@ type AuthConfig struct {
 	MasterPasswordHash     string // Pre-hashed password (Argon2id format)
 	Disabled               bool   // Disable authentication entirely (use with external auth like Authelia)
 	SessionSecret          string
-	SessionSecretGenerated bool // True if session secret was auto-generated (not recommended for production)
+	SessionSecretGenerated bool     // True if session secret was auto-generated (not recommended for production)
+	PreviousSessionSecrets []string // Older secrets still accepted while rotating (the rest of SNIPO_SESSION_SECRET)
 	SessionDuration        time.Duration
 	RateLimit              int
 	RateLimitWindow        time.Duration
+	BootstrapToken         string // Admin API token provisioned at startup (SNIPO_BOOTSTRAP_TOKEN); never logged
 }
 
-// S3Config holds S3 storage settings
+// S3Config holds backup storage settings. Despite the name it also
+// configures the Azure Blob, Google Cloud Storage and local directory
+// backends, and scheduled backups to whichever is selected.
 type S3Config struct {
 	Enabled         bool
+	Provider        string // "s3" (default), "azure", "gcs" or "local"
 	Endpoint        string
 	AccessKeyID     string
 	SecretAccessKey string
 	Bucket          string
 	Region          string
 	UseSSL          bool
+	SSE             string            // "", "s3" (SSE-S3) or "kms" (SSE-KMS)
+	SSEKMSKeyID     string            // KMS key ID or ARN for SSE-KMS
+	Tags            map[string]string // Object tags applied to uploaded backups
+	Prefix          string            // Key prefix template, e.g. "{hostname}/{date}/"
+
+	AzureAccountName   string
+	AzureAccountKey    string
+	AzureEndpoint      string // Blob service URL, for sovereign clouds or Azurite
+	GCSCredentialsFile string // Service account key file; empty uses application default credentials
+	LocalDir           string // Backup directory for the local provider
+
+	Retention        int           // Backups to keep; older ones are deleted after a sync. 0 keeps all
+	Schedule         time.Duration // Interval between scheduled backups; 0 disables them
+	ScheduleFormat   string        // Export format of scheduled backups
+	SchedulePassword string        // Optional encryption password for scheduled backups
+}
+
+// BackupConfig holds import and restore settings
+type BackupConfig struct {
+	SafetySnapshots    bool   // Save the current data before replacing imports and S3 restores
+	SafetySnapshotDir  string // Where safety snapshots are written
+	SafetySnapshotKeep int    // Snapshots to keep; 0 keeps all
+
+	LibrarySnapshots         bool          // Record a daily manifest of snippet IDs and checksums
+	LibrarySnapshotRetention time.Duration // How long daily manifests are kept; 0 keeps all
+}
+
+// CacheConfig holds the in-process read cache settings
+type CacheConfig struct {
+	Enabled  bool
+	TTL      time.Duration // How long entries live; bounds staleness from writes outside the app
+	Snippets int           // Most recently fetched snippets to keep
 }
 
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
-	Level  string
-	Format string
+	Level          string
+	Format         string
+	Stdout         bool          // Write logs to stdout
+	File           string        // Also append logs to this file (empty = no file)
+	FileMaxSize    int           // Rotate the file beyond this many megabytes (0 = never)
+	FileMaxAge     time.Duration // Rotate the file once it is this old (0 = never)
+	FileMaxBackups int           // Rotated files to keep (0 = keep all)
+	Syslog         string        // "local", or udp://host:port or tcp://host:port (empty = off)
 }
 
 // APIConfig holds API-specific settings
@@ -75,6 +148,102 @@ type APIConfig struct {
 	RateLimitRead  int      // requests per hour for read operations
 	RateLimitWrite int      // requests per hour for write operations
 	RateLimitAdmin int      // requests per hour for admin operations
+	RateLimitIP    int      // requests per hour per IP across all tokens (0 = disabled)
+
+	RateLimitPublic      int // requests per minute per IP on public routes
+	RateLimitPublicBurst int // requests allowed at once on public routes
+
+	RateLimitReports    int // abuse reports per hour per IP
+	ReportChallengeBits int // proof-of-work difficulty of abuse reports (0 = none)
+}
+
+// AlertConfig holds alert and notification settings
+type AlertConfig struct {
+	WebhookURL            string        // Destination for alert webhooks (empty = disabled)
+	WebhookSecret         string        // Optional HMAC secret for signing webhook payloads
+	FailedLoginThreshold  int           // Failed attempts from one IP that trigger an alert (0 = disabled)
+	FailedLoginWindow     time.Duration // Window for counting failed attempts
+	NewIPAlert            bool          // Alert on a successful login from a previously unseen IP
+	LoginEventRetention   time.Duration // How long login events are kept
+	BackupReports         bool          // Notify on S3 backup/restore success and failure
+	PublicViewAlert       bool          // Notify when a shared (public) snippet is viewed
+	DBSizeWarnMB          int           // Warn when the database file exceeds this size (0 = disabled)
+	NotificationRetention time.Duration // How long notification center entries are kept
+}
+
+// SMTPConfig holds outgoing email settings
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLSMode  string // starttls, tls, or none
+}
+
+// CaptchaConfig holds hCaptcha or Cloudflare Turnstile settings
+type CaptchaConfig struct {
+	Provider   string // hcaptcha or turnstile; empty disables captchas
+	SiteKey    string
+	SecretKey  string
+	LoginAfter int // Failed logins from all IPs within an hour before logging in needs a captcha
+}
+
+// Enabled reports whether a captcha provider is configured
+func (c CaptchaConfig) Enabled() bool {
+	return c.Provider != ""
+}
+
+// UpdateConfig holds the update checker settings
+type UpdateConfig struct {
+	Check      bool   // Look up the latest release on GitHub once a day (off = never contacts GitHub)
+	Repository string // GitHub repository whose releases are checked, as owner/name
+	Notify     bool   // Send a notification when a newer release is found
+}
+
+// TelemetryConfig holds the anonymous usage report settings
+type TelemetryConfig struct {
+	Enabled bool   // Send the report once a week (off = nothing is ever sent)
+	URL     string // Collector the report is posted to
+	Blocked bool   // Hard off switch (SNIPO_TELEMETRY_DISABLED or DO_NOT_TRACK), wins over Enabled
+}
+
+// EventConfig holds the message broker that snippet events are published to
+type EventConfig struct {
+	BrokerURL   string // mqtt://, mqtts://, nats:// or nats+tls:// URL with optional credentials (empty = disabled)
+	TopicPrefix string // Prefix of MQTT topics and NATS subjects
+}
+
+// ClusterConfig holds the role of this instance when several share one
+// replicated database
+type ClusterConfig struct {
+	Role          string // "standalone" (default), "primary", "replica" or "litefs" (role follows the LiteFS lease)
+	PrimaryURL    string // Base URL of the primary that replicas forward writes to
+	ReplicaWrites string // What replicas do with writes: "proxy" (default), "redirect" or "reject"
+}
+
+// ImportConfig holds URL import settings
+type ImportConfig struct {
+	URLTimeout      time.Duration // Total time allowed to fetch a URL
+	URLMaxBytes     int64         // Maximum size of a fetched page
+	URLAllowPrivate bool          // Allow fetching private/loopback addresses (disables SSRF protection)
+}
+
+// VaultConfig holds Obsidian vault sync settings
+type VaultConfig struct {
+	Path      string        // Vault directory on this machine
+	URL       string        // Local REST API plugin URL, used when Path is empty
+	APIKey    string        // Local REST API plugin key
+	Insecure  bool          // Accept the plugin's self-signed certificate
+	Folder    string        // Vault folder mirrored to snipo; "/" syncs the whole vault
+	Schedule  time.Duration // Interval between syncs; 0 syncs only on request
+	Conflicts string        // Which side wins when both changed: newest, vault, snipo or skip
+}
+
+// Enabled reports whether a vault is configured
+func (c VaultConfig) Enabled() bool {
+	return c.Path != "" || c.URL != ""
 }
 
 // FeatureFlags holds feature toggle settings
@@ -83,26 +252,69 @@ type FeatureFlags struct {
 	S3Sync         bool
 	APITokens      bool
 	BackupRestore  bool
+	PasteAPI       bool
+	VaultSync      bool
+	Collab         bool
+	Badges         bool // Public SVG badges counting public snippets
 }
 
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{}
 
+	// Secrets may come from mounted files (SNIPO_X_FILE) instead of the
+	// environment, so they stay out of docker inspect
+	secrets, err := readSecretFiles()
+	if err != nil {
+		return nil, err
+	}
+
 	// Server
 	cfg.Server.Host = getEnv("SNIPO_HOST", "0.0.0.0")
 	cfg.Server.Port = getEnvInt("SNIPO_PORT", 8080)
 	cfg.Server.ReadTimeout = getEnvDuration("SNIPO_READ_TIMEOUT", 30*time.Second)
 	cfg.Server.WriteTimeout = getEnvDuration("SNIPO_WRITE_TIMEOUT", 30*time.Second)
+	// By default requests get as long as a response may take to write;
+	// past that the client never sees the result
+	cfg.Server.RequestTimeout = getEnvDuration("SNIPO_REQUEST_TIMEOUT", cfg.Server.WriteTimeout)
+	cfg.Server.SearchTimeout = getEnvDuration("SNIPO_SEARCH_TIMEOUT", 10*time.Second)
+	if cfg.Server.RequestTimeout < 0 || cfg.Server.SearchTimeout < 0 {
+		return nil, errors.New("SNIPO_REQUEST_TIMEOUT and SNIPO_SEARCH_TIMEOUT must not be negative")
+	}
 	cfg.Server.TrustProxy = getEnvBool("SNIPO_TRUST_PROXY", false)
+	trustedProxies, err := parseTrustedProxies(getEnv("SNIPO_TRUSTED_PROXIES", "127.0.0.0/8,::1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNIPO_TRUSTED_PROXIES: %w", err)
+	}
+	cfg.Server.TrustedProxies = trustedProxies
 	cfg.Server.MaxFilesPerSnippet = getEnvInt("SNIPO_MAX_FILES_PER_SNIPPET", 10)
+	cfg.Server.MaxPinnedSnippets = getEnvInt("SNIPO_MAX_PINNED_SNIPPETS", 10)
+	cfg.Server.AutoSlugs = getEnvBool("SNIPO_AUTO_SLUGS", false)
+	cfg.Server.PublicCacheMaxAge = getEnvDuration("SNIPO_PUBLIC_CACHE_MAX_AGE", 5*time.Minute)
+	if path := os.Getenv("SNIPO_ROBOTS_TXT"); path != "" {
+		robots, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SNIPO_ROBOTS_TXT: %w", err)
+		}
+		cfg.Server.RobotsTxt = string(robots)
+	}
+	cfg.Server.MaxInboxItems = getEnvInt("SNIPO_INBOX_MAX_ITEMS", 100)
+	cfg.Server.ShareAnalytics = getEnvBool("SNIPO_SHARE_ANALYTICS", true)
+	cfg.Server.ShareAnalyticsIPs = getEnvBool("SNIPO_SHARE_ANALYTICS_IPS", false)
+	cfg.Server.ShareAnalyticsRetention = getEnvDuration("SNIPO_SHARE_ANALYTICS_RETENTION", 365*24*time.Hour)
 
 	// Database
 	cfg.Database.Path = getEnv("SNIPO_DB_PATH", "./data/snipo.db")
 	cfg.Database.MaxOpenConns = getEnvInt("SNIPO_DB_MAX_CONNS", 1)
+	cfg.Database.MaxIdleConns = getEnvInt("SNIPO_DB_MAX_IDLE_CONNS", cfg.Database.MaxOpenConns)
+	cfg.Database.ConnMaxIdleTime = getEnvDuration("SNIPO_DB_CONN_MAX_IDLE_TIME", 0)
+	cfg.Database.StatementCache = getEnvInt("SNIPO_DB_STATEMENT_CACHE", 64)
 	cfg.Database.BusyTimeout = getEnvInt("SNIPO_DB_BUSY_TIMEOUT", 5000)
 	cfg.Database.JournalMode = getEnv("SNIPO_DB_JOURNAL", "WAL")
 	cfg.Database.SynchronousMode = getEnv("SNIPO_DB_SYNC", "NORMAL")
+	if cfg.Database.MaxOpenConns < 1 || cfg.Database.MaxIdleConns < 0 || cfg.Database.StatementCache < 0 {
+		return nil, errors.New("SNIPO_DB_MAX_CONNS must be at least 1, and SNIPO_DB_MAX_IDLE_CONNS and SNIPO_DB_STATEMENT_CACHE not negative")
+	}
 
 	// Auth - Check if authentication is disabled
 	cfg.Auth.Disabled = getEnvBool("SNIPO_DISABLE_AUTH", false)
@@ -114,8 +326,8 @@ func Load() (*Config, error) {
 		cfg.Auth.MasterPasswordHash = ""
 	} else {
 		// Auth enabled - Support both plain text password and pre-hashed password
-		cfg.Auth.MasterPassword = os.Getenv("SNIPO_MASTER_PASSWORD")
-		cfg.Auth.MasterPasswordHash = os.Getenv("SNIPO_MASTER_PASSWORD_HASH")
+		cfg.Auth.MasterPassword = secrets.get("SNIPO_MASTER_PASSWORD")
+		cfg.Auth.MasterPasswordHash = secrets.get("SNIPO_MASTER_PASSWORD_HASH")
 		
 		// At least one password method must be provided when auth is enabled
 		if cfg.Auth.MasterPassword == "" && cfg.Auth.MasterPasswordHash == "" {
@@ -132,7 +344,15 @@ func Load() (*Config, error) {
 		}
 	}
 
-	sessionSecret := os.Getenv("SNIPO_SESSION_SECRET")
+	// A comma-separated list rotates the secret: the first signs new
+	// sessions, the others keep existing sessions valid
+	sessionSecret, previous, _ := strings.Cut(secrets.get("SNIPO_SESSION_SECRET"), ",")
+	sessionSecret = strings.TrimSpace(sessionSecret)
+	for _, secret := range strings.Split(previous, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			cfg.Auth.PreviousSessionSecrets = append(cfg.Auth.PreviousSessionSecrets, secret)
+		}
+	}
 	if sessionSecret == "" {
 		secret, err := generateSecret()
 		if err != nil {
@@ -145,19 +365,77 @@ func Load() (*Config, error) {
 	cfg.Auth.SessionDuration = getEnvDuration("SNIPO_SESSION_DURATION", 168*time.Hour)
 	cfg.Auth.RateLimit = getEnvInt("SNIPO_RATE_LIMIT", 100)
 	cfg.Auth.RateLimitWindow = getEnvDuration("SNIPO_RATE_WINDOW", 1*time.Minute)
+	cfg.Auth.BootstrapToken = strings.TrimSpace(secrets.get("SNIPO_BOOTSTRAP_TOKEN"))
+	if cfg.Auth.BootstrapToken != "" && len(cfg.Auth.BootstrapToken) < 32 {
+		return nil, errors.New("SNIPO_BOOTSTRAP_TOKEN must be at least 32 characters (generate one with: openssl rand -hex 32)")
+	}
 
-	// S3
-	cfg.S3.Enabled = getEnvBool("SNIPO_S3_ENABLED", false)
+	// Object storage
+	cfg.S3.Provider = strings.ToLower(getEnv("SNIPO_STORAGE_PROVIDER", "s3"))
+	switch cfg.S3.Provider {
+	case "s3", "azure", "gcs", "local":
+	default:
+		return nil, fmt.Errorf("SNIPO_STORAGE_PROVIDER must be s3, azure, gcs or local, got %q", cfg.S3.Provider)
+	}
+	// Choosing another provider turns backups on without SNIPO_S3_ENABLED
+	cfg.S3.Enabled = getEnvBool("SNIPO_S3_ENABLED", cfg.S3.Provider != "s3")
 	cfg.S3.Endpoint = os.Getenv("SNIPO_S3_ENDPOINT")
-	cfg.S3.AccessKeyID = os.Getenv("SNIPO_S3_ACCESS_KEY")
-	cfg.S3.SecretAccessKey = os.Getenv("SNIPO_S3_SECRET_KEY")
+	cfg.S3.AccessKeyID = secrets.get("SNIPO_S3_ACCESS_KEY")
+	cfg.S3.SecretAccessKey = secrets.get("SNIPO_S3_SECRET_KEY")
 	cfg.S3.Bucket = os.Getenv("SNIPO_S3_BUCKET")
 	cfg.S3.Region = getEnv("SNIPO_S3_REGION", "us-east-1")
 	cfg.S3.UseSSL = getEnvBool("SNIPO_S3_SSL", true)
+	cfg.S3.SSEKMSKeyID = os.Getenv("SNIPO_S3_SSE_KMS_KEY_ID")
+	cfg.S3.AzureAccountName = os.Getenv("SNIPO_AZURE_ACCOUNT_NAME")
+	cfg.S3.AzureAccountKey = secrets.get("SNIPO_AZURE_ACCOUNT_KEY")
+	cfg.S3.AzureEndpoint = os.Getenv("SNIPO_AZURE_ENDPOINT")
+	cfg.S3.GCSCredentialsFile = os.Getenv("SNIPO_GCS_CREDENTIALS_FILE")
+	cfg.S3.LocalDir = getEnv("SNIPO_BACKUP_DIR", "./data/backups")
+	switch cfg.S3.Provider {
+	case "azure":
+		cfg.S3.Bucket = getEnv("SNIPO_AZURE_CONTAINER", cfg.S3.Bucket)
+	case "gcs":
+		cfg.S3.Bucket = getEnv("SNIPO_GCS_BUCKET", cfg.S3.Bucket)
+	}
+	cfg.S3.Prefix = getEnv("SNIPO_S3_PREFIX", "backups/")
+	switch sse := strings.ToLower(os.Getenv("SNIPO_S3_SSE")); sse {
+	case "", "none":
+	case "s3", "aes256":
+		cfg.S3.SSE = "s3"
+	case "kms", "aws:kms":
+		cfg.S3.SSE = "kms"
+	default:
+		return nil, fmt.Errorf("SNIPO_S3_SSE must be none, s3 or kms, got %q", sse)
+	}
+	if tags := os.Getenv("SNIPO_S3_TAGS"); tags != "" {
+		cfg.S3.Tags = map[string]string{}
+		for _, pair := range strings.Split(tags, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				return nil, fmt.Errorf("SNIPO_S3_TAGS must be a comma-separated list of key=value pairs, got %q", pair)
+			}
+			cfg.S3.Tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	cfg.S3.Retention = getEnvInt("SNIPO_BACKUP_RETENTION", 0)
+	cfg.S3.Schedule = getEnvDuration("SNIPO_BACKUP_SCHEDULE", 0)
+	cfg.S3.ScheduleFormat = getEnv("SNIPO_BACKUP_FORMAT", "zip")
+	cfg.S3.SchedulePassword = secrets.get("SNIPO_BACKUP_PASSWORD")
+
+	// Backup
+	cfg.Backup.SafetySnapshots = getEnvBool("SNIPO_SAFETY_SNAPSHOTS", true)
+	cfg.Backup.SafetySnapshotDir = getEnv("SNIPO_SAFETY_SNAPSHOT_DIR", "./data/snapshots")
+	cfg.Backup.SafetySnapshotKeep = getEnvInt("SNIPO_SAFETY_SNAPSHOT_KEEP", 10)
+	cfg.Backup.LibrarySnapshots = getEnvBool("SNIPO_LIBRARY_SNAPSHOTS", true)
+	cfg.Backup.LibrarySnapshotRetention = getEnvDuration("SNIPO_LIBRARY_SNAPSHOT_RETENTION", 365*24*time.Hour)
+
+	// Read cache
+	cfg.Cache.Enabled = getEnvBool("SNIPO_CACHE_ENABLED", true)
+	cfg.Cache.TTL = getEnvDuration("SNIPO_CACHE_TTL", time.Minute)
+	cfg.Cache.Snippets = getEnvInt("SNIPO_CACHE_SNIPPETS", 500)
 
 	// Logging
-	cfg.Logging.Level = getEnv("SNIPO_LOG_LEVEL", "info")
-	cfg.Logging.Format = getEnv("SNIPO_LOG_FORMAT", "json")
+	cfg.Logging = LoadLogging()
 
 	// API
 	originsStr := getEnv("SNIPO_ALLOWED_ORIGINS", "*")
@@ -173,23 +451,255 @@ func Load() (*Config, error) {
 	cfg.API.RateLimitRead = getEnvInt("SNIPO_RATE_LIMIT_READ", 1000)
 	cfg.API.RateLimitWrite = getEnvInt("SNIPO_RATE_LIMIT_WRITE", 500)
 	cfg.API.RateLimitAdmin = getEnvInt("SNIPO_RATE_LIMIT_ADMIN", 100)
+	cfg.API.RateLimitIP = getEnvInt("SNIPO_RATE_LIMIT_IP", 2000)
+	cfg.API.RateLimitPublic = getEnvInt("SNIPO_RATE_LIMIT_PUBLIC", 60)
+	cfg.API.RateLimitPublicBurst = getEnvInt("SNIPO_RATE_LIMIT_PUBLIC_BURST", 20)
+	cfg.API.RateLimitReports = getEnvInt("SNIPO_RATE_LIMIT_REPORTS", 5)
+	cfg.API.ReportChallengeBits = getEnvInt("SNIPO_REPORT_CHALLENGE_BITS", 0)
+	if cfg.API.ReportChallengeBits < 0 || cfg.API.ReportChallengeBits > 28 {
+		return nil, fmt.Errorf("SNIPO_REPORT_CHALLENGE_BITS must be between 0 and 28, got %d", cfg.API.ReportChallengeBits)
+	}
 
 	// Feature Flags
 	cfg.Features.PublicSnippets = getEnvBool("SNIPO_ENABLE_PUBLIC_SNIPPETS", true)
 	cfg.Features.S3Sync = cfg.S3.Enabled // S3Sync follows S3.Enabled
 	cfg.Features.APITokens = getEnvBool("SNIPO_ENABLE_API_TOKENS", true)
 	cfg.Features.BackupRestore = getEnvBool("SNIPO_ENABLE_BACKUP_RESTORE", true)
+	cfg.Features.PasteAPI = getEnvBool("SNIPO_ENABLE_PASTE_API", false)
+	cfg.Features.Collab = getEnvBool("SNIPO_ENABLE_COLLAB", false)
+	cfg.Features.Badges = getEnvBool("SNIPO_ENABLE_BADGES", false)
+
+	// Alerts
+	cfg.Alerts.WebhookURL = secrets.get("SNIPO_ALERT_WEBHOOK_URL")
+	cfg.Alerts.WebhookSecret = secrets.get("SNIPO_ALERT_WEBHOOK_SECRET")
+	cfg.Alerts.FailedLoginThreshold = getEnvInt("SNIPO_ALERT_FAILED_LOGINS", 5)
+	cfg.Alerts.FailedLoginWindow = getEnvDuration("SNIPO_ALERT_FAILED_WINDOW", 15*time.Minute)
+	cfg.Alerts.NewIPAlert = getEnvBool("SNIPO_ALERT_NEW_IP", true)
+	cfg.Alerts.LoginEventRetention = getEnvDuration("SNIPO_LOGIN_EVENT_RETENTION", 90*24*time.Hour)
+	cfg.Alerts.BackupReports = getEnvBool("SNIPO_NOTIFY_BACKUPS", true)
+	cfg.Alerts.PublicViewAlert = getEnvBool("SNIPO_NOTIFY_PUBLIC_VIEWS", false)
+	cfg.Alerts.DBSizeWarnMB = getEnvInt("SNIPO_QUOTA_DB_SIZE_MB", 0)
+	cfg.Alerts.NotificationRetention = getEnvDuration("SNIPO_NOTIFICATION_RETENTION", 30*24*time.Hour)
+
+	// SMTP
+	cfg.SMTP.Host = os.Getenv("SNIPO_SMTP_HOST")
+	cfg.SMTP.Port = getEnvInt("SNIPO_SMTP_PORT", 587)
+	cfg.SMTP.Username = os.Getenv("SNIPO_SMTP_USERNAME")
+	cfg.SMTP.Password = secrets.get("SNIPO_SMTP_PASSWORD")
+	cfg.SMTP.From = os.Getenv("SNIPO_SMTP_FROM")
+	cfg.SMTP.TLSMode = getEnv("SNIPO_SMTP_TLS", "starttls")
+	if to := os.Getenv("SNIPO_SMTP_TO"); to != "" {
+		for _, addr := range strings.Split(to, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				cfg.SMTP.To = append(cfg.SMTP.To, addr)
+			}
+		}
+	}
+
+	// Captcha
+	cfg.Captcha.Provider = strings.ToLower(os.Getenv("SNIPO_CAPTCHA_PROVIDER"))
+	cfg.Captcha.SiteKey = os.Getenv("SNIPO_CAPTCHA_SITE_KEY")
+	cfg.Captcha.SecretKey = secrets.get("SNIPO_CAPTCHA_SECRET_KEY")
+	cfg.Captcha.LoginAfter = getEnvInt("SNIPO_CAPTCHA_LOGIN_AFTER", 5)
+	switch cfg.Captcha.Provider {
+	case "":
+	case "hcaptcha", "turnstile":
+		if cfg.Captcha.SiteKey == "" || cfg.Captcha.SecretKey == "" {
+			return nil, errors.New("SNIPO_CAPTCHA_SITE_KEY and SNIPO_CAPTCHA_SECRET_KEY are required with SNIPO_CAPTCHA_PROVIDER")
+		}
+	default:
+		return nil, fmt.Errorf("SNIPO_CAPTCHA_PROVIDER must be hcaptcha or turnstile, got %q", cfg.Captcha.Provider)
+	}
+
+	// Update checker (opt-in)
+	cfg.Updates.Check = getEnvBool("SNIPO_UPDATE_CHECK", false)
+	cfg.Updates.Repository = getEnv("SNIPO_UPDATE_REPOSITORY", "MohamedElashri/snipo")
+	cfg.Updates.Notify = getEnvBool("SNIPO_UPDATE_NOTIFY", true)
+	if owner, name, ok := strings.Cut(cfg.Updates.Repository, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("SNIPO_UPDATE_REPOSITORY must be owner/name, got %q", cfg.Updates.Repository)
+	}
+
+	// Anonymous telemetry (opt-in); the off switches win over everything
+	cfg.Telemetry.Enabled = getEnvBool("SNIPO_TELEMETRY", false)
+	cfg.Telemetry.URL = os.Getenv("SNIPO_TELEMETRY_URL")
+	cfg.Telemetry.Blocked = getEnvBool("SNIPO_TELEMETRY_DISABLED", false) || getEnvBool("DO_NOT_TRACK", false)
+	if cfg.Telemetry.Blocked {
+		cfg.Telemetry.Enabled = false
+	}
+	if cfg.Telemetry.Enabled {
+		u, err := url.Parse(cfg.Telemetry.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, errors.New("SNIPO_TELEMETRY_URL must be an http or https URL with SNIPO_TELEMETRY")
+		}
+	}
+
+	// Event publishing (opt-in)
+	cfg.Events.BrokerURL = secrets.get("SNIPO_EVENTS_BROKER_URL")
+	cfg.Events.TopicPrefix = getEnv("SNIPO_EVENTS_TOPIC_PREFIX", "snipo")
+	if cfg.Events.BrokerURL != "" {
+		u, err := url.Parse(cfg.Events.BrokerURL)
+		if err != nil || u.Hostname() == "" {
+			return nil, errors.New("SNIPO_EVENTS_BROKER_URL must be a URL with a host")
+		}
+		switch u.Scheme {
+		case "mqtt", "mqtts", "nats", "nats+tls":
+		default:
+			return nil, fmt.Errorf("SNIPO_EVENTS_BROKER_URL must use mqtt, mqtts, nats or nats+tls, got %q", u.Scheme)
+		}
+	}
+	if cfg.Events.TopicPrefix == "" || strings.ContainsAny(cfg.Events.TopicPrefix, "+#*> \t") {
+		return nil, fmt.Errorf("SNIPO_EVENTS_TOPIC_PREFIX must not be empty or contain wildcards or spaces, got %q", cfg.Events.TopicPrefix)
+	}
+
+	// Multi-instance mode
+	cfg.Cluster.Role = strings.ToLower(getEnv("SNIPO_CLUSTER_ROLE", "standalone"))
+	cfg.Cluster.PrimaryURL = strings.TrimSuffix(os.Getenv("SNIPO_PRIMARY_URL"), "/")
+	cfg.Cluster.ReplicaWrites = strings.ToLower(getEnv("SNIPO_REPLICA_WRITES", "proxy"))
+	switch cfg.Cluster.Role {
+	case "standalone", "primary", "replica", "litefs":
+	default:
+		return nil, fmt.Errorf("SNIPO_CLUSTER_ROLE must be standalone, primary, replica or litefs, got %q", cfg.Cluster.Role)
+	}
+	switch cfg.Cluster.ReplicaWrites {
+	case "proxy", "redirect", "reject":
+	default:
+		return nil, fmt.Errorf("SNIPO_REPLICA_WRITES must be proxy, redirect or reject, got %q", cfg.Cluster.ReplicaWrites)
+	}
+	if cfg.Cluster.PrimaryURL != "" {
+		u, err := url.Parse(cfg.Cluster.PrimaryURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("SNIPO_PRIMARY_URL must be an http or https URL")
+		}
+	}
+	if cfg.Cluster.Role == "replica" && cfg.Cluster.ReplicaWrites != "reject" && cfg.Cluster.PrimaryURL == "" {
+		return nil, errors.New("SNIPO_PRIMARY_URL is required for a replica unless SNIPO_REPLICA_WRITES is reject")
+	}
+
+	// URL import
+	cfg.Import.URLTimeout = getEnvDuration("SNIPO_IMPORT_URL_TIMEOUT", 10*time.Second)
+	cfg.Import.URLMaxBytes = int64(getEnvInt("SNIPO_IMPORT_URL_MAX_BYTES", 2*1024*1024))
+	cfg.Import.URLAllowPrivate = getEnvBool("SNIPO_IMPORT_ALLOW_PRIVATE", false)
+
+	// Obsidian vault sync
+	cfg.Vault.Path = os.Getenv("SNIPO_VAULT_PATH")
+	cfg.Vault.URL = os.Getenv("SNIPO_VAULT_URL")
+	cfg.Vault.APIKey = secrets.get("SNIPO_VAULT_API_KEY")
+	cfg.Vault.Insecure = getEnvBool("SNIPO_VAULT_INSECURE", false)
+	cfg.Vault.Folder = getEnv("SNIPO_VAULT_FOLDER", "Snipo")
+	cfg.Vault.Schedule = getEnvDuration("SNIPO_VAULT_SCHEDULE", 0)
+	cfg.Vault.Conflicts = strings.ToLower(getEnv("SNIPO_VAULT_CONFLICTS", "newest"))
+	switch cfg.Vault.Conflicts {
+	case "newest", "vault", "snipo", "skip":
+	default:
+		return nil, fmt.Errorf("SNIPO_VAULT_CONFLICTS must be newest, vault, snipo or skip, got %q", cfg.Vault.Conflicts)
+	}
+	cfg.Features.VaultSync = cfg.Vault.Enabled()
 
 	return cfg, nil
 }
 
+// LoadLogging loads the logging settings from the environment. They are
+// loaded on their own, as the logger is set up before the rest of the
+// configuration so that configuration errors get logged.
+func LoadLogging() LoggingConfig {
+	return LoggingConfig{
+		Level:          getEnv("SNIPO_LOG_LEVEL", "info"),
+		Format:         getEnv("SNIPO_LOG_FORMAT", "json"),
+		Stdout:         getEnvBool("SNIPO_LOG_STDOUT", true),
+		File:           getEnv("SNIPO_LOG_FILE", ""),
+		FileMaxSize:    getEnvInt("SNIPO_LOG_FILE_MAX_SIZE", 100),
+		FileMaxAge:     getEnvDuration("SNIPO_LOG_FILE_MAX_AGE", 24*time.Hour),
+		FileMaxBackups: getEnvInt("SNIPO_LOG_FILE_MAX_BACKUPS", 7),
+		Syslog:         getEnv("SNIPO_LOG_SYSLOG", ""),
+	}
+}
+
 // Addr returns the server address string
 func (c *ServerConfig) Addr() string {
 	return c.Host + ":" + strconv.Itoa(c.Port)
 }
 
+// secretVars are the variables that can also be given as a file, named by
+// the variable with a _FILE suffix, as Docker and Kubernetes mount secrets
+var secretVars = []string{
+	"SNIPO_MASTER_PASSWORD",
+	"SNIPO_MASTER_PASSWORD_HASH",
+	"SNIPO_SESSION_SECRET",
+	"SNIPO_BOOTSTRAP_TOKEN",
+	"SNIPO_S3_ACCESS_KEY",
+	"SNIPO_S3_SECRET_KEY",
+	"SNIPO_AZURE_ACCOUNT_KEY",
+	"SNIPO_BACKUP_PASSWORD",
+	"SNIPO_ALERT_WEBHOOK_URL",
+	"SNIPO_ALERT_WEBHOOK_SECRET",
+	"SNIPO_SMTP_PASSWORD",
+	"SNIPO_CAPTCHA_SECRET_KEY",
+	"SNIPO_EVENTS_BROKER_URL",
+	"SNIPO_VAULT_API_KEY",
+}
+
+// secretFiles holds the secrets read from files, by variable
+type secretFiles map[string]string
+
+// readSecretFiles reads the secrets given as files. The trailing newline
+// most editors add is dropped. Setting a secret both ways is an error, as
+// it would be unclear which one is used.
+func readSecretFiles() (secretFiles, error) {
+	secrets := make(secretFiles)
+	for _, key := range secretVars {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			return nil, fmt.Errorf("%s and %s_FILE are both set; use one", key, key)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s_FILE: %w", key, err)
+		}
+		secrets[key] = strings.TrimRight(string(data), "\r\n")
+	}
+	return secrets, nil
+}
+
+// get returns a secret from its file, or else from the environment
+func (s secretFiles) get(key string) string {
+	if val, ok := s[key]; ok {
+		return val
+	}
+	return os.Getenv(key)
+}
+
 // Helper functions
 
+// parseTrustedProxies parses a comma-separated list of addresses and CIDR
+// ranges
+func parseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, err
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 func getEnv(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -222,6 +732,28 @@ func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	return defaultVal
 }
 
+// SecretEntropyBits estimates the Shannon entropy of a secret in bits.
+// It is a heuristic used to flag obviously weak session secrets.
+func SecretEntropyBits(secret string) float64 {
+	if secret == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range secret {
+		counts[r]++
+		total++
+	}
+
+	perChar := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(total)
+}
+
 func generateSecret() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestAuthDisabled(t *testing.T) {
@@ -141,3 +144,285 @@ func TestAuthDisabledBackwardCompatibility(t *testing.T) {
 	_ = os.Unsetenv("SNIPO_MASTER_PASSWORD")
 	_ = os.Unsetenv("SNIPO_SESSION_SECRET")
 }
+
+func TestSecretEntropyBits(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		minBits float64
+		maxBits float64
+	}{
+		{name: "empty", secret: "", minBits: 0, maxBits: 0},
+		{name: "repeated character", secret: "aaaaaaaaaaaaaaaa", minBits: 0, maxBits: 0},
+		{name: "short word", secret: "changeme", minBits: 1, maxBits: 64},
+		{name: "hex 32 bytes", secret: "3f7a9c1e5b2d8f4a6c0e1b3d5f7a9c2e4b6d8f0a1c3e5b7d9f2a4c6e8b0d1f3a", minBits: 128, maxBits: 512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SecretEntropyBits(tt.secret)
+			if got < tt.minBits || got > tt.maxBits {
+				t.Errorf("SecretEntropyBits(%q) = %.1f, want between %.0f and %.0f", tt.secret, got, tt.minBits, tt.maxBits)
+			}
+		})
+	}
+}
+
+func TestCaptchaOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "test-session-secret-32chars!!")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Captcha.Enabled() || cfg.Captcha.LoginAfter != 5 {
+		t.Errorf("Unexpected defaults: %+v", cfg.Captcha)
+	}
+
+	t.Setenv("SNIPO_CAPTCHA_PROVIDER", "Turnstile")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error without keys")
+	}
+
+	t.Setenv("SNIPO_CAPTCHA_SITE_KEY", "site")
+	t.Setenv("SNIPO_CAPTCHA_SECRET_KEY", "secret")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.Captcha.Enabled() || cfg.Captcha.Provider != "turnstile" {
+		t.Errorf("Unexpected captcha config: %+v", cfg.Captcha)
+	}
+
+	t.Setenv("SNIPO_CAPTCHA_PROVIDER", "recaptcha")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
+
+func TestSessionSecretRotation(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "new-session-secret, old-session-secret,,older-session-secret")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Auth.SessionSecret != "new-session-secret" || cfg.Auth.SessionSecretGenerated {
+		t.Errorf("Unexpected session secret: %q", cfg.Auth.SessionSecret)
+	}
+	if len(cfg.Auth.PreviousSessionSecrets) != 2 || cfg.Auth.PreviousSessionSecrets[0] != "old-session-secret" || cfg.Auth.PreviousSessionSecrets[1] != "older-session-secret" {
+		t.Errorf("Unexpected previous secrets: %v", cfg.Auth.PreviousSessionSecrets)
+	}
+
+	t.Setenv("SNIPO_SESSION_SECRET", "only-secret")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Auth.PreviousSessionSecrets) != 0 {
+		t.Errorf("Expected no previous secrets, got %v", cfg.Auth.PreviousSessionSecrets)
+	}
+}
+
+func TestBootstrapToken(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_BOOTSTRAP_TOKEN", " 3f7a9c1e5b2d8f4a6c0e1b3d5f7a9c2e4b6d8f0a \n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Auth.BootstrapToken != "3f7a9c1e5b2d8f4a6c0e1b3d5f7a9c2e4b6d8f0a" {
+		t.Errorf("Unexpected bootstrap token: %q", cfg.Auth.BootstrapToken)
+	}
+
+	t.Setenv("SNIPO_BOOTSTRAP_TOKEN", "too-short")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a short bootstrap token")
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write secret: %v", err)
+		}
+		return path
+	}
+	t.Setenv("SNIPO_MASTER_PASSWORD_FILE", write("password", "from file\n"))
+	t.Setenv("SNIPO_SESSION_SECRET_FILE", write("session", "new-session-secret,old-session-secret\r\n"))
+	t.Setenv("SNIPO_SMTP_PASSWORD_FILE", write("smtp", " spaced "))
+	t.Setenv("SNIPO_S3_SECRET_KEY", "from env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Auth.MasterPassword != "from file" || cfg.SMTP.Password != " spaced " || cfg.S3.SecretAccessKey != "from env" {
+		t.Errorf("Unexpected secrets: %q, %q, %q", cfg.Auth.MasterPassword, cfg.SMTP.Password, cfg.S3.SecretAccessKey)
+	}
+	if cfg.Auth.SessionSecret != "new-session-secret" || len(cfg.Auth.PreviousSessionSecrets) != 1 {
+		t.Errorf("Unexpected session secrets: %q, %v", cfg.Auth.SessionSecret, cfg.Auth.PreviousSessionSecrets)
+	}
+
+	t.Setenv("SNIPO_MASTER_PASSWORD", "from env")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a secret set both ways")
+	}
+
+	t.Setenv("SNIPO_MASTER_PASSWORD", "")
+	t.Setenv("SNIPO_MASTER_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a missing secret file")
+	}
+}
+
+func TestUpdateCheckOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Updates.Check || cfg.Updates.Repository != "MohamedElashri/snipo" || !cfg.Updates.Notify {
+		t.Errorf("Unexpected update defaults: %+v", cfg.Updates)
+	}
+
+	t.Setenv("SNIPO_UPDATE_REPOSITORY", "not-a-repo")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a repository without owner")
+	}
+}
+
+func TestEventBrokerOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Events.BrokerURL != "" || cfg.Events.TopicPrefix != "snipo" {
+		t.Errorf("Unexpected event defaults: %+v", cfg.Events)
+	}
+
+	t.Setenv("SNIPO_EVENTS_BROKER_URL", "nats://token@localhost:4222")
+	t.Setenv("SNIPO_EVENTS_TOPIC_PREFIX", "home/snipo")
+	if _, err := Load(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Setenv("SNIPO_EVENTS_TOPIC_PREFIX", "home/#")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a wildcard topic prefix")
+	}
+
+	t.Setenv("SNIPO_EVENTS_TOPIC_PREFIX", "snipo")
+	t.Setenv("SNIPO_EVENTS_BROKER_URL", "amqp://localhost")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for an unsupported broker scheme")
+	}
+}
+
+func TestClusterOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Cluster.Role != "standalone" || cfg.Cluster.ReplicaWrites != "proxy" {
+		t.Errorf("Unexpected cluster defaults: %+v", cfg.Cluster)
+	}
+
+	t.Setenv("SNIPO_CLUSTER_ROLE", "replica")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a replica without a primary URL")
+	}
+
+	t.Setenv("SNIPO_PRIMARY_URL", "http://primary:8080/")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Cluster.PrimaryURL != "http://primary:8080" {
+		t.Errorf("Expected trailing slash trimmed, got %q", cfg.Cluster.PrimaryURL)
+	}
+
+	t.Setenv("SNIPO_REPLICA_WRITES", "drop")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for an unknown write mode")
+	}
+
+	t.Setenv("SNIPO_REPLICA_WRITES", "reject")
+	t.Setenv("SNIPO_CLUSTER_ROLE", "follower")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for an unknown role")
+	}
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_WRITE_TIMEOUT", "45s")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Server.RequestTimeout != 45*time.Second || cfg.Server.SearchTimeout != 10*time.Second {
+		t.Errorf("Unexpected timeout defaults: request %s, search %s", cfg.Server.RequestTimeout, cfg.Server.SearchTimeout)
+	}
+
+	t.Setenv("SNIPO_SEARCH_TIMEOUT", "-1s")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative search timeout")
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(cfg.Server.TrustedProxies); got != "[127.0.0.0/8 ::1/128]" {
+		t.Errorf("Expected loopback proxies by default, got %s", got)
+	}
+
+	t.Setenv("SNIPO_TRUSTED_PROXIES", "10.1.2.3/16, 192.0.2.7,2001:db8::/32")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(cfg.Server.TrustedProxies); got != "[10.1.0.0/16 192.0.2.7/32 2001:db8::/32]" {
+		t.Errorf("Unexpected trusted proxies %s", got)
+	}
+
+	t.Setenv("SNIPO_TRUSTED_PROXIES", "10.0.0.0/8,proxy")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a trusted proxy that is not an address")
+	}
+}
+
+func TestDatabasePoolOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_DB_MAX_CONNS", "4")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Database.MaxIdleConns != 4 || cfg.Database.StatementCache != 64 {
+		t.Errorf("Unexpected pool defaults: %+v", cfg.Database)
+	}
+
+	t.Setenv("SNIPO_DB_STATEMENT_CACHE", "-1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative statement cache size")
+	}
+}
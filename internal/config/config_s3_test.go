@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestS3Options(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "test-session-secret-32chars!!")
+	t.Setenv("SNIPO_S3_SSE", "aws:kms")
+	t.Setenv("SNIPO_S3_SSE_KMS_KEY_ID", "alias/backups")
+	t.Setenv("SNIPO_S3_TAGS", "team=platform, retention = 90d")
+	t.Setenv("SNIPO_S3_PREFIX", "{hostname}/{date}/")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.S3.SSE != "kms" || cfg.S3.SSEKMSKeyID != "alias/backups" {
+		t.Errorf("Expected SSE-KMS with key, got %q %q", cfg.S3.SSE, cfg.S3.SSEKMSKeyID)
+	}
+	if len(cfg.S3.Tags) != 2 || cfg.S3.Tags["team"] != "platform" || cfg.S3.Tags["retention"] != "90d" {
+		t.Errorf("Unexpected tags: %v", cfg.S3.Tags)
+	}
+	if cfg.S3.Prefix != "{hostname}/{date}/" {
+		t.Errorf("Unexpected prefix: %q", cfg.S3.Prefix)
+	}
+}
+
+func TestS3OptionsInvalid(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "test-session-secret-32chars!!")
+
+	t.Setenv("SNIPO_S3_SSE", "rot13")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown SSE mode")
+	}
+
+	t.Setenv("SNIPO_S3_SSE", "")
+	t.Setenv("SNIPO_S3_TAGS", "team")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for malformed tags")
+	}
+}
+
+func TestLocalBackupOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "test-session-secret-32chars!!")
+	t.Setenv("SNIPO_STORAGE_PROVIDER", "local")
+	t.Setenv("SNIPO_BACKUP_DIR", "/var/backups/snipo")
+	t.Setenv("SNIPO_BACKUP_RETENTION", "7")
+	t.Setenv("SNIPO_BACKUP_SCHEDULE", "24h")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !cfg.S3.Enabled || cfg.S3.Provider != "local" || cfg.S3.LocalDir != "/var/backups/snipo" {
+		t.Errorf("Expected enabled local storage, got %+v", cfg.S3)
+	}
+	if cfg.S3.Retention != 7 || cfg.S3.Schedule != 24*time.Hour || cfg.S3.ScheduleFormat != "zip" {
+		t.Errorf("Unexpected schedule: %d %v %q", cfg.S3.Retention, cfg.S3.Schedule, cfg.S3.ScheduleFormat)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVaultOptions(t *testing.T) {
+	t.Setenv("SNIPO_DISABLE_AUTH", "true")
+	t.Setenv("SNIPO_SESSION_SECRET", "test-session-secret-32chars!!")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Vault.Enabled() || cfg.Features.VaultSync {
+		t.Error("Expected vault sync to be off without a path or URL")
+	}
+	if cfg.Vault.Folder != "Snipo" || cfg.Vault.Conflicts != "newest" {
+		t.Errorf("Unexpected defaults: folder %q, conflicts %q", cfg.Vault.Folder, cfg.Vault.Conflicts)
+	}
+
+	t.Setenv("SNIPO_VAULT_URL", "https://127.0.0.1:27124")
+	t.Setenv("SNIPO_VAULT_SCHEDULE", "15m")
+	t.Setenv("SNIPO_VAULT_CONFLICTS", "Vault")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.Vault.Enabled() || !cfg.Features.VaultSync {
+		t.Error("Expected vault sync to be on with a URL")
+	}
+	if cfg.Vault.Schedule != 15*time.Minute || cfg.Vault.Conflicts != "vault" {
+		t.Errorf("Unexpected options: schedule %v, conflicts %q", cfg.Vault.Schedule, cfg.Vault.Conflicts)
+	}
+
+	t.Setenv("SNIPO_VAULT_CONFLICTS", "merge")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown conflict strategy")
+	}
+}
@@ -0,0 +1,322 @@
+// Package contracts defines the repository and service interfaces the HTTP
+// handlers depend on. The router wires the concrete implementations; any of
+// them can be swapped for another implementation or wrapped by a decorator
+// (caching, metrics, auditing) without touching the handlers.
+package contracts
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+)
+
+// SnippetService manages snippets and their history
+type SnippetService interface {
+	Create(ctx context.Context, input *models.SnippetInput) (*models.Snippet, error)
+	GetByID(ctx context.Context, id string) (*models.Snippet, error)
+	Download(ctx context.Context, id string) (*services.SnippetDownload, error)
+	PDF(ctx context.Context, id string) (*services.SnippetPDF, error)
+	CodeImage(ctx context.Context, id string, opts services.CodeImageOptions) ([]byte, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Snippet, error)
+	GetByIDPublic(ctx context.Context, id string) (*models.Snippet, error)
+	GetBySlugPublic(ctx context.Context, slug string) (*models.Snippet, error)
+	GetShared(ctx context.Context, id string) (*models.Snippet, error)
+	SharePreview(ctx context.Context, idOrSlug string) (*models.Snippet, error)
+	ListPublicInFolder(ctx context.Context, folderID int64, page, limit int) (*models.SnippetListResponse, error)
+	Update(ctx context.Context, id string, input *models.SnippetInput) (*models.Snippet, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filter models.SnippetFilter) (*models.SnippetListResponse, error)
+	Search(ctx context.Context, query string, limit int) ([]models.Snippet, error)
+	Duplicate(ctx context.Context, id string) (*models.Snippet, error)
+	ToggleFavorite(ctx context.Context, id string) (*models.Snippet, error)
+	TogglePin(ctx context.Context, id string) (*models.Snippet, error)
+	ToggleArchive(ctx context.Context, id string) (*models.Snippet, error)
+	ListPinned(ctx context.Context) ([]models.Snippet, error)
+	MaxPinned() int
+	MaxFiles() int
+	GetHistory(ctx context.Context, id string, limit int) ([]models.SnippetHistory, error)
+	SearchHistory(ctx context.Context, id string, opts models.HistorySearchOptions) ([]models.HistorySearchResult, error)
+	SearchAllHistory(ctx context.Context, opts models.HistorySearchOptions) ([]models.HistorySearchResult, error)
+	RestoreFromHistory(ctx context.Context, snippetID string, historyID int64) (*models.Snippet, error)
+	SubmitForReview(ctx context.Context, id, comment string) (*models.Snippet, error)
+	Approve(ctx context.Context, id, comment string) (*models.Snippet, error)
+	Reject(ctx context.Context, id, comment string) (*models.Snippet, error)
+	ListReviews(ctx context.Context, id string) ([]models.SnippetReview, error)
+	Announcement(ctx context.Context) (*models.Announcement, error)
+	AutoArchive(ctx context.Context, dryRun bool) (*models.AutoArchiveReport, error)
+	RecordUsage(ctx context.Context, id string, input *models.UsageInput) error
+	TrackView(id string)
+	Links(ctx context.Context, id string) (*models.SnippetLinks, error)
+	AddLink(ctx context.Context, id string, input *models.LinkInput) (*models.SnippetLink, error)
+	DeleteLink(ctx context.Context, id string, linkID int64) error
+	ResolveLinks(ctx context.Context, input *models.ResolveLinksInput) (*models.ResolveLinksResult, error)
+	AcquireLock(ctx context.Context, id string, input *models.LockInput) (*models.SnippetLock, error)
+	ReleaseLock(ctx context.Context, id, lockID string) error
+	Checkout(ctx context.Context, id string, input *models.CheckoutInput) (*models.SnippetCheckout, error)
+	Checkin(ctx context.Context, id string, input *models.CheckinInput) (*models.SnippetCheckout, error)
+	ListCheckouts(ctx context.Context, id string) ([]models.SnippetCheckout, error)
+	WithholdContent(ctx context.Context, snippets ...*models.Snippet)
+}
+
+// TagRepository stores tags, their aliases and the tags they imply
+type TagRepository interface {
+	Create(ctx context.Context, input *models.TagInput) (*models.Tag, error)
+	GetByID(ctx context.Context, id int64) (*models.Tag, error)
+	GetByName(ctx context.Context, name string) (*models.Tag, error)
+	List(ctx context.Context) ([]models.Tag, error)
+	Update(ctx context.Context, id int64, input *models.TagInput) (*models.Tag, error)
+	Delete(ctx context.Context, id int64) error
+	GetTagSnippetCount(ctx context.Context, tagID int64) (int, error)
+	ResolveAlias(ctx context.Context, alias string) (*models.Tag, error)
+	ListAliases(ctx context.Context, tagID int64) ([]models.TagAlias, error)
+	AddAlias(ctx context.Context, tagID int64, alias string) (*models.TagAlias, error)
+	DeleteAlias(ctx context.Context, tagID int64, alias string) error
+	ListImplied(ctx context.Context, tagID int64) ([]models.Tag, error)
+	AddImplication(ctx context.Context, tagID, impliedID int64) error
+	DeleteImplication(ctx context.Context, tagID, impliedID int64) error
+}
+
+// FolderRepository stores the folder tree
+type FolderRepository interface {
+	Create(ctx context.Context, input *models.FolderInput) (*models.Folder, error)
+	GetByID(ctx context.Context, id int64) (*models.Folder, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Folder, error)
+	SlugsWithPrefix(ctx context.Context, base string, excludeID int64) (map[string]bool, error)
+	List(ctx context.Context) ([]models.Folder, error)
+	ListTree(ctx context.Context) ([]models.Folder, error)
+	Update(ctx context.Context, id int64, input *models.FolderInput) (*models.Folder, error)
+	Move(ctx context.Context, id int64, newParentID *int64) (*models.Folder, error)
+	Delete(ctx context.Context, id int64) error
+	GetFolderSnippetCount(ctx context.Context, folderID int64) (int, error)
+}
+
+// TokenRepository stores API tokens
+type TokenRepository interface {
+	Create(ctx context.Context, input *models.APITokenInput) (*models.APIToken, error)
+	GetByID(ctx context.Context, id int64) (*models.APIToken, error)
+	List(ctx context.Context) ([]models.APIToken, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// SettingsRepository stores the application settings
+type SettingsRepository interface {
+	Get(ctx context.Context) (*models.Settings, error)
+	Update(ctx context.Context, input *models.SettingsInput) (*models.Settings, error)
+}
+
+// BackupService exports and restores backups
+type BackupService interface {
+	Export(ctx context.Context, opts models.ExportOptions) ([]byte, string, error)
+	Decode(content []byte, password string) (*models.BackupData, error)
+	Restore(ctx context.Context, data *models.BackupData, opts models.ImportOptions, progress services.ImportProgress) (*models.ImportResult, error)
+}
+
+// S3SyncService syncs backups with object storage
+type S3SyncService interface {
+	Provider() string
+	PrepareSync(ctx context.Context, opts models.ExportOptions) (*services.S3SyncPayload, error)
+	SyncToS3(ctx context.Context, opts models.ExportOptions) (*models.S3SyncResult, error)
+	ListBackups(ctx context.Context) ([]models.S3BackupInfo, error)
+	PreviewBackup(ctx context.Context, key string) (*models.S3BackupPreview, error)
+	RestoreFromS3(ctx context.Context, key string, opts models.ImportOptions) (*models.S3RestoreResult, error)
+	DeleteBackup(ctx context.Context, key string) error
+}
+
+// JobQueue runs background jobs
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any) (models.Job, error)
+	Get(ctx context.Context, id string) (models.Job, error)
+	List(ctx context.Context, filter models.JobFilter) ([]models.Job, error)
+	Subscribe(ctx context.Context, id string) (models.Job, <-chan models.Job, func(), error)
+}
+
+// IntegrityService checks and repairs stored data
+type IntegrityService interface {
+	Verify(ctx context.Context, repair bool) (*models.IntegrityReport, error)
+}
+
+// LoginAudit records and lists login attempts
+type LoginAudit interface {
+	Record(ctx context.Context, success bool, ip, userAgent, reason string)
+	List(ctx context.Context, filter models.LoginEventFilter) ([]models.LoginEvent, error)
+}
+
+// SiteExporter renders snippets as a static site
+type SiteExporter interface {
+	ExportZip(ctx context.Context, opts models.SiteExportOptions) ([]byte, string, error)
+}
+
+// URLImporter creates snippets from remote URLs
+type URLImporter interface {
+	Import(ctx context.Context, input models.URLImportInput) (*models.Snippet, error)
+}
+
+// VaultSync syncs snippets with an Obsidian vault
+type VaultSync interface {
+	Sync(ctx context.Context) (*models.VaultSyncResult, error)
+	Status(ctx context.Context) (*models.VaultStatus, error)
+}
+
+// Mailer sends notification emails
+type Mailer interface {
+	Recipients() []string
+	Notify(ctx context.Context, event notify.Event) error
+}
+
+// NotificationCenter lists stored notifications and tracks what has been read
+type NotificationCenter interface {
+	List(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error)
+	CountUnread(ctx context.Context) (int, error)
+	MarkRead(ctx context.Context, id int64) (*models.Notification, error)
+	MarkAllRead(ctx context.Context) (int64, error)
+}
+
+// Inbox collects untitled text and promotes it into snippets
+type Inbox interface {
+	Add(ctx context.Context, content, source string) (*models.InboxItem, error)
+	List(ctx context.Context) ([]models.InboxItem, error)
+	Delete(ctx context.Context, id int64) error
+	Promote(ctx context.Context, id int64, input *models.InboxPromoteInput) (*models.Snippet, error)
+}
+
+// Moderation takes abuse reports about public snippets and resolves them
+type Moderation interface {
+	Challenge() *models.ReportChallenge
+	Report(ctx context.Context, idOrSlug, reporterIP string, input *models.ReportInput) error
+	List(ctx context.Context, filter models.ReportFilter) ([]models.SnippetReport, error)
+	Unpublish(ctx context.Context, id int64) (*models.SnippetReport, error)
+	Dismiss(ctx context.Context, id int64) (*models.SnippetReport, error)
+}
+
+// BurnLinks shares snippets through links that can be opened only once
+type BurnLinks interface {
+	Create(ctx context.Context, snippetID string, input *models.BurnLinkInput) (*models.BurnLink, error)
+	List(ctx context.Context, snippetID string) ([]models.BurnLink, error)
+	Revoke(ctx context.Context, snippetID string, id int64) error
+	Reveal(ctx context.Context, token string) (*models.Snippet, error)
+}
+
+// ShareAnalytics counts views of public snippets and summarizes them
+type ShareAnalytics interface {
+	Record(snippetID, referrer, userAgent, ip, host string)
+	Get(ctx context.Context, snippetID string, days int) (*models.ShareAnalytics, error)
+}
+
+// APIUsage reports API requests per day, token and route
+type APIUsage interface {
+	Report(ctx context.Context, filter models.APIUsageFilter) (*models.APIUsageReport, error)
+	Entries(ctx context.Context, filter models.APIUsageFilter) ([]models.APIUsageEntry, error)
+}
+
+// UpdateChecker reports whether a newer Snipo release is available
+type UpdateChecker interface {
+	Status() *models.UpdateStatus
+}
+
+// Telemetry previews the anonymous telemetry report
+type Telemetry interface {
+	Preview(ctx context.Context) (*models.TelemetryStatus, error)
+}
+
+// QuickOpen matches snippet titles and filenames for quick navigation
+type QuickOpen interface {
+	Search(ctx context.Context, query string, limit int) ([]models.QuickOpenResult, error)
+}
+
+// Autocomplete suggests tags, folders and languages as they are typed
+type Autocomplete interface {
+	Suggest(ctx context.Context, kind, prefix string, limit int) ([]models.Suggestion, error)
+}
+
+// Rules manages the automation rules run when snippets are saved
+type Rules interface {
+	List(ctx context.Context) ([]models.Rule, error)
+	Get(ctx context.Context, id int64) (*models.Rule, error)
+	Create(ctx context.Context, input *models.RuleInput) (*models.Rule, error)
+	Update(ctx context.Context, id int64, input *models.RuleInput) (*models.Rule, error)
+	Delete(ctx context.Context, id int64) error
+	Runs(ctx context.Context, id int64, limit int) ([]models.RuleRun, error)
+}
+
+// Redactions manages the patterns masked in shared and exported snippets
+type Redactions interface {
+	List(ctx context.Context) ([]models.RedactionRule, error)
+	Get(ctx context.Context, id int64) (*models.RedactionRule, error)
+	Create(ctx context.Context, input *models.RedactionRuleInput) (*models.RedactionRule, error)
+	Update(ctx context.Context, id int64, input *models.RedactionRuleInput) (*models.RedactionRule, error)
+	Delete(ctx context.Context, id int64) error
+	Preview(ctx context.Context, content string) (*models.RedactionPreview, error)
+}
+
+// Snapshots answers what the library looked like on past days
+type Snapshots interface {
+	Take(ctx context.Context) (*models.LibrarySnapshot, error)
+	List(ctx context.Context, limit int) ([]models.LibrarySnapshot, error)
+	AsOf(ctx context.Context, day string) (*models.LibrarySnapshot, error)
+	Diff(ctx context.Context, from, to string) (*models.SnapshotDiff, error)
+}
+
+// Badges builds status badges about the public snippets
+type Badges interface {
+	Count(ctx context.Context) (*models.Badge, error)
+	Languages(ctx context.Context, top int) (*models.Badge, error)
+}
+
+// Authenticator checks passwords and manages sessions
+type Authenticator interface {
+	IsAuthDisabled() bool
+	VerifyPassword(password string) bool
+	VerifyPasswordWithDelay(password, clientIP string) (bool, time.Duration)
+	UpdatePassword(newPassword string) error
+	CreateSession() (string, error)
+	ValidateSession(token string) bool
+	InvalidateSession(token string) error
+	SessionStats(ctx context.Context) (*auth.SessionStats, error)
+	ReissueSessions(ctx context.Context) (int64, error)
+	ChangelogSeen(token string) (version string, ok bool)
+	SetChangelogSeen(token, version string) error
+	SetSessionCookie(w http.ResponseWriter, token string)
+	ClearSessionCookie(w http.ResponseWriter)
+}
+
+// URLSigner signs URLs granting time-limited access to a snippet
+type URLSigner interface {
+	SignSnippetURL(id string, expires time.Time) string
+}
+
+// Compile-time checks that the default implementations satisfy the contracts
+var (
+	_ SnippetService     = (*services.SnippetService)(nil)
+	_ TagRepository      = (*repository.TagRepository)(nil)
+	_ FolderRepository   = (*repository.FolderRepository)(nil)
+	_ TokenRepository    = (*repository.TokenRepository)(nil)
+	_ SettingsRepository = (*repository.SettingsRepository)(nil)
+	_ BackupService      = (*services.BackupService)(nil)
+	_ S3SyncService      = (*services.S3SyncService)(nil)
+	_ JobQueue           = (*jobs.Queue)(nil)
+	_ IntegrityService   = (*services.IntegrityService)(nil)
+	_ LoginAudit         = (*services.LoginAuditService)(nil)
+	_ SiteExporter       = (*services.SiteExportService)(nil)
+	_ URLImporter        = (*services.URLImportService)(nil)
+	_ VaultSync          = (*services.VaultSyncService)(nil)
+	_ Mailer             = (*notify.SMTP)(nil)
+	_ NotificationCenter = (*services.NotificationService)(nil)
+	_ Inbox              = (*services.InboxService)(nil)
+	_ Moderation         = (*services.ReportService)(nil)
+	_ BurnLinks          = (*services.BurnLinkService)(nil)
+	_ ShareAnalytics     = (*services.ShareAnalyticsService)(nil)
+	_ UpdateChecker      = (*services.UpdateChecker)(nil)
+	_ Telemetry          = (*services.TelemetryService)(nil)
+	_ Rules              = (*services.RuleService)(nil)
+	_ QuickOpen          = (*services.QuickOpenService)(nil)
+	_ Autocomplete       = (*services.AutocompleteService)(nil)
+	_ Authenticator      = (*auth.Service)(nil)
+	_ URLSigner          = (*auth.Service)(nil)
+)
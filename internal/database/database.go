@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -21,9 +23,12 @@ type DB struct {
 type Config struct {
 	Path            string
 	MaxOpenConns    int
+	MaxIdleConns    int           // Connections kept open while idle
+	ConnMaxIdleTime time.Duration // Close connections idle this long (0 = never)
 	BusyTimeout     int
 	JournalMode     string
 	SynchronousMode string
+	ReadOnly        bool // Reject writes on every connection, for a read-only replica
 }
 
 // New creates a new database connection
@@ -36,13 +41,18 @@ func New(cfg Config, logger *slog.Logger) (*DB, error) {
 		}
 	}
 
-	// Build connection string with pragmas
-	dsn := fmt.Sprintf("%s?_busy_timeout=%d&_journal_mode=%s&_synchronous=%s&_foreign_keys=ON",
+	// Build connection string with pragmas. The driver only applies
+	// _pragma parameters, on every new connection; anything else in the
+	// query string is ignored.
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(%s)&_pragma=synchronous(%s)&_pragma=foreign_keys(1)",
 		cfg.Path,
 		cfg.BusyTimeout,
 		cfg.JournalMode,
 		cfg.SynchronousMode,
 	)
+	if cfg.ReadOnly {
+		dsn += "&_pragma=query_only(1)"
+	}
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -51,6 +61,8 @@ func New(cfg Config, logger *slog.Logger) (*DB, error) {
 
 	// Set connection pool settings
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// Verify connection
 	if err := db.Ping(); err != nil {
@@ -71,7 +83,7 @@ func New(cfg Config, logger *slog.Logger) (*DB, error) {
 		}
 	}
 
-	logger.Info("database connected", "path", cfg.Path)
+	logger.Info("database connected", "path", cfg.Path, "read_only", cfg.ReadOnly)
 
 	return &DB{DB: db, logger: logger}, nil
 }
@@ -160,6 +172,129 @@ func (db *DB) Migrate(ctx context.Context) error {
 	return nil
 }
 
+// LatestVersion returns the highest migration version known to this binary
+func LatestVersion() int {
+	latest := 0
+	for _, m := range getMigrations() {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// SchemaVersion returns the migration version currently applied to the database
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var count int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='schema_migrations'",
+	).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get current migration version: %w", err)
+	}
+	return version, nil
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns any reported problems
+func (db *DB) IntegrityCheck(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			db.logger.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check result: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// CheckFTSIntegrity verifies the full-text index is internally consistent
+func (db *DB) CheckFTSIntegrity(ctx context.Context) error {
+	// The FTS column snippet_id maps to snippets.id, so a content-table
+	// comparison (rank=1) is not possible; this checks internal consistency
+	if _, err := db.ExecContext(ctx, "INSERT INTO snippets_fts(snippets_fts) VALUES('integrity-check')"); err != nil {
+		return fmt.Errorf("full-text index integrity check failed: %w", err)
+	}
+	return nil
+}
+
+// libraryTables are the tables ClearLibrary empties, rows that refer to
+// others first
+var libraryTables = []string{
+	"snippet_files_history",
+	"snippet_history",
+	"snippet_tags",
+	"snippet_folders",
+	"snippet_files",
+	"snippet_metadata",
+	"snippet_reviews",
+	"snippet_usage",
+	"snippet_links",
+	"snippet_locks",
+	"collab_documents",
+	"snippet_reports",
+	"burn_links",
+	"share_views",
+	"share_visitors",
+	"tag_aliases",
+	"tag_implications",
+	"token_folders",
+	"snippets",
+	"tags",
+	"folders",
+}
+
+// ClearLibrary deletes all snippets, tags and folders with every row that
+// belongs to them, in one transaction, so a failure leaves the data
+// untouched. The search index is emptied and ID sequences restart, as in a
+// fresh database; no row is left behind to match a reused ID. Check-outs,
+// vault sync state and library snapshots are kept as history.
+func ClearLibrary(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	queries := make([]string, 0, len(libraryTables)+2)
+	for _, table := range libraryTables {
+		queries = append(queries, "DELETE FROM "+table)
+	}
+	queries = append(queries,
+		"INSERT INTO snippets_fts(snippets_fts) VALUES('delete-all')",
+		"DELETE FROM sqlite_sequence WHERE name IN ('"+strings.Join(libraryTables, "', '")+"')",
+	)
+	for _, q := range queries {
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", q, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	db.logger.Info("closing database connection")
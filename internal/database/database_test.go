@@ -0,0 +1,56 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestNew_Pragmas(t *testing.T) {
+	db, err := New(Config{
+		Path:            filepath.Join(t.TempDir(), "snipo.db"),
+		MaxOpenConns:    2,
+		MaxIdleConns:    2,
+		BusyTimeout:     5000,
+		JournalMode:     "WAL",
+		SynchronousMode: "NORMAL",
+	}, testutil.TestLogger())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tests := []struct {
+		pragma string
+		want   string
+	}{
+		{"foreign_keys", "1"},
+		{"journal_mode", "wal"},
+		{"synchronous", "1"}, // NORMAL
+		{"busy_timeout", "5000"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := db.QueryRow("PRAGMA " + tt.pragma).Scan(&got); err != nil {
+			t.Fatalf("failed to read %s: %v", tt.pragma, err)
+		}
+		if strings.ToLower(got) != tt.want {
+			t.Errorf("expected %s = %s, got %s", tt.pragma, tt.want, got)
+		}
+	}
+
+	// The schema migrates cleanly with foreign keys enforced
+	if err := db.Migrate(testutil.TestContext()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		t.Fatalf("failed to check foreign keys: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	if rows.Next() {
+		t.Error("expected no foreign key violations after migrating")
+	}
+}
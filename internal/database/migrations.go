@@ -261,6 +261,541 @@ const addDisableLoginSQL = `
 ALTER TABLE settings ADD COLUMN disable_login INTEGER DEFAULT 0 NOT NULL;
 `
 
+// Migration 8: Add login audit events
+const addLoginEventsSQL = `
+-- Record every login attempt for auditing and alerting
+CREATE TABLE IF NOT EXISTS login_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    success INTEGER NOT NULL DEFAULT 0,
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_created ON login_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_ip ON login_events(ip_address, created_at);
+`
+
+// Migration 9: Add snippet source URL
+const addSourceURLSQL = `
+-- Remember where imported snippets came from
+ALTER TABLE snippets ADD COLUMN source_url TEXT DEFAULT NULL;
+`
+
+// Migration 10: Add snippet pinning
+const addPinningSQL = `
+-- Pinned snippets are shown on the dashboard, independent of favorites
+ALTER TABLE snippets ADD COLUMN is_pinned INTEGER DEFAULT 0;
+ALTER TABLE snippets ADD COLUMN pinned_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_snippets_pinned ON snippets(is_pinned, pinned_at);
+`
+
+// Migration 11: Add folder colors
+const addFolderColorSQL = `
+-- Optional display color for folders (hex, empty = default)
+ALTER TABLE folders ADD COLUMN color TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 12: Add snippet slugs
+const addSlugsSQL = `
+-- Human-readable share identifiers, unique when set
+ALTER TABLE snippets ADD COLUMN slug TEXT DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snippets_slug ON snippets(slug) WHERE slug IS NOT NULL;
+`
+
+// Migration 13: Add custom snippet metadata
+const addSnippetMetadataSQL = `
+-- Free-form key/value context attached to snippets (tickets, environments, ...)
+CREATE TABLE IF NOT EXISTS snippet_metadata (
+    snippet_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (snippet_id, key),
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_metadata_key_value ON snippet_metadata(key, value);
+`
+
+// Migration 14: Add background job queue
+const addJobsSQL = `
+-- Long-running operations, persisted so they survive restarts
+CREATE TABLE IF NOT EXISTS jobs (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    payload TEXT NOT NULL DEFAULT 'null',
+    processed INTEGER NOT NULL DEFAULT 0,
+    total INTEGER NOT NULL DEFAULT 0,
+    errors TEXT NOT NULL DEFAULT '[]',
+    result TEXT DEFAULT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 1,
+    run_after DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    started_at DATETIME DEFAULT NULL,
+    finished_at DATETIME DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue ON jobs(status, run_after);
+CREATE INDEX IF NOT EXISTS idx_jobs_created ON jobs(created_at DESC);
+`
+
+// Migration 15: Add Obsidian vault sync state
+const addVaultNotesSQL = `
+-- Vault notes linked to snippets, as they were after the last sync. There is
+-- no foreign key: a missing snippet tells the next sync it was deleted.
+CREATE TABLE IF NOT EXISTS vault_notes (
+    path TEXT PRIMARY KEY,
+    snippet_id TEXT NOT NULL UNIQUE,
+    note_mtime DATETIME DEFAULT NULL,
+    note_checksum TEXT NOT NULL,
+    snippet_checksum TEXT NOT NULL,
+    synced_at DATETIME NOT NULL
+);
+`
+
+// Migration 16: Add snippet license and attribution
+const addLicenseSQL = `
+-- Reuse terms for shared snippets: an SPDX license identifier and credit
+ALTER TABLE snippets ADD COLUMN license TEXT DEFAULT NULL;
+ALTER TABLE snippets ADD COLUMN attribution TEXT DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_snippets_license ON snippets(license);
+`
+
+// Migration 17: Add snippet review workflow
+const addReviewSQL = `
+-- Editorial review before publishing: draft -> pending -> approved. Snippets
+-- already public count as approved so turning review on keeps them published.
+ALTER TABLE snippets ADD COLUMN review_state TEXT NOT NULL DEFAULT 'draft';
+UPDATE snippets SET review_state = 'approved' WHERE is_public = 1;
+
+CREATE INDEX IF NOT EXISTS idx_snippets_review_state ON snippets(review_state);
+
+-- Log of review actions with reviewer comments
+CREATE TABLE IF NOT EXISTS snippet_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    state TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_reviews_snippet ON snippet_reviews(snippet_id, id);
+
+-- Require approval before public snippets are served
+ALTER TABLE settings ADD COLUMN review_required INTEGER DEFAULT 0 NOT NULL;
+`
+
+// Migration 18: Add notification center
+const addNotificationsSQL = `
+-- In-app notification center, fed by the same events as webhook and email alerts
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    fields TEXT NOT NULL DEFAULT '{}',
+    read_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_created ON notifications(created_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(read_at) WHERE read_at IS NULL;
+`
+
+// Migration 19: Add clipboard inbox
+const addInboxSQL = `
+-- Untitled text captured by clipboard integrations, kept until promoted to a
+-- snippet, deleted or pushed out by newer items
+CREATE TABLE IF NOT EXISTS inbox_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// Migration 20: Add scheduled publishing
+const addPublishAtSQL = `
+-- When a background scheduler makes the snippet public (UTC)
+ALTER TABLE snippets ADD COLUMN publish_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_snippets_publish_at ON snippets(publish_at) WHERE publish_at IS NOT NULL;
+`
+
+// Migration 21: Add announcement snippet
+const addAnnouncementSQL = `
+-- Snippet shown as an announcement on public pages (empty = none)
+ALTER TABLE settings ADD COLUMN announcement_snippet_id TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 22: Add folder auto-archive rules
+const addFolderArchiveRulesSQL = `
+-- Snippets in a folder that have not been updated for this many days are
+-- archived by a background job (0 = never)
+ALTER TABLE folders ADD COLUMN archive_after_days INTEGER NOT NULL DEFAULT 0;
+`
+
+// Migration 23: Add snippet usage events
+const addSnippetUsageSQL = `
+-- Views and copies of snippets, feeding the frecency sort. Events older
+-- than the scoring window are pruned by a background job.
+CREATE TABLE IF NOT EXISTS snippet_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_usage_snippet ON snippet_usage(snippet_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_snippet_usage_created ON snippet_usage(created_at);
+`
+
+// Migration 24: Add snippet links
+const addSnippetLinksSQL = `
+-- Typed links between snippets. Auto links are maintained from snipo:// and
+-- /s/ URLs in the source snippet's content and replaced on every save.
+CREATE TABLE IF NOT EXISTS snippet_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    auto INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_id, target_id, relation),
+    FOREIGN KEY (source_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_links_target ON snippet_links(target_id);
+`
+
+// Migration 25: Add history actors
+const addHistoryActorSQL = `
+-- Who made the change that recorded a history entry: an API token's name or
+-- "session" for the web interface, and the client's IP address. Empty for
+-- entries recorded before this migration or with authentication off.
+ALTER TABLE snippet_history ADD COLUMN changed_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE snippet_history ADD COLUMN source_ip TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 26: Add history details
+const addHistoryDetailsSQL = `
+-- Favorite, archive, tag and folder changes are recorded without a copy of
+-- the content; details holds the new tags or folder
+ALTER TABLE snippet_history ADD COLUMN details TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 27: Add snippet edit locks
+const addSnippetLocksSQL = `
+-- Advisory locks warning editors that someone else has a snippet open;
+-- a lock lapses at expires_at unless its holder renews it
+CREATE TABLE IF NOT EXISTS snippet_locks (
+    snippet_id TEXT PRIMARY KEY,
+    lock_id TEXT NOT NULL,
+    holder TEXT NOT NULL DEFAULT '',
+    acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+`
+
+// Migration 28: Add collaborative editing state
+const addCollabDocumentsSQL = `
+-- CRDT state of snippet files being edited together, kept until the edits
+-- are written back to the snippet; file_id is 0 for the snippet's content
+CREATE TABLE IF NOT EXISTS collab_documents (
+    snippet_id TEXT NOT NULL,
+    file_id INTEGER NOT NULL DEFAULT 0,
+    state TEXT NOT NULL,
+    base_hash TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snippet_id, file_id),
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+`
+
+// Migration 29: Add abuse reports for public snippets
+const addSnippetReportsSQL = `
+-- Reports from visitors about public snippets, waiting for moderation
+CREATE TABLE IF NOT EXISTS snippet_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    reporter_ip TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by TEXT NOT NULL DEFAULT '',
+    resolved_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_reports_status ON snippet_reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_snippet_reports_snippet ON snippet_reports(snippet_id);
+`
+
+// Migration 30: Add session secret rotation
+const addSessionKeysSQL = `
+-- Which session secret a session's token hash was made with ('' for sessions
+-- from before tokens were hashed with the secret), and whether the next
+-- request should get a new token
+ALTER TABLE sessions ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN reissue INTEGER NOT NULL DEFAULT 0;
+`
+
+// Migration 31: Restrict API tokens to addresses and referrers
+const addTokenRestrictionsSQL = `
+-- Comma-separated CIDR ranges and referrer hosts; '' allows any
+ALTER TABLE api_tokens ADD COLUMN allowed_ips TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_tokens ADD COLUMN allowed_referrers TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 32: Add burn-after-read share links
+const addBurnLinksSQL = `
+-- One-time share links: the token is stored hashed, and the row is deleted
+-- when the link is opened
+CREATE TABLE IF NOT EXISTS burn_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    delete_snippet INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_burn_links_snippet ON burn_links(snippet_id);
+`
+
+// Migration 33: Add share analytics
+const addShareAnalyticsSQL = `
+-- Public snippet views counted per day, referrer host and client class
+CREATE TABLE IF NOT EXISTS share_views (
+    snippet_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    client TEXT NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (snippet_id, day, referrer, client),
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_views_day ON share_views(day);
+
+-- Viewer addresses per day, only stored when SNIPO_SHARE_ANALYTICS_IPS is on
+CREATE TABLE IF NOT EXISTS share_visitors (
+    snippet_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    PRIMARY KEY (snippet_id, day, ip),
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_visitors_day ON share_visitors(day);
+`
+
+// Migration 34: Add release notes marker to sessions
+const addChangelogSeenSQL = `
+-- The Snipo version whose release notes the session has seen ('' if none)
+ALTER TABLE sessions ADD COLUMN changelog_seen TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 35: Add automation rules
+const addRulesSQL = `
+-- Scripts run when snippets are saved; events is a comma-separated list
+CREATE TABLE IF NOT EXISTS rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    events TEXT NOT NULL,
+    script TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Execution log of each rule; snippet_id is '' for snippets being created
+CREATE TABLE IF NOT EXISTS rule_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER NOT NULL,
+    snippet_id TEXT NOT NULL DEFAULT '',
+    snippet_title TEXT NOT NULL DEFAULT '',
+    event TEXT NOT NULL,
+    status TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    actions TEXT NOT NULL DEFAULT '',
+    output TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rule_id) REFERENCES rules(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_runs_rule ON rule_runs(rule_id, id);
+`
+
+// Migration 36: Add validation rules
+const addValidationRulesSQL = `
+-- Admin-adjustable snippet limits; an empty tag pattern means the default
+ALTER TABLE settings ADD COLUMN max_title_length INTEGER NOT NULL DEFAULT 200;
+ALTER TABLE settings ADD COLUMN max_description_length INTEGER NOT NULL DEFAULT 1000;
+ALTER TABLE settings ADD COLUMN max_content_size INTEGER NOT NULL DEFAULT 1048576;
+ALTER TABLE settings ADD COLUMN max_tag_length INTEGER NOT NULL DEFAULT 50;
+ALTER TABLE settings ADD COLUMN tag_pattern TEXT NOT NULL DEFAULT '';
+`
+
+// Migration 37: Add tag aliases and implications
+const addTagTaxonomySQL = `
+-- Alternative names that resolve to a tag when tagging and searching
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias TEXT PRIMARY KEY,
+    tag_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag_id);
+
+-- Tagging a snippet with tag_id also tags it with implied_tag_id
+CREATE TABLE IF NOT EXISTS tag_implications (
+    tag_id INTEGER NOT NULL,
+    implied_tag_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tag_id, implied_tag_id),
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+    FOREIGN KEY (implied_tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_implications_implied ON tag_implications(implied_tag_id);
+`
+
+const addTagTextColorSQL = `
+-- Tag colors used to be free text; reset anything that is not a hex color
+UPDATE tags SET color = lower(color);
+UPDATE tags SET color = '#6366f1'
+WHERE color IS NULL
+   OR (color NOT GLOB '#[0-9a-f][0-9a-f][0-9a-f]'
+       AND color NOT GLOB '#[0-9a-f][0-9a-f][0-9a-f][0-9a-f][0-9a-f][0-9a-f]');
+
+-- Label color with the most contrast against the tag color, set on save.
+-- Tags saved before have it computed when read.
+ALTER TABLE tags ADD COLUMN text_color TEXT NOT NULL DEFAULT '';
+`
+
+const addPublicFoldersSQL = `
+-- Public folders are listed read-only at /c/{slug}
+ALTER TABLE folders ADD COLUMN is_public INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE folders ADD COLUMN slug TEXT DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_slug ON folders(slug) WHERE slug IS NOT NULL;
+`
+
+const addAPIUsageSQL = `
+-- API requests counted per day, token (0 for sessions), method and route
+-- pattern. Tokens are not foreign keys, so deleting one keeps its history.
+CREATE TABLE IF NOT EXISTS api_usage (
+    day TEXT NOT NULL,
+    token_id INTEGER NOT NULL DEFAULT 0,
+    token_name TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    rate_limited INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, token_id, method, route)
+);
+`
+
+const addTokenFoldersSQL = `
+-- Folders an API token is limited to, each with a viewer or editor role
+-- that extends to subfolders. Tokens without rows reach every folder.
+CREATE TABLE IF NOT EXISTS token_folders (
+    token_id INTEGER NOT NULL,
+    folder_id INTEGER NOT NULL,
+    role TEXT NOT NULL,
+    PRIMARY KEY (token_id, folder_id),
+    FOREIGN KEY (token_id) REFERENCES api_tokens(id) ON DELETE CASCADE,
+    FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
+);
+`
+
+const addSnippetCheckoutsSQL = `
+-- Snippets used as runbooks can require a check-out, recording who is
+-- reading them and why, before their content is shown
+ALTER TABLE snippets ADD COLUMN requires_checkout INTEGER NOT NULL DEFAULT 0;
+
+-- Check-outs are the audit trail of those snippets. Snippets are not
+-- foreign keys, so deleting one keeps its trail.
+CREATE TABLE IF NOT EXISTS snippet_checkouts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id TEXT NOT NULL,
+    snippet_title TEXT NOT NULL DEFAULT '',
+    holder TEXT NOT NULL DEFAULT '',
+    source_ip TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL,
+    checked_out_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    checked_in_at DATETIME DEFAULT NULL,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_checkouts_snippet ON snippet_checkouts(snippet_id, checked_out_at);
+`
+
+const addRedactionRulesSQL = `
+-- Patterns masked in public views and exports; stored content is unchanged
+CREATE TABLE IF NOT EXISTS redaction_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    replacement TEXT NOT NULL DEFAULT '',
+    enabled INTEGER NOT NULL DEFAULT 1,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+const addLibrarySnapshotsSQL = `
+-- One manifest of snippet IDs, titles and checksums per day
+CREATE TABLE IF NOT EXISTS library_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    day TEXT NOT NULL UNIQUE,
+    snippet_count INTEGER NOT NULL DEFAULT 0,
+    manifest TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+const addSnippetNoIndexSQL = `
+-- Public snippets that may be shared by link but should stay out of
+-- search engines
+ALTER TABLE snippets ADD COLUMN noindex INTEGER NOT NULL DEFAULT 0;
+`
+
+const addTokenFolderLimitedSQL = `
+-- Tokens limited to folders stay limited when their folders are deleted,
+-- instead of reaching every folder once no token_folders rows are left
+ALTER TABLE api_tokens ADD COLUMN folder_limited INTEGER NOT NULL DEFAULT 0;
+UPDATE api_tokens SET folder_limited = 1 WHERE id IN (SELECT token_id FROM token_folders);
+`
+
+const addTokenProvisionedSQL = `
+-- Marks the token managed by SNIPO_BOOTSTRAP_TOKEN, rather than finding it
+-- by a name any token can have. Existing ones are adopted by their value
+-- on the next start.
+ALTER TABLE api_tokens ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
+`
+
 // getMigrations returns all available migrations in order
 func getMigrations() []Migration {
 	return []Migration{
@@ -271,5 +806,45 @@ func getMigrations() []Migration {
 		{Version: 5, Name: "add_editor_settings", SQL: addEditorSettingsSQL},
 		{Version: 6, Name: "add_markdown_settings", SQL: addMarkdownSettingsSQL},
 		{Version: 7, Name: "add_disable_login", SQL: addDisableLoginSQL},
+		{Version: 8, Name: "add_login_events", SQL: addLoginEventsSQL},
+		{Version: 9, Name: "add_source_url", SQL: addSourceURLSQL},
+		{Version: 10, Name: "add_pinning", SQL: addPinningSQL},
+		{Version: 11, Name: "add_folder_color", SQL: addFolderColorSQL},
+		{Version: 12, Name: "add_slugs", SQL: addSlugsSQL},
+		{Version: 13, Name: "add_snippet_metadata", SQL: addSnippetMetadataSQL},
+		{Version: 14, Name: "add_jobs", SQL: addJobsSQL},
+		{Version: 15, Name: "add_vault_notes", SQL: addVaultNotesSQL},
+		{Version: 16, Name: "add_license", SQL: addLicenseSQL},
+		{Version: 17, Name: "add_review", SQL: addReviewSQL},
+		{Version: 18, Name: "add_notifications", SQL: addNotificationsSQL},
+		{Version: 19, Name: "add_inbox", SQL: addInboxSQL},
+		{Version: 20, Name: "add_publish_at", SQL: addPublishAtSQL},
+		{Version: 21, Name: "add_announcement", SQL: addAnnouncementSQL},
+		{Version: 22, Name: "add_folder_archive_rules", SQL: addFolderArchiveRulesSQL},
+		{Version: 23, Name: "add_snippet_usage", SQL: addSnippetUsageSQL},
+		{Version: 24, Name: "add_snippet_links", SQL: addSnippetLinksSQL},
+		{Version: 25, Name: "add_history_actor", SQL: addHistoryActorSQL},
+		{Version: 26, Name: "add_history_details", SQL: addHistoryDetailsSQL},
+		{Version: 27, Name: "add_snippet_locks", SQL: addSnippetLocksSQL},
+		{Version: 28, Name: "add_collab_documents", SQL: addCollabDocumentsSQL},
+		{Version: 29, Name: "add_snippet_reports", SQL: addSnippetReportsSQL},
+		{Version: 30, Name: "add_session_keys", SQL: addSessionKeysSQL},
+		{Version: 31, Name: "add_token_restrictions", SQL: addTokenRestrictionsSQL},
+		{Version: 32, Name: "add_burn_links", SQL: addBurnLinksSQL},
+		{Version: 33, Name: "add_share_analytics", SQL: addShareAnalyticsSQL},
+		{Version: 34, Name: "add_changelog_seen", SQL: addChangelogSeenSQL},
+		{Version: 35, Name: "add_rules", SQL: addRulesSQL},
+		{Version: 36, Name: "add_validation_rules", SQL: addValidationRulesSQL},
+		{Version: 37, Name: "add_tag_taxonomy", SQL: addTagTaxonomySQL},
+		{Version: 38, Name: "add_tag_text_color", SQL: addTagTextColorSQL},
+		{Version: 39, Name: "add_public_folders", SQL: addPublicFoldersSQL},
+		{Version: 40, Name: "add_api_usage", SQL: addAPIUsageSQL},
+		{Version: 41, Name: "add_token_folders", SQL: addTokenFoldersSQL},
+		{Version: 42, Name: "add_snippet_checkouts", SQL: addSnippetCheckoutsSQL},
+		{Version: 43, Name: "add_redaction_rules", SQL: addRedactionRulesSQL},
+		{Version: 44, Name: "add_library_snapshots", SQL: addLibrarySnapshotsSQL},
+		{Version: 45, Name: "add_snippet_noindex", SQL: addSnippetNoIndexSQL},
+		{Version: 46, Name: "add_token_folder_limited", SQL: addTokenFolderLimitedSQL},
+		{Version: 47, Name: "add_token_provisioned", SQL: addTokenProvisionedSQL},
 	}
 }
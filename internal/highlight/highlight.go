@@ -0,0 +1,245 @@
+// Package highlight splits source code into tokens for syntax highlighting
+// where no browser is around to run Prism, such as PDF exports. It knows
+// each language's comments, strings and keywords, which is enough for a
+// readable printout; it does not parse anything.
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token
+type Kind int
+
+// Token kinds
+const (
+	Plain Kind = iota
+	Keyword
+	String
+	Comment
+	Number
+)
+
+// Token is a run of source text of one kind. Tokens may span lines.
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// syntax describes the lexical features of a language
+type syntax struct {
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	keywords     map[string]bool
+	ignoreCase   bool // Keywords match in any case; they are listed in lower case
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var (
+	cBlock    = [2]string{"/*", "*/"}
+	cKeywords = "auto break case char const continue default do double else enum extern float for goto if inline int long register return short signed sizeof static struct switch typedef union unsigned void volatile while NULL true false"
+)
+
+var syntaxes = map[string]syntax{
+	"go": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'`", keywords: words(
+		"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false iota")},
+	"javascript": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'`", keywords: words(
+		"async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new null of return static super switch this throw true false try typeof undefined var void while with yield")},
+	"typescript": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'`", keywords: words(
+		"abstract any as async await boolean break case catch class const continue declare default delete do else enum export extends finally for from function if implements import in instanceof interface keyof let namespace never new null number of private protected public readonly return static string super switch this throw true false try type typeof undefined unknown var void while yield")},
+	"python": {lineComments: []string{"#"}, quotes: "\"'", keywords: words(
+		"and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self")},
+	"java": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(
+		"abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long new null package private protected public return short static super switch synchronized this throw throws true false try void volatile while var")},
+	"c":   {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(cKeywords)},
+	"cpp": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(cKeywords + " bool catch class constexpr delete explicit friend namespace new noexcept nullptr operator private protected public template this throw try typename using virtual")},
+	"csharp": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(
+		"abstract as async await base bool break byte case catch char class const continue decimal default delegate do double else enum event false finally float for foreach if in int interface internal is long namespace new null object out override private protected public readonly ref return sealed short static string struct switch this throw true try typeof using var virtual void while")},
+	"rust": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"", keywords: words(
+		"as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while")},
+	"php": {lineComments: []string{"//", "#"}, blockComment: cBlock, quotes: "\"'", keywords: words(
+		"abstract array as break case catch class const continue default do echo else elseif extends false final finally fn for foreach function if implements interface namespace new null private protected public return static switch throw true try use var while")},
+	"ruby": {lineComments: []string{"#"}, quotes: "\"'", keywords: words(
+		"alias and begin break case class def do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield")},
+	"swift": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"", keywords: words(
+		"as break case catch class continue default defer do else enum extension false for func guard if import in init let nil private protocol public return self static struct switch throw throws true try var where while")},
+	"kotlin": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(
+		"as break class continue do else false for fun if import in interface is null object package private public return super this throw true try typealias val var when while")},
+	"scala": {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words(
+		"abstract case catch class def do else extends false final finally for if implicit import lazy match new null object override package private protected return sealed super this throw trait true try type val var while with yield")},
+	"bash": {lineComments: []string{"#"}, quotes: "\"'`", keywords: words(
+		"case do done elif else esac export fi for function if in local readonly return select then until while")},
+	"powershell": {lineComments: []string{"#"}, blockComment: [2]string{"<#", "#>"}, quotes: "\"'", keywords: words(
+		"begin break catch class continue do else elseif end exit filter finally for foreach function if in param process return switch throw trap try until while")},
+	"sql": {lineComments: []string{"--"}, blockComment: cBlock, quotes: "'\"", ignoreCase: true, keywords: words(
+		"add all alter and as asc begin between by case check column commit constraint create cross default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not null on or order outer primary references right rollback select set table then union unique update values view when where with")},
+	"lua": {lineComments: []string{"--"}, quotes: "\"'", keywords: words(
+		"and break do else elseif end false for function goto if in local nil not or repeat return then true until while")},
+	"haskell": {lineComments: []string{"--"}, blockComment: [2]string{"{-", "-}"}, quotes: "\"", keywords: words(
+		"case class data deriving do else if import in infix instance let module newtype of then type where")},
+	"elixir": {lineComments: []string{"#"}, quotes: "\"'", keywords: words(
+		"after and case catch cond def defmodule defp do else end false fn for if import in nil not or quote raise receive require rescue true try unless use when with")},
+	"perl": {lineComments: []string{"#"}, quotes: "\"'", keywords: words(
+		"else elsif for foreach if last local my next our package return sub unless until use while")},
+	"r": {lineComments: []string{"#"}, quotes: "\"'", keywords: words(
+		"break else FALSE for function if in NA next NULL repeat return TRUE while")},
+	"css":        {blockComment: cBlock, quotes: "\"'"},
+	"scss":       {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'"},
+	"json":       {quotes: "\"", keywords: words("true false null")},
+	"yaml":       {lineComments: []string{"#"}, quotes: "\"'", keywords: words("true false null yes no")},
+	"toml":       {lineComments: []string{"#"}, quotes: "\"'", keywords: words("true false")},
+	"ini":        {lineComments: []string{";", "#"}, quotes: "\""},
+	"dockerfile": {lineComments: []string{"#"}, quotes: "\"'", keywords: words("ADD ARG CMD COPY ENTRYPOINT ENV EXPOSE FROM HEALTHCHECK LABEL ONBUILD RUN SHELL STOPSIGNAL USER VOLUME WORKDIR AS")},
+	"makefile":   {lineComments: []string{"#"}, quotes: "\"'", keywords: words("define endef ifeq ifneq ifdef ifndef else endif include export")},
+	"nginx":      {lineComments: []string{"#"}, quotes: "\"'", keywords: words("server location listen root index include return rewrite proxy_pass upstream http events if set")},
+	"terraform":  {lineComments: []string{"#", "//"}, blockComment: cBlock, quotes: "\"", keywords: words("resource data variable output module provider locals terraform true false null for in if")},
+	"graphql":    {lineComments: []string{"#"}, quotes: "\"", keywords: words("query mutation subscription fragment on type input enum interface union scalar schema extend implements true false null")},
+	"protobuf":   {lineComments: []string{"//"}, blockComment: cBlock, quotes: "\"'", keywords: words("syntax package import option message enum service rpc returns repeated optional required map oneof reserved true false")},
+	"clojure":    {lineComments: []string{";"}, quotes: "\"", keywords: words("def defn defmacro fn let if do loop recur when cond nil true false ns")},
+	"html":       {blockComment: [2]string{"<!--", "-->"}, quotes: "\"'"},
+	"xml":        {blockComment: [2]string{"<!--", "-->"}, quotes: "\"'"},
+}
+
+func init() {
+	syntaxes["shell"] = syntaxes["bash"]
+}
+
+// Supported reports whether a language is highlighted at all
+func Supported(language string) bool {
+	_, ok := syntaxes[strings.ToLower(strings.TrimSpace(language))]
+	return ok
+}
+
+// Lex splits source into tokens. Unknown languages come back as a single
+// plain token. Concatenating the tokens' text always gives back source.
+func Lex(language, source string) []Token {
+	syn, ok := syntaxes[strings.ToLower(strings.TrimSpace(language))]
+	if !ok {
+		if source == "" {
+			return nil
+		}
+		return []Token{{Plain, source}}
+	}
+
+	var (
+		tokens []Token
+		plain  int // Start of the pending plain text
+	)
+	emit := func(start, end int, kind Kind) {
+		if plain < start {
+			tokens = append(tokens, Token{Plain, source[plain:start]})
+		}
+		tokens = append(tokens, Token{kind, source[start:end]})
+		plain = end
+	}
+
+	for i := 0; i < len(source); {
+		rest := source[i:]
+		if end, ok := syn.comment(rest); ok {
+			emit(i, i+end, Comment)
+			i += end
+			continue
+		}
+
+		c := source[i]
+		switch {
+		case strings.IndexByte(syn.quotes, c) >= 0:
+			end := quoted(rest)
+			emit(i, i+end, String)
+			i += end
+		case isDigit(c) && (i == 0 || !isWord(rune(source[i-1]))):
+			end := 1
+			for end < len(rest) && (isWord(rune(rest[end])) || rest[end] == '.') {
+				end++
+			}
+			emit(i, i+end, Number)
+			i += end
+		case isWordStart(rest):
+			end := wordEnd(rest)
+			word := rest[:end]
+			if syn.ignoreCase {
+				word = strings.ToLower(word)
+			}
+			if syn.keywords[word] {
+				emit(i, i+end, Keyword)
+			}
+			i += end
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			i += size
+		}
+	}
+	if plain < len(source) {
+		tokens = append(tokens, Token{Plain, source[plain:]})
+	}
+	return tokens
+}
+
+// comment returns the length of the comment s starts with, if any
+func (syn syntax) comment(s string) (int, bool) {
+	for _, prefix := range syn.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			if end := strings.IndexByte(s, '\n'); end >= 0 {
+				return end, true
+			}
+			return len(s), true
+		}
+	}
+	if open, closing := syn.blockComment[0], syn.blockComment[1]; open != "" && strings.HasPrefix(s, open) {
+		if end := strings.Index(s[len(open):], closing); end >= 0 {
+			return len(open) + end + len(closing), true
+		}
+		return len(s), true
+	}
+	return 0, false
+}
+
+// quoted returns the length of the string literal s starts with. Strings
+// end at the closing quote, and at the end of the line unless they are
+// backquoted.
+func quoted(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		case '\n':
+			if quote != '`' {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isWord(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+func isWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func wordEnd(s string) int {
+	for i, r := range s {
+		if !isWord(r) {
+			return i
+		}
+	}
+	return len(s)
+}
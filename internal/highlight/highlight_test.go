@@ -0,0 +1,81 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func kinds(tokens []Token) map[string]Kind {
+	m := map[string]Kind{}
+	for _, tok := range tokens {
+		m[strings.TrimSpace(tok.Text)] = tok.Kind
+	}
+	return m
+}
+
+func TestLex(t *testing.T) {
+	src := "// Greet\nfunc main() {\n\tfmt.Println(\"hi // there\", 42)\n}\n"
+	tokens := Lex("go", src)
+
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteString(tok.Text)
+	}
+	if b.String() != src {
+		t.Fatalf("expected the tokens to add up to the source, got %q", b.String())
+	}
+
+	got := kinds(tokens)
+	want := map[string]Kind{
+		"// Greet":      Comment,
+		"func":          Keyword,
+		`"hi // there"`: String,
+		"42":            Number,
+	}
+	for text, kind := range want {
+		if got[text] != kind {
+			t.Errorf("expected %q as kind %d, got %d", text, kind, got[text])
+		}
+	}
+}
+
+func TestLex_Languages(t *testing.T) {
+	tests := []struct {
+		language, src, text string
+		kind                Kind
+	}{
+		{"python", "x = 1  # note", "# note", Comment},
+		{"python", "def f(): pass", "def", Keyword},
+		{"sql", "SELECT * FROM t -- all", "SELECT", Keyword},
+		{"sql", "SELECT * FROM t -- all", "-- all", Comment},
+		{"bash", "echo 'a b' # c", "'a b'", String},
+		{"css", "/* a\nb */ p {}", "/* a\nb */", Comment},
+		{"javascript", "const s = `a\nb`", "`a\nb`", String},
+		{"Shell", "if true; then :; fi", "fi", Keyword},
+	}
+	for _, tt := range tests {
+		if got, ok := kinds(Lex(tt.language, tt.src))[tt.text]; !ok || got != tt.kind {
+			t.Errorf("%s %q: expected %q as kind %d, got %d (found %v)", tt.language, tt.src, tt.text, tt.kind, got, ok)
+		}
+	}
+}
+
+func TestLex_Unknown(t *testing.T) {
+	tokens := Lex("plaintext", "if x // y")
+	if len(tokens) != 1 || tokens[0].Kind != Plain {
+		t.Errorf("expected one plain token, got %v", tokens)
+	}
+	if Lex("plaintext", "") != nil {
+		t.Error("expected no tokens for empty source")
+	}
+	if Supported("plaintext") || !Supported("Go") {
+		t.Error("unexpected Supported result")
+	}
+}
+
+func TestLex_UnterminatedString(t *testing.T) {
+	tokens := Lex("go", "s := \"open\nnext")
+	if got := kinds(tokens)[`"open`]; got != String {
+		t.Errorf("expected the string to end at the line, got kind %d", got)
+	}
+}
@@ -0,0 +1,143 @@
+// Package i18n translates user-facing server messages. Catalogs are keyed by
+// the English message itself, so a message without a translation falls back
+// to English and callers keep passing plain strings.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed locales/*.json
+var catalogFS embed.FS
+
+// Default is the locale of the messages in the code
+const Default = "en"
+
+// Locale describes a supported locale
+type Locale struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`      // Native name
+	Direction string `json:"direction"` // Text direction: ltr or rtl
+}
+
+// locales are the supported locales, the default first
+var locales = []Locale{
+	{Code: "en", Name: "English", Direction: "ltr"},
+	{Code: "de", Name: "Deutsch", Direction: "ltr"},
+	{Code: "es", Name: "Español", Direction: "ltr"},
+	{Code: "ar", Name: "العربية", Direction: "rtl"},
+}
+
+// catalogs maps a locale code to its English -> translated messages
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string)
+	for _, locale := range locales {
+		if locale.Code == Default {
+			continue
+		}
+		data, err := catalogFS.ReadFile("locales/" + locale.Code + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale.Code, err))
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale.Code, err))
+		}
+		catalogs[locale.Code] = catalog
+	}
+	return catalogs
+}
+
+// Locales returns the supported locales, the default first
+func Locales() []Locale {
+	return append([]Locale(nil), locales...)
+}
+
+// Lookup returns a supported locale by code, or the default locale
+func Lookup(code string) Locale {
+	for _, locale := range locales {
+		if locale.Code == code {
+			return locale
+		}
+	}
+	return locales[0]
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language
+// header, e.g. "de-AT,de;q=0.9,en;q=0.5" gives "de". Region subtags match
+// their base language; without a match it returns Default.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return Default
+		}
+		base, _, _ := strings.Cut(c.tag, "-")
+		for _, locale := range locales {
+			if locale.Code == c.tag || locale.Code == base {
+				return locale.Code
+			}
+		}
+	}
+	return Default
+}
+
+// FromRequest returns the locale negotiated from the request's Accept-Language header
+func FromRequest(r *http.Request) string {
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// ForResponse negotiates the locale for a translated response and marks the
+// response with it, so caches keep one copy per language
+func ForResponse(w http.ResponseWriter, r *http.Request) string {
+	locale := FromRequest(r)
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+// T translates a message, returning it unchanged when the locale has no
+// translation for it
+func T(locale, message string) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf translates a format string and then formats it
+func Sprintf(locale, format string, args ...any) string {
+	return fmt.Sprintf(T(locale, format), args...)
+}
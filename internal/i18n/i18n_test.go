@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-AT,de;q=0.9,en;q=0.5", "de"},
+		{"fr-FR,fr;q=0.9,es;q=0.8", "es"},
+		{"en;q=0.4, ar;q=0.7", "ar"},
+		{"es;q=0, de", "de"},
+		{"ja, *;q=0.1", "en"},
+		{"AR-eg", "ar"},
+		{"de;q=abc", "en"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("de", "Title is required"); got != "Titel ist erforderlich" {
+		t.Errorf("unexpected translation %q", got)
+	}
+	if got := T("de", "No such message"); got != "No such message" {
+		t.Errorf("expected the message back, got %q", got)
+	}
+	if got := T("en", "Title is required"); got != "Title is required" {
+		t.Errorf("expected English unchanged, got %q", got)
+	}
+	if got := Sprintf("es", "Page %d of %d", 2, 5); got != "Página 2 de 5" {
+		t.Errorf("unexpected formatted translation %q", got)
+	}
+}
+
+// Every catalog must translate the same messages
+func TestCatalogsMatch(t *testing.T) {
+	reference := catalogs["de"]
+	for code, catalog := range catalogs {
+		if len(catalog) != len(reference) {
+			t.Errorf("%s has %d messages, de has %d", code, len(catalog), len(reference))
+		}
+		for message := range reference {
+			if catalog[message] == "" {
+				t.Errorf("%s is missing %q", code, message)
+			}
+		}
+	}
+	for _, locale := range Locales() {
+		if _, ok := catalogs[locale.Code]; !ok && locale.Code != Default {
+			t.Errorf("no catalog for %s", locale.Code)
+		}
+	}
+}
+
+func TestForResponse(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "ar")
+	rec := httptest.NewRecorder()
+
+	if got := ForResponse(rec, req); got != "ar" {
+		t.Errorf("expected ar, got %q", got)
+	}
+	if rec.Header().Get("Content-Language") != "ar" || rec.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+	if Lookup("ar").Direction != "rtl" || Lookup("xx").Code != Default {
+		t.Error("unexpected Lookup result")
+	}
+}
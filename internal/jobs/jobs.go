@@ -0,0 +1,509 @@
+// Package jobs runs long operations outside the HTTP request that started
+// them. Jobs are stored in SQLite so they survive restarts, picked up by a
+// pool of workers, and retried with backoff when they fail. Callers get a job
+// ID back immediately, can poll the job's status and result, and can subscribe
+// to progress events while it runs.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+const (
+	// DefaultWorkers is the number of jobs run concurrently
+	DefaultWorkers = 2
+
+	// DefaultRetention is how long finished jobs stay queryable
+	DefaultRetention = 7 * 24 * time.Hour
+
+	// pollInterval is how often idle workers look for due jobs (retries)
+	pollInterval = time.Second
+
+	// flushInterval limits how often progress is written to the database
+	flushInterval = time.Second
+
+	// maxErrors caps the per-item errors kept on a job
+	maxErrors = 100
+)
+
+var (
+	// ErrNotFound is returned for unknown or expired job IDs
+	ErrNotFound = errors.New("job not found")
+
+	// ErrUnknownKind is returned when enqueueing a kind without a handler
+	ErrUnknownKind = errors.New("unknown job kind")
+)
+
+// Handler does the work of a job. Its result is stored as JSON on the job
+// when it succeeds; returning an error fails the attempt.
+type Handler func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error)
+
+// RetryPolicy controls how often a failing job is attempted
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts, including the first
+	Backoff     time.Duration // Delay before the first retry, doubled after each
+}
+
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Queue stores jobs in the database and runs them on a worker pool
+type Queue struct {
+	repo      *repository.JobRepository
+	lc        *lifecycle.Manager
+	logger    *slog.Logger
+	workers   int
+	retention time.Duration
+	wake      chan struct{}
+
+	mu       sync.Mutex
+	handlers map[string]registration
+	running  map[string]*models.Job                  // Live state of jobs running in this process
+	subs     map[string]map[chan models.Job]struct{} // Subscribers by job ID
+}
+
+// NewQueue creates a job queue. When lc is set, workers are cancelled and
+// drained on shutdown and interrupted jobs are requeued.
+func NewQueue(repo *repository.JobRepository, lc *lifecycle.Manager, logger *slog.Logger) *Queue {
+	return &Queue{
+		repo:      repo,
+		lc:        lc,
+		logger:    logger,
+		workers:   DefaultWorkers,
+		retention: DefaultRetention,
+		wake:      make(chan struct{}, 1),
+		handlers:  make(map[string]registration),
+		running:   make(map[string]*models.Job),
+		subs:      make(map[string]map[chan models.Job]struct{}),
+	}
+}
+
+// WithWorkers sets the number of concurrent workers
+func (q *Queue) WithWorkers(n int) *Queue {
+	if n > 0 {
+		q.workers = n
+	}
+	return q
+}
+
+// WithRetention sets how long finished jobs stay queryable
+func (q *Queue) WithRetention(d time.Duration) *Queue {
+	q.retention = d
+	return q
+}
+
+// Register sets the handler for a job kind
+func (q *Queue) Register(kind string, handler Handler, policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = registration{handler: handler, policy: policy}
+}
+
+// Start requeues jobs interrupted by a previous crash and starts the workers
+func (q *Queue) Start(ctx context.Context) error {
+	// A replica leaves interrupted jobs to the primary
+	if q.isWriter() {
+		recovered, err := q.repo.RecoverInterrupted(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		if recovered > 0 {
+			q.logger.Info("recovered interrupted jobs", "count", recovered)
+		}
+	}
+
+	for i := 0; i < q.workers; i++ {
+		q.goBackground(fmt.Sprintf("job-worker-%d", i+1), q.work)
+	}
+	if q.lc != nil {
+		_ = q.lc.Every("job-cleanup", time.Hour, q.cleanup)
+	}
+
+	return nil
+}
+
+// goBackground runs fn tracked by the lifecycle manager when there is one
+func (q *Queue) goBackground(name string, fn func(ctx context.Context) error) {
+	if q.lc != nil {
+		if err := q.lc.Go(name, fn); err != nil {
+			q.logger.Warn("skipping background work", "worker", name, "error", err)
+		}
+		return
+	}
+	go func() { _ = fn(context.Background()) }()
+}
+
+// Enqueue stores a new job for kind with payload marshalled as JSON
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (models.Job, error) {
+	q.mu.Lock()
+	reg, ok := q.handlers[kind]
+	q.mu.Unlock()
+	if !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := models.Job{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Status:      models.JobStatusPending,
+		Payload:     encoded,
+		MaxAttempts: reg.policy.MaxAttempts,
+		RunAfter:    now,
+		CreatedAt:   now,
+	}
+	if err := q.repo.Create(ctx, &job); err != nil {
+		return models.Job{}, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	q.logger.Info("job enqueued", "id", job.ID, "kind", kind)
+	return job, nil
+}
+
+// Get returns a job, with live progress if it is running
+func (q *Queue) Get(ctx context.Context, id string) (models.Job, error) {
+	q.mu.Lock()
+	if live, ok := q.running[id]; ok {
+		job := clone(*live)
+		q.mu.Unlock()
+		return job, nil
+	}
+	q.mu.Unlock()
+
+	job, err := q.repo.GetByID(ctx, id)
+	if err != nil {
+		return models.Job{}, err
+	}
+	if job == nil {
+		return models.Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// List returns jobs, newest first, with live progress for running ones
+func (q *Queue) List(ctx context.Context, filter models.JobFilter) ([]models.Job, error) {
+	list, err := q.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range list {
+		if live, ok := q.running[list[i].ID]; ok {
+			list[i] = clone(*live)
+		}
+	}
+	return list, nil
+}
+
+// Subscribe returns the current state of a job and a channel of updates.
+// The channel is closed when the job finishes or this process stops running
+// it; call Get for the final state. The returned cancel func must be called
+// once the caller stops listening.
+func (q *Queue) Subscribe(ctx context.Context, id string) (models.Job, <-chan models.Job, func(), error) {
+	ch := make(chan models.Job, 16)
+
+	// Register before reading the state so a job finishing in between still
+	// closes the channel
+	q.mu.Lock()
+	if q.subs[id] == nil {
+		q.subs[id] = make(map[chan models.Job]struct{})
+	}
+	q.subs[id][ch] = struct{}{}
+	q.mu.Unlock()
+
+	cancel := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if _, ok := q.subs[id][ch]; ok {
+			delete(q.subs[id], ch)
+			close(ch)
+			if len(q.subs[id]) == 0 {
+				delete(q.subs, id)
+			}
+		}
+	}
+
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		cancel()
+		return models.Job{}, nil, nil, err
+	}
+	if job.Done() {
+		cancel()
+	}
+	return job, ch, cancel, nil
+}
+
+// work is a worker loop: run due jobs, then wait for new ones
+func (q *Queue) work(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			ran, err := q.runNext(ctx)
+			if err != nil {
+				q.logger.Warn("job worker failed", "error", err)
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// isWriter reports whether this instance may claim jobs, which only the
+// primary does when instances share a replicated database
+func (q *Queue) isWriter() bool {
+	return q.lc == nil || q.lc.IsWriter()
+}
+
+// runNext claims and runs one due job, reporting whether there was one
+func (q *Queue) runNext(ctx context.Context) (bool, error) {
+	if !q.isWriter() {
+		return false, nil
+	}
+	job, err := q.repo.ClaimNext(ctx, time.Now())
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// Bookkeeping must still happen when ctx is cancelled by shutdown
+	store := context.WithoutCancel(ctx)
+
+	q.mu.Lock()
+	reg, ok := q.handlers[job.Kind]
+	q.mu.Unlock()
+	if !ok {
+		q.finish(store, job, nil, fmt.Errorf("%w: %s", ErrUnknownKind, job.Kind))
+		return true, nil
+	}
+
+	// Each attempt reports progress from scratch
+	job.Processed, job.Total, job.Errors = 0, 0, nil
+	q.mu.Lock()
+	q.running[job.ID] = job
+	q.mu.Unlock()
+	q.notify(job.ID)
+
+	p := &Progress{queue: q, id: job.ID, ctx: store}
+	result, runErr := q.execute(ctx, reg.handler, job.Payload, p)
+	p.flush()
+
+	switch {
+	case runErr != nil && ctx.Err() != nil:
+		// Shutdown interrupted the job; run it again after the restart
+		if err := q.repo.Requeue(store, job.ID); err != nil {
+			q.logger.Error("failed to requeue interrupted job", "id", job.ID, "error", err)
+		}
+		q.logger.Info("job interrupted by shutdown", "id", job.ID, "kind", job.Kind)
+		q.release(job.ID, true)
+	case runErr != nil && job.Attempts < job.MaxAttempts:
+		delay := reg.policy.Backoff << (job.Attempts - 1)
+		if err := q.repo.Retry(store, job.ID, runErr.Error(), time.Now().Add(delay)); err != nil {
+			q.logger.Error("failed to schedule job retry", "id", job.ID, "error", err)
+		}
+		q.logger.Warn("job attempt failed, retrying", "id", job.ID, "kind", job.Kind,
+			"attempt", job.Attempts, "max_attempts", job.MaxAttempts, "retry_in", delay, "error", runErr)
+		q.release(job.ID, false)
+	default:
+		q.finish(store, job, result, runErr)
+	}
+
+	return true, nil
+}
+
+// execute runs a handler, turning panics into errors
+func (q *Queue) execute(ctx context.Context, h Handler, payload json.RawMessage, p *Progress) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(ctx, payload, p)
+}
+
+// finish records the final outcome of a job and ends its subscriptions
+func (q *Queue) finish(ctx context.Context, job *models.Job, result any, runErr error) {
+	now := time.Now()
+	if runErr == nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			runErr = fmt.Errorf("failed to encode job result: %w", err)
+		} else if err := q.repo.Complete(ctx, job.ID, encoded, now); err != nil {
+			q.logger.Error("failed to store job result", "id", job.ID, "error", err)
+		} else {
+			q.logger.Info("job finished", "id", job.ID, "kind", job.Kind, "processed", job.Processed, "errors", len(job.Errors))
+		}
+	}
+	if runErr != nil {
+		if err := q.repo.Fail(ctx, job.ID, runErr.Error(), now); err != nil {
+			q.logger.Error("failed to store job failure", "id", job.ID, "error", err)
+		}
+		q.logger.Warn("job failed", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", runErr)
+	}
+
+	q.release(job.ID, true)
+}
+
+// release stops tracking a job's live state. With end, subscribers are
+// closed; otherwise they get the stored state and keep listening.
+func (q *Queue) release(id string, end bool) {
+	q.mu.Lock()
+	delete(q.running, id)
+	q.mu.Unlock()
+
+	if !end {
+		q.notify(id)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for ch := range q.subs[id] {
+		close(ch)
+	}
+	delete(q.subs, id)
+}
+
+// notify sends the job's current state to its subscribers
+func (q *Queue) notify(id string) {
+	job, err := q.Get(context.Background(), id)
+	if err != nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for ch := range q.subs[id] {
+		// Snapshots are cumulative, so a slow subscriber can skip some
+		select {
+		case ch <- job:
+		default:
+		}
+	}
+}
+
+// cleanup deletes finished jobs past the retention period
+func (q *Queue) cleanup(ctx context.Context) error {
+	deleted, err := q.repo.DeleteFinishedBefore(ctx, time.Now().Add(-q.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		q.logger.Info("deleted old jobs", "count", deleted)
+	}
+	return nil
+}
+
+// clone copies a job so snapshots don't share the errors slice
+func clone(j models.Job) models.Job {
+	if j.Errors != nil {
+		j.Errors = append([]string(nil), j.Errors...)
+	}
+	return j
+}
+
+// Progress reports a running job's progress. Updates reach subscribers
+// immediately and are written to the database at most once per second.
+type Progress struct {
+	queue     *Queue
+	id        string
+	ctx       context.Context
+	lastFlush time.Time
+}
+
+// SetTotal sets the number of items the job will process
+func (p *Progress) SetTotal(total int) {
+	p.update(func(j *models.Job) { j.Total = total })
+}
+
+// Advance marks n more items as processed
+func (p *Progress) Advance(n int) {
+	p.update(func(j *models.Job) { j.Processed += n })
+}
+
+// Error records a per-item error without failing the job
+func (p *Progress) Error(msg string) {
+	p.update(func(j *models.Job) {
+		if len(j.Errors) < maxErrors {
+			j.Errors = append(j.Errors, msg)
+		}
+	})
+}
+
+func (p *Progress) update(fn func(j *models.Job)) {
+	q := p.queue
+	q.mu.Lock()
+	job, ok := q.running[p.id]
+	if ok {
+		fn(job)
+	}
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	q.notify(p.id)
+	if time.Since(p.lastFlush) >= flushInterval {
+		p.flush()
+	}
+}
+
+// flush writes the live progress to the database
+func (p *Progress) flush() {
+	q := p.queue
+	q.mu.Lock()
+	job, ok := q.running[p.id]
+	var snapshot models.Job
+	if ok {
+		snapshot = clone(*job)
+	}
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	p.lastFlush = time.Now()
+	if err := q.repo.UpdateProgress(p.ctx, p.id, snapshot.Processed, snapshot.Total, snapshot.Errors); err != nil {
+		q.logger.Warn("failed to store job progress", "id", p.id, "error", err)
+	}
+}
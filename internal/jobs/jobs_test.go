@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+// newTestQueue creates an unstarted queue and the lifecycle that owns its workers
+func newTestQueue(t *testing.T) (*Queue, *lifecycle.Manager, *repository.JobRepository) {
+	t.Helper()
+	db := testutil.TestDB(t)
+	// In-memory databases are per connection
+	db.SetMaxOpenConns(1)
+
+	logger := testutil.TestLogger()
+	lc := lifecycle.New(logger)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = lc.Shutdown(ctx)
+	})
+
+	repo := repository.NewJobRepository(db)
+	return NewQueue(repo, lc, logger), lc, repo
+}
+
+// waitDone polls until the job finishes
+func waitDone(t *testing.T, q *Queue, id string) models.Job {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("failed to get job: %v", err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish in time")
+	return models.Job{}
+}
+
+func TestQueue_RunsJobAndStoresResult(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.Register("echo", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		var input struct{ Items int }
+		if err := json.Unmarshal(payload, &input); err != nil {
+			return nil, err
+		}
+		p.SetTotal(input.Items)
+		for i := 0; i < input.Items; i++ {
+			p.Advance(1)
+		}
+		p.Error("item 2 skipped")
+		return map[string]int{"done": input.Items}, nil
+	}, RetryPolicy{})
+
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	job, err := q.Enqueue(ctx, "echo", map[string]int{"Items": 3})
+	if err != nil {
+		t.Fatalf("failed to enqueue job: %v", err)
+	}
+	if job.Status != models.JobStatusPending {
+		t.Errorf("expected new job to be pending, got %s", job.Status)
+	}
+
+	done := waitDone(t, q, job.ID)
+	if done.Status != models.JobStatusSucceeded || string(done.Result) != `{"done":3}` {
+		t.Errorf("expected succeeded job with result, got %+v", done)
+	}
+	if done.Processed != 3 || done.Total != 3 || len(done.Errors) != 1 || done.Attempts != 1 {
+		t.Errorf("unexpected job state: %+v", done)
+	}
+
+	list, err := q.List(ctx, models.JobFilter{Kind: "echo"})
+	if err != nil || len(list) != 1 || list[0].ID != job.ID {
+		t.Errorf("expected job in list, got %+v (err %v)", list, err)
+	}
+}
+
+func TestQueue_RetriesWithBackoff(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	q.Register("flaky", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("temporary failure")
+		}
+		return "ok", nil
+	}, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	q.Register("broken", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		panic("always")
+	}, RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	flaky, _ := q.Enqueue(ctx, "flaky", nil)
+	broken, _ := q.Enqueue(ctx, "broken", nil)
+
+	if job := waitDone(t, q, flaky.ID); job.Status != models.JobStatusSucceeded || job.Attempts != 3 {
+		t.Errorf("expected success on third attempt, got %+v", job)
+	}
+	if job := waitDone(t, q, broken.ID); job.Status != models.JobStatusFailed || job.Attempts != 2 || job.Error == "" {
+		t.Errorf("expected failure after two attempts, got %+v", job)
+	}
+}
+
+func TestQueue_UnknownKind(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	if _, err := q.Enqueue(context.Background(), "missing", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("expected ErrUnknownKind, got %v", err)
+	}
+	if _, err := q.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueue_SubscribeStreamsProgress(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	q.Register("gated", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		<-release
+		p.SetTotal(1)
+		p.Advance(1)
+		return nil, nil
+	}, RetryPolicy{})
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	job, _ := q.Enqueue(ctx, "gated", nil)
+	_, updates, cancel, err := q.Subscribe(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer cancel()
+	close(release)
+
+	var last models.Job
+	for update := range updates {
+		last = update
+	}
+	if last.Processed != 1 {
+		t.Errorf("expected to see progress before the channel closed, got %+v", last)
+	}
+	if final, _ := q.Get(ctx, job.ID); final.Status != models.JobStatusSucceeded {
+		t.Errorf("expected job to succeed, got %+v", final)
+	}
+}
+
+func TestQueue_ShutdownRequeuesAndRestartResumes(t *testing.T) {
+	q, lc, repo := newTestQueue(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, RetryPolicy{})
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	job, _ := q.Enqueue(ctx, "slow", nil)
+	<-started
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	stored, _ := repo.GetByID(ctx, job.ID)
+	if stored == nil || stored.Status != models.JobStatusPending || stored.Attempts != 0 {
+		t.Fatalf("expected interrupted job to be requeued without using an attempt, got %+v", stored)
+	}
+
+	// A new process picks the job up again
+	logger := testutil.TestLogger()
+	lc2 := lifecycle.New(logger)
+	defer func() { _ = lc2.Shutdown(shutdownCtx) }()
+	q2 := NewQueue(repo, lc2, logger)
+	q2.Register("slow", func(ctx context.Context, payload json.RawMessage, p *Progress) (any, error) {
+		return "resumed", nil
+	}, RetryPolicy{})
+	if err := q2.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	if done := waitDone(t, q2, job.ID); done.Status != models.JobStatusSucceeded || string(done.Result) != `"resumed"` {
+		t.Errorf("expected resumed job to succeed, got %+v", done)
+	}
+}
@@ -0,0 +1,125 @@
+// Package languages holds the snippet language catalog: the file extension
+// used for each language when files are exported or named automatically,
+// and the language detected from a file name.
+package languages
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// DefaultExtension is used for plain text and unknown languages
+const DefaultExtension = "txt"
+
+// extensions maps snippet languages to file extensions
+var extensions = map[string]string{
+	"plaintext":  "txt",
+	"javascript": "js",
+	"typescript": "ts",
+	"python":     "py",
+	"go":         "go",
+	"rust":       "rs",
+	"java":       "java",
+	"c":          "c",
+	"cpp":        "cpp",
+	"csharp":     "cs",
+	"php":        "php",
+	"ruby":       "rb",
+	"swift":      "swift",
+	"kotlin":     "kt",
+	"scala":      "scala",
+	"html":       "html",
+	"css":        "css",
+	"scss":       "scss",
+	"json":       "json",
+	"yaml":       "yaml",
+	"xml":        "xml",
+	"markdown":   "md",
+	"sql":        "sql",
+	"bash":       "sh",
+	"shell":      "sh",
+	"powershell": "ps1",
+	"dockerfile": "dockerfile",
+	"nginx":      "conf",
+	"toml":       "toml",
+	"ini":        "ini",
+	"makefile":   "mk",
+	"lua":        "lua",
+	"perl":       "pl",
+	"r":          "r",
+	"haskell":    "hs",
+	"elixir":     "ex",
+	"clojure":    "clj",
+	"graphql":    "graphql",
+	"protobuf":   "proto",
+	"terraform":  "tf",
+}
+
+// extensionAliases are extensions that are not the canonical one of their language
+var extensionAliases = map[string]string{
+	"jsx": "javascript", "mjs": "javascript", "cjs": "javascript", "tsx": "typescript",
+	"h": "c", "hpp": "cpp", "cc": "cpp", "cxx": "cpp", "yml": "yaml", "htm": "html",
+	"markdown": "markdown", "bash": "bash", "zsh": "bash", "psm1": "powershell",
+	"exs": "elixir", "cljs": "clojure", "gql": "graphql", "hcl": "terraform",
+	"text": "plaintext",
+}
+
+// fileNames maps file names without a telling extension to their language
+var fileNames = map[string]string{
+	"dockerfile": "dockerfile", "containerfile": "dockerfile", "makefile": "makefile",
+	"gnumakefile": "makefile", "nginx.conf": "nginx",
+}
+
+// byExtension maps each extension in the catalog back to its language. Where
+// languages share an extension the first in sorted order wins ("sh" is bash).
+var byExtension = func() map[string]string {
+	m := make(map[string]string, len(extensions)+len(extensionAliases))
+	for _, lang := range List() {
+		if _, ok := m[lang.Extension]; !ok {
+			m[lang.Extension] = lang.ID
+		}
+	}
+	for ext, lang := range extensionAliases {
+		m[ext] = lang
+	}
+	delete(m, "conf") // Too generic to mean nginx
+	return m
+}()
+
+// Detect returns the language of a file from its name, or "plaintext" when
+// the name does not tell
+func Detect(filename string) string {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if lang, ok := fileNames[name]; ok {
+		return lang
+	}
+	if lang, ok := byExtension[strings.TrimPrefix(path.Ext(name), ".")]; ok {
+		return lang
+	}
+	if strings.HasPrefix(name, "dockerfile.") {
+		return "dockerfile"
+	}
+	return "plaintext"
+}
+
+// Extension returns the file extension (without the dot) for a language,
+// or DefaultExtension when the language is unknown
+func Extension(lang string) string {
+	if ext, ok := extensions[strings.ToLower(strings.TrimSpace(lang))]; ok {
+		return ext
+	}
+	return DefaultExtension
+}
+
+// List returns the catalog sorted by language
+func List() []models.Language {
+	list := make([]models.Language, 0, len(extensions))
+	for id, ext := range extensions {
+		list = append(list, models.Language{ID: id, Extension: ext})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
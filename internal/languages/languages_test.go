@@ -0,0 +1,69 @@
+package languages
+
+import (
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+func TestExtension(t *testing.T) {
+	tests := map[string]string{
+		"python":     "py",
+		" Python ":   "py",
+		"shell":      "sh",
+		"markdown":   "md",
+		"plaintext":  "txt",
+		"":           "txt",
+		"brainfuck":  "txt",
+		"powershell": "ps1",
+	}
+	for lang, want := range tests {
+		if got := Extension(lang); got != want {
+			t.Errorf("Extension(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestListCoversAllowedLanguages(t *testing.T) {
+	known := make(map[string]bool)
+	list := List()
+	for i, lang := range list {
+		if i > 0 && list[i-1].ID >= lang.ID {
+			t.Errorf("expected the list sorted by ID, got %q before %q", list[i-1].ID, lang.ID)
+		}
+		known[lang.ID] = true
+	}
+	for _, lang := range validation.GetAllowedLanguages() {
+		if !known[lang] {
+			t.Errorf("language %q has no extension", lang)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"main.go":            "go",
+		"App.TSX":            "typescript",
+		"script.sh":          "bash",
+		"config.yml":         "yaml",
+		"Dockerfile":         "dockerfile",
+		"Dockerfile.dev":     "dockerfile",
+		"Makefile":           "makefile",
+		"nginx.conf":         "nginx",
+		"app.conf":           "plaintext",
+		`C:\src\header.h`:    "c",
+		"notes":              "plaintext",
+		"archive.tar.gz":     "plaintext",
+		"README.md":          "markdown",
+		"schema.graphql":     "graphql",
+		"infra/main.tf":      "terraform",
+		"requirements.txt":   "plaintext",
+		"module.psm1":        "powershell",
+		"component.test.jsx": "javascript",
+	}
+	for name, want := range tests {
+		if got := Detect(name); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
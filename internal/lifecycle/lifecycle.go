@@ -0,0 +1,142 @@
+// Package lifecycle owns background workers so they can be cancelled and
+// drained on shutdown before shared resources (such as the database) are closed.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShuttingDown is returned when a worker is started after shutdown began
+var ErrShuttingDown = errors.New("lifecycle manager is shutting down")
+
+// Manager tracks background workers and open HTTP connections
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+	logger *slog.Logger
+
+	isWriter func() bool
+
+	activeConns atomic.Int64
+}
+
+// New creates a new lifecycle manager
+func New(logger *slog.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+	}
+}
+
+// WithWriterCheck makes periodic workers skip their runs while isWriter
+// returns false, as on a read-only replica. Their work writes to the
+// database, which only the primary may do.
+func (m *Manager) WithWriterCheck(isWriter func() bool) *Manager {
+	m.isWriter = isWriter
+	return m
+}
+
+// IsWriter reports whether this instance may write to the database
+func (m *Manager) IsWriter() bool {
+	return m.isWriter == nil || m.isWriter()
+}
+
+// Context returns the manager's root context, cancelled when shutdown begins
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in a tracked goroutine. The context passed to fn is cancelled
+// when shutdown begins. Returns ErrShuttingDown if shutdown has already started.
+func (m *Manager) Go(name string, fn func(ctx context.Context) error) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrShuttingDown
+	}
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		if err := fn(m.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			m.logger.Warn("background worker failed", "worker", name, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Every runs fn on a fixed interval until shutdown begins. Runs are skipped
+// while the instance is not a writer.
+func (m *Manager) Every(name string, interval time.Duration, fn func(ctx context.Context) error) error {
+	return m.Go(name, func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if !m.IsWriter() {
+					continue
+				}
+				if err := fn(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					m.logger.Warn("periodic worker failed", "worker", name, "error", err)
+				}
+			}
+		}
+	})
+}
+
+// TrackConn is an http.Server ConnState hook that counts open connections
+func (m *Manager) TrackConn(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		m.activeConns.Add(1)
+	case http.StateHijacked, http.StateClosed:
+		m.activeConns.Add(-1)
+	}
+}
+
+// ActiveConnections returns the number of currently open HTTP connections
+func (m *Manager) ActiveConnections() int64 {
+	return m.activeConns.Load()
+}
+
+// Shutdown cancels all workers and waits for them to finish or for ctx to expire
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.logger.Info("background workers stopped")
+		return nil
+	case <-ctx.Done():
+		m.logger.Error("timed out waiting for background workers", "error", ctx.Err())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestManager() *Manager {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestManager_ShutdownWaitsForWorkers(t *testing.T) {
+	m := newTestManager()
+
+	var finished atomic.Bool
+	started := make(chan struct{})
+
+	if err := m.Go("slow", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		// Simulate finishing an in-flight write after cancellation
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	}); err != nil {
+		t.Fatalf("Go() error = %v", err)
+	}
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Shutdown() returned before worker finished")
+	}
+}
+
+func TestManager_GoAfterShutdown(t *testing.T) {
+	m := newTestManager()
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	err := m.Go("late", func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Go() after shutdown error = %v, want ErrShuttingDown", err)
+	}
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	m := newTestManager()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	_ = m.Go("stuck", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestManager_Every(t *testing.T) {
+	m := newTestManager()
+
+	var runs atomic.Int32
+	_ = m.Every("tick", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	time.Sleep(30 * time.Millisecond)
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if runs.Load() == 0 {
+		t.Error("Every() never ran")
+	}
+}
+
+func TestManager_EverySkipsNonWriter(t *testing.T) {
+	var writer atomic.Bool
+	m := newTestManager().WithWriterCheck(writer.Load)
+
+	var runs atomic.Int32
+	_ = m.Every("tick", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Errorf("Every() ran %d times on a replica", runs.Load())
+	}
+
+	writer.Store(true)
+	time.Sleep(30 * time.Millisecond)
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if runs.Load() == 0 {
+		t.Error("Every() never ran after promotion")
+	}
+}
+
+func TestManager_TrackConn(t *testing.T) {
+	m := newTestManager()
+
+	m.TrackConn(nil, http.StateNew)
+	m.TrackConn(nil, http.StateNew)
+	m.TrackConn(nil, http.StateActive)
+	m.TrackConn(nil, http.StateClosed)
+
+	if got := m.ActiveConnections(); got != 1 {
+		t.Errorf("ActiveConnections() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,186 @@
+// Package logging sets up the log outputs: stdout, a rotating file and
+// syslog, in any combination.
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+// NewHandler returns a handler writing to the outputs cfg turns on, and a
+// function closing them. Every output gets the same format and level.
+func NewHandler(cfg config.LoggingConfig) (slog.Handler, func() error, error) {
+	if cfg.FileMaxSize < 0 || cfg.FileMaxAge < 0 || cfg.FileMaxBackups < 0 {
+		return nil, nil, errors.New("SNIPO_LOG_FILE_MAX_SIZE, SNIPO_LOG_FILE_MAX_AGE and SNIPO_LOG_FILE_MAX_BACKUPS must not be negative")
+	}
+	if !cfg.Stdout && cfg.File == "" && cfg.Syslog == "" {
+		return nil, nil, errors.New("SNIPO_LOG_STDOUT is off without SNIPO_LOG_FILE or SNIPO_LOG_SYSLOG, so logs would go nowhere")
+	}
+
+	opts := &slog.HandlerOptions{Level: parseLevel(cfg.Level)}
+	format := func(w io.Writer) slog.Handler {
+		if cfg.Format == "text" {
+			return slog.NewTextHandler(w, opts)
+		}
+		return slog.NewJSONHandler(w, opts)
+	}
+
+	var writers []io.Writer
+	var closers []io.Closer
+	closeAll := func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c.Close())
+		}
+		return errors.Join(errs...)
+	}
+
+	if cfg.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+	if cfg.File != "" {
+		file, err := OpenRotatingFile(cfg.File, int64(cfg.FileMaxSize)<<20, cfg.FileMaxAge, cfg.FileMaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		writers = append(writers, file)
+		closers = append(closers, file)
+	}
+
+	var handlers fanout
+	if len(writers) > 0 {
+		handlers = append(handlers, format(io.MultiWriter(writers...)))
+	}
+	if cfg.Syslog != "" {
+		w, err := dialSyslog(cfg.Syslog)
+		if err != nil {
+			_ = closeAll()
+			return nil, nil, err
+		}
+		out := &syslogOutput{w: w}
+		handlers = append(handlers, &syslogHandler{Handler: format(out), out: out})
+		closers = append(closers, w)
+	}
+
+	if len(handlers) == 1 {
+		return handlers[0], closeAll, nil
+	}
+	return handlers, closeAll, nil
+}
+
+// parseLevel maps SNIPO_LOG_LEVEL to a level, info by default
+func parseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// fanout passes records on to several handlers
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := make(fanout, len(f))
+	for i, h := range f {
+		derived[i] = h.WithAttrs(attrs)
+	}
+	return derived
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	derived := make(fanout, len(f))
+	for i, h := range f {
+		derived[i] = h.WithGroup(name)
+	}
+	return derived
+}
+
+// syslogWriter sends messages at syslog severities, as *syslog.Writer does
+type syslogWriter interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+	Close() error
+}
+
+// syslogHandler formats records with the configured format and sends each
+// at the syslog severity matching its level
+type syslogHandler struct {
+	slog.Handler // Formats into out
+	out          *syslogOutput
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+	h.out.level = record.Level
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{Handler: h.Handler.WithAttrs(attrs), out: h.out}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{Handler: h.Handler.WithGroup(name), out: h.out}
+}
+
+// syslogOutput receives formatted records, one per write, while its lock
+// is held by syslogHandler.Handle with level set to the record's
+type syslogOutput struct {
+	mu    sync.Mutex
+	w     syslogWriter
+	level slog.Level
+}
+
+func (o *syslogOutput) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	var err error
+	switch {
+	case o.level >= slog.LevelError:
+		err = o.w.Err(msg)
+	case o.level >= slog.LevelWarn:
+		err = o.w.Warning(msg)
+	case o.level >= slog.LevelInfo:
+		err = o.w.Info(msg)
+	default:
+		err = o.w.Debug(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+func TestRotatingFile_Size(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "snipo.log")
+	f, err := OpenRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f.now = func() time.Time { return clock }
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		clock = clock.Add(time.Second)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil || string(current) != "fourth\n" {
+		t.Errorf("expected the current file to hold the last line, got %q (%v)", current, err)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups kept, got %v", backups)
+	}
+	if oldest, _ := os.ReadFile(backups[0]); string(oldest) != "second\n" {
+		t.Errorf("expected the oldest backup pruned, got %q in %s", oldest, backups[0])
+	}
+}
+
+func TestRotatingFile_Age(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snipo.log")
+	f, err := OpenRotatingFile(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	clock := time.Now()
+	f.now = func() time.Time { return clock }
+	f.opened = clock
+	_, _ = f.Write([]byte("old\n"))
+	clock = clock.Add(30 * time.Minute)
+	_, _ = f.Write([]byte("still current\n"))
+	clock = clock.Add(time.Hour)
+	_, _ = f.Write([]byte("new\n"))
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "new\n" {
+		t.Errorf("expected a new file after an hour, got %q", current)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v", backups)
+	}
+	if old, _ := os.ReadFile(backups[0]); string(old) != "old\nstill current\n" {
+		t.Errorf("unexpected backup content %q", old)
+	}
+}
+
+// fakeSyslog records messages by severity
+type fakeSyslog struct {
+	messages []string
+}
+
+func (s *fakeSyslog) Debug(m string) error   { return s.add("debug", m) }
+func (s *fakeSyslog) Info(m string) error    { return s.add("info", m) }
+func (s *fakeSyslog) Warning(m string) error { return s.add("warning", m) }
+func (s *fakeSyslog) Err(m string) error     { return s.add("err", m) }
+func (s *fakeSyslog) Close() error           { return nil }
+
+func (s *fakeSyslog) add(severity, m string) error {
+	s.messages = append(s.messages, severity+" "+m)
+	return nil
+}
+
+func TestNewHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snipo.log")
+	handler, closeAll, err := NewHandler(config.LoggingConfig{Level: "info", Format: "text", File: path, FileMaxSize: 1})
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	sink := &fakeSyslog{}
+	out := &syslogOutput{w: sink}
+	logger := slog.New(fanout{handler, &syslogHandler{Handler: slog.NewTextHandler(out, nil), out: out}}).With("component", "test")
+
+	logger.Info("started")
+	logger.WarnContext(context.Background(), "slow")
+	logger.Debug("hidden")
+	if err := closeAll(); err != nil {
+		t.Fatalf("failed to close outputs: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "msg=started component=test") || !strings.Contains(string(content), "msg=slow") || strings.Contains(string(content), "hidden") {
+		t.Errorf("unexpected log file content:\n%s", content)
+	}
+	if len(sink.messages) != 2 || !strings.HasPrefix(sink.messages[0], "info ") || !strings.HasPrefix(sink.messages[1], "warning ") || !strings.Contains(sink.messages[1], "component=test") {
+		t.Errorf("expected syslog messages at matching severities, got %q", sink.messages)
+	}
+
+	if _, _, err := NewHandler(config.LoggingConfig{}); err == nil {
+		t.Error("expected an error with every output off")
+	}
+	if _, _, err := NewHandler(config.LoggingConfig{Stdout: true, Syslog: "http://example.com"}); err == nil {
+		t.Error("expected an error for an unsupported syslog address")
+	}
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files: snipo.log becomes
+// snipo.log.20260102-150405
+const backupTimeFormat = "20060102-150405"
+
+// RotatingFile is a log file that is moved aside and replaced by an empty
+// one when it grows past a size or gets older than an age. Rotated files
+// beyond the newest maxBackups are deleted.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	now        func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory
+// as needed. maxSize is in bytes; zero limits turn that kind of rotation or
+// pruning off.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the file at f.path. A file left by an earlier run counts as
+// opened when it was last written, as its creation time is not portable.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	if f.size > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past its
+// size or the file is too old. A record larger than the size limit still
+// goes into a file of its own.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether the file must be rotated before writing n bytes
+func (f *RotatingFile) due(n int64) bool {
+	if f.maxSize > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	return f.maxAge > 0 && f.now().Sub(f.opened) >= f.maxAge
+}
+
+// rotate moves the file aside, opens a new one and prunes old backups
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	backup := f.path + "." + f.now().Format(backupTimeFormat)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.%s-%d", f.path, f.now().Format(backupTimeFormat), i)
+	}
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.opened = f.now()
+
+	if f.maxBackups > 0 {
+		f.prune()
+	}
+	return nil
+}
+
+// prune deletes all but the newest maxBackups rotated files. Their names
+// sort by rotation time.
+func (f *RotatingFile) prune() {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+	prefix := filepath.Base(f.path) + "."
+	backups := slices.DeleteFunc(matches, func(m string) bool {
+		stamp := strings.TrimPrefix(filepath.Base(m), prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			return true
+		}
+		_, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+		return err != nil
+	})
+	slices.Sort(backups)
+	for len(backups) > f.maxBackups {
+		_ = os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// Close closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
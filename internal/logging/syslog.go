@@ -0,0 +1,29 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+)
+
+// dialSyslog connects to the local syslog daemon (which journald also
+// reads) for "local", or to a remote one for udp://host:port or
+// tcp://host:port
+func dialSyslog(target string) (syslogWriter, error) {
+	network, addr := "", ""
+	if target != "local" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("SNIPO_LOG_SYSLOG must be local, udp://host:port or tcp://host:port, got %q", target)
+		}
+		network, addr = u.Scheme, u.Host
+	}
+
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, "snipo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return w, nil
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logging
+
+import "errors"
+
+// dialSyslog fails, as there is no syslog on this platform
+func dialSyslog(string) (syslogWriter, error) {
+	return nil, errors.New("SNIPO_LOG_SYSLOG is not supported on this platform")
+}
@@ -0,0 +1,832 @@
+// Package lua runs small scripts written in a subset of Lua, for user
+// automation where scripts must not be able to harm the server. Scripts
+// can only reach the functions they are given: there is no io, os, load
+// or require, and every run has a step budget and a call depth limit.
+//
+// The language is Lua 5.1 without varargs, goto, metatables and
+// coroutines; numbers are floats. Library functions count the work they do
+// against the step budget, and the strings a run builds count against a
+// memory budget. The string functions find, match,
+// gmatch and gsub take RE2 regular expressions instead of Lua patterns.
+package lua
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Limits that end a run. They cannot be caught by pcall.
+var (
+	ErrStepLimit   = errors.New("script exceeded its step limit")
+	ErrDepthLimit  = errors.New("script exceeded the call depth limit")
+	ErrTooLarge    = errors.New("script built a string that is too large")
+	ErrMemoryLimit = errors.New("script exceeded its memory limit")
+)
+
+// Error is a syntax or runtime error in a script
+type Error struct {
+	Line    int
+	Message string
+	Value   Value // The value passed to error(), if any
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+// Chunk is a parsed script, which can be run many times
+type Chunk struct {
+	body []stmt
+}
+
+// Compile parses a script, reporting syntax errors
+func Compile(source string) (*Chunk, error) {
+	body, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Chunk{body: body}, nil
+}
+
+// State is a sandboxed interpreter with its own globals. It is not safe
+// for concurrent use.
+type State struct {
+	globals   *Table
+	maxSteps  int
+	maxDepth  int
+	maxString int
+	maxMemory int // Bytes of strings a run may build in total
+
+	ctx    context.Context
+	steps  int
+	depth  int
+	line   int
+	memory int
+}
+
+// NewState creates an interpreter with the safe standard library: the
+// base functions, string, table and math
+func NewState() *State {
+	s := &State{
+		globals:   NewTable(),
+		maxSteps:  100000,
+		maxDepth:  100,
+		maxString: 1 << 20,
+		maxMemory: 16 << 20,
+	}
+	s.openLibs()
+	return s
+}
+
+// WithStepLimit sets how many statements and calls a run may execute
+func (s *State) WithStepLimit(steps int) *State {
+	s.maxSteps = steps
+	return s
+}
+
+// SetGlobal sets a global variable
+func (s *State) SetGlobal(name string, v Value) {
+	_ = s.globals.Set(name, v)
+}
+
+// Global returns a global variable
+func (s *State) Global(name string) Value {
+	return s.globals.Get(name)
+}
+
+// Register sets a global Go function
+func (s *State) Register(name string, fn func(args []Value) ([]Value, error)) {
+	s.SetGlobal(name, &GoFunction{Name: name, Fn: fn})
+}
+
+// Run executes a chunk and returns the values of its return statement.
+// The step budget applies to each run; ctx cancels a run early.
+func (s *State) Run(ctx context.Context, chunk *Chunk) ([]Value, error) {
+	s.ctx = ctx
+	s.steps = 0
+	s.depth = 0
+	s.line = 0
+	s.memory = 0
+	_, results, err := s.execBlock(chunk.body, &scope{})
+	return results, err
+}
+
+// DoString compiles and runs a script
+func (s *State) DoString(ctx context.Context, source string) ([]Value, error) {
+	chunk, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return s.Run(ctx, chunk)
+}
+
+// Call calls a Lua value with arguments
+func (s *State) Call(fn Value, args ...Value) ([]Value, error) {
+	return s.call(fn, args, "")
+}
+
+func (s *State) errorf(format string, args ...any) error {
+	return &Error{Line: s.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// step counts a unit of work against the budget
+func (s *State) step() error {
+	return s.charge(1)
+}
+
+// charge counts n units of work against the budget, for library functions
+// that loop over tables and strings
+func (s *State) charge(n int) error {
+	before := s.steps
+	s.steps += max(n, 0)
+	if s.steps > s.maxSteps {
+		return ErrStepLimit
+	}
+	if s.steps/1000 != before/1000 && s.ctx != nil {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alloc counts a string of n bytes built by the script against the memory
+// budget. Strings are not freed during a run, so a script cannot keep
+// building large strings until the server runs out of memory.
+func (s *State) alloc(n int) error {
+	s.memory += n
+	if s.memory > s.maxMemory {
+		return ErrMemoryLimit
+	}
+	return nil
+}
+
+// scope holds the local variables of a block
+type scope struct {
+	vars   map[string]*Value
+	parent *scope
+}
+
+func (sc *scope) declare(name string, v Value) {
+	if sc.vars == nil {
+		sc.vars = make(map[string]*Value)
+	}
+	sc.vars[name] = &v
+}
+
+func (sc *scope) lookup(name string) *Value {
+	for c := sc; c != nil; c = c.parent {
+		if v, ok := c.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// flow is how a block was left
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowBreak
+	flowReturn
+)
+
+func (s *State) execBlock(body []stmt, sc *scope) (flow, []Value, error) {
+	for _, st := range body {
+		if err := s.step(); err != nil {
+			return flowNormal, nil, err
+		}
+		f, results, err := s.exec(st, sc)
+		if err != nil || f != flowNormal {
+			return f, results, err
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+func (s *State) exec(st stmt, sc *scope) (flow, []Value, error) {
+	switch st := st.(type) {
+	case lineStmt:
+		s.line = st.line
+		return s.exec(st.stmt, sc)
+
+	case localStmt:
+		values, err := s.evalList(st.exprs, sc)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		for i, name := range st.names {
+			sc.declare(name, valueAt(values, i))
+		}
+
+	case localFuncStmt:
+		sc.declare(st.name, nil)
+		*sc.lookup(st.name) = &Function{fn: st.fn, scope: sc}
+
+	case assignStmt:
+		return flowNormal, nil, s.assign(st, sc)
+
+	case callStmt:
+		_, err := s.evalMulti(st.call, sc)
+		return flowNormal, nil, err
+
+	case doStmt:
+		return s.execBlock(st.body, &scope{parent: sc})
+
+	case whileStmt:
+		for {
+			if err := s.step(); err != nil {
+				return flowNormal, nil, err
+			}
+			cond, err := s.eval(st.cond, sc)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			if !Truthy(cond) {
+				return flowNormal, nil, nil
+			}
+			f, results, err := s.execBlock(st.body, &scope{parent: sc})
+			if err != nil || f == flowReturn {
+				return f, results, err
+			}
+			if f == flowBreak {
+				return flowNormal, nil, nil
+			}
+		}
+
+	case repeatStmt:
+		for {
+			if err := s.step(); err != nil {
+				return flowNormal, nil, err
+			}
+			inner := &scope{parent: sc}
+			f, results, err := s.execBlock(st.body, inner)
+			if err != nil || f == flowReturn {
+				return f, results, err
+			}
+			if f == flowBreak {
+				return flowNormal, nil, nil
+			}
+			// The condition sees the body's locals
+			cond, err := s.eval(st.cond, inner)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			if Truthy(cond) {
+				return flowNormal, nil, nil
+			}
+		}
+
+	case ifStmt:
+		for i, c := range st.conds {
+			cond, err := s.eval(c, sc)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			if Truthy(cond) {
+				return s.execBlock(st.blocks[i], &scope{parent: sc})
+			}
+		}
+		if st.orElse != nil {
+			return s.execBlock(st.orElse, &scope{parent: sc})
+		}
+
+	case numForStmt:
+		return s.numFor(st, sc)
+
+	case genForStmt:
+		return s.genFor(st, sc)
+
+	case returnStmt:
+		values, err := s.evalList(st.exprs, sc)
+		return flowReturn, values, err
+
+	case breakStmt:
+		return flowBreak, nil, nil
+
+	default:
+		return flowNormal, nil, s.errorf("unknown statement %T", st)
+	}
+	return flowNormal, nil, nil
+}
+
+func (s *State) assign(st assignStmt, sc *scope) error {
+	// Evaluate the targets' tables and keys before the values, as Lua does
+	type target struct {
+		name  string
+		table Value
+		key   Value
+	}
+	targets := make([]target, len(st.targets))
+	for i, t := range st.targets {
+		if l, ok := t.(lineExpr); ok {
+			s.line = l.line
+		}
+		switch t := unwrap(t).(type) {
+		case nameExpr:
+			targets[i] = target{name: t.name}
+		case indexExpr:
+			obj, err := s.eval(t.obj, sc)
+			if err != nil {
+				return err
+			}
+			key, err := s.eval(t.key, sc)
+			if err != nil {
+				return err
+			}
+			targets[i] = target{table: obj, key: key}
+		}
+	}
+
+	values, err := s.evalList(st.exprs, sc)
+	if err != nil {
+		return err
+	}
+	for i, t := range targets {
+		v := valueAt(values, i)
+		if t.name != "" {
+			if ref := sc.lookup(t.name); ref != nil {
+				*ref = v
+			} else if err := s.globals.Set(t.name, v); err != nil {
+				return s.errorf("%v", err)
+			}
+			continue
+		}
+		table, ok := t.table.(*Table)
+		if !ok {
+			return s.errorf("attempt to index a %s value%s", TypeName(t.table), describeExpr(st.targets[i]))
+		}
+		if err := table.Set(t.key, v); err != nil {
+			return s.errorf("%v", err)
+		}
+	}
+	return nil
+}
+
+func (s *State) numFor(st numForStmt, sc *scope) (flow, []Value, error) {
+	var bounds [3]float64
+	for i, e := range []expr{st.start, st.stop, st.step} {
+		if e == nil {
+			bounds[i] = 1
+			continue
+		}
+		v, err := s.eval(e, sc)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		n, ok := toNumber(v)
+		if !ok {
+			return flowNormal, nil, s.errorf("'for' %s value must be a number", [...]string{"initial", "limit", "step"}[i])
+		}
+		bounds[i] = n
+	}
+	start, stop, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return flowNormal, nil, s.errorf("'for' step is zero")
+	}
+
+	for i := start; (step > 0 && i <= stop) || (step < 0 && i >= stop); i += step {
+		if err := s.step(); err != nil {
+			return flowNormal, nil, err
+		}
+		inner := &scope{parent: sc}
+		inner.declare(st.name, i)
+		f, results, err := s.execBlock(st.body, inner)
+		if err != nil || f == flowReturn {
+			return f, results, err
+		}
+		if f == flowBreak {
+			break
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+func (s *State) genFor(st genForStmt, sc *scope) (flow, []Value, error) {
+	values, err := s.evalList(st.exprs, sc)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	iter, state, control := valueAt(values, 0), valueAt(values, 1), valueAt(values, 2)
+
+	for {
+		if err := s.step(); err != nil {
+			return flowNormal, nil, err
+		}
+		results, err := s.call(iter, []Value{state, control}, "for iterator")
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if valueAt(results, 0) == nil {
+			return flowNormal, nil, nil
+		}
+		control = results[0]
+
+		inner := &scope{parent: sc}
+		for i, name := range st.names {
+			inner.declare(name, valueAt(results, i))
+		}
+		f, res, err := s.execBlock(st.body, inner)
+		if err != nil || f == flowReturn {
+			return f, res, err
+		}
+		if f == flowBreak {
+			return flowNormal, nil, nil
+		}
+	}
+}
+
+// evalList evaluates expressions, expanding the results of a final call
+func (s *State) evalList(exprs []expr, sc *scope) ([]Value, error) {
+	var values []Value
+	for i, e := range exprs {
+		if i == len(exprs)-1 {
+			vs, err := s.evalMulti(e, sc)
+			if err != nil {
+				return nil, err
+			}
+			return append(values, vs...), nil
+		}
+		v, err := s.eval(e, sc)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// evalMulti evaluates an expression to all of its values: every result
+// of a call, or a single value otherwise
+func (s *State) evalMulti(e expr, sc *scope) ([]Value, error) {
+	inner := e
+	if l, ok := e.(lineExpr); ok {
+		s.line = l.line
+		inner = l.expr
+	}
+
+	switch c := inner.(type) {
+	case callExpr:
+		fn, err := s.eval(c.fn, sc)
+		if err != nil {
+			return nil, err
+		}
+		args, err := s.evalList(c.args, sc)
+		if err != nil {
+			return nil, err
+		}
+		return s.callAt(fn, args, describeExpr(c.fn), s.line)
+	case methodExpr:
+		obj, err := s.eval(c.obj, sc)
+		if err != nil {
+			return nil, err
+		}
+		line := s.line
+		fn, err := s.index(obj, c.name, c.obj)
+		if err != nil {
+			return nil, err
+		}
+		args, err := s.evalList(c.args, sc)
+		if err != nil {
+			return nil, err
+		}
+		return s.callAt(fn, append([]Value{obj}, args...), " (method '"+c.name+"')", line)
+	}
+
+	v, err := s.eval(e, sc)
+	if err != nil {
+		return nil, err
+	}
+	return []Value{v}, nil
+}
+
+// callAt calls fn and restores the current line afterwards, so errors
+// after the call point at the caller
+func (s *State) callAt(fn Value, args []Value, what string, line int) ([]Value, error) {
+	results, err := s.call(fn, args, what)
+	s.line = line
+	return results, err
+}
+
+func (s *State) call(fn Value, args []Value, what string) ([]Value, error) {
+	if err := s.step(); err != nil {
+		return nil, err
+	}
+	switch f := fn.(type) {
+	case *GoFunction:
+		results, err := f.Fn(args)
+		if err != nil {
+			var luaErr *Error
+			if errors.As(err, &luaErr) || errors.Is(err, ErrStepLimit) || errors.Is(err, ErrDepthLimit) || errors.Is(err, ErrTooLarge) || errors.Is(err, ErrMemoryLimit) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			return nil, s.errorf("%v", err)
+		}
+		return results, nil
+
+	case *Function:
+		if s.depth >= s.maxDepth {
+			return nil, ErrDepthLimit
+		}
+		s.depth++
+		defer func() { s.depth-- }()
+
+		sc := &scope{parent: f.scope}
+		for i, name := range f.fn.params {
+			sc.declare(name, valueAt(args, i))
+		}
+		_, results, err := s.execBlock(f.fn.body, sc)
+		return results, err
+	}
+	return nil, s.errorf("attempt to call a %s value%s", TypeName(fn), what)
+}
+
+func (s *State) eval(e expr, sc *scope) (Value, error) {
+	switch e := e.(type) {
+	case lineExpr:
+		s.line = e.line
+		switch e.expr.(type) {
+		case callExpr, methodExpr:
+			vs, err := s.evalMulti(e, sc)
+			return valueAt(vs, 0), err
+		}
+		return s.eval(e.expr, sc)
+
+	case constExpr:
+		return e.v, nil
+
+	case nameExpr:
+		if ref := sc.lookup(e.name); ref != nil {
+			return *ref, nil
+		}
+		return s.globals.Get(e.name), nil
+
+	case indexExpr:
+		obj, err := s.eval(e.obj, sc)
+		if err != nil {
+			return nil, err
+		}
+		key, err := s.eval(e.key, sc)
+		if err != nil {
+			return nil, err
+		}
+		return s.index(obj, key, e.obj)
+
+	case callExpr, methodExpr:
+		vs, err := s.evalMulti(e, sc)
+		return valueAt(vs, 0), err
+
+	case parenExpr:
+		return s.eval(e.e, sc)
+
+	case *funcExpr:
+		return &Function{fn: e, scope: sc}, nil
+
+	case tableExpr:
+		t := NewTable()
+		for i, item := range e.items {
+			if i == len(e.items)-1 {
+				vs, err := s.evalMulti(item, sc)
+				if err != nil {
+					return nil, err
+				}
+				for k, v := range vs {
+					if err := t.Set(float64(i+k+1), v); err != nil {
+						return nil, s.errorf("%v", err)
+					}
+				}
+				break
+			}
+			v, err := s.eval(item, sc)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.Set(float64(i+1), v); err != nil {
+				return nil, s.errorf("%v", err)
+			}
+		}
+		for i, k := range e.keys {
+			key, err := s.eval(k, sc)
+			if err != nil {
+				return nil, err
+			}
+			v, err := s.eval(e.vals[i], sc)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.Set(key, v); err != nil {
+				return nil, s.errorf("%v", err)
+			}
+		}
+		return t, nil
+
+	case unExpr:
+		v, err := s.eval(e.e, sc)
+		if err != nil {
+			return nil, err
+		}
+		return s.unary(e.op, v, e.e)
+
+	case binExpr:
+		return s.binary(e, sc)
+	}
+	return nil, s.errorf("unknown expression %T", e)
+}
+
+// index returns obj[key]; strings index the string library
+func (s *State) index(obj, key Value, objExpr expr) (Value, error) {
+	switch o := obj.(type) {
+	case *Table:
+		return o.Get(key), nil
+	case string:
+		if lib, ok := s.globals.Get("string").(*Table); ok {
+			return lib.Get(key), nil
+		}
+		return nil, nil
+	}
+	return nil, s.errorf("attempt to index a %s value%s", TypeName(obj), describeExpr(objExpr))
+}
+
+func (s *State) unary(op string, v Value, operand expr) (Value, error) {
+	switch op {
+	case "not":
+		return !Truthy(v), nil
+	case "-":
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, s.errorf("attempt to perform arithmetic on a %s value%s", TypeName(v), describeExpr(operand))
+		}
+		return -n, nil
+	case "#":
+		switch v := v.(type) {
+		case string:
+			return float64(len(v)), nil
+		case *Table:
+			return float64(v.Len()), nil
+		}
+		return nil, s.errorf("attempt to get length of a %s value%s", TypeName(v), describeExpr(operand))
+	}
+	return nil, s.errorf("unknown operator %s", op)
+}
+
+func (s *State) binary(e binExpr, sc *scope) (Value, error) {
+	l, err := s.eval(e.l, sc)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "and":
+		if !Truthy(l) {
+			return l, nil
+		}
+		return s.eval(e.r, sc)
+	case "or":
+		if Truthy(l) {
+			return l, nil
+		}
+		return s.eval(e.r, sc)
+	}
+
+	r, err := s.eval(e.r, sc)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==":
+		return l == r, nil
+	case "~=":
+		return l != r, nil
+	case "<", "<=", ">", ">=":
+		return s.compare(e.op, l, r)
+	case "..":
+		ls, lok := concatString(l)
+		rs, rok := concatString(r)
+		if !lok || !rok {
+			bad, badExpr := l, e.l
+			if lok {
+				bad, badExpr = r, e.r
+			}
+			return nil, s.errorf("attempt to concatenate a %s value%s", TypeName(bad), describeExpr(badExpr))
+		}
+		if len(ls)+len(rs) > s.maxString {
+			return nil, ErrTooLarge
+		}
+		if err := s.alloc(len(ls) + len(rs)); err != nil {
+			return nil, err
+		}
+		return ls + rs, nil
+	}
+
+	a, aok := toNumber(l)
+	b, bok := toNumber(r)
+	if !aok || !bok {
+		bad, badExpr := l, e.l
+		if aok {
+			bad, badExpr = r, e.r
+		}
+		return nil, s.errorf("attempt to perform arithmetic on a %s value%s", TypeName(bad), describeExpr(badExpr))
+	}
+	switch e.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	case "//":
+		return math.Floor(a / b), nil
+	case "%":
+		return a - math.Floor(a/b)*b, nil
+	case "^":
+		return math.Pow(a, b), nil
+	}
+	return nil, s.errorf("unknown operator %s", e.op)
+}
+
+func (s *State) compare(op string, l, r Value) (Value, error) {
+	var less, equal bool
+	switch a := l.(type) {
+	case float64:
+		b, ok := r.(float64)
+		if !ok {
+			return nil, s.errorf("attempt to compare number with %s", TypeName(r))
+		}
+		less, equal = a < b, a == b
+	case string:
+		b, ok := r.(string)
+		if !ok {
+			return nil, s.errorf("attempt to compare string with %s", TypeName(r))
+		}
+		less, equal = a < b, a == b
+	default:
+		return nil, s.errorf("attempt to compare two %s values", TypeName(l))
+	}
+	switch op {
+	case "<":
+		return less, nil
+	case "<=":
+		return less || equal, nil
+	case ">":
+		return !less && !equal, nil
+	default:
+		return !less, nil
+	}
+}
+
+func concatString(v Value) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return formatNumber(v), true
+	}
+	return "", false
+}
+
+// describeExpr names the variable an expression reads, for error messages
+func describeExpr(e expr) string {
+	switch e := unwrap(e).(type) {
+	case nameExpr:
+		return " (variable '" + e.name + "')"
+	case indexExpr:
+		if k, ok := unwrap(e.key).(constExpr); ok {
+			if name, ok := k.v.(string); ok {
+				return " (field '" + name + "')"
+			}
+		}
+	}
+	return ""
+}
+
+// valueAt returns values[i], or nil past the end
+func valueAt(values []Value, i int) Value {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
+
+// argError describes a bad argument to a library function
+func argError(n int, fn, format string, args ...any) error {
+	return fmt.Errorf("bad argument #%d to '%s' (%s)", n, fn, fmt.Sprintf(format, args...))
+}
+
+// Strings and numbers in error messages from error() are used as they are
+func errorMessage(v Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if v == nil {
+		return "nil"
+	}
+	return strings.TrimSpace(ToString(v))
+}
@@ -0,0 +1,278 @@
+package lua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind is the kind of a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokNumber
+	tokString
+	tokKeyword
+	tokOp
+)
+
+// token is a lexical token. Keywords and operators keep their text in s.
+type token struct {
+	kind tokenKind
+	s    string
+	n    float64
+	line int
+}
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// Operators, longest first so that the first match wins
+var operators = []string{
+	"...", "..", "==", "~=", "<=", ">=", "//", "::",
+	"+", "-", "*", "/", "%", "^", "#", "<", ">", "=",
+	"(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+// lexer splits a chunk into tokens
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return &Error{Line: l.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// tokens returns all tokens of the source, ending with tokEOF
+func (l *lexer) tokens() ([]token, error) {
+	var toks []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+		if tok.kind == tokEOF {
+			return toks, nil
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		word := l.src[start:l.pos]
+		if keywords[word] {
+			return token{kind: tokKeyword, s: word, line: l.line}, nil
+		}
+		return token{kind: tokName, s: word, line: l.line}, nil
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		return l.number()
+	case c == '"' || c == '\'':
+		return l.quoted(c)
+	case c == '[' && l.longBracket() >= 0:
+		s, err := l.long()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokString, s: s, line: l.line}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, s: op, line: l.line}, nil
+		}
+	}
+	return token{}, l.errorf("unexpected symbol %q", c)
+}
+
+// skipSpace skips whitespace and comments
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			if l.pos < len(l.src) && l.src[l.pos] == '[' && l.longBracket() >= 0 {
+				if _, err := l.long(); err != nil {
+					return err
+				}
+				continue
+			}
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// longBracket returns the level of the long bracket at the current
+// position ("[[" is 0, "[==[" is 2), or -1 if there is none
+func (l *lexer) longBracket() int {
+	i := l.pos + 1
+	for i < len(l.src) && l.src[i] == '=' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '[' {
+		return i - l.pos - 1
+	}
+	return -1
+}
+
+// long reads a long string or comment body, dropping a leading newline
+func (l *lexer) long() (string, error) {
+	level := l.longBracket()
+	l.pos += level + 2
+	if strings.HasPrefix(l.src[l.pos:], "\r\n") {
+		l.pos += 2
+		l.line++
+	} else if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+		l.pos++
+		l.line++
+	}
+
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(l.src[l.pos:], closing)
+	if end < 0 {
+		return "", l.errorf("unfinished long string")
+	}
+	s := l.src[l.pos : l.pos+end]
+	l.line += strings.Count(s, "\n")
+	l.pos += end + len(closing)
+	return s, nil
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X") {
+		l.pos += 2
+		for l.pos < len(l.src) && isHexDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		n, err := strconv.ParseUint(l.src[start+2:l.pos], 16, 64)
+		if err != nil {
+			return token{}, l.errorf("malformed number near %q", l.src[start:l.pos])
+		}
+		return token{kind: tokNumber, n: float64(n), line: l.line}, nil
+	}
+
+	for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.pos++
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	n, err := strconv.ParseFloat(l.src[start:l.pos], 64)
+	if err != nil || (l.pos < len(l.src) && isLetter(l.src[l.pos])) {
+		return token{}, l.errorf("malformed number near %q", l.src[start:l.pos])
+	}
+	return token{kind: tokNumber, n: n, line: l.line}, nil
+}
+
+func (l *lexer) quoted(quote byte) (token, error) {
+	l.pos++
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return token{}, l.errorf("unfinished string")
+		}
+		c := l.src[l.pos]
+		l.pos++
+		if c == quote {
+			return token{kind: tokString, s: b.String(), line: l.line}, nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if l.pos >= len(l.src) {
+			return token{}, l.errorf("unfinished string")
+		}
+		e := l.src[l.pos]
+		l.pos++
+		switch e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'':
+			b.WriteByte(e)
+		case '\n':
+			b.WriteByte('\n')
+			l.line++
+		case 'x':
+			if l.pos+2 > len(l.src) || !isHexDigit(l.src[l.pos]) || !isHexDigit(l.src[l.pos+1]) {
+				return token{}, l.errorf("hexadecimal digit expected")
+			}
+			n, _ := strconv.ParseUint(l.src[l.pos:l.pos+2], 16, 8)
+			b.WriteByte(byte(n))
+			l.pos += 2
+		default:
+			if !isDigit(e) {
+				return token{}, l.errorf("invalid escape sequence '\\%c'", e)
+			}
+			start := l.pos - 1
+			for l.pos < len(l.src) && l.pos-start < 3 && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+			n, _ := strconv.Atoi(l.src[start:l.pos])
+			if n > 255 {
+				return token{}, l.errorf("decimal escape too large")
+			}
+			b.WriteByte(byte(n))
+		}
+	}
+}
+
+func isLetter(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
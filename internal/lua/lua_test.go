@@ -0,0 +1,513 @@
+package lua
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"arithmetic", "return 1 + 2 * 3 - 4 / 2", "5"},
+		{"power right associative", "return 2 ^ 3 ^ 2", "512"},
+		{"modulo", "return -7 % 3", "2"},
+		{"floor division", "return 7 // 2", "3"},
+		{"concat", `return "a" .. 1 .. "b"`, "a1b"},
+		{"string coercion", `return "10" + 5`, "15"},
+		{"comparison", `return 1 < 2 and "a" < "b"`, "true"},
+		{"and or", `return nil or false or "x"`, "x"},
+		{"length", `return #"abc" + #{1, 2}`, "5"},
+		{"locals and scope", "local x = 1 do local x = 2 end return x", "1"},
+		{"multiple assignment", "local a, b = 1, 2 a, b = b, a return a .. b", "21"},
+		{"while", "local i, n = 0, 0 while i < 5 do i = i + 1 n = n + i end return n", "15"},
+		{"repeat", "local i = 0 repeat local j = i i = i + 1 until j >= 3 return i", "4"},
+		{"numeric for", "local s = 0 for i = 10, 1, -2 do s = s + i end return s", "30"},
+		{"break", "local n = 0 for i = 1, 10 do if i > 3 then break end n = i end return n", "3"},
+		{"if elseif", `local x = 2 if x == 1 then return "a" elseif x == 2 then return "b" else return "c" end`, "b"},
+		{"closures", "local function counter() local n = 0 return function() n = n + 1 return n end end local c = counter() c() return c()", "2"},
+		{"recursion", "local function fib(n) if n < 2 then return n end return fib(n-1) + fib(n-2) end return fib(15)", "610"},
+		{"multiple returns", "local function f() return 1, 2, 3 end local t = {f()} return #t", "3"},
+		{"truncated returns", "local function f() return 1, 2 end local t = {f(), f()} return #t", "3"},
+		{"tables", `local t = {1, 2, x = "y", ["z"] = 3} t[3] = 4 return t.x .. t.z .. #t`, "y33"},
+		{"table methods", "local o = {n = 1} function o:add(k) self.n = self.n + k return self end return o:add(2):add(3).n", "6"},
+		{"pairs order", `local t = {b = 1, a = 2, 10, 20} local s = "" for k, v in pairs(t) do s = s .. k .. "=" .. v .. " " end return s`, "1=10 2=20 a=2 b=1 "},
+		{"ipairs", `local s = 0 for i, v in ipairs({5, 6, 7}) do s = s + i * v end return s`, "38"},
+		{"string methods", `local s = "Hello" return s:upper() .. s:lower():len()`, "HELLO5"},
+		{"string sub", `return ("kubectl"):sub(1, 4) .. string.sub("abc", -2)`, "kubebc"},
+		{"string find plain", `return string.find("a.b", ".", 1, true)`, "2"},
+		{"string find regexp", `return ("apply kubectl now"):find("kube\\w+")`, "7"},
+		{"string match", `return ("v1.2.3"):match("v(\\d+)\\.(\\d+)")`, "1"},
+		{"gmatch", `local n = 0 for w in ("a b c"):gmatch("\\w") do n = n + 1 end return n`, "3"},
+		{"gsub", `return (("hello world"):gsub("(\\w+)", "<%1>"))`, "<hello> <world>"},
+		{"gsub function", `return (("a b"):gsub("\\w", function(c) return c:upper() end))`, "A B"},
+		{"format", `return string.format("%s=%d (%.1f) %5s%%", "x", 3.7, 2.25, "y")`, "x=3 (2.2)     y%"},
+		{"table functions", `local t = {3, 1, 2} table.sort(t) table.insert(t, 1, 0) table.remove(t) return table.concat(t, ",")`, "0,1,2"},
+		{"sort comparator", `local t = {1, 3, 2} table.sort(t, function(a, b) return a > b end) return table.concat(t)`, "321"},
+		{"math", "return math.max(1, 5, 3) + math.floor(2.7) + math.abs(-1)", "8"},
+		{"tostring tonumber", `return tostring(tonumber("12") + 1) .. tonumber("ff", 16)`, "13255"},
+		{"pcall", `local ok, err = pcall(error, "boom") return tostring(ok) .. err`, "falseboom"},
+		{"pcall success", `return select(2, pcall(function(a) return a * 2 end, 21))`, "42"},
+		{"long string", "return [[\nline]] .. [==[]]]==]", "line]]"},
+		{"comments", "-- comment\n--[[ long\ncomment ]] return 1", "1"},
+		{"escapes", `return "a\tb\65\x42"`, "a\tbAB"},
+		{"globals", "x = 5 local function f() return x end return f()", "5"},
+		{"unary minus binds looser than power", "return -2 ^ 2", "-4"},
+		{"negative exponent", "return 2 ^ -1", "0.5"},
+		{"not before comparison", "return not nil == true", "true"},
+		{"concat right associative", `return 1 .. 2 .. 3`, "123"},
+		{"comparison chain", `return 1 < 2 == true`, "true"},
+		{"number formats", "return 0x10 + 1e2 + .5 + 5.", "121.5"},
+		{"large numbers in exponent form", "return 2 ^ 53 .. ' ' .. 2 ^ 49", "9.007199254741e+15 562949953421312"},
+		{"division by zero", "return 1 / 0 .. -1 / 0", "inf-inf"},
+		{"float step", "local n = 0 for i = 1, 3, 0.5 do n = n + 1 end return n", "5"},
+		{"nan bound", "local n = 0 for i = 1, 0 / 0 do n = n + 1 end return n", "0"},
+		{"loop variable is a copy", "local n = 0 for i = 1, 3 do n = n + i i = 10 end return n", "6"},
+		{"repeat sees body locals", "local i = 0 repeat local done = i == 2 i = i + 1 until done return i", "3"},
+		{"closures capture per iteration", "local fs = {} for i = 1, 3 do fs[i] = function() return i end end return fs[1]() + fs[3]()", "4"},
+		{"upvalue shared", "local n = 0 local function inc() n = n + 1 end inc() inc() return n", "2"},
+		{"shadowed parameter", "local function f(a, a) return a end return f(1, 2)", "2"},
+		{"assignment evaluates values first", "local t = {1, 2} local i = 1 i, t[i] = 2, 5 return t[1] .. t[2]", "52"},
+		{"extra values dropped", "local a, b = 1 return tostring(b)", "nil"},
+		{"nested functions", "local a = {b = {}} function a.b.c(x) return x * 2 end return a.b.c(4)", "8"},
+		{"method definition", "local a = {b = {n = 3}} function a.b:get() return self.n end return a.b:get()", "3"},
+		{"call with string and table", `local function f(x) return type(x) end return f"s" .. f{}`, "stringtable"},
+		{"parenthesized call truncates", "local function f() return 1, 2 end return select('#', (f()))", "1"},
+		{"return ends block", "do return 1 end return 2", "1"},
+		{"semicolons", ";;local x = 1; return x;", "1"},
+		{"table trailing separator", "return #{1; 2, 3,}", "3"},
+		{"table keeps positions after nils", "local t = {nil, nil, 3} return t[3]", "3"},
+		{"table call positions after nils", "local function f() return 3, 4 end local t = {nil, f()} return t[2] .. t[3]", "34"},
+		{"table keys", `local t = {[1.0] = "a", [true] = "b", x = "c"} return t[1] .. t[true] .. t.x`, "abc"},
+		{"sequence grows from hash", "local t = {} t[2] = 2 t[1] = 1 return #t", "2"},
+		{"sequence shrinks", "local t = {1, 2, 3} t[3] = nil return #t", "2"},
+		{"fractional keys", "local t = {} t[1.5] = 1 t[1] = 2 return #t .. t[1.5]", "11"},
+		{"negative keys", "local t = {} t[-1] = 1 return #t .. t[-1]", "01"},
+		{"string indexing", `local s = "abc" return s.len == string.len`, "true"},
+		{"long string levels", "return [=[a]]b]=]", "a]]b"},
+		{"escaped newline", "return 'a\\\nb'", "a\nb"},
+		{"decimal escapes", `return "\104\105"`, "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewState().DoString(context.Background(), tt.src)
+			if err != nil {
+				t.Fatalf("DoString() error = %v", err)
+			}
+			if got := ToString(valueAt(results, 0)); got != tt.want {
+				t.Errorf("DoString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// runAll runs a script and joins all of its results with commas
+func runAll(t *testing.T, src string) string {
+	t.Helper()
+	results, err := NewState().DoString(context.Background(), src)
+	if err != nil {
+		t.Fatalf("DoString() error = %v", err)
+	}
+	out := make([]string, len(results))
+	for i, v := range results {
+		out[i] = ToString(v)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestStringLibrary(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"len counts bytes", `return ("héllo"):len()`, "6"},
+		{"sub negative", `return ("abcdef"):sub(-3, -2)`, "de"},
+		{"sub out of range", `return ("abc"):sub(5) .. "|" .. ("abc"):sub(0) .. "|" .. ("abc"):sub(-10, 10)`, "|abc|abc"},
+		{"sub empty range", `return ("abc"):sub(3, 2)`, ""},
+		{"sub huge indices", `return ("abc"):sub(math.huge) .. "|" .. ("abc"):sub(-math.huge, math.huge)`, "|abc"},
+		{"byte range", `return ("abc"):byte(1, -1)`, "97,98,99"},
+		{"byte out of range", `return select("#", ("abc"):byte(10))`, "0"},
+		{"char", `return string.char(104, 105) .. string.char()`, "hi"},
+		{"rep", `return ("ab"):rep(3) .. "|" .. ("ab"):rep(0) .. "|" .. ("ab"):rep(-1)`, "ababab||"},
+		{"rep fraction", `return ("ab"):rep(2.9)`, "abab"},
+		{"rep empty string forever", `return (""):rep(math.huge)`, ""},
+		{"rep nan", `return ("x"):rep(0 / 0)`, ""},
+		{"reverse", `return ("abc"):reverse()`, "cba"},
+		{"upper lower", `return ("MiXeD"):upper() .. ("MiXeD"):lower()`, "MIXEDmixed"},
+		{"numbers as strings", `return string.len(123) .. string.upper(1.5)`, "31.5"},
+		{"format integers", `return string.format("%d|%5d|%-3d|%03d|%i", 3.9, 42, 7, 5, -2)`, "3|   42|7  |005|-2"},
+		{"format floats", `return string.format("%.2f|%g|%e", 1 / 3, 0.1, 12345)`, "0.33|0.1|1.234500e+04"},
+		{"format hex and octal", `return string.format("%x|%X|%o|%c", 255, 255, 8, 65)`, "ff|FF|10|A"},
+		{"format strings", `return string.format("%s|%5.2s|%q", {} ~= nil, "abcdef", "a\nb")`, `true|   ab|"a\nb"`},
+		{"format percent", `return string.format("100%%")`, "100%"},
+		{"tostring", `return tostring(nil) .. tostring(true) .. tostring(1.5) .. tostring(10)`, "niltrue1.510"},
+		{"tonumber", `return tonumber("  12  "), tonumber("0x1F"), tonumber("1e2"), tonumber("z", 36), tonumber("1e"), tonumber("7", 99)`, "12,31,100,35,nil,nil"},
+		{"tonumber rejects words", `return tonumber("inf"), tonumber("nan"), tonumber(""), tonumber({})`, "nil,nil,nil,nil"},
+		{"type", `return type(nil), type(1), type(""), type({}), type(type), type(true)`, "nil,number,string,table,function,boolean"},
+		{"select count and negative", `return select("#", 1, nil, 3), select(2, "a", "b", "c")`, "3,b,c"},
+		{"select past the end", `return select("#", select(math.huge, 1, 2))`, "0"},
+		{"math", `return math.floor(-0.5), math.ceil(0.2), math.abs(-3), math.sqrt(16), math.min(3, "1", 2), math.max(-1, -5)`, "-1,1,3,4,1,-1"},
+		{"math constants", `return math.huge > 2 ^ 1000, math.floor(math.pi * 100)`, "true,314"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runAll(t, tt.src); got != tt.want {
+				t.Errorf("DoString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"find position", `return ("hello world"):find("wor")`, "7,9"},
+		{"find captures", `return ("key=value"):find("(\\w+)=(\\w+)")`, "1,9,key,value"},
+		{"find init", `return ("a.a.a"):find("a", 2)`, "3,3"},
+		{"find negative init", `return ("a.a.a"):find("a", -1)`, "5,5"},
+		{"find init past the end", `return ("abc"):find("", 10), ("abc"):find("", 4)`, "nil,4,3"},
+		{"find plain ignores syntax", `return ("1+1=2"):find("+1", 1, true)`, "2,3"},
+		{"find no match", `return ("abc"):find("x")`, "nil"},
+		{"match whole", `return ("v1.22"):match("\\d+\\.\\d+")`, "1.22"},
+		{"match captures", `return ("2024-05-01"):match("(\\d+)-(\\d+)-(\\d+)")`, "2024,05,01"},
+		{"match optional capture", `return ("ab"):match("(a)(x)?")`, "a,nil"},
+		{"match init", `return ("abcabc"):match("a.", 2)`, "ab"},
+		{"match anchored", `return ("xabc"):match("^abc")`, "nil"},
+		{"match case insensitive", `return ("KubeCTL"):match("(?i)kubectl")`, "KubeCTL"},
+		{"match multiline", `return ("a\nTODO: fix"):match("(?m)^TODO: (.*)$")`, "fix"},
+		{"gmatch words", `local out = {} for w in ("one two  three"):gmatch("\\S+") do out[#out + 1] = w end return table.concat(out, "|")`, "one|two|three"},
+		{"gmatch captures", `local out = {} for k, v in ("a=1, b=2"):gmatch("(\\w)=(\\d)") do out[#out + 1] = k .. v end return table.concat(out)`, "a1b2"},
+		{"gmatch no match", `local n = 0 for _ in ("abc"):gmatch("\\d") do n = n + 1 end return n`, "0"},
+		{"gsub count", `return ("a-b-c"):gsub("-", "+")`, "a+b+c,2"},
+		{"gsub limit", `return ("a-b-c"):gsub("-", "+", 1)`, "a+b-c,1"},
+		{"gsub captures", `return ("john smith"):gsub("(\\w+) (\\w+)", "%2, %1")`, "smith, john,1"},
+		{"gsub whole match and percent", `return ("5"):gsub("\\d", "%0%%")`, "5%,1"},
+		{"gsub dollars are literal", `return ("cost"):gsub("cost", "$1 ${x}")`, "$1 ${x},1"},
+		{"gsub empty matches", `return ("abc"):gsub("", "-")`, "-a-b-c-,4"},
+		{"gsub table", `return ("$a $b $c"):gsub("\\$(\\w)", {a = "1", b = 2})`, "1 2 $c,3"},
+		{"gsub function", `return ("a b"):gsub("\\w", function(c) if c == "a" then return "A" end end)`, "A b,2"},
+		{"gsub function captures", `return ("k=v"):gsub("(\\w)=(\\w)", function(k, v) return v .. "=" .. k end)`, "v=k,1"},
+		{"gsub number replacement", `return ("x"):gsub("x", 7)`, "7,1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runAll(t, tt.src); got != tt.want {
+				t.Errorf("DoString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableLibrary(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"insert append", `local t = {1} table.insert(t, 2) return table.concat(t, ",")`, "1,2"},
+		{"insert front", `local t = {2, 3} table.insert(t, 1, 1) return table.concat(t, ",")`, "1,2,3"},
+		{"insert end position", `local t = {1} table.insert(t, 2, 2) return table.concat(t, ",")`, "1,2"},
+		{"insert fraction", `local t = {1, 3} table.insert(t, 2.5, 2) return table.concat(t, ",")`, "1,2,3"},
+		{"remove last", `local t = {1, 2, 3} return table.remove(t), #t`, "3,2"},
+		{"remove first", `local t = {1, 2, 3} return table.remove(t, 1), table.concat(t, ",")`, "1,2,3"},
+		{"remove empty", `return table.remove({})`, "nil"},
+		{"concat range", `return table.concat({1, 2, 3, 4}, "-", 2, 3)`, "2-3"},
+		{"concat empty range", `return table.concat({1, 2}, ",", 3)`, ""},
+		{"concat numbers", `return table.concat({1, 2.5})`, "12.5"},
+		{"sort numbers", `local t = {3, 1, 2} table.sort(t) return table.concat(t)`, "123"},
+		{"sort strings", `local t = {"b", "C", "a"} table.sort(t) return table.concat(t)`, "Cab"},
+		{"sort stable with comparator", `local t = {{k = 1, v = "a"}, {k = 0, v = "b"}, {k = 1, v = "c"}} table.sort(t, function(a, b) return a.k < b.k end) return t[1].v .. t[2].v .. t[3].v`, "bac"},
+		{"unpack", `return unpack({1, 2, 3})`, "1,2,3"},
+		{"unpack range", `return table.unpack({1, 2, 3}, 2)`, "2,3"},
+		{"unpack holes", `return unpack({1, 2}, 1, 4)`, "1,2,nil,nil"},
+		{"unpack huge bounds", `return select("#", unpack({1}, -math.huge, -math.huge))`, "1"},
+		{"pairs skips removed keys", `local t = {a = 1, b = 2, c = 3} local s = "" for k in pairs(t) do t.b = nil s = s .. k end return s`, "ac"},
+		{"ipairs stops at nil", `local t = {1, 2, nil, 4} local n = 0 for _ in ipairs(t) do n = n + 1 end return n`, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runAll(t, tt.src); got != tt.want {
+				t.Errorf("DoString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"syntax", "local = 1", "line 1:"},
+		{"unfinished", "if x then", "'end' expected"},
+		{"varargs", "function f(...) end", "varargs are not supported"},
+		{"goto", "goto done", "goto is not supported"},
+		{"break outside loop", "break", "break outside a loop"},
+		{"call nil", "\nfoo()", "line 2: attempt to call a nil value (variable 'foo')"},
+		{"index nil", "local t = nil return t.x", "attempt to index a nil value (variable 't')"},
+		{"arithmetic", `return {} + 1`, "attempt to perform arithmetic on a table value"},
+		{"compare", "return 1 < 'a'", "attempt to compare number with string"},
+		{"error", `error("policy violated")`, "policy violated"},
+		{"bad argument", `string.rep()`, "bad argument #1 to 'rep'"},
+		{"bad pattern", `("x"):match("(")`, "malformed pattern"},
+		{"no io", `io.write("x")`, "attempt to index a nil value (variable 'io')"},
+		{"no os", `os.exit()`, "attempt to index a nil value (variable 'os')"},
+		{"no load", `load("return 1")`, "attempt to call a nil value (variable 'load')"},
+		{"no require", `require("os")`, "attempt to call a nil value (variable 'require')"},
+		{"no metatables", `setmetatable({}, {})`, "attempt to call a nil value (variable 'setmetatable')"},
+		{"error line", "local x = 1\n\nerror('late')", "line 3: late"},
+		{"error table value", "error({})", "table: "},
+		{"unexpected symbol", "return @", "line 1: unexpected symbol '@'"},
+		{"tilde is not an operator", "return ~1", "unexpected symbol '~'"},
+		{"attributes", "local x <const> = 1", "unexpected symbol near '<'"},
+		{"missing then", "if x end", "'then' expected near 'end'"},
+		{"missing do", "for i = 1, 2 end", "'do' expected near 'end'"},
+		{"for without limit", "for i = 1 do end", "',' expected near 'do'"},
+		{"bad generic for", "for i, j = 1, 2 do end", "'in' expected near '='"},
+		{"else twice", "if x then else else end", "'end' expected near 'else'"},
+		{"code after return", "return 1 return 2", "'end' expected near 'return'"},
+		{"bare expression", "x", "syntax error near <eof>"},
+		{"assign to call", "f() = 1", "cannot assign to this expression"},
+		{"assign to parens", "(x) = 1", "cannot assign to this expression"},
+		{"empty table field", "return {,}", "unexpected symbol near ','"},
+		{"break in nested function", "while true do local function f() break end end", "break outside a loop"},
+		{"vararg expression", "return ...", "varargs are not supported"},
+		{"unfinished string", `return "abc`, "unfinished string"},
+		{"string across lines", "return 'a\nb'", "unfinished string"},
+		{"unfinished long string", "return [==[ x ]=]", "unfinished long string"},
+		{"unfinished long comment", "--[[ x", "unfinished long string"},
+		{"bad escape", `return "\z"`, "invalid escape sequence '\\z'"},
+		{"big decimal escape", `return "\300"`, "decimal escape too large"},
+		{"bad hex escape", `return "\xZZ"`, "hexadecimal digit expected"},
+		{"bad number", "return 1..2", `malformed number near "1..2"`},
+		{"bad exponent", "return 1e+", `malformed number near "1e+"`},
+		{"bad hex", "return 0x", `malformed number near "0x"`},
+		{"number too big", "return 0xffffffffffffffffff", "malformed number"},
+		{"number followed by letters", "return 12abc", `malformed number near "12"`},
+		{"syntax error line", "local a = 1\nlocal b = = 2", "line 2:"},
+		{"index field", "local t = {} return t.a.b", "attempt to index a nil value (field 'a')"},
+		{"call field", "local t = {} t.run()", "attempt to call a nil value (field 'run')"},
+		{"call method", "local t = {} t:run()", "attempt to call a nil value (method 'run')"},
+		{"index number", "return (1).x", "attempt to index a number value"},
+		{"concat nil", "local x return 'a' .. x", "attempt to concatenate a nil value (variable 'x')"},
+		{"concat table", "return {} .. 'a'", "attempt to concatenate a table value"},
+		{"length of number", "return #5", "attempt to get length of a number value"},
+		{"negate table", "return -{}", "attempt to perform arithmetic on a table value"},
+		{"arithmetic on word", "return 'a' + 1", "attempt to perform arithmetic on a string value"},
+		{"compare tables", "return {} < {}", "attempt to compare two table values"},
+		{"nil index", "local t = {} t[nil] = 1", "table index is nil"},
+		{"nan index", "local t = {[0 / 0] = 1}", "table index is NaN"},
+		{"for step zero", "for i = 1, 2, 0 do end", "'for' step is zero"},
+		{"for bad limit", "for i = 1, 'x' do end", "'for' limit value must be a number"},
+		{"iterate nil", "for k in nil do end", "attempt to call a nil value"},
+		{"pairs of nil", "for k in pairs(nil) do end", "bad argument #1 to 'pairs' (table expected, got nil)"},
+		{"type without value", "type()", "bad argument #1 to 'type'"},
+		{"select zero", "select(0, 1)", "bad argument #1 to 'select'"},
+		{"char out of range", "string.char(256)", "bad argument #1 to 'char'"},
+		{"format without value", `string.format("%d")`, "bad argument #2 to 'format' (no value)"},
+		{"format bad number", `string.format("%d", "x")`, "bad argument #2 to 'format' (number expected, got string)"},
+		{"format bad verb", `string.format("%y", 1)`, "invalid conversion '%y' to 'format'"},
+		{"format star width", `string.format("%*d", 5, 1)`, "invalid conversion"},
+		{"format trailing percent", `string.format("%")`, "invalid conversion"},
+		{"find without pattern", `("x"):find()`, "bad argument #2 to 'find' (string expected, got nil)"},
+		{"gsub bad replacement", `("a"):gsub("a", true)`, "bad argument #3 to 'gsub'"},
+		{"gsub boolean from table", `("a"):gsub("a", {a = true})`, "invalid replacement value (a boolean)"},
+		{"gsub table from function", `("a"):gsub("a", function() return {} end)`, "invalid replacement value (a table)"},
+		{"insert out of bounds", "table.insert({}, 3, 1)", "bad argument #2 to 'insert' (position out of bounds)"},
+		{"insert huge position", "table.insert({}, math.huge, 1)", "position out of bounds"},
+		{"insert nan position", "table.insert({1, 2}, 0 / 0, 1)", "position out of bounds"},
+		{"insert arguments", "table.insert({})", "wrong number of arguments to 'insert'"},
+		{"remove out of bounds", "table.remove({1}, 5)", "bad argument #2 to 'remove'"},
+		{"concat invalid value", "table.concat({1, {}, 3})", "invalid value (at index 2) in table for 'concat'"},
+		{"sort mixed", `table.sort({3, "a", 2})`, "attempt to compare"},
+		{"sort bad comparator", "table.sort({2, 1}, 5)", "attempt to call a number value"},
+		{"sort comparator error", "table.sort({2, 1}, function() error('cmp') end)", "cmp"},
+		{"unpack too many", "unpack({}, 1, 1e6)", "too many results to unpack"},
+		{"math argument", "math.floor('x')", "bad argument #1 to 'floor' (number expected, got string)"},
+		{"math without arguments", "math.max()", "bad argument #1 to 'max' (number expected, got no value)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewState().DoString(context.Background(), tt.src)
+			if err == nil {
+				t.Fatal("DoString() should fail")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DoString() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewState().WithStepLimit(1000).DoString(ctx, "while true do end")
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("infinite loop error = %v, want ErrStepLimit", err)
+	}
+
+	// pcall must not swallow limits
+	_, err = NewState().WithStepLimit(1000).DoString(ctx, "pcall(function() while true do end end) return 1")
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("pcall loop error = %v, want ErrStepLimit", err)
+	}
+
+	_, err = NewState().DoString(ctx, "local function f() return f() + 1 end f()")
+	if !errors.Is(err, ErrDepthLimit) {
+		t.Errorf("recursion error = %v, want ErrDepthLimit", err)
+	}
+
+	_, err = NewState().DoString(ctx, `local s = "x" for i = 1, 30 do s = s .. s end`)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("string growth error = %v, want ErrTooLarge", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = NewState().DoString(cancelled, "while true do end")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled run error = %v, want context.Canceled", err)
+	}
+
+	expired, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = NewState().WithStepLimit(math.MaxInt32).DoString(expired, "while true do end")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expired run error = %v, want context.DeadlineExceeded", err)
+	}
+
+	tests := []struct {
+		name  string
+		steps int
+		src   string
+		want  error
+	}{
+		{"numeric for", 1000, "for i = 1, math.huge do end", ErrStepLimit},
+		{"repeat", 1000, "repeat until false", ErrStepLimit},
+		{"generic for", 1000, "local function iter() return 1 end for _ in iter do end", ErrStepLimit},
+		{"mutual recursion", 100000, "local g local function f() return g() end g = function() return f() end f()", ErrDepthLimit},
+		{"recursion through pcall", 100000, "local function f() pcall(f) end f() return 1", ErrDepthLimit},
+		{"recursion through sort", 100000, "local function f() table.sort({2, 1}, function() f() return false end) end f()", ErrDepthLimit},
+		{"unpack counts values", 1000, "local t = {} for i = 1, 900 do t[i] = i end local u = {unpack(t)} local v = {unpack(t)}", ErrStepLimit},
+		{"insert counts moves", 2000, "local t = {} for i = 1, 300 do t[i] = i end for i = 1, 100 do table.insert(t, 1, i) end", ErrStepLimit},
+		{"concat counts items", 2000, "local t = {} for i = 1, 500 do t[i] = 'x' end for i = 1, 10 do table.concat(t) end", ErrStepLimit},
+		{"sort counts items", 3000, "local t = {} for i = 1, 500 do t[i] = i end for i = 1, 10 do table.sort(t) end", ErrStepLimit},
+		{"gmatch counts matches", 1000, "local s = ('x'):rep(5000) for c in s:gmatch('x') do break end", ErrStepLimit},
+		{"gsub counts matches", 1000, "local s = ('x'):rep(5000) s:gsub('x', 'y')", ErrStepLimit},
+		{"pcall keeps limits", 1000, "local ok = pcall(function() while true do end end) return ok", ErrStepLimit},
+		{"string too large", 100000, "return ('x'):rep(2 ^ 21)", ErrTooLarge},
+		{"format too large", 100000, "return string.format('%s%s', ('x'):rep(2 ^ 20), 'y')", ErrTooLarge},
+		{"gsub too large", 100000, "return (('x'):rep(2 ^ 20)):gsub('^', 'y')", ErrTooLarge},
+		{"concat too large", 100000, "return table.concat({('x'):rep(2 ^ 20), 'y'})", ErrTooLarge},
+		{"rep memory", 100000, "local t = {} for i = 1, 100 do t[i] = ('x'):rep(2 ^ 20) end", ErrMemoryLimit},
+		{"concat memory", 100000, "local t = {} local s = ('x'):rep(2 ^ 19) for i = 1, 100 do t[i] = s .. i end", ErrMemoryLimit},
+		{"upper memory", 100000, "local s = ('x'):rep(2 ^ 20) local t = {} for i = 1, 100 do t[i] = s:upper() end", ErrMemoryLimit},
+		{"pcall keeps memory limit", 100000, "local s = ('x'):rep(2 ^ 20) pcall(function() for i = 1, 100 do local _ = s:lower() end end) return 1", ErrMemoryLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewState().WithStepLimit(tt.steps).DoString(ctx, tt.src)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("DoString() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Each run gets the whole budget again
+	s := NewState()
+	chunk, err := Compile("local t = {} for i = 1, 10 do t[i] = ('x'):rep(2 ^ 20) end")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.WithStepLimit(1000).Run(ctx, chunk); err != nil {
+			t.Errorf("run %d error = %v", i, err)
+		}
+	}
+}
+
+func TestSyntaxLevels(t *testing.T) {
+	nested := func(open, close string, n int) string {
+		return strings.Repeat(open, n) + "1" + strings.Repeat(close, n)
+	}
+	for _, src := range []string{
+		"return " + nested("(", ")", 150),
+		"return " + nested("{", "}", 150),
+		"return " + strings.Repeat("- ", 150) + "1",
+		strings.Repeat("do ", 150) + strings.Repeat("end ", 150),
+	} {
+		if _, err := Compile(src); err != nil {
+			t.Errorf("Compile() error = %v for %.20q", err, src)
+		}
+	}
+
+	// Nesting is bounded, so scripts cannot exhaust the stack
+	for _, src := range []string{
+		"return " + nested("(", ")", 30000),
+		"return " + nested("{", "}", 30000),
+		"return " + strings.Repeat("not ", 30000) + "1",
+		"return 1" + strings.Repeat(" .. 1", 30000),
+		strings.Repeat("if x then ", 30000) + strings.Repeat("end ", 30000),
+		strings.Repeat("local function f() ", 30000) + strings.Repeat("end ", 30000),
+	} {
+		_, err := Compile(src)
+		if err == nil || !strings.Contains(err.Error(), "chunk has too many syntax levels") {
+			t.Errorf("Compile() error = %v for %.20q, want too many syntax levels", err, src)
+		}
+	}
+}
+
+func TestGoFunctions(t *testing.T) {
+	s := NewState()
+	var tags []string
+	s.Register("add_tag", func(args []Value) ([]Value, error) {
+		tag, ok := valueAt(args, 0).(string)
+		if !ok {
+			return nil, errors.New("tag must be a string")
+		}
+		tags = append(tags, tag)
+		return nil, nil
+	})
+	snippet := NewTable()
+	_ = snippet.Set("content", "kubectl get pods")
+	_ = snippet.Set("tags", List([]string{"ops"}))
+	s.SetGlobal("snippet", snippet)
+
+	chunk, err := Compile(`
+		if snippet.content:find("kubectl", 1, true) then
+			add_tag("k8s")
+		end
+		for _, t in ipairs(snippet.tags) do add_tag(t .. "!") end
+		return #snippet.tags
+	`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	results, err := s.Run(context.Background(), chunk)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := strings.Join(tags, ","); got != "k8s,ops!" {
+		t.Errorf("tags = %q, want %q", got, "k8s,ops!")
+	}
+	if n, _ := valueAt(results, 0).(float64); n != 1 {
+		t.Errorf("result = %v, want 1", results)
+	}
+
+	_, err = s.DoString(context.Background(), "add_tag(1)")
+	if err == nil || !strings.Contains(err.Error(), "tag must be a string") {
+		t.Errorf("Go function error = %v", err)
+	}
+}
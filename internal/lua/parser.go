@@ -0,0 +1,802 @@
+package lua
+
+import "fmt"
+
+// Expressions
+
+type expr interface{ exprLine() int }
+
+type (
+	constExpr struct{ v Value }
+	nameExpr  struct{ name string }
+	indexExpr struct{ obj, key expr }
+	callExpr  struct {
+		fn   expr
+		args []expr
+	}
+	methodExpr struct {
+		obj  expr
+		name string
+		args []expr
+	}
+	funcExpr struct {
+		params []string
+		body   []stmt
+		name   string
+	}
+	tableExpr struct {
+		items []expr // Positional fields
+		keys  []expr // Keyed fields, with values in vals
+		vals  []expr
+	}
+	binExpr struct {
+		op   string
+		l, r expr
+	}
+	unExpr struct {
+		op string
+		e  expr
+	}
+	parenExpr struct{ e expr }
+)
+
+// lineExpr wraps an expression with the line it starts on, for errors
+type lineExpr struct {
+	expr
+	line int
+}
+
+func (e lineExpr) exprLine() int { return e.line }
+
+func (constExpr) exprLine() int  { return 0 }
+func (nameExpr) exprLine() int   { return 0 }
+func (indexExpr) exprLine() int  { return 0 }
+func (callExpr) exprLine() int   { return 0 }
+func (methodExpr) exprLine() int { return 0 }
+func (funcExpr) exprLine() int   { return 0 }
+func (tableExpr) exprLine() int  { return 0 }
+func (binExpr) exprLine() int    { return 0 }
+func (unExpr) exprLine() int     { return 0 }
+func (parenExpr) exprLine() int  { return 0 }
+
+// Statements
+
+type stmt interface{ stmtLine() int }
+
+type (
+	localStmt struct {
+		names []string
+		exprs []expr
+	}
+	assignStmt struct {
+		targets []expr
+		exprs   []expr
+	}
+	callStmt  struct{ call expr }
+	doStmt    struct{ body []stmt }
+	whileStmt struct {
+		cond expr
+		body []stmt
+	}
+	repeatStmt struct {
+		body []stmt
+		cond expr
+	}
+	ifStmt struct {
+		conds  []expr
+		blocks [][]stmt
+		orElse []stmt
+	}
+	numForStmt struct {
+		name              string
+		start, stop, step expr
+		body              []stmt
+	}
+	genForStmt struct {
+		names []string
+		exprs []expr
+		body  []stmt
+	}
+	localFuncStmt struct {
+		name string
+		fn   *funcExpr
+	}
+	returnStmt struct{ exprs []expr }
+	breakStmt  struct{}
+)
+
+// lineStmt wraps a statement with the line it starts on
+type lineStmt struct {
+	stmt
+	line int
+}
+
+func (s lineStmt) stmtLine() int { return s.line }
+
+func (localStmt) stmtLine() int     { return 0 }
+func (assignStmt) stmtLine() int    { return 0 }
+func (callStmt) stmtLine() int      { return 0 }
+func (doStmt) stmtLine() int        { return 0 }
+func (whileStmt) stmtLine() int     { return 0 }
+func (repeatStmt) stmtLine() int    { return 0 }
+func (ifStmt) stmtLine() int        { return 0 }
+func (numForStmt) stmtLine() int    { return 0 }
+func (genForStmt) stmtLine() int    { return 0 }
+func (localFuncStmt) stmtLine() int { return 0 }
+func (returnStmt) stmtLine() int    { return 0 }
+func (breakStmt) stmtLine() int     { return 0 }
+
+// maxSyntaxDepth is how deeply blocks and expressions may nest, as in Lua
+const maxSyntaxDepth = 200
+
+// parser builds the syntax tree of a chunk
+type parser struct {
+	toks  []token
+	pos   int
+	loop  int // Depth of enclosing loops, for break
+	depth int // Depth of nested blocks and expressions
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) advance() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the keyword or operator s
+func (p *parser) is(s string) bool {
+	t := p.peek()
+	return (t.kind == tokKeyword || t.kind == tokOp) && t.s == s
+}
+
+func (p *parser) accept(s string) bool {
+	if p.is(s) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("'%s' expected near %s", s, describe(p.peek()))
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	t := p.peek()
+	if t.kind != tokName {
+		return "", p.errorf("name expected near %s", describe(t))
+	}
+	p.advance()
+	return t.s, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Line: p.peek().line, Message: fmt.Sprintf(format, args...)}
+}
+
+// enter descends a syntax level; the caller calls leave when done
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxSyntaxDepth {
+		return p.errorf("chunk has too many syntax levels")
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "<eof>"
+	case tokString:
+		return fmt.Sprintf("%q", t.s)
+	case tokNumber:
+		return formatNumber(t.n)
+	default:
+		return "'" + t.s + "'"
+	}
+}
+
+// blockEnd reports whether the next token ends a block
+func (p *parser) blockEnd() bool {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return true
+	}
+	return t.kind == tokKeyword && (t.s == "end" || t.s == "else" || t.s == "elseif" || t.s == "until")
+}
+
+func (p *parser) block() ([]stmt, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	var body []stmt
+	for !p.blockEnd() {
+		if p.accept(";") {
+			continue
+		}
+		line := p.peek().line
+		if p.is("return") {
+			p.advance()
+			var exprs []expr
+			if !p.blockEnd() && !p.is(";") {
+				var err error
+				if exprs, err = p.exprList(); err != nil {
+					return nil, err
+				}
+			}
+			p.accept(";")
+			body = append(body, lineStmt{returnStmt{exprs}, line})
+			if !p.blockEnd() {
+				return nil, p.errorf("'end' expected near %s", describe(p.peek()))
+			}
+			break
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, lineStmt{s, line})
+	}
+	return body, nil
+}
+
+func (p *parser) statement() (stmt, error) {
+	t := p.peek()
+	if t.kind == tokKeyword {
+		switch t.s {
+		case "local":
+			p.advance()
+			if p.accept("function") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				fn, err := p.funcBody(name)
+				if err != nil {
+					return nil, err
+				}
+				return localFuncStmt{name, fn}, nil
+			}
+			var names []string
+			for {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				names = append(names, name)
+				if !p.accept(",") {
+					break
+				}
+			}
+			var exprs []expr
+			if p.accept("=") {
+				var err error
+				if exprs, err = p.exprList(); err != nil {
+					return nil, err
+				}
+			}
+			return localStmt{names, exprs}, nil
+		case "function":
+			p.advance()
+			return p.funcStatement()
+		case "do":
+			p.advance()
+			body, err := p.blockUntil("end")
+			return doStmt{body}, err
+		case "while":
+			p.advance()
+			cond, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("do"); err != nil {
+				return nil, err
+			}
+			body, err := p.loopBody("end")
+			return whileStmt{cond, body}, err
+		case "repeat":
+			p.advance()
+			body, err := p.loopBody("until")
+			if err != nil {
+				return nil, err
+			}
+			cond, err := p.expr()
+			return repeatStmt{body, cond}, err
+		case "if":
+			p.advance()
+			return p.ifStatement()
+		case "for":
+			p.advance()
+			return p.forStatement()
+		case "break":
+			p.advance()
+			if p.loop == 0 {
+				return nil, p.errorf("break outside a loop")
+			}
+			return breakStmt{}, nil
+		case "goto":
+			return nil, p.errorf("goto is not supported")
+		}
+	}
+
+	// Assignment or function call
+	target, err := p.suffixedExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("=") || p.is(",") {
+		targets := []expr{target}
+		for p.accept(",") {
+			next, err := p.suffixedExpr()
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, next)
+		}
+		for _, t := range targets {
+			switch unwrap(t).(type) {
+			case nameExpr, indexExpr:
+			default:
+				return nil, p.errorf("cannot assign to this expression")
+			}
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		exprs, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		return assignStmt{targets, exprs}, nil
+	}
+	switch unwrap(target).(type) {
+	case callExpr, methodExpr:
+		return callStmt{target}, nil
+	}
+	return nil, p.errorf("syntax error near %s", describe(p.peek()))
+}
+
+// blockUntil parses a block followed by the keyword end
+func (p *parser) blockUntil(end string) ([]stmt, error) {
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return body, p.expect(end)
+}
+
+func (p *parser) loopBody(end string) ([]stmt, error) {
+	p.loop++
+	defer func() { p.loop-- }()
+	return p.blockUntil(end)
+}
+
+func (p *parser) funcStatement() (stmt, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	line := p.peek().line
+	var target expr = lineExpr{nameExpr{name}, line}
+	fullName := name
+	method := false
+	for p.is(".") || p.is(":") {
+		method = p.is(":")
+		p.advance()
+		key, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		fullName += "." + key
+		target = lineExpr{indexExpr{target, constExpr{key}}, line}
+		if method {
+			break
+		}
+	}
+
+	fn, err := p.funcBody(fullName)
+	if err != nil {
+		return nil, err
+	}
+	if method {
+		fn.params = append([]string{"self"}, fn.params...)
+	}
+	return assignStmt{[]expr{target}, []expr{fn}}, nil
+}
+
+// funcBody parses a parameter list and body
+func (p *parser) funcBody(name string) (*funcExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []string
+	if !p.is(")") {
+		for {
+			if p.is("...") {
+				return nil, p.errorf("varargs are not supported")
+			}
+			param, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	saved := p.loop
+	p.loop = 0
+	body, err := p.blockUntil("end")
+	p.loop = saved
+	if err != nil {
+		return nil, err
+	}
+	return &funcExpr{params: params, body: body, name: name}, nil
+}
+
+func (p *parser) ifStatement() (stmt, error) {
+	var s ifStmt
+	for {
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("then"); err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s.conds = append(s.conds, cond)
+		s.blocks = append(s.blocks, body)
+
+		switch {
+		case p.accept("elseif"):
+			continue
+		case p.accept("else"):
+			if s.orElse, err = p.blockUntil("end"); err != nil {
+				return nil, err
+			}
+			return s, nil
+		default:
+			return s, p.expect("end")
+		}
+	}
+}
+
+func (p *parser) forStatement() (stmt, error) {
+	first, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("=") {
+		s := numForStmt{name: first}
+		if s.start, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if s.stop, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if p.accept(",") {
+			if s.step, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("do"); err != nil {
+			return nil, err
+		}
+		s.body, err = p.loopBody("end")
+		return s, err
+	}
+
+	s := genForStmt{names: []string{first}}
+	for p.accept(",") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		s.names = append(s.names, name)
+	}
+	if err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	if s.exprs, err = p.exprList(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	s.body, err = p.loopBody("end")
+	return s, err
+}
+
+func (p *parser) exprList() ([]expr, error) {
+	var exprs []expr
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		if !p.accept(",") {
+			return exprs, nil
+		}
+	}
+}
+
+// Binary operator precedences (left, right); right > left is right associative
+var binaryPriority = map[string][2]int{
+	"or": {1, 1}, "and": {2, 2},
+	"<": {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"..": {9, 8},
+	"+":  {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+const unaryPriority = 12
+
+func (p *parser) expr() (expr, error) { return p.subExpr(0) }
+
+// subExpr parses an expression whose binary operators bind tighter than limit
+func (p *parser) subExpr(limit int) (expr, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	line := p.peek().line
+	var left expr
+	if t := p.peek(); (t.kind == tokKeyword && t.s == "not") || (t.kind == tokOp && (t.s == "-" || t.s == "#")) {
+		p.advance()
+		operand, err := p.subExpr(unaryPriority)
+		if err != nil {
+			return nil, err
+		}
+		left = lineExpr{unExpr{t.s, operand}, line}
+	} else {
+		var err error
+		if left, err = p.simpleExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokOp && t.kind != tokKeyword {
+			return left, nil
+		}
+		prio, ok := binaryPriority[t.s]
+		if !ok || prio[0] <= limit {
+			return left, nil
+		}
+		p.advance()
+		right, err := p.subExpr(prio[1])
+		if err != nil {
+			return nil, err
+		}
+		left = lineExpr{binExpr{t.s, left, right}, t.line}
+	}
+}
+
+func (p *parser) simpleExpr() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.advance()
+		return constExpr{t.n}, nil
+	case tokString:
+		p.advance()
+		return constExpr{t.s}, nil
+	case tokKeyword:
+		switch t.s {
+		case "nil":
+			p.advance()
+			return constExpr{nil}, nil
+		case "true":
+			p.advance()
+			return constExpr{true}, nil
+		case "false":
+			p.advance()
+			return constExpr{false}, nil
+		case "function":
+			p.advance()
+			fn, err := p.funcBody("anonymous")
+			if err != nil {
+				return nil, err
+			}
+			return lineExpr{fn, t.line}, nil
+		}
+	case tokOp:
+		switch t.s {
+		case "{":
+			return p.table()
+		case "...":
+			return nil, p.errorf("varargs are not supported")
+		}
+	}
+	return p.suffixedExpr()
+}
+
+// suffixedExpr parses a name or parenthesized expression followed by
+// field accesses, indexing and calls
+func (p *parser) suffixedExpr() (expr, error) {
+	t := p.peek()
+	var e expr
+	switch {
+	case t.kind == tokName:
+		p.advance()
+		e = lineExpr{nameExpr{t.s}, t.line}
+	case p.accept("("):
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		e = lineExpr{parenExpr{inner}, t.line}
+	default:
+		return nil, p.errorf("unexpected symbol near %s", describe(t))
+	}
+
+	for {
+		line := p.peek().line
+		switch {
+		case p.accept("."):
+			key, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			e = lineExpr{indexExpr{e, constExpr{key}}, line}
+		case p.accept("["):
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			e = lineExpr{indexExpr{e, key}, line}
+		case p.accept(":"):
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = lineExpr{methodExpr{e, name, args}, line}
+		case p.is("(") || p.is("{") || p.peek().kind == tokString:
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = lineExpr{callExpr{e, args}, line}
+		default:
+			return e, nil
+		}
+	}
+}
+
+// callArgs parses f(args), f{table} or f"string"
+func (p *parser) callArgs() ([]expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokString:
+		p.advance()
+		return []expr{constExpr{t.s}}, nil
+	case p.is("{"):
+		table, err := p.table()
+		return []expr{table}, err
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.accept(")") {
+		return nil, nil
+	}
+	args, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) table() (expr, error) {
+	line := p.peek().line
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var t tableExpr
+	for !p.is("}") {
+		switch {
+		case p.is("["):
+			p.advance()
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			val, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			t.keys = append(t.keys, key)
+			t.vals = append(t.vals, val)
+		case p.peek().kind == tokName && p.toks[p.pos+1].kind == tokOp && p.toks[p.pos+1].s == "=":
+			key := p.advance().s
+			p.advance()
+			val, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			t.keys = append(t.keys, constExpr{key})
+			t.vals = append(t.vals, val)
+		default:
+			item, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			t.items = append(t.items, item)
+		}
+		if !p.accept(",") && !p.accept(";") {
+			break
+		}
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return lineExpr{t, line}, nil
+}
+
+// unwrap strips line information from an expression
+func unwrap(e expr) expr {
+	if l, ok := e.(lineExpr); ok {
+		return unwrap(l.expr)
+	}
+	return e
+}
+
+// parse parses a chunk
+func parse(source string) ([]stmt, error) {
+	toks, err := (&lexer{src: source, line: 1}).tokens()
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("'<eof>' expected near %s", describe(p.peek()))
+	}
+	return body, nil
+}
@@ -0,0 +1,756 @@
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// openLibs installs the safe part of the standard library
+func (s *State) openLibs() {
+	s.Register("type", func(args []Value) ([]Value, error) {
+		if len(args) == 0 {
+			return nil, argError(1, "type", "value expected")
+		}
+		return []Value{TypeName(args[0])}, nil
+	})
+	s.Register("tostring", func(args []Value) ([]Value, error) {
+		return []Value{ToString(valueAt(args, 0))}, nil
+	})
+	s.Register("tonumber", func(args []Value) ([]Value, error) {
+		v := valueAt(args, 0)
+		if base, ok := valueAt(args, 1).(float64); ok && base != 10 {
+			str, ok := v.(string)
+			if !ok {
+				return []Value{nil}, nil
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(str), int(base), 64)
+			if err != nil {
+				return []Value{nil}, nil
+			}
+			return []Value{float64(n)}, nil
+		}
+		if n, ok := toNumber(v); ok {
+			return []Value{n}, nil
+		}
+		return []Value{nil}, nil
+	})
+	s.Register("error", func(args []Value) ([]Value, error) {
+		v := valueAt(args, 0)
+		return nil, &Error{Line: s.line, Message: errorMessage(v), Value: v}
+	})
+	s.Register("assert", func(args []Value) ([]Value, error) {
+		if Truthy(valueAt(args, 0)) {
+			return args, nil
+		}
+		msg := valueAt(args, 1)
+		if msg == nil {
+			msg = "assertion failed!"
+		}
+		return nil, &Error{Line: s.line, Message: errorMessage(msg), Value: msg}
+	})
+	s.Register("pcall", func(args []Value) ([]Value, error) {
+		if len(args) == 0 {
+			return nil, argError(1, "pcall", "value expected")
+		}
+		results, err := s.call(args[0], args[1:], "")
+		if err == nil {
+			return append([]Value{true}, results...), nil
+		}
+		var luaErr *Error
+		if !errors.As(err, &luaErr) {
+			// Limits and cancellation end the whole run
+			return nil, err
+		}
+		if luaErr.Value != nil {
+			return []Value{false, luaErr.Value}, nil
+		}
+		return []Value{false, luaErr.Error()}, nil
+	})
+	s.Register("select", func(args []Value) ([]Value, error) {
+		if str, ok := valueAt(args, 0).(string); ok && str == "#" {
+			return []Value{float64(len(args) - 1)}, nil
+		}
+		n, ok := toNumber(valueAt(args, 0))
+		if !ok || toInt(n) < 1 {
+			return nil, argError(1, "select", "index out of range")
+		}
+		if toInt(n) >= len(args) {
+			return nil, nil
+		}
+		return args[toInt(n):], nil
+	})
+	s.Register("pairs", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "pairs")
+		if err != nil {
+			return nil, err
+		}
+		keys := t.Keys()
+		i := 0
+		next := &GoFunction{Name: "next", Fn: func([]Value) ([]Value, error) {
+			for i < len(keys) {
+				k := keys[i]
+				i++
+				// Skip keys removed during the traversal
+				if v := t.Get(k); v != nil {
+					return []Value{k, v}, nil
+				}
+			}
+			return []Value{nil}, nil
+		}}
+		return []Value{next, t, nil}, nil
+	})
+	s.Register("ipairs", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "ipairs")
+		if err != nil {
+			return nil, err
+		}
+		next := &GoFunction{Name: "ipairs_next", Fn: func(args []Value) ([]Value, error) {
+			i, _ := valueAt(args, 1).(float64)
+			v := t.Get(i + 1)
+			if v == nil {
+				return []Value{nil}, nil
+			}
+			return []Value{i + 1, v}, nil
+		}}
+		return []Value{next, t, float64(0)}, nil
+	})
+	unpack := func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "unpack")
+		if err != nil {
+			return nil, err
+		}
+		from, to := 1, t.Len()
+		if n, ok := valueAt(args, 1).(float64); ok {
+			from = toInt(n)
+		}
+		if n, ok := valueAt(args, 2).(float64); ok {
+			to = toInt(n)
+		}
+		if to-from >= 10000 {
+			return nil, argError(1, "unpack", "too many results to unpack")
+		}
+		if err := s.charge(to - from + 1); err != nil {
+			return nil, err
+		}
+		var out []Value
+		for i := from; i <= to; i++ {
+			out = append(out, t.Get(float64(i)))
+		}
+		return out, nil
+	}
+	s.Register("unpack", unpack)
+
+	s.SetGlobal("string", s.stringLib())
+	s.SetGlobal("table", s.tableLib(unpack))
+	s.SetGlobal("math", mathLib())
+}
+
+func (s *State) stringLib() *Table {
+	lib := NewTable()
+	fn := func(name string, f func(args []Value) ([]Value, error)) {
+		_ = lib.Set(name, &GoFunction{Name: name, Fn: f})
+	}
+
+	fn("len", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "len")
+		return []Value{float64(len(str))}, err
+	})
+	fn("lower", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "lower")
+		if err == nil {
+			err = s.alloc(len(str))
+		}
+		return []Value{strings.ToLower(str)}, err
+	})
+	fn("upper", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "upper")
+		if err == nil {
+			err = s.alloc(len(str))
+		}
+		return []Value{strings.ToUpper(str)}, err
+	})
+	fn("reverse", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "reverse")
+		if err == nil {
+			err = s.alloc(len(str))
+		}
+		b := []byte(str)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return []Value{string(b)}, err
+	})
+	fn("rep", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "rep")
+		if err != nil {
+			return nil, err
+		}
+		n := optInt(args, 1, 0)
+		if n <= 0 || str == "" {
+			return []Value{""}, nil
+		}
+		if len(str)*n > s.maxString {
+			return nil, ErrTooLarge
+		}
+		if err := s.alloc(len(str) * n); err != nil {
+			return nil, err
+		}
+		return []Value{strings.Repeat(str, n)}, nil
+	})
+	fn("sub", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "sub")
+		if err != nil {
+			return nil, err
+		}
+		i, j := optInt(args, 1, 1), optInt(args, 2, -1)
+		start, end := strRange(len(str), i, j)
+		if start > end {
+			return []Value{""}, nil
+		}
+		return []Value{str[start:end]}, nil
+	})
+	fn("byte", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "byte")
+		if err != nil {
+			return nil, err
+		}
+		i := optInt(args, 1, 1)
+		start, end := strRange(len(str), i, optInt(args, 2, i))
+		var out []Value
+		for k := start; k < end; k++ {
+			out = append(out, float64(str[k]))
+		}
+		return out, nil
+	})
+	fn("char", func(args []Value) ([]Value, error) {
+		b := make([]byte, len(args))
+		for i, a := range args {
+			n, ok := toNumber(a)
+			if !ok || n < 0 || n > 255 {
+				return nil, argError(i+1, "char", "value out of range")
+			}
+			b[i] = byte(n)
+		}
+		return []Value{string(b)}, nil
+	})
+	fn("format", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "format")
+		if err != nil {
+			return nil, err
+		}
+		out, err := format(str, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > s.maxString {
+			return nil, ErrTooLarge
+		}
+		if err := s.alloc(len(out)); err != nil {
+			return nil, err
+		}
+		return []Value{out}, nil
+	})
+	fn("find", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "find")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := stringArg(args, 2, "find")
+		if err != nil {
+			return nil, err
+		}
+		start := initPos(len(str), optInt(args, 2, 1))
+		if start > len(str) {
+			return []Value{nil}, nil
+		}
+		if Truthy(valueAt(args, 3)) {
+			i := strings.Index(str[start:], pattern)
+			if i < 0 {
+				return []Value{nil}, nil
+			}
+			return []Value{float64(start + i + 1), float64(start + i + len(pattern))}, nil
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m := re.FindStringSubmatchIndex(str[start:])
+		if m == nil {
+			return []Value{nil}, nil
+		}
+		out := []Value{float64(start + m[0] + 1), float64(start + m[1])}
+		return append(out, captures(str[start:], m, false)...), nil
+	})
+	fn("match", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "match")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := stringArg(args, 2, "match")
+		if err != nil {
+			return nil, err
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		start := initPos(len(str), optInt(args, 2, 1))
+		if start > len(str) {
+			return []Value{nil}, nil
+		}
+		m := re.FindStringSubmatchIndex(str[start:])
+		if m == nil {
+			return []Value{nil}, nil
+		}
+		return captures(str[start:], m, true), nil
+	})
+	fn("gmatch", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "gmatch")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := stringArg(args, 2, "gmatch")
+		if err != nil {
+			return nil, err
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matches := re.FindAllStringSubmatchIndex(str, -1)
+		if err := s.charge(len(matches)); err != nil {
+			return nil, err
+		}
+		i := 0
+		return []Value{&GoFunction{Name: "gmatch_next", Fn: func([]Value) ([]Value, error) {
+			if i >= len(matches) {
+				return []Value{nil}, nil
+			}
+			i++
+			return captures(str, matches[i-1], true), nil
+		}}}, nil
+	})
+	fn("gsub", func(args []Value) ([]Value, error) {
+		str, err := stringArg(args, 1, "gsub")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := stringArg(args, 2, "gsub")
+		if err != nil {
+			return nil, err
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		limit := optInt(args, 3, -1)
+		repl := valueAt(args, 2)
+
+		var b strings.Builder
+		count, last := 0, 0
+		for _, m := range re.FindAllStringSubmatchIndex(str, -1) {
+			if limit >= 0 && count >= limit {
+				break
+			}
+			if err := s.step(); err != nil {
+				return nil, err
+			}
+			b.WriteString(str[last:m[0]])
+			whole := str[m[0]:m[1]]
+			caps := captures(str, m, true)
+
+			var sub Value
+			switch r := repl.(type) {
+			case string:
+				sub = string(re.ExpandString(nil, replacement(r), str, m))
+			case float64:
+				sub = formatNumber(r)
+			case *Table:
+				sub = r.Get(caps[0])
+			case *Function, *GoFunction:
+				results, err := s.call(r, caps, "")
+				if err != nil {
+					return nil, err
+				}
+				sub = valueAt(results, 0)
+			default:
+				return nil, argError(3, "gsub", "string/function/table expected, got %s", TypeName(repl))
+			}
+			switch v := sub.(type) {
+			case nil, bool:
+				if Truthy(v) {
+					return nil, fmt.Errorf("invalid replacement value (a boolean)")
+				}
+				b.WriteString(whole)
+			default:
+				rs, ok := concatString(v)
+				if !ok {
+					return nil, fmt.Errorf("invalid replacement value (a %s)", TypeName(v))
+				}
+				b.WriteString(rs)
+			}
+			if b.Len() > s.maxString {
+				return nil, ErrTooLarge
+			}
+			last = m[1]
+			count++
+		}
+		b.WriteString(str[last:])
+		if b.Len() > s.maxString {
+			return nil, ErrTooLarge
+		}
+		if err := s.alloc(b.Len()); err != nil {
+			return nil, err
+		}
+		return []Value{b.String(), float64(count)}, nil
+	})
+	return lib
+}
+
+// compilePattern compiles an RE2 pattern for the string functions
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("malformed pattern: %v", err)
+	}
+	return re, nil
+}
+
+// captures returns the groups of a match. If the pattern has none, it
+// returns the whole match when wholeIfNone is set, as match does.
+func captures(str string, m []int, wholeIfNone bool) []Value {
+	if len(m) == 2 {
+		if wholeIfNone {
+			return []Value{str[m[0]:m[1]]}
+		}
+		return nil
+	}
+	out := make([]Value, 0, len(m)/2-1)
+	for i := 2; i < len(m); i += 2 {
+		if m[i] < 0 {
+			out = append(out, nil)
+			continue
+		}
+		out = append(out, str[m[i]:m[i+1]])
+	}
+	return out
+}
+
+// replacement converts a gsub replacement string, where %1 is a capture,
+// %0 the whole match and %% a percent sign, to regexp.Expand syntax
+func replacement(r string) string {
+	var b strings.Builder
+	for i := 0; i < len(r); i++ {
+		switch {
+		case r[i] == '$':
+			b.WriteString("$$")
+		case r[i] == '%' && i+1 < len(r) && isDigit(r[i+1]):
+			b.WriteString("${" + string(r[i+1]) + "}")
+			i++
+		case r[i] == '%' && i+1 < len(r):
+			b.WriteByte(r[i+1])
+			i++
+		default:
+			b.WriteByte(r[i])
+		}
+	}
+	return b.String()
+}
+
+// strRange converts Lua's 1-based, possibly negative, inclusive indices
+// into a Go slice range
+func strRange(n, i, j int) (int, int) {
+	if i < 0 {
+		i = max(n+i+1, 1)
+	} else if i == 0 {
+		i = 1
+	}
+	if j < 0 {
+		j = n + j + 1
+	} else if j > n {
+		j = n
+	}
+	if i > j {
+		return 0, 0
+	}
+	return i - 1, j
+}
+
+// initPos converts the init argument of find and match to a 0-based offset,
+// which is past the end if the search cannot match
+func initPos(n, init int) int {
+	switch {
+	case init < 0:
+		return max(n+init, 0)
+	case init == 0:
+		return 0
+	}
+	return init - 1
+}
+
+// format implements string.format for %d %i %s %q %f %g %e %x %X %o %c %%
+func format(f string, args []Value) (string, error) {
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			b.WriteByte(f[i])
+			continue
+		}
+		j := i + 1
+		for j < len(f) && strings.IndexByte("-+ #0123456789.", f[j]) >= 0 {
+			j++
+		}
+		if j >= len(f) {
+			return "", fmt.Errorf("invalid conversion '%s' to 'format'", f[i:])
+		}
+		verb, flags := f[j], f[i+1:j]
+		i = j
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+
+		if n >= len(args) {
+			return "", argError(n+2, "format", "no value")
+		}
+		arg := args[n]
+		n++
+		switch verb {
+		case 'd', 'i', 'x', 'X', 'o', 'c':
+			num, ok := toNumber(arg)
+			if !ok {
+				return "", argError(n+1, "format", "number expected, got %s", TypeName(arg))
+			}
+			if verb == 'i' {
+				verb = 'd'
+			}
+			fmt.Fprintf(&b, "%"+flags+string(verb), int64(num))
+		case 'f', 'F', 'g', 'G', 'e', 'E':
+			num, ok := toNumber(arg)
+			if !ok {
+				return "", argError(n+1, "format", "number expected, got %s", TypeName(arg))
+			}
+			fmt.Fprintf(&b, "%"+flags+string(verb), num)
+		case 's':
+			fmt.Fprintf(&b, "%"+flags+"s", ToString(arg))
+		case 'q':
+			b.WriteString(strconv.Quote(ToString(arg)))
+		default:
+			return "", fmt.Errorf("invalid conversion '%%%s%c' to 'format'", flags, verb)
+		}
+	}
+	return b.String(), nil
+}
+
+func (s *State) tableLib(unpack func([]Value) ([]Value, error)) *Table {
+	lib := NewTable()
+	fn := func(name string, f func(args []Value) ([]Value, error)) {
+		_ = lib.Set(name, &GoFunction{Name: name, Fn: f})
+	}
+
+	fn("insert", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "insert")
+		if err != nil {
+			return nil, err
+		}
+		switch len(args) {
+		case 2:
+			t.Append(args[1])
+		case 3:
+			num, ok := toNumber(args[1])
+			pos, n := toInt(num), t.Len()
+			if !ok || pos < 1 || pos > n+1 {
+				return nil, argError(2, "insert", "position out of bounds")
+			}
+			if err := s.charge(n - pos); err != nil {
+				return nil, err
+			}
+			for i := n; i >= pos; i-- {
+				_ = t.Set(float64(i+1), t.Get(float64(i)))
+			}
+			_ = t.Set(float64(pos), args[2])
+		default:
+			return nil, fmt.Errorf("wrong number of arguments to 'insert'")
+		}
+		return nil, nil
+	})
+	fn("remove", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "remove")
+		if err != nil {
+			return nil, err
+		}
+		n := t.Len()
+		if n == 0 {
+			return []Value{nil}, nil
+		}
+		pos := optInt(args, 1, n)
+		if pos < 1 || pos > n {
+			return nil, argError(2, "remove", "position out of bounds")
+		}
+		if err := s.charge(n - pos); err != nil {
+			return nil, err
+		}
+		v := t.Get(float64(pos))
+		for i := pos; i < n; i++ {
+			_ = t.Set(float64(i), t.Get(float64(i+1)))
+		}
+		_ = t.Set(float64(n), nil)
+		return []Value{v}, nil
+	})
+	fn("concat", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "concat")
+		if err != nil {
+			return nil, err
+		}
+		sep, _ := concatString(valueAt(args, 1))
+		from, to := optInt(args, 2, 1), optInt(args, 3, t.Len())
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			if err := s.step(); err != nil {
+				return nil, err
+			}
+			str, ok := concatString(t.Get(float64(i)))
+			if !ok {
+				return nil, fmt.Errorf("invalid value (at index %d) in table for 'concat'", i)
+			}
+			if i > from {
+				b.WriteString(sep)
+			}
+			b.WriteString(str)
+			if b.Len() > s.maxString {
+				return nil, ErrTooLarge
+			}
+		}
+		if err := s.alloc(b.Len()); err != nil {
+			return nil, err
+		}
+		return []Value{b.String()}, nil
+	})
+	fn("sort", func(args []Value) ([]Value, error) {
+		t, err := tableArg(args, 1, "sort")
+		if err != nil {
+			return nil, err
+		}
+		less := valueAt(args, 1)
+		if err := s.charge(t.Len()); err != nil {
+			return nil, err
+		}
+		var sortErr error
+		sort.SliceStable(t.arr, func(i, j int) bool {
+			if sortErr != nil {
+				return false
+			}
+			a, b := t.arr[i], t.arr[j]
+			if less != nil {
+				results, err := s.call(less, []Value{a, b}, "")
+				if err != nil {
+					sortErr = err
+					return false
+				}
+				return Truthy(valueAt(results, 0))
+			}
+			lt, err := s.compare("<", a, b)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			return lt.(bool)
+		})
+		return nil, sortErr
+	})
+	fn("unpack", unpack)
+	return lib
+}
+
+func mathLib() *Table {
+	lib := NewTable()
+	fn := func(name string, f func(float64) float64) {
+		_ = lib.Set(name, &GoFunction{Name: name, Fn: func(args []Value) ([]Value, error) {
+			n, ok := toNumber(valueAt(args, 0))
+			if !ok {
+				return nil, argError(1, name, "number expected, got %s", TypeName(valueAt(args, 0)))
+			}
+			return []Value{f(n)}, nil
+		}})
+	}
+	fn("floor", math.Floor)
+	fn("ceil", math.Ceil)
+	fn("abs", math.Abs)
+	fn("sqrt", math.Sqrt)
+	fn("exp", math.Exp)
+	fn("log", math.Log)
+
+	extreme := func(name string, better func(a, b float64) bool) {
+		_ = lib.Set(name, &GoFunction{Name: name, Fn: func(args []Value) ([]Value, error) {
+			if len(args) == 0 {
+				return nil, argError(1, name, "number expected, got no value")
+			}
+			var best float64
+			for i, a := range args {
+				n, ok := toNumber(a)
+				if !ok {
+					return nil, argError(i+1, name, "number expected, got %s", TypeName(a))
+				}
+				if i == 0 || better(n, best) {
+					best = n
+				}
+			}
+			return []Value{best}, nil
+		}})
+	}
+	extreme("max", func(a, b float64) bool { return a > b })
+	extreme("min", func(a, b float64) bool { return a < b })
+
+	_ = lib.Set("huge", math.Inf(1))
+	_ = lib.Set("pi", math.Pi)
+	return lib
+}
+
+func tableArg(args []Value, n int, fn string) (*Table, error) {
+	t, ok := valueAt(args, n-1).(*Table)
+	if !ok {
+		return nil, argError(n, fn, "table expected, got %s", TypeName(valueAt(args, n-1)))
+	}
+	return t, nil
+}
+
+// stringArg returns a string argument; numbers are converted, as in Lua
+func stringArg(args []Value, n int, fn string) (string, error) {
+	switch v := valueAt(args, n-1).(type) {
+	case string:
+		return v, nil
+	case float64:
+		return formatNumber(v), nil
+	}
+	return "", argError(n, fn, "string expected, got %s", TypeName(valueAt(args, n-1)))
+}
+
+// optInt returns the integer at args[i], or def if it is missing
+func optInt(args []Value, i, def int) int {
+	n, ok := toNumber(valueAt(args, i))
+	if !ok {
+		return def
+	}
+	return toInt(n)
+}
+
+// toInt truncates a number to an integer. NaN is 0 and numbers out of
+// the int32 range are clamped to it, so that index arithmetic on the
+// result cannot overflow.
+func toInt(n float64) int {
+	switch {
+	case math.IsNaN(n):
+		return 0
+	case n > math.MaxInt32:
+		return math.MaxInt32
+	case n < math.MinInt32:
+		return math.MinInt32
+	}
+	return int(n)
+}
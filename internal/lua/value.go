@@ -0,0 +1,306 @@
+package lua
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is a Lua value: nil, bool, float64, string, *Table, *Function or
+// *GoFunction. All numbers are floats, as in Lua 5.1.
+type Value any
+
+// Function is a function defined in a script
+type Function struct {
+	fn    *funcExpr
+	scope *scope
+}
+
+// GoFunction is a function implemented in Go. It receives the call's
+// arguments and returns its results; returned errors become Lua errors.
+type GoFunction struct {
+	Name string
+	Fn   func(args []Value) ([]Value, error)
+}
+
+// Table is a Lua table. Integer keys from 1 up are kept in a slice, so
+// the length operator and ipairs are cheap.
+type Table struct {
+	arr  []Value
+	hash map[Value]Value
+}
+
+// NewTable creates an empty table
+func NewTable() *Table {
+	return &Table{}
+}
+
+// List creates a table holding values at 1, 2, ...
+func List[T any](values []T) *Table {
+	t := &Table{arr: make([]Value, len(values))}
+	for i, v := range values {
+		t.arr[i] = v
+	}
+	return t
+}
+
+// normalizeKey turns integral floats into the canonical key type
+func normalizeKey(key Value) Value {
+	switch k := key.(type) {
+	case int:
+		return float64(k)
+	case int64:
+		return float64(k)
+	}
+	return key
+}
+
+// arrayIndex returns the slice index of key if it is an integer >= 1
+func arrayIndex(key Value) (int, bool) {
+	n, ok := key.(float64)
+	if !ok || n < 1 || n != math.Trunc(n) || n > math.MaxInt32 {
+		return 0, false
+	}
+	return int(n) - 1, true
+}
+
+// Get returns t[key]
+func (t *Table) Get(key Value) Value {
+	key = normalizeKey(key)
+	if i, ok := arrayIndex(key); ok && i < len(t.arr) {
+		return t.arr[i]
+	}
+	if t.hash == nil {
+		return nil
+	}
+	return t.hash[key]
+}
+
+// GetString returns t[key] for a string key
+func (t *Table) GetString(key string) Value {
+	return t.Get(key)
+}
+
+// Set sets t[key] = value; a nil value removes the key
+func (t *Table) Set(key, value Value) error {
+	key = normalizeKey(key)
+	switch k := key.(type) {
+	case nil:
+		return fmt.Errorf("table index is nil")
+	case float64:
+		if math.IsNaN(k) {
+			return fmt.Errorf("table index is NaN")
+		}
+	}
+
+	if i, ok := arrayIndex(key); ok {
+		switch {
+		case i < len(t.arr):
+			t.arr[i] = value
+			if value == nil && i == len(t.arr)-1 {
+				t.trim()
+			}
+			return nil
+		case i == len(t.arr) && value != nil:
+			t.arr = append(t.arr, value)
+			delete(t.hash, key)
+			t.migrate()
+			return nil
+		}
+	}
+
+	if value == nil {
+		delete(t.hash, key)
+		return nil
+	}
+	if t.hash == nil {
+		t.hash = make(map[Value]Value)
+	}
+	t.hash[key] = value
+	return nil
+}
+
+// trim drops trailing nils from the array part
+func (t *Table) trim() {
+	n := len(t.arr)
+	for n > 0 && t.arr[n-1] == nil {
+		n--
+	}
+	t.arr = t.arr[:n]
+}
+
+// migrate moves keys following the array part from the hash into it
+func (t *Table) migrate() {
+	for t.hash != nil {
+		key := float64(len(t.arr) + 1)
+		v, ok := t.hash[key]
+		if !ok {
+			return
+		}
+		t.arr = append(t.arr, v)
+		delete(t.hash, key)
+	}
+}
+
+// Len returns the length of the table's sequence, #t
+func (t *Table) Len() int {
+	return len(t.arr)
+}
+
+// Append adds a value at the end of the sequence
+func (t *Table) Append(v Value) {
+	_ = t.Set(float64(len(t.arr)+1), v)
+}
+
+// Keys returns the table's keys: the sequence in order, then numbers,
+// strings and other keys sorted, so that pairs is deterministic
+func (t *Table) Keys() []Value {
+	keys := make([]Value, 0, len(t.arr)+len(t.hash))
+	for i, v := range t.arr {
+		if v != nil {
+			keys = append(keys, float64(i+1))
+		}
+	}
+	rest := make([]Value, 0, len(t.hash))
+	for k := range t.hash {
+		rest = append(rest, k)
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		a, b := rest[i], rest[j]
+		ra, rb := keyRank(a), keyRank(b)
+		if ra != rb {
+			return ra < rb
+		}
+		switch a := a.(type) {
+		case float64:
+			return a < b.(float64)
+		case string:
+			return a < b.(string)
+		case bool:
+			return !a && b.(bool)
+		}
+		return fmt.Sprintf("%p", a) < fmt.Sprintf("%p", b)
+	})
+	return append(keys, rest...)
+}
+
+func keyRank(k Value) int {
+	switch k.(type) {
+	case float64:
+		return 0
+	case string:
+		return 1
+	case bool:
+		return 2
+	}
+	return 3
+}
+
+// TypeName returns the Lua type name of a value
+func TypeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *Table:
+		return "table"
+	case *Function, *GoFunction:
+		return "function"
+	}
+	return "userdata"
+}
+
+// Truthy reports whether a value counts as true: anything but nil and false
+func Truthy(v Value) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	return true
+}
+
+// ToString converts a value to a string as tostring does
+func ToString(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case *Table:
+		return fmt.Sprintf("table: %p", v)
+	case *Function:
+		return fmt.Sprintf("function: %p", v)
+	case *GoFunction:
+		return "function: builtin: " + v.Name
+	}
+	return fmt.Sprint(v)
+}
+
+// formatNumber formats integral numbers without a fraction
+func formatNumber(n float64) string {
+	switch {
+	case math.IsInf(n, 1):
+		return "inf"
+	case math.IsInf(n, -1):
+		return "-inf"
+	case math.IsNaN(n):
+		return "nan"
+	case n == math.Trunc(n) && math.Abs(n) < 1e15:
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return strconv.FormatFloat(n, 'g', 14, 64)
+}
+
+// toNumber converts numbers and numeric strings to a number
+func toNumber(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		return parseNumber(v)
+	}
+	return 0, false
+}
+
+// parseNumber parses a number as Lua source would write it
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(s, "-") {
+		neg, s = true, s[1:]
+	}
+	var n float64
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		u, err := strconv.ParseUint(s[2:], 16, 64)
+		if err != nil {
+			return 0, false
+		}
+		n = float64(u)
+	} else {
+		if s == "" || strings.ContainsAny(s, "_xXpP") || strings.EqualFold(s, "inf") || strings.EqualFold(s, "infinity") || strings.EqualFold(s, "nan") {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		n = f
+	}
+	if neg {
+		n = -n
+	}
+	return n, true
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Announcement is the snippet admins chose to show on public pages
+type Announcement struct {
+	SnippetID string    `json:"snippet_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Language  string    `json:"language"`
+	HTML      string    `json:"html"` // Content rendered as escaped HTML
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,86 @@
+package models
+
+// API usage report periods, in days. Counts older than the longest period
+// are pruned.
+const (
+	DefaultAPIUsageDays = 30
+	MaxAPIUsageDays     = 365
+)
+
+// APIUsageHit is one answered API request
+type APIUsageHit struct {
+	TokenID   int64 // 0 for browser sessions and instances without login
+	TokenName string
+	Method    string
+	Route     string // Route pattern, such as /api/v1/snippets/{id}
+	Status    int
+}
+
+// APIUsageCounts counts requests. Errors are 4xx and 5xx responses other
+// than 429, which are counted as rate limited.
+type APIUsageCounts struct {
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"`
+	RateLimited int     `json:"rate_limited"`
+	ErrorRate   float64 `json:"error_rate"` // Errors per request, 0 to 1
+}
+
+// Add adds other to the counts and updates the error rate
+func (c *APIUsageCounts) Add(other APIUsageCounts) {
+	c.Requests += other.Requests
+	c.Errors += other.Errors
+	c.RateLimited += other.RateLimited
+	if c.Requests > 0 {
+		c.ErrorRate = float64(c.Errors) / float64(c.Requests)
+	}
+}
+
+// APIUsageEntry is a day's requests by one token to one route
+type APIUsageEntry struct {
+	Day       string `json:"day"` // YYYY-MM-DD, UTC
+	TokenID   int64  `json:"token_id"`
+	TokenName string `json:"token_name"`
+	Method    string `json:"method"`
+	Route     string `json:"route"`
+	APIUsageCounts
+}
+
+// APIUsageFilter selects the days, and optionally the token, of a report
+type APIUsageFilter struct {
+	From    string // First day, YYYY-MM-DD
+	To      string // Last day, inclusive
+	TokenID *int64
+}
+
+// APIUsageReport summarizes API requests over a period
+type APIUsageReport struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Totals APIUsageCounts  `json:"totals"`
+	Days   []APIUsageDay   `json:"days"`
+	Tokens []APIUsageToken `json:"tokens"` // Most requests first
+	Routes []APIUsageRoute `json:"routes"` // Per token and route, most requests first
+}
+
+// APIUsageDay is a day's requests. Days without requests are included
+// with zero, so the list covers the whole period.
+type APIUsageDay struct {
+	Date string `json:"date"`
+	APIUsageCounts
+}
+
+// APIUsageToken is the requests made with one token
+type APIUsageToken struct {
+	TokenID   int64  `json:"token_id"`
+	TokenName string `json:"token_name"`
+	APIUsageCounts
+}
+
+// APIUsageRoute is the requests made with one token to one route
+type APIUsageRoute struct {
+	TokenID   int64  `json:"token_id"`
+	TokenName string `json:"token_name"`
+	Method    string `json:"method"`
+	Route     string `json:"route"`
+	APIUsageCounts
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AutoArchiveCandidate is a snippet matched by a folder's auto-archive rule
+type AutoArchiveCandidate struct {
+	SnippetID        string    `json:"snippet_id"`
+	Title            string    `json:"title"`
+	FolderID         int64     `json:"folder_id"`
+	FolderName       string    `json:"folder_name"`
+	ArchiveAfterDays int       `json:"archive_after_days"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// AutoArchiveReport lists the snippets an auto-archive run matched. On a
+// dry run nothing is archived.
+type AutoArchiveReport struct {
+	DryRun   bool                   `json:"dry_run"`
+	Snippets []AutoArchiveCandidate `json:"snippets"`
+	Archived int                    `json:"archived"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Autocomplete kinds
+const (
+	AutocompleteTag      = "tag"
+	AutocompleteFolder   = "folder"
+	AutocompleteLanguage = "language"
+)
+
+// IsAutocompleteKind reports whether s is a known autocomplete kind
+func IsAutocompleteKind(s string) bool {
+	return s == AutocompleteTag || s == AutocompleteFolder || s == AutocompleteLanguage
+}
+
+// Suggestion is an autocomplete match
+type Suggestion struct {
+	Value    string `json:"value"`
+	ID       int64  `json:"id,omitempty"`        // Tag or folder ID
+	ParentID *int64 `json:"parent_id,omitempty"` // Parent of a folder
+	Color    string `json:"color,omitempty"`
+	Count    int    `json:"count"` // Unarchived snippets using it
+}
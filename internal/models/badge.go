@@ -0,0 +1,14 @@
+package models
+
+// Badge is the text and colour of a shields.io-style status badge
+type Badge struct {
+	Label   string // Left-hand side, on grey
+	Message string // Right-hand side, on Color
+	Color   string // Hex colour, with the leading #
+}
+
+// LanguageCount is the number of snippets written in a language
+type LanguageCount struct {
+	Language string
+	Count    int
+}
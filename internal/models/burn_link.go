@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Burn link lifetimes, in days
+const (
+	DefaultBurnLinkDays = 7
+	MaxBurnLinkDays     = 30
+)
+
+// BurnLink is a share link that shows a snippet, public or not, exactly
+// once. Opening it deletes the link, and the snippet too when DeleteSnippet
+// is set.
+type BurnLink struct {
+	ID            int64      `json:"id"`
+	SnippetID     string     `json:"snippet_id"`
+	DeleteSnippet bool       `json:"delete_snippet"`
+	Token         string     `json:"token,omitempty"` // Only returned on creation
+	URL           string     `json:"url,omitempty"`   // Only returned on creation
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BurnLinkInput creates a burn link
+type BurnLinkInput struct {
+	DeleteSnippet bool `json:"delete_snippet,omitempty"`  // Delete the snippet once the link is opened
+	ExpiresInDays int  `json:"expires_in_days,omitempty"` // Defaults to DefaultBurnLinkDays
+}
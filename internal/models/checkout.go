@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Check-out durations, in seconds. A check-out ends at its expiry if it is
+// not checked in before.
+const (
+	DefaultCheckoutTTL = 3600
+	MinCheckoutTTL     = 60
+	MaxCheckoutTTL     = 86400
+)
+
+// SnippetCheckout records who checked out a snippet that requires it, when
+// and why. Check-outs are kept as the snippet's audit trail.
+type SnippetCheckout struct {
+	ID           int64      `json:"id"`
+	SnippetID    string     `json:"snippet_id"`
+	SnippetTitle string     `json:"snippet_title"` // Title when checked out, kept after the snippet is deleted
+	Holder       string     `json:"holder"`        // API token name, "session", or empty without authentication
+	SourceIP     string     `json:"source_ip,omitempty"`
+	Reason       string     `json:"reason"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	Note         string     `json:"note,omitempty"` // Left on check-in
+}
+
+// Active reports whether the check-out is still held
+func (c *SnippetCheckout) Active() bool {
+	return c.CheckedInAt == nil && time.Now().Before(c.ExpiresAt)
+}
+
+// CheckoutInput checks out a snippet
+type CheckoutInput struct {
+	Reason string `json:"reason"`        // Why the snippet is needed, e.g. a change ticket
+	TTL    int    `json:"ttl,omitempty"` // Seconds; defaults to DefaultCheckoutTTL
+}
+
+// CheckinInput checks a snippet back in
+type CheckinInput struct {
+	Note string `json:"note,omitempty"` // What was done, for the audit trail
+}
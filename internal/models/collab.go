@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CollabDocument is the saved state of a document being edited together.
+// FileID is 0 for the snippet's own content. The state is kept until its
+// edits are written to the snippet, and discarded when the snippet changes
+// in another way; BaseHash identifies the text the edits started from.
+type CollabDocument struct {
+	SnippetID string
+	FileID    int64
+	State     string // JSON-encoded collab.State
+	BaseHash  string
+	UpdatedAt time.Time
+}
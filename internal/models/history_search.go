@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Limits on searching a snippet's history
+const (
+	DefaultHistorySearchContext = 2  // Lines shown around each match
+	MaxHistorySearchContext     = 10 // Lines
+	MaxHistorySearchMatches     = 50 // Matching lines reported per version
+)
+
+// HistorySearchOptions configures a search of history, of one snippet or
+// of all of them
+type HistorySearchOptions struct {
+	Query   string
+	Regex   bool   // Query is a regular expression; otherwise a case-insensitive substring
+	Context int    // Lines shown before and after each match
+	Limit   int    // Most matching versions returned
+	From    string // Earliest day of the versions searched, YYYY-MM-DD in UTC; empty for no bound
+	To      string // Latest day, inclusive
+	Removed bool   // Only versions of snippets whose current version no longer matches
+}
+
+// HistoryFilter selects the history entries with content to search
+type HistoryFilter struct {
+	SnippetID string // Empty for every snippet
+	Text      string // Only entries containing this, ignoring ASCII case; empty for all
+	From      string // YYYY-MM-DD, inclusive; empty for no bound
+	To        string // YYYY-MM-DD, inclusive; empty for no bound
+	Limit     int
+}
+
+// HistorySearchResult is a version of a snippet that matches a search.
+// Like the history entry it comes from, an update is the version replaced
+// at CreatedAt.
+type HistorySearchResult struct {
+	HistoryID  int64              `json:"history_id"`
+	SnippetID  string             `json:"snippet_id"`
+	ChangeType string             `json:"change_type"`
+	ChangedBy  string             `json:"changed_by"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Title      string             `json:"title"`
+	Matches    []HistoryLineMatch `json:"matches"`
+	Truncated  bool               `json:"truncated,omitempty"` // More lines matched than are reported
+}
+
+// HistoryLineMatch is a matching line of a historical version
+type HistoryLineMatch struct {
+	Field    string   `json:"field"`              // "title", "description", "content" or "file"
+	Filename string   `json:"filename,omitempty"` // For matches in a file
+	Line     int      `json:"line"`               // 1-based
+	Text     string   `json:"text"`
+	Before   []string `json:"before,omitempty"`
+	After    []string `json:"after,omitempty"`
+}
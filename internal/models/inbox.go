@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InboxItem is untitled text captured by a clipboard integration
+type InboxItem struct {
+	ID        int64     `json:"id"`
+	Content   string    `json:"content"`
+	Source    string    `json:"source,omitempty"` // Client-supplied label, e.g. the sending app
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InboxPromoteInput sets the snippet fields when an inbox item is promoted.
+// Everything is optional: the title defaults to the first line of the text.
+type InboxPromoteInput struct {
+	Title    string   `json:"title"`
+	Language string   `json:"language"`
+	Tags     []string `json:"tags,omitempty"`
+	FolderID *int64   `json:"folder_id,omitempty"`
+	IsPublic bool     `json:"is_public"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+// Job states. Failed jobs that will be retried go back to pending.
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job represents a queued or finished background job
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      JobStatus       `json:"status"`
+	Payload     json.RawMessage `json:"-"` // Input for the job handler
+	Processed   int             `json:"processed"`
+	Total       int             `json:"total"`
+	Errors      []string        `json:"errors,omitempty"` // Per-item errors; the job can still succeed
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"` // Last failure
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAfter    time.Time       `json:"run_after"` // Not picked up before this time (retry backoff)
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the job has finished for good
+func (j Job) Done() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
+
+// JobFilter represents filter options for listing jobs
+type JobFilter struct {
+	Kind   string
+	Status JobStatus
+	Limit  int
+}
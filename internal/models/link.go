@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Relations of a link between snippets. References are maintained
+// automatically from snipo://{id} and /s/{id} URLs and, in markdown,
+// [[Title]] references in the source snippet's content; the other
+// relations are set by hand.
+const (
+	LinkRelated    = "related"
+	LinkUses       = "uses"
+	LinkSupersedes = "supersedes"
+	LinkReferences = "references"
+)
+
+// IsLinkRelation reports whether s is a known link relation
+func IsLinkRelation(s string) bool {
+	return s == LinkRelated || s == LinkUses || s == LinkSupersedes || s == LinkReferences
+}
+
+// SnippetLink is a typed link from a source snippet to a target snippet
+type SnippetLink struct {
+	ID        int64     `json:"id"`
+	SourceID  string    `json:"source_id"`
+	TargetID  string    `json:"target_id"`
+	Relation  string    `json:"relation"`
+	Auto      bool      `json:"auto"`  // Found in the source's content
+	Title     string    `json:"title"` // Title of the snippet at the other end
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SnippetLinks holds a snippet's outgoing links and the links pointing to it
+type SnippetLinks struct {
+	Links     []SnippetLink `json:"links"`
+	Backlinks []SnippetLink `json:"backlinks"`
+}
+
+// LinkInput creates a link to another snippet
+type LinkInput struct {
+	TargetID string `json:"target_id"` // ID or slug
+	Relation string `json:"relation"`  // Defaults to "related"
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Edit lock timeouts, in seconds. Editors renew their lock before it
+// expires; a lock that is not renewed lapses on its own.
+const (
+	DefaultLockTTL = 120
+	MinLockTTL     = 10
+	MaxLockTTL     = 600
+)
+
+// SnippetLock is an advisory lock taken by a client editing a snippet.
+// Locks warn other editors but do not block writes.
+type SnippetLock struct {
+	SnippetID  string    `json:"snippet_id"`
+	LockID     string    `json:"lock_id,omitempty"` // Only returned to the holder
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LockInput acquires or renews an edit lock. Sending the lock ID of a held
+// lock renews it.
+type LockInput struct {
+	LockID string `json:"lock_id,omitempty"`
+	Holder string `json:"holder,omitempty"` // Shown to other editors; defaults to the caller's token name or "session"
+	TTL    int    `json:"ttl,omitempty"`    // Seconds; defaults to DefaultLockTTL
+}
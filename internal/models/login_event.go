@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Login event failure reasons
+const (
+	LoginReasonInvalidPassword = "invalid_password"
+	LoginReasonRateLimited     = "rate_limited"
+)
+
+// LoginEvent represents a recorded login attempt
+type LoginEvent struct {
+	ID        int64     `json:"id"`
+	Success   bool      `json:"success"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginEventFilter represents filter options for listing login events
+type LoginEventFilter struct {
+	FailedOnly bool
+	IPAddress  string
+	Limit      int
+}
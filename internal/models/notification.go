@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Notification is an entry in the in-app notification center
+type Notification struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	ReadAt    *time.Time        `json:"read_at,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// NotificationFilter represents filter options for listing notifications
+type NotificationFilter struct {
+	UnreadOnly bool
+	Limit      int
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Permission levels are hierarchical: admin > write > read
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+	PermissionAdmin = "admin"
+)
+
+// Roles name the levels for people and folders: a viewer reads, an editor
+// also writes and an admin also manages settings, tokens and backups. They
+// are accepted wherever a level is.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// roleLevels maps roles to the levels they stand for
+var roleLevels = map[string]string{
+	RoleViewer: PermissionRead,
+	RoleEditor: PermissionWrite,
+	RoleAdmin:  PermissionAdmin,
+}
+
+// Scopes grant individual administrative capabilities without full admin.
+// A token's permissions column holds a comma-separated list of levels and scopes,
+// e.g. "read,backup:run".
+const (
+	ScopeSettingsRead  = "settings:read"
+	ScopeSettingsWrite = "settings:write"
+	ScopeBackupRun     = "backup:run"
+	ScopeTokensManage  = "tokens:manage"
+)
+
+// permissionRank orders the hierarchical levels
+var permissionRank = map[string]int{
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionAdmin: 3,
+}
+
+// validScopes lists the known scopes and the scopes each one implies
+var validScopes = map[string][]string{
+	ScopeSettingsRead:  nil,
+	ScopeSettingsWrite: {ScopeSettingsRead},
+	ScopeBackupRun:     nil,
+	ScopeTokensManage:  nil,
+}
+
+// IsScope reports whether p is a known granular scope
+func IsScope(p string) bool {
+	_, ok := validScopes[p]
+	return ok
+}
+
+// NormalizePermissions validates a comma- or space-separated permission list and
+// returns it in canonical form (level first, then scopes, de-duplicated).
+// An empty list defaults to read.
+func NormalizePermissions(perms string) (string, error) {
+	entries := splitPermissions(perms)
+	if len(entries) == 0 {
+		return PermissionRead, nil
+	}
+
+	level := ""
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+
+		switch {
+		case permissionRank[e] > 0:
+			if permissionRank[e] > permissionRank[level] {
+				level = e
+			}
+		case IsScope(e):
+			scopes = append(scopes, e)
+		default:
+			return "", fmt.Errorf("invalid permission %q: must be 'read', 'write', 'admin' (or 'viewer', 'editor') or one of %s", e, strings.Join(KnownScopes(), ", "))
+		}
+	}
+
+	// Admin already grants every scope
+	if level == PermissionAdmin {
+		return PermissionAdmin, nil
+	}
+
+	out := make([]string, 0, len(scopes)+1)
+	if level != "" {
+		out = append(out, level)
+	}
+	out = append(out, scopes...)
+	return strings.Join(out, ","), nil
+}
+
+// KnownScopes returns the supported scopes in a stable order
+func KnownScopes() []string {
+	return []string{ScopeSettingsRead, ScopeSettingsWrite, ScopeBackupRun, ScopeTokensManage}
+}
+
+// HasPermission checks whether a token permission list satisfies a required
+// level or scope. Unknown entries are ignored.
+func HasPermission(perms, required string) bool {
+	entries := splitPermissions(perms)
+
+	level := 0
+	granted := make(map[string]bool)
+	for _, e := range entries {
+		if r := permissionRank[e]; r > level {
+			level = r
+		}
+		if IsScope(e) {
+			granted[e] = true
+			for _, implied := range validScopes[e] {
+				granted[implied] = true
+			}
+		}
+	}
+
+	// Admin has all permissions and scopes
+	if level == permissionRank[PermissionAdmin] {
+		return true
+	}
+
+	if rank, ok := permissionRank[required]; ok {
+		return level >= rank
+	}
+	return granted[required]
+}
+
+func splitPermissions(perms string) []string {
+	fields := strings.FieldsFunc(perms, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	for i, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if level, ok := roleLevels[f]; ok {
+			f = level
+		}
+		fields[i] = f
+	}
+	return fields
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// QuickOpenEntry is a snippet as held by the quick-open index: only what
+// is matched and ranked, not the content
+type QuickOpenEntry struct {
+	ID        string
+	Title     string
+	Language  string
+	Filenames []string
+	Frecency  int // Usage score over the last 90 days, as in the frecency sort
+	UpdatedAt time.Time
+}
+
+// QuickOpenResult is a snippet matching a quick-open query
+type QuickOpenResult struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Language string  `json:"language"`
+	Filename string  `json:"filename,omitempty"` // Set when the query matched a filename rather than the title
+	Matches  []int   `json:"matches,omitempty"`  // Rune offsets of the matched characters in the title, or in filename
+	Score    float64 `json:"score"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// RedactionRule masks text matching a regular expression wherever snippets
+// leave the instance: public views, share links and exports. Stored
+// content is never changed.
+type RedactionRule struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Pattern     string    `json:"pattern"`     // RE2 regular expression
+	Replacement string    `json:"replacement"` // May refer to groups as $1 or ${name}
+	Enabled     bool      `json:"enabled"`
+	Position    int       `json:"position"` // Rules apply in ascending position, then ID
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RedactionRuleInput creates or replaces a redaction rule
+type RedactionRuleInput struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Enabled     *bool  `json:"enabled,omitempty"` // Defaults to true
+	Position    int    `json:"position"`
+}
+
+// RedactionPreviewInput is a text to try the enabled redaction rules on
+type RedactionPreviewInput struct {
+	Content string `json:"content"`
+}
+
+// RedactionPreview is the result of trying the redaction rules on a text
+type RedactionPreview struct {
+	Content string `json:"content"`
+	Matches int    `json:"matches"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Reasons for reporting a public snippet
+const (
+	ReportSpam      = "spam"
+	ReportMalware   = "malware"
+	ReportAbuse     = "abuse"
+	ReportIllegal   = "illegal"
+	ReportCopyright = "copyright"
+	ReportOther     = "other"
+)
+
+// IsReportReason reports whether s is a known report reason
+func IsReportReason(s string) bool {
+	switch s {
+	case ReportSpam, ReportMalware, ReportAbuse, ReportIllegal, ReportCopyright, ReportOther:
+		return true
+	}
+	return false
+}
+
+// Report statuses. Open reports wait in the moderation queue; unpublishing
+// a snippet or dismissing a report resolves every open report about it.
+const (
+	ReportOpen        = "open"
+	ReportUnpublished = "unpublished"
+	ReportDismissed   = "dismissed"
+)
+
+// SnippetReport is a visitor's report about a public snippet
+type SnippetReport struct {
+	ID           int64      `json:"id"`
+	SnippetID    string     `json:"snippet_id"`
+	SnippetTitle string     `json:"snippet_title"`
+	IsPublic     bool       `json:"is_public"` // Whether the snippet is still public
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details,omitempty"`
+	ReporterIP   string     `json:"reporter_ip,omitempty"`
+	Status       string     `json:"status"`
+	ResolvedBy   string     `json:"resolved_by,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ReportInput is a report sent from a share page
+type ReportInput struct {
+	Reason    string           `json:"reason"`
+	Details   string           `json:"details,omitempty"`
+	Challenge *ReportChallenge `json:"challenge,omitempty"` // Solved challenge, when the server requires one
+}
+
+// ReportChallenge is a proof-of-work puzzle a reporter solves before
+// sending a report: find a nonce such that the SHA-256 hash of the salt
+// followed by the nonce starts with Bits zero bits
+type ReportChallenge struct {
+	Salt      string    `json:"salt"`
+	Bits      int       `json:"bits"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Signature string    `json:"signature"`
+	Nonce     string    `json:"nonce,omitempty"` // Set by the reporter
+}
+
+// ReportFilter represents filter options for the moderation queue
+type ReportFilter struct {
+	Status string // Defaults to open; "all" lists every report
+	Limit  int
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Review states of a snippet. Snippets start as drafts, are submitted for
+// review and approved or rejected back to draft. While review is required,
+// only approved snippets are served publicly.
+const (
+	ReviewDraft    = "draft"
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+)
+
+// Review actions recorded in a snippet's review log
+const (
+	ReviewActionSubmit  = "submit"
+	ReviewActionApprove = "approve"
+	ReviewActionReject  = "reject"
+	ReviewActionReset   = "reset" // An edit invalidated the approval
+)
+
+// IsReviewState reports whether s is a known review state
+func IsReviewState(s string) bool {
+	return s == ReviewDraft || s == ReviewPending || s == ReviewApproved
+}
+
+// SnippetReview is an entry in a snippet's review log
+type SnippetReview struct {
+	ID        int64     `json:"id"`
+	SnippetID string    `json:"snippet_id"`
+	Action    string    `json:"action"`
+	State     string    `json:"state"` // State after the action
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewInput carries the comment for a review action
+type ReviewInput struct {
+	Comment string `json:"comment"`
+}
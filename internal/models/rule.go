@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Events that run automation rules
+const (
+	RuleEventCreate = "create"
+	RuleEventUpdate = "update"
+)
+
+// IsRuleEvent reports whether s is a known rule event
+func IsRuleEvent(s string) bool {
+	return s == RuleEventCreate || s == RuleEventUpdate
+}
+
+// Outcomes of a rule run
+const (
+	RuleRunOK       = "ok"
+	RuleRunRejected = "rejected" // The script rejected the save
+	RuleRunError    = "error"    // The script failed; the save went ahead
+)
+
+// Rule is an automation rule: a Lua script run before snippets are saved,
+// which can change their tags or reject the save
+type Rule struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Events    []string  `json:"events"`
+	Script    string    `json:"script"`
+	Enabled   bool      `json:"enabled"`
+	Position  int       `json:"position"` // Rules run in ascending position, then ID
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RuleInput creates or replaces a rule
+type RuleInput struct {
+	Name     string   `json:"name"`
+	Events   []string `json:"events"`
+	Script   string   `json:"script"`
+	Enabled  *bool    `json:"enabled,omitempty"` // Defaults to true
+	Position int      `json:"position"`
+}
+
+// RuleRun is one execution of a rule
+type RuleRun struct {
+	ID           int64     `json:"id"`
+	RuleID       int64     `json:"rule_id"`
+	SnippetID    string    `json:"snippet_id,omitempty"` // Empty for snippets being created
+	SnippetTitle string    `json:"snippet_title"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message,omitempty"` // Rejection reason or error
+	Actions      string    `json:"actions,omitempty"` // Changes made, e.g. "added tag k8s"
+	Output       string    `json:"output,omitempty"`  // Lines written with log()
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -17,6 +17,8 @@ type Settings struct {
 	ArchiveEnabled          bool      `json:"archive_enabled"`
 	HistoryEnabled          bool      `json:"history_enabled"`
 	DisableLogin            bool      `json:"disable_login"`
+	ReviewRequired          bool      `json:"review_required"`
+	AnnouncementSnippetID   string    `json:"announcement_snippet_id"` // Empty when no announcement is shown
 	EditorFontSize          int       `json:"editor_font_size"`
 	EditorTabSize           int       `json:"editor_tab_size"`
 	EditorTheme             string    `json:"editor_theme"`
@@ -29,6 +31,7 @@ type Settings struct {
 	EditorEnableSnippets    bool      `json:"editor_enable_snippets"`
 	EditorEnableLiveAutocompletion bool `json:"editor_enable_live_autocompletion"`
 	MarkdownFontSize        int       `json:"markdown_font_size"`
+	Validation              ValidationRules `json:"validation"`
 	CreatedAt               time.Time `json:"created_at"`
 	UpdatedAt               time.Time `json:"updated_at"`
 }
@@ -49,6 +52,8 @@ type SettingsInput struct {
 	ArchiveEnabled          bool   `json:"archive_enabled"`
 	HistoryEnabled          bool   `json:"history_enabled"`
 	DisableLogin            bool   `json:"disable_login"`
+	ReviewRequired          bool   `json:"review_required"` // Public snippets are only served once approved
+	AnnouncementSnippetID   *string `json:"announcement_snippet_id,omitempty"` // nil keeps the current announcement; "" removes it
 	EditorFontSize          int    `json:"editor_font_size"`
 	EditorTabSize           int    `json:"editor_tab_size"`
 	EditorTheme             string `json:"editor_theme"`
@@ -61,4 +66,15 @@ type SettingsInput struct {
 	EditorEnableSnippets    bool   `json:"editor_enable_snippets"`
 	EditorEnableLiveAutocompletion bool `json:"editor_enable_live_autocompletion"`
 	MarkdownFontSize        int    `json:"markdown_font_size"`
+	Validation              *ValidationRules `json:"validation,omitempty"` // nil keeps the current rules
+}
+
+// ValidationRules are the admin-adjustable limits snippets are validated
+// against. Lengths count characters, except for tags, which count bytes.
+type ValidationRules struct {
+	MaxTitleLength       int    `json:"max_title_length"`
+	MaxDescriptionLength int    `json:"max_description_length"`
+	MaxContentSize       int    `json:"max_content_size"` // Bytes, for the content and each file
+	MaxTagLength         int    `json:"max_tag_length"`
+	TagPattern           string `json:"tag_pattern"` // Regular expression tag names must match in full; empty for the default
 }
@@ -0,0 +1,52 @@
+package models
+
+// Client classes of share viewers, derived from the User-Agent
+const (
+	ClientBrowser = "browser"
+	ClientCLI     = "cli" // curl, wget, HTTP libraries
+	ClientBot     = "bot" // Crawlers and link previews
+	ClientOther   = "other"
+)
+
+// Share analytics periods, in days
+const (
+	DefaultAnalyticsDays = 30
+	MaxAnalyticsDays     = 365
+)
+
+// ShareView is one counted view of a public snippet. Referrer is the
+// referring host, empty for direct visits.
+type ShareView struct {
+	SnippetID string
+	Referrer  string
+	Client    string
+	IP        string // Only stored when visitor IPs are enabled
+}
+
+// ShareAnalytics summarizes the views of a public snippet over a period
+type ShareAnalytics struct {
+	SnippetID  string              `json:"snippet_id"`
+	ViewCount  int                 `json:"view_count"` // All-time views, as on the snippet
+	From       string              `json:"from"`       // First day of the period (YYYY-MM-DD, UTC)
+	To         string              `json:"to"`         // Last day of the period
+	Views      int                 `json:"views"`      // Views in the period
+	Visitors   *int                `json:"visitors,omitempty"`
+	Days       []ShareAnalyticsDay `json:"days"`
+	Referrers  []ShareAnalyticsKey `json:"referrers"`
+	Clients    []ShareAnalyticsKey `json:"clients"`
+	VisitorIPs bool                `json:"visitor_ips"` // Whether unique visitors are counted
+}
+
+// ShareAnalyticsDay is a day's views. Days without views are included
+// with zero, so the list covers the whole period.
+type ShareAnalyticsDay struct {
+	Date     string `json:"date"`
+	Views    int    `json:"views"`
+	Visitors *int   `json:"visitors,omitempty"` // Unique IPs, when they are stored
+}
+
+// ShareAnalyticsKey is the views of a referrer or client class
+type ShareAnalyticsKey struct {
+	Key   string `json:"key"`
+	Views int    `json:"views"`
+}
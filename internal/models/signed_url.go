@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Signed URL lifetimes, in seconds
+const (
+	DefaultSignedURLTTL = 3600
+	MinSignedURLTTL     = 60
+	MaxSignedURLTTL     = 7 * 24 * 3600
+)
+
+// SignedURLInput requests a signed URL for a snippet
+type SignedURLInput struct {
+	TTL int `json:"ttl,omitempty"` // Seconds; defaults to DefaultSignedURLTTL
+}
+
+// SignedURL fetches a snippet, public or not, without credentials until it
+// expires
+type SignedURL struct {
+	URL       string    `json:"url"`               // Public snippet API; send Accept: text/plain for the raw content
+	RawURL    string    `json:"raw_url,omitempty"` // Raw content, when the paste API is enabled
+	ExpiresAt time.Time `json:"expires_at"`
+}
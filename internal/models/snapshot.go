@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// LibrarySnapshot records which snippets existed on a day and the checksum
+// of each one's content, so the library can be compared across dates
+// without keeping the content itself
+type LibrarySnapshot struct {
+	ID           int64           `json:"id"`
+	Day          string          `json:"day"` // YYYY-MM-DD, UTC
+	SnippetCount int             `json:"snippet_count"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Snippets     []SnapshotEntry `json:"snippets,omitempty"` // Only set when a single snapshot is read
+}
+
+// SnapshotEntry is one snippet in a library snapshot
+type SnapshotEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Checksum string `json:"checksum"` // See ContentChecksum
+}
+
+// SnapshotChange is a snippet present in both snapshots of a diff whose
+// title or content changed between them
+type SnapshotChange struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	PreviousTitle  string `json:"previous_title,omitempty"` // Set when the title changed
+	ContentChanged bool   `json:"content_changed"`
+}
+
+// SnapshotDiff compares the library between two snapshots
+type SnapshotDiff struct {
+	From    string           `json:"from"` // Day of the earlier snapshot
+	To      string           `json:"to"`   // Day of the later snapshot
+	Added   []SnapshotEntry  `json:"added"`
+	Removed []SnapshotEntry  `json:"removed"`
+	Changed []SnapshotChange `json:"changed"`
+}
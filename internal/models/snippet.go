@@ -18,24 +18,38 @@ type SnippetFile struct {
 
 // Snippet represents a code snippet
 type Snippet struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Content     string    `json:"content"`  // Primary/legacy content (first file)
-	Language    string    `json:"language"` // Primary/legacy language
-	IsFavorite  bool      `json:"is_favorite"`
-	IsPublic    bool      `json:"is_public"`
-	IsArchived  bool      `json:"is_archived"`
-	ViewCount   int       `json:"view_count"`
-	S3Key       *string   `json:"s3_key,omitempty"`
-	Checksum    *string   `json:"checksum,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               string     `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	Content          string     `json:"content"`  // Primary/legacy content (first file)
+	Language         string     `json:"language"` // Primary/legacy language
+	IsFavorite       bool       `json:"is_favorite"`
+	IsPublic         bool       `json:"is_public"`
+	IsArchived       bool       `json:"is_archived"`
+	IsPinned         bool       `json:"is_pinned"`
+	PinnedAt         *time.Time `json:"pinned_at,omitempty"`
+	ViewCount        int        `json:"view_count"`
+	S3Key            *string    `json:"s3_key,omitempty"`
+	Checksum         *string    `json:"checksum,omitempty"`
+	SourceURL        *string    `json:"source_url,omitempty"`       // Where the snippet was imported from
+	Slug             *string    `json:"slug,omitempty"`             // Human-readable share identifier
+	License          *string    `json:"license,omitempty"`          // SPDX license identifier
+	Attribution      *string    `json:"attribution,omitempty"`      // Credit to show when reusing the snippet
+	ReviewState      string     `json:"review_state"`               // draft, pending or approved
+	PublishAt        *time.Time `json:"publish_at,omitempty"`       // When the scheduler will make the snippet public
+	RequiresCheckout bool       `json:"requires_checkout"`          // Content is only shown to whoever has the snippet checked out
+	NoIndex          bool       `json:"noindex"`                    // Its public page asks search engines not to index it
+	ContentWithheld  bool       `json:"content_withheld,omitempty"` // Content and file contents were left out pending a check-out
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 
 	// Relationships (populated when needed)
-	Tags    []Tag         `json:"tags,omitempty"`
-	Folders []Folder      `json:"folders,omitempty"`
-	Files   []SnippetFile `json:"files,omitempty"` // Multi-file support
+	Tags     []Tag             `json:"tags,omitempty"`
+	Folders  []Folder          `json:"folders,omitempty"`
+	Files    []SnippetFile     `json:"files,omitempty"`    // Multi-file support
+	Metadata map[string]string `json:"metadata,omitempty"` // Custom key/value fields
+	Lock     *SnippetLock      `json:"lock,omitempty"`     // Active edit lock
+	Checkout *SnippetCheckout  `json:"checkout,omitempty"` // Active check-out, for snippets that require one
 }
 
 // SnippetFileInput represents input for a file within a snippet
@@ -48,32 +62,50 @@ type SnippetFileInput struct {
 
 // SnippetInput represents input for creating/updating a snippet
 type SnippetInput struct {
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Content     string             `json:"content"`  // Legacy single-file content
-	Language    string             `json:"language"` // Legacy single-file language
-	Tags        []string           `json:"tags,omitempty"`
-	FolderID    *int64             `json:"folder_id,omitempty"`
-	IsPublic    bool               `json:"is_public"`
-	IsArchived  bool               `json:"is_archived,omitempty"`
-	Files       []SnippetFileInput `json:"files,omitempty"` // Multi-file support
+	ID               string             `json:"-"` // Preserved identity when restoring a backup; empty generates one
+	Title            string             `json:"title"`
+	Description      string             `json:"description"`
+	Content          string             `json:"content"`  // Legacy single-file content
+	Language         string             `json:"language"` // Legacy single-file language
+	Tags             []string           `json:"tags,omitempty"`
+	FolderID         *int64             `json:"folder_id,omitempty"`
+	IsPublic         bool               `json:"is_public"`
+	IsArchived       bool               `json:"is_archived,omitempty"`
+	Files            []SnippetFileInput `json:"files,omitempty"`             // Multi-file support
+	SourceURL        *string            `json:"source_url,omitempty"`        // nil keeps the current value on update; "" clears it
+	Slug             *string            `json:"slug,omitempty"`              // nil keeps the current value (or derives one); "" clears it
+	License          *string            `json:"license,omitempty"`           // SPDX identifier; nil keeps the current value on update, "" clears it
+	Attribution      *string            `json:"attribution,omitempty"`       // nil keeps the current value on update; "" clears it
+	Metadata         map[string]string  `json:"metadata,omitempty"`          // nil keeps the current fields on update; {} clears them
+	ReviewState      string             `json:"-"`                           // Preserved review state when restoring a backup; empty starts a draft
+	PublishAt        *string            `json:"publish_at,omitempty"`        // RFC 3339 time to make the snippet public; nil keeps the current value on update, "" clears it
+	RequiresCheckout *bool              `json:"requires_checkout,omitempty"` // Hide the content until checked out; nil keeps the current value on update
+	NoIndex          *bool              `json:"noindex,omitempty"`           // Keep the public page out of search engines; nil keeps the current value on update
+	IsFavorite       bool               `json:"-"`                           // Preserved favorite mark when restoring a backup
+	PinnedAt         *time.Time         `json:"-"`                           // Preserved pin when restoring a backup; nil leaves the snippet unpinned
 }
 
 // SnippetFilter represents filter options for listing snippets
 type SnippetFilter struct {
-	Query      string
-	Language   string
-	TagID      int64   // Single tag filter (deprecated, use TagIDs)
-	FolderID   int64   // Single folder filter (deprecated, use FolderIDs)
-	TagIDs     []int64 // Multiple tags filter
-	FolderIDs  []int64 // Multiple folders filter
-	IsFavorite *bool
-	IsPublic   *bool
-	IsArchived *bool
-	Page       int
-	Limit      int
-	SortBy     string
-	SortOrder  string
+	Query            string
+	Language         string
+	License          string   // SPDX identifier, matched case-insensitively
+	ReviewState      string   // draft, pending or approved
+	TagID            int64    // Single tag filter (deprecated, use TagIDs)
+	FolderID         int64    // Single folder filter (deprecated, use FolderIDs)
+	TagIDs           []int64  // Multiple tags filter
+	TagNames         []string // Tags by name or alias, combined with TagID or TagIDs
+	FolderIDs        []int64  // Multiple folders filter
+	AccessFolderIDs  []int64  // Only snippets in these folders, for tokens limited to folders; nil allows all
+	IsFavorite       *bool
+	IsPublic         *bool
+	IsArchived       *bool
+	RequiresCheckout *bool
+	Metadata         map[string]string // Exact key/value matches, all must hold
+	Page             int
+	Limit            int
+	SortBy           string
+	SortOrder        string
 }
 
 // DefaultSnippetFilter returns default filter values
@@ -91,6 +123,7 @@ type Tag struct {
 	ID           int64     `json:"id"`
 	Name         string    `json:"name"`
 	Color        string    `json:"color"`
+	TextColor    string    `json:"text_color"` // Black or white, whichever contrasts more with Color
 	CreatedAt    time.Time `json:"created_at"`
 	SnippetCount int       `json:"snippet_count,omitempty"`
 }
@@ -101,24 +134,84 @@ type TagInput struct {
 	Color string `json:"color"`
 }
 
+// TagAlias is another name for a tag. Tagging a snippet with the alias, or
+// filtering by it, uses the tag instead.
+type TagAlias struct {
+	Alias     string    `json:"alias"`
+	TagID     int64     `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagAliasInput represents input for adding a tag alias
+type TagAliasInput struct {
+	Alias string `json:"alias"`
+}
+
+// TagImplicationInput represents input for making a tag imply another
+type TagImplicationInput struct {
+	TagID int64 `json:"tag_id"` // The implied tag
+}
+
 // Folder represents a folder for organizing snippets
 type Folder struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	ParentID     *int64    `json:"parent_id,omitempty"`
-	Icon         string    `json:"icon"`
-	SortOrder    int       `json:"sort_order"`
-	CreatedAt    time.Time `json:"created_at"`
-	SnippetCount int       `json:"snippet_count,omitempty"`
-	Children     []Folder  `json:"children,omitempty"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	ParentID         *int64    `json:"parent_id,omitempty"`
+	Icon             string    `json:"icon"`
+	Color            string    `json:"color,omitempty"`
+	SortOrder        int       `json:"sort_order"`
+	ArchiveAfterDays int       `json:"archive_after_days"` // Auto-archive snippets not updated for this many days (0 = never)
+	IsPublic         bool      `json:"is_public"`          // Listed as a read-only collection at /c/{slug}
+	Slug             *string   `json:"slug,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	SnippetCount     int       `json:"snippet_count,omitempty"`
+	Children         []Folder  `json:"children,omitempty"`
 }
 
 // FolderInput represents input for creating/updating a folder
 type FolderInput struct {
-	Name      string `json:"name"`
-	ParentID  *int64 `json:"parent_id,omitempty"`
-	Icon      string `json:"icon,omitempty"`
-	SortOrder int    `json:"sort_order,omitempty"`
+	Name             string  `json:"name"`
+	ParentID         *int64  `json:"parent_id,omitempty"`
+	Icon             string  `json:"icon,omitempty"`
+	Color            string  `json:"color,omitempty"` // Hex color (#rgb or #rrggbb), empty for default
+	SortOrder        int     `json:"sort_order,omitempty"`
+	ArchiveAfterDays *int    `json:"archive_after_days,omitempty"` // nil keeps the current rule on update; 0 turns it off
+	IsPublic         *bool   `json:"is_public,omitempty"`          // nil keeps the current state on update
+	Slug             *string `json:"slug,omitempty"`               // nil keeps the current slug on update; empty clears it
+}
+
+// Collection is a public folder with a page of its public snippets
+type Collection struct {
+	Folder     CollectionFolder `json:"folder"`
+	Snippets   []Snippet        `json:"snippets"`
+	Pagination Pagination       `json:"pagination"`
+}
+
+// CollectionFolder is the part of a folder shown on its public collection
+type CollectionFolder struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Icon  string `json:"icon"`
+	Color string `json:"color,omitempty"`
+}
+
+// Icon describes an entry in the folder icon catalog
+type Icon struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// License describes an entry in the snippet license catalog
+type License struct {
+	ID   string `json:"id"` // SPDX license identifier
+	Name string `json:"name"`
+}
+
+// Language describes an entry in the snippet language catalog
+type Language struct {
+	ID        string `json:"id"`
+	Extension string `json:"extension"` // Without the leading dot
 }
 
 // APIToken represents an API token for external access
@@ -131,14 +224,25 @@ type APIToken struct {
 	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
+	Provisioned bool       `json:"provisioned,omitempty"` // Managed by SNIPO_BOOTSTRAP_TOKEN
+
+	AllowedIPs       []string      `json:"allowed_ips,omitempty"`       // CIDR ranges the token may be used from; empty allows any
+	AllowedReferrers []string      `json:"allowed_referrers,omitempty"` // Hosts requests must come from (Origin or Referer); empty allows any
+	Folders          []TokenFolder `json:"folders,omitempty"`           // Folders the token is limited to; empty reaches every folder
+	FolderLimited    bool          `json:"folder_limited,omitempty"`    // Limited to Folders, even once all of them are deleted
+	FolderAccess     FolderAccess  `json:"-"`                           // Role per reachable folder, set when a limited token is validated
 }
 
 // APITokenInput struct here represents input for creating an API token
 type APITokenInput struct {
 	Name          string `json:"name"`
-	Permissions   string `json:"permissions"` // "read", "write", "admin"
+	Permissions   string `json:"permissions"` // "read", "write", "admin", optionally with scopes: "read,backup:run"
 	ExpiresInDays *int   `json:"expires_in_days,omitempty"`
 	Password      string `json:"password,omitempty"` // Required when disable_login is enabled
+
+	AllowedIPs       []string      `json:"allowed_ips,omitempty"`       // Addresses or CIDR ranges, e.g. "203.0.113.0/24"
+	AllowedReferrers []string      `json:"allowed_referrers,omitempty"` // Hosts, e.g. "dash.example.com", "https://*.example.com"
+	Folders          []TokenFolder `json:"folders,omitempty"`           // Limit the token to these folders, each with a role
 }
 
 // Pagination holds pagination info for list responses (ايه ده ؟)
@@ -158,7 +262,7 @@ type SnippetListResponse struct {
 // BackupData represents a complete backup of all data
 type BackupData struct {
 	Version   string    `json:"version"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at"` // Time of the most recent change in the exported data
 	Snippets  []Snippet `json:"snippets"`
 	Tags      []Tag     `json:"tags"`
 	Folders   []Folder  `json:"folders"`
@@ -166,34 +270,127 @@ type BackupData struct {
 
 // ExportOptions configures backup export behavior
 type ExportOptions struct {
-	Format   string `json:"format"`   // "json" or "zip"
-	Password string `json:"password"` // Optional encryption password
+	Format   string `json:"format"`              // "json" or "zip"
+	Password string `json:"password"`            // Optional encryption password
+	FolderID int64  `json:"folder_id,omitempty"` // Only snippets in this folder
+	TagID    int64  `json:"tag_id,omitempty"`    // Only snippets with this tag
+	Redact   bool   `json:"redact,omitempty"`    // Mask with the redaction rules; always on for markdown
+}
+
+// SiteExportOptions configures static site export
+type SiteExportOptions struct {
+	Title string `json:"title"` // Site title (default "Snipo")
+}
+
+// URLImportInput represents a request to import code from a web page
+type URLImportInput struct {
+	URL      string   `json:"url"`
+	Title    string   `json:"title,omitempty"` // Defaults to the page title
+	Tags     []string `json:"tags,omitempty"`
+	FolderID *int64   `json:"folder_id,omitempty"`
+	IsPublic bool     `json:"is_public"`
+	Block    *int     `json:"block,omitempty"` // 1-based index of a single code block to import; all blocks when nil
 }
 
 // ImportOptions configures backup import behavior
 type ImportOptions struct {
 	Strategy string `json:"strategy"` // "replace", "merge", "skip"
 	Password string `json:"password"` // Decryption password if encrypted
+	// Snapshot takes a safety snapshot before any strategy; replace always takes one
+	Snapshot bool `json:"-"`
 }
 
 // ImportResult contains the results of an import operation
 type ImportResult struct {
-	SnippetsImported int      `json:"snippets_imported"`
-	TagsImported     int      `json:"tags_imported"`
-	FoldersImported  int      `json:"folders_imported"`
-	Errors           []string `json:"errors,omitempty"`
+	SnippetsImported   int          `json:"snippets_imported"`
+	TagsImported       int          `json:"tags_imported"`
+	FoldersImported    int          `json:"folders_imported"`
+	Errors             []string     `json:"errors,omitempty"`
+	ChecksumMismatches []string     `json:"checksum_mismatches,omitempty"` // Titles whose content no longer matched the exported checksum
+	SafetySnapshot     string       `json:"safety_snapshot,omitempty"`     // File the previous data was saved to before the import
+	Items              []ImportItem `json:"items,omitempty"`               // What happened to each tag, folder and snippet, in backup order
+}
+
+// Import item kinds
+const (
+	ImportKindTag     = "tag"
+	ImportKindFolder  = "folder"
+	ImportKindSnippet = "snippet"
+)
+
+// Actions an import takes with an item
+const (
+	ImportCreated  = "created"  // Created as a new item
+	ImportExisting = "existing" // Mapped to a local item with the same name
+	ImportSkipped  = "skipped"  // Not imported, a snippet with the title exists
+	ImportFailed   = "failed"   // Could not be created; Reason says why
+)
+
+// ImportItem records what an import did with one item of the backup, so
+// large migrations can be audited and failed items retried
+type ImportItem struct {
+	Kind     string `json:"kind"`
+	Line     int    `json:"line"`                // 1-based position among the backup's items of this kind
+	SourceID string `json:"source_id,omitempty"` // ID in the backup
+	Title    string `json:"title"`               // Snippet title, or tag or folder name
+	Action   string `json:"action"`
+	NewID    string `json:"new_id,omitempty"` // Local ID the item maps to
+	Reason   string `json:"reason,omitempty"`
+}
+
+// IntegrityReport is the result of re-hashing all stored snippets
+type IntegrityReport struct {
+	Checked    int                `json:"checked"`
+	Valid      int                `json:"valid"`
+	Missing    int                `json:"missing"`  // No stored checksum
+	Repaired   int                `json:"repaired"` // Missing checksums filled in
+	Mismatches []ChecksumMismatch `json:"mismatches"`
+	CheckedAt  time.Time          `json:"checked_at"`
+}
+
+// ChecksumMismatch describes a snippet whose content does not match its checksum
+type ChecksumMismatch struct {
+	SnippetID string `json:"snippet_id"`
+	Title     string `json:"title"`
+	Expected  string `json:"expected"` // Stored checksum
+	Actual    string `json:"actual"`   // Checksum of the current content
+}
+
+// BackupManifest summarizes what a backup contains. It is stored with each
+// uploaded backup so it can be shown without downloading or decrypting it.
+type BackupManifest struct {
+	Version    string    `json:"version"`               // Backup format version
+	AppVersion string    `json:"app_version,omitempty"` // Snipo version that created the backup
+	CreatedAt  time.Time `json:"created_at"`
+	Format     string    `json:"format"` // "json" or "zip"
+	Encrypted  bool      `json:"encrypted"`
+	Snippets   int       `json:"snippets"`
+	Tags       int       `json:"tags"`
+	Folders    int       `json:"folders"`
 }
 
 // S3BackupInfo represents info about a backup stored in S3
 type S3BackupInfo struct {
-	Key          string    `json:"key"`
-	Size         int64     `json:"size"`
-	LastModified time.Time `json:"last_modified"`
+	Key          string          `json:"key"`
+	Size         int64           `json:"size"`
+	LastModified time.Time       `json:"last_modified"`
+	Manifest     *BackupManifest `json:"manifest,omitempty"` // Nil for backups uploaded without one
+}
+
+// S3BackupPreview describes a stored backup before it is restored
+type S3BackupPreview struct {
+	Key      string          `json:"key"`
+	Manifest *BackupManifest `json:"manifest,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"` // Reasons to double-check before restoring
 }
 
 // S3SyncResult contains the results of an S3 sync operation
 type S3SyncResult struct {
+	Key        string    `json:"key,omitempty"`
 	Uploaded   int       `json:"uploaded"`
+	Size       int64     `json:"size,omitempty"`
+	ETag       string    `json:"etag,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
 	Errors     []string  `json:"errors,omitempty"`
 	StartedAt  time.Time `json:"started_at"`
 	FinishedAt time.Time `json:"finished_at"`
@@ -201,10 +398,11 @@ type S3SyncResult struct {
 
 // S3RestoreResult contains the results of an S3 restore operation
 type S3RestoreResult struct {
-	Restored   int       `json:"restored"`
-	Errors     []string  `json:"errors,omitempty"`
-	StartedAt  time.Time `json:"started_at"`
-	FinishedAt time.Time `json:"finished_at"`
+	Restored       int       `json:"restored"`
+	SafetySnapshot string    `json:"safety_snapshot,omitempty"` // File the previous data was saved to before the restore
+	Errors         []string  `json:"errors,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
 }
 
 // SnippetHistory represents a historical version of a snippet
@@ -218,11 +416,32 @@ type SnippetHistory struct {
 	IsFavorite  bool               `json:"is_favorite"`
 	IsPublic    bool               `json:"is_public"`
 	IsArchived  bool               `json:"is_archived"`
-	ChangeType  string             `json:"change_type"` // 'create', 'update', 'delete'
+	ChangeType  string             `json:"change_type"` // One of the History* change types
+	Details     string             `json:"details,omitempty"` // New tags or folder of a metadata change
+	ChangedBy   string             `json:"changed_by"`  // API token name or "session"; empty when unknown
+	SourceIP    string             `json:"source_ip"`
 	CreatedAt   time.Time          `json:"created_at"`
 	Files       []SnippetFileHistory `json:"files,omitempty"`
 }
 
+// History change types. Creates and updates snapshot the snippet's content
+// and files; the others record a metadata change without them, with the
+// entry's flags showing the state after the change.
+const (
+	HistoryCreate   = "create"
+	HistoryUpdate   = "update"
+	HistoryFavorite = "favorite"
+	HistoryArchive  = "archive"
+	HistoryTags     = "tags"   // Details lists the new tags
+	HistoryFolder   = "folder" // Details names the new folder, empty when removed from one
+)
+
+// IsMetadataChange reports whether a history entry records a metadata
+// change, which has no content to restore
+func IsMetadataChange(changeType string) bool {
+	return changeType != HistoryCreate && changeType != HistoryUpdate
+}
+
 // SnippetFileHistory represents a historical version of a snippet file
 type SnippetFileHistory struct {
 	ID         int64     `json:"id"`
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultTagColor is the color of tags created without one
+const DefaultTagColor = "#6366f1"
+
+// Text colors a tag's label is drawn in
+const (
+	TagTextDark  = "#000000"
+	TagTextLight = "#ffffff"
+)
+
+// PaletteColor describes an entry in the tag color palette
+type PaletteColor struct {
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	TextColor string `json:"text_color"`
+}
+
+// TagTextColor returns the text color, black or white, with the higher WCAG
+// contrast ratio against a hex background color (#rgb or #rrggbb). Colors
+// that do not parse get white.
+func TagTextColor(color string) string {
+	l, ok := relativeLuminance(color)
+	if !ok {
+		return TagTextLight
+	}
+	// Contrast is (lighter + 0.05) / (darker + 0.05); black is 0 and white 1
+	if (l+0.05)/0.05 >= 1.05/(l+0.05) {
+		return TagTextDark
+	}
+	return TagTextLight
+}
+
+// relativeLuminance returns the WCAG 2 relative luminance of a hex color
+func relativeLuminance(color string) (float64, bool) {
+	hex, ok := strings.CutPrefix(color, "#")
+	if !ok {
+		return 0, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, false
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	channel := func(v uint64) float64 {
+		c := float64(v) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(rgb>>16&0xff) + 0.7152*channel(rgb>>8&0xff) + 0.0722*channel(rgb&0xff), true
+}
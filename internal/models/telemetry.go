@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TelemetryReport is the anonymous report sent when telemetry is enabled.
+// It holds no content, names, addresses or identifiers, so reports from
+// one instance cannot be told apart from another's.
+type TelemetryReport struct {
+	Version  string   `json:"version"`
+	Platform string   `json:"platform"` // GOOS/GOARCH
+	Snippets string   `json:"snippets"` // Snippet count bucket, such as "11-100"
+	Features []string `json:"features"` // Optional features turned on, sorted
+}
+
+// TelemetryStatus is the telemetry preview shown to admins: the report as
+// it would be sent now and whether it is sent at all
+type TelemetryStatus struct {
+	Enabled    bool            `json:"enabled"`
+	Blocked    bool            `json:"blocked"` // Turned off by SNIPO_TELEMETRY_DISABLED or DO_NOT_TRACK
+	URL        string          `json:"url,omitempty"`
+	LastSentAt *time.Time      `json:"last_sent_at,omitempty"`
+	Error      string          `json:"error,omitempty"` // Why the last send failed, if it did
+	Report     TelemetryReport `json:"report"`
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// MaxTokenRestrictions is the most allowed IPs or referrers a token can list
+const MaxTokenRestrictions = 50
+
+// TokenClient is where a request using an API token comes from
+type TokenClient struct {
+	IP       string // Client address
+	Referrer string // Origin header, or the Referer header without one
+}
+
+// NormalizeAllowedIPs validates a token's allowed IPs, each an address or
+// a CIDR range, and returns them as CIDR ranges
+func NormalizeAllowedIPs(entries []string) ([]string, error) {
+	if len(entries) > MaxTokenRestrictions {
+		return nil, fmt.Errorf("at most %d allowed IPs", MaxTokenRestrictions)
+	}
+	var normalized []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := parseIPRange(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP or CIDR range %q", entry)
+		}
+		if !slices.Contains(normalized, prefix.String()) {
+			normalized = append(normalized, prefix.String())
+		}
+	}
+	return normalized, nil
+}
+
+// NormalizeAllowedReferrers validates a token's allowed referrers and
+// returns them in lower case. Each is a host such as "dash.example.com",
+// optionally with a scheme and port ("https://dash.example.com:8443"); a
+// leading "*." matches any subdomain.
+func NormalizeAllowedReferrers(entries []string) ([]string, error) {
+	if len(entries) > MaxTokenRestrictions {
+		return nil, fmt.Errorf("at most %d allowed referrers", MaxTokenRestrictions)
+	}
+	var normalized []string
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		scheme, host := parseReferrerPattern(entry)
+		u, err := url.Parse("//" + host)
+		if err != nil || host == "" || u.Host != host || u.Hostname() == "" || strings.Contains(strings.TrimPrefix(u.Hostname(), "*."), "*") {
+			return nil, fmt.Errorf("invalid referrer %q", entry)
+		}
+		if scheme != "" {
+			host = scheme + "://" + host
+		}
+		if !slices.Contains(normalized, host) {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized, nil
+}
+
+// TokenFolder grants a token a role on a folder and its subfolders
+type TokenFolder struct {
+	FolderID int64  `json:"folder_id"`
+	Role     string `json:"role"` // viewer or editor
+}
+
+// NormalizeTokenFolders validates a token's folder grants against its
+// normalized permissions, keeping the stronger role for a folder listed
+// twice. A token limited to folders holds read or write and no scopes, as
+// settings, tokens and backups span every folder; editor grants need write.
+func NormalizeTokenFolders(permissions string, folders []TokenFolder) ([]TokenFolder, error) {
+	if len(folders) == 0 {
+		return nil, nil
+	}
+	if len(folders) > MaxTokenRestrictions {
+		return nil, fmt.Errorf("at most %d folders", MaxTokenRestrictions)
+	}
+	if permissions != PermissionRead && permissions != PermissionWrite {
+		return nil, fmt.Errorf("tokens limited to folders must have read or write permissions, got %q", permissions)
+	}
+
+	var normalized []TokenFolder
+	for _, f := range folders {
+		role := strings.ToLower(strings.TrimSpace(f.Role))
+		switch {
+		case f.FolderID <= 0:
+			return nil, fmt.Errorf("invalid folder ID %d", f.FolderID)
+		case role != RoleViewer && role != RoleEditor:
+			return nil, fmt.Errorf("folder role must be viewer or editor, got %q", f.Role)
+		case role == RoleEditor && permissions != PermissionWrite:
+			return nil, errors.New("editor folders need write permissions")
+		}
+		i := slices.IndexFunc(normalized, func(n TokenFolder) bool { return n.FolderID == f.FolderID })
+		if i < 0 {
+			normalized = append(normalized, TokenFolder{FolderID: f.FolderID, Role: role})
+		} else if role == RoleEditor {
+			normalized[i].Role = RoleEditor
+		}
+	}
+	return normalized, nil
+}
+
+// FolderAccess holds the role a token limited to folders has on each
+// folder it reaches, subfolders included. A nil FolderAccess means the
+// token is not limited to folders.
+type FolderAccess map[int64]string
+
+// Allows reports whether role is held on any of folderIDs; editors may
+// also view
+func (a FolderAccess) Allows(folderIDs []int64, role string) bool {
+	if a == nil {
+		return true
+	}
+	for _, id := range folderIDs {
+		if granted, ok := a[id]; ok && (granted == role || granted == RoleEditor) {
+			return true
+		}
+	}
+	return false
+}
+
+// FolderIDs returns the folders on which role is held, sorted
+func (a FolderAccess) FolderIDs(role string) []int64 {
+	ids := make([]int64, 0, len(a))
+	for id := range a {
+		if a.Allows([]int64{id}, role) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// AllowsClient reports whether the token may be used by client. Tokens
+// without restrictions may be used from anywhere; a token restricted to
+// referrers refuses requests that carry neither Origin nor Referer.
+func (t *APIToken) AllowsClient(client TokenClient) bool {
+	return t.allowsIP(client.IP) && t.allowsReferrer(client.Referrer)
+}
+
+func (t *APIToken) allowsIP(ip string) bool {
+	if len(t.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range t.AllowedIPs {
+		if prefix, err := parseIPRange(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *APIToken) allowsReferrer(referrer string) bool {
+	if len(t.AllowedReferrers) == 0 {
+		return true
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme, host, port := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port()
+
+	for _, entry := range t.AllowedReferrers {
+		patternScheme, patternHost := parseReferrerPattern(entry)
+		if patternScheme != "" && patternScheme != scheme {
+			continue
+		}
+		pattern, err := url.Parse("//" + patternHost)
+		if err != nil {
+			continue
+		}
+		// A pattern without a port matches any port
+		if pattern.Port() != "" && pattern.Port() != port {
+			continue
+		}
+		name := pattern.Hostname()
+		if suffix, ok := strings.CutPrefix(name, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPRange parses an address (as a single-address range) or a CIDR range
+func parseIPRange(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parseReferrerPattern splits an allowed referrer into its optional scheme
+// and its host (with any port)
+func parseReferrerPattern(entry string) (scheme, host string) {
+	if s, rest, ok := strings.Cut(entry, "://"); ok {
+		return s, strings.TrimSuffix(rest, "/")
+	}
+	return "", strings.TrimSuffix(entry, "/")
+}
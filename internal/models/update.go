@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UpdateStatus is the outcome of the latest check for a newer Snipo release
+type UpdateStatus struct {
+	CurrentVersion  string     `json:"current_version"`
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	UpdateAvailable bool       `json:"update_available"`
+	ReleaseURL      string     `json:"release_url,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	CheckedAt       *time.Time `json:"checked_at,omitempty"` // Last successful check; nil before the first one
+	Error           string     `json:"error,omitempty"`      // Why the last check failed, if it did
+}
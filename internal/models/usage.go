@@ -0,0 +1,19 @@
+package models
+
+// Snippet usage events. Views are recorded when a snippet is opened and
+// copies when the web UI or a client reports one; both feed the frecency
+// sort, with copies weighing more.
+const (
+	UsageView = "view"
+	UsageCopy = "copy"
+)
+
+// IsUsageEvent reports whether s is a known usage event
+func IsUsageEvent(s string) bool {
+	return s == UsageView || s == UsageCopy
+}
+
+// UsageInput reports a usage event for a snippet
+type UsageInput struct {
+	Event string `json:"event"`
+}
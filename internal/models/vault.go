@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Vault conflict strategies: which side wins when a note and its snippet
+// both changed since the last sync
+const (
+	VaultConflictNewest = "newest" // The more recently modified side
+	VaultConflictVault  = "vault"  // The note
+	VaultConflictSnipo  = "snipo"  // The snippet
+	VaultConflictSkip   = "skip"   // Neither; the conflict is reported on every sync
+)
+
+// VaultNote links a vault note to a snippet and records both as they were
+// after the last sync, so later syncs can tell which side changed
+type VaultNote struct {
+	Path            string     `json:"path"`
+	SnippetID       string     `json:"snippet_id"`
+	NoteModTime     *time.Time `json:"note_mtime,omitempty"` // nil when the vault does not report one
+	NoteChecksum    string     `json:"note_checksum"`
+	SnippetChecksum string     `json:"snippet_checksum"`
+	SyncedAt        time.Time  `json:"synced_at"`
+}
+
+// VaultConflict is a note and snippet that both changed since the last sync
+type VaultConflict struct {
+	Path      string `json:"path"`
+	SnippetID string `json:"snippet_id"`
+	Winner    string `json:"winner"` // "vault", "snipo" or "" when skipped
+}
+
+// VaultSyncResult contains the results of an Obsidian vault sync
+type VaultSyncResult struct {
+	SnippetsCreated int             `json:"snippets_created"`
+	SnippetsUpdated int             `json:"snippets_updated"`
+	SnippetsDeleted int             `json:"snippets_deleted"`
+	NotesWritten    int             `json:"notes_written"`
+	NotesDeleted    int             `json:"notes_deleted"`
+	NotesMoved      int             `json:"notes_moved"`
+	Unchanged       int             `json:"unchanged"`
+	Skipped         []string        `json:"skipped,omitempty"` // Notes without code blocks
+	Conflicts       []VaultConflict `json:"conflicts,omitempty"`
+	Errors          []string        `json:"errors,omitempty"`
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      time.Time       `json:"finished_at"`
+}
+
+// VaultStatus describes the configured vault sync
+type VaultStatus struct {
+	Enabled   bool             `json:"enabled"`
+	Location  string           `json:"location,omitempty"`
+	Schedule  string           `json:"schedule,omitempty"` // Interval between syncs, empty when unscheduled
+	Conflicts string           `json:"conflicts,omitempty"`
+	Notes     int              `json:"notes"` // Notes linked to snippets
+	LastSync  *VaultSyncResult `json:"last_sync,omitempty"`
+}
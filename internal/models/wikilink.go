@@ -0,0 +1,23 @@
+package models
+
+// ResolveLinksInput asks for the snippets behind wiki-style [[Title]]
+// references, given as titles, as markdown content containing them or both
+type ResolveLinksInput struct {
+	Titles      []string `json:"titles"`
+	Content     string   `json:"content"`
+	CreateStubs bool     `json:"create_stubs"` // Create markdown snippets for unknown titles
+}
+
+// ResolvedLink maps a referenced title to its snippet
+type ResolvedLink struct {
+	Title     string  `json:"title"` // As referenced
+	SnippetID string  `json:"snippet_id"`
+	Slug      *string `json:"slug,omitempty"`
+	Created   bool    `json:"created"` // A stub was created for it
+}
+
+// ResolveLinksResult lists the resolved titles and those without a snippet
+type ResolveLinksResult struct {
+	Links      []ResolvedLink `json:"links"`
+	Unresolved []string       `json:"unresolved"`
+}
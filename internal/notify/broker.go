@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// brokerTimeout bounds connecting to a broker and each publish
+const brokerTimeout = 10 * time.Second
+
+// Publisher publishes events to a message broker over a connection it keeps
+// open between events
+type Publisher interface {
+	Notifier
+	Close() error
+}
+
+// NewBroker creates a publisher for a broker URL: mqtt:// or mqtts:// for
+// an MQTT 3.1.1 broker, nats:// or nats+tls:// for NATS. Credentials are
+// taken from the URL's user info; a NATS user without a password is sent
+// as a token. Events are published as JSON to "<prefix>/snippet/created"
+// on MQTT and "<prefix>.snippet.created" on NATS.
+func NewBroker(rawURL, prefix string) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("broker URL %q has no host", rawURL)
+	}
+	if err := ValidateTopicPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "mqtt":
+		m := &MQTT{clientID: "snipo-" + randomHex(6)}
+		m.init(u, prefix, "1883", false)
+		return m, nil
+	case "mqtts":
+		m := &MQTT{clientID: "snipo-" + randomHex(6)}
+		m.init(u, prefix, "8883", true)
+		return m, nil
+	case "nats", "nats+tls":
+		n := &NATS{}
+		n.init(u, prefix, "4222", u.Scheme == "nats+tls")
+		return n, nil
+	}
+	return nil, fmt.Errorf("unsupported broker scheme %q: use mqtt, mqtts, nats or nats+tls", u.Scheme)
+}
+
+// ValidateTopicPrefix checks that a prefix can start MQTT topics and NATS
+// subjects: no wildcards or whitespace, and no empty levels
+func ValidateTopicPrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("topic prefix is required")
+	}
+	if strings.ContainsAny(prefix, "+#*> \t\r\n") {
+		return fmt.Errorf("topic prefix %q must not contain wildcards or whitespace", prefix)
+	}
+	if strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, ".") ||
+		strings.HasSuffix(prefix, "/") || strings.HasSuffix(prefix, ".") ||
+		strings.Contains(prefix, "//") || strings.Contains(prefix, "..") {
+		return fmt.Errorf("topic prefix %q has an empty level", prefix)
+	}
+	return nil
+}
+
+// broker holds what the MQTT and NATS publishers share: the address,
+// credentials and the connection, which is reopened after a failure
+type broker struct {
+	addr   string
+	host   string
+	tls    bool
+	user   string
+	pass   string
+	prefix string
+
+	mu   sync.Mutex
+	conn *brokerConn
+}
+
+// brokerConn is a connection with a reader that buffers across publishes
+type brokerConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *broker) init(u *url.URL, prefix, defaultPort string, tls bool) {
+	b.addr = hostPort(u, defaultPort)
+	b.host = u.Hostname()
+	b.tls = tls
+	b.user = u.User.Username()
+	b.pass, _ = u.User.Password()
+	b.prefix = prefix
+}
+
+// dial opens a connection, with TLS from the start when tls is set
+func (b *broker) dial(ctx context.Context, tls bool) (*brokerConn, error) {
+	dialer := &net.Dialer{Timeout: brokerTimeout}
+	var conn net.Conn
+	var err error
+	if tls {
+		conn, err = (&cryptotls.Dialer{NetDialer: dialer, Config: b.tlsConfig()}).DialContext(ctx, "tcp", b.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", b.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(deadline(ctx))
+	return &brokerConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (b *broker) tlsConfig() *cryptotls.Config {
+	return &cryptotls.Config{ServerName: b.host, MinVersion: cryptotls.VersionTLS12}
+}
+
+// publish runs send over the open connection, connecting first when there
+// is none. A failure on a connection that was already open is retried once
+// on a new one, since brokers drop idle clients.
+func (b *broker) publish(ctx context.Context, connect func(ctx context.Context) (*brokerConn, error), send func(conn *brokerConn) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		reused := b.conn != nil
+		if b.conn == nil {
+			conn, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			b.conn = conn
+		}
+
+		_ = b.conn.SetDeadline(deadline(ctx))
+		err := send(b.conn)
+		if err == nil {
+			return nil
+		}
+		_ = b.conn.Close()
+		b.conn = nil
+		if !reused || attempt > 0 {
+			return err
+		}
+	}
+}
+
+// close closes the connection if it is open, first sending goodbye
+func (b *broker) close(goodbye []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	_ = b.conn.SetDeadline(time.Now().Add(time.Second))
+	if len(goodbye) > 0 {
+		_, _ = b.conn.Write(goodbye)
+	}
+	err := b.conn.Close()
+	b.conn = nil
+	return err
+}
+
+// deadline returns the context's deadline, capped at brokerTimeout from now
+func deadline(ctx context.Context) time.Time {
+	d := time.Now().Add(brokerTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(d) {
+		return ctxDeadline
+	}
+	return d
+}
+
+// hostPort returns the URL's host and port, or defaultPort if it has none
+func hostPort(u *url.URL, defaultPort string) string {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBroker accepts connections and hands each to serve
+func fakeBroker(t *testing.T, serve func(conn net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				serve(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func testEvent() Event {
+	return Event{
+		Type:    EventSnippetCreated,
+		Title:   "Snippet created",
+		Message: "hello",
+		Fields:  map[string]string{"snippet_id": "abc"},
+		Time:    time.Now().UTC(),
+	}
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+func TestMQTT_Notify(t *testing.T) {
+	messages := make(chan mqttMessage, 4)
+	connects := make(chan []string, 4)
+	addr := fakeBroker(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			kind, body, err := readMQTTPacket(r)
+			if err != nil {
+				return
+			}
+			switch kind {
+			case mqttConnect:
+				// Protocol name, level, flags and keepalive precede the payload
+				rest := body[10:]
+				var fields []string
+				for len(rest) >= 2 {
+					n := int(binary.BigEndian.Uint16(rest))
+					fields = append(fields, string(rest[2:2+n]))
+					rest = rest[2+n:]
+				}
+				connects <- fields
+				_, _ = conn.Write(mqttPacket(mqttConnack<<4, []byte{0, 0}))
+			case mqttPublish:
+				n := int(binary.BigEndian.Uint16(body))
+				topic := string(body[2 : 2+n])
+				id := body[2+n : 4+n]
+				messages <- mqttMessage{topic: topic, payload: body[4+n:]}
+				_, _ = conn.Write(mqttPacket(mqttPuback<<4, id))
+			case mqttDisconnect:
+				return
+			}
+		}
+	})
+
+	p, err := NewBroker("mqtt://alice:s3cret@"+addr, "home/snipo")
+	if err != nil {
+		t.Fatalf("NewBroker failed: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	for range 2 {
+		if err := p.Notify(context.Background(), testEvent()); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+
+	if len(connects) != 1 {
+		t.Fatalf("expected the connection to be reused, got %d connects", len(connects))
+	}
+	// Client ID, user name and password
+	if fields := <-connects; len(fields) != 3 || fields[1] != "alice" || fields[2] != "s3cret" {
+		t.Errorf("expected credentials alice/s3cret, got %q", fields)
+	}
+	msg := <-messages
+	if msg.topic != "home/snipo/snippet/created" {
+		t.Errorf("unexpected topic %q", msg.topic)
+	}
+	var decoded Event
+	if err := json.Unmarshal(msg.payload, &decoded); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if decoded.Fields["snippet_id"] != "abc" {
+		t.Errorf("expected snippet_id field, got %v", decoded.Fields)
+	}
+}
+
+func TestMQTT_NotifyRefused(t *testing.T) {
+	addr := fakeBroker(t, func(conn net.Conn) {
+		if _, _, err := readMQTTPacket(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		// Bad user name or password
+		_, _ = conn.Write(mqttPacket(mqttConnack<<4, []byte{0, 4}))
+	})
+
+	p, err := NewBroker("mqtt://"+addr, "snipo")
+	if err != nil {
+		t.Fatalf("NewBroker failed: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	err = p.Notify(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "return code 4") {
+		t.Errorf("expected refused connection, got %v", err)
+	}
+}
+
+func TestNATS_Notify(t *testing.T) {
+	connects := make(chan string, 4)
+	subjects := make(chan string, 4)
+	addr := fakeBroker(t, func(conn net.Conn) {
+		_, _ = io.WriteString(conn, `INFO {"server_id":"test","max_payload":1048576}`+"\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := readNATSLine(r)
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connects <- strings.TrimPrefix(line, "CONNECT ")
+			case line == "PING":
+				// The client must answer server pings while it waits
+				_, _ = io.WriteString(conn, "PING\r\n+OK\r\nPONG\r\n")
+			case strings.HasPrefix(line, "PUB "):
+				parts := strings.Fields(line)
+				n, _ := strconv.Atoi(parts[2])
+				payload := make([]byte, n+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				subjects <- parts[1]
+			}
+		}
+	})
+
+	p, err := NewBroker("nats://tok3n@"+addr, "snipo.events")
+	if err != nil {
+		t.Fatalf("NewBroker failed: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	for range 2 {
+		if err := p.Notify(context.Background(), testEvent()); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+
+	if len(connects) != 1 {
+		t.Fatalf("expected the connection to be reused, got %d connects", len(connects))
+	}
+	var opts map[string]any
+	if err := json.Unmarshal([]byte(<-connects), &opts); err != nil {
+		t.Fatalf("invalid CONNECT: %v", err)
+	}
+	if opts["auth_token"] != "tok3n" {
+		t.Errorf("expected auth token, got %v", opts)
+	}
+	if subject := <-subjects; subject != "snipo.events.snippet.created" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+}
+
+func TestNATS_NotifyServerError(t *testing.T) {
+	addr := fakeBroker(t, func(conn net.Conn) {
+		_, _ = io.WriteString(conn, "INFO {}\r\n")
+		_, _ = readNATSLine(bufio.NewReader(conn))
+		_, _ = io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+	})
+
+	p, err := NewBroker(fmt.Sprintf("nats://%s", addr), "snipo")
+	if err != nil {
+		t.Fatalf("NewBroker failed: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	err = p.Notify(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("expected server error, got %v", err)
+	}
+}
+
+func TestNewBroker_Invalid(t *testing.T) {
+	tests := []struct {
+		url, prefix string
+	}{
+		{"amqp://localhost", "snipo"},
+		{"mqtt://", "snipo"},
+		{"mqtt://localhost", ""},
+		{"mqtt://localhost", "snipo/#"},
+		{"nats://localhost", "snipo.>"},
+		{"nats://localhost", "snipo..events"},
+		{"mqtt://localhost", "snipo/"},
+	}
+	for _, tt := range tests {
+		if _, err := NewBroker(tt.url, tt.prefix); err == nil {
+			t.Errorf("NewBroker(%q, %q): expected error", tt.url, tt.prefix)
+		}
+	}
+}
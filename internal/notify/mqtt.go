@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MQTT 3.1.1 packet types, as the high nibble of the first header byte
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttDisconnect = 14
+)
+
+// mqttMaxPacket bounds packets read from the broker, which only sends acks
+const mqttMaxPacket = 64 * 1024
+
+// MQTT publishes events to an MQTT 3.1.1 broker at QoS 1
+type MQTT struct {
+	broker
+	clientID string
+	packetID uint16
+}
+
+// Notify publishes the event to <prefix>/<type>, with the dots of the
+// event type as topic levels, and waits for the broker to acknowledge it
+func (m *MQTT) Notify(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	topic := m.prefix + "/" + strings.ReplaceAll(event.Type, ".", "/")
+
+	err = m.publish(ctx, m.connect, func(conn *brokerConn) error {
+		m.packetID++
+		if m.packetID == 0 {
+			m.packetID = 1
+		}
+		id := m.packetID
+
+		var body []byte
+		body = mqttString(body, topic)
+		body = binary.BigEndian.AppendUint16(body, id)
+		body = append(body, payload...)
+		// QoS 1
+		if _, err := conn.Write(mqttPacket(mqttPublish<<4|0x02, body)); err != nil {
+			return err
+		}
+
+		for {
+			kind, body, err := readMQTTPacket(conn.r)
+			if err != nil {
+				return err
+			}
+			if kind == mqttPuback && len(body) >= 2 && binary.BigEndian.Uint16(body) == id {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to MQTT broker: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects from the broker
+func (m *MQTT) Close() error {
+	return m.close(mqttPacket(mqttDisconnect<<4, nil))
+}
+
+// connect opens a clean session
+func (m *MQTT) connect(ctx context.Context) (*brokerConn, error) {
+	conn, err := m.dial(ctx, m.tls)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags byte = 0x02 // Clean session
+	var body []byte
+	body = mqttString(body, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+	body = append(body, 0) // Flags, set below
+	body = append(body, 0, 0)
+	body = mqttString(body, m.clientID)
+	if m.user != "" {
+		flags |= 0x80
+		body = mqttString(body, m.user)
+		if m.pass != "" {
+			flags |= 0x40
+			body = mqttString(body, m.pass)
+		}
+	}
+	body[7] = flags
+
+	if _, err := conn.Write(mqttPacket(mqttConnect<<4, body)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	kind, ack, err := readMQTTPacket(conn.r)
+	if err == nil && (kind != mqttConnack || len(ack) < 2) {
+		err = errors.New("unexpected reply to CONNECT")
+	}
+	if err == nil && ack[1] != 0 {
+		err = fmt.Errorf("broker refused connection (return code %d)", ack[1])
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// mqttPacket frames a packet body with its fixed header
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString appends a length-prefixed UTF-8 string
+func mqttString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// readMQTTPacket reads one packet, returning its type and body
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+	}
+	if n > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("packet of %d bytes is too large", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	cryptotls "crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// natsMaxLine bounds protocol lines read from the server
+const natsMaxLine = 64 * 1024
+
+// NATS publishes events to a NATS server. Each publish is followed by a
+// PING so that it returns once the server has processed the message.
+type NATS struct {
+	broker
+}
+
+// Notify publishes the event to <prefix>.<type>
+func (n *NATS) Notify(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	subject := n.prefix + "." + event.Type
+
+	err = n.publish(ctx, n.connect, func(conn *brokerConn) error {
+		msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			return err
+		}
+		return waitPong(conn)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to NATS server: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection
+func (n *NATS) Close() error {
+	return n.close(nil)
+}
+
+// connect reads the server's INFO, upgrades to TLS when configured or
+// required, and authenticates
+func (n *NATS) connect(ctx context.Context) (*brokerConn, error) {
+	conn, err := n.dial(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err = n.handshake(ctx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake returns the connection to use from then on, which differs from
+// conn after a TLS upgrade
+func (n *NATS) handshake(ctx context.Context, conn *brokerConn) (*brokerConn, error) {
+	line, err := readNATSLine(conn.r)
+	if err != nil {
+		return conn, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return conn, fmt.Errorf("unexpected greeting %q", line)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return conn, fmt.Errorf("invalid INFO: %w", err)
+	}
+
+	if n.tls || info.TLSRequired {
+		tlsConn := cryptotls.Client(conn.Conn, n.tlsConfig())
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return conn, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = &brokerConn{Conn: tlsConn, r: bufio.NewReader(tlsConn)}
+	}
+
+	opts := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "snipo",
+		"lang":     "go",
+		"protocol": 1,
+	}
+	switch {
+	case n.user != "" && n.pass != "":
+		opts["user"] = n.user
+		opts["pass"] = n.pass
+	case n.user != "":
+		opts["auth_token"] = n.user
+	}
+	connect, err := json.Marshal(opts)
+	if err != nil {
+		return conn, err
+	}
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		return conn, err
+	}
+	return conn, waitPong(conn)
+}
+
+// waitPong reads until the server's PONG, answering its PINGs on the way
+func waitPong(conn *brokerConn) error {
+	for {
+		line, err := readNATSLine(conn.r)
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+		// +OK and INFO updates need no reply
+	}
+}
+
+// readNATSLine reads one CRLF-terminated protocol line
+func readNATSLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > natsMaxLine {
+			return "", errors.New("protocol line too long")
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package notify delivers operational alerts (such as suspicious login
+// activity) to external channels and the in-app notification center.
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Event types
+const (
+	EventLoginFailures    = "login.failures"
+	EventLoginNewIP       = "login.new_ip"
+	EventBackupSucceeded  = "backup.succeeded"
+	EventBackupFailed     = "backup.failed"
+	EventImportFinished   = "import.finished"
+	EventImportFailed     = "import.failed"
+	EventQuotaWarning     = "quota.warning"
+	EventShareAccessed    = "share.accessed"
+	EventReviewRequested  = "review.requested"
+	EventReviewApproved   = "review.approved"
+	EventReviewRejected   = "review.rejected"
+	EventSnippetCreated   = "snippet.created"
+	EventSnippetUpdated   = "snippet.updated"
+	EventSnippetDeleted   = "snippet.deleted"
+	EventSnippetPublished = "snippet.published"
+	EventSnippetReported  = "snippet.reported"
+	EventUpdateAvailable  = "update.available"
+	EventTest             = "test"
+)
+
+// Event is an alert delivered to notifiers
+type Event struct {
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// Notifier delivers events to a single channel
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans an event out to several notifiers
+type Multi []Notifier
+
+// Notify sends the event to every notifier and joins any errors
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP TLS modes
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// SMTPConfig holds mail server settings
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLSMode  string // starttls (default), tls, or none
+}
+
+// SMTP sends events as plain-text email
+type SMTP struct {
+	cfg     SMTPConfig
+	timeout time.Duration
+}
+
+// NewSMTP creates an SMTP notifier
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if cfg.From == "" {
+		return nil, errors.New("smtp from address is required")
+	}
+	if len(cfg.To) == 0 {
+		return nil, errors.New("at least one smtp recipient is required")
+	}
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = SMTPTLSStartTLS
+	}
+	switch cfg.TLSMode {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid smtp tls mode %q: must be starttls, tls or none", cfg.TLSMode)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLSMode == SMTPTLSImplicit {
+			cfg.Port = 465
+		}
+	}
+
+	return &SMTP{cfg: cfg, timeout: 30 * time.Second}, nil
+}
+
+// Recipients returns the configured recipient addresses
+func (s *SMTP) Recipients() []string {
+	return append([]string(nil), s.cfg.To...)
+}
+
+// Notify emails the event to the configured recipients
+func (s *SMTP) Notify(ctx context.Context, event Event) error {
+	return s.Send(ctx, "[Snipo] "+event.Title, FormatText(event))
+}
+
+// Send delivers a plain-text message to the configured recipients
+func (s *SMTP) Send(ctx context.Context, subject, body string) error {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.cfg.TLSMode == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if s.cfg.TLSMode == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	for _, to := range s.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA rejected: %w", err)
+	}
+	if _, err := w.Write(buildMessage(s.cfg.From, s.cfg.To, subject, body, time.Now())); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// FormatText renders an event as a plain-text message body
+func FormatText(event Event) string {
+	var b strings.Builder
+	b.WriteString(event.Message)
+	b.WriteString("\n\n")
+
+	if len(event.Fields) > 0 {
+		keys := make([]string, 0, len(event.Fields))
+		for k := range event.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s: %s\n", k, event.Fields[k])
+		}
+		b.WriteString("\n")
+	}
+
+	if !event.Time.IsZero() {
+		fmt.Fprintf(&b, "time: %s\n", event.Time.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "event: %s\n", event.Type)
+	return b.String()
+}
+
+// buildMessage assembles RFC 5322 headers and a CRLF-normalized body
+func buildMessage(from string, to []string, subject, body string, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", sanitizeHeader(from))
+	fmt.Fprintf(&b, "To: %s\r\n", sanitizeHeader(strings.Join(to, ", ")))
+	// Snippet titles may be non-ASCII, which headers must carry as encoded words
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", sanitizeHeader(subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		// Dot-stuffing is handled by the smtp package's DATA writer
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
+
+// sanitizeHeader strips line breaks to prevent header injection
+func sanitizeHeader(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
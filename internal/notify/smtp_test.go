@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSMTP_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SMTPConfig
+		wantErr bool
+	}{
+		{"valid", SMTPConfig{Host: "mail.example.com", From: "snipo@example.com", To: []string{"me@example.com"}}, false},
+		{"missing host", SMTPConfig{From: "snipo@example.com", To: []string{"me@example.com"}}, true},
+		{"missing from", SMTPConfig{Host: "mail.example.com", To: []string{"me@example.com"}}, true},
+		{"missing recipients", SMTPConfig{Host: "mail.example.com", From: "snipo@example.com"}, true},
+		{"bad tls mode", SMTPConfig{Host: "mail.example.com", From: "snipo@example.com", To: []string{"me@example.com"}, TLSMode: "ssl"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSMTP(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSMTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSMTP_DefaultPort(t *testing.T) {
+	s, err := NewSMTP(SMTPConfig{Host: "mail.example.com", From: "a@example.com", To: []string{"b@example.com"}, TLSMode: SMTPTLSImplicit})
+	if err != nil {
+		t.Fatalf("NewSMTP failed: %v", err)
+	}
+	if s.cfg.Port != 465 {
+		t.Errorf("expected implicit TLS default port 465, got %d", s.cfg.Port)
+	}
+}
+
+func TestBuildMessage_SanitizesHeaders(t *testing.T) {
+	msg := string(buildMessage("snipo@example.com", []string{"me@example.com"}, "Hello\r\nBcc: evil@example.com", "line one\nline two", time.Unix(0, 0)))
+
+	headers, body, ok := strings.Cut(msg, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message has no header/body separator: %q", msg)
+	}
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("header injection not prevented: %q", headers)
+	}
+	if !strings.Contains(headers, "Subject: HelloBcc: evil@example.com") {
+		t.Errorf("unexpected subject header: %q", headers)
+	}
+	if body != "line one\r\nline two\r\n" {
+		t.Errorf("expected CRLF-normalized body, got %q", body)
+	}
+}
+
+func TestBuildMessage_EncodesSubject(t *testing.T) {
+	msg := string(buildMessage("snipo@example.com", []string{"me@example.com"}, "[Snipo] Snippet viewed: Übersicht – 日本語", "body", time.Unix(0, 0)))
+
+	headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+	var subject string
+	for _, line := range strings.Split(headers, "\r\n") {
+		if v, ok := strings.CutPrefix(line, "Subject: "); ok {
+			subject = v
+		}
+	}
+	for _, r := range subject {
+		if r > 127 {
+			t.Fatalf("expected an ASCII subject header, got %q", subject)
+		}
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil {
+		t.Fatalf("failed to decode subject %q: %v", subject, err)
+	}
+	if decoded != "[Snipo] Snippet viewed: Übersicht – 日本語" {
+		t.Errorf("expected the title back, got %q", decoded)
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	text := FormatText(Event{
+		Type:    EventBackupFailed,
+		Message: "S3 backup sync failed",
+		Fields:  map[string]string{"key": "backup.json", "bucket": "snipo"},
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+
+	for _, want := range []string{"S3 backup sync failed", "bucket: snipo\nkey: backup.json", "time: 2024-01-02T03:04:05Z", "event: " + EventBackupFailed} {
+		if !strings.Contains(text, want) {
+			t.Errorf("FormatText() missing %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer func() {
+		_ = ln.Close()
+	}()
+
+	received := make(chan string, 1)
+	go serveFakeSMTP(ln, received)
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	s, err := NewSMTP(SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		From:    "snipo@example.com",
+		To:      []string{"me@example.com"},
+		TLSMode: SMTPTLSNone,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTP failed: %v", err)
+	}
+
+	if err := s.Notify(context.Background(), Event{Type: EventTest, Title: "Test email", Message: "hello"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "Subject: [Snipo] Test email") || !strings.Contains(data, "hello") {
+			t.Errorf("unexpected message data: %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fake server received no message")
+	}
+}
+
+// serveFakeSMTP accepts one connection and speaks just enough SMTP to receive a message
+func serveFakeSMTP(ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) {
+		_, _ = conn.Write([]byte(s + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			received <- data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	var (
+		gotBody      []byte
+		gotSignature string
+		gotEvent     string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-Snipo-Signature")
+		gotEvent = r.Header.Get("X-Snipo-Event")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := Event{
+		Type:    EventLoginFailures,
+		Title:   "Repeated failed logins",
+		Message: "5 failed attempts",
+		Fields:  map[string]string{"ip": "203.0.113.7"},
+		Time:    time.Now().UTC(),
+	}
+
+	if err := NewWebhook(server.URL, "s3cret").Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if gotEvent != EventLoginFailures {
+		t.Errorf("expected event header %q, got %q", EventLoginFailures, gotEvent)
+	}
+	if want := "sha256=" + Sign("s3cret", gotBody); gotSignature != want {
+		t.Errorf("expected signature %q, got %q", want, gotSignature)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(gotBody, &decoded); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if decoded.Fields["ip"] != "203.0.113.7" {
+		t.Errorf("expected ip field, got %v", decoded.Fields)
+	}
+}
+
+func TestWebhook_NotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(server.URL, "").Notify(context.Background(), Event{Type: EventLoginNewIP}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,146 @@
+package ogimage
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Code is a screenshot-style image of code: numbered lines in a window
+// with a title bar, on a background
+type Code struct {
+	Title       string   // Shown in the title bar
+	Lines       [][]Span // One slice per line; long lines wrap
+	Theme       Theme
+	Width       int // In pixels, kept between MinWidth and MaxWidth; zero for DefaultWidth
+	LineNumbers bool
+}
+
+// Widths of code images; the height follows from the lines
+const (
+	MinWidth     = 400
+	MaxWidth     = 2000
+	DefaultWidth = 1000
+)
+
+// MaxCodeLines is the number of rows drawn on a code image, counting
+// wrapped ones. Lines past it are left out with a note.
+const MaxCodeLines = 100
+
+// layout of code images, in image pixels
+const (
+	codeScale      = 2
+	codeAdvance    = (glyphWidth + 1) * codeScale
+	codeLineHeight = 24
+	outerPad       = 48
+	windowPad      = 24
+	titleBar       = 48
+	cornerRadius   = 10
+)
+
+// codeRow is one drawn row of code; number is zero on wrapped rows
+type codeRow struct {
+	number int
+	cells  []cell
+}
+
+// RenderCode draws the code image as a PNG
+func RenderCode(code Code) ([]byte, error) {
+	width := code.Width
+	if width == 0 {
+		width = DefaultWidth
+	}
+	width = min(max(width, MinWidth), MaxWidth)
+	theme := code.Theme
+
+	digits, gutter := len(strconv.Itoa(len(code.Lines))), 0
+	if code.LineNumbers {
+		gutter = (digits + 2) * codeAdvance
+	}
+	cols := columns(width-2*outerPad-2*windowPad-gutter, codeScale)
+
+	var rows []codeRow
+	for i, line := range code.Lines {
+		cells := layout(line)
+		for first := true; first || len(cells) > 0; first = false {
+			n := min(cols, len(cells))
+			row := codeRow{cells: cells[:n]}
+			if first {
+				row.number = i + 1
+			}
+			rows = append(rows, row)
+			cells = cells[n:]
+		}
+	}
+	if len(rows) > MaxCodeLines {
+		// Cut before the first left out line, unless one line fills it all
+		cut := MaxCodeLines - 1
+		for cut > 0 && rows[cut].number == 0 {
+			cut--
+		}
+		note := "…"
+		if cut == 0 {
+			cut = MaxCodeLines - 1
+		} else {
+			note = fmt.Sprintf("… %d more lines", len(code.Lines)-rows[cut].number+1)
+		}
+		rows = append(rows[:cut], codeRow{cells: layout([]Span{{Text: note, Color: theme.Muted}})})
+	}
+
+	height := 2*outerPad + titleBar + 8 + len(rows)*codeLineHeight + windowPad - (codeLineHeight - glyphHeight*codeScale)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), theme.Background)
+	window := image.Rect(outerPad, outerPad, width-outerPad, height-outerPad)
+	fill(img, window, theme.Window)
+	roundCorners(img, window, cornerRadius, theme.Background)
+
+	// Title bar: the three window buttons and the centered title
+	for i, c := range []color.RGBA{rgb(0xff5f56), rgb(0xffbd2e), rgb(0x27c93f)} {
+		fillCircle(img, window.Min.X+22+i*20, window.Min.Y+titleBar/2, 6, c)
+	}
+	title := fit(code.Title, columns(window.Dx()-2*90, codeScale))
+	titleX := window.Min.X + (window.Dx()-utf8.RuneCountInString(title)*codeAdvance)/2
+	drawText(img, titleX, window.Min.Y+(titleBar-glyphHeight*codeScale)/2, title, codeScale, theme.Muted)
+
+	x, y := window.Min.X+windowPad, window.Min.Y+titleBar+8
+	for _, row := range rows {
+		if code.LineNumbers && row.number > 0 {
+			number := strconv.Itoa(row.number)
+			drawText(img, x+(digits-len(number))*codeAdvance, y, number, codeScale, theme.Muted)
+		}
+		drawCells(img, x+gutter, y, row.cells, codeScale)
+		y += codeLineHeight
+	}
+
+	return encode(img)
+}
+
+// roundCorners paints the corners of r outside a quarter circle of radius
+// in c
+func roundCorners(img *image.RGBA, r image.Rectangle, radius int, c color.RGBA) {
+	for dy := 0; dy < radius; dy++ {
+		for dx := 0; dx < radius; dx++ {
+			ox, oy := radius-dx, radius-dy
+			if ox*ox+oy*oy <= radius*radius {
+				continue
+			}
+			img.SetRGBA(r.Min.X+dx, r.Min.Y+dy, c)
+			img.SetRGBA(r.Max.X-1-dx, r.Min.Y+dy, c)
+			img.SetRGBA(r.Min.X+dx, r.Max.Y-1-dy, c)
+			img.SetRGBA(r.Max.X-1-dx, r.Max.Y-1-dy, c)
+		}
+	}
+}
+
+// fillCircle draws a filled circle of radius around cx, cy
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
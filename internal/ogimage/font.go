@@ -0,0 +1,113 @@
+package ogimage
+
+// glyphWidth and glyphHeight are the size of a glyph in font pixels. The
+// bottom row only holds descenders.
+const (
+	glyphWidth  = 5
+	glyphHeight = 8
+)
+
+// missingGlyph is drawn for runes the font does not have
+var missingGlyph = [glyphHeight]string{"#####", "#...#", "#...#", "#...#", "#...#", "#...#", "#####", "....."}
+
+// glyphs are bitmaps of printable ASCII and the few other runes the cards
+// use, top row first
+var glyphs = map[rune][glyphHeight]string{
+	' ':  {".....", ".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'!':  {"..#..", "..#..", "..#..", "..#..", "..#..", ".....", "..#..", "....."},
+	'"':  {".#.#.", ".#.#.", ".#.#.", ".....", ".....", ".....", ".....", "....."},
+	'#':  {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#.", "....."},
+	'$':  {"..#..", ".####", "#.#..", ".###.", "..#.#", "####.", "..#..", "....."},
+	'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##", "....."},
+	'&':  {".##..", "#..#.", "#.#..", ".#...", "#.#.#", "#..#.", ".##.#", "....."},
+	'\'': {"..#..", "..#..", ".#...", ".....", ".....", ".....", ".....", "....."},
+	'(':  {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#.", "....."},
+	')':  {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#...", "....."},
+	'*':  {".....", "..#..", "#.#.#", ".###.", "#.#.#", "..#..", ".....", "....."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", ".....", "....."},
+	',':  {".....", ".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+	'-':  {".....", ".....", ".....", "#####", ".....", ".....", ".....", "....."},
+	'.':  {".....", ".....", ".....", ".....", ".....", ".##..", ".##..", "....."},
+	'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", ".....", "....."},
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###.", "....."},
+	'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###.", "....."},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####", "....."},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###.", "....."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#.", "....."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###.", "....."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###.", "....."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#...", "....."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###.", "....."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##..", "....."},
+	':':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", ".....", "....."},
+	';':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", "..#..", ".#..."},
+	'<':  {"...#.", "..#..", ".#...", "#....", ".#...", "..#..", "...#.", "....."},
+	'=':  {".....", ".....", "#####", ".....", "#####", ".....", ".....", "....."},
+	'>':  {".#...", "..#..", "...#.", "....#", "...#.", "..#..", ".#...", "....."},
+	'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#..", "....."},
+	'@':  {".###.", "#...#", "....#", ".##.#", "#.#.#", "#.#.#", ".###.", "....."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#", "....."},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####.", "....."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###.", "....."},
+	'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###..", "....."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####", "....."},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#....", "....."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####", "....."},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#", "....."},
+	'I':  {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###.", "....."},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##..", "....."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#", "....."},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####", "....."},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#", "....."},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#", "....."},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###.", "....."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#....", "....."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#", "....."},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#", "....."},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####.", "....."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "....."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###.", "....."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#..", "....."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#.", "....."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#", "....."},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#..", "....."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####", "....."},
+	'[':  {".###.", ".#...", ".#...", ".#...", ".#...", ".#...", ".###.", "....."},
+	'\\': {".....", "#....", ".#...", "..#..", "...#.", "....#", ".....", "....."},
+	']':  {".###.", "...#.", "...#.", "...#.", "...#.", "...#.", ".###.", "....."},
+	'^':  {"..#..", ".#.#.", "#...#", ".....", ".....", ".....", ".....", "....."},
+	'_':  {".....", ".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'`':  {".#...", "..#..", "...#.", ".....", ".....", ".....", ".....", "....."},
+	'a':  {".....", ".....", ".###.", "....#", ".####", "#...#", ".####", "....."},
+	'b':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "####.", "....."},
+	'c':  {".....", ".....", ".###.", "#....", "#....", "#...#", ".###.", "....."},
+	'd':  {"....#", "....#", ".##.#", "#..##", "#...#", "#...#", ".####", "....."},
+	'e':  {".....", ".....", ".###.", "#...#", "#####", "#....", ".###.", "....."},
+	'f':  {"..##.", ".#..#", ".#...", "###..", ".#...", ".#...", ".#...", "....."},
+	'g':  {".....", ".....", ".####", "#...#", "#...#", ".####", "....#", ".###."},
+	'h':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "#...#", "....."},
+	'i':  {"..#..", ".....", ".##..", "..#..", "..#..", "..#..", ".###.", "....."},
+	'j':  {"...#.", ".....", "..##.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'k':  {"#....", "#....", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "....."},
+	'l':  {".##..", "..#..", "..#..", "..#..", "..#..", "..#..", ".###.", "....."},
+	'm':  {".....", ".....", "##.#.", "#.#.#", "#.#.#", "#...#", "#...#", "....."},
+	'n':  {".....", ".....", "#.##.", "##..#", "#...#", "#...#", "#...#", "....."},
+	'o':  {".....", ".....", ".###.", "#...#", "#...#", "#...#", ".###.", "....."},
+	'p':  {".....", ".....", "####.", "#...#", "#...#", "####.", "#....", "#...."},
+	'q':  {".....", ".....", ".####", "#...#", "#...#", ".####", "....#", "....#"},
+	'r':  {".....", ".....", "#.##.", "##..#", "#....", "#....", "#....", "....."},
+	's':  {".....", ".....", ".####", "#....", ".###.", "....#", "####.", "....."},
+	't':  {".#...", ".#...", "###..", ".#...", ".#...", ".#..#", "..##.", "....."},
+	'u':  {".....", ".....", "#...#", "#...#", "#...#", "#..##", ".##.#", "....."},
+	'v':  {".....", ".....", "#...#", "#...#", "#...#", ".#.#.", "..#..", "....."},
+	'w':  {".....", ".....", "#...#", "#...#", "#.#.#", "#.#.#", ".#.#.", "....."},
+	'x':  {".....", ".....", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "....."},
+	'y':  {".....", ".....", "#...#", "#...#", "#...#", ".####", "....#", ".###."},
+	'z':  {".....", ".....", "#####", "...#.", "..#..", ".#...", "#####", "....."},
+	'{':  {"...#.", "..#..", "..#..", ".#...", "..#..", "..#..", "...#.", "....."},
+	'|':  {"..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "....."},
+	'}':  {".#...", "..#..", "..#..", "...#.", "..#..", "..#..", ".#...", "....."},
+	'~':  {".....", ".....", ".#...", "#.#.#", "...#.", ".....", ".....", "....."},
+	'·':  {".....", ".....", ".....", "..#..", ".....", ".....", ".....", "....."},
+	'…':  {".....", ".....", ".....", ".....", ".....", ".....", "#.#.#", "....."},
+}
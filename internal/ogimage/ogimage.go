@@ -0,0 +1,160 @@
+// Package ogimage draws code as PNG images: the preview cards shown when a
+// share link is unfurled, and screenshot-style images of whole snippets.
+// Text is drawn with a built-in 5x8 bitmap font so nothing has to be
+// loaded; runes outside ASCII are shown as an empty box.
+package ogimage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"unicode/utf8"
+)
+
+// Size of a card, the one Open Graph and Twitter recommend
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// MaxLines is the number of code lines that fit on a card
+const MaxLines = 12
+
+// Span is a run of code in one color
+type Span struct {
+	Text  string
+	Color color.RGBA
+}
+
+// Card is the text drawn on a preview image
+type Card struct {
+	Title    string
+	Subtitle string   // Shown under the title, e.g. the language
+	Code     [][]Span // One slice per line, cut to MaxLines and the card's width
+	Footer   string   // Shown in the bottom right corner
+	Theme    Theme
+}
+
+// layout of the card, in image pixels
+const (
+	margin      = 60
+	titleScale  = 5
+	textScale   = 3
+	panelTop    = 180
+	panelBottom = Height - 80
+	panelPad    = 24
+	lineHeight  = 30
+)
+
+// Render draws the card as a PNG
+func Render(card Card) ([]byte, error) {
+	theme := card.Theme
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	fill(img, img.Bounds(), theme.Background)
+	fill(img, image.Rect(0, 0, Width, 8), theme.Accent)
+
+	cols := columns(Width-2*margin, textScale)
+	drawText(img, margin, margin, fit(card.Title, columns(Width-2*margin, titleScale)), titleScale, theme.Plain)
+	drawText(img, margin, margin+glyphHeight*titleScale+20, fit(card.Subtitle, cols), textScale, theme.Muted)
+
+	fill(img, image.Rect(margin-panelPad, panelTop, Width-margin+panelPad, panelBottom), theme.Window)
+	for i, line := range card.Code {
+		if i == MaxLines {
+			break
+		}
+		cells := layout(line)
+		if len(cells) > cols {
+			cells = append(cells[:cols-1], cell{'…', theme.Muted})
+		}
+		drawCells(img, margin, panelTop+panelPad+i*lineHeight, cells, textScale)
+	}
+
+	footer := fit(card.Footer, cols)
+	footerX := Width - margin - utf8.RuneCountInString(footer)*(glyphWidth+1)*textScale
+	drawText(img, footerX, panelBottom+24, footer, textScale, theme.Accent)
+
+	return encode(img)
+}
+
+// cell is one rune of laid out text
+type cell struct {
+	r     rune
+	color color.RGBA
+}
+
+// layout lays out a line of spans one rune per column, expanding tabs to
+// the next stop of four
+func layout(line []Span) []cell {
+	var cells []cell
+	for _, span := range line {
+		for _, r := range span.Text {
+			if r == '\t' {
+				for n := 4 - len(cells)%4; n > 0; n-- {
+					cells = append(cells, cell{' ', span.Color})
+				}
+				continue
+			}
+			cells = append(cells, cell{r, span.Color})
+		}
+	}
+	return cells
+}
+
+// columns returns how many glyphs fit in width pixels at scale
+func columns(width, scale int) int {
+	return width / ((glyphWidth + 1) * scale)
+}
+
+// fit cuts s to n runes, ending it with an ellipsis when cut
+func fit(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:n-1]), " ") + "…"
+}
+
+// drawText draws s in one color with its top left corner at x, y, each
+// font pixel scale image pixels wide
+func drawText(img *image.RGBA, x, y int, s string, scale int, c color.RGBA) {
+	cells := make([]cell, 0, len(s))
+	for _, r := range s {
+		cells = append(cells, cell{r, c})
+	}
+	drawCells(img, x, y, cells, scale)
+}
+
+// drawCells draws laid out text with its top left corner at x, y
+func drawCells(img *image.RGBA, x, y int, cells []cell, scale int) {
+	for _, c := range cells {
+		glyph, ok := glyphs[c.r]
+		if !ok {
+			glyph = missingGlyph
+		}
+		for row, bits := range glyph {
+			for col := 0; col < glyphWidth; col++ {
+				if bits[col] == '#' {
+					px, py := x+col*scale, y+row*scale
+					fill(img, image.Rect(px, py, px+scale, py+scale), c.color)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
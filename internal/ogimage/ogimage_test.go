@@ -0,0 +1,102 @@
+package ogimage
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestGlyphs(t *testing.T) {
+	for r := rune(' '); r <= '~'; r++ {
+		glyph, ok := glyphs[r]
+		if !ok {
+			t.Errorf("missing glyph for %q", r)
+			continue
+		}
+		for _, row := range glyph {
+			if len(row) != glyphWidth || strings.Trim(row, "#.") != "" {
+				t.Errorf("malformed row %q in glyph %q", row, r)
+			}
+		}
+	}
+}
+
+func decode(t *testing.T, out []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("expected a PNG: %v", err)
+	}
+	return img
+}
+
+func TestRender(t *testing.T) {
+	theme := Themes[DefaultTheme]
+	code := make([][]Span, 20)
+	for i := range code {
+		code[i] = []Span{{Text: "\tfmt.", Color: theme.Plain}, {Text: `"` + strings.Repeat("x", 100) + `"`, Color: theme.String}}
+	}
+	out, err := Render(Card{Title: "Deploy script — ünïcode", Subtitle: "bash · 20 lines", Code: code, Footer: "Snipo", Theme: theme})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	img := decode(t, out)
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Errorf("expected %dx%d, got %v", Width, Height, b)
+	}
+	if r, g, b, _ := img.At(Width/2, 2).RGBA(); r>>8 != uint32(theme.Accent.R) || g>>8 != uint32(theme.Accent.G) || b>>8 != uint32(theme.Accent.B) {
+		t.Error("expected the accent bar along the top")
+	}
+}
+
+func TestRenderCode(t *testing.T) {
+	theme := Themes["light"]
+	lines := [][]Span{
+		{{Text: "package", Color: theme.Keyword}, {Text: " main", Color: theme.Plain}},
+		{},
+		{{Text: strings.Repeat("y", 150), Color: theme.Plain}},
+	}
+	out, err := RenderCode(Code{Title: "main.go", Lines: lines, Theme: theme, Width: 600, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("RenderCode failed: %v", err)
+	}
+	img := decode(t, out)
+	// 150 runes wrap onto several rows at this width, making it taller
+	// than three rows would
+	if b := img.Bounds(); b.Dx() != 600 || b.Dy() <= 2*outerPad+titleBar+3*codeLineHeight {
+		t.Errorf("expected 600 wide with wrapped rows, got %v", b)
+	}
+	if r, _, _, _ := img.At(1, 1).RGBA(); r>>8 != uint32(theme.Background.R) {
+		t.Error("expected the background around the window")
+	}
+
+	many := make([][]Span, 500)
+	out, err = RenderCode(Code{Lines: many, Theme: theme, Width: 1})
+	if err != nil {
+		t.Fatalf("RenderCode failed: %v", err)
+	}
+	img = decode(t, out)
+	if b := img.Bounds(); b.Dx() != MinWidth || b.Dy() > 2*outerPad+titleBar+8+MaxCodeLines*codeLineHeight+windowPad {
+		t.Errorf("expected the width clamped and the lines capped, got %v", b)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	if got := fit("short", 10); got != "short" {
+		t.Errorf("expected short text kept, got %q", got)
+	}
+	if got := fit("a long title here", 8); got != "a long…" {
+		t.Errorf("expected a cut with an ellipsis, got %q", got)
+	}
+
+	var got strings.Builder
+	for _, c := range layout([]Span{{Text: "a\tb"}, {Text: "\t\tc"}}) {
+		got.WriteRune(c.r)
+	}
+	if got.String() != "a   b       c" {
+		t.Errorf("unexpected tab expansion %q", got.String())
+	}
+}
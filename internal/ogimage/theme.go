@@ -0,0 +1,57 @@
+package ogimage
+
+import (
+	"image/color"
+	"slices"
+)
+
+// Theme is the colors of an image. Code is colored by token kind, as
+// split by the highlight package.
+type Theme struct {
+	Background color.RGBA // Around the window or panel
+	Window     color.RGBA // Behind the code
+	Accent     color.RGBA // Top bar and footer of cards
+	Muted      color.RGBA // Subtitles, window titles and line numbers
+	Plain      color.RGBA
+	Keyword    color.RGBA
+	String     color.RGBA
+	Comment    color.RGBA
+	Number     color.RGBA
+}
+
+// DefaultTheme is the theme used when none is asked for
+const DefaultTheme = "dark"
+
+// Themes are the themes available by name
+var Themes = map[string]Theme{
+	"dark": { // Tomorrow Night, as in the web UI
+		Background: rgb(0x1d1f21), Window: rgb(0x282a2e), Accent: rgb(0x81a2be), Muted: rgb(0x969896),
+		Plain: rgb(0xc5c8c6), Keyword: rgb(0xb294bb), String: rgb(0xb5bd68), Comment: rgb(0x969896), Number: rgb(0xde935f),
+	},
+	"light": { // Tomorrow
+		Background: rgb(0xdfe2e5), Window: rgb(0xffffff), Accent: rgb(0x4271ae), Muted: rgb(0x8e908c),
+		Plain: rgb(0x4d4d4c), Keyword: rgb(0x8959a8), String: rgb(0x718c00), Comment: rgb(0x8e908c), Number: rgb(0xf5871f),
+	},
+	"dracula": {
+		Background: rgb(0x191a21), Window: rgb(0x282a36), Accent: rgb(0xbd93f9), Muted: rgb(0x6272a4),
+		Plain: rgb(0xf8f8f2), Keyword: rgb(0xff79c6), String: rgb(0xf1fa8c), Comment: rgb(0x6272a4), Number: rgb(0xbd93f9),
+	},
+	"solarized": { // Solarized dark
+		Background: rgb(0x00212b), Window: rgb(0x002b36), Accent: rgb(0x268bd2), Muted: rgb(0x586e75),
+		Plain: rgb(0x93a1a1), Keyword: rgb(0x859900), String: rgb(0x2aa198), Comment: rgb(0x586e75), Number: rgb(0xd33682),
+	},
+}
+
+// ThemeNames returns the names of the themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func rgb(hex uint32) color.RGBA {
+	return color.RGBA{R: uint8(hex >> 16), G: uint8(hex >> 8), B: uint8(hex), A: 0xff}
+}
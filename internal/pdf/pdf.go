@@ -0,0 +1,322 @@
+// Package pdf writes simple text documents as PDF: wrapped paragraphs and
+// monospaced code lines on A4 pages, using the standard Helvetica and
+// Courier fonts so nothing has to be embedded. Text outside Windows-1252
+// is shown as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Font is one of the standard fonts
+type Font int
+
+// Fonts, named F1 to F6 in the document
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+	Courier
+	CourierBold
+	CourierOblique
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier", "Courier-Bold", "Courier-Oblique"}
+
+// Color is an RGB color with components from 0 to 1
+type Color struct{ R, G, B float64 }
+
+// Common colors
+var (
+	Black = Color{0, 0, 0}
+	Gray  = Color{0.45, 0.45, 0.45}
+)
+
+// Span is a run of text in one font and color
+type Span struct {
+	Text  string
+	Font  Font
+	Color Color
+}
+
+// A4 page size and margins, in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+	footerSize = 8.0
+)
+
+// Document is a PDF being laid out top to bottom
+type Document struct {
+	title  string
+	footer string
+	pages  []*bytes.Buffer
+	y      float64 // Baseline of the next line on the current page
+}
+
+// New creates an empty document. The title is stored in the document
+// information and, with the page number, in every page's footer.
+func New(title string) *Document {
+	return &Document{title: title, footer: title}
+}
+
+// WithFooter sets the text shown before the page number in every footer
+func (d *Document) WithFooter(footer string) *Document {
+	d.footer = footer
+	return d
+}
+
+// Pages returns the number of pages laid out so far
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// page returns the current page, starting a new one when fewer than
+// height points are left on it
+func (d *Document) page(height float64) *bytes.Buffer {
+	if len(d.pages) == 0 || d.y-height < margin+2*footerSize {
+		d.pages = append(d.pages, &bytes.Buffer{})
+		d.y = pageHeight - margin
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Space adds vertical space, in points
+func (d *Document) Space(height float64) {
+	if len(d.pages) > 0 {
+		d.y -= height
+	}
+}
+
+// Rule draws a thin horizontal line across the text width
+func (d *Document) Rule() {
+	p := d.page(6)
+	d.y -= 3
+	fmt.Fprintf(p, "0.8 0.8 0.8 RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, d.y, pageWidth-margin, d.y)
+	d.y -= 3
+}
+
+// Paragraph adds text wrapped at word boundaries to the text width
+func (d *Document) Paragraph(text string, font Font, size float64, color Color) {
+	for _, line := range strings.Split(text, "\n") {
+		for _, wrapped := range wrapWords(line, font, size, pageWidth-2*margin) {
+			d.line([]Span{{Text: wrapped, Font: font, Color: color}}, size)
+		}
+	}
+}
+
+// CodeLine adds a line of monospaced spans in the given size, wrapped at
+// the text width. A non-empty gutter (such as a line number) is shown
+// left of the first row; wrapped rows are indented to line up with it.
+func (d *Document) CodeLine(gutter string, spans []Span, size float64) {
+	charWidth := 0.6 * size
+	indent := float64(utf8.RuneCountInString(gutter)) * charWidth
+	columns := int((pageWidth - 2*margin - indent) / charWidth)
+	if columns < 1 {
+		columns = 1
+	}
+
+	rows := [][]Span{nil}
+	used := 0
+	for _, span := range spans {
+		text := strings.ReplaceAll(span.Text, "\t", "    ")
+		for text != "" {
+			if used == columns {
+				rows = append(rows, nil)
+				used = 0
+			}
+			n, cut := 0, len(text)
+			for i := range text {
+				if n == columns-used {
+					cut = i
+					break
+				}
+				n++
+			}
+			row := &rows[len(rows)-1]
+			*row = append(*row, Span{Text: text[:cut], Font: span.Font, Color: span.Color})
+			used += n
+			text = text[cut:]
+		}
+	}
+
+	for i, row := range rows {
+		if gutter != "" {
+			label := gutter
+			if i > 0 {
+				label = strings.Repeat(" ", utf8.RuneCountInString(gutter))
+			}
+			row = append([]Span{{Text: label, Font: Courier, Color: Gray}}, row...)
+		}
+		d.line(row, size)
+	}
+}
+
+// line writes one row of spans at the next baseline
+func (d *Document) line(spans []Span, size float64) {
+	leading := size * 1.35
+	p := d.page(leading)
+	d.y -= size
+	fmt.Fprintf(p, "BT %.2f %.2f Td\n", margin, d.y)
+	for _, span := range spans {
+		fmt.Fprintf(p, "/F%d %.1f Tf %.3f %.3f %.3f rg (%s) Tj\n", int(span.Font)+1, size, span.Color.R, span.Color.G, span.Color.B, escape(span.Text))
+	}
+	p.WriteString("ET\n")
+	d.y -= leading - size
+}
+
+// WriteTo writes the document as PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.page(0)
+	}
+
+	var (
+		buf     bytes.Buffer
+		offsets []int
+	)
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3 info, 4-9 fonts, then a page and
+	// its content stream for each page
+	const firstPage = 4 + 6
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Snipo) >>", escape(d.title)))
+
+	fonts := make([]string, len(fontNames))
+	for i, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts[i] = fmt.Sprintf("/F%d %d 0 R", i+1, 4+i)
+	}
+	resources := fmt.Sprintf("<< /Font << %s >> >>", strings.Join(fonts, " "))
+
+	for i, content := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		if d.footer != "" {
+			footer = d.footer + " - " + footer
+		}
+		fmt.Fprintf(content, "BT %.2f %.2f Td /F1 %.1f Tf 0.45 0.45 0.45 rg (%s) Tj ET\n", margin, margin-footerSize, footerSize, escape(footer))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources %s /Contents %d 0 R >>",
+			pageWidth, pageHeight, resources, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// escape encodes text as Windows-1252 for a PDF string literal
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+			continue
+		case '\t':
+			b.WriteString("    ")
+			continue
+		}
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok || c < 0x20 {
+			c = '?'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth returns the width of text in points
+func textWidth(text string, font Font, size float64) float64 {
+	switch font {
+	case Courier, CourierBold, CourierOblique:
+		return float64(utf8.RuneCountInString(text)) * 0.6 * size
+	}
+	width := 0
+	for _, r := range text {
+		if r == '\t' {
+			width += 4 * helveticaWidths[0]
+		} else if r >= 32 && r < 127 {
+			width += helveticaWidths[r-32]
+		} else {
+			width += 556
+		}
+	}
+	// Bold glyphs are a little wider; overestimating only wraps earlier
+	if font == HelveticaBold {
+		width = width * 11 / 10
+	}
+	return float64(width) * size / 1000
+}
+
+// wrapWords splits a line into rows no wider than width, breaking at
+// spaces, or inside words longer than a row
+func wrapWords(line string, font Font, size, width float64) []string {
+	var rows []string
+	current := ""
+	for _, word := range strings.Split(line, " ") {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if textWidth(candidate, font, size) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			rows = append(rows, current)
+		}
+		current = word
+		for textWidth(current, font, size) > width {
+			cut := 1
+			for i := range current {
+				if i > 0 && textWidth(current[:i], font, size) > width {
+					break
+				}
+				cut = i
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(current)
+			}
+			rows = append(rows, current[:cut])
+			current = current[cut:]
+		}
+	}
+	return append(rows, current)
+}
@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New("Runbook (v2)")
+	doc.Paragraph("Runbook (v2)", HelveticaBold, 18, Black)
+	doc.Rule()
+	for i := 0; i < 120; i++ {
+		doc.CodeLine(fmt.Sprintf("%3d  ", i+1), []Span{{Text: "echo step", Font: Courier, Color: Black}}, 8.5)
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("expected a PDF header and trailer")
+	}
+	if doc.Pages() < 2 {
+		t.Errorf("expected the code to spill onto a second page, got %d pages", doc.Pages())
+	}
+	if !strings.Contains(out, fmt.Sprintf("/Count %d", doc.Pages())) {
+		t.Error("expected the page tree to count every page")
+	}
+	if !strings.Contains(out, `(Runbook \(v2\)) Tj`) {
+		t.Error("expected parentheses in text escaped")
+	}
+	if !strings.Contains(out, fmt.Sprintf("Page 2 of %d", doc.Pages())) {
+		t.Error("expected page numbers in the footers")
+	}
+
+	// Every cross-reference entry points at its object
+	start, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(out)[1])
+	if err != nil || !strings.HasPrefix(out[start:], "xref\n") {
+		t.Fatalf("expected startxref to point at the xref table")
+	}
+	for i, m := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[start:], -1) {
+		offset, _ := strconv.Atoi(m[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
+
+func TestCodeLine_Wraps(t *testing.T) {
+	doc := New("")
+	doc.CodeLine("1  ", []Span{{Text: strings.Repeat("x", 250), Font: Courier, Color: Black}}, 10)
+	// 495pt of text width minus a 3 column gutter leaves 79 columns of 6pt
+	if rows := strings.Count(doc.pages[0].String(), "BT "); rows != 4 {
+		t.Errorf("expected 250 columns wrapped onto 4 rows, got %d", rows)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		`a\b`:   `a\\b`,
+		"café":  "caf\xe9",
+		"€":     "\x80",
+		"日本":    "??",
+		"a\tb":  "a    b",
+		"a\x01": "a?",
+	}
+	for in, want := range tests {
+		if got := escape(in); got != want {
+			t.Errorf("escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	rows := wrapWords("the quick brown fox jumps over the lazy dog", Helvetica, 10, 100)
+	if len(rows) < 2 {
+		t.Fatalf("expected the line wrapped, got %q", rows)
+	}
+	for _, row := range rows {
+		if textWidth(row, Helvetica, 10) > 100 {
+			t.Errorf("row %q is wider than 100pt", row)
+		}
+	}
+	if got := strings.Join(rows, " "); got != "the quick brown fox jumps over the lazy dog" {
+		t.Errorf("expected no words lost, got %q", got)
+	}
+
+	if rows := wrapWords(strings.Repeat("w", 50), Helvetica, 10, 100); len(rows) < 2 {
+		t.Errorf("expected a long word broken up, got %q", rows)
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// APIUsageRepository handles API request counts
+type APIUsageRepository struct {
+	db *sql.DB
+}
+
+// NewAPIUsageRepository creates a new API usage repository
+func NewAPIUsageRepository(db *sql.DB) *APIUsageRepository {
+	return &APIUsageRepository{db: db}
+}
+
+// Add adds counts to the stored ones in a single transaction. A token's
+// name is updated to the one in the latest entry.
+func (r *APIUsageRepository) Add(ctx context.Context, entries []models.APIUsageEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO api_usage (day, token_id, token_name, method, route, requests, errors, rate_limited)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(day, token_id, method, route) DO UPDATE SET
+			token_name = excluded.token_name,
+			requests = requests + excluded.requests,
+			errors = errors + excluded.errors,
+			rate_limited = rate_limited + excluded.rate_limited
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare API usage insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, e := range entries {
+		if _, err := stmt.ExecContext(ctx, e.Day, e.TokenID, e.TokenName, e.Method, e.Route, e.Requests, e.Errors, e.RateLimited); err != nil {
+			return fmt.Errorf("failed to record API usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// List returns the daily counts in a filter's period, by day, token,
+// route and method
+func (r *APIUsageRepository) List(ctx context.Context, filter models.APIUsageFilter) ([]models.APIUsageEntry, error) {
+	query := `
+		SELECT day, token_id, token_name, method, route, requests, errors, rate_limited
+		FROM api_usage WHERE day >= ? AND day <= ?`
+	args := []any{filter.From, filter.To}
+	if filter.TokenID != nil {
+		query += ` AND token_id = ?`
+		args = append(args, *filter.TokenID)
+	}
+	query += ` ORDER BY day, token_id, route, method`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API usage: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	entries := []models.APIUsageEntry{}
+	for rows.Next() {
+		var e models.APIUsageEntry
+		if err := rows.Scan(&e.Day, &e.TokenID, &e.TokenName, &e.Method, &e.Route, &e.Requests, &e.Errors, &e.RateLimited); err != nil {
+			return nil, fmt.Errorf("failed to scan API usage: %w", err)
+		}
+		if e.Requests > 0 {
+			e.ErrorRate = float64(e.Errors) / float64(e.Requests)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Prune deletes counts from before day before
+func (r *APIUsageRepository) Prune(ctx context.Context, before string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_usage WHERE day < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune API usage: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// likePrefix returns a LIKE pattern, with ESCAPE '\', matching values that
+// start with prefix
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// Suggest returns up to limit tags whose name starts with prefix (ignoring
+// ASCII case), most used first
+func (r *TagRepository) Suggest(ctx context.Context, prefix string, limit int) ([]models.Suggestion, error) {
+	return querySuggestions(ctx, r.db, `
+		SELECT t.id, t.name, t.color, NULL,
+		       (SELECT COUNT(*) FROM snippet_tags st
+		        INNER JOIN snippets s ON s.id = st.snippet_id
+		        WHERE st.tag_id = t.id AND s.is_archived = 0) AS uses
+		FROM tags t
+		WHERE t.name LIKE ? ESCAPE '\'
+		ORDER BY uses DESC, t.name ASC
+		LIMIT ?
+	`, likePrefix(prefix), limit)
+}
+
+// Suggest returns up to limit folders whose name starts with prefix
+// (ignoring ASCII case), most used first
+func (r *FolderRepository) Suggest(ctx context.Context, prefix string, limit int) ([]models.Suggestion, error) {
+	return querySuggestions(ctx, r.db, `
+		SELECT f.id, f.name, f.color, f.parent_id,
+		       (SELECT COUNT(*) FROM snippet_folders sf
+		        INNER JOIN snippets s ON s.id = sf.snippet_id
+		        WHERE sf.folder_id = f.id AND s.is_archived = 0) AS uses
+		FROM folders f
+		WHERE f.name LIKE ? ESCAPE '\'
+		ORDER BY uses DESC, f.name ASC
+		LIMIT ?
+	`, likePrefix(prefix), limit)
+}
+
+// LanguageCounts counts the unarchived snippets and files per language
+// starting with prefix
+func (r *SnippetRepository) LanguageCounts(ctx context.Context, prefix string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT language, COUNT(*) FROM (
+			SELECT s.language FROM snippets s WHERE s.is_archived = 0
+			UNION ALL
+			SELECT f.language FROM snippet_files f
+			INNER JOIN snippets s ON s.id = f.snippet_id
+			WHERE s.is_archived = 0 AND f.language IS NOT NULL
+		)
+		WHERE language LIKE ? ESCAPE '\'
+		GROUP BY language
+	`, likePrefix(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count languages: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var language string
+		var count int
+		if err := rows.Scan(&language, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan language count: %w", err)
+		}
+		counts[strings.ToLower(language)] += count
+	}
+	return counts, rows.Err()
+}
+
+func querySuggestions(ctx context.Context, db *sql.DB, query string, args ...any) ([]models.Suggestion, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suggestions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	suggestions := []models.Suggestion{}
+	for rows.Next() {
+		var s models.Suggestion
+		var color sql.NullString
+		if err := rows.Scan(&s.ID, &s.Value, &color, &s.ParentID, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		s.Color = color.String
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
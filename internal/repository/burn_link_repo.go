@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// BurnLinkRepository handles burn-after-read share link database operations
+type BurnLinkRepository struct {
+	db *sql.DB
+}
+
+// NewBurnLinkRepository creates a new burn link repository
+func NewBurnLinkRepository(db *sql.DB) *BurnLinkRepository {
+	return &BurnLinkRepository{db: db}
+}
+
+const burnLinkColumns = `id, snippet_id, delete_snippet, expires_at, created_at`
+
+func scanBurnLink(row interface{ Scan(...any) error }) (*models.BurnLink, error) {
+	link := &models.BurnLink{}
+	if err := row.Scan(&link.ID, &link.SnippetID, &link.DeleteSnippet, &link.ExpiresAt, &link.CreatedAt); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Create stores a link for a snippet, returning it with its plain token
+func (r *BurnLinkRepository) Create(ctx context.Context, snippetID string, deleteSnippet bool, expiresAt *time.Time) (*models.BurnLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	link, err := scanBurnLink(r.db.QueryRowContext(ctx,
+		`INSERT INTO burn_links (snippet_id, token_hash, delete_snippet, expires_at) VALUES (?, ?, ?, ?)
+		RETURNING `+burnLinkColumns,
+		snippetID, hashToken(token), deleteSnippet, expiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create burn link: %w", err)
+	}
+	link.Token = token
+	return link, nil
+}
+
+// GetByToken retrieves an unexpired link by its plain token, or nil if
+// there is none
+func (r *BurnLinkRepository) GetByToken(ctx context.Context, token string) (*models.BurnLink, error) {
+	link, err := scanBurnLink(r.db.QueryRowContext(ctx,
+		`SELECT `+burnLinkColumns+` FROM burn_links WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		hashToken(token), time.Now().UTC(),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get burn link: %w", err)
+	}
+	return link, nil
+}
+
+// ListBySnippet retrieves a snippet's unopened, unexpired links, newest first
+func (r *BurnLinkRepository) ListBySnippet(ctx context.Context, snippetID string) ([]models.BurnLink, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+burnLinkColumns+` FROM burn_links WHERE snippet_id = ? AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY created_at DESC, id DESC`,
+		snippetID, time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list burn links: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	links := []models.BurnLink{}
+	for rows.Next() {
+		link, err := scanBurnLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan burn link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+// Consume deletes a link as it is opened. It reports false when the link
+// was already gone, so of two concurrent opens only one succeeds.
+func (r *BurnLinkRepository) Consume(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM burn_links WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume burn link: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// Delete revokes one of a snippet's links
+func (r *BurnLinkRepository) Delete(ctx context.Context, snippetID string, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM burn_links WHERE id = ? AND snippet_id = ?`, id, snippetID)
+	if err != nil {
+		return fmt.Errorf("failed to delete burn link: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteExpired removes links that expired unopened
+func (r *BurnLinkRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM burn_links WHERE expires_at IS NOT NULL AND expires_at <= ?`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired burn links: %w", err)
+	}
+	return result.RowsAffected()
+}
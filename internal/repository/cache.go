@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/cache"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ReadCache caches hot reads shared by the repositories: settings, the tag
+// and folder lists, and recently fetched snippets. Writes through a
+// repository invalidate what they affect; the TTL bounds staleness from
+// anything else that writes to the database. A nil *ReadCache caches
+// nothing.
+type ReadCache struct {
+	settings *cache.LRU[struct{}, models.Settings]
+	tags     *cache.LRU[struct{}, []models.Tag]
+	folders  *cache.LRU[struct{}, []models.Folder]
+	snippets *cache.LRU[string, models.Snippet]
+}
+
+// NewReadCache creates a cache keeping up to snippets snippets, with every
+// entry expiring after ttl
+func NewReadCache(snippets int, ttl time.Duration) *ReadCache {
+	return &ReadCache{
+		settings: cache.New[struct{}, models.Settings](1, ttl),
+		tags:     cache.New[struct{}, []models.Tag](1, ttl),
+		folders:  cache.New[struct{}, []models.Folder](1, ttl),
+		snippets: cache.New[string, models.Snippet](snippets, ttl),
+	}
+}
+
+// Stats returns hit metrics per cached kind
+func (c *ReadCache) Stats() map[string]cache.Stats {
+	if c == nil {
+		return nil
+	}
+	return map[string]cache.Stats{
+		"settings": c.settings.Stats(),
+		"tags":     c.tags.Stats(),
+		"folders":  c.folders.Stats(),
+		"snippets": c.snippets.Stats(),
+	}
+}
+
+// Purge drops everything, for bulk changes made outside the repositories
+// such as restoring a backup
+func (c *ReadCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.settings.Purge()
+	c.tags.Purge()
+	c.folders.Purge()
+	c.snippets.Purge()
+}
+
+// getSettings returns a copy of the cached settings
+func (c *ReadCache) getSettings() (*models.Settings, bool) {
+	if c == nil {
+		return nil, false
+	}
+	settings, ok := c.settings.Get(struct{}{})
+	return &settings, ok
+}
+
+func (c *ReadCache) setSettings(settings *models.Settings) {
+	if c != nil && settings != nil {
+		c.settings.Set(struct{}{}, *settings)
+	}
+}
+
+func (c *ReadCache) invalidateSettings() {
+	if c != nil {
+		c.settings.Purge()
+	}
+}
+
+// getTags returns a copy of the cached tag list
+func (c *ReadCache) getTags() ([]models.Tag, bool) {
+	if c == nil {
+		return nil, false
+	}
+	tags, ok := c.tags.Get(struct{}{})
+	return append([]models.Tag(nil), tags...), ok
+}
+
+func (c *ReadCache) setTags(tags []models.Tag) {
+	if c != nil {
+		c.tags.Set(struct{}{}, append([]models.Tag(nil), tags...))
+	}
+}
+
+// getFolders returns a copy of the cached flat folder list
+func (c *ReadCache) getFolders() ([]models.Folder, bool) {
+	if c == nil {
+		return nil, false
+	}
+	folders, ok := c.folders.Get(struct{}{})
+	return append([]models.Folder(nil), folders...), ok
+}
+
+func (c *ReadCache) setFolders(folders []models.Folder) {
+	if c != nil {
+		c.folders.Set(struct{}{}, append([]models.Folder(nil), folders...))
+	}
+}
+
+// invalidateLists drops the tag and folder lists. Their snippet counts
+// change with snippet writes too.
+func (c *ReadCache) invalidateLists() {
+	if c != nil {
+		c.tags.Purge()
+		c.folders.Purge()
+	}
+}
+
+// getSnippet returns a copy of a cached snippet row
+func (c *ReadCache) getSnippet(id string) (*models.Snippet, bool) {
+	if c == nil {
+		return nil, false
+	}
+	snippet, ok := c.snippets.Get(id)
+	return &snippet, ok
+}
+
+func (c *ReadCache) setSnippet(snippet *models.Snippet) {
+	if c != nil && snippet != nil {
+		c.snippets.Set(snippet.ID, *snippet)
+	}
+}
+
+func (c *ReadCache) invalidateSnippet(id string) {
+	if c != nil {
+		c.snippets.Delete(id)
+	}
+}
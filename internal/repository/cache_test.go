@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestReadCache_Snippets(t *testing.T) {
+	db := testutil.TestDB(t)
+	c := NewReadCache(10, time.Minute)
+	repo := NewSnippetRepository(db).WithCache(c)
+	ctx := testutil.TestContext()
+
+	created, err := repo.Create(ctx, &models.SnippetInput{Title: "Cached", Content: "x", Language: "go"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	first, _ := repo.GetByID(ctx, created.ID)
+	second, _ := repo.GetByID(ctx, created.ID)
+	if stats := c.Stats()["snippets"]; stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("expected one hit and one miss, got %+v", stats)
+	}
+
+	// Callers get copies they may modify
+	first.Title = "changed"
+	if second.Title != "Cached" {
+		t.Error("expected cached snippets to be copies")
+	}
+
+	if _, err := repo.Update(ctx, created.ID, &models.SnippetInput{Title: "Updated", Content: "x", Language: "go"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, _ := repo.GetByID(ctx, created.ID)
+	if got.Title != "Updated" {
+		t.Errorf("expected update to invalidate the cache, got %q", got.Title)
+	}
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, created.ID); got != nil {
+		t.Error("expected deleted snippet not to be served from the cache")
+	}
+}
+
+func TestReadCache_ListsFollowSnippetWrites(t *testing.T) {
+	db := testutil.TestDB(t)
+	c := NewReadCache(10, time.Minute)
+	snippetRepo := NewSnippetRepository(db).WithCache(c)
+	tagRepo := NewTagRepository(db).WithCache(c)
+	ctx := testutil.TestContext()
+
+	snippet, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Tagged", Content: "x", Language: "go"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := tagRepo.SetSnippetTags(ctx, snippet.ID, []string{"go"}); err != nil {
+		t.Fatalf("SetSnippetTags failed: %v", err)
+	}
+
+	tags, _ := tagRepo.List(ctx)
+	if len(tags) != 1 || tags[0].SnippetCount != 1 {
+		t.Fatalf("expected one tag used once, got %+v", tags)
+	}
+
+	// Archiving changes the count, so the cached list must go
+	if _, err := snippetRepo.ToggleArchive(ctx, snippet.ID); err != nil {
+		t.Fatalf("ToggleArchive failed: %v", err)
+	}
+	tags, _ = tagRepo.List(ctx)
+	if tags[0].SnippetCount != 0 {
+		t.Errorf("expected archived snippet not to be counted, got %d", tags[0].SnippetCount)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// checkoutColumns lists the columns read by every check-out query, in scan
+// order
+const checkoutColumns = `id, snippet_id, snippet_title, holder, source_ip, reason,
+	checked_out_at, expires_at, checked_in_at, note`
+
+// CheckoutRepository handles check-outs of snippets that require them
+type CheckoutRepository struct {
+	db *sql.DB
+}
+
+// NewCheckoutRepository creates a new check-out repository
+func NewCheckoutRepository(db *sql.DB) *CheckoutRepository {
+	return &CheckoutRepository{db: db}
+}
+
+func scanCheckout(row interface{ Scan(...interface{}) error }) (*models.SnippetCheckout, error) {
+	c := &models.SnippetCheckout{}
+	err := row.Scan(&c.ID, &c.SnippetID, &c.SnippetTitle, &c.Holder, &c.SourceIP, &c.Reason,
+		&c.CheckedOutAt, &c.ExpiresAt, &c.CheckedInAt, &c.Note)
+	return c, err
+}
+
+// Checkout checks out a snippet for ttl seconds. Only one check-out is held
+// at a time: while another is, it returns that one and false.
+func (r *CheckoutRepository) Checkout(ctx context.Context, checkout *models.SnippetCheckout, ttl int) (*models.SnippetCheckout, bool, error) {
+	created, err := scanCheckout(r.db.QueryRowContext(ctx, `
+		INSERT INTO snippet_checkouts (snippet_id, snippet_title, holder, source_ip, reason, expires_at)
+		SELECT ?, ?, ?, ?, ?, datetime('now', ?)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM snippet_checkouts
+			WHERE snippet_id = ? AND checked_in_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		)
+		RETURNING `+checkoutColumns,
+		checkout.SnippetID, checkout.SnippetTitle, checkout.Holder, checkout.SourceIP, checkout.Reason,
+		fmt.Sprintf("+%d seconds", ttl), checkout.SnippetID))
+	if errors.Is(err, sql.ErrNoRows) {
+		held, err := r.Active(ctx, checkout.SnippetID)
+		return held, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check out snippet: %w", err)
+	}
+	return created, true, nil
+}
+
+// Active returns the check-out held on a snippet, or nil when there is none
+func (r *CheckoutRepository) Active(ctx context.Context, snippetID string) (*models.SnippetCheckout, error) {
+	checkout, err := scanCheckout(r.db.QueryRowContext(ctx, `
+		SELECT `+checkoutColumns+`
+		FROM snippet_checkouts
+		WHERE snippet_id = ? AND checked_in_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, snippetID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check-out: %w", err)
+	}
+	return checkout, nil
+}
+
+// Checkin ends the check-out holder has on a snippet, returning
+// ErrNotFound when holder has none
+func (r *CheckoutRepository) Checkin(ctx context.Context, snippetID, holder, note string) (*models.SnippetCheckout, error) {
+	checkout, err := scanCheckout(r.db.QueryRowContext(ctx, `
+		UPDATE snippet_checkouts
+		SET checked_in_at = CURRENT_TIMESTAMP, note = ?
+		WHERE snippet_id = ? AND holder = ? AND checked_in_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING `+checkoutColumns,
+		note, snippetID, holder))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check in snippet: %w", err)
+	}
+	return checkout, nil
+}
+
+// List returns the newest check-outs of a snippet, or of every snippet
+// when snippetID is empty
+func (r *CheckoutRepository) List(ctx context.Context, snippetID string, limit int) ([]models.SnippetCheckout, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+checkoutColumns+`
+		FROM snippet_checkouts
+		WHERE ? = '' OR snippet_id = ?
+		ORDER BY checked_out_at DESC, id DESC
+		LIMIT ?
+	`, snippetID, snippetID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check-outs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	checkouts := []models.SnippetCheckout{}
+	for rows.Next() {
+		checkout, err := scanCheckout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan check-out: %w", err)
+		}
+		checkouts = append(checkouts, *checkout)
+	}
+	return checkouts, rows.Err()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// CollabRepository stores the state of documents being edited together
+type CollabRepository struct {
+	db *sql.DB
+}
+
+// NewCollabRepository creates a new collab repository
+func NewCollabRepository(db *sql.DB) *CollabRepository {
+	return &CollabRepository{db: db}
+}
+
+// GetDocument returns the saved state of a snippet file's document, or nil
+func (r *CollabRepository) GetDocument(ctx context.Context, snippetID string, fileID int64) (*models.CollabDocument, error) {
+	doc := &models.CollabDocument{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT snippet_id, file_id, state, base_hash, updated_at
+		FROM collab_documents
+		WHERE snippet_id = ? AND file_id = ?
+	`, snippetID, fileID).Scan(&doc.SnippetID, &doc.FileID, &doc.State, &doc.BaseHash, &doc.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collab document: %w", err)
+	}
+	return doc, nil
+}
+
+// SaveDocument stores the state of a document, replacing any saved before
+func (r *CollabRepository) SaveDocument(ctx context.Context, doc *models.CollabDocument) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO collab_documents (snippet_id, file_id, state, base_hash, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(snippet_id, file_id) DO UPDATE SET
+			state = excluded.state,
+			base_hash = excluded.base_hash,
+			updated_at = excluded.updated_at
+	`, doc.SnippetID, doc.FileID, doc.State, doc.BaseHash)
+	if err != nil {
+		return fmt.Errorf("failed to save collab document: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocument removes the saved state of a document
+func (r *CollabRepository) DeleteDocument(ctx context.Context, snippetID string, fileID int64) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM collab_documents WHERE snippet_id = ? AND file_id = ?", snippetID, fileID); err != nil {
+		return fmt.Errorf("failed to delete collab document: %w", err)
+	}
+	return nil
+}
@@ -6,4 +6,8 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrTokenNotAllowed is returned for an API token used from an address
+	// or referrer it is not allowed from
+	ErrTokenNotAllowed = errors.New("token not allowed from this client")
 )
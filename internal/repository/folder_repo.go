@@ -5,13 +5,15 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/MohamedElashri/snipo/internal/models"
 )
 
 // FolderRepository handles folder database operations
 type FolderRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	cache *ReadCache
 }
 
 // NewFolderRepository creates a new folder repository
@@ -19,28 +21,43 @@ func NewFolderRepository(db *sql.DB) *FolderRepository {
 	return &FolderRepository{db: db}
 }
 
+// WithCache serves reads from c and invalidates it on writes
+func (r *FolderRepository) WithCache(c *ReadCache) *FolderRepository {
+	r.cache = c
+	return r
+}
+
 // Create creates a new folder
 func (r *FolderRepository) Create(ctx context.Context, input *models.FolderInput) (*models.Folder, error) {
+	defer r.cache.invalidateLists()
+
 	icon := input.Icon
 	if icon == "" {
 		icon = "folder"
 	}
 
 	query := `
-		INSERT INTO folders (name, parent_id, icon, sort_order)
-		VALUES (?, ?, ?, ?)
-		RETURNING id, name, parent_id, icon, sort_order, created_at
+		INSERT INTO folders (name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug)
+		VALUES (?, ?, ?, ?, ?, COALESCE(?, 0), COALESCE(?, 0), NULLIF(?, ''))
+		RETURNING id, name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug, created_at
 	`
 
 	folder := &models.Folder{}
-	err := r.db.QueryRowContext(ctx, query, input.Name, input.ParentID, icon, input.SortOrder).Scan(
+	err := r.db.QueryRowContext(ctx, query, input.Name, input.ParentID, icon, input.Color, input.SortOrder, input.ArchiveAfterDays, input.IsPublic, input.Slug).Scan(
 		&folder.ID,
 		&folder.Name,
 		&folder.ParentID,
 		&folder.Icon,
+		&folder.Color,
 		&folder.SortOrder,
+		&folder.ArchiveAfterDays,
+		&folder.IsPublic,
+		&folder.Slug,
 		&folder.CreatedAt,
 	)
+	if isFolderSlugConflict(err) {
+		return nil, ErrAlreadyExists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create folder: %w", err)
 	}
@@ -50,7 +67,7 @@ func (r *FolderRepository) Create(ctx context.Context, input *models.FolderInput
 
 // GetByID retrieves a folder by ID
 func (r *FolderRepository) GetByID(ctx context.Context, id int64) (*models.Folder, error) {
-	query := `SELECT id, name, parent_id, icon, sort_order, created_at FROM folders WHERE id = ?`
+	query := `SELECT id, name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug, created_at FROM folders WHERE id = ?`
 
 	folder := &models.Folder{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -58,7 +75,11 @@ func (r *FolderRepository) GetByID(ctx context.Context, id int64) (*models.Folde
 		&folder.Name,
 		&folder.ParentID,
 		&folder.Icon,
+		&folder.Color,
 		&folder.SortOrder,
+		&folder.ArchiveAfterDays,
+		&folder.IsPublic,
+		&folder.Slug,
 		&folder.CreatedAt,
 	)
 	if err != nil {
@@ -71,10 +92,72 @@ func (r *FolderRepository) GetByID(ctx context.Context, id int64) (*models.Folde
 	return folder, nil
 }
 
+// GetBySlug retrieves a folder by its slug
+func (r *FolderRepository) GetBySlug(ctx context.Context, slug string) (*models.Folder, error) {
+	query := `SELECT id, name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug, created_at FROM folders WHERE slug = ?`
+
+	folder := &models.Folder{}
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+		&folder.ID,
+		&folder.Name,
+		&folder.ParentID,
+		&folder.Icon,
+		&folder.Color,
+		&folder.SortOrder,
+		&folder.ArchiveAfterDays,
+		&folder.IsPublic,
+		&folder.Slug,
+		&folder.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get folder by slug: %w", err)
+	}
+
+	return folder, nil
+}
+
+// SlugsWithPrefix returns the folder slugs equal to base or starting with
+// base-, ignoring the folder excludeID
+func (r *FolderRepository) SlugsWithPrefix(ctx context.Context, base string, excludeID int64) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT slug FROM folders
+		WHERE (slug = ? OR slug LIKE ?) AND id != ?
+	`, base, base+"-%", excludeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folder slugs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	slugs := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, fmt.Errorf("failed to scan slug: %w", err)
+		}
+		slugs[slug] = true
+	}
+	return slugs, rows.Err()
+}
+
+// isFolderSlugConflict reports whether err is a unique index violation on
+// the folder slug
+func isFolderSlugConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: folders.slug")
+}
+
 // List retrieves all folders (flat list) with snippet counts
 func (r *FolderRepository) List(ctx context.Context) ([]models.Folder, error) {
+	if folders, ok := r.cache.getFolders(); ok {
+		return folders, nil
+	}
+
 	query := `
-		SELECT f.id, f.name, f.parent_id, f.icon, f.sort_order, f.created_at,
+		SELECT f.id, f.name, f.parent_id, f.icon, f.color, f.sort_order, f.archive_after_days, f.is_public, f.slug, f.created_at,
 		       (SELECT COUNT(*) FROM snippet_folders sf 
 		        INNER JOIN snippets s ON s.id = sf.snippet_id 
 		        WHERE sf.folder_id = f.id AND s.is_archived = 0) as snippet_count
@@ -100,7 +183,11 @@ func (r *FolderRepository) List(ctx context.Context) ([]models.Folder, error) {
 			&folder.Name,
 			&folder.ParentID,
 			&folder.Icon,
+			&folder.Color,
 			&folder.SortOrder,
+			&folder.ArchiveAfterDays,
+			&folder.IsPublic,
+			&folder.Slug,
 			&folder.CreatedAt,
 			&folder.SnippetCount,
 		); err != nil {
@@ -113,6 +200,7 @@ func (r *FolderRepository) List(ctx context.Context) ([]models.Folder, error) {
 		return nil, fmt.Errorf("error iterating folders: %w", err)
 	}
 
+	r.cache.setFolders(folders)
 	return folders, nil
 }
 
@@ -164,6 +252,8 @@ func buildFolderTree(folders []models.Folder) []models.Folder {
 
 // Update updates an existing folder
 func (r *FolderRepository) Update(ctx context.Context, id int64, input *models.FolderInput) (*models.Folder, error) {
+	defer r.cache.invalidateLists()
+
 	icon := input.Icon
 	if icon == "" {
 		icon = "folder"
@@ -171,33 +261,64 @@ func (r *FolderRepository) Update(ctx context.Context, id int64, input *models.F
 
 	query := `
 		UPDATE folders
-		SET name = ?, parent_id = ?, icon = ?, sort_order = ?
+		SET name = ?, parent_id = ?, icon = ?, color = ?, sort_order = ?,
+		    archive_after_days = COALESCE(?, archive_after_days),
+		    is_public = COALESCE(?, is_public),
+		    slug = CASE WHEN ? IS NULL THEN slug ELSE NULLIF(?, '') END
 		WHERE id = ?
-		RETURNING id, name, parent_id, icon, sort_order, created_at
+		RETURNING id, name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug, created_at
 	`
 
 	folder := &models.Folder{}
-	err := r.db.QueryRowContext(ctx, query, input.Name, input.ParentID, icon, input.SortOrder, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, input.Name, input.ParentID, icon, input.Color, input.SortOrder, input.ArchiveAfterDays, input.IsPublic, input.Slug, input.Slug, id).Scan(
 		&folder.ID,
 		&folder.Name,
 		&folder.ParentID,
 		&folder.Icon,
+		&folder.Color,
 		&folder.SortOrder,
+		&folder.ArchiveAfterDays,
+		&folder.IsPublic,
+		&folder.Slug,
 		&folder.CreatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
+		if isFolderSlugConflict(err) {
+			return nil, ErrAlreadyExists
+		}
 		return nil, fmt.Errorf("failed to update folder: %w", err)
 	}
 
 	return folder, nil
 }
 
-// Delete deletes a folder
+// Delete deletes a folder and its subfolders. Tokens lose their grants on
+// them in the same transaction, so a folder that later reuses an ID is not
+// reachable through an old grant.
 func (r *FolderRepository) Delete(ctx context.Context, id int64) error {
-	result, err := r.db.ExecContext(ctx, `DELETE FROM folders WHERE id = ?`, id)
+	defer r.cache.invalidateLists()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		WITH RECURSIVE tree(id) AS (
+			SELECT ?
+			UNION
+			SELECT f.id FROM folders f JOIN tree ON f.parent_id = tree.id
+		)
+		DELETE FROM token_folders WHERE folder_id IN (SELECT id FROM tree)`, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete token folders: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM folders WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete folder: %w", err)
 	}
@@ -211,11 +332,16 @@ func (r *FolderRepository) Delete(ctx context.Context, id int64) error {
 		return ErrNotFound
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 // Move moves a folder to a new parent
 func (r *FolderRepository) Move(ctx context.Context, id int64, newParentID *int64) (*models.Folder, error) {
+	defer r.cache.invalidateLists()
+
 	// Check for circular reference
 	if newParentID != nil {
 		if err := r.checkCircularReference(ctx, id, *newParentID); err != nil {
@@ -227,7 +353,7 @@ func (r *FolderRepository) Move(ctx context.Context, id int64, newParentID *int6
 		UPDATE folders
 		SET parent_id = ?
 		WHERE id = ?
-		RETURNING id, name, parent_id, icon, sort_order, created_at
+		RETURNING id, name, parent_id, icon, color, sort_order, archive_after_days, is_public, slug, created_at
 	`
 
 	folder := &models.Folder{}
@@ -236,7 +362,11 @@ func (r *FolderRepository) Move(ctx context.Context, id int64, newParentID *int6
 		&folder.Name,
 		&folder.ParentID,
 		&folder.Icon,
+		&folder.Color,
 		&folder.SortOrder,
+		&folder.ArchiveAfterDays,
+		&folder.IsPublic,
+		&folder.Slug,
 		&folder.CreatedAt,
 	)
 	if err != nil {
@@ -293,7 +423,7 @@ func (r *FolderRepository) GetFolderSnippetCount(ctx context.Context, folderID i
 // GetSnippetFolders retrieves all folders for a snippet
 func (r *FolderRepository) GetSnippetFolders(ctx context.Context, snippetID string) ([]models.Folder, error) {
 	query := `
-		SELECT f.id, f.name, f.parent_id, f.icon, f.sort_order, f.created_at
+		SELECT f.id, f.name, f.parent_id, f.icon, f.color, f.sort_order, f.archive_after_days, f.is_public, f.slug, f.created_at
 		FROM folders f
 		JOIN snippet_folders sf ON f.id = sf.folder_id
 		WHERE sf.snippet_id = ?
@@ -318,7 +448,11 @@ func (r *FolderRepository) GetSnippetFolders(ctx context.Context, snippetID stri
 			&folder.Name,
 			&folder.ParentID,
 			&folder.Icon,
+			&folder.Color,
 			&folder.SortOrder,
+			&folder.ArchiveAfterDays,
+			&folder.IsPublic,
+			&folder.Slug,
 			&folder.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan folder: %w", err)
@@ -331,6 +465,8 @@ func (r *FolderRepository) GetSnippetFolders(ctx context.Context, snippetID stri
 
 // SetSnippetFolder sets the folder for a snippet
 func (r *FolderRepository) SetSnippetFolder(ctx context.Context, snippetID string, folderID *int64) error {
+	defer r.cache.invalidateLists()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -206,6 +206,7 @@ func TestFolderRepository_Update(t *testing.T) {
 	updateInput := &models.FolderInput{
 		Name:      "Updated",
 		Icon:      "star",
+		Color:     "#f59e0b",
 		SortOrder: 5,
 	}
 	updated, err := repo.Update(ctx, created.ID, updateInput)
@@ -219,6 +220,9 @@ func TestFolderRepository_Update(t *testing.T) {
 	if updated.Icon != updateInput.Icon {
 		t.Errorf("expected icon %q, got %q", updateInput.Icon, updated.Icon)
 	}
+	if updated.Color != updateInput.Color {
+		t.Errorf("expected color %q, got %q", updateInput.Color, updated.Color)
+	}
 	if updated.SortOrder != updateInput.SortOrder {
 		t.Errorf("expected sort_order %d, got %d", updateInput.SortOrder, updated.SortOrder)
 	}
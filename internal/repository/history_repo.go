@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/MohamedElashri/snipo/internal/models"
 )
@@ -19,12 +20,13 @@ func NewHistoryRepository(db *sql.DB) *HistoryRepository {
 	return &HistoryRepository{db: db}
 }
 
-// CreateHistory creates a new history entry for a snippet
-func (r *HistoryRepository) CreateHistory(ctx context.Context, snippet *models.Snippet, changeType string) (int64, error) {
+// CreateHistory creates a new history entry for a snippet, recording who
+// made the change and from which IP address
+func (r *HistoryRepository) CreateHistory(ctx context.Context, snippet *models.Snippet, changeType, changedBy, sourceIP string) (int64, error) {
 	query := `
 		INSERT INTO snippet_history 
-		(snippet_id, title, description, content, language, is_favorite, is_public, is_archived, change_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(snippet_id, title, description, content, language, is_favorite, is_public, is_archived, change_type, changed_by, source_ip)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -37,6 +39,8 @@ func (r *HistoryRepository) CreateHistory(ctx context.Context, snippet *models.S
 		snippet.IsPublic,
 		snippet.IsArchived,
 		changeType,
+		changedBy,
+		sourceIP,
 	)
 
 	if err != nil {
@@ -51,6 +55,33 @@ func (r *HistoryRepository) CreateHistory(ctx context.Context, snippet *models.S
 	return historyID, nil
 }
 
+// CreateMetadataHistory records a metadata change of a snippet. Only the
+// entry's snippet ID, title, language, flags, change type, details and
+// actor are stored.
+func (r *HistoryRepository) CreateMetadataHistory(ctx context.Context, entry *models.SnippetHistory) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO snippet_history
+		(snippet_id, title, content, language, is_favorite, is_public, is_archived, change_type, details, changed_by, source_ip)
+		VALUES (?, ?, '', ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		entry.SnippetID,
+		entry.Title,
+		entry.Language,
+		entry.IsFavorite,
+		entry.IsPublic,
+		entry.IsArchived,
+		entry.ChangeType,
+		entry.Details,
+		entry.ChangedBy,
+		entry.SourceIP,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create snippet history: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
 // CreateFileHistory creates history entries for snippet files
 func (r *HistoryRepository) CreateFileHistory(ctx context.Context, historyID int64, files []models.SnippetFile) error {
 	if len(files) == 0 {
@@ -98,7 +129,7 @@ func (r *HistoryRepository) GetSnippetHistory(ctx context.Context, snippetID str
 
 	query := `
 		SELECT id, snippet_id, title, description, content, language, 
-		       is_favorite, is_public, is_archived, change_type, created_at
+		       is_favorite, is_public, is_archived, change_type, details, changed_by, source_ip, created_at
 		FROM snippet_history
 		WHERE snippet_id = ?
 		ORDER BY created_at DESC
@@ -129,6 +160,9 @@ func (r *HistoryRepository) GetSnippetHistory(ctx context.Context, snippetID str
 			&h.IsPublic,
 			&h.IsArchived,
 			&h.ChangeType,
+			&h.Details,
+			&h.ChangedBy,
+			&h.SourceIP,
 			&h.CreatedAt,
 		)
 		if err != nil {
@@ -154,11 +188,78 @@ func (r *HistoryRepository) GetSnippetHistory(ctx context.Context, snippetID str
 	return history, nil
 }
 
+// SearchHistory retrieves the create and update entries matching a
+// filter, with their files, newest first
+func (r *HistoryRepository) SearchHistory(ctx context.Context, filter models.HistoryFilter) ([]models.SnippetHistory, error) {
+	where := []string{`h.change_type IN ('create', 'update')`}
+	var args []any
+	if filter.SnippetID != "" {
+		where = append(where, `h.snippet_id = ?`)
+		args = append(args, filter.SnippetID)
+	}
+	if filter.Text != "" {
+		where = append(where, `(h.title LIKE ? ESCAPE '\' OR h.description LIKE ? ESCAPE '\' OR h.content LIKE ? ESCAPE '\'
+			OR EXISTS (SELECT 1 FROM snippet_files_history f WHERE f.history_id = h.id AND f.content LIKE ? ESCAPE '\'))`)
+		pattern := "%" + likePrefix(filter.Text)
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	if filter.From != "" {
+		where = append(where, `date(h.created_at) >= ?`)
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where = append(where, `date(h.created_at) <= ?`)
+		args = append(args, filter.To)
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.id, h.snippet_id, h.title, h.description, h.content, h.language,
+		       h.is_favorite, h.is_public, h.is_archived, h.change_type, h.details, h.changed_by, h.source_ip, h.created_at
+		FROM snippet_history h
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY h.created_at DESC, h.id DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search history: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	var history []models.SnippetHistory
+	for rows.Next() {
+		var h models.SnippetHistory
+		err := rows.Scan(&h.ID, &h.SnippetID, &h.Title, &h.Description, &h.Content, &h.Language,
+			&h.IsFavorite, &h.IsPublic, &h.IsArchived, &h.ChangeType, &h.Details, &h.ChangedBy, &h.SourceIP, &h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+		history = append(history, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating history rows: %w", err)
+	}
+
+	for i := range history {
+		files, err := r.GetHistoryFiles(ctx, history[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		history[i].Files = files
+	}
+
+	return history, nil
+}
+
 // GetHistoryByID retrieves a specific history entry by ID
 func (r *HistoryRepository) GetHistoryByID(ctx context.Context, historyID int64) (*models.SnippetHistory, error) {
 	query := `
 		SELECT id, snippet_id, title, description, content, language,
-		       is_favorite, is_public, is_archived, change_type, created_at
+		       is_favorite, is_public, is_archived, change_type, details, changed_by, source_ip, created_at
 		FROM snippet_history
 		WHERE id = ?
 	`
@@ -175,6 +276,9 @@ func (r *HistoryRepository) GetHistoryByID(ctx context.Context, historyID int64)
 		&h.IsPublic,
 		&h.IsArchived,
 		&h.ChangeType,
+		&h.Details,
+		&h.ChangedBy,
+		&h.SourceIP,
 		&h.CreatedAt,
 	)
 
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// InboxRepository handles clipboard inbox database operations
+type InboxRepository struct {
+	db *sql.DB
+}
+
+// NewInboxRepository creates a new inbox repository
+func NewInboxRepository(db *sql.DB) *InboxRepository {
+	return &InboxRepository{db: db}
+}
+
+// Create stores an item and drops the oldest items beyond max (0 keeps all)
+func (r *InboxRepository) Create(ctx context.Context, item *models.InboxItem, max int) (*models.InboxItem, error) {
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now().UTC()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO inbox_items (content, source, created_at) VALUES (?, ?, ?)`,
+		item.Content, item.Source, item.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inbox item: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox item ID: %w", err)
+	}
+	item.ID = id
+
+	if max > 0 {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM inbox_items WHERE id NOT IN (SELECT id FROM inbox_items ORDER BY id DESC LIMIT ?)`, max,
+		); err != nil {
+			return nil, fmt.Errorf("failed to trim inbox: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return item, nil
+}
+
+// GetByID retrieves an item, or nil if it does not exist
+func (r *InboxRepository) GetByID(ctx context.Context, id int64) (*models.InboxItem, error) {
+	var item models.InboxItem
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, content, source, created_at FROM inbox_items WHERE id = ?`, id,
+	).Scan(&item.ID, &item.Content, &item.Source, &item.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox item: %w", err)
+	}
+	return &item, nil
+}
+
+// List retrieves all items, newest first
+func (r *InboxRepository) List(ctx context.Context) ([]models.InboxItem, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, content, source, created_at FROM inbox_items ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbox items: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	items := []models.InboxItem{}
+	for rows.Next() {
+		var item models.InboxItem
+		if err := rows.Scan(&item.ID, &item.Content, &item.Source, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inbox item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inbox items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Delete removes an item and reports whether it existed
+func (r *InboxRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM inbox_items WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete inbox item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
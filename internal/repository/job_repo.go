@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// jobColumns is the column list shared by job queries, in scanJob order
+const jobColumns = `id, kind, status, payload, processed, total, errors, result, error,
+	attempts, max_attempts, run_after, created_at, started_at, finished_at`
+
+// JobRepository handles background job database operations
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row interface{ Scan(...any) error }) (*models.Job, error) {
+	var (
+		job     models.Job
+		payload string
+		errs    string
+		result  sql.NullString
+	)
+	if err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Status,
+		&payload,
+		&job.Processed,
+		&job.Total,
+		&errs,
+		&result,
+		&job.Error,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAfter,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	job.Payload = json.RawMessage(payload)
+	if result.Valid {
+		job.Result = json.RawMessage(result.String)
+	}
+	if err := json.Unmarshal([]byte(errs), &job.Errors); err != nil {
+		return nil, fmt.Errorf("failed to decode job errors: %w", err)
+	}
+	if len(job.Errors) == 0 {
+		job.Errors = nil
+	}
+	return &job, nil
+}
+
+// Create inserts a new pending job
+func (r *JobRepository) Create(ctx context.Context, job *models.Job) error {
+	payload := job.Payload
+	if payload == nil {
+		payload = json.RawMessage("null")
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO jobs (id, kind, status, payload, max_attempts, run_after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.Kind, job.Status, string(payload), job.MaxAttempts, job.RunAfter.UTC(), job.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a job by ID
+func (r *JobRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	job, err := scanJob(r.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// List retrieves jobs, newest first
+func (r *JobRepository) List(ctx context.Context, filter models.JobFilter) ([]models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE 1=1`
+	var args []interface{}
+
+	if filter.Kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, filter.Kind)
+	}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ClaimNext marks the oldest due pending job as running and returns it, or
+// nil when none is due. Claiming counts as an attempt.
+func (r *JobRepository) ClaimNext(ctx context.Context, now time.Time) (*models.Job, error) {
+	now = now.UTC()
+	job, err := scanJob(r.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = ?, attempts = attempts + 1, started_at = ?, error = ''
+		WHERE id = (
+			SELECT id FROM jobs WHERE status = ? AND run_after <= ?
+			ORDER BY run_after, created_at LIMIT 1
+		)
+		RETURNING `+jobColumns,
+		models.JobStatusRunning, now, models.JobStatusPending, now,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateProgress stores a running job's progress
+func (r *JobRepository) UpdateProgress(ctx context.Context, id string, processed, total int, errs []string) error {
+	encoded, err := json.Marshal(errs)
+	if err != nil {
+		return fmt.Errorf("failed to encode job errors: %w", err)
+	}
+	if errs == nil {
+		encoded = []byte("[]")
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE jobs SET processed = ?, total = ?, errors = ? WHERE id = ?`,
+		processed, total, string(encoded), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return nil
+}
+
+// Complete marks a job as succeeded with its result and drops its payload
+func (r *JobRepository) Complete(ctx context.Context, id string, result json.RawMessage, finishedAt time.Time) error {
+	var stored interface{}
+	if result != nil {
+		stored = string(result)
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jobs SET status = ?, result = ?, error = '', payload = 'null', finished_at = ? WHERE id = ?`,
+		models.JobStatusSucceeded, stored, finishedAt.UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Fail marks a job as failed for good and drops its payload
+func (r *JobRepository) Fail(ctx context.Context, id, message string, finishedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jobs SET status = ?, error = ?, payload = 'null', finished_at = ? WHERE id = ?`,
+		models.JobStatusFailed, message, finishedAt.UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fail job: %w", err)
+	}
+	return nil
+}
+
+// Retry puts a failed job back in the queue, due at runAfter
+func (r *JobRepository) Retry(ctx context.Context, id, message string, runAfter time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jobs SET status = ?, error = ?, run_after = ? WHERE id = ?`,
+		models.JobStatusPending, message, runAfter.UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+// Requeue returns an interrupted job to the queue without using up an attempt
+func (r *JobRepository) Requeue(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jobs SET status = ?, attempts = MAX(attempts - 1, 0) WHERE id = ?`,
+		models.JobStatusPending, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return nil
+}
+
+// RecoverInterrupted requeues jobs left running by a crash. Jobs that have
+// used up their attempts fail instead, so a job that crashes the process
+// cannot loop forever.
+func (r *JobRepository) RecoverInterrupted(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET
+			status = CASE WHEN attempts >= max_attempts THEN ? ELSE ? END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN ? ELSE NULL END,
+			error = 'interrupted by restart'
+		WHERE status = ?`,
+		models.JobStatusFailed, models.JobStatusPending, now.UTC(), models.JobStatusRunning,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteFinishedBefore removes succeeded and failed jobs finished before t
+func (r *JobRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM jobs WHERE status IN (?, ?) AND finished_at < ?`,
+		models.JobStatusSucceeded, models.JobStatusFailed, t.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
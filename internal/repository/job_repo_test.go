@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestJobRepository_ClaimAndComplete(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewJobRepository(db)
+	ctx := testutil.TestContext()
+	now := time.Now()
+
+	jobs := []models.Job{
+		{ID: "later", Kind: "k", Status: models.JobStatusPending, MaxAttempts: 1, RunAfter: now.Add(time.Hour), CreatedAt: now},
+		{ID: "due", Kind: "k", Status: models.JobStatusPending, Payload: json.RawMessage(`{"n":1}`), MaxAttempts: 1, RunAfter: now, CreatedAt: now},
+	}
+	for i := range jobs {
+		if err := repo.Create(ctx, &jobs[i]); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	claimed, err := repo.ClaimNext(ctx, now)
+	if err != nil {
+		t.Fatalf("ClaimNext failed: %v", err)
+	}
+	if claimed == nil || claimed.ID != "due" || claimed.Status != models.JobStatusRunning || claimed.Attempts != 1 {
+		t.Fatalf("expected the due job to be claimed, got %+v", claimed)
+	}
+	if string(claimed.Payload) != `{"n":1}` {
+		t.Errorf("expected payload to round-trip, got %s", claimed.Payload)
+	}
+
+	// Jobs scheduled for later are not due yet
+	if next, err := repo.ClaimNext(ctx, now); err != nil || next != nil {
+		t.Errorf("expected no due job, got %+v (err %v)", next, err)
+	}
+
+	if err := repo.UpdateProgress(ctx, "due", 2, 5, []string{"bad item"}); err != nil {
+		t.Fatalf("UpdateProgress failed: %v", err)
+	}
+	if err := repo.Complete(ctx, "due", json.RawMessage(`{"ok":true}`), now); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	done, err := repo.GetByID(ctx, "due")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if done.Status != models.JobStatusSucceeded || done.Processed != 2 || done.Total != 5 || len(done.Errors) != 1 {
+		t.Errorf("unexpected job: %+v", done)
+	}
+	if string(done.Result) != `{"ok":true}` || string(done.Payload) != "null" || done.FinishedAt == nil {
+		t.Errorf("expected result stored and payload dropped, got result %s payload %s", done.Result, done.Payload)
+	}
+
+	list, err := repo.List(ctx, models.JobFilter{Status: models.JobStatusPending})
+	if err != nil || len(list) != 1 || list[0].ID != "later" {
+		t.Errorf("expected only the pending job, got %+v (err %v)", list, err)
+	}
+
+	deleted, err := repo.DeleteFinishedBefore(ctx, now.Add(time.Minute))
+	if err != nil || deleted != 1 {
+		t.Errorf("expected 1 finished job deleted, got %d (err %v)", deleted, err)
+	}
+}
+
+func TestJobRepository_RecoverInterrupted(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewJobRepository(db)
+	ctx := testutil.TestContext()
+	now := time.Now()
+
+	for _, job := range []models.Job{
+		{ID: "retry", Kind: "k", Status: models.JobStatusPending, MaxAttempts: 2, RunAfter: now, CreatedAt: now},
+		{ID: "exhausted", Kind: "k", Status: models.JobStatusPending, MaxAttempts: 1, RunAfter: now, CreatedAt: now},
+	} {
+		if err := repo.Create(ctx, &job); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		// Simulate a crash while running
+		if _, err := repo.ClaimNext(ctx, now); err != nil {
+			t.Fatalf("ClaimNext failed: %v", err)
+		}
+	}
+
+	recovered, err := repo.RecoverInterrupted(ctx, now)
+	if err != nil || recovered != 2 {
+		t.Fatalf("expected 2 recovered jobs, got %d (err %v)", recovered, err)
+	}
+
+	if job, _ := repo.GetByID(ctx, "retry"); job.Status != models.JobStatusPending {
+		t.Errorf("expected job with attempts left to be pending, got %s", job.Status)
+	}
+	if job, _ := repo.GetByID(ctx, "exhausted"); job.Status != models.JobStatusFailed || job.FinishedAt == nil {
+		t.Errorf("expected exhausted job to fail, got %+v", job)
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// LinkRepository handles links between snippets
+type LinkRepository struct {
+	db *sql.DB
+}
+
+// NewLinkRepository creates a new link repository
+func NewLinkRepository(db *sql.DB) *LinkRepository {
+	return &LinkRepository{db: db}
+}
+
+// Create links source to target. Linking an existing auto link by hand
+// keeps it when the URL is later removed from the content.
+func (r *LinkRepository) Create(ctx context.Context, sourceID, targetID, relation string) (*models.SnippetLink, error) {
+	link := &models.SnippetLink{}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO snippet_links (source_id, target_id, relation)
+		VALUES (?, ?, ?)
+		ON CONFLICT(source_id, target_id, relation) DO UPDATE SET auto = 0
+		RETURNING id, source_id, target_id, relation, auto, created_at
+	`, sourceID, targetID, relation).Scan(&link.ID, &link.SourceID, &link.TargetID, &link.Relation, &link.Auto, &link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link: %w", err)
+	}
+	return link, nil
+}
+
+// ListFrom returns the links of a snippet with their targets' titles
+func (r *LinkRepository) ListFrom(ctx context.Context, sourceID string) ([]models.SnippetLink, error) {
+	return r.list(ctx, `
+		SELECT l.id, l.source_id, l.target_id, l.relation, l.auto, s.title, l.created_at
+		FROM snippet_links l
+		JOIN snippets s ON s.id = l.target_id
+		WHERE l.source_id = ?
+		ORDER BY l.relation, s.title
+	`, sourceID)
+}
+
+// ListTo returns the links pointing to a snippet with their sources' titles
+func (r *LinkRepository) ListTo(ctx context.Context, targetID string) ([]models.SnippetLink, error) {
+	return r.list(ctx, `
+		SELECT l.id, l.source_id, l.target_id, l.relation, l.auto, s.title, l.created_at
+		FROM snippet_links l
+		JOIN snippets s ON s.id = l.source_id
+		WHERE l.target_id = ?
+		ORDER BY l.relation, s.title
+	`, targetID)
+}
+
+func (r *LinkRepository) list(ctx context.Context, query, id string) ([]models.SnippetLink, error) {
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	links := []models.SnippetLink{}
+	for rows.Next() {
+		var l models.SnippetLink
+		if err := rows.Scan(&l.ID, &l.SourceID, &l.TargetID, &l.Relation, &l.Auto, &l.Title, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// Delete removes a link of the source snippet, reporting whether it existed
+func (r *LinkRepository) Delete(ctx context.Context, sourceID string, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM snippet_links WHERE id = ? AND source_id = ?", id, sourceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete link: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// SyncAuto replaces the auto links of a snippet with references to targetIDs
+func (r *LinkRepository) SyncAuto(ctx context.Context, sourceID string, targetIDs []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "DELETE FROM snippet_links WHERE source_id = ? AND auto = 1"
+	args := []interface{}{sourceID}
+	if len(targetIDs) > 0 {
+		query += " AND target_id NOT IN (" + strings.TrimSuffix(strings.Repeat("?,", len(targetIDs)), ",") + ")"
+		for _, id := range targetIDs {
+			args = append(args, id)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to clear auto links: %w", err)
+	}
+
+	for _, targetID := range targetIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO snippet_links (source_id, target_id, relation, auto)
+			VALUES (?, ?, ?, 1)
+			ON CONFLICT(source_id, target_id, relation) DO NOTHING
+		`, sourceID, targetID, models.LinkReferences); err != nil {
+			return fmt.Errorf("failed to create auto link: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// LockRepository handles advisory edit locks on snippets
+type LockRepository struct {
+	db *sql.DB
+}
+
+// NewLockRepository creates a new lock repository
+func NewLockRepository(db *sql.DB) *LockRepository {
+	return &LockRepository{db: db}
+}
+
+// Acquire takes the edit lock of a snippet for ttl seconds, or renews it
+// when lockID names the held lock. An empty lockID takes a new lock. When
+// another unexpired lock is held, it returns that lock and false.
+func (r *LockRepository) Acquire(ctx context.Context, snippetID, lockID, holder string, ttl int) (*models.SnippetLock, bool, error) {
+	lock := &models.SnippetLock{}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO snippet_locks (snippet_id, lock_id, holder, expires_at)
+		VALUES (?, COALESCE(NULLIF(?, ''), lower(hex(randomblob(16)))), ?, datetime('now', ?))
+		ON CONFLICT(snippet_id) DO UPDATE SET
+			acquired_at = CASE WHEN snippet_locks.lock_id = excluded.lock_id THEN snippet_locks.acquired_at ELSE CURRENT_TIMESTAMP END,
+			lock_id = excluded.lock_id,
+			holder = excluded.holder,
+			expires_at = excluded.expires_at
+		WHERE snippet_locks.lock_id = excluded.lock_id OR snippet_locks.expires_at <= CURRENT_TIMESTAMP
+		RETURNING snippet_id, lock_id, holder, acquired_at, expires_at
+	`, snippetID, lockID, holder, fmt.Sprintf("+%d seconds", ttl)).Scan(&lock.SnippetID, &lock.LockID, &lock.Holder, &lock.AcquiredAt, &lock.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		held, err := r.Get(ctx, snippetID)
+		return held, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return lock, true, nil
+}
+
+// Get returns the unexpired lock of a snippet, or nil when it is not locked
+func (r *LockRepository) Get(ctx context.Context, snippetID string) (*models.SnippetLock, error) {
+	lock := &models.SnippetLock{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT snippet_id, lock_id, holder, acquired_at, expires_at
+		FROM snippet_locks
+		WHERE snippet_id = ? AND expires_at > CURRENT_TIMESTAMP
+	`, snippetID).Scan(&lock.SnippetID, &lock.LockID, &lock.Holder, &lock.AcquiredAt, &lock.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock: %w", err)
+	}
+	return lock, nil
+}
+
+// Release drops the lock of a snippet if lockID still holds it
+func (r *LockRepository) Release(ctx context.Context, snippetID, lockID string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM snippet_locks WHERE snippet_id = ? AND lock_id = ?", snippetID, lockID); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
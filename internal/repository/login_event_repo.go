@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// maxUserAgentLength bounds stored user agents
+const maxUserAgentLength = 512
+
+// LoginEventRepository handles login audit database operations
+type LoginEventRepository struct {
+	db *sql.DB
+}
+
+// NewLoginEventRepository creates a new login event repository
+func NewLoginEventRepository(db *sql.DB) *LoginEventRepository {
+	return &LoginEventRepository{db: db}
+}
+
+// Create records a login attempt
+func (r *LoginEventRepository) Create(ctx context.Context, event *models.LoginEvent) (*models.LoginEvent, error) {
+	if len(event.UserAgent) > maxUserAgentLength {
+		// Cut on a rune boundary so the stored value stays valid UTF-8
+		cut := maxUserAgentLength
+		for cut > 0 && !utf8.RuneStart(event.UserAgent[cut]) {
+			cut--
+		}
+		event.UserAgent = event.UserAgent[:cut]
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO login_events (success, ip_address, user_agent, reason, created_at) VALUES (?, ?, ?, ?, ?)`,
+		event.Success, event.IPAddress, event.UserAgent, event.Reason, event.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login event ID: %w", err)
+	}
+	event.ID = id
+
+	return event, nil
+}
+
+// List retrieves login events, newest first
+func (r *LoginEventRepository) List(ctx context.Context, filter models.LoginEventFilter) ([]models.LoginEvent, error) {
+	query := `SELECT id, success, ip_address, user_agent, reason, created_at FROM login_events WHERE 1=1`
+	var args []interface{}
+
+	if filter.FailedOnly {
+		query += ` AND success = 0`
+	}
+	if filter.IPAddress != "" {
+		query += ` AND ip_address = ?`
+		args = append(args, filter.IPAddress)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login events: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	events := []models.LoginEvent{}
+	for rows.Next() {
+		var event models.LoginEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Success,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.Reason,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan login event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating login events: %w", err)
+	}
+
+	return events, nil
+}
+
+// CountFailuresSince counts failed attempts from an IP since the given time
+func (r *LoginEventRepository) CountFailuresSince(ctx context.Context, ip string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM login_events WHERE success = 0 AND ip_address = ? AND created_at >= ?`,
+		ip, since.UTC(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login failures: %w", err)
+	}
+	return count, nil
+}
+
+// CountSuccesses counts successful logins, optionally restricted to one IP,
+// excluding the event with the given ID
+func (r *LoginEventRepository) CountSuccesses(ctx context.Context, ip string, excludeID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM login_events WHERE success = 1 AND id != ?`
+	args := []interface{}{excludeID}
+	if ip != "" {
+		query += ` AND ip_address = ?`
+		args = append(args, ip)
+	}
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count successful logins: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteOlderThan removes events recorded before the cutoff
+func (r *LoginEventRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM login_events WHERE created_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old login events: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
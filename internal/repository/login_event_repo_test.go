@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestLoginEventRepository_CreateAndList(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewLoginEventRepository(db)
+	ctx := testutil.TestContext()
+
+	inputs := []models.LoginEvent{
+		{Success: false, IPAddress: "203.0.113.7", UserAgent: "curl/8.0", Reason: models.LoginReasonInvalidPassword},
+		{Success: false, IPAddress: "203.0.113.7", UserAgent: "curl/8.0", Reason: models.LoginReasonInvalidPassword},
+		{Success: true, IPAddress: "198.51.100.1", UserAgent: "Mozilla/5.0"},
+	}
+	for i := range inputs {
+		if _, err := repo.Create(ctx, &inputs[i]); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	all, err := repo.List(ctx, models.LoginEventFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+	if !all[0].Success {
+		t.Error("expected newest event first")
+	}
+
+	failed, err := repo.List(ctx, models.LoginEventFilter{FailedOnly: true})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(failed) != 2 {
+		t.Errorf("expected 2 failed events, got %d", len(failed))
+	}
+
+	byIP, err := repo.List(ctx, models.LoginEventFilter{IPAddress: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(byIP) != 1 || byIP[0].UserAgent != "Mozilla/5.0" {
+		t.Errorf("expected one event for IP, got %+v", byIP)
+	}
+}
+
+func TestLoginEventRepository_Counts(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewLoginEventRepository(db)
+	ctx := testutil.TestContext()
+
+	old := &models.LoginEvent{IPAddress: "203.0.113.7", CreatedAt: time.Now().UTC().Add(-2 * time.Hour)}
+	if _, err := repo.Create(ctx, old); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := repo.Create(ctx, &models.LoginEvent{IPAddress: "203.0.113.7"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	count, err := repo.CountFailuresSince(ctx, "203.0.113.7", time.Now().Add(-15*time.Minute))
+	if err != nil {
+		t.Fatalf("CountFailuresSince failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 recent failures, got %d", count)
+	}
+
+	success, err := repo.Create(ctx, &models.LoginEvent{Success: true, IPAddress: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	prior, err := repo.CountSuccesses(ctx, "198.51.100.1", success.ID)
+	if err != nil {
+		t.Fatalf("CountSuccesses failed: %v", err)
+	}
+	if prior != 0 {
+		t.Errorf("expected no prior successes, got %d", prior)
+	}
+
+	deleted, err := repo.DeleteOlderThan(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOlderThan failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted event, got %d", deleted)
+	}
+}
+
+func TestLoginEventRepository_TruncatesUserAgent(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewLoginEventRepository(db)
+	ctx := testutil.TestContext()
+
+	long := make([]byte, 2000)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	event, err := repo.Create(ctx, &models.LoginEvent{IPAddress: "203.0.113.7", UserAgent: string(long)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(event.UserAgent) != maxUserAgentLength {
+		t.Errorf("expected user agent truncated to %d, got %d", maxUserAgentLength, len(event.UserAgent))
+	}
+
+	// Multi-byte runes straddling the limit are dropped whole
+	event, err = repo.Create(ctx, &models.LoginEvent{IPAddress: "203.0.113.7", UserAgent: "a" + strings.Repeat("€", 300)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(event.UserAgent) != maxUserAgentLength-1 || !utf8.ValidString(event.UserAgent) {
+		t.Errorf("expected user agent cut to %d bytes of valid UTF-8, got %d (valid %v)", maxUserAgentLength-1, len(event.UserAgent), utf8.ValidString(event.UserAgent))
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MetadataRepository handles custom snippet metadata
+type MetadataRepository struct {
+	db *sql.DB
+}
+
+// NewMetadataRepository creates a new metadata repository
+func NewMetadataRepository(db *sql.DB) *MetadataRepository {
+	return &MetadataRepository{db: db}
+}
+
+// GetBySnippetID returns the metadata fields of a snippet, nil when it has none
+func (r *MetadataRepository) GetBySnippetID(ctx context.Context, snippetID string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT key, value FROM snippet_metadata
+		WHERE snippet_id = ?
+		ORDER BY key
+	`, snippetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snippet metadata: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var metadata map[string]string
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet metadata: %w", err)
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key] = value
+	}
+
+	return metadata, rows.Err()
+}
+
+// SetSnippetMetadata replaces all metadata fields of a snippet
+func (r *MetadataRepository) SetSnippetMetadata(ctx context.Context, snippetID string, metadata map[string]string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM snippet_metadata WHERE snippet_id = ?", snippetID); err != nil {
+		return fmt.Errorf("failed to clear snippet metadata: %w", err)
+	}
+
+	for key, value := range metadata {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO snippet_metadata (snippet_id, key, value) VALUES (?, ?, ?)",
+			snippetID, key, value,
+		); err != nil {
+			return fmt.Errorf("failed to set snippet metadata: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
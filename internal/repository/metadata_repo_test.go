@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestMetadataRepository_SetSnippetMetadata(t *testing.T) {
+	db := testutil.TestDB(t)
+	snippetRepo := NewSnippetRepository(db)
+	repo := NewMetadataRepository(db)
+	ctx := testutil.TestContext()
+
+	snippet, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Test", Content: "x", Language: "plaintext"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := repo.SetSnippetMetadata(ctx, snippet.ID, map[string]string{"ticket": "JIRA-123", "env": "prod"}); err != nil {
+		t.Fatalf("SetSnippetMetadata failed: %v", err)
+	}
+
+	// Setting again replaces all fields
+	if err := repo.SetSnippetMetadata(ctx, snippet.ID, map[string]string{"env": "staging"}); err != nil {
+		t.Fatalf("SetSnippetMetadata failed: %v", err)
+	}
+
+	metadata, err := repo.GetBySnippetID(ctx, snippet.ID)
+	if err != nil {
+		t.Fatalf("GetBySnippetID failed: %v", err)
+	}
+	if len(metadata) != 1 || metadata["env"] != "staging" {
+		t.Errorf("expected only env=staging, got %v", metadata)
+	}
+
+	if err := repo.SetSnippetMetadata(ctx, snippet.ID, map[string]string{}); err != nil {
+		t.Fatalf("SetSnippetMetadata failed: %v", err)
+	}
+	metadata, err = repo.GetBySnippetID(ctx, snippet.ID)
+	if err != nil {
+		t.Fatalf("GetBySnippetID failed: %v", err)
+	}
+	if metadata != nil {
+		t.Errorf("expected no metadata after clearing, got %v", metadata)
+	}
+}
+
+func TestSnippetRepository_List_FilterByMetadata(t *testing.T) {
+	db := testutil.TestDB(t)
+	snippetRepo := NewSnippetRepository(db)
+	repo := NewMetadataRepository(db)
+	ctx := testutil.TestContext()
+
+	fields := []map[string]string{
+		{"ticket": "JIRA-123", "env": "prod"},
+		{"ticket": "JIRA-123", "env": "dev"},
+		{"ticket": "JIRA-456", "env": "prod"},
+	}
+	for _, f := range fields {
+		snippet, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Test", Content: "x", Language: "plaintext"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := repo.SetSnippetMetadata(ctx, snippet.ID, f); err != nil {
+			t.Fatalf("SetSnippetMetadata failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter map[string]string
+		want   int
+	}{
+		{map[string]string{"ticket": "JIRA-123"}, 2},
+		{map[string]string{"ticket": "JIRA-123", "env": "prod"}, 1},
+		{map[string]string{"env": "qa"}, 0},
+	}
+	for _, tt := range tests {
+		result, err := snippetRepo.List(ctx, models.SnippetFilter{Metadata: tt.filter})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if result.Pagination.Total != tt.want {
+			t.Errorf("filter %v: expected %d snippets, got %d", tt.filter, tt.want, result.Pagination.Total)
+		}
+	}
+}
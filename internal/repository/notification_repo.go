@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// NotificationRepository handles notification center database operations
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create stores a notification
+func (r *NotificationRepository) Create(ctx context.Context, n *models.Notification) (*models.Notification, error) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+	fields, err := json.Marshal(n.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification fields: %w", err)
+	}
+	if n.Fields == nil {
+		fields = []byte("{}")
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO notifications (type, title, message, fields, created_at) VALUES (?, ?, ?, ?, ?)`,
+		n.Type, n.Title, n.Message, string(fields), n.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification ID: %w", err)
+	}
+	n.ID = id
+
+	return n, nil
+}
+
+// GetByID retrieves a notification, or nil if it does not exist
+func (r *NotificationRepository) GetByID(ctx context.Context, id int64) (*models.Notification, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, type, title, message, fields, read_at, created_at FROM notifications WHERE id = ?`, id)
+
+	n, err := scanNotification(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+	return n, nil
+}
+
+// List retrieves notifications, newest first
+func (r *NotificationRepository) List(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error) {
+	query := `SELECT id, type, title, message, fields, read_at, created_at FROM notifications WHERE 1=1`
+	var args []interface{}
+
+	if filter.UnreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, *n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// CountUnread counts notifications that have not been read
+func (r *NotificationRepository) CountUnread(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE read_at IS NULL`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification as read. Already read notifications keep
+// their original read time.
+func (r *NotificationRepository) MarkRead(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = ? WHERE id = ? AND read_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification as read and returns how many
+// were updated
+func (r *NotificationRepository) MarkAllRead(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = ? WHERE read_at IS NULL`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+// DeleteOlderThan removes notifications created before the cutoff
+func (r *NotificationRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notifications WHERE created_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old notifications: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+func scanNotification(row interface{ Scan(...any) error }) (*models.Notification, error) {
+	var (
+		n      models.Notification
+		fields string
+		readAt sql.NullTime
+	)
+	if err := row.Scan(&n.ID, &n.Type, &n.Title, &n.Message, &fields, &readAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if fields != "" && fields != "{}" {
+		if err := json.Unmarshal([]byte(fields), &n.Fields); err != nil {
+			return nil, fmt.Errorf("failed to decode notification fields: %w", err)
+		}
+	}
+	if readAt.Valid {
+		t := readAt.Time
+		n.ReadAt = &t
+	}
+	return &n, nil
+}
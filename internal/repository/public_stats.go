@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// CountPublic returns the number of public snippets that are not archived
+func (r *SnippetRepository) CountPublic(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM snippets WHERE is_public = 1 AND is_archived = 0`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count public snippets: %w", err)
+	}
+	return count, nil
+}
+
+// PublicLanguageCounts counts the public snippets that are not archived by
+// language, most used first
+func (r *SnippetRepository) PublicLanguageCounts(ctx context.Context) ([]models.LanguageCount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT LOWER(language) AS lang, COUNT(*) AS n FROM snippets
+		WHERE is_public = 1 AND is_archived = 0 AND language != ''
+		GROUP BY lang
+		ORDER BY n DESC, lang ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count public languages: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	counts := []models.LanguageCount{}
+	for rows.Next() {
+		var count models.LanguageCount
+		if err := rows.Scan(&count.Language, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan language count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// quickOpenScoreTTL is how long frecency scores are reused. Usage changes
+// them on every view, so they are reloaded on a timer instead.
+const quickOpenScoreTTL = time.Minute
+
+// QuickOpenIndex keeps the titles and filenames of unarchived snippets in
+// memory, so quick-open lookups never touch the database on the hot path.
+// Writes through the snippet and file repositories mark the snippets they
+// touch as stale, and stale entries are reloaded on the next lookup; the
+// TTL bounds staleness from anything else that writes to the database. A
+// nil *QuickOpenIndex indexes nothing.
+type QuickOpenIndex struct {
+	db  *sql.DB
+	ttl time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*models.QuickOpenEntry
+	list     []models.QuickOpenEntry
+	stale    map[string]bool
+	loadedAt time.Time
+	scoredAt time.Time
+}
+
+// NewQuickOpenIndex creates an index that is fully reloaded every ttl
+func NewQuickOpenIndex(db *sql.DB, ttl time.Duration) *QuickOpenIndex {
+	return &QuickOpenIndex{db: db, ttl: ttl, stale: map[string]bool{}}
+}
+
+// Entries returns every indexed snippet. The slice is shared between
+// callers and must not be modified.
+func (x *QuickOpenIndex) Entries(ctx context.Context) ([]models.QuickOpenEntry, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	changed := false
+	switch {
+	case x.entries == nil || time.Since(x.loadedAt) >= x.ttl:
+		entries, err := x.load(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		x.entries = entries
+		x.stale = map[string]bool{}
+		x.loadedAt = time.Now()
+		x.scoredAt = time.Time{}
+		changed = true
+	case len(x.stale) > 0:
+		ids := make([]string, 0, len(x.stale))
+		for id := range x.stale {
+			ids = append(ids, id)
+		}
+		entries, err := x.load(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if entry, ok := entries[id]; ok {
+				if old, ok := x.entries[id]; ok {
+					entry.Frecency = old.Frecency
+				}
+				x.entries[id] = entry
+			} else {
+				delete(x.entries, id) // Deleted or archived
+			}
+		}
+		x.stale = map[string]bool{}
+		changed = true
+	}
+
+	if time.Since(x.scoredAt) >= quickOpenScoreTTL {
+		if err := x.score(ctx); err != nil {
+			return nil, err
+		}
+		x.scoredAt = time.Now()
+		changed = true
+	}
+
+	if changed {
+		// A new slice, so earlier callers keep a consistent snapshot
+		x.list = make([]models.QuickOpenEntry, 0, len(x.entries))
+		for _, entry := range x.entries {
+			x.list = append(x.list, *entry)
+		}
+	}
+	return x.list, nil
+}
+
+// Purge drops the index, for bulk changes made outside the repositories
+// such as restoring a backup
+func (x *QuickOpenIndex) Purge() {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	x.entries = nil
+	x.mu.Unlock()
+}
+
+// invalidate marks a snippet for reloading on the next lookup
+func (x *QuickOpenIndex) invalidate(id string) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	x.stale[id] = true
+	x.mu.Unlock()
+}
+
+// load reads the unarchived snippets among ids, or all of them when ids is nil
+func (x *QuickOpenIndex) load(ctx context.Context, ids []string) (map[string]*models.QuickOpenEntry, error) {
+	query := `
+		SELECT s.id, s.title, s.language, s.updated_at,
+			COALESCE((SELECT group_concat(filename, char(10)) FROM
+				(SELECT filename FROM snippet_files f WHERE f.snippet_id = s.id ORDER BY f.sort_order, f.id)), '')
+		FROM snippets s
+		WHERE s.is_archived = 0`
+	var args []any
+	if ids != nil {
+		query += ` AND s.id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quick-open index: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	entries := make(map[string]*models.QuickOpenEntry)
+	for rows.Next() {
+		entry := &models.QuickOpenEntry{}
+		var filenames string
+		if err := rows.Scan(&entry.ID, &entry.Title, &entry.Language, &entry.UpdatedAt, &filenames); err != nil {
+			return nil, fmt.Errorf("failed to scan quick-open entry: %w", err)
+		}
+		if filenames != "" {
+			entry.Filenames = strings.Split(filenames, "\n")
+		}
+		entries[entry.ID] = entry
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quick-open entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// score loads the frecency scores of the indexed snippets
+func (x *QuickOpenIndex) score(ctx context.Context) error {
+	rows, err := x.db.QueryContext(ctx, `
+		SELECT u.snippet_id, SUM(`+frecencyWeight+`)
+		FROM snippet_usage u
+		WHERE u.created_at >= datetime('now', '-90 days')
+		GROUP BY u.snippet_id`)
+	if err != nil {
+		return fmt.Errorf("failed to load frecency scores: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	for _, entry := range x.entries {
+		entry.Frecency = 0
+	}
+	for rows.Next() {
+		var id string
+		var score int
+		if err := rows.Scan(&id, &score); err != nil {
+			return fmt.Errorf("failed to scan frecency score: %w", err)
+		}
+		if entry, ok := x.entries[id]; ok {
+			entry.Frecency = score
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestQuickOpenIndex(t *testing.T) {
+	db := testutil.TestDB(t)
+	ctx := testutil.TestContext()
+	index := NewQuickOpenIndex(db, time.Hour)
+	repo := NewSnippetRepository(db).WithQuickOpen(index)
+	fileRepo := NewSnippetFileRepository(db).WithQuickOpen(index)
+
+	find := func(id string) *models.QuickOpenEntry {
+		t.Helper()
+		entries, err := index.Entries(ctx)
+		if err != nil {
+			t.Fatalf("Entries failed: %v", err)
+		}
+		for i := range entries {
+			if entries[i].ID == id {
+				return &entries[i]
+			}
+		}
+		return nil
+	}
+
+	snippet, err := repo.Create(ctx, &models.SnippetInput{Title: "Docker compose", Content: "x", Language: "yaml"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if entry := find(snippet.ID); entry == nil || entry.Title != "Docker compose" {
+		t.Fatalf("expected the new snippet in the index, got %+v", entry)
+	}
+
+	// Writes after the first load are picked up without waiting for the TTL
+	if _, err := repo.Update(ctx, snippet.ID, &models.SnippetInput{Title: "Compose stack", Content: "x", Language: "yaml"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := fileRepo.SyncFiles(ctx, snippet.ID, []models.SnippetFileInput{{Filename: "compose.yaml", Content: "x"}}); err != nil {
+		t.Fatalf("SyncFiles failed: %v", err)
+	}
+	if err := repo.RecordUsage(ctx, snippet.ID, models.UsageCopy); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	entry := find(snippet.ID)
+	if entry == nil || entry.Title != "Compose stack" || len(entry.Filenames) != 1 || entry.Filenames[0] != "compose.yaml" {
+		t.Fatalf("expected the updated title and filename, got %+v", entry)
+	}
+
+	// Archived and deleted snippets leave the index
+	if _, err := repo.ToggleArchive(ctx, snippet.ID); err != nil {
+		t.Fatalf("ToggleArchive failed: %v", err)
+	}
+	if entry := find(snippet.ID); entry != nil {
+		t.Errorf("expected archived snippet to be dropped, got %+v", entry)
+	}
+	if _, err := repo.ToggleArchive(ctx, snippet.ID); err != nil {
+		t.Fatalf("ToggleArchive failed: %v", err)
+	}
+	if entry := find(snippet.ID); entry == nil {
+		t.Error("expected unarchived snippet to return")
+	}
+	if err := repo.Delete(ctx, snippet.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if entry := find(snippet.ID); entry != nil {
+		t.Errorf("expected deleted snippet to be dropped, got %+v", entry)
+	}
+}
+
+func TestQuickOpenIndex_Frecency(t *testing.T) {
+	db := testutil.TestDB(t)
+	ctx := testutil.TestContext()
+	index := NewQuickOpenIndex(db, time.Hour)
+	repo := NewSnippetRepository(db).WithQuickOpen(index)
+
+	snippet, err := repo.Create(ctx, &models.SnippetInput{Title: "Used", Content: "x", Language: "go"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.RecordUsage(ctx, snippet.ID, models.UsageView); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := repo.RecordUsage(ctx, snippet.ID, models.UsageCopy); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+
+	entries, err := index.Entries(ctx)
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	// A recent view scores 100 and a recent copy 200
+	if len(entries) != 1 || entries[0].Frecency != 300 {
+		t.Errorf("expected frecency 300, got %+v", entries)
+	}
+}
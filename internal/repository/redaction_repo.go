@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// RedactionRepository handles redaction rule database operations
+type RedactionRepository struct {
+	db *sql.DB
+}
+
+// NewRedactionRepository creates a new redaction rule repository
+func NewRedactionRepository(db *sql.DB) *RedactionRepository {
+	return &RedactionRepository{db: db}
+}
+
+const redactionColumns = `id, name, pattern, replacement, enabled, position, created_at, updated_at`
+
+func scanRedactionRule(row interface{ Scan(...any) error }) (*models.RedactionRule, error) {
+	rule := &models.RedactionRule{}
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Pattern, &rule.Replacement, &rule.Enabled, &rule.Position, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Create stores a redaction rule
+func (r *RedactionRepository) Create(ctx context.Context, input *models.RedactionRuleInput) (*models.RedactionRule, error) {
+	rule, err := scanRedactionRule(r.db.QueryRowContext(ctx,
+		`INSERT INTO redaction_rules (name, pattern, replacement, enabled, position) VALUES (?, ?, ?, ?, ?)
+		RETURNING `+redactionColumns,
+		input.Name, input.Pattern, input.Replacement, input.Enabled == nil || *input.Enabled, input.Position,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redaction rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Update replaces a redaction rule, returning nil if it does not exist
+func (r *RedactionRepository) Update(ctx context.Context, id int64, input *models.RedactionRuleInput) (*models.RedactionRule, error) {
+	rule, err := scanRedactionRule(r.db.QueryRowContext(ctx,
+		`UPDATE redaction_rules SET name = ?, pattern = ?, replacement = ?, enabled = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? RETURNING `+redactionColumns,
+		input.Name, input.Pattern, input.Replacement, input.Enabled == nil || *input.Enabled, input.Position, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update redaction rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Delete removes a redaction rule
+func (r *RedactionRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM redaction_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete redaction rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetByID retrieves a redaction rule, or nil if it does not exist
+func (r *RedactionRepository) GetByID(ctx context.Context, id int64) (*models.RedactionRule, error) {
+	rule, err := scanRedactionRule(r.db.QueryRowContext(ctx, `SELECT `+redactionColumns+` FROM redaction_rules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redaction rule: %w", err)
+	}
+	return rule, nil
+}
+
+// List retrieves redaction rules in the order they apply
+func (r *RedactionRepository) List(ctx context.Context) ([]models.RedactionRule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+redactionColumns+` FROM redaction_rules ORDER BY position ASC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redaction rules: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	rules := []models.RedactionRule{}
+	for rows.Next() {
+		rule, err := scanRedactionRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan redaction rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating redaction rules: %w", err)
+	}
+
+	return rules, nil
+}
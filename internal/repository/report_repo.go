@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ReportRepository handles abuse report database operations
+type ReportRepository struct {
+	db *sql.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+const reportColumns = `r.id, r.snippet_id, s.title, s.is_public, r.reason, r.details, r.reporter_ip,
+	r.status, r.resolved_by, r.resolved_at, r.created_at`
+
+// Create stores an open report
+func (r *ReportRepository) Create(ctx context.Context, report *models.SnippetReport) (*models.SnippetReport, error) {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO snippet_reports (snippet_id, reason, details, reporter_ip, status) VALUES (?, ?, ?, ?, ?)`,
+		report.SnippetID, report.Reason, report.Details, report.ReporterIP, models.ReportOpen,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report ID: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// HasOpenReport reports whether an address already has an open report
+// about a snippet
+func (r *ReportRepository) HasOpenReport(ctx context.Context, snippetID, reporterIP string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM snippet_reports WHERE snippet_id = ? AND reporter_ip = ? AND status = ?)`,
+		snippetID, reporterIP, models.ReportOpen,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check reports: %w", err)
+	}
+	return exists, nil
+}
+
+// GetByID retrieves a report, or nil if it does not exist
+func (r *ReportRepository) GetByID(ctx context.Context, id int64) (*models.SnippetReport, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+reportColumns+` FROM snippet_reports r JOIN snippets s ON s.id = r.snippet_id WHERE r.id = ?`, id)
+
+	report, err := scanReport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	return report, nil
+}
+
+// List retrieves reports, oldest first so the queue is worked in order
+func (r *ReportRepository) List(ctx context.Context, filter models.ReportFilter) ([]models.SnippetReport, error) {
+	query := `SELECT ` + reportColumns + ` FROM snippet_reports r JOIN snippets s ON s.id = r.snippet_id WHERE 1=1`
+	var args []interface{}
+
+	if filter.Status != "all" {
+		status := filter.Status
+		if status == "" {
+			status = models.ReportOpen
+		}
+		query += ` AND r.status = ?`
+		args = append(args, status)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += ` ORDER BY r.created_at ASC, r.id ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	reports := []models.SnippetReport{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, *report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ResolveSnippet closes the open reports about a snippet with a status,
+// returning how many were closed
+func (r *ReportRepository) ResolveSnippet(ctx context.Context, snippetID, status, resolvedBy string) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE snippet_reports SET status = ?, resolved_by = ?, resolved_at = CURRENT_TIMESTAMP
+		 WHERE snippet_id = ? AND status = ?`,
+		status, resolvedBy, snippetID, models.ReportOpen,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve reports: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanReport(row interface{ Scan(...any) error }) (*models.SnippetReport, error) {
+	var report models.SnippetReport
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&report.ID, &report.SnippetID, &report.SnippetTitle, &report.IsPublic, &report.Reason, &report.Details,
+		&report.ReporterIP, &report.Status, &report.ResolvedBy, &resolvedAt, &report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return &report, nil
+}
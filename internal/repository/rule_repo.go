@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// maxRuleRuns is how many runs are kept per rule
+const maxRuleRuns = 200
+
+// RuleRepository handles automation rule database operations
+type RuleRepository struct {
+	db *sql.DB
+}
+
+// NewRuleRepository creates a new rule repository
+func NewRuleRepository(db *sql.DB) *RuleRepository {
+	return &RuleRepository{db: db}
+}
+
+const ruleColumns = `id, name, events, script, enabled, position, created_at, updated_at`
+
+func scanRule(row interface{ Scan(...any) error }) (*models.Rule, error) {
+	rule := &models.Rule{}
+	var events string
+	err := row.Scan(&rule.ID, &rule.Name, &events, &rule.Script, &rule.Enabled, &rule.Position, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rule.Events = strings.Split(events, ",")
+	return rule, nil
+}
+
+// Create stores a rule
+func (r *RuleRepository) Create(ctx context.Context, input *models.RuleInput) (*models.Rule, error) {
+	rule, err := scanRule(r.db.QueryRowContext(ctx,
+		`INSERT INTO rules (name, events, script, enabled, position) VALUES (?, ?, ?, ?, ?)
+		RETURNING `+ruleColumns,
+		input.Name, strings.Join(input.Events, ","), input.Script, input.Enabled == nil || *input.Enabled, input.Position,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Update replaces a rule, returning nil if it does not exist
+func (r *RuleRepository) Update(ctx context.Context, id int64, input *models.RuleInput) (*models.Rule, error) {
+	rule, err := scanRule(r.db.QueryRowContext(ctx,
+		`UPDATE rules SET name = ?, events = ?, script = ?, enabled = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? RETURNING `+ruleColumns,
+		input.Name, strings.Join(input.Events, ","), input.Script, input.Enabled == nil || *input.Enabled, input.Position, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Delete removes a rule and its runs
+func (r *RuleRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetByID retrieves a rule, or nil if it does not exist
+func (r *RuleRepository) GetByID(ctx context.Context, id int64) (*models.Rule, error) {
+	rule, err := scanRule(r.db.QueryRowContext(ctx, `SELECT `+ruleColumns+` FROM rules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	return rule, nil
+}
+
+// List retrieves rules in the order they run
+func (r *RuleRepository) List(ctx context.Context) ([]models.Rule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+ruleColumns+` FROM rules ORDER BY position ASC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	rules := []models.Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// AddRun records a run, keeping only the latest runs of the rule
+func (r *RuleRepository) AddRun(ctx context.Context, run *models.RuleRun) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO rule_runs (rule_id, snippet_id, snippet_title, event, status, message, actions, output, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.RuleID, run.SnippetID, run.SnippetTitle, run.Event, run.Status, run.Message, run.Actions, run.Output, run.DurationMS,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record rule run: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`DELETE FROM rule_runs WHERE rule_id = ? AND id NOT IN
+		(SELECT id FROM rule_runs WHERE rule_id = ? ORDER BY id DESC LIMIT ?)`,
+		run.RuleID, run.RuleID, maxRuleRuns,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune rule runs: %w", err)
+	}
+	return nil
+}
+
+// ListRuns retrieves a rule's runs, newest first
+func (r *RuleRepository) ListRuns(ctx context.Context, ruleID int64, limit int) ([]models.RuleRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, rule_id, snippet_id, snippet_title, event, status, message, actions, output, duration_ms, created_at
+		FROM rule_runs WHERE rule_id = ? ORDER BY id DESC LIMIT ?`,
+		ruleID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule runs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	runs := []models.RuleRun{}
+	for rows.Next() {
+		var run models.RuleRun
+		err := rows.Scan(&run.ID, &run.RuleID, &run.SnippetID, &run.SnippetTitle, &run.Event, &run.Status,
+			&run.Message, &run.Actions, &run.Output, &run.DurationMS, &run.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestRuleRepository_ListOrder(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewRuleRepository(db)
+	ctx := testutil.TestContext()
+
+	disabled := false
+	for _, input := range []models.RuleInput{
+		{Name: "last", Events: []string{"create"}, Script: "log(1)", Position: 5},
+		{Name: "first", Events: []string{"create", "update"}, Script: "log(2)", Enabled: &disabled},
+		{Name: "second", Events: []string{"update"}, Script: "log(3)"},
+	} {
+		if _, err := repo.Create(ctx, &input); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	rules, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var names []string
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	if len(names) != 3 || names[0] != "first" || names[1] != "second" || names[2] != "last" {
+		t.Errorf("expected rules ordered by position then ID, got %v", names)
+	}
+	if rules[0].Enabled || len(rules[0].Events) != 2 || !rules[1].Enabled {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+}
+
+func TestRuleRepository_RunsArePruned(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewRuleRepository(db)
+	ctx := testutil.TestContext()
+
+	rule, err := repo.Create(ctx, &models.RuleInput{Name: "noisy", Events: []string{"create"}, Script: "log(1)"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < maxRuleRuns+5; i++ {
+		run := &models.RuleRun{RuleID: rule.ID, SnippetTitle: "s", Event: models.RuleEventCreate, Status: models.RuleRunOK, DurationMS: int64(i)}
+		if err := repo.AddRun(ctx, run); err != nil {
+			t.Fatalf("AddRun failed: %v", err)
+		}
+	}
+
+	runs, err := repo.ListRuns(ctx, rule.ID, 1000)
+	if err != nil {
+		t.Fatalf("ListRuns failed: %v", err)
+	}
+	if len(runs) != maxRuleRuns {
+		t.Fatalf("expected %d runs kept, got %d", maxRuleRuns, len(runs))
+	}
+	if runs[0].DurationMS != maxRuleRuns+4 || runs[len(runs)-1].DurationMS != 5 {
+		t.Errorf("expected the newest runs kept, got %d..%d", runs[0].DurationMS, runs[len(runs)-1].DurationMS)
+	}
+
+	if err := repo.Delete(ctx, rule.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, rule.ID); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
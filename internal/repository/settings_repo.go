@@ -10,7 +10,8 @@ import (
 
 // SettingsRepository handles settings database operations
 type SettingsRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	cache *ReadCache
 }
 
 // NewSettingsRepository creates a new settings repository
@@ -18,17 +19,29 @@ func NewSettingsRepository(db *sql.DB) *SettingsRepository {
 	return &SettingsRepository{db: db}
 }
 
+// WithCache serves reads from c and invalidates it on writes
+func (r *SettingsRepository) WithCache(c *ReadCache) *SettingsRepository {
+	r.cache = c
+	return r
+}
+
 // Get retrieves application settings
 func (r *SettingsRepository) Get(ctx context.Context) (*models.Settings, error) {
+	if settings, ok := r.cache.getSettings(); ok {
+		return settings, nil
+	}
+
 	query := `
 		SELECT id, app_name, custom_css, theme, default_language, 
 		       s3_enabled, s3_endpoint, s3_bucket, s3_region, 
 		       backup_encryption_enabled, archive_enabled, history_enabled,
-		       disable_login,
+		       disable_login, review_required, announcement_snippet_id,
 		       editor_font_size, editor_tab_size, editor_theme, editor_word_wrap,
 		       editor_show_print_margin, editor_show_gutter, editor_show_indent_guides,
 		       editor_highlight_active_line, editor_use_soft_tabs, editor_enable_snippets,
-		       editor_enable_live_autocompletion, markdown_font_size, created_at, updated_at
+		       editor_enable_live_autocompletion, markdown_font_size,
+		       max_title_length, max_description_length, max_content_size, max_tag_length, tag_pattern,
+		       created_at, updated_at
 		FROM settings
 		WHERE id = 1
 	`
@@ -48,6 +61,8 @@ func (r *SettingsRepository) Get(ctx context.Context) (*models.Settings, error)
 		&settings.ArchiveEnabled,
 		&settings.HistoryEnabled,
 		&settings.DisableLogin,
+		&settings.ReviewRequired,
+		&settings.AnnouncementSnippetID,
 		&settings.EditorFontSize,
 		&settings.EditorTabSize,
 		&settings.EditorTheme,
@@ -60,6 +75,11 @@ func (r *SettingsRepository) Get(ctx context.Context) (*models.Settings, error)
 		&settings.EditorEnableSnippets,
 		&settings.EditorEnableLiveAutocompletion,
 		&settings.MarkdownFontSize,
+		&settings.Validation.MaxTitleLength,
+		&settings.Validation.MaxDescriptionLength,
+		&settings.Validation.MaxContentSize,
+		&settings.Validation.MaxTagLength,
+		&settings.Validation.TagPattern,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
@@ -68,32 +88,56 @@ func (r *SettingsRepository) Get(ctx context.Context) (*models.Settings, error)
 		return nil, fmt.Errorf("failed to get settings: %w", err)
 	}
 
+	r.cache.setSettings(settings)
 	return settings, nil
 }
 
 // Update updates application settings
 func (r *SettingsRepository) Update(ctx context.Context, input *models.SettingsInput) (*models.Settings, error) {
+	defer r.cache.invalidateSettings()
+
 	query := `
 		UPDATE settings
 		SET app_name = ?, custom_css = ?, theme = ?, default_language = ?,
 		    s3_enabled = ?, s3_endpoint = ?, s3_bucket = ?, s3_region = ?,
 		    backup_encryption_enabled = ?, archive_enabled = ?, history_enabled = ?,
-		    disable_login = ?,
+		    disable_login = ?, review_required = ?,
+		    announcement_snippet_id = COALESCE(?, announcement_snippet_id),
 		    editor_font_size = ?, editor_tab_size = ?, editor_theme = ?, editor_word_wrap = ?,
 		    editor_show_print_margin = ?, editor_show_gutter = ?, editor_show_indent_guides = ?,
 		    editor_highlight_active_line = ?, editor_use_soft_tabs = ?, editor_enable_snippets = ?,
-		    editor_enable_live_autocompletion = ?, markdown_font_size = ?, updated_at = CURRENT_TIMESTAMP
+		    editor_enable_live_autocompletion = ?, markdown_font_size = ?,
+		    max_title_length = COALESCE(?, max_title_length),
+		    max_description_length = COALESCE(?, max_description_length),
+		    max_content_size = COALESCE(?, max_content_size),
+		    max_tag_length = COALESCE(?, max_tag_length),
+		    tag_pattern = COALESCE(?, tag_pattern),
+		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = 1
 		RETURNING id, app_name, custom_css, theme, default_language,
 		          s3_enabled, s3_endpoint, s3_bucket, s3_region,
 		          backup_encryption_enabled, archive_enabled, history_enabled,
-		          disable_login,
+		          disable_login, review_required, announcement_snippet_id,
 		          editor_font_size, editor_tab_size, editor_theme, editor_word_wrap,
 		          editor_show_print_margin, editor_show_gutter, editor_show_indent_guides,
 		          editor_highlight_active_line, editor_use_soft_tabs, editor_enable_snippets,
-		          editor_enable_live_autocompletion, markdown_font_size, created_at, updated_at
+		          editor_enable_live_autocompletion, markdown_font_size,
+		          max_title_length, max_description_length, max_content_size, max_tag_length, tag_pattern,
+		          created_at, updated_at
 	`
 
+	// Without rules in the input, each is passed as NULL and kept
+	var rules struct {
+		MaxTitleLength, MaxDescriptionLength, MaxContentSize, MaxTagLength, TagPattern any
+	}
+	if v := input.Validation; v != nil {
+		rules.MaxTitleLength = v.MaxTitleLength
+		rules.MaxDescriptionLength = v.MaxDescriptionLength
+		rules.MaxContentSize = v.MaxContentSize
+		rules.MaxTagLength = v.MaxTagLength
+		rules.TagPattern = v.TagPattern
+	}
+
 	settings := &models.Settings{}
 	err := r.db.QueryRowContext(ctx, query,
 		input.AppName,
@@ -108,6 +152,8 @@ func (r *SettingsRepository) Update(ctx context.Context, input *models.SettingsI
 		input.ArchiveEnabled,
 		input.HistoryEnabled,
 		input.DisableLogin,
+		input.ReviewRequired,
+		input.AnnouncementSnippetID,
 		input.EditorFontSize,
 		input.EditorTabSize,
 		input.EditorTheme,
@@ -120,6 +166,11 @@ func (r *SettingsRepository) Update(ctx context.Context, input *models.SettingsI
 		input.EditorEnableSnippets,
 		input.EditorEnableLiveAutocompletion,
 		input.MarkdownFontSize,
+		rules.MaxTitleLength,
+		rules.MaxDescriptionLength,
+		rules.MaxContentSize,
+		rules.MaxTagLength,
+		rules.TagPattern,
 	).Scan(
 		&settings.ID,
 		&settings.AppName,
@@ -134,6 +185,8 @@ func (r *SettingsRepository) Update(ctx context.Context, input *models.SettingsI
 		&settings.ArchiveEnabled,
 		&settings.HistoryEnabled,
 		&settings.DisableLogin,
+		&settings.ReviewRequired,
+		&settings.AnnouncementSnippetID,
 		&settings.EditorFontSize,
 		&settings.EditorTabSize,
 		&settings.EditorTheme,
@@ -146,6 +199,11 @@ func (r *SettingsRepository) Update(ctx context.Context, input *models.SettingsI
 		&settings.EditorEnableSnippets,
 		&settings.EditorEnableLiveAutocompletion,
 		&settings.MarkdownFontSize,
+		&settings.Validation.MaxTitleLength,
+		&settings.Validation.MaxDescriptionLength,
+		&settings.Validation.MaxContentSize,
+		&settings.Validation.MaxTagLength,
+		&settings.Validation.TagPattern,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
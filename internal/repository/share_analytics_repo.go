@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ShareAnalyticsRepository handles public snippet view counts
+type ShareAnalyticsRepository struct {
+	db *sql.DB
+}
+
+// NewShareAnalyticsRepository creates a new share analytics repository
+func NewShareAnalyticsRepository(db *sql.DB) *ShareAnalyticsRepository {
+	return &ShareAnalyticsRepository{db: db}
+}
+
+// Record counts a view on day (YYYY-MM-DD), and stores the viewer's IP
+// when the view carries one
+func (r *ShareAnalyticsRepository) Record(ctx context.Context, day string, view models.ShareView) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO share_views (snippet_id, day, referrer, client, views) VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT(snippet_id, day, referrer, client) DO UPDATE SET views = views + 1
+	`, view.SnippetID, day, view.Referrer, view.Client)
+	if err != nil {
+		return fmt.Errorf("failed to record share view: %w", err)
+	}
+
+	if view.IP != "" {
+		_, err = r.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO share_visitors (snippet_id, day, ip) VALUES (?, ?, ?)`,
+			view.SnippetID, day, view.IP)
+		if err != nil {
+			return fmt.Errorf("failed to record share visitor: %w", err)
+		}
+	}
+	return nil
+}
+
+// DailyViews returns a snippet's views by day from day from on, with the
+// unique visitors of each day
+func (r *ShareAnalyticsRepository) DailyViews(ctx context.Context, snippetID, from string) (map[string][2]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT v.day, v.views, COALESCE(u.visitors, 0)
+		FROM (SELECT day, SUM(views) AS views FROM share_views WHERE snippet_id = ? AND day >= ? GROUP BY day) v
+		LEFT JOIN (SELECT day, COUNT(*) AS visitors FROM share_visitors WHERE snippet_id = ? AND day >= ? GROUP BY day) u
+		ON u.day = v.day
+	`, snippetID, from, snippetID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily share views: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	days := map[string][2]int{}
+	for rows.Next() {
+		var day string
+		var views, visitors int
+		if err := rows.Scan(&day, &views, &visitors); err != nil {
+			return nil, fmt.Errorf("failed to scan share views: %w", err)
+		}
+		days[day] = [2]int{views, visitors}
+	}
+	return days, rows.Err()
+}
+
+// Visitors counts the unique IPs that viewed a snippet from day from on
+func (r *ShareAnalyticsRepository) Visitors(ctx context.Context, snippetID, from string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT ip) FROM share_visitors WHERE snippet_id = ? AND day >= ?`,
+		snippetID, from).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count share visitors: %w", err)
+	}
+	return count, nil
+}
+
+// ViewsBy returns a snippet's views from day from on, grouped by column
+// ("referrer" or "client"), most views first
+func (r *ShareAnalyticsRepository) ViewsBy(ctx context.Context, snippetID, from, column string) ([]models.ShareAnalyticsKey, error) {
+	if column != "referrer" && column != "client" {
+		return nil, fmt.Errorf("invalid share views column %q", column)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+column+`, SUM(views) AS total FROM share_views
+		WHERE snippet_id = ? AND day >= ?
+		GROUP BY `+column+` ORDER BY total DESC, `+column+` LIMIT 50
+	`, snippetID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share views: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	keys := []models.ShareAnalyticsKey{}
+	for rows.Next() {
+		var key models.ShareAnalyticsKey
+		if err := rows.Scan(&key.Key, &key.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan share views: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Prune deletes view counts and visitor IPs from before day before
+func (r *ShareAnalyticsRepository) Prune(ctx context.Context, before string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM share_views WHERE day < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune share views: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM share_visitors WHERE day < ?`, before); err != nil {
+		return 0, fmt.Errorf("failed to prune share visitors: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteVisitors removes all stored visitor IPs
+func (r *ShareAnalyticsRepository) DeleteVisitors(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM share_visitors`); err != nil {
+		return fmt.Errorf("failed to delete share visitors: %w", err)
+	}
+	return nil
+}
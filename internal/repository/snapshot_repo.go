@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// SnapshotRepository handles daily library snapshots
+type SnapshotRepository struct {
+	db *sql.DB
+}
+
+// NewSnapshotRepository creates a new snapshot repository
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// Save stores the snapshot of day (YYYY-MM-DD), replacing one already
+// taken that day
+func (r *SnapshotRepository) Save(ctx context.Context, day string, entries []models.SnapshotEntry) (*models.LibrarySnapshot, error) {
+	manifest, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+
+	snapshot := &models.LibrarySnapshot{Snippets: entries}
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO library_snapshots (day, snippet_count, manifest) VALUES (?, ?, ?)
+		ON CONFLICT(day) DO UPDATE SET snippet_count = excluded.snippet_count, manifest = excluded.manifest, created_at = CURRENT_TIMESTAMP
+		RETURNING id, day, snippet_count, created_at
+	`, day, len(entries), string(manifest)).Scan(&snapshot.ID, &snapshot.Day, &snapshot.SnippetCount, &snapshot.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Exists reports whether a snapshot was taken on day
+func (r *SnapshotRepository) Exists(ctx context.Context, day string) (bool, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM library_snapshots WHERE day = ?`, day).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check snapshot: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AsOf retrieves the latest snapshot taken on or before day with its
+// manifest, or nil if there is none
+func (r *SnapshotRepository) AsOf(ctx context.Context, day string) (*models.LibrarySnapshot, error) {
+	var snapshot models.LibrarySnapshot
+	var manifest string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, day, snippet_count, created_at, manifest FROM library_snapshots
+		WHERE day <= ? ORDER BY day DESC LIMIT 1
+	`, day).Scan(&snapshot.ID, &snapshot.Day, &snapshot.SnippetCount, &snapshot.CreatedAt, &manifest)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if err := json.Unmarshal([]byte(manifest), &snapshot.Snippets); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot manifest: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// List retrieves snapshots without their manifests, newest first
+func (r *SnapshotRepository) List(ctx context.Context, limit int) ([]models.LibrarySnapshot, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, day, snippet_count, created_at FROM library_snapshots ORDER BY day DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	snapshots := []models.LibrarySnapshot{}
+	for rows.Next() {
+		var snapshot models.LibrarySnapshot
+		if err := rows.Scan(&snapshot.ID, &snapshot.Day, &snapshot.SnippetCount, &snapshot.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// Prune deletes snapshots taken before day, returning how many were deleted
+func (r *SnapshotRepository) Prune(ctx context.Context, before string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM library_snapshots WHERE day < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
@@ -10,7 +10,8 @@ import (
 
 // SnippetFileRepository handles snippet file database operations
 type SnippetFileRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	quickOpen *QuickOpenIndex
 }
 
 // NewSnippetFileRepository creates a new snippet file repository
@@ -18,6 +19,12 @@ func NewSnippetFileRepository(db *sql.DB) *SnippetFileRepository {
 	return &SnippetFileRepository{db: db}
 }
 
+// WithQuickOpen keeps the quick-open index's filenames up to date on writes
+func (r *SnippetFileRepository) WithQuickOpen(x *QuickOpenIndex) *SnippetFileRepository {
+	r.quickOpen = x
+	return r
+}
+
 // GetBySnippetID retrieves all files for a snippet
 func (r *SnippetFileRepository) GetBySnippetID(ctx context.Context, snippetID string) ([]models.SnippetFile, error) {
 	query := `
@@ -58,6 +65,7 @@ func (r *SnippetFileRepository) GetBySnippetID(ctx context.Context, snippetID st
 
 // Create creates a new snippet file
 func (r *SnippetFileRepository) Create(ctx context.Context, snippetID string, file *models.SnippetFileInput, sortOrder int) (*models.SnippetFile, error) {
+	defer r.quickOpen.invalidate(snippetID)
 	query := `
 		INSERT INTO snippet_files (snippet_id, filename, content, language, sort_order)
 		VALUES (?, ?, ?, ?, ?)
@@ -132,6 +140,7 @@ func (r *SnippetFileRepository) Delete(ctx context.Context, fileID int64) error
 
 // DeleteBySnippetID deletes all files for a snippet
 func (r *SnippetFileRepository) DeleteBySnippetID(ctx context.Context, snippetID string) error {
+	defer r.quickOpen.invalidate(snippetID)
 	_, err := r.db.ExecContext(ctx, "DELETE FROM snippet_files WHERE snippet_id = ?", snippetID)
 	if err != nil {
 		return fmt.Errorf("failed to delete snippet files: %w", err)
@@ -141,6 +150,8 @@ func (r *SnippetFileRepository) DeleteBySnippetID(ctx context.Context, snippetID
 
 // SyncFiles synchronizes files for a snippet (creates, updates, deletes as needed)
 func (r *SnippetFileRepository) SyncFiles(ctx context.Context, snippetID string, files []models.SnippetFileInput) ([]models.SnippetFile, error) {
+	defer r.quickOpen.invalidate(snippetID)
+
 	// Get existing files
 	existing, err := r.GetBySnippetID(ctx, snippetID)
 	if err != nil {
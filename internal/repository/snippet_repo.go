@@ -12,7 +12,44 @@ import (
 
 // SnippetRepository handles snippet database operations
 type SnippetRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	cache     *ReadCache
+	quickOpen *QuickOpenIndex
+	stmts     *Statements
+}
+
+// snippetColumns lists the columns read by every snippet query, in scan order
+const snippetColumns = `id, title, description, content, language, is_favorite, is_public,
+	view_count, s3_key, checksum, is_archived, source_url, is_pinned, pinned_at, slug, license, attribution,
+	review_state, publish_at, requires_checkout, noindex, created_at, updated_at`
+
+// snippetScanDest returns the scan destinations matching snippetColumns
+func snippetScanDest(s *models.Snippet) []interface{} {
+	return []interface{}{
+		&s.ID,
+		&s.Title,
+		&s.Description,
+		&s.Content,
+		&s.Language,
+		&s.IsFavorite,
+		&s.IsPublic,
+		&s.ViewCount,
+		&s.S3Key,
+		&s.Checksum,
+		&s.IsArchived,
+		&s.SourceURL,
+		&s.IsPinned,
+		&s.PinnedAt,
+		&s.Slug,
+		&s.License,
+		&s.Attribution,
+		&s.ReviewState,
+		&s.PublishAt,
+		&s.RequiresCheckout,
+		&s.NoIndex,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	}
 }
 
 // NewSnippetRepository creates a new snippet repository
@@ -20,71 +57,85 @@ func NewSnippetRepository(db *sql.DB) *SnippetRepository {
 	return &SnippetRepository{db: db}
 }
 
+// WithCache serves GetByID from c and invalidates it on writes
+func (r *SnippetRepository) WithCache(c *ReadCache) *SnippetRepository {
+	r.cache = c
+	return r
+}
+
+// WithQuickOpen keeps the quick-open index up to date on writes
+func (r *SnippetRepository) WithQuickOpen(x *QuickOpenIndex) *SnippetRepository {
+	r.quickOpen = x
+	return r
+}
+
+// WithStatements runs hot queries as prepared statements from stmts
+func (r *SnippetRepository) WithStatements(stmts *Statements) *SnippetRepository {
+	r.stmts = stmts
+	return r
+}
+
 // Create inserts a new snippet
 func (r *SnippetRepository) Create(ctx context.Context, input *models.SnippetInput) (*models.Snippet, error) {
 	query := `
-		INSERT INTO snippets (title, description, content, language, is_public, is_archived)
-		VALUES (?, ?, ?, ?, ?, ?)
-		RETURNING id, title, description, content, language, is_favorite, is_public, 
-		          view_count, s3_key, checksum, is_archived, created_at, updated_at
+		INSERT INTO snippets (id, title, description, content, language, is_public, is_archived, source_url, slug, license, attribution, review_state, publish_at, requires_checkout, noindex, is_favorite, is_pinned, pinned_at)
+		VALUES (COALESCE(NULLIF(?, ''), lower(hex(randomblob(8)))), ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), COALESCE(NULLIF(?, ''), 'draft'), NULLIF(?, ''), COALESCE(?, 0), COALESCE(?, 0), ?, ?, ?)
+		RETURNING ` + snippetColumns + `
 	`
 
+	// Stored like TogglePin stores it, so restored pins sort among new ones
+	var pinnedAt any
+	if input.PinnedAt != nil {
+		pinnedAt = input.PinnedAt.UTC().Format("2006-01-02 15:04:05.000")
+	}
+
 	snippet := &models.Snippet{}
 	err := r.db.QueryRowContext(ctx, query,
+		input.ID,
 		input.Title,
 		input.Description,
 		input.Content,
 		input.Language,
 		input.IsPublic,
 		input.IsArchived,
-	).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Description,
-		&snippet.Content,
-		&snippet.Language,
-		&snippet.IsFavorite,
-		&snippet.IsPublic,
-		&snippet.ViewCount,
-		&snippet.S3Key,
-		&snippet.Checksum,
-		&snippet.IsArchived,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-	)
-
+		input.SourceURL,
+		input.Slug,
+		input.License,
+		input.Attribution,
+		input.ReviewState,
+		input.PublishAt,
+		input.RequiresCheckout,
+		input.NoIndex,
+		input.IsFavorite,
+		input.PinnedAt != nil,
+		pinnedAt,
+	).Scan(snippetScanDest(snippet)...)
+
+	if isSlugConflict(err) {
+		return nil, ErrAlreadyExists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create snippet: %w", err)
 	}
 
+	r.quickOpen.invalidate(snippet.ID)
 	return snippet, nil
 }
 
 // GetByID retrieves a snippet by ID
 func (r *SnippetRepository) GetByID(ctx context.Context, id string) (*models.Snippet, error) {
+	if snippet, ok := r.cache.getSnippet(id); ok {
+		return snippet, nil
+	}
+
 	query := `
-		SELECT id, title, description, content, language, is_favorite, is_public,
-		       view_count, s3_key, checksum, is_archived, created_at, updated_at
+		SELECT ` + snippetColumns + `
 		FROM snippets
 		WHERE id = ?
 	`
 
 	snippet := &models.Snippet{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Description,
-		&snippet.Content,
-		&snippet.Language,
-		&snippet.IsFavorite,
-		&snippet.IsPublic,
-		&snippet.ViewCount,
-		&snippet.S3Key,
-		&snippet.Checksum,
-		&snippet.IsArchived,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-	)
+	err := r.stmts.queryRow(ctx, r.db, query, id).Scan(snippetScanDest(snippet)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -93,19 +144,148 @@ func (r *SnippetRepository) GetByID(ctx context.Context, id string) (*models.Sni
 		return nil, fmt.Errorf("failed to get snippet: %w", err)
 	}
 
+	r.cache.setSnippet(snippet)
 	return snippet, nil
 }
 
+// GetBySlug retrieves a snippet by its slug
+func (r *SnippetRepository) GetBySlug(ctx context.Context, slug string) (*models.Snippet, error) {
+	query := `
+		SELECT ` + snippetColumns + `
+		FROM snippets
+		WHERE slug = ?
+	`
+
+	snippet := &models.Snippet{}
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(snippetScanDest(snippet)...)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snippet by slug: %w", err)
+	}
+
+	return snippet, nil
+}
+
+// GetByTitle retrieves the snippet with a title, ignoring ASCII case. When
+// several match, active snippets win over archived ones and then the most
+// recently updated.
+func (r *SnippetRepository) GetByTitle(ctx context.Context, title string) (*models.Snippet, error) {
+	query := `
+		SELECT ` + snippetColumns + `
+		FROM snippets
+		WHERE title = ? COLLATE NOCASE
+		ORDER BY is_archived, updated_at DESC
+		LIMIT 1
+	`
+
+	snippet := &models.Snippet{}
+	err := r.db.QueryRowContext(ctx, query, title).Scan(snippetScanDest(snippet)...)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snippet by title: %w", err)
+	}
+
+	return snippet, nil
+}
+
+// SlugsWithPrefix returns the slugs equal to base or starting with base-,
+// ignoring the snippet excludeID. Slugs never contain LIKE wildcards.
+func (r *SnippetRepository) SlugsWithPrefix(ctx context.Context, base, excludeID string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT slug FROM snippets
+		WHERE (slug = ? OR slug LIKE ?) AND id != ?
+	`, base, base+"-%", excludeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list slugs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	slugs := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, fmt.Errorf("failed to scan slug: %w", err)
+		}
+		slugs[slug] = true
+	}
+	return slugs, rows.Err()
+}
+
+// SetChecksum stores the content checksum without touching updated_at
+func (r *SnippetRepository) SetChecksum(ctx context.Context, id, checksum string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE snippets SET checksum = ? WHERE id = ?", checksum, id)
+	r.cache.invalidateSnippet(id)
+	if err != nil {
+		return fmt.Errorf("failed to set checksum: %w", err)
+	}
+	return nil
+}
+
+// ListAll retrieves every snippet, including archived ones, oldest first
+func (r *SnippetRepository) ListAll(ctx context.Context) ([]models.Snippet, error) {
+	query := `
+		SELECT ` + snippetColumns + `
+		FROM snippets
+		ORDER BY created_at ASC, rowid ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	snippets := []models.Snippet{}
+	for rows.Next() {
+		var s models.Snippet
+		if err := rows.Scan(snippetScanDest(&s)...); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet: %w", err)
+		}
+		snippets = append(snippets, s)
+	}
+
+	return snippets, rows.Err()
+}
+
+// isSlugConflict reports whether err is a unique index violation on the slug
+func isSlugConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: snippets.slug")
+}
+
 // Update updates an existing snippet
 func (r *SnippetRepository) Update(ctx context.Context, id string, input *models.SnippetInput) (*models.Snippet, error) {
 	query := `
 		UPDATE snippets
-		SET title = ?, description = ?, content = ?, language = ?, is_public = ?, is_archived = ?, updated_at = CURRENT_TIMESTAMP
+		SET title = ?, description = ?, content = ?, language = ?, is_public = ?, is_archived = ?,
+		    source_url = CASE WHEN ? IS NULL THEN source_url ELSE NULLIF(?, '') END,
+		    slug = CASE WHEN ? IS NULL THEN slug ELSE NULLIF(?, '') END,
+		    license = CASE WHEN ? IS NULL THEN license ELSE NULLIF(?, '') END,
+		    attribution = CASE WHEN ? IS NULL THEN attribution ELSE NULLIF(?, '') END,
+		    publish_at = CASE WHEN ? IS NULL THEN publish_at ELSE NULLIF(?, '') END,
+		    requires_checkout = COALESCE(?, requires_checkout),
+		    noindex = COALESCE(?, noindex),
+		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-		RETURNING id, title, description, content, language, is_favorite, is_public,
-		          view_count, s3_key, checksum, is_archived, created_at, updated_at
+		RETURNING ` + snippetColumns + `
 	`
 
+	// Archiving changes tag and folder snippet counts
+	defer r.cache.invalidateLists()
+	defer r.cache.invalidateSnippet(id)
+	defer r.quickOpen.invalidate(id)
+
 	snippet := &models.Snippet{}
 	err := r.db.QueryRowContext(ctx, query,
 		input.Title,
@@ -114,26 +294,27 @@ func (r *SnippetRepository) Update(ctx context.Context, id string, input *models
 		input.Language,
 		input.IsPublic,
 		input.IsArchived,
+		input.SourceURL,
+		input.SourceURL,
+		input.Slug,
+		input.Slug,
+		input.License,
+		input.License,
+		input.Attribution,
+		input.Attribution,
+		input.PublishAt,
+		input.PublishAt,
+		input.RequiresCheckout,
+		input.NoIndex,
 		id,
-	).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Description,
-		&snippet.Content,
-		&snippet.Language,
-		&snippet.IsFavorite,
-		&snippet.IsPublic,
-		&snippet.ViewCount,
-		&snippet.S3Key,
-		&snippet.Checksum,
-		&snippet.IsArchived,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-	)
+	).Scan(snippetScanDest(snippet)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if isSlugConflict(err) {
+		return nil, ErrAlreadyExists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update snippet: %w", err)
 	}
@@ -149,11 +330,24 @@ func (r *SnippetRepository) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
+	defer r.cache.invalidateLists()
+	defer r.cache.invalidateSnippet(id)
+	defer r.quickOpen.invalidate(id)
 
 	// Delete related data first (in case CASCADE doesn't work)
 	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_tags WHERE snippet_id = ?", id)
 	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_folders WHERE snippet_id = ?", id)
 	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_files WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_metadata WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_reviews WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_usage WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_links WHERE source_id = ? OR target_id = ?", id, id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_locks WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM collab_documents WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM snippet_reports WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM burn_links WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM share_views WHERE snippet_id = ?", id)
+	_, _ = tx.ExecContext(ctx, "DELETE FROM share_visitors WHERE snippet_id = ?", id)
 
 	// Delete the snippet
 	result, err := tx.ExecContext(ctx, "DELETE FROM snippets WHERE id = ?", id)
@@ -177,6 +371,24 @@ func (r *SnippetRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// frecencyScore ranks a snippet by its usage over the last 90 days: every
+// event scores by age, from 100 points in the last four days down to 30
+// after a month, and copies count twice as much as views
+const frecencyScore = `(
+	SELECT COALESCE(SUM(` + frecencyWeight + `), 0)
+	FROM snippet_usage u
+	WHERE u.snippet_id = s.id AND u.created_at >= datetime('now', '-90 days')
+)`
+
+// frecencyWeight is the score of a single usage event u
+const frecencyWeight = `CASE u.event WHEN 'copy' THEN 2 ELSE 1 END *
+	CASE
+		WHEN u.created_at >= datetime('now', '-4 days') THEN 100
+		WHEN u.created_at >= datetime('now', '-14 days') THEN 70
+		WHEN u.created_at >= datetime('now', '-31 days') THEN 50
+		ELSE 30
+	END`
+
 // List retrieves snippets with filtering and pagination
 func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilter) (*models.SnippetListResponse, error) {
 	if filter.Limit <= 0 {
@@ -213,6 +425,16 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 		args = append(args, filter.Language)
 	}
 
+	if filter.License != "" {
+		conditions = append(conditions, "s.license = ? COLLATE NOCASE")
+		args = append(args, filter.License)
+	}
+
+	if filter.ReviewState != "" {
+		conditions = append(conditions, "s.review_state = ?")
+		args = append(args, filter.ReviewState)
+	}
+
 	if filter.IsFavorite != nil {
 		conditions = append(conditions, "s.is_favorite = ?")
 		if *filter.IsFavorite {
@@ -231,6 +453,15 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 		}
 	}
 
+	if filter.RequiresCheckout != nil {
+		conditions = append(conditions, "s.requires_checkout = ?")
+		if *filter.RequiresCheckout {
+			args = append(args, 1)
+		} else {
+			args = append(args, 0)
+		}
+	}
+
 	if filter.IsArchived != nil {
 		conditions = append(conditions, "s.is_archived = ?")
 		if *filter.IsArchived {
@@ -244,16 +475,13 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 	}
 
 	// Filter by tag (support both single and multiple tags)
+	tagIDs := filter.TagIDs
 	if filter.TagID > 0 {
-		conditions = append(conditions, "s.id IN (SELECT snippet_id FROM snippet_tags WHERE tag_id = ?)")
-		args = append(args, filter.TagID)
-	} else if len(filter.TagIDs) > 0 {
-		placeholders := make([]string, len(filter.TagIDs))
-		for i, tagID := range filter.TagIDs {
-			placeholders[i] = "?"
-			args = append(args, tagID)
-		}
-		conditions = append(conditions, fmt.Sprintf("s.id IN (SELECT snippet_id FROM snippet_tags WHERE tag_id IN (%s))", strings.Join(placeholders, ",")))
+		tagIDs = []int64{filter.TagID}
+	}
+	if condition, tagArgs := tagFilterCondition(tagIDs, filter.TagNames); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, tagArgs...)
 	}
 
 	// Filter by folder (support both single and multiple folders)
@@ -269,6 +497,26 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 		conditions = append(conditions, fmt.Sprintf("s.id IN (SELECT snippet_id FROM snippet_folders WHERE folder_id IN (%s))", strings.Join(placeholders, ",")))
 	}
 
+	// Limit to the folders a restricted token may see
+	if filter.AccessFolderIDs != nil {
+		if len(filter.AccessFolderIDs) == 0 {
+			conditions = append(conditions, "0 = 1")
+		} else {
+			placeholders := make([]string, len(filter.AccessFolderIDs))
+			for i, folderID := range filter.AccessFolderIDs {
+				placeholders[i] = "?"
+				args = append(args, folderID)
+			}
+			conditions = append(conditions, fmt.Sprintf("s.id IN (SELECT snippet_id FROM snippet_folders WHERE folder_id IN (%s))", strings.Join(placeholders, ",")))
+		}
+	}
+
+	// Filter by custom metadata (every key/value pair must match)
+	for key, value := range filter.Metadata {
+		conditions = append(conditions, "s.id IN (SELECT snippet_id FROM snippet_metadata WHERE key = ? AND value = ?)")
+		args = append(args, key, value)
+	}
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
@@ -277,7 +525,7 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM snippets s %s", whereClause)
 	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.stmts.queryRow(ctx, r.db, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count snippets: %w", err)
 	}
 
@@ -287,6 +535,7 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 		"updated_at": true,
 		"title":      true,
 		"language":   true,
+		"frecency":   true,
 	}
 	if !validSortColumns[filter.SortBy] {
 		filter.SortBy = "updated_at"
@@ -297,18 +546,26 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 		sortOrder = "ASC"
 	}
 
+	// Snippets without recent usage tie on frecency and fall back to the
+	// most recently updated
+	orderBy := fmt.Sprintf("s.%s %s", filter.SortBy, sortOrder)
+	if filter.SortBy == "frecency" {
+		orderBy = fmt.Sprintf("%s %s, s.updated_at DESC", frecencyScore, sortOrder)
+	}
+	// Ties keep insertion order, so paging neither repeats nor skips snippets
+	orderBy += ", s.rowid"
+
 	// Calculate offset
 	offset := (filter.Page - 1) * filter.Limit
 
 	// Build main query
 	query := fmt.Sprintf(`
-		SELECT s.id, s.title, s.description, s.content, s.language, s.is_favorite, s.is_public,
-		       s.view_count, s.s3_key, s.checksum, s.is_archived, s.created_at, s.updated_at
+		SELECT ` + snippetColumns + `
 		FROM snippets s
 		%s
-		ORDER BY s.%s %s
+		ORDER BY %s
 		LIMIT ? OFFSET ?
-	`, whereClause, filter.SortBy, sortOrder)
+	`, whereClause, orderBy)
 
 	args = append(args, filter.Limit, offset)
 
@@ -325,21 +582,7 @@ func (r *SnippetRepository) List(ctx context.Context, filter models.SnippetFilte
 	var snippets []models.Snippet
 	for rows.Next() {
 		var s models.Snippet
-		if err := rows.Scan(
-			&s.ID,
-			&s.Title,
-			&s.Description,
-			&s.Content,
-			&s.Language,
-			&s.IsFavorite,
-			&s.IsPublic,
-			&s.ViewCount,
-			&s.S3Key,
-			&s.Checksum,
-			&s.IsArchived,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
+		if err := rows.Scan(snippetScanDest(&s)...); err != nil {
 			return nil, fmt.Errorf("failed to scan snippet: %w", err)
 		}
 		snippets = append(snippets, s)
@@ -372,26 +615,12 @@ func (r *SnippetRepository) ToggleFavorite(ctx context.Context, id string) (*mod
 		UPDATE snippets
 		SET is_favorite = NOT is_favorite
 		WHERE id = ?
-		RETURNING id, title, description, content, language, is_favorite, is_public,
-		          view_count, s3_key, checksum, is_archived, created_at, updated_at
+		RETURNING ` + snippetColumns + `
 	`
 
+	defer r.cache.invalidateSnippet(id)
 	snippet := &models.Snippet{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Description,
-		&snippet.Content,
-		&snippet.Language,
-		&snippet.IsFavorite,
-		&snippet.IsPublic,
-		&snippet.ViewCount,
-		&snippet.S3Key,
-		&snippet.Checksum,
-		&snippet.IsArchived,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-	)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(snippetScanDest(snippet)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -403,34 +632,168 @@ func (r *SnippetRepository) ToggleFavorite(ctx context.Context, id string) (*mod
 	return snippet, nil
 }
 
+// TogglePin toggles the pinned status of a snippet
+func (r *SnippetRepository) TogglePin(ctx context.Context, id string) (*models.Snippet, error) {
+	query := `
+		UPDATE snippets
+		SET is_pinned = NOT is_pinned,
+		    pinned_at = CASE WHEN is_pinned = 1 THEN NULL ELSE strftime('%Y-%m-%d %H:%M:%f', 'now') END
+		WHERE id = ?
+		RETURNING ` + snippetColumns + `
+	`
+
+	defer r.cache.invalidateSnippet(id)
+	snippet := &models.Snippet{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(snippetScanDest(snippet)...)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to toggle pin: %w", err)
+	}
+
+	return snippet, nil
+}
+
+// Review moves a snippet from one review state to another and records the
+// action in the review log. It returns nil when the snippet does not exist or
+// is no longer in the from state.
+func (r *SnippetRepository) Review(ctx context.Context, id, from, to, action, comment string) (*models.Snippet, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	defer r.cache.invalidateSnippet(id)
+
+	query := `
+		UPDATE snippets
+		SET review_state = ?
+		WHERE id = ? AND review_state = ?
+		RETURNING ` + snippetColumns + `
+	`
+
+	snippet := &models.Snippet{}
+	err = tx.QueryRowContext(ctx, query, to, id, from).Scan(snippetScanDest(snippet)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update review state: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO snippet_reviews (snippet_id, action, state, comment) VALUES (?, ?, ?, ?)",
+		id, action, to, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return snippet, nil
+}
+
+// ListReviews returns a snippet's review log, newest first
+func (r *SnippetRepository) ListReviews(ctx context.Context, id string) ([]models.SnippetReview, error) {
+	query := `
+		SELECT id, snippet_id, action, state, comment, created_at
+		FROM snippet_reviews
+		WHERE snippet_id = ?
+		ORDER BY id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	reviews := []models.SnippetReview{}
+	for rows.Next() {
+		var review models.SnippetReview
+		if err := rows.Scan(&review.ID, &review.SnippetID, &review.Action, &review.State, &review.Comment, &review.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// Count returns the number of snippets
+func (r *SnippetRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snippets").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count snippets: %w", err)
+	}
+	return count, nil
+}
+
+// CountPinned returns the number of pinned snippets
+func (r *SnippetRepository) CountPinned(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snippets WHERE is_pinned = 1").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pinned snippets: %w", err)
+	}
+	return count, nil
+}
+
+// ListPinned retrieves pinned snippets in the order they were pinned
+func (r *SnippetRepository) ListPinned(ctx context.Context, limit int) ([]models.Snippet, error) {
+	query := `
+		SELECT ` + snippetColumns + `
+		FROM snippets
+		WHERE is_pinned = 1
+		ORDER BY pinned_at ASC, rowid ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned snippets: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	snippets := []models.Snippet{}
+	for rows.Next() {
+		var s models.Snippet
+		if err := rows.Scan(snippetScanDest(&s)...); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet: %w", err)
+		}
+		snippets = append(snippets, s)
+	}
+
+	return snippets, rows.Err()
+}
+
 // ToggleArchive toggles the archive status of a snippet
 func (r *SnippetRepository) ToggleArchive(ctx context.Context, id string) (*models.Snippet, error) {
 	query := `
 		UPDATE snippets
 		SET is_archived = NOT is_archived,
 		    is_public = CASE WHEN (NOT is_archived) = 1 THEN 0 ELSE is_public END,
+		    publish_at = CASE WHEN (NOT is_archived) = 1 THEN NULL ELSE publish_at END,
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-		RETURNING id, title, description, content, language, is_favorite, is_public,
-		          view_count, s3_key, checksum, is_archived, created_at, updated_at
+		RETURNING ` + snippetColumns + `
 	`
 
+	defer r.cache.invalidateLists()
+	defer r.cache.invalidateSnippet(id)
+	defer r.quickOpen.invalidate(id)
 	snippet := &models.Snippet{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Description,
-		&snippet.Content,
-		&snippet.Language,
-		&snippet.IsFavorite,
-		&snippet.IsPublic,
-		&snippet.ViewCount,
-		&snippet.S3Key,
-		&snippet.Checksum,
-		&snippet.IsArchived,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-	)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(snippetScanDest(snippet)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -442,9 +805,155 @@ func (r *SnippetRepository) ToggleArchive(ctx context.Context, id string) (*mode
 	return snippet, nil
 }
 
+// Unpublish makes a snippet private and cancels its scheduled publishing
+func (r *SnippetRepository) Unpublish(ctx context.Context, id string) (*models.Snippet, error) {
+	query := `
+		UPDATE snippets
+		SET is_public = 0, publish_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		RETURNING ` + snippetColumns + `
+	`
+
+	defer r.cache.invalidateLists()
+	defer r.cache.invalidateSnippet(id)
+	snippet := &models.Snippet{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(snippetScanDest(snippet)...)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpublish snippet: %w", err)
+	}
+
+	return snippet, nil
+}
+
+// PublishDue makes snippets whose publish time has passed public, clears
+// their schedule and returns them
+func (r *SnippetRepository) PublishDue(ctx context.Context) ([]models.Snippet, error) {
+	query := `
+		UPDATE snippets
+		SET is_public = 1, publish_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE publish_at IS NOT NULL AND publish_at <= CURRENT_TIMESTAMP AND is_archived = 0
+		RETURNING ` + snippetColumns + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish snippets: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	snippets := []models.Snippet{}
+	for rows.Next() {
+		var s models.Snippet
+		if err := rows.Scan(snippetScanDest(&s)...); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet: %w", err)
+		}
+		snippets = append(snippets, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(snippets) > 0 {
+		r.cache.invalidateLists()
+		for _, s := range snippets {
+			r.cache.invalidateSnippet(s.ID)
+		}
+	}
+	return snippets, nil
+}
+
+// ListAutoArchive lists unarchived, unpinned snippets that have not been
+// updated for longer than the auto-archive period of a folder they are in.
+// A snippet in several such folders is reported once, under the folder with
+// the shortest period.
+func (r *SnippetRepository) ListAutoArchive(ctx context.Context) ([]models.AutoArchiveCandidate, error) {
+	query := `
+		SELECT s.id, s.title, f.id, f.name, MIN(f.archive_after_days), s.updated_at
+		FROM snippets s
+		INNER JOIN snippet_folders sf ON sf.snippet_id = s.id
+		INNER JOIN folders f ON f.id = sf.folder_id
+		WHERE f.archive_after_days > 0 AND s.is_archived = 0 AND s.is_pinned = 0
+		  AND s.updated_at <= datetime('now', '-' || f.archive_after_days || ' days')
+		GROUP BY s.id
+		ORDER BY s.updated_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auto-archive candidates: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	candidates := []models.AutoArchiveCandidate{}
+	for rows.Next() {
+		var c models.AutoArchiveCandidate
+		if err := rows.Scan(&c.SnippetID, &c.Title, &c.FolderID, &c.FolderName, &c.ArchiveAfterDays, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan auto-archive candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// Archive archives a snippet, making it private and dropping any publish
+// schedule. It reports false when the snippet is missing or already archived.
+func (r *SnippetRepository) Archive(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE snippets
+		SET is_archived = 1, is_public = 0, publish_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_archived = 0
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to archive snippet: %w", err)
+	}
+
+	r.cache.invalidateLists()
+	r.cache.invalidateSnippet(id)
+	r.quickOpen.invalidate(id)
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// RecordUsage stores a usage event for a snippet
+func (r *SnippetRepository) RecordUsage(ctx context.Context, id, event string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO snippet_usage (snippet_id, event) VALUES (?, ?)", id, event)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// PruneUsage deletes usage events too old to count towards frecency and
+// returns how many were removed
+func (r *SnippetRepository) PruneUsage(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM snippet_usage WHERE created_at < datetime('now', '-90 days')")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune usage: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 // IncrementViewCount increments the view count for a snippet
 func (r *SnippetRepository) IncrementViewCount(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, "UPDATE snippets SET view_count = view_count + 1 WHERE id = ?", id)
+	r.cache.invalidateSnippet(id)
 	if err != nil {
 		return fmt.Errorf("failed to increment view count: %w", err)
 	}
@@ -458,8 +967,7 @@ func (r *SnippetRepository) Search(ctx context.Context, query string, limit int)
 	}
 
 	sqlQuery := `
-		SELECT s.id, s.title, s.description, s.content, s.language, s.is_favorite, s.is_public,
-		       s.view_count, s.s3_key, s.checksum, s.is_archived, s.created_at, s.updated_at
+		SELECT ` + snippetColumns + `
 		FROM snippets s
 		WHERE s.rowid IN (
 			SELECT rowid FROM snippets_fts WHERE snippets_fts MATCH ?
@@ -480,21 +988,7 @@ func (r *SnippetRepository) Search(ctx context.Context, query string, limit int)
 	var snippets []models.Snippet
 	for rows.Next() {
 		var s models.Snippet
-		if err := rows.Scan(
-			&s.ID,
-			&s.Title,
-			&s.Description,
-			&s.Content,
-			&s.Language,
-			&s.IsFavorite,
-			&s.IsPublic,
-			&s.ViewCount,
-			&s.S3Key,
-			&s.Checksum,
-			&s.IsArchived,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
+		if err := rows.Scan(snippetScanDest(&s)...); err != nil {
 			return nil, fmt.Errorf("failed to scan snippet: %w", err)
 		}
 		snippets = append(snippets, s)
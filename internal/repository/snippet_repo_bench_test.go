@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+var benchLanguages = []string{"go", "python", "javascript", "rust", "sql"}
+
+// seedBenchSnippets creates n snippets spread across languages, tags and favorites
+func seedBenchSnippets(b *testing.B, repo *SnippetRepository, tagRepo *TagRepository, n int) []int64 {
+	b.Helper()
+	ctx := testutil.TestContext()
+
+	for i := 0; i < n; i++ {
+		snippet, err := repo.Create(ctx, &models.SnippetInput{
+			Title:       fmt.Sprintf("Benchmark snippet %d", i),
+			Description: fmt.Sprintf("Handles request parsing case %d", i),
+			Content:     fmt.Sprintf("func handler%d(w http.ResponseWriter, r *http.Request) {\n\tparse(r)\n}\n", i),
+			Language:    benchLanguages[i%len(benchLanguages)],
+		})
+		if err != nil {
+			b.Fatalf("Create failed: %v", err)
+		}
+		if err := tagRepo.SetSnippetTags(ctx, snippet.ID, []string{fmt.Sprintf("tag%d", i%10)}); err != nil {
+			b.Fatalf("SetSnippetTags failed: %v", err)
+		}
+		if i%7 == 0 {
+			if _, err := repo.ToggleFavorite(ctx, snippet.ID); err != nil {
+				b.Fatalf("ToggleFavorite failed: %v", err)
+			}
+		}
+	}
+
+	tags, err := tagRepo.List(ctx)
+	if err != nil {
+		b.Fatalf("List tags failed: %v", err)
+	}
+	ids := make([]int64, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
+
+func BenchmarkSnippetRepository_List(b *testing.B) {
+	db := testutil.TestDB(b)
+	repo := NewSnippetRepository(db)
+	tagIDs := seedBenchSnippets(b, repo, NewTagRepository(db), 1000)
+	ctx := testutil.TestContext()
+	favorite := true
+
+	cases := []struct {
+		name   string
+		filter models.SnippetFilter
+	}{
+		{"default", models.DefaultSnippetFilter()},
+		{"language", func() models.SnippetFilter {
+			f := models.DefaultSnippetFilter()
+			f.Language = "go"
+			return f
+		}()},
+		{"tags_and_favorite", func() models.SnippetFilter {
+			f := models.DefaultSnippetFilter()
+			f.TagIDs = tagIDs[:3]
+			f.IsFavorite = &favorite
+			return f
+		}()},
+		{"query", func() models.SnippetFilter {
+			f := models.DefaultSnippetFilter()
+			f.Query = "parsing"
+			return f
+		}()},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.List(ctx, tc.filter); err != nil {
+					b.Fatalf("List failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSnippetRepository_Search(b *testing.B) {
+	db := testutil.TestDB(b)
+	repo := NewSnippetRepository(db)
+	seedBenchSnippets(b, repo, NewTagRepository(db), 1000)
+	ctx := testutil.TestContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.Search(ctx, "request", 20); err != nil {
+			b.Fatalf("Search failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSnippetRepository_CreateWithFiles(b *testing.B) {
+	db := testutil.TestDB(b)
+	repo := NewSnippetRepository(db)
+	fileRepo := NewSnippetFileRepository(db)
+	ctx := testutil.TestContext()
+
+	files := []models.SnippetFileInput{
+		{Filename: "main.go", Content: "package main\n\nfunc main() {}\n", Language: "go"},
+		{Filename: "README.md", Content: "# Example\n", Language: "markdown"},
+		{Filename: "Makefile", Content: "build:\n\tgo build ./...\n", Language: "makefile"},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		snippet, err := repo.Create(ctx, &models.SnippetInput{
+			Title:    fmt.Sprintf("Multi-file %d", i),
+			Content:  files[0].Content,
+			Language: "go",
+		})
+		if err != nil {
+			b.Fatalf("Create failed: %v", err)
+		}
+		if _, err := fileRepo.SyncFiles(ctx, snippet.ID, files); err != nil {
+			b.Fatalf("SyncFiles failed: %v", err)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/testutil"
@@ -200,6 +203,18 @@ func TestSnippetRepository_Delete_NotFound(t *testing.T) {
 	}
 }
 
+func TestSnippetRepository_ListDeadline(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewSnippetRepository(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	_, err := repo.List(ctx, models.SnippetFilter{Query: "content"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline error, got %v", err)
+	}
+}
+
 func TestSnippetRepository_List(t *testing.T) {
 	db := testutil.TestDB(t)
 	repo := NewSnippetRepository(db)
@@ -384,6 +399,69 @@ func TestSnippetRepository_ToggleFavorite(t *testing.T) {
 	}
 }
 
+func TestSnippetRepository_TogglePin(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewSnippetRepository(db)
+	ctx := testutil.TestContext()
+
+	var ids []string
+	for _, title := range []string{"First", "Second", "Third"} {
+		created, err := repo.Create(ctx, &models.SnippetInput{Title: title, Content: "content", Language: "plaintext"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ids = append(ids, created.ID)
+	}
+
+	// Pin in a different order than creation
+	for _, id := range []string{ids[2], ids[0]} {
+		pinned, err := repo.TogglePin(ctx, id)
+		if err != nil {
+			t.Fatalf("TogglePin failed: %v", err)
+		}
+		if !pinned.IsPinned || pinned.PinnedAt == nil {
+			t.Errorf("expected snippet %s to be pinned with a timestamp", id)
+		}
+	}
+
+	count, err := repo.CountPinned(ctx)
+	if err != nil {
+		t.Fatalf("CountPinned failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 pinned snippets, got %d", count)
+	}
+
+	pinned, err := repo.ListPinned(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPinned failed: %v", err)
+	}
+	if len(pinned) != 2 {
+		t.Fatalf("expected 2 pinned snippets, got %d", len(pinned))
+	}
+
+	// Unpinning clears the timestamp and leaves favorites untouched
+	unpinned, err := repo.TogglePin(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("TogglePin failed: %v", err)
+	}
+	if unpinned.IsPinned || unpinned.PinnedAt != nil || unpinned.IsFavorite {
+		t.Errorf("unexpected state after unpin: %+v", unpinned)
+	}
+
+	pinned, err = repo.ListPinned(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPinned failed: %v", err)
+	}
+	if len(pinned) != 1 || pinned[0].ID != ids[0] {
+		t.Errorf("expected only %s to remain pinned, got %+v", ids[0], pinned)
+	}
+
+	if missing, err := repo.TogglePin(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("expected nil result for missing snippet, got %v, %v", missing, err)
+	}
+}
+
 func TestSnippetRepository_Search(t *testing.T) {
 	db := testutil.TestDB(t)
 	repo := NewSnippetRepository(db)
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// StatementStats reports how the statement cache is doing
+type StatementStats struct {
+	Prepared int    `json:"prepared"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Bypassed uint64 `json:"bypassed"` // Queries run unprepared because the cache was full
+}
+
+// Statements caches prepared statements for hot queries, so SQLite parses
+// each once rather than on every call. Queries are keyed by their text, so
+// a query built at runtime gets one entry per shape; once max are cached,
+// new shapes run unprepared. A nil *Statements prepares nothing and runs
+// every query directly on the database.
+type Statements struct {
+	db  *sql.DB
+	max int
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+	bypassed atomic.Uint64
+}
+
+// NewStatements creates a cache holding up to max prepared statements
+func NewStatements(db *sql.DB, max int) *Statements {
+	return &Statements{db: db, max: max, stmts: make(map[string]*sql.Stmt)}
+}
+
+// Stats returns the cache's metrics, or nil for a nil cache
+func (s *Statements) Stats() *StatementStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	prepared := len(s.stmts)
+	s.mu.Unlock()
+	return &StatementStats{
+		Prepared: prepared,
+		Hits:     s.hits.Load(),
+		Misses:   s.misses.Load(),
+		Bypassed: s.bypassed.Load(),
+	}
+}
+
+// Close closes every cached statement. Later queries run unprepared.
+func (s *Statements) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, stmt := range s.stmts {
+		errs = append(errs, stmt.Close())
+	}
+	s.stmts = make(map[string]*sql.Stmt)
+	s.max = 0
+	return errors.Join(errs...)
+}
+
+// queryRow runs a single-row query, prepared when possible and on db otherwise
+func (s *Statements) queryRow(ctx context.Context, db *sql.DB, query string, args ...any) *sql.Row {
+	if stmt := s.stmt(ctx, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+// query runs a query, prepared when possible and on db otherwise
+func (s *Statements) query(ctx context.Context, db *sql.DB, query string, args ...any) (*sql.Rows, error) {
+	if stmt := s.stmt(ctx, query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// stmt returns the prepared statement for query, preparing it on first use.
+// It returns nil when the query should run unprepared: no cache, a full
+// cache, or a failed prepare, whose error the direct query then reports.
+func (s *Statements) stmt(ctx context.Context, query string) *sql.Stmt {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	stmt, ok := s.stmts[query]
+	full := len(s.stmts) >= s.max
+	s.mu.Unlock()
+	if ok {
+		s.hits.Add(1)
+		return stmt
+	}
+	if full {
+		s.bypassed.Add(1)
+		return nil
+	}
+
+	// Prepare without the lock, as it may wait for a connection held by a
+	// caller that is about to look up another statement
+	s.misses.Add(1)
+	prepared, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stmt, ok := s.stmts[query]; ok {
+		// Another caller prepared it meanwhile
+		_ = prepared.Close()
+		return stmt
+	}
+	if len(s.stmts) >= s.max {
+		_ = prepared.Close()
+		return nil
+	}
+	s.stmts[query] = prepared
+	return prepared
+}
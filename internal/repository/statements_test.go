@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestStatements(t *testing.T) {
+	db := testutil.TestDB(t)
+	stmts := NewStatements(db, 2)
+	defer func() { _ = stmts.Close() }()
+	repo := NewSnippetRepository(db).WithStatements(stmts)
+	ctx := testutil.TestContext()
+
+	created, err := repo.Create(ctx, &models.SnippetInput{Title: "Prepared", Content: "x", Language: "go"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	for range 3 {
+		got, err := repo.GetByID(ctx, created.ID)
+		if err != nil || got == nil || got.Title != "Prepared" {
+			t.Fatalf("GetByID = %v, %v", got, err)
+		}
+	}
+	stats := stmts.Stats()
+	if stats.Prepared != 1 || stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("expected one statement reused twice, got %+v", stats)
+	}
+
+	// Each filter shape is a query of its own; the third does not fit
+	for _, filter := range []models.SnippetFilter{{}, {Language: "go"}, {Query: "Prep"}} {
+		list, err := repo.List(ctx, filter)
+		if err != nil || list.Pagination.Total != 1 {
+			t.Fatalf("List(%+v) = %v, %v", filter, list, err)
+		}
+	}
+	stats = stmts.Stats()
+	if stats.Prepared != 2 || stats.Bypassed != 2 {
+		t.Errorf("expected a full cache to run the rest unprepared, got %+v", stats)
+	}
+
+	if err := stmts.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, created.ID); err != nil || got == nil {
+		t.Errorf("expected queries to run unprepared after Close, got %v, %v", got, err)
+	}
+}
+
+func TestStatements_Nil(t *testing.T) {
+	db := testutil.TestDB(t)
+	var stmts *Statements
+	if stmts.Stats() != nil || stmts.Close() != nil {
+		t.Error("expected a nil cache to report nothing")
+	}
+
+	var n int
+	if err := stmts.queryRow(testutil.TestContext(), db, "SELECT 1").Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected the query to run on the database, got %d, %v", n, err)
+	}
+}
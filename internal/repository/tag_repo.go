@@ -11,7 +11,9 @@ import (
 
 // TagRepository handles tag database operations
 type TagRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	cache *ReadCache
+	stmts *Statements
 }
 
 // NewTagRepository creates a new tag repository
@@ -19,19 +21,34 @@ func NewTagRepository(db *sql.DB) *TagRepository {
 	return &TagRepository{db: db}
 }
 
+// WithCache serves reads from c and invalidates it on writes
+func (r *TagRepository) WithCache(c *ReadCache) *TagRepository {
+	r.cache = c
+	return r
+}
+
+// WithStatements runs hot queries as prepared statements from stmts
+func (r *TagRepository) WithStatements(stmts *Statements) *TagRepository {
+	r.stmts = stmts
+	return r
+}
+
 // Create creates a new tag
 func (r *TagRepository) Create(ctx context.Context, input *models.TagInput) (*models.Tag, error) {
+	defer r.cache.invalidateLists()
+
 	query := `
-		INSERT INTO tags (name, color)
-		VALUES (?, ?)
-		RETURNING id, name, color, created_at
+		INSERT INTO tags (name, color, text_color)
+		VALUES (?, ?, ?)
+		RETURNING id, name, color, text_color, created_at
 	`
 
 	tag := &models.Tag{}
-	err := r.db.QueryRowContext(ctx, query, input.Name, input.Color).Scan(
+	err := r.db.QueryRowContext(ctx, query, input.Name, input.Color, models.TagTextColor(input.Color)).Scan(
 		&tag.ID,
 		&tag.Name,
 		&tag.Color,
+		&tag.TextColor,
 		&tag.CreatedAt,
 	)
 	if err != nil {
@@ -43,13 +60,14 @@ func (r *TagRepository) Create(ctx context.Context, input *models.TagInput) (*mo
 
 // GetByID retrieves a tag by ID
 func (r *TagRepository) GetByID(ctx context.Context, id int64) (*models.Tag, error) {
-	query := `SELECT id, name, color, created_at FROM tags WHERE id = ?`
+	query := `SELECT id, name, color, text_color, created_at FROM tags WHERE id = ?`
 
 	tag := &models.Tag{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.queryRow(ctx, r.db, query, id).Scan(
 		&tag.ID,
 		&tag.Name,
 		&tag.Color,
+		&tag.TextColor,
 		&tag.CreatedAt,
 	)
 	if err != nil {
@@ -59,18 +77,20 @@ func (r *TagRepository) GetByID(ctx context.Context, id int64) (*models.Tag, err
 		return nil, fmt.Errorf("failed to get tag: %w", err)
 	}
 
+	fillTextColor(tag)
 	return tag, nil
 }
 
 // GetByName retrieves a tag by name
 func (r *TagRepository) GetByName(ctx context.Context, name string) (*models.Tag, error) {
-	query := `SELECT id, name, color, created_at FROM tags WHERE name = ?`
+	query := `SELECT id, name, color, text_color, created_at FROM tags WHERE name = ?`
 
 	tag := &models.Tag{}
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
+	err := r.stmts.queryRow(ctx, r.db, query, name).Scan(
 		&tag.ID,
 		&tag.Name,
 		&tag.Color,
+		&tag.TextColor,
 		&tag.CreatedAt,
 	)
 	if err != nil {
@@ -80,13 +100,18 @@ func (r *TagRepository) GetByName(ctx context.Context, name string) (*models.Tag
 		return nil, fmt.Errorf("failed to get tag by name: %w", err)
 	}
 
+	fillTextColor(tag)
 	return tag, nil
 }
 
 // List retrieves all tags with snippet counts
 func (r *TagRepository) List(ctx context.Context) ([]models.Tag, error) {
+	if tags, ok := r.cache.getTags(); ok {
+		return tags, nil
+	}
+
 	query := `
-		SELECT t.id, t.name, t.color, t.created_at,
+		SELECT t.id, t.name, t.color, t.text_color, t.created_at,
 		       (SELECT COUNT(*) FROM snippet_tags st 
 		        INNER JOIN snippets s ON s.id = st.snippet_id 
 		        WHERE st.tag_id = t.id AND s.is_archived = 0) as snippet_count
@@ -107,9 +132,10 @@ func (r *TagRepository) List(ctx context.Context) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.SnippetCount); err != nil {
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.TextColor, &tag.CreatedAt, &tag.SnippetCount); err != nil {
 			return nil, fmt.Errorf("failed to scan tag: %w", err)
 		}
+		fillTextColor(&tag)
 		tags = append(tags, tag)
 	}
 
@@ -117,23 +143,27 @@ func (r *TagRepository) List(ctx context.Context) ([]models.Tag, error) {
 		return nil, fmt.Errorf("error iterating tags: %w", err)
 	}
 
+	r.cache.setTags(tags)
 	return tags, nil
 }
 
 // Update updates an existing tag
 func (r *TagRepository) Update(ctx context.Context, id int64, input *models.TagInput) (*models.Tag, error) {
+	defer r.cache.invalidateLists()
+
 	query := `
 		UPDATE tags
-		SET name = ?, color = ?
+		SET name = ?, color = ?, text_color = ?
 		WHERE id = ?
-		RETURNING id, name, color, created_at
+		RETURNING id, name, color, text_color, created_at
 	`
 
 	tag := &models.Tag{}
-	err := r.db.QueryRowContext(ctx, query, input.Name, input.Color, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, input.Name, input.Color, models.TagTextColor(input.Color), id).Scan(
 		&tag.ID,
 		&tag.Name,
 		&tag.Color,
+		&tag.TextColor,
 		&tag.CreatedAt,
 	)
 	if err != nil {
@@ -146,9 +176,28 @@ func (r *TagRepository) Update(ctx context.Context, id int64, input *models.TagI
 	return tag, nil
 }
 
-// Delete deletes a tag
+// Delete deletes a tag with its aliases, implications and snippet tags in
+// one transaction, so none of them attach to a tag that later reuses the ID
 func (r *TagRepository) Delete(ctx context.Context, id int64) error {
-	result, err := r.db.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+	defer r.cache.invalidateLists()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, q := range []string{
+		`DELETE FROM tag_aliases WHERE tag_id = ?`,
+		`DELETE FROM tag_implications WHERE tag_id = ?1 OR implied_tag_id = ?1`,
+		`DELETE FROM snippet_tags WHERE tag_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return fmt.Errorf("failed to delete tag references: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete tag: %w", err)
 	}
@@ -162,20 +211,23 @@ func (r *TagRepository) Delete(ctx context.Context, id int64) error {
 		return ErrNotFound
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 // GetSnippetTags retrieves all tags for a snippet
 func (r *TagRepository) GetSnippetTags(ctx context.Context, snippetID string) ([]models.Tag, error) {
 	query := `
-		SELECT t.id, t.name, t.color, t.created_at
+		SELECT t.id, t.name, t.color, t.text_color, t.created_at
 		FROM tags t
 		JOIN snippet_tags st ON t.id = st.tag_id
 		WHERE st.snippet_id = ?
 		ORDER BY t.name ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, snippetID)
+	rows, err := r.stmts.query(ctx, r.db, query, snippetID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get snippet tags: %w", err)
 	}
@@ -188,17 +240,21 @@ func (r *TagRepository) GetSnippetTags(ctx context.Context, snippetID string) ([
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt); err != nil {
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.TextColor, &tag.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan tag: %w", err)
 		}
+		fillTextColor(&tag)
 		tags = append(tags, tag)
 	}
 
 	return tags, nil
 }
 
-// SetSnippetTags sets the tags for a snippet (replaces existing)
+// SetSnippetTags sets the tags for a snippet (replaces existing). Aliases
+// are replaced by their tag, and tags add the tags they imply.
 func (r *TagRepository) SetSnippetTags(ctx context.Context, snippetID string, tagNames []string) error {
+	defer r.cache.invalidateLists()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -211,16 +267,21 @@ func (r *TagRepository) SetSnippetTags(ctx context.Context, snippetID string, ta
 		return fmt.Errorf("failed to remove existing tags: %w", err)
 	}
 
-	// Add new tags
+	// Add new tags, with aliases replaced by their tag
 	for _, name := range tagNames {
+		name, err := resolveTagName(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
 		// Get or create tag
 		var tagID int64
-		err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, name).Scan(&tagID)
+		err = tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, name).Scan(&tagID)
 		if err == sql.ErrNoRows {
 			// Create new tag with default color
 			err = tx.QueryRowContext(ctx,
-				`INSERT INTO tags (name, color) VALUES (?, '#6366f1') RETURNING id`,
-				name,
+				`INSERT INTO tags (name, color, text_color) VALUES (?, ?, ?) RETURNING id`,
+				name, models.DefaultTagColor, models.TagTextColor(models.DefaultTagColor),
 			).Scan(&tagID)
 			if err != nil {
 				return fmt.Errorf("failed to create tag %s: %w", name, err)
@@ -237,6 +298,21 @@ func (r *TagRepository) SetSnippetTags(ctx context.Context, snippetID string, ta
 		if err != nil {
 			return fmt.Errorf("failed to link tag %s to snippet: %w", name, err)
 		}
+
+		// Add the tags it implies
+		implied, err := impliedTagIDs(ctx, tx, tagID)
+		if err != nil {
+			return err
+		}
+		for _, impliedID := range implied {
+			_, err = tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO snippet_tags (snippet_id, tag_id) VALUES (?, ?)`,
+				snippetID, impliedID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to link implied tag to snippet: %w", err)
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -260,3 +336,11 @@ func (r *TagRepository) GetTagSnippetCount(ctx context.Context, tagID int64) (in
 	}
 	return count, nil
 }
+
+// fillTextColor computes the text color of tags saved before text colors
+// were stored
+func fillTextColor(tag *models.Tag) {
+	if tag.TextColor == "" {
+		tag.TextColor = models.TagTextColor(tag.Color)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/MohamedElashri/snipo/internal/models"
@@ -428,3 +429,49 @@ func TestTagRepository_GetTagSnippetCount_Archived(t *testing.T) {
 		t.Errorf("expected count 1 after unarchiving, got %d", count)
 	}
 }
+
+func TestTagRepository_Suggest(t *testing.T) {
+	db := testutil.TestDB(t)
+	tagRepo := NewTagRepository(db)
+	snippetRepo := NewSnippetRepository(db)
+	ctx := testutil.TestContext()
+
+	for _, name := range []string{"go", "golang", "go_tips", "gossip", "rust"} {
+		if _, err := tagRepo.Create(ctx, &models.TagInput{Name: name}); err != nil {
+			t.Fatalf("Create tag failed: %v", err)
+		}
+	}
+	for _, tags := range [][]string{{"golang"}, {"golang", "gossip"}} {
+		snippet, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Snippet", Content: "content", Language: "go"})
+		if err != nil {
+			t.Fatalf("Create snippet failed: %v", err)
+		}
+		if err := tagRepo.SetSnippetTags(ctx, snippet.ID, tags); err != nil {
+			t.Fatalf("SetSnippetTags failed: %v", err)
+		}
+	}
+
+	suggestions, err := tagRepo.Suggest(ctx, "GO", 10)
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	var names []string
+	for _, s := range suggestions {
+		names = append(names, s.Value)
+	}
+	if want := "golang gossip go go_tips"; strings.Join(names, " ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(names, " "))
+	}
+	if suggestions[0].Count != 2 || suggestions[0].ID == 0 {
+		t.Errorf("unexpected first suggestion: %+v", suggestions[0])
+	}
+
+	// LIKE wildcards in the prefix match literally
+	suggestions, err = tagRepo.Suggest(ctx, "go_", 10)
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Value != "go_tips" {
+		t.Errorf("expected only go_tips, got %+v", suggestions)
+	}
+}
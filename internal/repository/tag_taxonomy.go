@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// ErrImplicationCycle is returned when making a tag imply another would
+// make it imply itself
+var ErrImplicationCycle = errors.New("tag implication would form a cycle")
+
+// impliedTagsCTE lists, as implied(id), every tag the tag bound to the
+// parameter implies, directly or through other tags. UNION stops cycles
+// left behind by concurrent writes.
+const impliedTagsCTE = `
+	WITH RECURSIVE implied(id) AS (
+		SELECT implied_tag_id FROM tag_implications WHERE tag_id = ?
+		UNION
+		SELECT i.implied_tag_id FROM tag_implications i JOIN implied ON i.tag_id = implied.id
+	)
+`
+
+// queryer runs queries on a database or in a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ResolveAlias returns the tag an alias names, or ErrNotFound
+func (r *TagRepository) ResolveAlias(ctx context.Context, alias string) (*models.Tag, error) {
+	tag := &models.Tag{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT t.id, t.name, t.color, t.text_color, t.created_at
+		FROM tag_aliases a
+		JOIN tags t ON t.id = a.tag_id
+		WHERE a.alias = ?
+	`, alias).Scan(&tag.ID, &tag.Name, &tag.Color, &tag.TextColor, &tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to resolve tag alias: %w", err)
+	}
+	fillTextColor(tag)
+	return tag, nil
+}
+
+// ListAliases returns a tag's aliases, sorted by name
+func (r *TagRepository) ListAliases(ctx context.Context, tagID int64) ([]models.TagAlias, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT alias, tag_id, created_at FROM tag_aliases WHERE tag_id = ? ORDER BY alias ASC
+	`, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag aliases: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	aliases := []models.TagAlias{}
+	for rows.Next() {
+		var alias models.TagAlias
+		if err := rows.Scan(&alias.Alias, &alias.TagID, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// AddAlias makes alias another name for a tag. It returns ErrAlreadyExists
+// when a tag or another alias already has that name.
+func (r *TagRepository) AddAlias(ctx context.Context, tagID int64, alias string) (*models.TagAlias, error) {
+	if _, err := r.GetByID(ctx, tagID); err != nil {
+		return nil, err
+	}
+	if _, err := r.GetByName(ctx, alias); err == nil {
+		return nil, ErrAlreadyExists
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	created := &models.TagAlias{}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO tag_aliases (alias, tag_id) VALUES (?, ?)
+		RETURNING alias, tag_id, created_at
+	`, alias, tagID).Scan(&created.Alias, &created.TagID, &created.CreatedAt)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: tag_aliases.alias") {
+		return nil, ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tag alias: %w", err)
+	}
+	return created, nil
+}
+
+// DeleteAlias removes one of a tag's aliases
+func (r *TagRepository) DeleteAlias(ctx context.Context, tagID int64, alias string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tag_aliases WHERE tag_id = ? AND alias = ?`, tagID, alias)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag alias: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListImplied returns the tags a tag directly implies, sorted by name
+func (r *TagRepository) ListImplied(ctx context.Context, tagID int64) ([]models.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.name, t.color, t.text_color, t.created_at
+		FROM tag_implications i
+		JOIN tags t ON t.id = i.implied_tag_id
+		WHERE i.tag_id = ?
+		ORDER BY t.name ASC
+	`, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list implied tags: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.TextColor, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		fillTextColor(&tag)
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating implied tags: %w", err)
+	}
+	return tags, nil
+}
+
+// AddImplication makes tagging a snippet with tagID also tag it with
+// impliedID. Both tags must exist; it returns ErrImplicationCycle when
+// impliedID already implies tagID, or is tagID. Adding an existing
+// implication does nothing.
+func (r *TagRepository) AddImplication(ctx context.Context, tagID, impliedID int64) error {
+	if tagID == impliedID {
+		return ErrImplicationCycle
+	}
+	for _, id := range []int64{tagID, impliedID} {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cycle bool
+	err = tx.QueryRowContext(ctx, impliedTagsCTE+`SELECT EXISTS (SELECT 1 FROM implied WHERE id = ?)`, impliedID, tagID).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check tag implications: %w", err)
+	}
+	if cycle {
+		return ErrImplicationCycle
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tag_implications (tag_id, implied_tag_id) VALUES (?, ?)`, tagID, impliedID); err != nil {
+		return fmt.Errorf("failed to add tag implication: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteImplication stops tagID from implying impliedID. Snippets already
+// tagged keep the implied tag.
+func (r *TagRepository) DeleteImplication(ctx context.Context, tagID, impliedID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tag_implications WHERE tag_id = ? AND implied_tag_id = ?`, tagID, impliedID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag implication: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// resolveTagName returns the name a snippet is tagged with for name: the
+// tag's name when name is an alias, or name itself
+func resolveTagName(ctx context.Context, q queryer, name string) (string, error) {
+	var resolved string
+	err := q.QueryRowContext(ctx, `
+		SELECT t.name FROM tag_aliases a JOIN tags t ON t.id = a.tag_id WHERE a.alias = ?
+	`, name).Scan(&resolved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return name, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve tag alias %s: %w", name, err)
+	}
+	return resolved, nil
+}
+
+// impliedTagIDs returns every tag tagID implies, directly or not
+func impliedTagIDs(ctx context.Context, q queryer, tagID int64) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, impliedTagsCTE+`SELECT implied.id FROM implied JOIN tags t ON t.id = implied.id`, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implied tags: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan implied tag: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// tagFilterCondition returns a condition on snippets s matching those
+// tagged with any of ids or names, where names may be aliases, or with a
+// tag implying one of them, so snippets tagged before an implication was
+// added are found too. It returns "" without tags.
+func tagFilterCondition(ids []int64, names []string) (string, []any) {
+	var anchors []string
+	var args []any
+	if len(ids) > 0 {
+		anchors = append(anchors, `SELECT id FROM tags WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+`)`)
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	if len(names) > 0 {
+		in := strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")
+		anchors = append(anchors,
+			`SELECT id FROM tags WHERE name IN (`+in+`)`,
+			`SELECT tag_id FROM tag_aliases WHERE alias IN (`+in+`)`,
+		)
+		for range 2 {
+			for _, name := range names {
+				args = append(args, name)
+			}
+		}
+	}
+	if len(anchors) == 0 {
+		return "", nil
+	}
+
+	return `s.id IN (SELECT snippet_id FROM snippet_tags WHERE tag_id IN (
+		WITH RECURSIVE matched(id) AS (
+			` + strings.Join(anchors, " UNION ") + `
+			UNION
+			SELECT i.tag_id FROM tag_implications i JOIN matched ON i.implied_tag_id = matched.id
+		)
+		SELECT id FROM matched
+	))`, args
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestTagRepository_Aliases(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTagRepository(db)
+	snippetRepo := NewSnippetRepository(db)
+	ctx := testutil.TestContext()
+
+	javascript, err := repo.Create(ctx, &models.TagInput{Name: "javascript", Color: "#f7df1e"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, &models.TagInput{Name: "go", Color: "#00ADD8"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := repo.AddAlias(ctx, javascript.ID, "js"); err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if _, err := repo.AddAlias(ctx, javascript.ID, "js"); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a duplicate alias, got %v", err)
+	}
+	if _, err := repo.AddAlias(ctx, javascript.ID, "go"); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a tag name, got %v", err)
+	}
+	if _, err := repo.AddAlias(ctx, 999, "ecmascript"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing tag, got %v", err)
+	}
+
+	if tag, err := repo.ResolveAlias(ctx, "js"); err != nil || tag.ID != javascript.ID {
+		t.Errorf("expected js to resolve to javascript, got %+v (%v)", tag, err)
+	}
+
+	// Tagging with the alias uses the tag
+	snippet, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Fetch", Content: "fetch()", Language: "javascript"})
+	if err != nil {
+		t.Fatalf("Create snippet failed: %v", err)
+	}
+	if err := repo.SetSnippetTags(ctx, snippet.ID, []string{"js", "javascript"}); err != nil {
+		t.Fatalf("SetSnippetTags failed: %v", err)
+	}
+	tags, err := repo.GetSnippetTags(ctx, snippet.ID)
+	if err != nil || len(tags) != 1 || tags[0].ID != javascript.ID {
+		t.Errorf("expected only the javascript tag, got %+v (%v)", tags, err)
+	}
+	if _, err := repo.GetByName(ctx, "js"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected no tag created for the alias, got %v", err)
+	}
+
+	// Filtering by the alias finds it
+	list, err := snippetRepo.List(ctx, models.SnippetFilter{TagNames: []string{"js"}, Page: 1, Limit: 10})
+	if err != nil || list.Pagination.Total != 1 {
+		t.Errorf("expected one snippet tagged by alias, got %+v (%v)", list, err)
+	}
+
+	if err := repo.DeleteAlias(ctx, javascript.ID, "js"); err != nil {
+		t.Fatalf("DeleteAlias failed: %v", err)
+	}
+	if err := repo.DeleteAlias(ctx, javascript.ID, "js"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a removed alias, got %v", err)
+	}
+	if aliases, err := repo.ListAliases(ctx, javascript.ID); err != nil || len(aliases) != 0 {
+		t.Errorf("expected no aliases, got %+v (%v)", aliases, err)
+	}
+}
+
+func TestTagRepository_Implications(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTagRepository(db)
+	snippetRepo := NewSnippetRepository(db)
+	ctx := testutil.TestContext()
+
+	create := func(name string) *models.Tag {
+		tag, err := repo.Create(ctx, &models.TagInput{Name: name, Color: "#6366f1"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return tag
+	}
+	kubernetes, devops, infra := create("kubernetes"), create("devops"), create("infra")
+
+	// An existing snippet tagged before the implication is added
+	before, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Pods", Content: "kubectl get pods", Language: "bash"})
+	if err != nil {
+		t.Fatalf("Create snippet failed: %v", err)
+	}
+	if err := repo.SetSnippetTags(ctx, before.ID, []string{"kubernetes"}); err != nil {
+		t.Fatalf("SetSnippetTags failed: %v", err)
+	}
+
+	if err := repo.AddImplication(ctx, kubernetes.ID, devops.ID); err != nil {
+		t.Fatalf("AddImplication failed: %v", err)
+	}
+	if err := repo.AddImplication(ctx, devops.ID, infra.ID); err != nil {
+		t.Fatalf("AddImplication failed: %v", err)
+	}
+	if err := repo.AddImplication(ctx, kubernetes.ID, devops.ID); err != nil {
+		t.Errorf("expected adding an existing implication to succeed, got %v", err)
+	}
+	if err := repo.AddImplication(ctx, infra.ID, kubernetes.ID); !errors.Is(err, ErrImplicationCycle) {
+		t.Errorf("expected ErrImplicationCycle, got %v", err)
+	}
+	if err := repo.AddImplication(ctx, devops.ID, devops.ID); !errors.Is(err, ErrImplicationCycle) {
+		t.Errorf("expected ErrImplicationCycle for a tag implying itself, got %v", err)
+	}
+	if err := repo.AddImplication(ctx, devops.ID, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing tag, got %v", err)
+	}
+
+	implied, err := repo.ListImplied(ctx, kubernetes.ID)
+	if err != nil || len(implied) != 1 || implied[0].ID != devops.ID {
+		t.Errorf("expected kubernetes to imply devops, got %+v (%v)", implied, err)
+	}
+
+	// New tagging adds implied tags, transitively
+	after, err := snippetRepo.Create(ctx, &models.SnippetInput{Title: "Deploy", Content: "kubectl apply", Language: "bash"})
+	if err != nil {
+		t.Fatalf("Create snippet failed: %v", err)
+	}
+	if err := repo.SetSnippetTags(ctx, after.ID, []string{"kubernetes"}); err != nil {
+		t.Fatalf("SetSnippetTags failed: %v", err)
+	}
+	tags, err := repo.GetSnippetTags(ctx, after.ID)
+	if err != nil || len(tags) != 3 {
+		t.Errorf("expected devops, infra and kubernetes, got %+v (%v)", tags, err)
+	}
+
+	// Filtering by an implied tag finds both, including the older snippet
+	for _, filter := range []models.SnippetFilter{{TagID: infra.ID}, {TagNames: []string{"devops"}}} {
+		filter.Page, filter.Limit = 1, 10
+		list, err := snippetRepo.List(ctx, filter)
+		if err != nil || list.Pagination.Total != 2 {
+			t.Errorf("expected two snippets for %+v, got %+v (%v)", filter, list, err)
+		}
+	}
+
+	if err := repo.DeleteImplication(ctx, kubernetes.ID, devops.ID); err != nil {
+		t.Fatalf("DeleteImplication failed: %v", err)
+	}
+	if err := repo.DeleteImplication(ctx, kubernetes.ID, devops.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a removed implication, got %v", err)
+	}
+	list, err := snippetRepo.List(ctx, models.SnippetFilter{TagID: devops.ID, Page: 1, Limit: 10})
+	if err != nil || list.Pagination.Total != 1 {
+		t.Errorf("expected only the snippet tagged devops, got %+v (%v)", list, err)
+	}
+}
+
+func TestTagRepository_Delete_Taxonomy(t *testing.T) {
+	db := testutil.TestDB(t)
+	db.SetMaxOpenConns(1) // Keep the in-memory database on one connection
+	repo := NewTagRepository(db)
+	ctx := testutil.TestContext()
+	// Without foreign keys nothing cascades; the delete must not rely on it
+	if _, err := db.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("failed to disable foreign keys: %v", err)
+	}
+
+	javascript, _ := repo.Create(ctx, &models.TagInput{Name: "javascript"})
+	web, _ := repo.Create(ctx, &models.TagInput{Name: "web"})
+	react, _ := repo.Create(ctx, &models.TagInput{Name: "react"})
+	if _, err := repo.AddAlias(ctx, react.ID, "reactjs"); err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if err := repo.AddImplication(ctx, react.ID, javascript.ID); err != nil {
+		t.Fatalf("AddImplication failed: %v", err)
+	}
+	if err := repo.AddImplication(ctx, web.ID, react.ID); err != nil {
+		t.Fatalf("AddImplication failed: %v", err)
+	}
+
+	if err := repo.Delete(ctx, react.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	for _, table := range []string{"tag_aliases", "tag_implications"} {
+		var count int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil || count != 0 {
+			t.Errorf("expected %s of the tag deleted, got %d rows (%v)", table, count, err)
+		}
+	}
+
+	// A tag that reuses the ID starts without them
+	if _, err := db.ExecContext(ctx, `INSERT INTO tags (id, name) VALUES (?, 'vue')`, react.ID); err != nil {
+		t.Fatalf("failed to reuse tag ID: %v", err)
+	}
+	if _, err := repo.ResolveAlias(ctx, "reactjs"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the alias gone, got %v", err)
+	}
+	if implied, err := repo.ListImplied(ctx, web.ID); err != nil || len(implied) != 0 {
+		t.Errorf("expected no implications left, got %+v (%v)", implied, err)
+	}
+	if err := repo.Delete(ctx, react.ID+100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing tag, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/MohamedElashri/snipo/internal/models"
@@ -15,7 +16,8 @@ import (
 
 // TokenRepository handles API token database operations
 type TokenRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	isWriter func() bool // Reports whether last use may be recorded
 }
 
 // NewTokenRepository creates a new token repository
@@ -23,6 +25,13 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 	return &TokenRepository{db: db}
 }
 
+// WithWriterCheck skips recording when tokens were last used while
+// isWriter returns false, as on a read-only replica
+func (r *TokenRepository) WithWriterCheck(isWriter func() bool) *TokenRepository {
+	r.isWriter = isWriter
+	return r
+}
+
 // generateToken generates a secure random token
 func generateToken() (string, error) {
 	bytes := make([]byte, 32)
@@ -38,6 +47,39 @@ func hashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// tokenColumns are the columns scanned by scanToken
+const tokenColumns = `id, name, permissions, last_used_at, expires_at, created_at, allowed_ips, allowed_referrers, folder_limited, provisioned`
+
+// scanToken scans a row of tokenColumns
+func scanToken(row interface{ Scan(...any) error }, token *models.APIToken) error {
+	var allowedIPs, allowedReferrers string
+	if err := row.Scan(
+		&token.ID,
+		&token.Name,
+		&token.Permissions,
+		&token.LastUsedAt,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&allowedIPs,
+		&allowedReferrers,
+		&token.FolderLimited,
+		&token.Provisioned,
+	); err != nil {
+		return err
+	}
+	token.AllowedIPs = splitList(allowedIPs)
+	token.AllowedReferrers = splitList(allowedReferrers)
+	return nil
+}
+
+// splitList splits a comma-separated column, returning nil for ''
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 // Create creates a new API token
 func (r *TokenRepository) Create(ctx context.Context, input *models.APITokenInput) (*models.APIToken, error) {
 	// Generate token
@@ -49,11 +91,23 @@ func (r *TokenRepository) Create(ctx context.Context, input *models.APITokenInpu
 	tokenHash := hashToken(token)
 
 	// Validate permissions
-	if input.Permissions == "" {
-		input.Permissions = "read"
+	permissions, err := models.NormalizePermissions(input.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permissions: %w", err)
 	}
-	if input.Permissions != "read" && input.Permissions != "write" && input.Permissions != "admin" {
-		return nil, fmt.Errorf("invalid permissions: must be 'read', 'write', or 'admin'")
+	input.Permissions = permissions
+
+	allowedIPs, err := models.NormalizeAllowedIPs(input.AllowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed IPs: %w", err)
+	}
+	allowedReferrers, err := models.NormalizeAllowedReferrers(input.AllowedReferrers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed referrers: %w", err)
+	}
+	folders, err := models.NormalizeTokenFolders(input.Permissions, input.Folders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid folders: %w", err)
 	}
 
 	// Calculate expiration date from expires_in_days
@@ -64,49 +118,147 @@ func (r *TokenRepository) Create(ctx context.Context, input *models.APITokenInpu
 	}
 
 	query := `
-		INSERT INTO api_tokens (name, token_hash, permissions, expires_at)
-		VALUES (?, ?, ?, ?)
-		RETURNING id, name, permissions, last_used_at, expires_at, created_at
-	`
+		INSERT INTO api_tokens (name, token_hash, permissions, expires_at, allowed_ips, allowed_referrers, folder_limited)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING ` + tokenColumns
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
 
 	apiToken := &models.APIToken{}
-	err = r.db.QueryRowContext(ctx, query, input.Name, tokenHash, input.Permissions, expiresAt).Scan(
-		&apiToken.ID,
-		&apiToken.Name,
-		&apiToken.Permissions,
-		&apiToken.LastUsedAt,
-		&apiToken.ExpiresAt,
-		&apiToken.CreatedAt,
-	)
+	err = scanToken(tx.QueryRowContext(ctx, query, input.Name, tokenHash, input.Permissions, expiresAt,
+		strings.Join(allowedIPs, ","), strings.Join(allowedReferrers, ","), len(folders) > 0), apiToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token: %w", err)
 	}
 
+	for _, f := range folders {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM folders WHERE id = ?)`, f.FolderID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to check folder: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("folder %d: %w", f.FolderID, ErrNotFound)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO token_folders (token_id, folder_id, role) VALUES (?, ?, ?)`,
+			apiToken.ID, f.FolderID, f.Role,
+		); err != nil {
+			return nil, fmt.Errorf("failed to limit token to folder: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	apiToken.Folders = folders
+
 	// Include the plain token in the response (only time it's returned)
 	apiToken.Token = token
 
 	return apiToken, nil
 }
 
+// EnsureToken makes sure the token provisioned from configuration exists
+// under name with the given value and permissions, creating it or
+// replacing the hash of an earlier value. The token is marked as
+// provisioned rather than found by name, which any token can have, and
+// keeps no expiry, IP, referrer or folder restrictions set on it since, so
+// configuration alone decides what it can do. A token already holding the
+// value is adopted. Only the hash is stored. It reports whether the token
+// was created or changed.
+func (r *TokenRepository) EnsureToken(ctx context.Context, name, token, permissions string) (bool, error) {
+	permissions, err := models.NormalizePermissions(permissions)
+	if err != nil {
+		return false, fmt.Errorf("invalid permissions: %w", err)
+	}
+	tokenHash := hashToken(token)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	var unchanged bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, provisioned = 1 AND name = ? AND token_hash = ? AND permissions = ?
+			AND expires_at IS NULL AND allowed_ips = '' AND allowed_referrers = '' AND folder_limited = 0
+			AND NOT EXISTS (SELECT 1 FROM token_folders WHERE token_id = api_tokens.id)
+		FROM api_tokens WHERE provisioned = 1 OR token_hash = ?
+		ORDER BY provisioned DESC, id LIMIT 1`,
+		name, tokenHash, permissions, tokenHash,
+	).Scan(&id, &unchanged)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO api_tokens (name, token_hash, permissions, provisioned) VALUES (?, ?, ?, 1)`,
+			name, tokenHash, permissions,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to create token: %w", err)
+		}
+	case err != nil:
+		return false, fmt.Errorf("failed to get token: %w", err)
+	case unchanged:
+		return false, nil
+	default:
+		// Another token holding the value gives it up to the provisioned one
+		if _, err := tx.ExecContext(ctx, `DELETE FROM api_tokens WHERE token_hash = ? AND id != ?`, tokenHash, id); err != nil {
+			return false, fmt.Errorf("failed to delete token: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM token_folders WHERE token_id = ?`, id); err != nil {
+			return false, fmt.Errorf("failed to delete token folders: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE api_tokens SET name = ?, token_hash = ?, permissions = ?, provisioned = 1, expires_at = NULL,
+				allowed_ips = '', allowed_referrers = '', folder_limited = 0
+			WHERE id = ?`,
+			name, tokenHash, permissions, id,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to update token: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteProvisioned deletes the token provisioned from configuration, once
+// it is no longer configured. It reports whether there was one.
+func (r *TokenRepository) DeleteProvisioned(ctx context.Context) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_tokens WHERE provisioned = 1`)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete provisioned token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
 // GetByID retrieves a token by ID
 func (r *TokenRepository) GetByID(ctx context.Context, id int64) (*models.APIToken, error) {
-	query := `SELECT id, name, permissions, last_used_at, expires_at, created_at FROM api_tokens WHERE id = ?`
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE id = ?`
 
 	token := &models.APIToken{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&token.ID,
-		&token.Name,
-		&token.Permissions,
-		&token.LastUsedAt,
-		&token.ExpiresAt,
-		&token.CreatedAt,
-	)
+	err := scanToken(r.db.QueryRowContext(ctx, query, id), token)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get token: %w", err)
 	}
+	if token.Folders, err = r.folders(ctx, token.ID); err != nil {
+		return nil, err
+	}
 
 	return token, nil
 }
@@ -115,30 +267,26 @@ func (r *TokenRepository) GetByID(ctx context.Context, id int64) (*models.APITok
 func (r *TokenRepository) GetByToken(ctx context.Context, token string) (*models.APIToken, error) {
 	tokenHash := hashToken(token)
 
-	query := `SELECT id, name, permissions, last_used_at, expires_at, created_at FROM api_tokens WHERE token_hash = ?`
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE token_hash = ?`
 
 	apiToken := &models.APIToken{}
-	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
-		&apiToken.ID,
-		&apiToken.Name,
-		&apiToken.Permissions,
-		&apiToken.LastUsedAt,
-		&apiToken.ExpiresAt,
-		&apiToken.CreatedAt,
-	)
+	err := scanToken(r.db.QueryRowContext(ctx, query, tokenHash), apiToken)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get token: %w", err)
 	}
+	if apiToken.Folders, err = r.folders(ctx, apiToken.ID); err != nil {
+		return nil, err
+	}
 
 	return apiToken, nil
 }
 
 // List retrieves all API tokens
 func (r *TokenRepository) List(ctx context.Context) ([]models.APIToken, error) {
-	query := `SELECT id, name, permissions, last_used_at, expires_at, created_at FROM api_tokens ORDER BY created_at DESC`
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -153,14 +301,7 @@ func (r *TokenRepository) List(ctx context.Context) ([]models.APIToken, error) {
 	var tokens []models.APIToken
 	for rows.Next() {
 		var token models.APIToken
-		if err := rows.Scan(
-			&token.ID,
-			&token.Name,
-			&token.Permissions,
-			&token.LastUsedAt,
-			&token.ExpiresAt,
-			&token.CreatedAt,
-		); err != nil {
+		if err := scanToken(rows, &token); err != nil {
 			return nil, fmt.Errorf("failed to scan token: %w", err)
 		}
 		tokens = append(tokens, token)
@@ -170,11 +311,79 @@ func (r *TokenRepository) List(ctx context.Context) ([]models.APIToken, error) {
 		return nil, fmt.Errorf("error iterating tokens: %w", err)
 	}
 
+	for i := range tokens {
+		if tokens[i].Folders, err = r.folders(ctx, tokens[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
 	return tokens, nil
 }
 
+// folders returns the folders a token is limited to
+func (r *TokenRepository) folders(ctx context.Context, tokenID int64) ([]models.TokenFolder, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT folder_id, role FROM token_folders WHERE token_id = ? ORDER BY folder_id`, tokenID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list token folders: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	var folders []models.TokenFolder
+	for rows.Next() {
+		var f models.TokenFolder
+		if err := rows.Scan(&f.FolderID, &f.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan token folder: %w", err)
+		}
+		folders = append(folders, f)
+	}
+	return folders, rows.Err()
+}
+
+// folderAccess resolves a token's folders to the role it has on each
+// folder it reaches, subfolders included. Where grants overlap, editor wins.
+func (r *TokenRepository) folderAccess(ctx context.Context, tokenID int64) (models.FolderAccess, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH RECURSIVE reach(id, role) AS (
+			SELECT folder_id, role FROM token_folders WHERE token_id = ?
+			UNION
+			SELECT f.id, reach.role FROM folders f JOIN reach ON f.parent_id = reach.id
+		)
+		SELECT id, role FROM reach`, tokenID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve token folders: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	access := make(models.FolderAccess)
+	for rows.Next() {
+		var id int64
+		var role string
+		if err := rows.Scan(&id, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan token folder: %w", err)
+		}
+		if access[id] != models.RoleEditor {
+			access[id] = role
+		}
+	}
+	return access, rows.Err()
+}
+
 // Delete deletes a token
 func (r *TokenRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM token_folders WHERE token_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete token folders: %w", err)
+	}
 	result, err := r.db.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete token: %w", err)
@@ -204,8 +413,10 @@ func (r *TokenRepository) UpdateLastUsed(ctx context.Context, id int64) error {
 	return nil
 }
 
-// ValidateToken validates a token and returns it if valid
-func (r *TokenRepository) ValidateToken(ctx context.Context, token string) (*models.APIToken, error) {
+// ValidateToken validates a token used by client and returns it if valid.
+// It returns ErrTokenNotAllowed when the token is restricted to other
+// addresses or referrers.
+func (r *TokenRepository) ValidateToken(ctx context.Context, token string, client models.TokenClient) (*models.APIToken, error) {
 	apiToken, err := r.GetByToken(ctx, token)
 	if err != nil {
 		return nil, err
@@ -216,8 +427,21 @@ func (r *TokenRepository) ValidateToken(ctx context.Context, token string) (*mod
 		return nil, fmt.Errorf("token expired")
 	}
 
+	if !apiToken.AllowsClient(client) {
+		return nil, ErrTokenNotAllowed
+	}
+
+	// A limited token whose folders were all deleted reaches nothing
+	if apiToken.FolderLimited {
+		if apiToken.FolderAccess, err = r.folderAccess(ctx, apiToken.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update last used timestamp
-	_ = r.UpdateLastUsed(ctx, apiToken.ID)
+	if r.isWriter == nil || r.isWriter() {
+		_ = r.UpdateLastUsed(ctx, apiToken.ID)
+	}
 
 	return apiToken, nil
 }
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestTokenRepository_Restrictions(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTokenRepository(db)
+	ctx := testutil.TestContext()
+
+	token, err := repo.Create(ctx, &models.APITokenInput{
+		Name:             "dashboard",
+		AllowedIPs:       []string{"203.0.113.0/24", " 2001:db8::1 ", "203.0.113.9/24"},
+		AllowedReferrers: []string{"https://*.Example.com", "dash.test:8443"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, token.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if len(got.AllowedIPs) != 2 || got.AllowedIPs[0] != "203.0.113.0/24" || got.AllowedIPs[1] != "2001:db8::1/128" {
+		t.Errorf("unexpected allowed IPs: %v", got.AllowedIPs)
+	}
+	if len(got.AllowedReferrers) != 2 || got.AllowedReferrers[0] != "https://*.example.com" {
+		t.Errorf("unexpected allowed referrers: %v", got.AllowedReferrers)
+	}
+
+	tests := []struct {
+		name   string
+		client models.TokenClient
+		ok     bool
+	}{
+		{"allowed address and subdomain", models.TokenClient{IP: "203.0.113.50", Referrer: "https://grafana.example.com/d/1"}, true},
+		{"allowed IPv6 address and port", models.TokenClient{IP: "2001:db8::1", Referrer: "http://dash.test:8443"}, true},
+		{"other address", models.TokenClient{IP: "198.51.100.1", Referrer: "https://grafana.example.com"}, false},
+		{"other scheme", models.TokenClient{IP: "203.0.113.50", Referrer: "http://grafana.example.com"}, false},
+		{"other port", models.TokenClient{IP: "203.0.113.50", Referrer: "https://dash.test"}, false},
+		{"bare domain against a wildcard", models.TokenClient{IP: "203.0.113.50", Referrer: "https://example.com"}, false},
+		{"no referrer", models.TokenClient{IP: "203.0.113.50"}, false},
+	}
+	for _, tt := range tests {
+		_, err := repo.ValidateToken(ctx, token.Token, tt.client)
+		if tt.ok && err != nil {
+			t.Errorf("%s: expected the token accepted, got %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrTokenNotAllowed) {
+			t.Errorf("%s: expected ErrTokenNotAllowed, got %v", tt.name, err)
+		}
+	}
+
+	// Tokens without restrictions work from anywhere
+	open, err := repo.Create(ctx, &models.APITokenInput{Name: "cli"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repo.ValidateToken(ctx, open.Token, models.TokenClient{IP: "198.51.100.1"}); err != nil {
+		t.Errorf("expected an unrestricted token accepted, got %v", err)
+	}
+
+	if _, err := repo.Create(ctx, &models.APITokenInput{Name: "bad", AllowedIPs: []string{"10.0.0.300"}}); err == nil {
+		t.Error("expected an invalid address rejected")
+	}
+	if _, err := repo.Create(ctx, &models.APITokenInput{Name: "bad", AllowedReferrers: []string{"https://example.com/path"}}); err == nil {
+		t.Error("expected a referrer with a path rejected")
+	}
+}
+
+func TestTokenRepository_EnsureToken(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTokenRepository(db)
+	ctx := testutil.TestContext()
+
+	// A token that only shares the name is left alone
+	lookalike, err := repo.Create(ctx, &models.APITokenInput{Name: "provisioned", Permissions: models.PermissionRead})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	first := "3f7a9c1e5b2d8f4a6c0e1b3d5f7a9c2e4b6d8f0a1c3e5b7d9f2a4c6e8b0d1f3a"
+	if changed, err := repo.EnsureToken(ctx, "provisioned", first, models.PermissionAdmin); err != nil || !changed {
+		t.Fatalf("expected the token created, got %v (%v)", changed, err)
+	}
+	token, err := repo.ValidateToken(ctx, first, models.TokenClient{})
+	if err != nil || token.Name != "provisioned" || token.Permissions != models.PermissionAdmin || !token.Provisioned || token.ID == lookalike.ID {
+		t.Fatalf("expected a new provisioned admin token accepted, got %+v (%v)", token, err)
+	}
+	if other, err := repo.ValidateToken(ctx, lookalike.Token, models.TokenClient{}); err != nil || other.Permissions != models.PermissionRead || other.Provisioned {
+		t.Errorf("expected the token of the same name unchanged, got %+v (%v)", other, err)
+	}
+
+	// Restarting with the same value changes nothing
+	if changed, err := repo.EnsureToken(ctx, "provisioned", first, models.PermissionAdmin); err != nil || changed {
+		t.Errorf("expected no change, got %v (%v)", changed, err)
+	}
+
+	// A new value replaces the old one on the same token
+	second := "a1c3e5b7d9f2a4c6e8b0d1f3a3f7a9c1e5b2d8f4a6c0e1b3d5f7a9c2e4b6d8f0"
+	if changed, err := repo.EnsureToken(ctx, "provisioned", second, models.PermissionAdmin); err != nil || !changed {
+		t.Fatalf("expected the token rotated, got %v (%v)", changed, err)
+	}
+	if _, err := repo.ValidateToken(ctx, first, models.TokenClient{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the old value rejected, got %v", err)
+	}
+	rotated, err := repo.ValidateToken(ctx, second, models.TokenClient{})
+	if err != nil || rotated.ID != token.ID {
+		t.Errorf("expected the new value on the same token, got %+v (%v)", rotated, err)
+	}
+
+	var stored string
+	if err := db.QueryRowContext(ctx, `SELECT token_hash FROM api_tokens WHERE id = ?`, token.ID).Scan(&stored); err != nil || stored == second {
+		t.Errorf("expected only the hash stored, got %q (%v)", stored, err)
+	}
+
+	// Restrictions set on the token since are reset on the next start
+	folder, _ := NewFolderRepository(db).Create(ctx, &models.FolderInput{Name: "Docs"})
+	for _, q := range []string{
+		`UPDATE api_tokens SET allowed_ips = '192.0.2.0/24', allowed_referrers = 'dash.example.com', folder_limited = 1, expires_at = '2000-01-01' WHERE id = ?`,
+		`INSERT INTO token_folders (token_id, folder_id, role) VALUES (?, ` + strconv.FormatInt(folder.ID, 10) + `, 'viewer')`,
+	} {
+		if _, err := db.ExecContext(ctx, q, token.ID); err != nil {
+			t.Fatalf("failed to restrict token: %v", err)
+		}
+	}
+	if changed, err := repo.EnsureToken(ctx, "provisioned", second, models.PermissionAdmin); err != nil || !changed {
+		t.Fatalf("expected the restrictions reset, got %v (%v)", changed, err)
+	}
+	reset, err := repo.ValidateToken(ctx, second, models.TokenClient{IP: "198.51.100.1"})
+	if err != nil || reset.ExpiresAt != nil || len(reset.AllowedIPs) != 0 || len(reset.AllowedReferrers) != 0 || reset.FolderLimited || len(reset.Folders) != 0 {
+		t.Errorf("expected an unrestricted token, got %+v (%v)", reset, err)
+	}
+
+	// Unsetting the variable deletes only the provisioned token
+	if deleted, err := repo.DeleteProvisioned(ctx); err != nil || !deleted {
+		t.Fatalf("expected the provisioned token deleted, got %v (%v)", deleted, err)
+	}
+	if _, err := repo.ValidateToken(ctx, second, models.TokenClient{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted token rejected, got %v", err)
+	}
+	if _, err := repo.ValidateToken(ctx, lookalike.Token, models.TokenClient{}); err != nil {
+		t.Errorf("expected the token of the same name kept, got %v", err)
+	}
+
+	// Tokens provisioned before the marker are adopted by their value
+	result, err := db.ExecContext(ctx, `INSERT INTO api_tokens (name, token_hash, permissions) VALUES ('provisioned', ?, 'admin')`, hashToken(first))
+	if err != nil {
+		t.Fatalf("failed to insert token: %v", err)
+	}
+	legacyID, _ := result.LastInsertId()
+	if changed, err := repo.EnsureToken(ctx, "provisioned", first, models.PermissionAdmin); err != nil || !changed {
+		t.Fatalf("expected the token adopted, got %v (%v)", changed, err)
+	}
+	if adopted, err := repo.ValidateToken(ctx, first, models.TokenClient{}); err != nil || adopted.ID != legacyID || !adopted.Provisioned {
+		t.Errorf("expected the earlier token marked as provisioned, got %+v (%v)", adopted, err)
+	}
+	if deleted, err := repo.DeleteProvisioned(ctx); err != nil || !deleted {
+		t.Fatalf("expected the provisioned token deleted, got %v (%v)", deleted, err)
+	}
+	if _, err := repo.ValidateToken(ctx, first, models.TokenClient{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted token rejected, got %v", err)
+	}
+	if _, err := repo.ValidateToken(ctx, lookalike.Token, models.TokenClient{}); err != nil {
+		t.Errorf("expected the token of the same name kept, got %v", err)
+	}
+	if deleted, err := repo.DeleteProvisioned(ctx); err != nil || deleted {
+		t.Errorf("expected nothing left to delete, got %v (%v)", deleted, err)
+	}
+}
+
+func TestTokenRepository_Folders(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTokenRepository(db)
+	folders := NewFolderRepository(db)
+	ctx := testutil.TestContext()
+
+	docs, _ := folders.Create(ctx, &models.FolderInput{Name: "Docs"})
+	drafts, _ := folders.Create(ctx, &models.FolderInput{Name: "Drafts", ParentID: &docs.ID})
+	team, _ := folders.Create(ctx, &models.FolderInput{Name: "Team"})
+	other, _ := folders.Create(ctx, &models.FolderInput{Name: "Other"})
+
+	created, err := repo.Create(ctx, &models.APITokenInput{
+		Name:        "scoped",
+		Permissions: models.PermissionWrite,
+		Folders: []models.TokenFolder{
+			{FolderID: docs.ID, Role: models.RoleViewer},
+			{FolderID: team.ID, Role: models.RoleEditor},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	if len(created.Folders) != 2 {
+		t.Fatalf("expected 2 folders on the token, got %+v", created.Folders)
+	}
+
+	token, err := repo.ValidateToken(ctx, created.Token, models.TokenClient{})
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	access := token.FolderAccess
+	if !access.Allows([]int64{drafts.ID}, models.RoleViewer) || access.Allows([]int64{drafts.ID}, models.RoleEditor) {
+		t.Errorf("expected the viewer role inherited by subfolders, got %v", access)
+	}
+	if !access.Allows([]int64{team.ID}, models.RoleViewer) || !access.Allows([]int64{other.ID, team.ID}, models.RoleEditor) {
+		t.Errorf("expected editors to view and edit, got %v", access)
+	}
+	if access.Allows([]int64{other.ID}, models.RoleViewer) {
+		t.Errorf("expected other folders hidden, got %v", access)
+	}
+
+	unrestricted, _ := repo.Create(ctx, &models.APITokenInput{Name: "all", Permissions: models.PermissionRead})
+	if token, _ := repo.ValidateToken(ctx, unrestricted.Token, models.TokenClient{}); token.FolderAccess != nil {
+		t.Errorf("expected no folder access map for an unrestricted token, got %v", token.FolderAccess)
+	}
+
+	missing := &models.APITokenInput{Name: "missing", Permissions: models.PermissionRead, Folders: []models.TokenFolder{{FolderID: 999, Role: models.RoleViewer}}}
+	if _, err := repo.Create(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing folder, got %v", err)
+	}
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Errorf("failed to delete token: %v", err)
+	}
+}
+
+func TestTokenRepository_Folders_DeletedFolder(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewTokenRepository(db)
+	folders := NewFolderRepository(db)
+	ctx := testutil.TestContext()
+
+	docs, _ := folders.Create(ctx, &models.FolderInput{Name: "Docs"})
+	drafts, _ := folders.Create(ctx, &models.FolderInput{Name: "Drafts", ParentID: &docs.ID})
+	created, err := repo.Create(ctx, &models.APITokenInput{
+		Name:        "scoped",
+		Permissions: models.PermissionRead,
+		Folders: []models.TokenFolder{
+			{FolderID: docs.ID, Role: models.RoleViewer},
+			{FolderID: drafts.ID, Role: models.RoleViewer},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	if err := folders.Delete(ctx, docs.ID); err != nil {
+		t.Fatalf("failed to delete folder: %v", err)
+	}
+	var grants int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM token_folders`).Scan(&grants); err != nil {
+		t.Fatalf("failed to count token folders: %v", err)
+	}
+	if grants != 0 {
+		t.Errorf("expected the grants on the folder and its subfolders deleted, %d left", grants)
+	}
+
+	// A restore resets the sequence, so the next folder reuses the ID
+	if _, err := db.ExecContext(ctx, `DELETE FROM sqlite_sequence WHERE name = 'folders'`); err != nil {
+		t.Fatalf("failed to reset sequence: %v", err)
+	}
+	reused, _ := folders.Create(ctx, &models.FolderInput{Name: "Private"})
+	if reused.ID != docs.ID {
+		t.Fatalf("expected folder ID %d reused, got %d", docs.ID, reused.ID)
+	}
+
+	token, err := repo.ValidateToken(ctx, created.Token, models.TokenClient{})
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if token.FolderAccess == nil || token.FolderAccess.Allows([]int64{reused.ID}, models.RoleViewer) {
+		t.Errorf("expected the token to reach no folder, got %v", token.FolderAccess)
+	}
+}
+
+func TestTokenRepository_WithWriterCheck(t *testing.T) {
+	db := testutil.TestDB(t)
+	writer := false
+	repo := NewTokenRepository(db).WithWriterCheck(func() bool { return writer })
+	ctx := testutil.TestContext()
+
+	created, err := repo.Create(ctx, &models.APITokenInput{Name: "replica", Permissions: models.PermissionRead})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repo.ValidateToken(ctx, created.Token, models.TokenClient{}); err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if token, _ := repo.GetByID(ctx, created.ID); token.LastUsedAt != nil {
+		t.Errorf("expected no last use recorded on a replica, got %v", token.LastUsedAt)
+	}
+
+	writer = true
+	if _, err := repo.ValidateToken(ctx, created.Token, models.TokenClient{}); err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if token, _ := repo.GetByID(ctx, created.ID); token.LastUsedAt == nil {
+		t.Error("expected the last use recorded on the primary")
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// VaultNoteRepository stores the Obsidian vault sync state
+type VaultNoteRepository struct {
+	db *sql.DB
+}
+
+// NewVaultNoteRepository creates a new vault note repository
+func NewVaultNoteRepository(db *sql.DB) *VaultNoteRepository {
+	return &VaultNoteRepository{db: db}
+}
+
+// List returns every linked note, ordered by path
+func (r *VaultNoteRepository) List(ctx context.Context) ([]models.VaultNote, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT path, snippet_id, note_mtime, note_checksum, snippet_checksum, synced_at
+		FROM vault_notes
+		ORDER BY path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vault notes: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var notes []models.VaultNote
+	for rows.Next() {
+		var note models.VaultNote
+		if err := rows.Scan(
+			&note.Path,
+			&note.SnippetID,
+			&note.NoteModTime,
+			&note.NoteChecksum,
+			&note.SnippetChecksum,
+			&note.SyncedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan vault note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+// Save stores a note's state. A row for the same path or the same snippet is
+// replaced, so moving a note keeps a single link.
+func (r *VaultNoteRepository) Save(ctx context.Context, note *models.VaultNote) error {
+	var mtime any
+	if note.NoteModTime != nil {
+		mtime = note.NoteModTime.UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO vault_notes (path, snippet_id, note_mtime, note_checksum, snippet_checksum, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		note.Path, note.SnippetID, mtime, note.NoteChecksum, note.SnippetChecksum, note.SyncedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save vault note: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the link of a note
+func (r *VaultNoteRepository) Delete(ctx context.Context, path string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM vault_notes WHERE path = ?", path); err != nil {
+		return fmt.Errorf("failed to delete vault note: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of linked notes
+func (r *VaultNoteRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM vault_notes").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count vault notes: %w", err)
+	}
+	return count, nil
+}
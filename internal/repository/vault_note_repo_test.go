@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestVaultNoteRepository_Save(t *testing.T) {
+	db := testutil.TestDB(t)
+	repo := NewVaultNoteRepository(db)
+	ctx := testutil.TestContext()
+	mtime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	notes := []models.VaultNote{
+		{Path: "go/http.md", SnippetID: "a", NoteModTime: &mtime, NoteChecksum: "n1", SnippetChecksum: "s1", SyncedAt: mtime},
+		{Path: "sql.md", SnippetID: "b", NoteChecksum: "n2", SnippetChecksum: "s2", SyncedAt: mtime},
+	}
+	for i := range notes {
+		if err := repo.Save(ctx, &notes[i]); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	got, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Path != "go/http.md" || got[1].NoteModTime != nil {
+		t.Fatalf("unexpected notes: %+v", got)
+	}
+	if got[0].NoteModTime == nil || !got[0].NoteModTime.Equal(mtime) {
+		t.Errorf("expected the modification time to round-trip, got %v", got[0].NoteModTime)
+	}
+
+	// Moving a note replaces the old link of its snippet
+	moved := models.VaultNote{Path: "go/server.md", SnippetID: "a", NoteChecksum: "n1", SnippetChecksum: "s1", SyncedAt: mtime}
+	if err := repo.Save(ctx, &moved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, _ = repo.List(ctx)
+	if len(got) != 2 || got[0].Path != "go/server.md" {
+		t.Errorf("expected the move to replace the old path, got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, "sql.md"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if count, err := repo.Count(ctx); err != nil || count != 1 {
+		t.Errorf("expected 1 note after delete, got %d (%v)", count, err)
+	}
+}
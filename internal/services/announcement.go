@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// Announcement returns the snippet set as the announcement in settings, or
+// nil when none is set or it is not published. Only published snippets are
+// shown, so a private snippet picked by mistake does not leak.
+func (s *SnippetService) Announcement(ctx context.Context) (*models.Announcement, error) {
+	if s.settingsRepo == nil {
+		return nil, nil
+	}
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.AnnouncementSnippetID == "" {
+		return nil, nil
+	}
+
+	snippet, err := s.repo.GetByID(ctx, settings.AnnouncementSnippetID)
+	if err != nil {
+		return nil, err
+	}
+	if snippet == nil || !s.isPublished(ctx, snippet) {
+		return nil, nil
+	}
+
+	return &models.Announcement{
+		SnippetID: snippet.ID,
+		Title:     snippet.Title,
+		Content:   snippet.Content,
+		Language:  snippet.Language,
+		HTML:      renderAnnouncement(snippet.Content, snippet.Language),
+		UpdatedAt: snippet.UpdatedAt,
+	}, nil
+}
+
+// renderAnnouncement renders text as paragraphs, one per blank-line
+// separated block, and code as a preformatted block for the highlighter.
+// Everything is escaped, so the result is safe to insert into a page.
+func renderAnnouncement(content, language string) string {
+	var b strings.Builder
+	if language != "plaintext" && language != "markdown" {
+		b.WriteString(`<pre><code class="language-`)
+		b.WriteString(html.EscapeString(language))
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(content))
+		b.WriteString("</code></pre>")
+		return b.String()
+	}
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, block := range strings.Split(content, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(block), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
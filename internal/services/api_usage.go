@@ -0,0 +1,229 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// apiUsageFlushInterval is how often counted requests are written
+const apiUsageFlushInterval = time.Minute
+
+// apiUsageKey identifies a day's requests by one token to one route
+type apiUsageKey struct {
+	day     string
+	tokenID int64
+	method  string
+	route   string
+}
+
+// APIUsageService counts API requests per day, token and route for the
+// admin usage report. Requests are counted in memory and written once a
+// minute, so counting adds no database write to each request.
+type APIUsageService struct {
+	repo      *repository.APIUsageRepository
+	lifecycle *lifecycle.Manager
+	logger    *slog.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending map[apiUsageKey]*models.APIUsageEntry
+}
+
+// NewAPIUsageService creates a new API usage service
+func NewAPIUsageService(repo *repository.APIUsageRepository, logger *slog.Logger) *APIUsageService {
+	return &APIUsageService{
+		repo:    repo,
+		logger:  logger,
+		now:     time.Now,
+		pending: make(map[apiUsageKey]*models.APIUsageEntry),
+	}
+}
+
+// WithLifecycle sets the lifecycle manager that runs the writer; counts
+// are only written while the instance is a writer
+func (s *APIUsageService) WithLifecycle(lc *lifecycle.Manager) *APIUsageService {
+	s.lifecycle = lc
+	return s
+}
+
+// Record counts an answered request
+func (s *APIUsageService) Record(hit models.APIUsageHit) {
+	key := apiUsageKey{
+		day:     s.now().UTC().Format(analyticsDay),
+		tokenID: hit.TokenID,
+		method:  hit.Method,
+		route:   hit.Route,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.pending[key]
+	if !ok {
+		entry = &models.APIUsageEntry{Day: key.day, TokenID: key.tokenID, Method: key.method, Route: key.route}
+		s.pending[key] = entry
+	}
+	entry.TokenName = hit.TokenName
+	entry.Requests++
+	switch {
+	case hit.Status == http.StatusTooManyRequests:
+		entry.RateLimited++
+	case hit.Status >= 400:
+		entry.Errors++
+	}
+}
+
+// Run writes the counts every minute until ctx ends, then writes what is
+// left so shutdown loses nothing. It is run by the lifecycle manager.
+func (s *APIUsageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(apiUsageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.Flush(context.WithoutCancel(ctx))
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.WarnContext(ctx, "failed to write API usage", "error", err)
+			}
+		}
+	}
+}
+
+// Flush writes the counted requests. Counts that fail to write are kept
+// for the next flush.
+func (s *APIUsageService) Flush(ctx context.Context) error {
+	if s.lifecycle != nil && !s.lifecycle.IsWriter() {
+		return nil
+	}
+
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[apiUsageKey]*models.APIUsageEntry)
+	s.mu.Unlock()
+
+	entries := make([]models.APIUsageEntry, 0, len(pending))
+	for _, e := range pending {
+		entries = append(entries, *e)
+	}
+	if err := s.repo.Add(ctx, entries); err != nil {
+		s.mu.Lock()
+		for key, e := range pending {
+			if current, ok := s.pending[key]; ok {
+				current.Requests += e.Requests
+				current.Errors += e.Errors
+				current.RateLimited += e.RateLimited
+			} else {
+				s.pending[key] = e
+			}
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Prune deletes counts older than the longest report period. It is run
+// periodically.
+func (s *APIUsageService) Prune(ctx context.Context) error {
+	before := s.now().UTC().AddDate(0, 0, -models.MaxAPIUsageDays).Format(analyticsDay)
+	pruned, err := s.repo.Prune(ctx, before)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.logger.InfoContext(ctx, "pruned API usage", "count", pruned)
+	}
+	return nil
+}
+
+// Entries returns the daily counts in a period, for CSV export. Counts
+// not yet written are written first.
+func (s *APIUsageService) Entries(ctx context.Context, filter models.APIUsageFilter) ([]models.APIUsageEntry, error) {
+	if err := s.Flush(ctx); err != nil {
+		s.logger.WarnContext(ctx, "failed to write API usage", "error", err)
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// Report summarizes the requests in a period by day, token and route
+func (s *APIUsageService) Report(ctx context.Context, filter models.APIUsageFilter) (*models.APIUsageReport, error) {
+	entries, err := s.Entries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.APIUsageReport{
+		From:   filter.From,
+		To:     filter.To,
+		Days:   []models.APIUsageDay{},
+		Tokens: []models.APIUsageToken{},
+		Routes: []models.APIUsageRoute{},
+	}
+	days := make(map[string]*models.APIUsageCounts)
+	tokens := make(map[int64]*models.APIUsageToken)
+	routes := make(map[apiUsageKey]*models.APIUsageRoute)
+	for _, e := range entries {
+		report.Totals.Add(e.APIUsageCounts)
+
+		if days[e.Day] == nil {
+			days[e.Day] = &models.APIUsageCounts{}
+		}
+		days[e.Day].Add(e.APIUsageCounts)
+
+		token := tokens[e.TokenID]
+		if token == nil {
+			token = &models.APIUsageToken{TokenID: e.TokenID}
+			tokens[e.TokenID] = token
+		}
+		token.TokenName = e.TokenName // Entries are by day, so this is the latest name
+		token.Add(e.APIUsageCounts)
+
+		key := apiUsageKey{tokenID: e.TokenID, method: e.Method, route: e.Route}
+		route := routes[key]
+		if route == nil {
+			route = &models.APIUsageRoute{TokenID: e.TokenID, Method: e.Method, Route: e.Route}
+			routes[key] = route
+		}
+		route.TokenName = e.TokenName
+		route.Add(e.APIUsageCounts)
+	}
+
+	from, _ := time.Parse(analyticsDay, filter.From)
+	to, _ := time.Parse(analyticsDay, filter.To)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(analyticsDay)
+		entry := models.APIUsageDay{Date: date}
+		if counts := days[date]; counts != nil {
+			entry.APIUsageCounts = *counts
+		}
+		report.Days = append(report.Days, entry)
+	}
+	for _, token := range tokens {
+		report.Tokens = append(report.Tokens, *token)
+	}
+	slices.SortFunc(report.Tokens, func(a, b models.APIUsageToken) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.TokenID, b.TokenID))
+	})
+	for _, route := range routes {
+		report.Routes = append(report.Routes, *route)
+	}
+	slices.SortFunc(report.Routes, func(a, b models.APIUsageRoute) int {
+		return cmp.Or(
+			cmp.Compare(b.Requests, a.Requests),
+			cmp.Compare(a.TokenID, b.TokenID),
+			cmp.Compare(a.Route, b.Route),
+			cmp.Compare(a.Method, b.Method),
+		)
+	})
+	return report, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// AutoArchive applies the folders' auto-archive rules, archiving snippets
+// that have not been updated within their folder's period. With dryRun it
+// only reports what would be archived.
+func (s *SnippetService) AutoArchive(ctx context.Context, dryRun bool) (*models.AutoArchiveReport, error) {
+	candidates, err := s.repo.ListAutoArchive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AutoArchiveReport{DryRun: dryRun, Snippets: candidates}
+	if dryRun {
+		return report, nil
+	}
+
+	for _, c := range candidates {
+		archived, err := s.repo.Archive(ctx, c.SnippetID)
+		if err != nil {
+			return report, err
+		}
+		if archived {
+			report.Archived++
+			if snippet, err := s.repo.GetByID(ctx, c.SnippetID); err == nil && snippet != nil {
+				s.saveMetadataHistory(ctx, snippet, models.HistoryArchive, "")
+			}
+		}
+	}
+	if report.Archived > 0 {
+		s.logger.InfoContext(ctx, "auto-archived stale snippets", "count", report.Archived)
+	}
+	return report, nil
+}
+
+// RunAutoArchive applies the auto-archive rules. It is run periodically by
+// the scheduler.
+func (s *SnippetService) RunAutoArchive(ctx context.Context) error {
+	_, err := s.AutoArchive(auth.WithActor(ctx, auth.Actor{Name: "auto-archive"}), false)
+	return err
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// AutocompleteService suggests tags, folders and languages as they are
+// typed, so editors need not download the full lists
+type AutocompleteService struct {
+	tagRepo     *repository.TagRepository
+	folderRepo  *repository.FolderRepository
+	snippetRepo *repository.SnippetRepository
+}
+
+// NewAutocompleteService creates a new autocomplete service
+func NewAutocompleteService(tagRepo *repository.TagRepository, folderRepo *repository.FolderRepository, snippetRepo *repository.SnippetRepository) *AutocompleteService {
+	return &AutocompleteService{tagRepo: tagRepo, folderRepo: folderRepo, snippetRepo: snippetRepo}
+}
+
+// Suggest returns up to limit values of kind starting with prefix, most
+// used first
+func (s *AutocompleteService) Suggest(ctx context.Context, kind, prefix string, limit int) ([]models.Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	switch kind {
+	case models.AutocompleteTag:
+		return s.tagRepo.Suggest(ctx, prefix, limit)
+	case models.AutocompleteFolder:
+		return s.folderRepo.Suggest(ctx, prefix, limit)
+	case models.AutocompleteLanguage:
+		return s.suggestLanguages(ctx, prefix, limit)
+	}
+	return nil, fmt.Errorf("unknown autocomplete kind %q", kind)
+}
+
+// suggestLanguages matches the language catalog, plus any other language
+// snippets use, ranked by how many snippets and files use each
+func (s *AutocompleteService) suggestLanguages(ctx context.Context, prefix string, limit int) ([]models.Suggestion, error) {
+	counts, err := s.snippetRepo.LanguageCounts(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToLower(prefix)
+	for _, lang := range languages.List() {
+		if _, ok := counts[lang.ID]; !ok && strings.HasPrefix(lang.ID, prefix) {
+			counts[lang.ID] = 0
+		}
+	}
+
+	suggestions := make([]models.Suggestion, 0, len(counts))
+	for lang, count := range counts {
+		suggestions = append(suggestions, models.Suggestion{Value: lang, Count: count})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Value < suggestions[j].Value
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
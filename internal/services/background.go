@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+)
+
+// runBackground runs fn outside the request. When lc is set the work is tracked
+// so shutdown can drain it; otherwise it runs in a plain goroutine.
+func runBackground(lc *lifecycle.Manager, logger *slog.Logger, name string, fn func(ctx context.Context) error) {
+	if lc != nil {
+		if err := lc.Go(name, fn); err != nil {
+			logger.Warn("skipping background work", "worker", name, "error", err)
+		}
+		return
+	}
+
+	go func() {
+		if err := fn(context.Background()); err != nil {
+			logger.Warn("background work failed", "worker", name, "error", err)
+		}
+	}()
+}
+
+// runBackgroundWrite runs fn like runBackground, for writes made while
+// serving reads, such as view counts. They are dropped while lc reports that
+// this instance may not write, as on a read-only replica; the primary counts
+// only what it serves.
+func runBackgroundWrite(lc *lifecycle.Manager, logger *slog.Logger, name string, fn func(ctx context.Context) error) {
+	if lc != nil && !lc.IsWriter() {
+		return
+	}
+	runBackground(lc, logger, name, fn)
+}
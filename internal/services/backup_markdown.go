@@ -0,0 +1,327 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// markdownFrontMatter is the YAML header of an exported note
+type markdownFrontMatter struct {
+	ID          string    `yaml:"id"`
+	Title       string    `yaml:"title"`
+	Description string    `yaml:"description,omitempty"`
+	Language    string    `yaml:"language,omitempty"`
+	Tags        []string  `yaml:"tags,omitempty"`
+	Folders     []string  `yaml:"folders,omitempty"`
+	Source      string    `yaml:"source,omitempty"`
+	License     string    `yaml:"license,omitempty"`
+	Attribution string    `yaml:"attribution,omitempty"`
+	Created     time.Time `yaml:"created"`
+	Updated     time.Time `yaml:"updated"`
+}
+
+// createMarkdownBundle creates a ZIP archive with one Markdown note per
+// snippet, for note-taking apps such as Obsidian or Logseq. It cannot be
+// restored.
+func createMarkdownBundle(data models.BackupData) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	used := make(map[string]bool)
+	for _, s := range data.Snippets {
+		note, err := snippetMarkdown(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %q: %w", s.Title, err)
+		}
+
+		w, err := zw.Create(uniqueNoteName(s.Title, used))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(note); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// snippetMarkdown renders a snippet as front matter followed by a fenced
+// code block per file
+func snippetMarkdown(s models.Snippet) ([]byte, error) {
+	front := markdownFrontMatter{
+		ID:          s.ID,
+		Title:       s.Title,
+		Description: s.Description,
+		Language:    s.Language,
+		Created:     s.CreatedAt.UTC(),
+		Updated:     s.UpdatedAt.UTC(),
+	}
+	for _, tag := range s.Tags {
+		front.Tags = append(front.Tags, tag.Name)
+	}
+	for _, folder := range s.Folders {
+		front.Folders = append(front.Folders, folder.Name)
+	}
+	if s.SourceURL != nil {
+		front.Source = *s.SourceURL
+	}
+	if s.License != nil {
+		front.License = *s.License
+	}
+	if s.Attribution != nil {
+		front.Attribution = *s.Attribution
+	}
+
+	var header bytes.Buffer
+	enc := yaml.NewEncoder(&header)
+	enc.SetIndent(2)
+	if err := enc.Encode(front); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.Write(header.Bytes())
+	b.WriteString("---\n\n")
+	b.WriteString("# " + s.Title + "\n")
+	if s.Description != "" {
+		b.WriteString("\n" + s.Description + "\n")
+	}
+
+	if len(s.Files) == 0 {
+		writeCodeBlock(&b, s.Language, s.Content)
+	}
+	for _, f := range s.Files {
+		if len(s.Files) > 1 {
+			b.WriteString("\n## " + f.Filename + "\n")
+		}
+		writeCodeBlock(&b, f.Language, f.Content)
+	}
+
+	return []byte(b.String()), nil
+}
+
+// writeCodeBlock writes a fenced code block, with a fence longer than any
+// run of backticks in the content so it cannot be closed early
+func writeCodeBlock(b *strings.Builder, language, content string) {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	b.WriteString("\n" + fence + language + "\n")
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(fence + "\n")
+}
+
+// uniqueNoteName returns a .md file name for title not yet in used, adding
+// a counter for duplicate titles
+func uniqueNoteName(title string, used map[string]bool) string {
+	// A leading dot would hide the note from Obsidian
+	return uniqueFilename(strings.TrimLeft(sanitizeFilename(title), "."), ".md", used)
+}
+
+// errNoCodeBlocks is returned for notes without a fenced code block; they
+// are ordinary notes rather than snippets
+var errNoCodeBlocks = errors.New("note has no code blocks")
+
+// markdownNote is a note parsed back into snippet fields. The body is the
+// source of truth for content: the title is its first "# " heading, the
+// description the text under it and the files its fenced code blocks, named
+// by a preceding "## " heading. Tags, the ID, the source URL, license and
+// attribution come from the front matter.
+type markdownNote struct {
+	ID          string
+	Title       string
+	Description string
+	Tags        []string
+	Source      string
+	License     string
+	Attribution string
+	Blocks      []noteBlock
+}
+
+// noteBlock is a fenced code block of a note
+type noteBlock struct {
+	Filename string // From the preceding "## " heading, if any
+	Language string // The fence's info string
+	Content  string
+}
+
+// noteFrontMatter holds the front matter fields read back from notes
+type noteFrontMatter struct {
+	ID          string   `yaml:"id"`
+	Title       string   `yaml:"title"`
+	Language    string   `yaml:"language"`
+	Tags        noteTags `yaml:"tags"`
+	Source      string   `yaml:"source"`
+	License     string   `yaml:"license"`
+	Attribution string   `yaml:"attribution"`
+}
+
+// noteTags accepts Obsidian's tag forms: a list, or a single string of
+// comma or space separated tags
+type noteTags []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (t *noteTags) UnmarshalYAML(node *yaml.Node) error {
+	var tags []string
+	if node.Kind == yaml.ScalarNode {
+		tags = strings.FieldsFunc(node.Value, func(r rune) bool { return r == ',' || r == ' ' })
+	} else if err := node.Decode(&tags); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+// parseMarkdownNote parses a note written by snippetMarkdown, or by hand in
+// the same shape. name is the note's file name, the title of last resort.
+func parseMarkdownNote(name string, content []byte) (*markdownNote, error) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	var front noteFrontMatter
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		header, body, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			header, found = strings.CutSuffix(rest, "\n---")
+			body = ""
+		}
+		if found {
+			if err := yaml.Unmarshal([]byte(header), &front); err != nil {
+				return nil, fmt.Errorf("invalid front matter: %w", err)
+			}
+			text = body
+		}
+	}
+
+	note := &markdownNote{
+		ID:          strings.TrimSpace(front.ID),
+		Tags:        front.Tags,
+		Source:      strings.TrimSpace(front.Source),
+		License:     strings.TrimSpace(front.License),
+		Attribution: strings.TrimSpace(front.Attribution),
+	}
+
+	var (
+		description []string
+		heading     string // Pending "## " heading for the next block
+		fence       string // Closing fence while inside a block
+		block       *noteBlock
+		lines       []string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		if block != nil {
+			if isClosingFence(line, fence) {
+				block.Content = strings.Join(lines, "\n")
+				if len(lines) > 0 {
+					block.Content += "\n"
+				}
+				note.Blocks = append(note.Blocks, *block)
+				block, lines = nil, nil
+				continue
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		if marker, info, ok := openingFence(line); ok {
+			fence = marker
+			block = &noteBlock{Filename: heading, Language: info}
+			heading = ""
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "# ") && note.Title == "" && len(note.Blocks) == 0 && heading == "":
+			note.Title = strings.TrimSpace(trimmed[2:])
+			description = nil
+		case strings.HasPrefix(trimmed, "## "):
+			heading = strings.TrimSpace(trimmed[3:])
+		case len(note.Blocks) == 0 && heading == "":
+			description = append(description, line)
+		}
+	}
+	// An unclosed block runs to the end of the note
+	if block != nil {
+		block.Content = strings.Join(lines, "\n")
+		note.Blocks = append(note.Blocks, *block)
+	}
+
+	if len(note.Blocks) == 0 {
+		return nil, errNoCodeBlocks
+	}
+
+	note.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	if note.Title == "" {
+		note.Title = strings.TrimSpace(front.Title)
+	}
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+	if note.Blocks[0].Language == "" {
+		note.Blocks[0].Language = front.Language
+	}
+	return note, nil
+}
+
+// openingFence reports whether line opens a fenced code block, returning
+// the fence and the first word of its info string
+func openingFence(line string) (fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return "", "", false
+	}
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n < 3 {
+			continue
+		}
+		rest := trimmed[n:]
+		if c == "`" && strings.Contains(rest, "`") {
+			return "", "", false
+		}
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			info = strings.ToLower(fields[0])
+		}
+		return trimmed[:n], info, true
+	}
+	return "", "", false
+}
+
+// isClosingFence reports whether line closes a block opened with fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	trimmed = strings.TrimRight(trimmed, " \t")
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
@@ -9,25 +9,44 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/languages"
 	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
 	"github.com/MohamedElashri/snipo/internal/repository"
 )
 
+// Backup format versions. Exports are written in BackupVersion, whose
+// schema is docs/backup-schema.json; imports read any version from
+// MinBackupVersion up to the same major version. A newer minor version
+// only adds fields, which older servers ignore.
 const (
-	BackupVersion = "1.0"
+	BackupVersion    = "2.0"
+	MinBackupVersion = "1.0"
 )
 
 var (
-	ErrInvalidBackupFormat = errors.New("invalid backup format")
-	ErrDecryptionFailed    = errors.New("decryption failed - wrong password?")
+	ErrInvalidBackupFormat      = errors.New("invalid backup format")
+	ErrDecryptionFailed         = errors.New("decryption failed - wrong password?")
+	ErrCorruptBackup            = errors.New("backup file is corrupt")
+	ErrUnsupportedBackupVersion = errors.New("unsupported backup version")
 )
 
 // BackupService handles backup and restore operations
@@ -37,6 +56,12 @@ type BackupService struct {
 	tagRepo    *repository.TagRepository
 	folderRepo *repository.FolderRepository
 	fileRepo   *repository.SnippetFileRepository
+	appVersion string
+	safetyDir  string
+	safetyKeep int
+	cache      *repository.ReadCache
+	quickOpen  *repository.QuickOpenIndex
+	notifier   notify.Notifier
 	logger     *slog.Logger
 }
 
@@ -59,29 +84,91 @@ func NewBackupService(
 	}
 }
 
-// Export creates a complete backup of all data
+// WithAppVersion sets the Snipo version recorded in backup manifests
+func (b *BackupService) WithAppVersion(v string) *BackupService {
+	b.appVersion = v
+	return b
+}
+
+// WithNotifier sets the notifier used to report finished background imports
+func (b *BackupService) WithNotifier(n notify.Notifier) *BackupService {
+	b.notifier = n
+	return b
+}
+
+// WithSafetySnapshots saves the current data to a ZIP backup in dir before
+// every replacing import, keeping the newest keep snapshots (0 keeps all).
+// An empty dir disables snapshots.
+func (b *BackupService) WithSafetySnapshots(dir string, keep int) *BackupService {
+	b.safetyDir = dir
+	b.safetyKeep = keep
+	return b
+}
+
+// WithCache purges c after restores, which change data behind the
+// repositories' backs
+func (b *BackupService) WithCache(c *repository.ReadCache) *BackupService {
+	b.cache = c
+	return b
+}
+
+// WithQuickOpen purges the quick-open index after restores
+func (b *BackupService) WithQuickOpen(x *repository.QuickOpenIndex) *BackupService {
+	b.quickOpen = x
+	return b
+}
+
+// Export creates a complete backup of all data. Unencrypted exports are
+// deterministic: identical data always produces identical bytes.
 func (b *BackupService) Export(ctx context.Context, opts models.ExportOptions) ([]byte, string, error) {
+	content, filename, _, err := b.ExportWithManifest(ctx, opts)
+	return content, filename, err
+}
+
+// ExportWithManifest creates a backup like Export and also returns a
+// manifest describing it
+func (b *BackupService) ExportWithManifest(ctx context.Context, opts models.ExportOptions) ([]byte, string, *models.BackupManifest, error) {
 	data := models.BackupData{
-		Version:   BackupVersion,
-		CreatedAt: time.Now().UTC(),
+		Version: BackupVersion,
+	}
+
+	// A scoped export needs its folder or tag to exist
+	if opts.FolderID > 0 && b.folderRepo != nil {
+		if _, err := b.folderRepo.GetByID(ctx, opts.FolderID); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	if opts.TagID > 0 && b.tagRepo != nil {
+		if _, err := b.tagRepo.GetByID(ctx, opts.TagID); err != nil {
+			return nil, "", nil, err
+		}
 	}
 
 	// Gather all snippets with their files
-	snippetList, err := b.snippetSvc.List(ctx, models.SnippetFilter{
-		Page:  1,
-		Limit: 10000, // Get all snippets
+	snippets, err := b.allSnippets(ctx, models.SnippetFilter{
+		FolderID: opts.FolderID,
+		TagID:    opts.TagID,
 	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get snippets: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get snippets: %w", err)
 	}
 
+	// Markdown bundles are only read by other tools, so they are always
+	// masked; backups only on request, as they are restored as they are
+	redact := opts.Redact || opts.Format == "markdown"
+
 	// Fetch full details for each snippet (including files, tags, folders)
-	for _, s := range snippetList.Data {
+	for _, s := range snippets {
 		snippet, err := b.snippetSvc.GetByID(ctx, s.ID)
 		if err != nil {
-			b.logger.Warn("failed to get snippet details", "id", s.ID, "error", err)
+			b.logger.WarnContext(ctx, "failed to get snippet details", "id", s.ID, "error", err)
 			continue
 		}
+		if redact {
+			if err := b.snippetSvc.Redact(ctx, snippet); err != nil {
+				return nil, "", nil, err
+			}
+		}
 		data.Snippets = append(data.Snippets, *snippet)
 	}
 
@@ -89,7 +176,7 @@ func (b *BackupService) Export(ctx context.Context, opts models.ExportOptions) (
 	if b.tagRepo != nil {
 		tags, err := b.tagRepo.List(ctx)
 		if err != nil {
-			b.logger.Warn("failed to get tags", "error", err)
+			b.logger.WarnContext(ctx, "failed to get tags", "error", err)
 		} else {
 			data.Tags = tags
 		}
@@ -99,58 +186,284 @@ func (b *BackupService) Export(ctx context.Context, opts models.ExportOptions) (
 	if b.folderRepo != nil {
 		folders, err := b.folderRepo.List(ctx)
 		if err != nil {
-			b.logger.Warn("failed to get folders", "error", err)
+			b.logger.WarnContext(ctx, "failed to get folders", "error", err)
 		} else {
 			data.Folders = folders
 		}
 	}
 
+	// A scoped export keeps only the tags and folders its snippets use, so
+	// importing it elsewhere brings no unrelated ones along
+	name := "backup"
+	if opts.FolderID > 0 || opts.TagID > 0 {
+		scopeBackup(&data)
+		if opts.FolderID > 0 {
+			name = fmt.Sprintf("folder-%d", opts.FolderID)
+		} else {
+			name = fmt.Sprintf("tag-%d", opts.TagID)
+		}
+	}
+
+	canonicalizeBackup(&data)
+
 	var content []byte
 	var filename string
 
 	if opts.Format == "zip" {
 		content, err = b.createZipBackup(data)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to create zip backup: %w", err)
+			return nil, "", nil, fmt.Errorf("failed to create zip backup: %w", err)
 		}
-		filename = fmt.Sprintf("snipo-backup-%s.zip", time.Now().Format("2006-01-02-150405"))
+		filename = fmt.Sprintf("snipo-%s-%s.zip", name, time.Now().Format("2006-01-02-150405"))
+	} else if opts.Format == "markdown" {
+		content, err = createMarkdownBundle(data)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to create markdown bundle: %w", err)
+		}
+		if name == "backup" {
+			name = "markdown"
+		} else {
+			name += "-markdown"
+		}
+		filename = fmt.Sprintf("snipo-%s-%s.zip", name, time.Now().Format("2006-01-02-150405"))
 	} else {
 		// Default to JSON
-		content, err = json.MarshalIndent(data, "", "  ")
+		content, err = marshalBackup(data)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to marshal backup: %w", err)
+			return nil, "", nil, fmt.Errorf("failed to marshal backup: %w", err)
 		}
-		filename = fmt.Sprintf("snipo-backup-%s.json", time.Now().Format("2006-01-02-150405"))
+		filename = fmt.Sprintf("snipo-%s-%s.json", name, time.Now().Format("2006-01-02-150405"))
 	}
 
 	// Encrypt if password provided
 	if opts.Password != "" {
 		content, err = encrypt(content, opts.Password)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to encrypt backup: %w", err)
+			return nil, "", nil, fmt.Errorf("failed to encrypt backup: %w", err)
 		}
 		filename = filename + ".enc"
 	}
 
-	b.logger.Info("backup exported",
+	b.logger.InfoContext(ctx, "backup exported",
 		"snippets", len(data.Snippets),
 		"tags", len(data.Tags),
 		"folders", len(data.Folders),
 		"format", opts.Format,
 		"encrypted", opts.Password != "",
+		"folder_id", opts.FolderID,
+		"tag_id", opts.TagID,
+		"redacted", redact,
 	)
 
-	return content, filename, nil
+	manifest := b.manifest(&data, opts.Format, opts.Password != "")
+	return content, filename, manifest, nil
+}
+
+// allSnippets lists every snippet matching filter, archived ones included,
+// a page at a time since lists cap their page size
+func (b *BackupService) allSnippets(ctx context.Context, filter models.SnippetFilter) ([]models.Snippet, error) {
+	var snippets []models.Snippet
+	for _, archived := range []bool{false, true} {
+		filter.IsArchived = &archived
+		filter.Limit = 100
+		for page := 1; ; page++ {
+			filter.Page = page
+			list, err := b.snippetSvc.List(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			snippets = append(snippets, list.Data...)
+			if page >= list.Pagination.TotalPages {
+				break
+			}
+		}
+	}
+	return snippets, nil
+}
+
+// manifest summarizes backup data
+func (b *BackupService) manifest(data *models.BackupData, format string, encrypted bool) *models.BackupManifest {
+	if format != "zip" && format != "markdown" {
+		format = "json"
+	}
+	return &models.BackupManifest{
+		Version:    data.Version,
+		AppVersion: b.appVersion,
+		CreatedAt:  data.CreatedAt,
+		Format:     format,
+		Encrypted:  encrypted,
+		Snippets:   len(data.Snippets),
+		Tags:       len(data.Tags),
+		Folders:    len(data.Folders),
+	}
+}
+
+// Inspect builds a manifest for a backup stored without one. Encrypted
+// backups cannot be read without their password, so only the flag is set.
+func (b *BackupService) Inspect(content []byte) (*models.BackupManifest, error) {
+	format, err := ValidateBackupFile(content)
+	if err != nil {
+		return nil, err
+	}
+	if format == "encrypted" {
+		return &models.BackupManifest{Encrypted: true}, nil
+	}
+
+	data, err := b.Decode(content, "")
+	if err != nil {
+		return nil, err
+	}
+	return b.manifest(data, format, false), nil
+}
+
+// ManifestWarnings lists what to double-check before restoring a backup
+// with this manifest
+func (b *BackupService) ManifestWarnings(m *models.BackupManifest) []string {
+	var warnings []string
+	if m.Version == "" {
+		warnings = append(warnings, "The backup contents are unknown until it is restored")
+	} else if err := checkBackupVersion(m.Version); err != nil {
+		warnings = append(warnings, fmt.Sprintf("The backup format (%s) cannot be restored by this server (%s)", m.Version, BackupVersion))
+	} else if newerVersion(m.Version, BackupVersion) {
+		warnings = append(warnings, fmt.Sprintf("The backup format (%s) is newer than this server supports (%s); data may be lost", m.Version, BackupVersion))
+	}
+	if m.AppVersion != "" && b.appVersion != "" && m.AppVersion != b.appVersion {
+		warnings = append(warnings, fmt.Sprintf("The backup was created by Snipo %s; this server runs %s", m.AppVersion, b.appVersion))
+	}
+	if m.Encrypted {
+		warnings = append(warnings, "The backup is encrypted; its password is needed to restore it")
+	}
+	return warnings
+}
+
+// checkBackupVersion accepts the backup format versions this server can
+// restore and explains why others are refused
+func checkBackupVersion(v string) error {
+	major, _, _ := strings.Cut(v, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not a backup format version", ErrInvalidBackupFormat, v)
+	}
+	current, _, _ := strings.Cut(BackupVersion, ".")
+	if latest, _ := strconv.Atoi(current); n > latest {
+		return fmt.Errorf("%w: the backup format (%s) is newer than this server supports (%s); upgrade Snipo to restore it", ErrUnsupportedBackupVersion, v, BackupVersion)
+	}
+	if newerVersion(MinBackupVersion, v) {
+		return fmt.Errorf("%w: the backup format (%s) is older than this server supports (%s)", ErrUnsupportedBackupVersion, v, MinBackupVersion)
+	}
+	return nil
+}
+
+// newerVersion reports whether dotted version a is newer than b
+func newerVersion(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// ImportProgress receives progress updates while a backup is restored
+type ImportProgress interface {
+	SetTotal(total int)
+	Advance(n int)
+	Error(msg string)
+}
+
+// noProgress discards progress updates
+type noProgress struct{}
+
+func (noProgress) SetTotal(int) {}
+func (noProgress) Advance(int)  {}
+func (noProgress) Error(string) {}
+
+// BackupImportJob is the job kind of background imports
+const BackupImportJob = "backup_import"
+
+// BackupImportPayload is the queued input of a BackupImportJob. It holds the
+// decoded backup, so the password never reaches the job queue.
+type BackupImportPayload struct {
+	Data     *models.BackupData `json:"data"`
+	Strategy string             `json:"strategy"`
+}
+
+// RunImportJob restores a queued backup; it is the handler for BackupImportJob.
+// Restores skip or replace existing data, so an interrupted job can safely run again.
+func (b *BackupService) RunImportJob(ctx context.Context, payload json.RawMessage, p *jobs.Progress) (any, error) {
+	var input BackupImportPayload
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, fmt.Errorf("invalid import payload: %w", err)
+	}
+	if input.Data == nil {
+		return nil, ErrInvalidBackupFormat
+	}
+
+	result, err := b.Restore(ctx, input.Data, models.ImportOptions{Strategy: input.Strategy}, p)
+	b.reportImport(ctx, result, err)
+	return result, err
+}
+
+// reportImport notifies about a finished background import. Failures are
+// reported per attempt, so a retried import can report more than once.
+func (b *BackupService) reportImport(ctx context.Context, result *models.ImportResult, importErr error) {
+	if b.notifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Type:  notify.EventImportFailed,
+		Title: "Import failed",
+		Time:  time.Now().UTC(),
+	}
+	if importErr != nil {
+		event.Message = fmt.Sprintf("Backup import failed: %v", importErr)
+		event.Fields = map[string]string{"error": importErr.Error()}
+	} else {
+		event.Type = notify.EventImportFinished
+		event.Title = "Import finished"
+		event.Message = fmt.Sprintf("Imported %d snippets, %d tags and %d folders", result.SnippetsImported, result.TagsImported, result.FoldersImported)
+		event.Fields = map[string]string{
+			"snippets": strconv.Itoa(result.SnippetsImported),
+			"tags":     strconv.Itoa(result.TagsImported),
+			"folders":  strconv.Itoa(result.FoldersImported),
+			"errors":   strconv.Itoa(len(result.Errors)),
+		}
+	}
+
+	if err := b.notifier.Notify(ctx, event); err != nil {
+		b.logger.WarnContext(ctx, "failed to report import", "error", err)
+	}
 }
 
 // Import restores data from a backup
 func (b *BackupService) Import(ctx context.Context, content []byte, opts models.ImportOptions) (*models.ImportResult, error) {
+	data, err := b.Decode(content, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+	return b.Restore(ctx, data, opts, nil)
+}
+
+// Decode decrypts (when password is set) and parses a JSON or ZIP backup
+// without touching the database, so callers can reject bad files up front.
+// Backups in a format version this server cannot restore fail with
+// ErrUnsupportedBackupVersion.
+func (b *BackupService) Decode(content []byte, password string) (*models.BackupData, error) {
 	// Decrypt if password provided
 	var err error
-	if opts.Password != "" {
-		content, err = decrypt(content, opts.Password)
+	if password != "" {
+		content, err = decrypt(content, password)
 		if err != nil {
-			return nil, ErrDecryptionFailed
+			return nil, err
 		}
 	}
 
@@ -178,13 +491,42 @@ func (b *BackupService) Import(ctx context.Context, content []byte, opts models.
 				break
 			}
 		}
+	}
 
-		if data.Version == "" {
-			return nil, ErrInvalidBackupFormat
-		}
+	if data.Version == "" {
+		return nil, ErrInvalidBackupFormat
+	}
+	if err := checkBackupVersion(data.Version); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// Restore imports decoded backup data, reporting each tag, folder and
+// snippet to progress (which may be nil). The result lists what was done
+// with every item.
+func (b *BackupService) Restore(ctx context.Context, data *models.BackupData, opts models.ImportOptions, progress ImportProgress) (*models.ImportResult, error) {
+	if progress == nil {
+		progress = noProgress{}
 	}
+	progress.SetTotal(len(data.Tags) + len(data.Folders) + len(data.Snippets))
+	defer b.cache.Purge()
+	defer b.quickOpen.Purge()
 
 	result := &models.ImportResult{}
+	addError := func(msg string) {
+		result.Errors = append(result.Errors, msg)
+		progress.Error(msg)
+	}
+
+	if opts.Strategy == "replace" || opts.Snapshot {
+		snapshot, err := b.SafetySnapshot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create safety snapshot: %w", err)
+		}
+		result.SafetySnapshot = snapshot
+	}
 
 	// Handle strategy
 	if opts.Strategy == "replace" {
@@ -200,28 +542,29 @@ func (b *BackupService) Import(ctx context.Context, content []byte, opts models.
 		existingTagsByName[existingTags[i].Name] = &existingTags[i]
 	}
 
-	existingFolders, _ := b.folderRepo.List(ctx)
-	existingFoldersByName := make(map[string]*models.Folder)
-	for i := range existingFolders {
-		existingFoldersByName[existingFolders[i].Name] = &existingFolders[i]
-	}
-
-	existingSnippets, _ := b.snippetSvc.List(ctx, models.SnippetFilter{Limit: 10000})
+	existingSnippets, _ := b.allSnippets(ctx, models.SnippetFilter{})
 	existingSnippetsByTitle := make(map[string]*models.Snippet)
-	if existingSnippets != nil {
-		for i := range existingSnippets.Data {
-			existingSnippetsByTitle[existingSnippets.Data[i].Title] = &existingSnippets.Data[i]
-		}
+	for i := range existingSnippets {
+		existingSnippetsByTitle[existingSnippets[i].Title] = &existingSnippets[i]
 	}
 
 	// Import tags first (needed for relationships)
 	tagMap := make(map[int64]int64) // old ID -> new ID
-	for _, tag := range data.Tags {
+	for i, tag := range data.Tags {
+		progress.Advance(1)
 		oldID := tag.ID
+		item := models.ImportItem{
+			Kind:     models.ImportKindTag,
+			Line:     i + 1,
+			SourceID: strconv.FormatInt(oldID, 10),
+			Title:    tag.Name,
+		}
 		// Check if tag already exists by name
 		if existingTag, exists := existingTagsByName[tag.Name]; exists {
 			tagMap[oldID] = existingTag.ID
 			// Don't count as imported since it already existed
+			item.Action = models.ImportExisting
+			item.NewID = strconv.FormatInt(existingTag.ID, 10)
 		} else {
 			// Create new tag
 			newTag, err := b.tagRepo.Create(ctx, &models.TagInput{
@@ -232,70 +575,105 @@ func (b *BackupService) Import(ctx context.Context, content []byte, opts models.
 				tagMap[oldID] = newTag.ID
 				existingTagsByName[tag.Name] = newTag // Add to map to prevent duplicates
 				result.TagsImported++
+				b.restoreTimes(ctx, "UPDATE tags SET created_at = COALESCE(?, created_at) WHERE id = ?", timeArg(tag.CreatedAt), newTag.ID)
+				item.Action = models.ImportCreated
+				item.NewID = strconv.FormatInt(newTag.ID, 10)
 			} else {
-				result.Errors = append(result.Errors, fmt.Sprintf("tag %s: %v", tag.Name, err))
+				addError(fmt.Sprintf("tag %s: %v", tag.Name, err))
+				item.Action = models.ImportFailed
+				item.Reason = err.Error()
 			}
 		}
+		result.Items = append(result.Items, item)
 	}
 
 	// Import folders
-	folderMap := make(map[int64]int64) // old ID -> new ID
-	// First pass: create folders without parent relationships (only if they don't exist)
-	for _, folder := range data.Folders {
-		oldID := folder.ID
-		// Check if folder already exists by name
-		if existingFolder, exists := existingFoldersByName[folder.Name]; exists {
-			folderMap[oldID] = existingFolder.ID
-			// Don't count as imported since it already existed
-		} else {
-			input := &models.FolderInput{
-				Name:      folder.Name,
-				Icon:      folder.Icon,
-				SortOrder: folder.SortOrder,
-			}
-			newFolder, err := b.folderRepo.Create(ctx, input)
-			if err == nil {
-				folderMap[oldID] = newFolder.ID
-				existingFoldersByName[folder.Name] = newFolder // Add to map to prevent duplicates
-				result.FoldersImported++
-			} else {
-				result.Errors = append(result.Errors, fmt.Sprintf("folder %s: %v", folder.Name, err))
-			}
-		}
-	}
+	folderMap := b.restoreFolders(ctx, data.Folders, result, addError, progress) // old ID -> new ID
 
-	// Second pass: update parent relationships for newly created folders
-	for _, folder := range data.Folders {
-		if folder.ParentID != nil {
-			// Only update if this folder was newly created
-			if _, existed := existingFoldersByName[folder.Name]; !existed {
-				if newID, ok := folderMap[folder.ID]; ok {
-					if newParentID, ok := folderMap[*folder.ParentID]; ok {
-						_, _ = b.folderRepo.Move(ctx, newID, &newParentID)
-					}
-				}
-			}
+	// Import snippets
+	for i, snippet := range data.Snippets {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		progress.Advance(1)
+		item := models.ImportItem{
+			Kind:     models.ImportKindSnippet,
+			Line:     i + 1,
+			SourceID: snippet.ID,
+			Title:    snippet.Title,
 		}
-	}
 
-	// Import snippets
-	for _, snippet := range data.Snippets {
 		// Check if snippet with same title already exists
-		if _, exists := existingSnippetsByTitle[snippet.Title]; exists {
+		if existing, exists := existingSnippetsByTitle[snippet.Title]; exists {
 			// Skip if strategy is "skip" or "merge" (merge doesn't overwrite existing)
 			if opts.Strategy == "skip" || opts.Strategy == "merge" {
+				item.Action = models.ImportSkipped
+				item.NewID = existing.ID
+				item.Reason = "a snippet with this title already exists"
+				result.Items = append(result.Items, item)
 				continue
 			}
 		}
 
+		// Exports carry checksums; report content altered since the export
+		if snippet.Checksum != nil && *snippet.Checksum != ContentChecksum(snippet.Content, snippet.Files) {
+			b.logger.WarnContext(ctx, "backup snippet checksum mismatch", "title", snippet.Title)
+			result.ChecksumMismatches = append(result.ChecksumMismatches, snippet.Title)
+		}
+
+		// Keep the original ID unless another snippet already has it
+		var id string
+		var notes []string
+		if snippet.ID != "" {
+			if _, err := b.snippetSvc.GetByID(ctx, snippet.ID); errors.Is(err, ErrSnippetNotFound) {
+				id = snippet.ID
+			} else {
+				notes = append(notes, "ID already in use, given a new one")
+			}
+		}
+
 		// Prepare input
 		input := &models.SnippetInput{
+			ID:          id,
 			Title:       snippet.Title,
 			Description: snippet.Description,
 			Content:     snippet.Content,
 			Language:    snippet.Language,
 			IsPublic:    snippet.IsPublic,
 			IsArchived:  snippet.IsArchived,
+			SourceURL:   snippet.SourceURL,
+			Slug:        snippet.Slug,
+			License:     snippet.License,
+			Attribution: snippet.Attribution,
+			Metadata:    snippet.Metadata,
+			ReviewState: snippet.ReviewState,
+		}
+		if snippet.PublishAt != nil && !snippet.IsPublic {
+			publishAt := snippet.PublishAt.UTC().Format(time.RFC3339)
+			input.PublishAt = &publishAt
+		}
+		if snippet.RequiresCheckout {
+			input.RequiresCheckout = &snippet.RequiresCheckout
+		}
+		if snippet.NoIndex {
+			input.NoIndex = &snippet.NoIndex
+		}
+		input.IsFavorite = snippet.IsFavorite
+		if snippet.IsPinned {
+			// Backups without pin times order pins by last update
+			pinnedAt := snippet.UpdatedAt
+			if snippet.PinnedAt != nil {
+				pinnedAt = *snippet.PinnedAt
+			}
+			input.PinnedAt = &pinnedAt
+		}
+		// Backups from before the review workflow: public snippets stay
+		// published, as they do when the database is migrated
+		if !models.IsReviewState(input.ReviewState) {
+			input.ReviewState = ""
+			if snippet.IsPublic {
+				input.ReviewState = models.ReviewApproved
+			}
 		}
 
 		// Map tags
@@ -319,17 +697,30 @@ func (b *BackupService) Import(ctx context.Context, content []byte, opts models.
 			})
 		}
 
-		_, err := b.snippetSvc.Create(ctx, input)
+		created, err := b.snippetSvc.Create(ctx, input)
+		if errors.Is(err, ErrSlugTaken) {
+			// Keep the snippet even if its slug now belongs to another one
+			input.Slug = nil
+			created, err = b.snippetSvc.Create(ctx, input)
+			notes = append(notes, "slug already in use, imported without it")
+		}
 		if err == nil {
 			result.SnippetsImported++
+			b.restoreSnippetTimes(ctx, created.ID, snippet)
 			// Add to map to prevent duplicates within same import
-			existingSnippetsByTitle[snippet.Title] = &snippet
+			existingSnippetsByTitle[snippet.Title] = created
+			item.Action = models.ImportCreated
+			item.NewID = created.ID
+			item.Reason = strings.Join(notes, "; ")
 		} else {
-			result.Errors = append(result.Errors, fmt.Sprintf("snippet %s: %v", snippet.Title, err))
+			addError(fmt.Sprintf("snippet %s: %v", snippet.Title, err))
+			item.Action = models.ImportFailed
+			item.Reason = err.Error()
 		}
+		result.Items = append(result.Items, item)
 	}
 
-	b.logger.Info("backup imported",
+	b.logger.InfoContext(ctx, "backup imported",
 		"snippets", result.SnippetsImported,
 		"tags", result.TagsImported,
 		"folders", result.FoldersImported,
@@ -339,17 +730,184 @@ func (b *BackupService) Import(ctx context.Context, content []byte, opts models.
 	return result, nil
 }
 
+// folderKey identifies a folder by its name and parent, the way the
+// folder tree shows it
+type folderKey struct {
+	parent int64 // Zero at the top level
+	name   string
+}
+
+// restoreFolders creates the backup's folders that do not exist yet, with
+// their icon, color, sort order and other settings, and maps every backup
+// folder ID to the local one. Parents come first, so each folder lands in
+// its place in the tree. A folder exists when one with its name already
+// sits under the same parent, so same-named folders in different places
+// stay apart; existing folders keep their own settings. Each folder is
+// added to the result's items in backup order.
+func (b *BackupService) restoreFolders(ctx context.Context, folders []models.Folder, result *models.ImportResult, addError func(string), progress ImportProgress) map[int64]int64 {
+	existing := make(map[folderKey]int64)
+	if local, err := b.folderRepo.List(ctx); err == nil {
+		for _, f := range local {
+			existing[folderKey{parentOrZero(f.ParentID), f.Name}] = f.ID
+		}
+	}
+
+	folderMap := make(map[int64]int64, len(folders))
+	items := make([]models.ImportItem, len(folders))
+	for _, i := range foldersParentsFirst(folders) {
+		folder := folders[i]
+		progress.Advance(1)
+		items[i] = models.ImportItem{
+			Kind:     models.ImportKindFolder,
+			Line:     i + 1,
+			SourceID: strconv.FormatInt(folder.ID, 10),
+			Title:    folder.Name,
+		}
+
+		// Folders whose parent is missing from the backup, or failed to
+		// import, go to the top level
+		var parentID *int64
+		if folder.ParentID != nil {
+			if id, ok := folderMap[*folder.ParentID]; ok {
+				parentID = &id
+			}
+		}
+		key := folderKey{parentOrZero(parentID), folder.Name}
+		if id, ok := existing[key]; ok {
+			folderMap[folder.ID] = id
+			items[i].Action = models.ImportExisting
+			items[i].NewID = strconv.FormatInt(id, 10)
+			continue
+		}
+
+		input := &models.FolderInput{
+			Name:             folder.Name,
+			ParentID:         parentID,
+			Icon:             folder.Icon,
+			Color:            folder.Color,
+			SortOrder:        folder.SortOrder,
+			ArchiveAfterDays: &folder.ArchiveAfterDays,
+			IsPublic:         &folder.IsPublic,
+			Slug:             folder.Slug,
+		}
+		newFolder, err := b.folderRepo.Create(ctx, input)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			// Keep the folder even if its slug now belongs to another one
+			input.Slug = nil
+			newFolder, err = b.folderRepo.Create(ctx, input)
+			items[i].Reason = "slug already in use, imported without it"
+		}
+		if err != nil {
+			addError(fmt.Sprintf("folder %s: %v", folder.Name, err))
+			items[i].Action = models.ImportFailed
+			items[i].Reason = err.Error()
+			continue
+		}
+		folderMap[folder.ID] = newFolder.ID
+		existing[key] = newFolder.ID
+		result.FoldersImported++
+		b.restoreTimes(ctx, "UPDATE folders SET created_at = COALESCE(?, created_at) WHERE id = ?", timeArg(folder.CreatedAt), newFolder.ID)
+		items[i].Action = models.ImportCreated
+		items[i].NewID = strconv.FormatInt(newFolder.ID, 10)
+	}
+	result.Items = append(result.Items, items...)
+	return folderMap
+}
+
+// foldersParentsFirst returns the indexes of folders ordered so each comes
+// after its parent, keeping the backup's order otherwise. Folders in a
+// parent cycle, which only a hand-edited backup has, come last.
+func foldersParentsFirst(folders []models.Folder) []int {
+	byID := make(map[int64]*models.Folder, len(folders))
+	for i := range folders {
+		byID[folders[i].ID] = &folders[i]
+	}
+
+	depth := make(map[int64]int, len(folders))
+	for _, f := range folders {
+		d := 0
+		seen := map[int64]bool{f.ID: true}
+		for parent := f.ParentID; parent != nil; d++ {
+			p, ok := byID[*parent]
+			if !ok {
+				break
+			}
+			if seen[p.ID] {
+				d = len(folders)
+				break
+			}
+			seen[p.ID] = true
+			parent = p.ParentID
+		}
+		depth[f.ID] = d
+	}
+
+	order := make([]int, len(folders))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return depth[folders[a].ID] - depth[folders[b].ID] })
+	return order
+}
+
+func parentOrZero(id *int64) int64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// restoreSnippetTimes gives a restored snippet and its files the creation
+// and update times, and the snippet its view count, recorded in the backup
+// instead of the ones set on creation
+func (b *BackupService) restoreSnippetTimes(ctx context.Context, id string, snippet models.Snippet) {
+	b.restoreTimes(ctx, `
+		UPDATE snippets
+		SET created_at = COALESCE(?, created_at), updated_at = COALESCE(?, updated_at), view_count = ?
+		WHERE id = ?`,
+		timeArg(snippet.CreatedAt), timeArg(snippet.UpdatedAt), snippet.ViewCount, id)
+	for _, file := range snippet.Files {
+		b.restoreTimes(ctx, `
+			UPDATE snippet_files
+			SET created_at = COALESCE(?, created_at), updated_at = COALESCE(?, updated_at)
+			WHERE snippet_id = ? AND filename = ?`,
+			timeArg(file.CreatedAt), timeArg(file.UpdatedAt), id, file.Filename)
+	}
+}
+
+// restoreTimes runs an update restoring recorded times. A failure only
+// leaves the time of the import in place, so it is logged, not reported.
+func (b *BackupService) restoreTimes(ctx context.Context, query string, args ...any) {
+	if _, err := b.db.ExecContext(ctx, query, args...); err != nil {
+		b.logger.WarnContext(ctx, "failed to restore backup times", "error", err)
+	}
+}
+
+// timeArg formats t the way CURRENT_TIMESTAMP stores times, or returns nil
+// for the zero time of backups without it
+func timeArg(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
 // createZipBackup creates a ZIP archive with snippets as individual files
 func (b *BackupService) createZipBackup(data models.BackupData) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	zw := zip.NewWriter(buf)
 
-	// Add snippets as individual files
+	// Add snippets as individual files. Titles repeat and can differ only
+	// in case, so entries get unique names.
+	used := make(map[string]bool)
 	for _, s := range data.Snippets {
 		// Add each file in the snippet
 		if len(s.Files) > 0 {
+			dir := uniqueFilename(sanitizeFilename(s.Title), "", used)
+			files := make(map[string]bool, len(s.Files))
 			for _, f := range s.Files {
-				filename := fmt.Sprintf("snippets/%s/%s", sanitizeFilename(s.Title), f.Filename)
+				base, ext := splitExt(norm.NFC.String(f.Filename))
+				filename := "snippets/" + dir + "/" + uniqueFilename(base, ext, files)
 				w, err := zw.Create(filename)
 				if err != nil {
 					return nil, err
@@ -360,8 +918,8 @@ func (b *BackupService) createZipBackup(data models.BackupData) ([]byte, error)
 			}
 		} else {
 			// Legacy single-file snippet
-			ext := getExtension(s.Language)
-			filename := fmt.Sprintf("snippets/%s.%s", sanitizeFilename(s.Title), ext)
+			ext := languages.Extension(s.Language)
+			filename := "snippets/" + uniqueFilename(sanitizeFilename(s.Title), "."+ext, used)
 			w, err := zw.Create(filename)
 			if err != nil {
 				return nil, err
@@ -377,7 +935,11 @@ func (b *BackupService) createZipBackup(data models.BackupData) ([]byte, error)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.NewEncoder(metaW).Encode(data); err != nil {
+	meta, err := marshalBackup(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := metaW.Write(meta); err != nil {
 		return nil, err
 	}
 
@@ -388,98 +950,259 @@ func (b *BackupService) createZipBackup(data models.BackupData) ([]byte, error)
 	return buf.Bytes(), nil
 }
 
-// clearAllData removes all snippets, tags, and folders
-func (b *BackupService) clearAllData(ctx context.Context) error {
-	queries := []string{
-		"DELETE FROM snippet_tags",
-		"DELETE FROM snippet_folders",
-		"DELETE FROM snippet_files",
-		"DELETE FROM snippets",
-		"DELETE FROM tags",
-		"DELETE FROM folders",
+// scopeBackup drops the tags and folders no exported snippet uses. Parents
+// of kept folders stay, so the hierarchy survives a restore.
+func scopeBackup(data *models.BackupData) {
+	tagIDs := make(map[int64]bool)
+	folderIDs := make(map[int64]bool)
+	for _, s := range data.Snippets {
+		for _, t := range s.Tags {
+			tagIDs[t.ID] = true
+		}
+		for _, f := range s.Folders {
+			folderIDs[f.ID] = true
+		}
 	}
 
-	for _, q := range queries {
-		if _, err := b.db.ExecContext(ctx, q); err != nil {
-			b.logger.Warn("failed to execute clear query", "query", q, "error", err)
+	parents := make(map[int64]*int64, len(data.Folders))
+	for _, f := range data.Folders {
+		parents[f.ID] = f.ParentID
+	}
+	for id := range folderIDs {
+		for parent := parents[id]; parent != nil && !folderIDs[*parent]; parent = parents[*parent] {
+			folderIDs[*parent] = true
 		}
 	}
 
-	return nil
+	data.Tags = slices.DeleteFunc(data.Tags, func(t models.Tag) bool { return !tagIDs[t.ID] })
+	data.Folders = slices.DeleteFunc(data.Folders, func(f models.Folder) bool { return !folderIDs[f.ID] })
+}
+
+// canonicalizeBackup sorts everything in the backup by a stable key and
+// derives CreatedAt from the data itself, so exports depend only on content
+// and not on query plans or the time of export
+func canonicalizeBackup(data *models.BackupData) {
+	var latest time.Time
+	observe := func(t time.Time) {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	sort.Slice(data.Snippets, func(i, j int) bool { return data.Snippets[i].ID < data.Snippets[j].ID })
+	for i := range data.Snippets {
+		s := &data.Snippets[i]
+		observe(s.UpdatedAt)
+		sort.Slice(s.Tags, func(i, j int) bool { return s.Tags[i].Name < s.Tags[j].Name })
+		sort.Slice(s.Folders, func(i, j int) bool { return s.Folders[i].ID < s.Folders[j].ID })
+		// Files keep their sort order, which the checksum depends on
+		sort.SliceStable(s.Files, func(i, j int) bool {
+			if s.Files[i].SortOrder != s.Files[j].SortOrder {
+				return s.Files[i].SortOrder < s.Files[j].SortOrder
+			}
+			return s.Files[i].ID < s.Files[j].ID
+		})
+	}
+
+	sort.Slice(data.Tags, func(i, j int) bool { return data.Tags[i].Name < data.Tags[j].Name })
+	for _, t := range data.Tags {
+		observe(t.CreatedAt)
+	}
+
+	sort.Slice(data.Folders, func(i, j int) bool { return data.Folders[i].ID < data.Folders[j].ID })
+	for _, f := range data.Folders {
+		observe(f.CreatedAt)
+	}
+
+	data.CreatedAt = latest.UTC()
+}
+
+// marshalBackup encodes backup data as canonical JSON: two-space indent,
+// struct field order, sorted map keys, no HTML escaping and a trailing newline
+func marshalBackup(data models.BackupData) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// safetySnapshotPrefix starts the filename of every safety snapshot
+const safetySnapshotPrefix = "pre-restore-"
+
+// SafetySnapshot exports the current data to the safety snapshot directory
+// and returns the file's name. It returns "" when snapshots are disabled.
+func (b *BackupService) SafetySnapshot(ctx context.Context) (string, error) {
+	if b.safetyDir == "" {
+		return "", nil
+	}
+
+	content, filename, err := b.Export(ctx, models.ExportOptions{Format: "zip"})
+	if err != nil {
+		return "", err
+	}
+
+	// Snapshots hold all data unencrypted
+	if err := os.MkdirAll(b.safetyDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	name := safetySnapshotPrefix + filename
+	tmp := filepath.Join(b.safetyDir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(b.safetyDir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	b.logger.InfoContext(ctx, "safety snapshot created", "file", name, "size", len(content))
+	b.pruneSafetySnapshots(ctx)
+	return name, nil
+}
+
+// pruneSafetySnapshots deletes the oldest snapshots beyond the keep count.
+// Names are timestamped, so they sort oldest first.
+func (b *BackupService) pruneSafetySnapshots(ctx context.Context) {
+	if b.safetyKeep <= 0 {
+		return
+	}
+
+	names, err := filepath.Glob(filepath.Join(b.safetyDir, safetySnapshotPrefix+"*"))
+	if err != nil || len(names) <= b.safetyKeep {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-b.safetyKeep] {
+		if err := os.Remove(name); err != nil {
+			b.logger.WarnContext(ctx, "failed to remove old safety snapshot", "file", name, "error", err)
+		}
+	}
+}
+
+// clearAllData deletes all snippets, tags and folders and what belongs to
+// them, before a replace restore
+func (b *BackupService) clearAllData(ctx context.Context) error {
+	return database.ClearLibrary(ctx, b.db)
+}
+
+// Encrypted backups start with a versioned envelope header:
+//
+//	magic "SNPENC" | version (1) | kdf (1) | time (4) | memory KiB (4) | threads (1) | salt length (1) | salt
+//
+// followed by the GCM nonce and ciphertext. The header is authenticated as
+// additional data, so tampering with the KDF parameters fails decryption.
+// Files without the magic are legacy backups keyed with an unsalted SHA-256.
+const (
+	envelopeVersion = 1
+	kdfArgon2id     = 1
+
+	backupArgonTime    = 3
+	backupArgonMemory  = 64 * 1024
+	backupArgonThreads = 4
+	backupSaltLen      = 16
+
+	// Upper bounds for parameters read from untrusted files
+	maxArgonTime   = 16
+	maxArgonMemory = 1024 * 1024
+)
+
+var envelopeMagic = []byte("SNPENC")
+
+// envelopeHeader holds the key derivation parameters of an encrypted backup
+type envelopeHeader struct {
+	version uint8
+	kdf     uint8
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+}
+
+func (h envelopeHeader) marshal() []byte {
+	buf := make([]byte, 0, len(envelopeMagic)+13+len(h.salt))
+	buf = append(buf, envelopeMagic...)
+	buf = append(buf, h.version, h.kdf)
+	buf = binary.BigEndian.AppendUint32(buf, h.time)
+	buf = binary.BigEndian.AppendUint32(buf, h.memory)
+	buf = append(buf, h.threads, uint8(len(h.salt)))
+	return append(buf, h.salt...)
+}
+
+// parseEnvelopeHeader reads the header from data and returns it with the
+// number of bytes it occupies
+func parseEnvelopeHeader(data []byte) (envelopeHeader, int, error) {
+	var h envelopeHeader
+	n := len(envelopeMagic)
+	if len(data) < n+12 {
+		return h, 0, fmt.Errorf("%w: truncated header", ErrCorruptBackup)
+	}
+
+	h.version, h.kdf = data[n], data[n+1]
+	h.time = binary.BigEndian.Uint32(data[n+2:])
+	h.memory = binary.BigEndian.Uint32(data[n+6:])
+	h.threads = data[n+10]
+	saltLen := int(data[n+11])
+	n += 12
+
+	if h.version != envelopeVersion {
+		return h, 0, fmt.Errorf("%w: unsupported envelope version %d", ErrCorruptBackup, h.version)
+	}
+	if h.kdf != kdfArgon2id {
+		return h, 0, fmt.Errorf("%w: unknown key derivation %d", ErrCorruptBackup, h.kdf)
+	}
+	if h.time == 0 || h.time > maxArgonTime || h.memory == 0 || h.memory > maxArgonMemory || h.threads == 0 || saltLen == 0 {
+		return h, 0, fmt.Errorf("%w: invalid key derivation parameters", ErrCorruptBackup)
+	}
+	if len(data) < n+saltLen {
+		return h, 0, fmt.Errorf("%w: truncated header", ErrCorruptBackup)
+	}
+	h.salt = data[n : n+saltLen]
+
+	return h, n + saltLen, nil
+}
+
+// deriveKey derives a 32-byte key from password using the header's KDF
+func (h envelopeHeader) deriveKey(password string) []byte {
+	return argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, 32)
 }
 
-// sanitizeFilename removes invalid characters from filename
-func sanitizeFilename(name string) string {
-	// Replace invalid characters
-	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
-	result := name
-	for _, c := range invalid {
-		result = strings.ReplaceAll(result, c, "_")
-	}
-	// Limit length
-	if len(result) > 50 {
-		result = result[:50]
-	}
-	return result
-}
-
-// getExtension returns file extension for a language
-func getExtension(lang string) string {
-	extensions := map[string]string{
-		"javascript": "js",
-		"typescript": "ts",
-		"python":     "py",
-		"go":         "go",
-		"rust":       "rs",
-		"java":       "java",
-		"c":          "c",
-		"cpp":        "cpp",
-		"csharp":     "cs",
-		"php":        "php",
-		"ruby":       "rb",
-		"swift":      "swift",
-		"kotlin":     "kt",
-		"scala":      "scala",
-		"html":       "html",
-		"css":        "css",
-		"scss":       "scss",
-		"json":       "json",
-		"yaml":       "yaml",
-		"xml":        "xml",
-		"markdown":   "md",
-		"sql":        "sql",
-		"bash":       "sh",
-		"shell":      "sh",
-		"powershell": "ps1",
-		"dockerfile": "dockerfile",
-		"nginx":      "conf",
-		"toml":       "toml",
-		"ini":        "ini",
-		"makefile":   "mk",
-	}
-
-	if ext, ok := extensions[lang]; ok {
-		return ext
-	}
-	return "txt"
-}
-
-// deriveKey derives a 32-byte key from password using SHA256
-func deriveKey(password string) []byte {
+// deriveLegacyKey derives the key of pre-envelope backups: unsalted SHA-256
+func deriveLegacyKey(password string) []byte {
 	hash := sha256.Sum256([]byte(password))
 	return hash[:]
 }
 
-// encrypt encrypts data using AES-256-GCM
-func encrypt(data []byte, password string) ([]byte, error) {
-	key := deriveKey(password)
-
+// newGCM creates an AES-256-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
+	return cipher.NewGCM(block)
+}
 
-	gcm, err := cipher.NewGCM(block)
+// encrypt encrypts data using AES-256-GCM with an Argon2id-derived key
+func encrypt(data []byte, password string) ([]byte, error) {
+	salt := make([]byte, backupSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	header := envelopeHeader{
+		version: envelopeVersion,
+		kdf:     kdfArgon2id,
+		time:    backupArgonTime,
+		memory:  backupArgonMemory,
+		threads: backupArgonThreads,
+		salt:    salt,
+	}
+
+	gcm, err := newGCM(header.deriveKey(password))
 	if err != nil {
 		return nil, err
 	}
@@ -489,30 +1212,44 @@ func encrypt(data []byte, password string) ([]byte, error) {
 		return nil, err
 	}
 
-	return gcm.Seal(nonce, nonce, data, nil), nil
+	out := header.marshal()
+	aad := out
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, aad), nil
 }
 
-// decrypt decrypts data using AES-256-GCM
+// decrypt decrypts an encrypted backup, returning ErrDecryptionFailed for a
+// wrong password and ErrCorruptBackup for a damaged or truncated file
 func decrypt(data []byte, password string) ([]byte, error) {
-	key := deriveKey(password)
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
+	var key, aad []byte
+	if bytes.HasPrefix(data, envelopeMagic) {
+		header, n, err := parseEnvelopeHeader(data)
+		if err != nil {
+			return nil, err
+		}
+		key = header.deriveKey(password)
+		aad, data = data[:n], data[n:]
+	} else {
+		key = deriveLegacyKey(password)
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return nil, errors.New("ciphertext too short")
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrCorruptBackup)
 	}
 
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		// GCM cannot tell a wrong key from a modified ciphertext; with an
+		// intact header the password is by far the likelier cause
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
 }
 
 // GetFilename generates a backup filename
@@ -544,8 +1281,9 @@ func ValidateBackupFile(content []byte) (string, error) {
 		return "zip", nil
 	}
 
-	// Check for encrypted (starts with random bytes, so just check it's not empty)
-	if len(content) > 32 {
+	// Check for encrypted (versioned envelope, or legacy files that start
+	// with random bytes, so just check it's not empty)
+	if bytes.HasPrefix(content, envelopeMagic) || len(content) > 32 {
 		return "encrypted", nil
 	}
 
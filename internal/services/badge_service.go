@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// badgeColors are the shields.io colour names accepted besides hex colours
+var badgeColors = map[string]string{
+	"brightgreen":   "#4c1",
+	"green":         "#97ca00",
+	"yellowgreen":   "#a4a61d",
+	"yellow":        "#dfb317",
+	"orange":        "#fe7d37",
+	"red":           "#e05d44",
+	"blue":          "#007ec6",
+	"lightgrey":     "#9f9f9f",
+	"grey":          "#555",
+	"informational": "#007ec6",
+}
+
+var hexColor = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// publicStats counts the public snippets
+type publicStats interface {
+	CountPublic(ctx context.Context) (int, error)
+	PublicLanguageCounts(ctx context.Context) ([]models.LanguageCount, error)
+}
+
+// BadgeService builds status badges about the public snippets of an
+// instance, for embedding in READMEs. Private and archived snippets are
+// never counted.
+type BadgeService struct {
+	stats publicStats
+}
+
+// NewBadgeService creates a new badge service
+func NewBadgeService(stats publicStats) *BadgeService {
+	return &BadgeService{stats: stats}
+}
+
+// Count returns a badge with the number of public snippets
+func (s *BadgeService) Count(ctx context.Context) (*models.Badge, error) {
+	count, err := s.stats.CountPublic(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Badge{Label: "snippets", Message: strconv.Itoa(count), Color: badgeColors["blue"]}, nil
+}
+
+// Languages returns a badge with the share of the top most used languages
+// among public snippets
+func (s *BadgeService) Languages(ctx context.Context, top int) (*models.Badge, error) {
+	counts, err := s.stats.PublicLanguageCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	badge := &models.Badge{Label: "languages", Message: "none", Color: badgeColors["lightgrey"]}
+	total := 0
+	for _, c := range counts {
+		total += c.Count
+	}
+	if total == 0 {
+		return badge, nil
+	}
+
+	parts := make([]string, 0, top)
+	for i, c := range counts {
+		if i == top {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d%%", c.Language, int(math.Round(float64(c.Count)*100/float64(total)))))
+	}
+	badge.Message = strings.Join(parts, " · ")
+	badge.Color = badgeColors["blue"]
+	return badge, nil
+}
+
+// BadgeColor resolves a shields.io colour name or a hex colour (with or
+// without #) to a hex colour
+func BadgeColor(color string) (string, bool) {
+	if hex, ok := badgeColors[strings.ToLower(color)]; ok {
+		return hex, true
+	}
+	if !hexColor.MatchString(color) {
+		return "", false
+	}
+	return "#" + strings.TrimPrefix(color, "#"), true
+}
+
+// RenderBadge draws a badge as a flat shields.io-style SVG
+func RenderBadge(badge *models.Badge) []byte {
+	labelWidth := textWidth(badge.Label) + 10
+	messageWidth := textWidth(badge.Message) + 10
+	width := labelWidth + messageWidth
+	label := html.EscapeString(badge.Label)
+	message := html.EscapeString(badge.Message)
+	title := label + ": " + message
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`, width, title)
+	fmt.Fprintf(&b, `<title>%s</title>`, title)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, html.EscapeString(badge.Color), width)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, text := range []struct {
+		x       float64
+		content string
+	}{{float64(labelWidth) / 2, label}, {float64(labelWidth) + float64(messageWidth)/2, message}} {
+		fmt.Fprintf(&b, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`, text.x, text.content, text.x, text.content)
+	}
+	b.WriteString(`</g></svg>`)
+	return []byte(b.String())
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana. It only
+// needs to be close enough for the text to fit its box.
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("iljI.,:;'|!· ", r):
+			width += 3.5
+		case strings.ContainsRune("frt()[]", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrBurnLinkNotFound is returned for an unknown, expired or already
+// opened burn link
+var ErrBurnLinkNotFound = errors.New("burn link not found")
+
+// BurnLinkService shares snippets through links that work exactly once.
+// Opening a link deletes it, and the snippet too when the link asks for it.
+type BurnLinkService struct {
+	repo     *repository.BurnLinkRepository
+	snippets *SnippetService
+	logger   *slog.Logger
+}
+
+// NewBurnLinkService creates a new burn link service
+func NewBurnLinkService(repo *repository.BurnLinkRepository, snippets *SnippetService, logger *slog.Logger) *BurnLinkService {
+	return &BurnLinkService{repo: repo, snippets: snippets, logger: logger}
+}
+
+// Create makes a new link to a snippet. The returned link carries its
+// token, which is not stored and cannot be retrieved again.
+func (s *BurnLinkService) Create(ctx context.Context, snippetID string, input *models.BurnLinkInput) (*models.BurnLink, error) {
+	if errs := validation.ValidateBurnLinkInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+
+	snippet, err := s.snippets.GetByID(ctx, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	if snippet.RequiresCheckout {
+		return nil, ErrSnippetNotShareable
+	}
+
+	expiresAt := time.Now().UTC().AddDate(0, 0, input.ExpiresInDays)
+	link, err := s.repo.Create(ctx, snippet.ID, input.DeleteSnippet, &expiresAt)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to create burn link", "snippet_id", snippet.ID, "error", err)
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "burn link created", "snippet_id", snippet.ID, "link_id", link.ID, "delete_snippet", link.DeleteSnippet)
+	return link, nil
+}
+
+// List returns a snippet's unopened links
+func (s *BurnLinkService) List(ctx context.Context, snippetID string) ([]models.BurnLink, error) {
+	if _, err := s.snippets.GetByID(ctx, snippetID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListBySnippet(ctx, snippetID)
+}
+
+// Revoke deletes one of a snippet's links before it is opened
+func (s *BurnLinkService) Revoke(ctx context.Context, snippetID string, id int64) error {
+	err := s.repo.Delete(ctx, snippetID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrBurnLinkNotFound
+	}
+	return err
+}
+
+// Reveal opens a link, returning its snippet and burning the link. Of two
+// concurrent opens only one gets the snippet.
+func (s *BurnLinkService) Reveal(ctx context.Context, token string) (*models.Snippet, error) {
+	link, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrBurnLinkNotFound
+	}
+
+	snippet, err := s.snippets.GetByID(ctx, link.SnippetID)
+	if errors.Is(err, ErrSnippetNotFound) || (err == nil && snippet.RequiresCheckout) {
+		return nil, ErrBurnLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Mask before using up the link, so a failure leaves it unopened
+	if err := s.snippets.Redact(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.repo.Consume(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrBurnLinkNotFound
+	}
+
+	if link.DeleteSnippet {
+		if err := s.snippets.Delete(ctx, snippet.ID); err != nil && !errors.Is(err, ErrSnippetNotFound) {
+			s.logger.ErrorContext(ctx, "failed to delete burned snippet", "snippet_id", snippet.ID, "error", err)
+			return nil, err
+		}
+	}
+
+	s.logger.InfoContext(ctx, "burn link opened", "snippet_id", snippet.ID, "link_id", link.ID, "snippet_deleted", link.DeleteSnippet)
+	return snippet, nil
+}
+
+// CleanupExpired removes links that expired unopened
+func (s *BurnLinkService) CleanupExpired(ctx context.Context) error {
+	_, err := s.repo.DeleteExpired(ctx)
+	return err
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxFilenameRunes bounds file names derived from titles. At up to four
+// bytes a character this stays well inside the 255-byte limit of common
+// file systems.
+const maxFilenameRunes = 50
+
+// invalidFilenameChars are replaced in file names derived from titles
+const invalidFilenameChars = `/\:*?"<>|`
+
+const zeroWidthJoiner = '\u200d'
+
+// sanitizeFilename turns a title into a file name. Characters that are
+// invalid on common file systems and control characters become
+// underscores, bidi controls are dropped so a name cannot display
+// differently from what it is, and the result is NFC-normalized (titles
+// typed on macOS arrive decomposed) and cut to maxFilenameRunes without
+// splitting a character from its combining marks or an emoji sequence.
+func sanitizeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(name) {
+		switch {
+		case unicode.Is(unicode.Bidi_Control, r):
+			continue
+		case strings.ContainsRune(invalidFilenameChars, r) || unicode.IsControl(r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(truncateRunes(b.String(), maxFilenameRunes))
+}
+
+// uniqueFilename returns base+ext, adding a counter when the name is
+// already in used. Names compare case-insensitively, as they do on macOS
+// and Windows file systems, and an empty base becomes "Untitled".
+func uniqueFilename(base, ext string, used map[string]bool) string {
+	if base == "" {
+		base = "Untitled"
+	}
+
+	name := base + ext
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// truncateRunes cuts s to at most n runes, backing off so that a base
+// character keeps its combining marks, variation selectors and emoji
+// modifiers, joined emoji stay whole and flags keep both halves
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	cut := n
+	for cut > 0 && (extendsPrevious(runes[cut]) || runes[cut-1] == zeroWidthJoiner) {
+		cut--
+	}
+	// Regional indicators pair up into flags; an odd run before the cut
+	// means it falls inside a flag
+	if cut > 0 && isRegionalIndicator(runes[cut]) {
+		pairs := 0
+		for i := cut - 1; i >= 0 && isRegionalIndicator(runes[i]); i-- {
+			pairs++
+		}
+		if pairs%2 == 1 {
+			cut--
+		}
+	}
+	return string(runes[:cut])
+}
+
+// extendsPrevious reports whether r renders as part of the character before it
+func extendsPrevious(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // Emoji skin tone modifiers
+		(r >= 0xe0020 && r <= 0xe007f) // Tag characters of subdivision flags
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// splitExt splits a file name into its base and extension, keeping names
+// such as ".env" whole
+func splitExt(name string) (string, string) {
+	ext := path.Ext(name)
+	if ext == name {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
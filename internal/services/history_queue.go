@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+)
+
+// historyQueue writes history entries after the request that made the
+// change has been answered. Entries for one snippet are written in order
+// by a single worker, while different snippets are written in parallel.
+// Workers are tracked by the lifecycle manager, so shutdown waits for the
+// queue to drain before the database is closed.
+type historyQueue struct {
+	mu      sync.Mutex
+	pending map[string]*historyBatch
+}
+
+// historyBatch holds the writes waiting for one snippet
+type historyBatch struct {
+	writes []historyWrite
+	done   chan struct{} // Closed once the batch is drained
+}
+
+// historyWrite is a queued write and the context it runs with
+type historyWrite struct {
+	ctx   context.Context
+	write func(ctx context.Context) error
+}
+
+// enqueue queues write for snippetID. Without a lifecycle manager the
+// write runs in the caller and its error is returned; otherwise the write
+// must log its own failures. The write gets ctx without its cancellation, so it outlives
+// the request while keeping its values for logging.
+func (q *historyQueue) enqueue(ctx context.Context, lc *lifecycle.Manager, snippetID string, write func(ctx context.Context) error) error {
+	if lc == nil {
+		return write(ctx)
+	}
+
+	queued := historyWrite{ctx: context.WithoutCancel(ctx), write: write}
+
+	q.mu.Lock()
+	if q.pending == nil {
+		q.pending = make(map[string]*historyBatch)
+	}
+	if batch, ok := q.pending[snippetID]; ok {
+		// The snippet's worker picks it up after the writes ahead of it
+		batch.writes = append(batch.writes, queued)
+		q.mu.Unlock()
+		return nil
+	}
+	batch := &historyBatch{writes: []historyWrite{queued}, done: make(chan struct{})}
+	q.pending[snippetID] = batch
+	q.mu.Unlock()
+
+	err := lc.Go("history-writer", func(context.Context) error {
+		q.drain(snippetID, batch)
+		return nil
+	})
+	if err != nil {
+		// Shutting down: write in the caller rather than lose the entry
+		q.drain(snippetID, batch)
+	}
+	return nil
+}
+
+// drain runs a batch's writes in order until none are left
+func (q *historyQueue) drain(snippetID string, batch *historyBatch) {
+	for {
+		q.mu.Lock()
+		if len(batch.writes) == 0 {
+			delete(q.pending, snippetID)
+			close(batch.done)
+			q.mu.Unlock()
+			return
+		}
+		next := batch.writes[0]
+		batch.writes = batch.writes[1:]
+		q.mu.Unlock()
+
+		_ = next.write(next.ctx)
+	}
+}
+
+// flush waits until the queued writes for snippetID are done or ctx ends,
+// so reads see every change made before them
+func (q *historyQueue) flush(ctx context.Context, snippetID string) {
+	q.mu.Lock()
+	batch := q.pending[snippetID]
+	q.mu.Unlock()
+	if batch == nil {
+		return
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+	}
+}
+
+// flushAll waits until every queued write is done or ctx ends
+func (q *historyQueue) flushAll(ctx context.Context) {
+	q.mu.Lock()
+	batches := make([]*historyBatch, 0, len(q.pending))
+	for _, batch := range q.pending {
+		batches = append(batches, batch)
+	}
+	q.mu.Unlock()
+
+	for _, batch := range batches {
+		select {
+		case <-batch.done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
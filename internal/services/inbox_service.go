@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrInboxItemNotFound is returned for an unknown inbox item ID
+var ErrInboxItemNotFound = errors.New("inbox item not found")
+
+// maxInboxTitleRunes bounds titles derived from the first line of an item
+const maxInboxTitleRunes = 80
+
+// InboxService collects untitled text from clipboard integrations. The
+// inbox is capped: adding beyond the limit drops the oldest items.
+type InboxService struct {
+	repo     *repository.InboxRepository
+	snippets *SnippetService
+	logger   *slog.Logger
+	maxItems int
+}
+
+// NewInboxService creates a new inbox service
+func NewInboxService(repo *repository.InboxRepository, snippets *SnippetService, logger *slog.Logger) *InboxService {
+	return &InboxService{
+		repo:     repo,
+		snippets: snippets,
+		logger:   logger,
+		maxItems: 100,
+	}
+}
+
+// WithMaxItems sets how many items the inbox keeps (0 keeps them all)
+func (s *InboxService) WithMaxItems(n int) *InboxService {
+	s.maxItems = n
+	return s
+}
+
+// Add stores text in the inbox
+func (s *InboxService) Add(ctx context.Context, content, source string) (*models.InboxItem, error) {
+	item := &models.InboxItem{Content: content, Source: source}
+	if errs := validation.ValidateInboxItem(item); errs.HasErrors() {
+		return nil, errs
+	}
+	return s.repo.Create(ctx, item, s.maxItems)
+}
+
+// List retrieves inbox items, newest first
+func (s *InboxService) List(ctx context.Context) ([]models.InboxItem, error) {
+	return s.repo.List(ctx)
+}
+
+// Delete discards an inbox item
+func (s *InboxService) Delete(ctx context.Context, id int64) error {
+	found, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrInboxItemNotFound
+	}
+	return nil
+}
+
+// Promote turns an inbox item into a snippet and removes it from the inbox.
+// Without a title the first non-blank line of the text is used.
+func (s *InboxService) Promote(ctx context.Context, id int64, input *models.InboxPromoteInput) (*models.Snippet, error) {
+	item, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrInboxItemNotFound
+	}
+
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		title = inboxTitle(item)
+	}
+	language := input.Language
+	if language == "" {
+		language = "plaintext"
+	}
+
+	snippet, err := s.snippets.Create(ctx, &models.SnippetInput{
+		Title:    title,
+		Content:  item.Content,
+		Language: language,
+		Tags:     input.Tags,
+		FolderID: input.FolderID,
+		IsPublic: input.IsPublic,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.WarnContext(ctx, "failed to remove promoted inbox item", "id", id, "snippet_id", snippet.ID, "error", err)
+	}
+	return snippet, nil
+}
+
+// inboxTitle derives a title from the first non-blank line of an item,
+// falling back to the time it arrived
+func inboxTitle(item *models.InboxItem) string {
+	for line := range strings.Lines(item.Content) {
+		if line = strings.TrimSpace(line); line != "" {
+			return truncateRunes(line, maxInboxTitleRunes)
+		}
+	}
+	return "Inbox " + item.CreatedAt.UTC().Format(time.DateTime)
+}
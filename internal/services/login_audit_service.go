@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// LoginAuditService records login attempts and raises alerts on suspicious activity
+type LoginAuditService struct {
+	repo             *repository.LoginEventRepository
+	notifier         notify.Notifier
+	lifecycle        *lifecycle.Manager
+	logger           *slog.Logger
+	failureThreshold int
+	failureWindow    time.Duration
+	alertNewIP       bool
+	retention        time.Duration
+}
+
+// NewLoginAuditService creates a new login audit service
+func NewLoginAuditService(repo *repository.LoginEventRepository, logger *slog.Logger) *LoginAuditService {
+	return &LoginAuditService{
+		repo:             repo,
+		logger:           logger,
+		failureThreshold: 5,
+		failureWindow:    15 * time.Minute,
+		retention:        90 * 24 * time.Hour,
+	}
+}
+
+// WithNotifier sets the notifier used for alerts
+func (s *LoginAuditService) WithNotifier(n notify.Notifier) *LoginAuditService {
+	s.notifier = n
+	return s
+}
+
+// WithLifecycle sets the lifecycle manager used for sending alerts
+func (s *LoginAuditService) WithLifecycle(lc *lifecycle.Manager) *LoginAuditService {
+	s.lifecycle = lc
+	return s
+}
+
+// WithFailureAlert alerts once threshold failures from one IP occur within window (0 disables)
+func (s *LoginAuditService) WithFailureAlert(threshold int, window time.Duration) *LoginAuditService {
+	s.failureThreshold = threshold
+	s.failureWindow = window
+	return s
+}
+
+// WithNewIPAlert enables alerts for successful logins from previously unseen IPs
+func (s *LoginAuditService) WithNewIPAlert(enabled bool) *LoginAuditService {
+	s.alertNewIP = enabled
+	return s
+}
+
+// WithRetention sets how long login events are kept
+func (s *LoginAuditService) WithRetention(retention time.Duration) *LoginAuditService {
+	s.retention = retention
+	return s
+}
+
+// Record stores a login attempt and evaluates alert rules.
+// Failures are logged rather than returned so auditing never blocks a login.
+func (s *LoginAuditService) Record(ctx context.Context, success bool, ip, userAgent, reason string) {
+	event, err := s.repo.Create(ctx, &models.LoginEvent{
+		Success:   success,
+		IPAddress: ip,
+		UserAgent: userAgent,
+		Reason:    reason,
+	})
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to record login event", "ip", ip, "error", err)
+		return
+	}
+
+	if s.notifier == nil {
+		return
+	}
+
+	if success {
+		s.checkNewIP(ctx, event)
+	} else {
+		s.checkFailures(ctx, event)
+	}
+}
+
+// List retrieves recorded login events
+func (s *LoginAuditService) List(ctx context.Context, filter models.LoginEventFilter) ([]models.LoginEvent, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Prune deletes login events older than the retention period
+func (s *LoginAuditService) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	deleted, err := s.repo.DeleteOlderThan(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.logger.InfoContext(ctx, "pruned login events", "count", deleted)
+	}
+	return nil
+}
+
+func (s *LoginAuditService) checkFailures(ctx context.Context, event *models.LoginEvent) {
+	if s.failureThreshold <= 0 {
+		return
+	}
+
+	count, err := s.repo.CountFailuresSince(ctx, event.IPAddress, event.CreatedAt.Add(-s.failureWindow))
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to count login failures", "ip", event.IPAddress, "error", err)
+		return
+	}
+
+	// Alert when the threshold is crossed, not on every attempt after it
+	if count != s.failureThreshold {
+		return
+	}
+
+	s.send(notify.Event{
+		Type:    notify.EventLoginFailures,
+		Title:   "Repeated failed logins",
+		Message: fmt.Sprintf("%d failed login attempts from %s within %s", count, event.IPAddress, s.failureWindow),
+		Fields: map[string]string{
+			"ip":         event.IPAddress,
+			"user_agent": event.UserAgent,
+			"failures":   strconv.Itoa(count),
+			"window":     s.failureWindow.String(),
+		},
+		Time: event.CreatedAt,
+	})
+}
+
+func (s *LoginAuditService) checkNewIP(ctx context.Context, event *models.LoginEvent) {
+	if !s.alertNewIP {
+		return
+	}
+
+	fromIP, err := s.repo.CountSuccesses(ctx, event.IPAddress, event.ID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to check login history", "ip", event.IPAddress, "error", err)
+		return
+	}
+	if fromIP > 0 {
+		return
+	}
+
+	// The very first login has no history to compare against
+	total, err := s.repo.CountSuccesses(ctx, "", event.ID)
+	if err != nil || total == 0 {
+		return
+	}
+
+	s.send(notify.Event{
+		Type:    notify.EventLoginNewIP,
+		Title:   "Login from new IP address",
+		Message: fmt.Sprintf("Successful login from previously unseen IP %s", event.IPAddress),
+		Fields: map[string]string{
+			"ip":         event.IPAddress,
+			"user_agent": event.UserAgent,
+		},
+		Time: event.CreatedAt,
+	})
+}
+
+func (s *LoginAuditService) send(event notify.Event) {
+	runBackground(s.lifecycle, s.logger, "login-alert", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		if err := s.notifier.Notify(ctx, event); err != nil {
+			return fmt.Errorf("failed to send %s alert: %w", event.Type, err)
+		}
+		s.logger.InfoContext(ctx, "security alert sent", "type", event.Type, "ip", event.Fields["ip"])
+		return nil
+	})
+}
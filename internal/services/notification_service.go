@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// ErrNotificationNotFound is returned for an unknown notification ID
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationService stores events for the in-app notification center. It
+// is a notify.Notifier, so it sits next to the webhook and email channels.
+type NotificationService struct {
+	repo      *repository.NotificationRepository
+	logger    *slog.Logger
+	retention time.Duration
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(repo *repository.NotificationRepository, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:      repo,
+		logger:    logger,
+		retention: 30 * 24 * time.Hour,
+	}
+}
+
+// WithRetention sets how long notifications are kept (0 keeps them forever)
+func (s *NotificationService) WithRetention(retention time.Duration) *NotificationService {
+	s.retention = retention
+	return s
+}
+
+// Notify stores an event as an unread notification
+func (s *NotificationService) Notify(ctx context.Context, event notify.Event) error {
+	_, err := s.repo.Create(ctx, &models.Notification{
+		Type:      event.Type,
+		Title:     event.Title,
+		Message:   event.Message,
+		Fields:    event.Fields,
+		CreatedAt: event.Time,
+	})
+	return err
+}
+
+// List retrieves notifications, newest first
+func (s *NotificationService) List(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// CountUnread counts notifications that have not been read
+func (s *NotificationService) CountUnread(ctx context.Context) (int, error) {
+	return s.repo.CountUnread(ctx)
+}
+
+// MarkRead marks a notification as read and returns it
+func (s *NotificationService) MarkRead(ctx context.Context, id int64) (*models.Notification, error) {
+	if err := s.repo.MarkRead(ctx, id); err != nil {
+		return nil, err
+	}
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, ErrNotificationNotFound
+	}
+	return n, nil
+}
+
+// MarkAllRead marks every notification as read and returns how many were unread
+func (s *NotificationService) MarkAllRead(ctx context.Context) (int64, error) {
+	return s.repo.MarkAllRead(ctx)
+}
+
+// Prune deletes notifications older than the retention period
+func (s *NotificationService) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	deleted, err := s.repo.DeleteOlderThan(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.logger.InfoContext(ctx, "pruned notifications", "count", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// Quick-open match scoring: every matched character scores, more so at the
+// start of a word or right after the previous match, and skipped
+// characters cost a little
+const (
+	fuzzyMatch       = 16
+	fuzzyConsecutive = 16
+	fuzzyBoundary    = 24
+	fuzzyGap         = 2
+	fuzzyLeading     = 1  // Per character before the first match
+	fuzzyMaxLeading  = 15 // Cap on the leading penalty
+	fuzzySubstring   = 40 // The query appears as is
+	fuzzyPrefix      = 40 // The text starts with the query
+	fuzzyFilename    = 10 // Filename matches rank below equal title matches
+	frecencyBoost    = 20 // Points per tenfold frecency
+)
+
+// QuickOpenService matches snippet titles and filenames for quick
+// navigation, ranking matches by how well they match and by frecency
+type QuickOpenService struct {
+	index *repository.QuickOpenIndex
+}
+
+// NewQuickOpenService creates a quick-open service over index
+func NewQuickOpenService(index *repository.QuickOpenIndex) *QuickOpenService {
+	return &QuickOpenService{index: index}
+}
+
+// Search returns up to limit snippets whose title or a filename contains
+// the query's characters in order, best first. Spaces in the query are
+// ignored. An empty query returns the most used and then most recently
+// updated snippets.
+func (s *QuickOpenService) Search(ctx context.Context, query string, limit int) ([]models.QuickOpenResult, error) {
+	entries, err := s.index.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := lowerRunes(strings.Join(strings.Fields(query), ""))
+	type ranked struct {
+		result models.QuickOpenResult
+		entry  *models.QuickOpenEntry
+	}
+	matches := make([]ranked, 0, min(len(entries), 256))
+	for i := range entries {
+		entry := &entries[i]
+		result := models.QuickOpenResult{ID: entry.ID, Title: entry.Title, Language: entry.Language}
+
+		if len(pattern) > 0 {
+			score, positions, ok := fuzzyScore(entry.Title, pattern)
+			for _, filename := range entry.Filenames {
+				fileScore, filePositions, fileOK := fuzzyScore(filename, pattern)
+				if fileOK && (!ok || fileScore-fuzzyFilename > score) {
+					score, positions, ok = fileScore-fuzzyFilename, filePositions, true
+					result.Filename = filename
+				}
+			}
+			if !ok {
+				continue
+			}
+			result.Score = float64(score)
+			result.Matches = positions
+		}
+		if entry.Frecency > 0 {
+			result.Score += frecencyBoost * math.Log10(1+float64(entry.Frecency))
+		}
+		result.Score = math.Round(result.Score*100) / 100
+		matches = append(matches, ranked{result: result, entry: entry})
+	}
+
+	slices.SortFunc(matches, func(a, b ranked) int {
+		switch {
+		case a.result.Score != b.result.Score:
+			if a.result.Score > b.result.Score {
+				return -1
+			}
+			return 1
+		case !a.entry.UpdatedAt.Equal(b.entry.UpdatedAt):
+			return b.entry.UpdatedAt.Compare(a.entry.UpdatedAt)
+		}
+		return strings.Compare(a.entry.ID, b.entry.ID)
+	})
+
+	results := make([]models.QuickOpenResult, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		results = append(results, m.result)
+	}
+	return results, nil
+}
+
+// fuzzyScore matches pattern (lowered with lowerRunes) against text as a subsequence,
+// returning the score of the best alignment and the rune offsets it
+// matched. Each occurrence of the first pattern character is tried as a
+// start, matching the rest greedily from there.
+func fuzzyScore(text string, pattern []rune) (int, []int, bool) {
+	runes := []rune(text)
+	lower := lowerRunes(text)
+	if len(pattern) > len(lower) {
+		return 0, nil, false
+	}
+
+	best, found := 0, false
+	var bestPositions []int
+	positions := make([]int, len(pattern))
+	for start := range lower {
+		if lower[start] != pattern[0] || len(lower)-start < len(pattern) {
+			continue
+		}
+
+		positions[0] = start
+		j := 1
+		for i := start + 1; i < len(lower) && j < len(pattern); i++ {
+			if lower[i] == pattern[j] {
+				positions[j] = i
+				j++
+			}
+		}
+		if j < len(pattern) {
+			// No later start can match either
+			break
+		}
+
+		score := min(start*fuzzyLeading, fuzzyMaxLeading) * -1
+		for k, pos := range positions {
+			score += fuzzyMatch
+			if isWordStart(runes, pos) {
+				score += fuzzyBoundary
+			}
+			if k > 0 {
+				if gap := pos - positions[k-1] - 1; gap == 0 {
+					score += fuzzyConsecutive
+				} else {
+					score -= gap * fuzzyGap
+				}
+			}
+		}
+		if !found || score > best {
+			best, found = score, true
+			bestPositions = slices.Clone(positions)
+		}
+	}
+	if !found {
+		return 0, nil, false
+	}
+
+	needle := string(pattern)
+	if strings.HasPrefix(string(lower), needle) {
+		best += fuzzyPrefix + fuzzySubstring
+	} else if strings.Contains(string(lower), needle) {
+		best += fuzzySubstring
+	}
+	return best, bestPositions, true
+}
+
+// isWordStart reports whether the rune at i starts a word: the first rune,
+// one after a separator, or an upper-case rune after a lower-case one
+func isWordStart(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := runes[i-1], runes[i]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(cur) && unicode.IsLower(prev)
+}
+
+// lowerRunes lowers s rune by rune, so offsets match the original
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrRedactionRuleNotFound is returned for an unknown redaction rule ID
+var ErrRedactionRuleNotFound = errors.New("redaction rule not found")
+
+// redaction is an enabled redaction rule, compiled
+type redaction struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// RedactionService manages redaction rules and masks what they match in
+// snippets on their way out: public views, share links and exports. The
+// enabled rules are compiled once and reloaded after every change.
+type RedactionService struct {
+	repo   *repository.RedactionRepository
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	rules  []redaction
+	loaded bool
+}
+
+// NewRedactionService creates a new redaction service
+func NewRedactionService(repo *repository.RedactionRepository, logger *slog.Logger) *RedactionService {
+	return &RedactionService{repo: repo, logger: logger}
+}
+
+// List returns all redaction rules in the order they apply
+func (s *RedactionService) List(ctx context.Context) ([]models.RedactionRule, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns a redaction rule
+func (s *RedactionService) Get(ctx context.Context, id int64) (*models.RedactionRule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRedactionRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create adds a redaction rule after checking its pattern
+func (s *RedactionService) Create(ctx context.Context, input *models.RedactionRuleInput) (*models.RedactionRule, error) {
+	if errs := validation.ValidateRedactionRuleInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	rule, err := s.repo.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	s.reload()
+	s.logger.InfoContext(ctx, "redaction rule created", "id", rule.ID, "name", rule.Name)
+	return rule, nil
+}
+
+// Update replaces a redaction rule
+func (s *RedactionService) Update(ctx context.Context, id int64, input *models.RedactionRuleInput) (*models.RedactionRule, error) {
+	if errs := validation.ValidateRedactionRuleInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	rule, err := s.repo.Update(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRedactionRuleNotFound
+	}
+	s.reload()
+	s.logger.InfoContext(ctx, "redaction rule updated", "id", rule.ID, "name", rule.Name)
+	return rule, nil
+}
+
+// Delete removes a redaction rule
+func (s *RedactionService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRedactionRuleNotFound
+		}
+		return err
+	}
+	s.reload()
+	s.logger.InfoContext(ctx, "redaction rule deleted", "id", id)
+	return nil
+}
+
+// Preview applies the enabled rules to a text, so they can be tried
+// without publishing anything
+func (s *RedactionService) Preview(ctx context.Context, content string) (*models.RedactionPreview, error) {
+	rules, err := s.enabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	preview := &models.RedactionPreview{Content: content}
+	for _, rule := range rules {
+		preview.Matches += len(rule.re.FindAllStringIndex(preview.Content, -1))
+		preview.Content = rule.re.ReplaceAllString(preview.Content, rule.replacement)
+	}
+	return preview, nil
+}
+
+// Redact masks the enabled rules' matches in the title, description,
+// content and files of snippets in place. Callers pass snippets they are
+// about to hand out, never ones that are saved back.
+func (s *RedactionService) Redact(ctx context.Context, snippets ...*models.Snippet) error {
+	rules, err := s.enabled(ctx)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	redact := func(text string) string {
+		for _, rule := range rules {
+			text = rule.re.ReplaceAllString(text, rule.replacement)
+		}
+		return text
+	}
+	for _, snippet := range snippets {
+		snippet.Title = redact(snippet.Title)
+		snippet.Description = redact(snippet.Description)
+		snippet.Content = redact(snippet.Content)
+		for i := range snippet.Files {
+			snippet.Files[i].Content = redact(snippet.Files[i].Content)
+		}
+	}
+	return nil
+}
+
+// enabled returns the compiled enabled rules, loading them when needed.
+// Failing to load them is an error rather than an empty list, so nothing
+// is shared unmasked.
+func (s *RedactionService) enabled(ctx context.Context) ([]redaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return s.rules, nil
+	}
+
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load redaction rules: %w", err)
+	}
+	var rules []redaction
+	for _, rule := range stored {
+		if !rule.Enabled {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			// Patterns are checked when saved, so this only happens to
+			// rules written to the database by hand
+			return nil, fmt.Errorf("redaction rule %d: %w", rule.ID, err)
+		}
+		rules = append(rules, redaction{re: re, replacement: rule.Replacement})
+	}
+	s.rules = rules
+	s.loaded = true
+	return rules, nil
+}
+
+// reload drops the compiled rules, so the next use loads them again
+func (s *RedactionService) reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+	s.loaded = false
+}
+
+// WithRedactions masks snippets with the redaction rules wherever they are
+// shared or exported
+func (s *SnippetService) WithRedactions(redactions *RedactionService) *SnippetService {
+	s.redactions = redactions
+	return s
+}
+
+// Redact masks snippets with the redaction rules, if any are set up
+func (s *SnippetService) Redact(ctx context.Context, snippets ...*models.Snippet) error {
+	if s.redactions == nil {
+		return nil
+	}
+	return s.redactions.Redact(ctx, snippets...)
+}
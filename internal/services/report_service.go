@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrReportNotFound is returned for an unknown report ID
+var ErrReportNotFound = errors.New("report not found")
+
+// reportChallengeTTL is how long a report challenge can be solved and used
+const reportChallengeTTL = 10 * time.Minute
+
+// ReportService takes abuse reports about public snippets from visitors and
+// resolves them for moderators. Reports can be required to carry a solved
+// proof-of-work challenge, which costs a browser a moment but makes
+// flooding the queue expensive.
+type ReportService struct {
+	repo          *repository.ReportRepository
+	snippets      *SnippetService
+	notifier      notify.Notifier
+	lifecycle     *lifecycle.Manager
+	logger        *slog.Logger
+	challengeBits int
+	challengeKey  []byte
+
+	mu   sync.Mutex
+	used map[string]time.Time // Solved challenges, by salt, until they expire
+}
+
+// NewReportService creates a new report service
+func NewReportService(repo *repository.ReportRepository, snippets *SnippetService, logger *slog.Logger) *ReportService {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &ReportService{
+		repo:         repo,
+		snippets:     snippets,
+		logger:       logger,
+		challengeKey: key,
+		used:         map[string]time.Time{},
+	}
+}
+
+// WithNotifier sets the notifier told about new reports
+func (s *ReportService) WithNotifier(n notify.Notifier) *ReportService {
+	s.notifier = n
+	return s
+}
+
+// WithLifecycle sets the lifecycle manager used for sending notifications
+func (s *ReportService) WithLifecycle(lc *lifecycle.Manager) *ReportService {
+	s.lifecycle = lc
+	return s
+}
+
+// WithChallenge requires reports to carry a solved challenge of the given
+// difficulty in bits (0 disables it)
+func (s *ReportService) WithChallenge(bits int) *ReportService {
+	s.challengeBits = bits
+	return s
+}
+
+// Challenge returns a new challenge for a reporter to solve, or nil when
+// reports do not need one
+func (s *ReportService) Challenge() *models.ReportChallenge {
+	if s.challengeBits <= 0 {
+		return nil
+	}
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	challenge := &models.ReportChallenge{
+		Salt:      hex.EncodeToString(salt),
+		Bits:      s.challengeBits,
+		ExpiresAt: time.Now().UTC().Add(reportChallengeTTL).Truncate(time.Second),
+	}
+	challenge.Signature = s.signChallenge(challenge)
+	return challenge
+}
+
+func (s *ReportService) signChallenge(c *models.ReportChallenge) string {
+	mac := hmac.New(sha256.New, s.challengeKey)
+	fmt.Fprintf(mac, "%s|%d|%d", c.Salt, c.Bits, c.ExpiresAt.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkChallenge reports whether a challenge was issued here, is solved and
+// has not been used before, marking it used
+func (s *ReportService) checkChallenge(c *models.ReportChallenge) bool {
+	if c == nil || c.Bits < s.challengeBits || time.Now().After(c.ExpiresAt) {
+		return false
+	}
+	if !hmac.Equal([]byte(c.Signature), []byte(s.signChallenge(c))) {
+		return false
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(c.Salt+c.Nonce))) < c.Bits {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for salt, expires := range s.used {
+		if now.After(expires) {
+			delete(s.used, salt)
+		}
+	}
+	if _, ok := s.used[c.Salt]; ok {
+		return false
+	}
+	s.used[c.Salt] = c.ExpiresAt
+	return true
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
+
+// Report records a visitor's report about a public snippet, found by ID or
+// slug. A second open report from the same address is accepted but not
+// stored again.
+func (s *ReportService) Report(ctx context.Context, idOrSlug, reporterIP string, input *models.ReportInput) error {
+	if errs := validation.ValidateReportInput(input); errs.HasErrors() {
+		return errs
+	}
+	if s.challengeBits > 0 && !s.checkChallenge(input.Challenge) {
+		return validation.ValidationErrors{{Field: "challenge", Code: validation.CodeReportChallengeFailed, Message: "Challenge is missing, expired or not solved"}}
+	}
+
+	snippet, err := s.snippets.GetByID(ctx, idOrSlug)
+	if errors.Is(err, ErrSnippetNotFound) && validation.IsValidSlug(idOrSlug) {
+		snippet, err = s.snippets.GetBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		return err
+	}
+	if !snippet.IsPublic {
+		return ErrSnippetNotFound
+	}
+
+	duplicate, err := s.repo.HasOpenReport(ctx, snippet.ID, reporterIP)
+	if err != nil || duplicate {
+		return err
+	}
+
+	report, err := s.repo.Create(ctx, &models.SnippetReport{
+		SnippetID:  snippet.ID,
+		Reason:     input.Reason,
+		Details:    input.Details,
+		ReporterIP: reporterIP,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to create report", "snippet_id", snippet.ID, "error", err)
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "snippet reported", "snippet_id", snippet.ID, "report_id", report.ID, "reason", report.Reason)
+	s.notifyReported(report)
+	return nil
+}
+
+// notifyReported tells moderators about a new report
+func (s *ReportService) notifyReported(report *models.SnippetReport) {
+	if s.notifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Type:    notify.EventSnippetReported,
+		Title:   "Snippet reported",
+		Message: fmt.Sprintf("Public snippet %q was reported as %s", report.SnippetTitle, report.Reason),
+		Fields: map[string]string{
+			"report_id":  strconv.FormatInt(report.ID, 10),
+			"snippet_id": report.SnippetID,
+			"title":      report.SnippetTitle,
+			"reason":     report.Reason,
+		},
+		Time: time.Now().UTC(),
+	}
+	runBackground(s.lifecycle, s.logger, "report-notify", func(ctx context.Context) error {
+		return s.notifier.Notify(ctx, event)
+	})
+}
+
+// List retrieves reports for the moderation queue
+func (s *ReportService) List(ctx context.Context, filter models.ReportFilter) ([]models.SnippetReport, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Unpublish makes the snippet a report is about private and closes every
+// open report about it
+func (s *ReportService) Unpublish(ctx context.Context, id int64) (*models.SnippetReport, error) {
+	report, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.snippets.Unpublish(ctx, report.SnippetID); err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, report, models.ReportUnpublished)
+}
+
+// Dismiss closes every open report about the snippet a report is about,
+// leaving the snippet public
+func (s *ReportService) Dismiss(ctx context.Context, id int64) (*models.SnippetReport, error) {
+	report, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, report, models.ReportDismissed)
+}
+
+func (s *ReportService) get(ctx context.Context, id int64) (*models.SnippetReport, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+func (s *ReportService) resolve(ctx context.Context, report *models.SnippetReport, status string) (*models.SnippetReport, error) {
+	actor := auth.ActorFromContext(ctx)
+	closed, err := s.repo.ResolveSnippet(ctx, report.SnippetID, status, actor.Name)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "reports resolved", "snippet_id", report.SnippetID, "status", status, "count", closed)
+	return s.get(ctx, report.ID)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/lua"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrRuleNotFound is returned for an unknown rule ID
+var ErrRuleNotFound = errors.New("rule not found")
+
+// Limits on a single rule run
+const (
+	ruleTimeout   = time.Second
+	ruleStepLimit = 200000
+	ruleMaxOutput = 4096 // Bytes of log() output kept per run
+)
+
+// SaveHook inspects snippets before they are saved and may change their
+// input. A returned error stops the save.
+type SaveHook interface {
+	BeforeSave(ctx context.Context, event string, existing *models.Snippet, input *models.SnippetInput) error
+}
+
+// RuleService manages automation rules and runs them as the snippet
+// service's save hook. Rules are Lua scripts run in order before a snippet
+// is created or updated; they can add and remove tags or reject the save.
+// A rule that fails is logged and skipped, so a broken script cannot stop
+// people from saving.
+type RuleService struct {
+	repo   *repository.RuleRepository
+	logger *slog.Logger
+}
+
+// NewRuleService creates a new rule service
+func NewRuleService(repo *repository.RuleRepository, logger *slog.Logger) *RuleService {
+	return &RuleService{repo: repo, logger: logger}
+}
+
+// List returns all rules in the order they run
+func (s *RuleService) List(ctx context.Context) ([]models.Rule, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns a rule
+func (s *RuleService) Get(ctx context.Context, id int64) (*models.Rule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create adds a rule after checking its script compiles
+func (s *RuleService) Create(ctx context.Context, input *models.RuleInput) (*models.Rule, error) {
+	if errs := validation.ValidateRuleInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	rule, err := s.repo.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "rule created", "id", rule.ID, "name", rule.Name)
+	return rule, nil
+}
+
+// Update replaces a rule
+func (s *RuleService) Update(ctx context.Context, id int64, input *models.RuleInput) (*models.Rule, error) {
+	if errs := validation.ValidateRuleInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	rule, err := s.repo.Update(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRuleNotFound
+	}
+	s.logger.InfoContext(ctx, "rule updated", "id", rule.ID, "name", rule.Name)
+	return rule, nil
+}
+
+// Delete removes a rule and its run log
+func (s *RuleService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRuleNotFound
+		}
+		return err
+	}
+	s.logger.InfoContext(ctx, "rule deleted", "id", id)
+	return nil
+}
+
+// Runs returns a rule's latest runs, newest first
+func (s *RuleService) Runs(ctx context.Context, id int64, limit int) ([]models.RuleRun, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRuns(ctx, id, limit)
+}
+
+// BeforeSave runs the enabled rules for an event against a snippet's
+// input. existing is the stored snippet, with its tags, on update and nil
+// on create. Tag changes are written to input.Tags; a rejection is
+// returned as a validation error.
+func (s *RuleService) BeforeSave(ctx context.Context, event string, existing *models.Snippet, input *models.SnippetInput) error {
+	rules, err := s.repo.List(ctx)
+	if err != nil {
+		// Rules cannot be loaded: save without them rather than not at all
+		s.logger.ErrorContext(ctx, "failed to load rules", "error", err)
+		return nil
+	}
+
+	tags := input.Tags
+	if tags == nil && existing != nil {
+		for _, tag := range existing.Tags {
+			tags = append(tags, tag.Name)
+		}
+	}
+	original := slices.Clone(tags)
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled || !slices.Contains(rule.Events, event) {
+			continue
+		}
+
+		run := s.run(ctx, rule, event, existing, input, &tags)
+		if err := s.repo.AddRun(ctx, run); err != nil {
+			s.logger.WarnContext(ctx, "failed to record rule run", "rule", rule.ID, "error", err)
+		}
+		switch run.Status {
+		case models.RuleRunRejected:
+			s.logger.InfoContext(ctx, "rule rejected snippet save", "rule", rule.ID, "snippet", run.SnippetID, "reason", run.Message)
+			return validation.ValidationErrors{{
+				Field:   "rules",
+				Code:    validation.CodeRuleRejected,
+				Message: run.Message,
+				Params:  map[string]any{"rule": rule.Name},
+			}}
+		case models.RuleRunError:
+			s.logger.WarnContext(ctx, "rule failed", "rule", rule.ID, "error", run.Message)
+		}
+	}
+
+	if !slices.Equal(tags, original) {
+		input.Tags = tags
+	}
+	return nil
+}
+
+// run executes one rule. tags is the snippet's tag list, which the rule
+// may change.
+func (s *RuleService) run(ctx context.Context, rule *models.Rule, event string, existing *models.Snippet, input *models.SnippetInput, tags *[]string) *models.RuleRun {
+	start := time.Now()
+	run := &models.RuleRun{RuleID: rule.ID, SnippetTitle: input.Title, Event: event, Status: models.RuleRunOK}
+	if existing != nil {
+		run.SnippetID = existing.ID
+	} else {
+		run.SnippetID = input.ID
+	}
+
+	var actions []string
+	var output strings.Builder
+	rejected := ""
+	working := slices.Clone(*tags)
+
+	state := lua.NewState().WithStepLimit(ruleStepLimit)
+	state.SetGlobal("event", event)
+	state.SetGlobal("snippet", snippetTable(run.SnippetID, input, working))
+	state.Register("has_tag", func(args []lua.Value) ([]lua.Value, error) {
+		name, err := tagArg(args, "has_tag")
+		if err != nil {
+			return nil, err
+		}
+		return []lua.Value{tagIndex(working, name) >= 0}, nil
+	})
+	state.Register("add_tag", func(args []lua.Value) ([]lua.Value, error) {
+		name, err := tagArg(args, "add_tag")
+		if err != nil {
+			return nil, err
+		}
+		if tagIndex(working, name) < 0 {
+			working = append(working, name)
+			actions = append(actions, "added tag "+name)
+		}
+		return nil, nil
+	})
+	state.Register("remove_tag", func(args []lua.Value) ([]lua.Value, error) {
+		name, err := tagArg(args, "remove_tag")
+		if err != nil {
+			return nil, err
+		}
+		if i := tagIndex(working, name); i >= 0 {
+			working = slices.Delete(working, i, i+1)
+			actions = append(actions, "removed tag "+name)
+		}
+		return nil, nil
+	})
+	state.Register("contains", func(args []lua.Value) ([]lua.Value, error) {
+		text, ok := args0(args).(string)
+		if !ok || text == "" {
+			return nil, errors.New("bad argument #1 to 'contains' (non-empty string expected)")
+		}
+		return []lua.Value{snippetContains(input, text)}, nil
+	})
+	state.Register("reject", func(args []lua.Value) ([]lua.Value, error) {
+		rejected = strings.TrimSpace(lua.ToString(args0(args)))
+		if args0(args) == nil || rejected == "" {
+			rejected = "Rejected by rule " + rule.Name
+		}
+		// Stop the script; the rejection stands even if it is caught with pcall
+		return nil, &lua.Error{Message: "rejected: " + rejected}
+	})
+	state.Register("log", func(args []lua.Value) ([]lua.Value, error) {
+		parts := make([]string, len(args))
+		for i, a := range args {
+			parts[i] = lua.ToString(a)
+		}
+		if output.Len() < ruleMaxOutput {
+			output.WriteString(strings.Join(parts, " "))
+			output.WriteByte('\n')
+		}
+		return nil, nil
+	})
+
+	runCtx, cancel := context.WithTimeout(ctx, ruleTimeout)
+	defer cancel()
+	chunk, err := lua.Compile(rule.Script)
+	if err == nil {
+		_, err = state.Run(runCtx, chunk)
+	}
+
+	switch {
+	case rejected != "":
+		run.Status = models.RuleRunRejected
+		run.Message = rejected
+	case err != nil:
+		run.Status = models.RuleRunError
+		run.Message = err.Error()
+	default:
+		// Only a rule that finished changes the snippet
+		*tags = working
+		run.Actions = strings.Join(actions, ", ")
+	}
+
+	out := output.String()
+	if len(out) > ruleMaxOutput {
+		// Cut on a rune boundary so the output stays valid UTF-8
+		cut := ruleMaxOutput
+		for cut > 0 && !utf8.RuneStart(out[cut]) {
+			cut--
+		}
+		out = out[:cut]
+	}
+	run.Output = strings.TrimSuffix(out, "\n")
+	run.DurationMS = time.Since(start).Milliseconds()
+	return run
+}
+
+// snippetTable exposes a snippet's input to scripts as the snippet global
+func snippetTable(id string, input *models.SnippetInput, tags []string) *lua.Table {
+	t := lua.NewTable()
+	_ = t.Set("id", id)
+	_ = t.Set("title", input.Title)
+	_ = t.Set("description", input.Description)
+	_ = t.Set("content", input.Content)
+	_ = t.Set("language", input.Language)
+	_ = t.Set("is_public", input.IsPublic)
+	_ = t.Set("tags", lua.List(tags))
+	if input.SourceURL != nil {
+		_ = t.Set("source_url", *input.SourceURL)
+	}
+
+	files := lua.NewTable()
+	for _, f := range input.Files {
+		file := lua.NewTable()
+		_ = file.Set("filename", f.Filename)
+		_ = file.Set("language", f.Language)
+		_ = file.Set("content", f.Content)
+		files.Append(file)
+	}
+	_ = t.Set("files", files)
+	return t
+}
+
+// snippetContains reports whether the title, description, content or any
+// file of a snippet contains text, ignoring case
+func snippetContains(input *models.SnippetInput, text string) bool {
+	text = strings.ToLower(text)
+	fields := []string{input.Title, input.Description, input.Content}
+	for _, f := range input.Files {
+		fields = append(fields, f.Filename, f.Content)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagIndex finds a tag by name, ignoring case
+func tagIndex(tags []string, name string) int {
+	return slices.IndexFunc(tags, func(t string) bool { return strings.EqualFold(t, name) })
+}
+
+func tagArg(args []lua.Value, fn string) (string, error) {
+	name, ok := args0(args).(string)
+	if name = strings.TrimSpace(name); !ok || name == "" {
+		return "", fmt.Errorf("bad argument #1 to '%s' (tag name expected)", fn)
+	}
+	return name, nil
+}
+
+// args0 returns the first argument of a script call, or nil
+func args0(args []lua.Value) lua.Value {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[0]
+}
@@ -2,24 +2,34 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"path"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
 	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
 	"github.com/MohamedElashri/snipo/internal/storage"
 )
 
 // S3SyncService handles S3 backup operations
 type S3SyncService struct {
-	storage   *storage.S3Storage
+	storage   storage.ObjectStore
 	backupSvc *BackupService
+	notifier  notify.Notifier
+	lifecycle *lifecycle.Manager
+	retention int
 	logger    *slog.Logger
 }
 
 // NewS3SyncService creates a new S3 sync service
-func NewS3SyncService(storage *storage.S3Storage, backupSvc *BackupService, logger *slog.Logger) *S3SyncService {
+func NewS3SyncService(storage storage.ObjectStore, backupSvc *BackupService, logger *slog.Logger) *S3SyncService {
 	return &S3SyncService{
 		storage:   storage,
 		backupSvc: backupSvc,
@@ -27,17 +37,88 @@ func NewS3SyncService(storage *storage.S3Storage, backupSvc *BackupService, logg
 	}
 }
 
-// SyncToS3 uploads a backup to S3
-func (s *S3SyncService) SyncToS3(ctx context.Context, opts models.ExportOptions) (*models.S3SyncResult, error) {
-	result := &models.S3SyncResult{
-		StartedAt: time.Now().UTC(),
+// WithNotifier sets the notifier used for backup reports
+func (s *S3SyncService) WithNotifier(n notify.Notifier) *S3SyncService {
+	s.notifier = n
+	return s
+}
+
+// WithLifecycle sets the lifecycle manager used for sending reports
+func (s *S3SyncService) WithLifecycle(lc *lifecycle.Manager) *S3SyncService {
+	s.lifecycle = lc
+	return s
+}
+
+// WithRetention keeps only the newest n backups, deleting older ones after
+// each successful sync. Zero keeps every backup.
+func (s *S3SyncService) WithRetention(n int) *S3SyncService {
+	s.retention = n
+	return s
+}
+
+// report sends a backup success or failure notification in the background
+func (s *S3SyncService) report(operation, key string, started time.Time, opErr error, fields map[string]string) {
+	if s.notifier == nil {
+		return
 	}
 
-	// Create backup
-	content, filename, err := s.backupSvc.Export(ctx, opts)
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	fields["operation"] = operation
+	fields["key"] = key
+	fields["bucket"] = s.storage.GetBucket()
+	fields["duration"] = time.Since(started).Round(time.Millisecond).String()
+
+	event := notify.Event{
+		Type:    notify.EventBackupSucceeded,
+		Title:   fmt.Sprintf("Backup %s succeeded", operation),
+		Message: fmt.Sprintf("S3 backup %s completed for %s", operation, key),
+		Fields:  fields,
+		Time:    time.Now().UTC(),
+	}
+	if opErr != nil {
+		event.Type = notify.EventBackupFailed
+		event.Title = fmt.Sprintf("Backup %s failed", operation)
+		event.Message = fmt.Sprintf("S3 backup %s failed: %v", operation, opErr)
+		fields["error"] = opErr.Error()
+	}
+
+	runBackground(s.lifecycle, s.logger, "backup-report", func(ctx context.Context) error {
+		return s.notifier.Notify(ctx, event)
+	})
+}
+
+// S3SyncJob is the job kind of background S3 uploads
+const S3SyncJob = "s3_sync"
+
+// S3SyncPayload is the queued input of an S3SyncJob. It holds the finished
+// (possibly encrypted) backup, so retries upload the same bytes and can
+// resume an interrupted multipart upload.
+type S3SyncPayload struct {
+	Key         string            `json:"key"`
+	ContentType string            `json:"content_type"`
+	Content     []byte            `json:"content"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// manifestMetadata is the object metadata key holding a backup's manifest
+const manifestMetadata = "manifest"
+
+// manifestLookups bounds concurrent metadata requests when listing backups
+const manifestLookups = 8
+
+// PrepareSync creates the backup to upload
+func (s *S3SyncService) PrepareSync(ctx context.Context, opts models.ExportOptions) (*S3SyncPayload, error) {
+	content, filename, manifest, err := s.backupSvc.ExportWithManifest(ctx, opts)
 	if err != nil {
+		s.report("sync", "", time.Now(), err, nil)
 		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
 
 	// Determine content type
 	contentType := "application/json"
@@ -48,35 +129,125 @@ func (s *S3SyncService) SyncToS3(ctx context.Context, opts models.ExportOptions)
 		contentType = "application/octet-stream"
 	}
 
-	// Upload to S3
-	key := "backups/" + filename
-	if err := s.storage.Upload(ctx, key, content, contentType); err != nil {
+	return &S3SyncPayload{
+		Key:         s.storage.ExpandPrefix(time.Now()) + filename,
+		ContentType: contentType,
+		Content:     content,
+		Metadata:    map[string]string{manifestMetadata: string(encoded)},
+	}, nil
+}
+
+// SyncToS3 uploads a backup to S3
+func (s *S3SyncService) SyncToS3(ctx context.Context, opts models.ExportOptions) (*models.S3SyncResult, error) {
+	payload, err := s.PrepareSync(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.upload(ctx, payload, nil)
+}
+
+// RunSyncJob uploads a prepared backup; it is the handler for S3SyncJob.
+// Progress is reported in bytes.
+func (s *S3SyncService) RunSyncJob(ctx context.Context, payload json.RawMessage, p *jobs.Progress) (any, error) {
+	var input S3SyncPayload
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, fmt.Errorf("invalid sync payload: %w", err)
+	}
+
+	var reported int64
+	return s.upload(ctx, &input, func(uploaded, total int64) {
+		if reported == 0 {
+			p.SetTotal(int(total))
+		}
+		p.Advance(int(uploaded - reported))
+		reported = uploaded
+	})
+}
+
+// upload stores a prepared backup, in parts when it is large
+func (s *S3SyncService) upload(ctx context.Context, payload *S3SyncPayload, progress func(uploaded, total int64)) (*models.S3SyncResult, error) {
+	result := &models.S3SyncResult{
+		Key:       payload.Key,
+		StartedAt: time.Now().UTC(),
+	}
+
+	uploaded, err := s.storage.UploadLarge(ctx, payload.Key, payload.Content, payload.ContentType, storage.UploadOptions{
+		Progress: progress,
+		Metadata: payload.Metadata,
+	})
+	if err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("failed to upload: %v", err))
 		result.FinishedAt = time.Now().UTC()
+		s.report("sync", payload.Key, result.StartedAt, err, nil)
 		return result, fmt.Errorf("failed to upload backup: %w", err)
 	}
 
 	result.Uploaded = 1
+	result.Size = uploaded.Size
+	result.ETag = uploaded.ETag
+	result.SHA256 = uploaded.SHA256
 	result.FinishedAt = time.Now().UTC()
 
-	s.logger.Info("backup synced to S3",
-		"key", key,
-		"size", len(content),
+	s.logger.InfoContext(ctx, "backup synced to S3",
+		"key", payload.Key,
+		"size", uploaded.Size,
+		"parts", uploaded.Parts,
+		"resumed_parts", uploaded.ResumedParts,
 		"duration", result.FinishedAt.Sub(result.StartedAt),
 	)
+	s.report("sync", payload.Key, result.StartedAt, nil, map[string]string{"size": fmt.Sprintf("%d", uploaded.Size)})
+
+	if err := s.rotate(ctx); err != nil {
+		// The backup itself succeeded; old ones are pruned after the next sync
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to prune old backups: %v", err))
+		s.logger.WarnContext(ctx, "failed to prune old backups", "error", err)
+	}
 
 	return result, nil
 }
 
+// rotate deletes the oldest backups beyond the retention count
+func (s *S3SyncService) rotate(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	backups, err := s.ListBackups(ctx)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= s.retention {
+		return nil
+	}
+
+	// Newest first; backup names are timestamped, so they break ties
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].LastModified.Equal(backups[j].LastModified) {
+			return backups[i].LastModified.After(backups[j].LastModified)
+		}
+		return backups[i].Key > backups[j].Key
+	})
+	for _, b := range backups[s.retention:] {
+		if err := s.DeleteBackup(ctx, b.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ListBackups returns all backups stored in S3
 func (s *S3SyncService) ListBackups(ctx context.Context) ([]models.S3BackupInfo, error) {
-	objects, err := s.storage.List(ctx, "backups/")
+	objects, err := s.storage.List(ctx, s.storage.ListPrefix())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list backups: %w", err)
 	}
 
 	var backups []models.S3BackupInfo
 	for _, obj := range objects {
+		// The prefix may be shared with other objects in the bucket
+		if !strings.HasPrefix(path.Base(obj.Key), "snipo-backup-") {
+			continue
+		}
 		backups = append(backups, models.S3BackupInfo{
 			Key:          obj.Key,
 			Size:         obj.Size,
@@ -84,9 +255,79 @@ func (s *S3SyncService) ListBackups(ctx context.Context) ([]models.S3BackupInfo,
 		})
 	}
 
+	s.loadManifests(ctx, backups)
 	return backups, nil
 }
 
+// loadManifests fills in the manifests of listed backups. Backups whose
+// metadata cannot be read are listed without one.
+func (s *S3SyncService) loadManifests(ctx context.Context, backups []models.S3BackupInfo) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, manifestLookups)
+	for i := range backups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(b *models.S3BackupInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			manifest, err := s.storedManifest(ctx, b.Key)
+			if err != nil {
+				s.logger.DebugContext(ctx, "failed to read backup manifest", "key", b.Key, "error", err)
+				return
+			}
+			b.Manifest = manifest
+		}(&backups[i])
+	}
+	wg.Wait()
+}
+
+// storedManifest reads the manifest uploaded with a backup; it returns nil
+// for backups uploaded without one
+func (s *S3SyncService) storedManifest(ctx context.Context, key string) (*models.BackupManifest, error) {
+	metadata, err := s.storage.Metadata(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	encoded, ok := metadata[manifestMetadata]
+	if !ok {
+		return nil, nil
+	}
+
+	var manifest models.BackupManifest
+	if err := json.Unmarshal([]byte(encoded), &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// PreviewBackup describes a stored backup and warns about anything to check
+// before restoring it. Backups uploaded without a manifest are downloaded
+// and inspected instead.
+func (s *S3SyncService) PreviewBackup(ctx context.Context, key string) (*models.S3BackupPreview, error) {
+	manifest, err := s.storedManifest(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+
+	if manifest == nil {
+		content, err := s.storage.Download(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download backup: %w", err)
+		}
+		manifest, err = s.backupSvc.Inspect(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect backup: %w", err)
+		}
+	}
+
+	return &models.S3BackupPreview{
+		Key:      key,
+		Manifest: manifest,
+		Warnings: s.backupSvc.ManifestWarnings(manifest),
+	}, nil
+}
+
 // RestoreFromS3 downloads and restores a backup from S3
 func (s *S3SyncService) RestoreFromS3(ctx context.Context, key string, opts models.ImportOptions) (*models.S3RestoreResult, error) {
 	result := &models.S3RestoreResult{
@@ -98,28 +339,37 @@ func (s *S3SyncService) RestoreFromS3(ctx context.Context, key string, opts mode
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("failed to download: %v", err))
 		result.FinishedAt = time.Now().UTC()
+		s.report("restore", key, result.StartedAt, err, nil)
 		return result, fmt.Errorf("failed to download backup: %w", err)
 	}
 
-	// Import backup
+	// Import backup, saving the current data first
+	opts.Snapshot = true
 	importResult, err := s.backupSvc.Import(ctx, content, opts)
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("failed to import: %v", err))
 		result.FinishedAt = time.Now().UTC()
+		s.report("restore", key, result.StartedAt, err, nil)
 		return result, fmt.Errorf("failed to import backup: %w", err)
 	}
 
 	result.Restored = importResult.SnippetsImported + importResult.TagsImported + importResult.FoldersImported
+	result.SafetySnapshot = importResult.SafetySnapshot
 	result.Errors = append(result.Errors, importResult.Errors...)
 	result.FinishedAt = time.Now().UTC()
 
-	s.logger.Info("backup restored from S3",
+	s.logger.InfoContext(ctx, "backup restored from S3",
 		"key", key,
 		"snippets", importResult.SnippetsImported,
 		"tags", importResult.TagsImported,
 		"folders", importResult.FoldersImported,
 		"duration", result.FinishedAt.Sub(result.StartedAt),
 	)
+	s.report("restore", key, result.StartedAt, nil, map[string]string{
+		"snippets": fmt.Sprintf("%d", importResult.SnippetsImported),
+		"tags":     fmt.Sprintf("%d", importResult.TagsImported),
+		"folders":  fmt.Sprintf("%d", importResult.FoldersImported),
+	})
 
 	return result, nil
 }
@@ -130,10 +380,15 @@ func (s *S3SyncService) DeleteBackup(ctx context.Context, key string) error {
 		return fmt.Errorf("failed to delete backup: %w", err)
 	}
 
-	s.logger.Info("backup deleted from S3", "key", key)
+	s.logger.InfoContext(ctx, "backup deleted from S3", "key", key)
 	return nil
 }
 
+// Provider returns the object storage provider backups go to
+func (s *S3SyncService) Provider() string {
+	return s.storage.Provider()
+}
+
 // GetBackupURL generates a presigned URL for downloading a backup
 func (s *S3SyncService) GetBackupURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
 	url, err := s.storage.GetPresignedURL(ctx, key, expiry)
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// analyticsDay is the layout of the day buckets
+const analyticsDay = "2006-01-02"
+
+// ShareAnalyticsService counts views of public snippets per day, referring
+// host and client class, so authors can see how their shares are used.
+// Viewer IPs are only kept when enabled, to count unique visitors.
+type ShareAnalyticsService struct {
+	repo      *repository.ShareAnalyticsRepository
+	snippets  *SnippetService
+	lifecycle *lifecycle.Manager
+	logger    *slog.Logger
+	storeIPs  bool
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewShareAnalyticsService creates a new share analytics service
+func NewShareAnalyticsService(repo *repository.ShareAnalyticsRepository, snippets *SnippetService, logger *slog.Logger) *ShareAnalyticsService {
+	return &ShareAnalyticsService{
+		repo:      repo,
+		snippets:  snippets,
+		logger:    logger,
+		retention: 365 * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// WithLifecycle sets the lifecycle manager used for recording views
+func (s *ShareAnalyticsService) WithLifecycle(lc *lifecycle.Manager) *ShareAnalyticsService {
+	s.lifecycle = lc
+	return s
+}
+
+// WithVisitorIPs stores viewer IPs to count unique visitors
+func (s *ShareAnalyticsService) WithVisitorIPs(enabled bool) *ShareAnalyticsService {
+	s.storeIPs = enabled
+	return s
+}
+
+// WithRetention sets how long view counts are kept (0 keeps them forever)
+func (s *ShareAnalyticsService) WithRetention(retention time.Duration) *ShareAnalyticsService {
+	s.retention = retention
+	return s
+}
+
+// Record counts a view of a public snippet in the background. referrer is
+// the Referer URL, if any; views referred by host itself count as direct.
+func (s *ShareAnalyticsService) Record(snippetID, referrer, userAgent, ip, host string) {
+	view := models.ShareView{
+		SnippetID: snippetID,
+		Referrer:  referrerHost(referrer, host),
+		Client:    ClassifyClient(userAgent),
+	}
+	if s.storeIPs {
+		view.IP = ip
+	}
+	day := s.now().UTC().Format(analyticsDay)
+
+	runBackgroundWrite(s.lifecycle, s.logger, "share-analytics", func(ctx context.Context) error {
+		if err := s.repo.Record(ctx, day, view); err != nil {
+			return fmt.Errorf("snippet %s: %w", snippetID, err)
+		}
+		return nil
+	})
+}
+
+// Get summarizes a snippet's views over the last days days, today included
+func (s *ShareAnalyticsService) Get(ctx context.Context, snippetID string, days int) (*models.ShareAnalytics, error) {
+	snippet, err := s.snippets.GetByID(ctx, snippetID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.now().UTC()
+	from := today.AddDate(0, 0, 1-days).Format(analyticsDay)
+	result := &models.ShareAnalytics{
+		SnippetID:  snippet.ID,
+		ViewCount:  snippet.ViewCount,
+		From:       from,
+		To:         today.Format(analyticsDay),
+		Days:       make([]models.ShareAnalyticsDay, 0, days),
+		VisitorIPs: s.storeIPs,
+	}
+
+	daily, err := s.repo.DailyViews(ctx, snippet.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	for i := days - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(analyticsDay)
+		counts := daily[date]
+		day := models.ShareAnalyticsDay{Date: date, Views: counts[0]}
+		if s.storeIPs {
+			visitors := counts[1]
+			day.Visitors = &visitors
+		}
+		result.Views += counts[0]
+		result.Days = append(result.Days, day)
+	}
+
+	if s.storeIPs {
+		visitors, err := s.repo.Visitors(ctx, snippet.ID, from)
+		if err != nil {
+			return nil, err
+		}
+		result.Visitors = &visitors
+	}
+	if result.Referrers, err = s.repo.ViewsBy(ctx, snippet.ID, from, "referrer"); err != nil {
+		return nil, err
+	}
+	if result.Clients, err = s.repo.ViewsBy(ctx, snippet.ID, from, "client"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Prune deletes counts older than the retention period, and all stored
+// IPs once storing them is turned off. It is run periodically.
+func (s *ShareAnalyticsService) Prune(ctx context.Context) error {
+	if !s.storeIPs {
+		if err := s.repo.DeleteVisitors(ctx); err != nil {
+			return err
+		}
+	}
+	if s.retention <= 0 {
+		return nil
+	}
+
+	pruned, err := s.repo.Prune(ctx, s.now().UTC().Add(-s.retention).Format(analyticsDay))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.logger.InfoContext(ctx, "pruned share analytics", "count", pruned)
+	}
+	return nil
+}
+
+// referrerHost reduces a Referer URL to its host. Empty, unparsable and
+// same-site referrers give "".
+func referrerHost(referrer, host string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	self := strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(self); err == nil {
+		self = h
+	}
+	name := strings.ToLower(u.Hostname())
+	if name == self {
+		return ""
+	}
+	return strings.TrimPrefix(name, "www.")
+}
+
+// clientPatterns map lower-cased User-Agent substrings to client classes,
+// checked in order: bots first, as many announce themselves as browsers
+var clientPatterns = []struct {
+	pattern, client string
+}{
+	{"bot", models.ClientBot},
+	{"crawler", models.ClientBot},
+	{"spider", models.ClientBot},
+	{"slurp", models.ClientBot},
+	{"preview", models.ClientBot},
+	{"facebookexternalhit", models.ClientBot},
+	{"embedly", models.ClientBot},
+	{"curl/", models.ClientCLI},
+	{"wget/", models.ClientCLI},
+	{"httpie/", models.ClientCLI},
+	{"python-requests", models.ClientCLI},
+	{"python-urllib", models.ClientCLI},
+	{"go-http-client", models.ClientCLI},
+	{"powershell", models.ClientCLI},
+	{"aiohttp", models.ClientCLI},
+	{"node-fetch", models.ClientCLI},
+	{"mozilla/", models.ClientBrowser},
+}
+
+// ClassifyClient sorts a User-Agent into a client class
+func ClassifyClient(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	for _, p := range clientPatterns {
+		if strings.Contains(ua, p.pattern) {
+			return p.client
+		}
+	}
+	return models.ClientOther
+}
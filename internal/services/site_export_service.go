@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/sitegen"
+)
+
+// SiteExportService renders public snippets into a static HTML site
+type SiteExportService struct {
+	snippetSvc *SnippetService
+	assets     []sitegen.Asset
+	logger     *slog.Logger
+}
+
+// NewSiteExportService creates a new site export service
+func NewSiteExportService(snippetSvc *SnippetService, logger *slog.Logger) *SiteExportService {
+	return &SiteExportService{
+		snippetSvc: snippetSvc,
+		logger:     logger,
+	}
+}
+
+// WithAssets sets extra static files (e.g. syntax highlighting) bundled into the site
+func (s *SiteExportService) WithAssets(assets []sitegen.Asset) *SiteExportService {
+	s.assets = assets
+	return s
+}
+
+// PublicSnippets returns every public, non-archived snippet with tags and
+// files, masked with the redaction rules. While review is required only
+// approved snippets are included; snippets that must be checked out never are.
+func (s *SiteExportService) PublicSnippets(ctx context.Context) ([]models.Snippet, error) {
+	isPublic, requiresCheckout := true, false
+	filter := models.SnippetFilter{
+		IsPublic:         &isPublic,
+		RequiresCheckout: &requiresCheckout,
+		Page:             1,
+		Limit:            100,
+		SortBy:           "updated_at",
+		SortOrder:        "desc",
+	}
+	if s.snippetSvc.ReviewRequired(ctx) {
+		filter.ReviewState = models.ReviewApproved
+	}
+
+	var snippets []models.Snippet
+	for {
+		list, err := s.snippetSvc.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list public snippets: %w", err)
+		}
+		for _, item := range list.Data {
+			snippet, err := s.snippetSvc.GetByID(ctx, item.ID)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to get snippet details", "id", item.ID, "error", err)
+				continue
+			}
+			if err := s.snippetSvc.Redact(ctx, snippet); err != nil {
+				return nil, err
+			}
+			snippets = append(snippets, *snippet)
+		}
+		if filter.Page >= list.Pagination.TotalPages {
+			break
+		}
+		filter.Page++
+	}
+
+	return snippets, nil
+}
+
+// Export renders the site into w
+func (s *SiteExportService) Export(ctx context.Context, w sitegen.Writer, opts models.SiteExportOptions) (*sitegen.Result, error) {
+	snippets, err := s.PublicSnippets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := sitegen.Render(sitegen.Site{
+		Title:       opts.Title,
+		Snippets:    snippets,
+		Assets:      s.assets,
+		GeneratedAt: time.Now().UTC(),
+	}, w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render site: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "static site exported", "snippets", result.Snippets, "tags", result.Tags, "files", result.Files)
+	return result, nil
+}
+
+// ExportZip renders the site into a zip archive and returns it with a filename
+func (s *SiteExportService) ExportZip(ctx context.Context, opts models.SiteExportOptions) ([]byte, string, error) {
+	buf := new(bytes.Buffer)
+	zw := sitegen.NewZipWriter(buf)
+	if _, err := s.Export(ctx, zw, opts); err != nil {
+		return nil, "", err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize zip: %w", err)
+	}
+
+	filename := fmt.Sprintf("snipo-site-%s.zip", time.Now().Format("2006-01-02-150405"))
+	return buf.Bytes(), filename, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+)
+
+// ErrSnapshotNotFound is returned when no snapshot was taken on or before
+// the requested day
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// snapshotDay is the layout of snapshot days
+const snapshotDay = "2006-01-02"
+
+// SnapshotService takes a daily manifest of the library (snippet IDs,
+// titles and content checksums), independent of per-edit history, and
+// answers what the library looked like on a day and what changed between
+// two days.
+type SnapshotService struct {
+	repo      *repository.SnapshotRepository
+	snippets  *repository.SnippetRepository
+	files     *repository.SnippetFileRepository
+	logger    *slog.Logger
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewSnapshotService creates a new snapshot service
+func NewSnapshotService(repo *repository.SnapshotRepository, snippets *repository.SnippetRepository, files *repository.SnippetFileRepository, logger *slog.Logger) *SnapshotService {
+	return &SnapshotService{
+		repo:      repo,
+		snippets:  snippets,
+		files:     files,
+		logger:    logger,
+		retention: 365 * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// WithRetention sets how long snapshots are kept (0 keeps them forever)
+func (s *SnapshotService) WithRetention(retention time.Duration) *SnapshotService {
+	s.retention = retention
+	return s
+}
+
+// Take records the library as it is now as today's snapshot, replacing
+// one already taken today
+func (s *SnapshotService) Take(ctx context.Context) (*models.LibrarySnapshot, error) {
+	snippets, err := s.snippets.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.SnapshotEntry, 0, len(snippets))
+	for _, snippet := range snippets {
+		entry := models.SnapshotEntry{ID: snippet.ID, Title: snippet.Title}
+		if snippet.Checksum != nil && *snippet.Checksum != "" {
+			entry.Checksum = *snippet.Checksum
+		} else {
+			// Written before checksums existed
+			var files []models.SnippetFile
+			if s.files != nil {
+				if files, err = s.files.GetBySnippetID(ctx, snippet.ID); err != nil {
+					return nil, err
+				}
+			}
+			entry.Checksum = ContentChecksum(snippet.Content, files)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	snapshot, err := s.repo.Save(ctx, s.today(), entries)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "library snapshot taken", "day", snapshot.Day, "snippets", snapshot.SnippetCount)
+	return snapshot, nil
+}
+
+// TakeDaily takes today's snapshot unless it was already taken. It is run
+// hourly, so a day is not missed when the instance was down at midnight.
+func (s *SnapshotService) TakeDaily(ctx context.Context) error {
+	exists, err := s.repo.Exists(ctx, s.today())
+	if err != nil || exists {
+		return err
+	}
+	_, err = s.Take(ctx)
+	return err
+}
+
+// List returns the snapshots without their manifests, newest first
+func (s *SnapshotService) List(ctx context.Context, limit int) ([]models.LibrarySnapshot, error) {
+	return s.repo.List(ctx, limit)
+}
+
+// AsOf returns the library as of day (YYYY-MM-DD): the latest snapshot
+// taken on or before it
+func (s *SnapshotService) AsOf(ctx context.Context, day string) (*models.LibrarySnapshot, error) {
+	snapshot, err := s.repo.AsOf(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+// Diff compares the library as of day from with the library as of day to
+func (s *SnapshotService) Diff(ctx context.Context, from, to string) (*models.SnapshotDiff, error) {
+	before, err := s.AsOf(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.AsOf(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffSnapshots(before, after), nil
+}
+
+// Prune deletes snapshots older than the retention period. It is run
+// periodically.
+func (s *SnapshotService) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	pruned, err := s.repo.Prune(ctx, s.now().UTC().Add(-s.retention).Format(snapshotDay))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.logger.InfoContext(ctx, "pruned library snapshots", "count", pruned)
+	}
+	return nil
+}
+
+func (s *SnapshotService) today() string {
+	return s.now().UTC().Format(snapshotDay)
+}
+
+// diffSnapshots lists the snippets added, removed and changed from before
+// to after, each ordered by ID
+func diffSnapshots(before, after *models.LibrarySnapshot) *models.SnapshotDiff {
+	diff := &models.SnapshotDiff{
+		From:    before.Day,
+		To:      after.Day,
+		Added:   []models.SnapshotEntry{},
+		Removed: []models.SnapshotEntry{},
+		Changed: []models.SnapshotChange{},
+	}
+
+	previous := make(map[string]models.SnapshotEntry, len(before.Snippets))
+	for _, entry := range before.Snippets {
+		previous[entry.ID] = entry
+	}
+	for _, entry := range after.Snippets {
+		old, ok := previous[entry.ID]
+		if !ok {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+		delete(previous, entry.ID)
+		if old.Title == entry.Title && old.Checksum == entry.Checksum {
+			continue
+		}
+		change := models.SnapshotChange{ID: entry.ID, Title: entry.Title, ContentChanged: old.Checksum != entry.Checksum}
+		if old.Title != entry.Title {
+			change.PreviousTitle = old.Title
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+	for _, entry := range before.Snippets {
+		if _, ok := previous[entry.ID]; ok {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// maxCheckoutTrail limits the check-outs listed at once
+const maxCheckoutTrail = 500
+
+var (
+	// ErrCheckoutRequired is returned when reading or changing the content
+	// of a snippet that requires a check-out the caller does not hold
+	ErrCheckoutRequired = errors.New("snippet must be checked out first")
+	// ErrCheckoutNotRequired is returned when checking out a snippet that
+	// does not require it
+	ErrCheckoutNotRequired = errors.New("snippet does not require a check-out")
+	// ErrSnippetCheckedOut is returned while someone else holds the
+	// check-out of a snippet
+	ErrSnippetCheckedOut = errors.New("snippet is checked out by someone else")
+	// ErrNotCheckedOut is returned when checking in a snippet the caller
+	// has not checked out
+	ErrNotCheckedOut = errors.New("snippet is not checked out by the caller")
+	// ErrSnippetNotShareable is returned when sharing a snippet that
+	// requires a check-out, as anonymous readers cannot check it out
+	ErrSnippetNotShareable = errors.New("snippets that require a check-out cannot be shared")
+)
+
+// WithCheckoutRepo adds check-out repository to the service
+func (s *SnippetService) WithCheckoutRepo(checkoutRepo *repository.CheckoutRepository) *SnippetService {
+	s.checkoutRepo = checkoutRepo
+	return s
+}
+
+// Checkout checks out a snippet that requires it, recording the caller and
+// the reason. Checking out again while holding it returns the held
+// check-out; while someone else holds it, it returns theirs and
+// ErrSnippetCheckedOut.
+func (s *SnippetService) Checkout(ctx context.Context, id string, input *models.CheckoutInput) (*models.SnippetCheckout, error) {
+	if errs := validation.ValidateCheckoutInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	snippet, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if snippet == nil {
+		return nil, ErrSnippetNotFound
+	}
+	if !snippet.RequiresCheckout {
+		return nil, ErrCheckoutNotRequired
+	}
+
+	actor := auth.ActorFromContext(ctx)
+	checkout, created, err := s.checkoutRepo.Checkout(ctx, &models.SnippetCheckout{
+		SnippetID:    id,
+		SnippetTitle: snippet.Title,
+		Holder:       actor.Name,
+		SourceIP:     actor.IP,
+		Reason:       input.Reason,
+	}, input.TTL)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to check out snippet", "id", id, "error", err)
+		return nil, err
+	}
+	if !created {
+		if checkout == nil {
+			// The other check-out ended in between; try again
+			return s.Checkout(ctx, id, input)
+		}
+		if checkout.Holder == actor.Name {
+			return checkout, nil
+		}
+		return checkout, ErrSnippetCheckedOut
+	}
+
+	s.logger.InfoContext(ctx, "snippet checked out", "id", id, "holder", checkout.Holder, "reason", checkout.Reason, "expires_at", checkout.ExpiresAt)
+	return checkout, nil
+}
+
+// Checkin ends the caller's check-out of a snippet
+func (s *SnippetService) Checkin(ctx context.Context, id string, input *models.CheckinInput) (*models.SnippetCheckout, error) {
+	if errs := validation.ValidateCheckinInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	checkout, err := s.checkoutRepo.Checkin(ctx, id, auth.ActorFromContext(ctx).Name, input.Note)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotCheckedOut
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to check in snippet", "id", id, "error", err)
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "snippet checked in", "id", id, "holder", checkout.Holder)
+	return checkout, nil
+}
+
+// ListCheckouts returns the audit trail of a snippet, newest first. An
+// empty id lists the check-outs of every snippet, deleted ones included.
+func (s *SnippetService) ListCheckouts(ctx context.Context, id string) ([]models.SnippetCheckout, error) {
+	if id != "" {
+		if err := s.requireSnippet(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.checkoutRepo.List(ctx, id, maxCheckoutTrail)
+}
+
+// holdsCheckout reports whether the caller may see the content of a
+// snippet, along with its active check-out if it requires one
+func (s *SnippetService) holdsCheckout(ctx context.Context, snippet *models.Snippet) (*models.SnippetCheckout, bool) {
+	if !snippet.RequiresCheckout || s.checkoutRepo == nil {
+		return nil, true
+	}
+	checkout, err := s.checkoutRepo.Active(ctx, snippet.ID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to get snippet check-out", "id", snippet.ID, "error", err)
+		return nil, false
+	}
+	return checkout, checkout != nil && checkout.Holder == auth.ActorFromContext(ctx).Name
+}
+
+// requireCheckout returns ErrCheckoutRequired unless the caller may see
+// the content of a snippet
+func (s *SnippetService) requireCheckout(ctx context.Context, snippet *models.Snippet) error {
+	if _, ok := s.holdsCheckout(ctx, snippet); !ok {
+		return ErrCheckoutRequired
+	}
+	return nil
+}
+
+// WithholdContent blanks the content and file contents of the snippets the
+// caller has not checked out, for responses that list or show snippets.
+// Snippets that require a check-out also get their active check-out.
+func (s *SnippetService) WithholdContent(ctx context.Context, snippets ...*models.Snippet) {
+	for _, snippet := range snippets {
+		checkout, ok := s.holdsCheckout(ctx, snippet)
+		snippet.Checkout = checkout
+		if ok {
+			continue
+		}
+		snippet.Content = ""
+		for i := range snippet.Files {
+			snippet.Files[i].Content = ""
+		}
+		snippet.ContentWithheld = true
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/collab"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// CollabText returns the text edited collaboratively as a document: a
+// snippet's content for fileID 0, otherwise the content of that file
+func (s *SnippetService) CollabText(ctx context.Context, snippetID string, fileID int64) (string, error) {
+	snippet, err := s.GetByID(ctx, snippetID)
+	if errors.Is(err, ErrSnippetNotFound) {
+		return "", collab.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// Check-outs are per caller, which a shared document cannot honour
+	if snippet.RequiresCheckout {
+		return "", collab.ErrNotFound
+	}
+	if fileID == 0 {
+		return snippet.Content, nil
+	}
+	for _, f := range snippet.Files {
+		if f.ID == fileID {
+			return f.Content, nil
+		}
+	}
+	return "", collab.ErrNotFound
+}
+
+// MaterializeCollab stores the text of a collaboratively edited document
+// through a regular update, so it is validated and recorded in history
+func (s *SnippetService) MaterializeCollab(ctx context.Context, snippetID string, fileID int64, text string) error {
+	snippet, err := s.GetByID(ctx, snippetID)
+	if errors.Is(err, ErrSnippetNotFound) {
+		return collab.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	input := &models.SnippetInput{
+		Title:       snippet.Title,
+		Description: snippet.Description,
+		Content:     snippet.Content,
+		Language:    snippet.Language,
+		IsPublic:    snippet.IsPublic,
+		IsArchived:  snippet.IsArchived,
+	}
+	if len(snippet.Folders) > 0 {
+		input.FolderID = &snippet.Folders[0].ID
+	}
+	if fileID == 0 {
+		input.Content = text
+	}
+
+	found := fileID == 0
+	for i, f := range snippet.Files {
+		file := models.SnippetFileInput{ID: f.ID, Filename: f.Filename, Content: f.Content, Language: f.Language}
+		// The first file and the snippet content are the same text
+		if f.ID == fileID || (i == 0 && fileID == 0) {
+			file.Content = text
+			found = true
+			if i == 0 {
+				input.Content = text
+			}
+		}
+		input.Files = append(input.Files, file)
+	}
+	if !found {
+		return collab.ErrNotFound
+	}
+
+	_, err = s.Update(auth.WithActor(ctx, auth.Actor{Name: "collab"}), snippetID, input)
+	return err
+}
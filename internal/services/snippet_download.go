@@ -0,0 +1,74 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// SnippetDownload is a snippet prepared for download as one file: the raw
+// file for single-file snippets, a ZIP archive of its files otherwise
+type SnippetDownload struct {
+	Filename    string
+	ContentType string
+	snippet     *models.Snippet
+}
+
+// Download prepares a snippet for download
+func (s *SnippetService) Download(ctx context.Context, id string) (*SnippetDownload, error) {
+	snippet, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCheckout(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	d := &SnippetDownload{ContentType: "text/plain; charset=utf-8", snippet: snippet}
+	switch len(snippet.Files) {
+	case 0:
+		d.Filename = uniqueFilename(sanitizeFilename(snippet.Title), "."+languages.Extension(snippet.Language), map[string]bool{})
+	case 1:
+		d.Filename = norm.NFC.String(snippet.Files[0].Filename)
+	default:
+		d.Filename = uniqueFilename(sanitizeFilename(snippet.Title), ".zip", map[string]bool{})
+		d.ContentType = "application/zip"
+	}
+	return d, nil
+}
+
+// Write streams the download to w. Archives are written entry by entry, so
+// nothing larger than a single file is held in memory.
+func (d *SnippetDownload) Write(w io.Writer) error {
+	switch len(d.snippet.Files) {
+	case 0:
+		_, err := io.WriteString(w, d.snippet.Content)
+		return err
+	case 1:
+		_, err := io.WriteString(w, d.snippet.Files[0].Content)
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool, len(d.snippet.Files))
+	for _, f := range d.snippet.Files {
+		base, ext := splitExt(norm.NFC.String(f.Filename))
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     uniqueFilename(base, ext, used),
+			Method:   zip.Deflate,
+			Modified: f.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.Content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
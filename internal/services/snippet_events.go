@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+)
+
+// WithEventNotifier publishes snippet create, update and delete events,
+// such as to a message broker
+func (s *SnippetService) WithEventNotifier(n notify.Notifier) *SnippetService {
+	s.eventNotifier = n
+	return s
+}
+
+// notifyLifecycle reports that a snippet was created, updated or deleted
+func (s *SnippetService) notifyLifecycle(eventType string, snippet *models.Snippet) {
+	if s.eventNotifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Type: eventType,
+		Fields: map[string]string{
+			"snippet_id": snippet.ID,
+			"title":      snippet.Title,
+		},
+		Time: time.Now().UTC(),
+	}
+	switch eventType {
+	case notify.EventSnippetCreated:
+		event.Title = "Snippet created"
+		event.Message = fmt.Sprintf("Snippet %q was created", snippet.Title)
+	case notify.EventSnippetUpdated:
+		event.Title = "Snippet updated"
+		event.Message = fmt.Sprintf("Snippet %q was updated", snippet.Title)
+	case notify.EventSnippetDeleted:
+		event.Title = "Snippet deleted"
+		event.Message = fmt.Sprintf("Snippet %q was deleted", snippet.Title)
+	}
+	if snippet.Language != "" {
+		event.Fields["language"] = snippet.Language
+	}
+
+	s.runBackground("snippet-event", func(ctx context.Context) error {
+		return s.eventNotifier.Notify(ctx, event)
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// saveMetadataHistory records a favorite, archive, tag or folder change of
+// a snippet without copying its content. The snippet is in its state after
+// the change.
+func (s *SnippetService) saveMetadataHistory(ctx context.Context, snippet *models.Snippet, changeType, details string) {
+	if !s.isHistoryEnabled(ctx) {
+		return
+	}
+
+	actor := auth.ActorFromContext(ctx)
+	entry := &models.SnippetHistory{
+		SnippetID:  snippet.ID,
+		Title:      snippet.Title,
+		Language:   snippet.Language,
+		IsFavorite: snippet.IsFavorite,
+		IsPublic:   snippet.IsPublic,
+		IsArchived: snippet.IsArchived,
+		ChangeType: changeType,
+		Details:    details,
+		ChangedBy:  actor.Name,
+		SourceIP:   actor.IP,
+	}
+	_ = s.history.enqueue(ctx, s.lifecycle, snippet.ID, func(ctx context.Context) error {
+		if _, err := s.historyRepo.CreateMetadataHistory(ctx, entry); err != nil {
+			s.logger.WarnContext(ctx, "failed to create snippet history", "id", entry.SnippetID, "changeType", changeType, "error", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// saveTagFolderHistory records the tag and folder changes an update made,
+// given the snippet's tags and folders before it. Tags are only compared
+// when the input set them.
+func (s *SnippetService) saveTagFolderHistory(ctx context.Context, snippet *models.Snippet, input *models.SnippetInput, tags []models.Tag, folders []models.Folder) {
+	if s.tagRepo != nil && input.Tags != nil && tagNames(tags) != tagNames(snippet.Tags) {
+		s.saveMetadataHistory(ctx, snippet, models.HistoryTags, tagNames(snippet.Tags))
+	}
+	if s.folderRepo != nil && folderID(folders) != folderID(snippet.Folders) {
+		s.saveMetadataHistory(ctx, snippet, models.HistoryFolder, folderName(snippet.Folders))
+	}
+}
+
+// tagNames joins the names of tags, which are sorted by name
+func tagNames(tags []models.Tag) string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// folderID returns the ID of a snippet's folder, or 0 without one
+func folderID(folders []models.Folder) int64 {
+	if len(folders) == 0 {
+		return 0
+	}
+	return folders[0].ID
+}
+
+// folderName returns the name of a snippet's folder, if any
+func folderName(folders []models.Folder) string {
+	if len(folders) == 0 {
+		return ""
+	}
+	return folders[0].Name
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// maxHistorySearched is how many history entries a search reads, newest
+// first, after narrowing them down in the database
+const maxHistorySearched = 5000
+
+// SearchHistory finds the versions of a snippet whose title, description,
+// content or files match a query, newest first, with each matching line
+// and the lines around it. Metadata changes have no content and are
+// skipped.
+func (s *SnippetService) SearchHistory(ctx context.Context, id string, opts models.HistorySearchOptions) ([]models.HistorySearchResult, error) {
+	if s.historyRepo == nil {
+		return nil, fmt.Errorf("history repository not configured")
+	}
+	re, err := historySearchRegexp(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snippet, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCheckout(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	s.history.flush(ctx, id)
+	return s.searchHistory(ctx, re, opts, models.HistoryFilter{SnippetID: id}, map[string]*models.Snippet{id: snippet})
+}
+
+// SearchAllHistory searches the history of every snippet like
+// SearchHistory, to find text edited out long ago. Snippets that require a
+// check-out are only searched while the caller holds it.
+func (s *SnippetService) SearchAllHistory(ctx context.Context, opts models.HistorySearchOptions) ([]models.HistorySearchResult, error) {
+	if s.historyRepo == nil {
+		return nil, fmt.Errorf("history repository not configured")
+	}
+	re, err := historySearchRegexp(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.history.flushAll(ctx)
+	return s.searchHistory(ctx, re, opts, models.HistoryFilter{}, map[string]*models.Snippet{})
+}
+
+// historySearchRegexp validates a search and compiles its query, defaulting
+// the number of versions returned
+func historySearchRegexp(opts *models.HistorySearchOptions) (*regexp.Regexp, error) {
+	if errs := validation.ValidateHistorySearch(opts); errs.HasErrors() {
+		return nil, errs
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	pattern := opts.Query
+	if !opts.Regex {
+		pattern = "(?i)" + regexp.QuoteMeta(pattern)
+	}
+	return regexp.Compile(pattern)
+}
+
+// searchHistory greps the entries matching filter. current holds the
+// snippets already loaded and checked, by ID; others are loaded as their
+// entries come up, and skipped when the caller may not read them.
+func (s *SnippetService) searchHistory(ctx context.Context, re *regexp.Regexp, opts models.HistorySearchOptions, filter models.HistoryFilter, current map[string]*models.Snippet) ([]models.HistorySearchResult, error) {
+	filter.From = opts.From
+	filter.To = opts.To
+	filter.Limit = maxHistorySearched
+	// The database only ignores ASCII case, so other text is matched here
+	if !opts.Regex && isASCII(opts.Query) {
+		filter.Text = opts.Query
+	}
+
+	history, err := s.historyRepo.SearchHistory(ctx, filter)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to search history", "error", err)
+		return nil, err
+	}
+
+	results := []models.HistorySearchResult{}
+	for _, entry := range history {
+		snippet, ok := current[entry.SnippetID]
+		if !ok {
+			snippet, err = s.GetByID(ctx, entry.SnippetID)
+			if err == nil {
+				err = s.requireCheckout(ctx, snippet)
+			}
+			if errors.Is(err, ErrSnippetNotFound) || errors.Is(err, ErrCheckoutRequired) {
+				snippet = nil
+			} else if err != nil {
+				return nil, err
+			}
+			current[entry.SnippetID] = snippet
+		}
+		if snippet == nil || (opts.Removed && snippetMatches(snippet, re)) {
+			continue
+		}
+
+		g := historyGrep{re: re, context: opts.Context}
+		g.search("title", "", entry.Title)
+		g.search("description", "", entry.Description)
+		g.search("content", "", entry.Content)
+		for _, file := range entry.Files {
+			g.search("file", file.Filename, file.Content)
+		}
+		if len(g.matches) == 0 {
+			continue
+		}
+
+		results = append(results, models.HistorySearchResult{
+			HistoryID:  entry.ID,
+			SnippetID:  entry.SnippetID,
+			ChangeType: entry.ChangeType,
+			ChangedBy:  entry.ChangedBy,
+			CreatedAt:  entry.CreatedAt,
+			Title:      entry.Title,
+			Matches:    g.matches,
+			Truncated:  g.truncated,
+		})
+		if len(results) >= opts.Limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// snippetMatches reports whether the current version of a snippet matches
+func snippetMatches(snippet *models.Snippet, re *regexp.Regexp) bool {
+	if re.MatchString(snippet.Title) || re.MatchString(snippet.Description) || re.MatchString(snippet.Content) {
+		return true
+	}
+	for _, file := range snippet.Files {
+		if re.MatchString(file.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// isASCII reports whether s only holds ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// historyGrep collects the matching lines of one historical version
+type historyGrep struct {
+	re        *regexp.Regexp
+	context   int
+	matches   []models.HistoryLineMatch
+	truncated bool
+}
+
+// search adds the lines of text that match, up to the per-version limit
+func (g *historyGrep) search(field, filename, text string) {
+	if text == "" || !g.re.MatchString(text) {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !g.re.MatchString(line) {
+			continue
+		}
+		if len(g.matches) >= models.MaxHistorySearchMatches {
+			g.truncated = true
+			return
+		}
+		g.matches = append(g.matches, models.HistoryLineMatch{
+			Field:    field,
+			Filename: filename,
+			Line:     i + 1,
+			Text:     line,
+			Before:   lines[max(i-g.context, 0):i],
+			After:    lines[i+1 : min(i+1+g.context, len(lines))],
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"image/color"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/highlight"
+	"github.com/MohamedElashri/snipo/internal/ogimage"
+)
+
+// CodeImageOptions are the settings of a code image
+type CodeImageOptions struct {
+	Theme       string // A name in ogimage.Themes; empty for the default
+	Width       int    // In pixels; zero for the default
+	LineNumbers bool
+}
+
+// CodeImage renders a snippet as a syntax highlighted PNG in the style of a
+// window screenshot, for chats that do not render code. Multi-file
+// snippets show their first file.
+func (s *SnippetService) CodeImage(ctx context.Context, id string, opts CodeImageOptions) ([]byte, error) {
+	snippet, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCheckout(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	theme, ok := ogimage.Themes[opts.Theme]
+	if !ok {
+		theme = ogimage.Themes[ogimage.DefaultTheme]
+	}
+	title := snippet.Title
+	if len(snippet.Files) > 0 && snippet.Files[0].Filename != "" {
+		title = snippet.Files[0].Filename
+	}
+	content, language := previewFile(snippet)
+
+	return ogimage.RenderCode(ogimage.Code{
+		Title:       title,
+		Lines:       highlightLines(theme, language, content, -1),
+		Theme:       theme,
+		Width:       opts.Width,
+		LineNumbers: opts.LineNumbers,
+	})
+}
+
+// highlightLines splits up to n lines of code (all with n < 0) into spans
+// colored by the theme, one slice per line
+func highlightLines(theme ogimage.Theme, language, content string, n int) [][]ogimage.Span {
+	content = strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if n >= 0 {
+		if parts := strings.SplitN(content, "\n", n+1); len(parts) > n {
+			content = strings.Join(parts[:n], "\n")
+		}
+	}
+
+	lines := [][]ogimage.Span{nil}
+	for _, token := range highlight.Lex(language, content) {
+		c := tokenColor(theme, token.Kind)
+		for i, part := range strings.Split(token.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				lines[len(lines)-1] = append(lines[len(lines)-1], ogimage.Span{Text: part, Color: c})
+			}
+		}
+	}
+	return lines
+}
+
+// tokenColor returns the theme's color for a kind of token
+func tokenColor(theme ogimage.Theme, kind highlight.Kind) color.RGBA {
+	switch kind {
+	case highlight.Keyword:
+		return theme.Keyword
+	case highlight.String:
+		return theme.String
+	case highlight.Comment:
+		return theme.Comment
+	case highlight.Number:
+		return theme.Number
+	default:
+		return theme.Plain
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrLinkNotFound is returned for a link the snippet does not have
+var ErrLinkNotFound = errors.New("link not found")
+
+// snippetRefPattern matches snipo://{id} URLs and share URLs (/s/{id or slug})
+var snippetRefPattern = regexp.MustCompile(`(?:snipo://|/s/)([A-Za-z0-9][A-Za-z0-9_-]*)`)
+
+// maxAutoLinks bounds the references resolved from one snippet's content
+const maxAutoLinks = 50
+
+// WithLinkRepo adds link repository to the service
+func (s *SnippetService) WithLinkRepo(linkRepo *repository.LinkRepository) *SnippetService {
+	s.linkRepo = linkRepo
+	return s
+}
+
+// Links returns the links of a snippet and its backlinks
+func (s *SnippetService) Links(ctx context.Context, id string) (*models.SnippetLinks, error) {
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.ListFrom(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	backlinks, err := s.linkRepo.ListTo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SnippetLinks{Links: links, Backlinks: backlinks}, nil
+}
+
+// AddLink links a snippet to the snippet with the input's ID or slug
+func (s *SnippetService) AddLink(ctx context.Context, id string, input *models.LinkInput) (*models.SnippetLink, error) {
+	if errs := validation.ValidateLinkInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	target, err := s.resolveRef(ctx, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, validation.ValidationErrors{{Field: "target_id", Code: validation.CodeLinkTargetNotFound, Message: "Target snippet not found"}}
+	}
+	if target.ID == id {
+		return nil, validation.ValidationErrors{{Field: "target_id", Code: validation.CodeLinkTargetIsSelf, Message: "A snippet cannot link to itself"}}
+	}
+
+	link, err := s.linkRepo.Create(ctx, id, target.ID, input.Relation)
+	if err != nil {
+		return nil, err
+	}
+	link.Title = target.Title
+	return link, nil
+}
+
+// DeleteLink removes a link of a snippet
+func (s *SnippetService) DeleteLink(ctx context.Context, id string, linkID int64) error {
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return err
+	}
+
+	deleted, err := s.linkRepo.Delete(ctx, id, linkID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// requireSnippet returns ErrSnippetNotFound unless the snippet exists
+func (s *SnippetService) requireSnippet(ctx context.Context, id string) error {
+	snippet, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if snippet == nil {
+		return ErrSnippetNotFound
+	}
+	return nil
+}
+
+// resolveRef finds a snippet by ID or slug, returning nil when neither matches
+func (s *SnippetService) resolveRef(ctx context.Context, ref string) (*models.Snippet, error) {
+	snippet, err := s.repo.GetByID(ctx, ref)
+	if err != nil || snippet != nil {
+		return snippet, err
+	}
+	return s.repo.GetBySlug(ctx, ref)
+}
+
+// syncAutoLinks replaces a snippet's auto links with the snippets its
+// content and files refer to, by URL or, in markdown, by [[Title]].
+// References to unknown snippets are ignored.
+func (s *SnippetService) syncAutoLinks(ctx context.Context, snippet *models.Snippet) {
+	if s.linkRepo == nil {
+		return
+	}
+
+	var urlRefs, titles []string
+	collect := func(content, language string) {
+		for _, m := range snippetRefPattern.FindAllStringSubmatch(content, -1) {
+			urlRefs = append(urlRefs, m[1])
+		}
+		if language == "markdown" {
+			titles = append(titles, wikiTitles(content)...)
+		}
+	}
+
+	collect(snippet.Content, snippet.Language)
+	if s.fileRepo != nil {
+		files, err := s.fileRepo.GetBySnippetID(ctx, snippet.ID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to read files for links", "id", snippet.ID, "error", err)
+			return
+		}
+		for _, file := range files {
+			collect(file.Content, file.Language)
+		}
+	}
+
+	resolved := map[string]bool{}
+	linked := map[string]bool{}
+	var targets []string
+	// key tells URL references from titles so each is looked up once
+	resolve := func(key string, find func() (*models.Snippet, error)) bool {
+		if resolved[key] || len(resolved) >= maxAutoLinks {
+			return true
+		}
+		resolved[key] = true
+
+		target, err := find()
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to resolve snippet reference", "id", snippet.ID, "ref", key, "error", err)
+			return false
+		}
+		if target != nil && target.ID != snippet.ID && !linked[target.ID] {
+			linked[target.ID] = true
+			targets = append(targets, target.ID)
+		}
+		return true
+	}
+	for _, ref := range urlRefs {
+		if !resolve("url:"+ref, func() (*models.Snippet, error) { return s.resolveRef(ctx, ref) }) {
+			return
+		}
+	}
+	for _, title := range uniqueTitles(titles) {
+		if !resolve("title:"+strings.ToLower(title), func() (*models.Snippet, error) { return s.repo.GetByTitle(ctx, title) }) {
+			return
+		}
+	}
+
+	if err := s.linkRepo.SyncAuto(ctx, snippet.ID, targets); err != nil {
+		s.logger.WarnContext(ctx, "failed to update snippet links", "id", snippet.ID, "error", err)
+	}
+}
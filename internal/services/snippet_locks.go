@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrSnippetLocked is returned when another editor holds a snippet's lock
+var ErrSnippetLocked = errors.New("snippet is locked by another editor")
+
+// WithLockRepo adds lock repository to the service
+func (s *SnippetService) WithLockRepo(lockRepo *repository.LockRepository) *SnippetService {
+	s.lockRepo = lockRepo
+	return s
+}
+
+// AcquireLock takes or renews the edit lock of a snippet. While another
+// editor holds it, it returns their lock, without its ID, and
+// ErrSnippetLocked.
+func (s *SnippetService) AcquireLock(ctx context.Context, id string, input *models.LockInput) (*models.SnippetLock, error) {
+	if errs := validation.ValidateLockInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	holder := input.Holder
+	if holder == "" {
+		holder = auth.ActorFromContext(ctx).Name
+	}
+	lock, acquired, err := s.lockRepo.Acquire(ctx, id, input.LockID, holder, input.TTL)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to acquire snippet lock", "id", id, "error", err)
+		return nil, err
+	}
+	if !acquired {
+		if lock == nil {
+			// The other lock lapsed in between; try again
+			return s.AcquireLock(ctx, id, input)
+		}
+		lock.LockID = ""
+		return lock, ErrSnippetLocked
+	}
+	return lock, nil
+}
+
+// ReleaseLock drops the edit lock of a snippet. Releasing a lock that has
+// lapsed or passed to another editor does nothing.
+func (s *SnippetService) ReleaseLock(ctx context.Context, id, lockID string) error {
+	if err := s.requireSnippet(ctx, id); err != nil {
+		return err
+	}
+	return s.lockRepo.Release(ctx, id, lockID)
+}
+
+// activeLock returns the unexpired edit lock of a snippet without its ID
+func (s *SnippetService) activeLock(ctx context.Context, id string) *models.SnippetLock {
+	if s.lockRepo == nil {
+		return nil
+	}
+	lock, err := s.lockRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to get snippet lock", "id", id, "error", err)
+		return nil
+	}
+	if lock != nil {
+		lock.LockID = ""
+	}
+	return lock
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/highlight"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/pdf"
+)
+
+// Code colors for printing on white paper
+var pdfTokenStyles = map[highlight.Kind]struct {
+	font  pdf.Font
+	color pdf.Color
+}{
+	highlight.Plain:   {pdf.Courier, pdf.Black},
+	highlight.Keyword: {pdf.CourierBold, pdf.Color{R: 0.05, G: 0.25, B: 0.6}},
+	highlight.String:  {pdf.Courier, pdf.Color{R: 0.1, G: 0.45, B: 0.1}},
+	highlight.Comment: {pdf.CourierOblique, pdf.Gray},
+	highlight.Number:  {pdf.Courier, pdf.Color{R: 0.65, G: 0.3, B: 0}},
+}
+
+// SnippetPDF is a snippet laid out for printing: a header with its
+// metadata, then each file with line numbers and syntax highlighting
+type SnippetPDF struct {
+	Filename string
+	doc      *pdf.Document
+}
+
+// PDF renders a snippet as a PDF document
+func (s *SnippetService) PDF(ctx context.Context, id string) (*SnippetPDF, error) {
+	snippet, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCheckout(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	// Footers are a single line, so long titles are shortened there
+	footer := snippet.Title
+	if short := truncateRunes(footer, 60); short != footer {
+		footer = short + "..."
+	}
+	doc := pdf.New(snippet.Title).WithFooter(footer + " (" + snippet.ID + ")")
+	doc.Paragraph(snippet.Title, pdf.HelveticaBold, 18, pdf.Black)
+	if snippet.Description != "" {
+		doc.Space(4)
+		doc.Paragraph(snippet.Description, pdf.Helvetica, 10, pdf.Black)
+	}
+	doc.Space(6)
+	for _, line := range pdfMetadata(snippet) {
+		doc.Paragraph(line, pdf.Helvetica, 9, pdf.Gray)
+	}
+
+	files := snippet.Files
+	if len(files) == 0 {
+		files = []models.SnippetFile{{Content: snippet.Content, Language: snippet.Language}}
+	}
+	for _, f := range files {
+		doc.Space(8)
+		doc.Rule()
+		if f.Filename != "" {
+			doc.Paragraph(f.Filename+"  ("+f.Language+")", pdf.HelveticaBold, 10, pdf.Black)
+			doc.Space(2)
+		}
+		writePDFCode(doc, f.Language, f.Content)
+	}
+
+	return &SnippetPDF{Filename: uniqueFilename(sanitizeFilename(snippet.Title), ".pdf", map[string]bool{}), doc: doc}, nil
+}
+
+// Write writes the PDF to w
+func (p *SnippetPDF) Write(w io.Writer) error {
+	_, err := p.doc.WriteTo(w)
+	return err
+}
+
+// pdfMetadata returns the header lines describing a snippet
+func pdfMetadata(snippet *models.Snippet) []string {
+	var lines []string
+	details := []string{"Language: " + snippet.Language}
+	if len(snippet.Tags) > 0 {
+		names := make([]string, len(snippet.Tags))
+		for i, tag := range snippet.Tags {
+			names[i] = tag.Name
+		}
+		details = append(details, "Tags: "+strings.Join(names, ", "))
+	}
+	if len(snippet.Folders) > 0 {
+		names := make([]string, len(snippet.Folders))
+		for i, folder := range snippet.Folders {
+			names[i] = folder.Name
+		}
+		details = append(details, "Folders: "+strings.Join(names, ", "))
+	}
+	lines = append(lines, strings.Join(details, "   "))
+	lines = append(lines, fmt.Sprintf("Created: %s   Updated: %s",
+		snippet.CreatedAt.UTC().Format("2006-01-02 15:04 MST"), snippet.UpdatedAt.UTC().Format("2006-01-02 15:04 MST")))
+
+	if snippet.License != nil && *snippet.License != "" {
+		lines = append(lines, "License: "+*snippet.License)
+	}
+	if snippet.Attribution != nil && *snippet.Attribution != "" {
+		lines = append(lines, "Attribution: "+*snippet.Attribution)
+	}
+	if snippet.SourceURL != nil && *snippet.SourceURL != "" {
+		lines = append(lines, "Source: "+*snippet.SourceURL)
+	}
+
+	keys := make([]string, 0, len(snippet.Metadata))
+	for key := range snippet.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+": "+snippet.Metadata[key])
+	}
+	return lines
+}
+
+// writePDFCode adds highlighted, numbered code lines
+func writePDFCode(doc *pdf.Document, language, content string) {
+	lines := [][]pdf.Span{nil}
+	for _, token := range highlight.Lex(language, strings.ReplaceAll(content, "\r\n", "\n")) {
+		style := pdfTokenStyles[token.Kind]
+		for i, part := range strings.Split(token.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				lines[len(lines)-1] = append(lines[len(lines)-1], pdf.Span{Text: part, Font: style.font, Color: style.color})
+			}
+		}
+	}
+
+	width := len(fmt.Sprint(len(lines)))
+	for i, spans := range lines {
+		doc.CodeLine(fmt.Sprintf("%*d  ", width, i+1), spans, 8.5)
+	}
+}
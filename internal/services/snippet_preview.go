@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/ogimage"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// previewDescriptionLength is the longest description shown in a link
+// preview; unfurlers cut longer ones anyway
+const previewDescriptionLength = 200
+
+// SharePreview returns the published snippet with an ID or slug, masked
+// like a public one, for the link preview of its share page. Unlike the
+// public lookups it does not count a view, as unfurlers fetch the page
+// whenever the link is posted.
+func (s *SnippetService) SharePreview(ctx context.Context, idOrSlug string) (*models.Snippet, error) {
+	snippet, err := s.repo.GetByID(ctx, idOrSlug)
+	if err == nil && snippet == nil && validation.IsValidSlug(idOrSlug) {
+		snippet, err = s.repo.GetBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snippet == nil || !s.isPublished(ctx, snippet) {
+		return nil, ErrSnippetNotFound
+	}
+
+	if s.fileRepo != nil {
+		files, _ := s.fileRepo.GetBySnippetID(ctx, snippet.ID)
+		snippet.Files = files
+	}
+	if err := s.Redact(ctx, snippet); err != nil {
+		return nil, err
+	}
+	return snippet, nil
+}
+
+// PreviewDescription is the text under the title of a link preview: the
+// snippet's description, or else its first line of code
+func PreviewDescription(snippet *models.Snippet) string {
+	description := strings.Join(strings.Fields(snippet.Description), " ")
+	if description == "" {
+		description = firstCodeLine(snippet)
+	}
+	if short := truncateRunes(description, previewDescriptionLength); short != description {
+		description = short + "…"
+	}
+	return description
+}
+
+// PreviewImage draws the link preview card of a snippet as a PNG: its
+// title, language and first lines of code, highlighted like a code image
+func PreviewImage(snippet *models.Snippet) ([]byte, error) {
+	content, language := previewFile(snippet)
+	lineCount := strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+	subtitle := fmt.Sprintf("%s · %d lines", language, lineCount)
+	if lineCount == 1 {
+		subtitle = language + " · 1 line"
+	}
+	if len(snippet.Files) > 1 {
+		subtitle += fmt.Sprintf(" · %d files", len(snippet.Files))
+	}
+
+	theme := ogimage.Themes[ogimage.DefaultTheme]
+	return ogimage.Render(ogimage.Card{
+		Title:    snippet.Title,
+		Subtitle: subtitle,
+		Code:     highlightLines(theme, language, strings.TrimLeft(content, "\r\n"), ogimage.MaxLines),
+		Footer:   "Snipo",
+		Theme:    theme,
+	})
+}
+
+// previewFile returns the content and language of the file a preview
+// shows, the first one of a multi-file snippet
+func previewFile(snippet *models.Snippet) (string, string) {
+	if len(snippet.Files) > 0 {
+		return snippet.Files[0].Content, snippet.Files[0].Language
+	}
+	return snippet.Content, snippet.Language
+}
+
+// firstCodeLine returns the first line of the previewed file that is not
+// blank
+func firstCodeLine(snippet *models.Snippet) string {
+	content, _ := previewFile(snippet)
+	for line := range strings.Lines(content) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
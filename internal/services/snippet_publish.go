@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+)
+
+// WithPublishNotifier enables notifications when scheduled snippets are published
+func (s *SnippetService) WithPublishNotifier(n notify.Notifier) *SnippetService {
+	s.publishNotifier = n
+	return s
+}
+
+// PublishDue makes snippets public whose publish time has passed. It is
+// run periodically by the scheduler.
+func (s *SnippetService) PublishDue(ctx context.Context) error {
+	published, err := s.repo.PublishDue(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range published {
+		snippet := &published[i]
+		s.logger.InfoContext(ctx, "scheduled snippet published", "id", snippet.ID)
+		s.notifyPublished(snippet)
+	}
+	return nil
+}
+
+// Unpublish makes a snippet private, as moderation of a reported snippet
+func (s *SnippetService) Unpublish(ctx context.Context, id string) (*models.Snippet, error) {
+	snippet, err := s.repo.Unpublish(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to unpublish snippet", "id", id, "error", err)
+		return nil, err
+	}
+	if snippet == nil {
+		return nil, ErrSnippetNotFound
+	}
+
+	s.logger.InfoContext(ctx, "snippet unpublished", "id", id)
+	return snippet, nil
+}
+
+// notifyPublished reports a snippet published by the scheduler
+func (s *SnippetService) notifyPublished(snippet *models.Snippet) {
+	if s.publishNotifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Type:    notify.EventSnippetPublished,
+		Title:   "Snippet published",
+		Message: fmt.Sprintf("Scheduled snippet %q is now public", snippet.Title),
+		Fields: map[string]string{
+			"snippet_id": snippet.ID,
+			"title":      snippet.Title,
+		},
+		Time: time.Now().UTC(),
+	}
+	s.runBackground("publish-notify", func(ctx context.Context) error {
+		return s.publishNotifier.Notify(ctx, event)
+	})
+}
+
+// unscheduleIfPublic clears the publish time of a snippet saved as public,
+// which has nothing left to wait for
+func unscheduleIfPublic(input *models.SnippetInput) {
+	if input.IsPublic {
+		cleared := ""
+		input.PublishAt = &cleared
+	}
+}
@@ -6,8 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
 	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
 	"github.com/MohamedElashri/snipo/internal/repository"
 	"github.com/MohamedElashri/snipo/internal/validation"
 )
@@ -16,6 +24,9 @@ import (
 var (
 	ErrSnippetNotFound = errors.New("snippet not found")
 	ErrValidation      = errors.New("validation error")
+	ErrPinLimitReached = errors.New("pinned snippet limit reached")
+	ErrSlugTaken       = errors.New("slug already in use")
+	ErrReviewState     = errors.New("review action not allowed in the current state")
 )
 
 // SnippetService handles snippet business logic
@@ -24,18 +35,38 @@ type SnippetService struct {
 	tagRepo            *repository.TagRepository
 	folderRepo         *repository.FolderRepository
 	fileRepo           *repository.SnippetFileRepository
+	metadataRepo       *repository.MetadataRepository
 	historyRepo        *repository.HistoryRepository
+	linkRepo           *repository.LinkRepository
+	lockRepo           *repository.LockRepository
+	checkoutRepo       *repository.CheckoutRepository
+	redactions         *RedactionService
 	settingsRepo       *repository.SettingsRepository
+	lifecycle          *lifecycle.Manager
+	history            historyQueue
+	shareNotifier      notify.Notifier
+	shareNotified      map[string]time.Time
+	shareMu            sync.Mutex
+	reviewNotifier     notify.Notifier
+	publishNotifier    notify.Notifier
+	eventNotifier      notify.Notifier
+	saveHook           SaveHook
 	logger             *slog.Logger
 	maxFilesPerSnippet int
+	maxPinned          int
+	autoSlugs          bool
 }
 
+// shareNotifyInterval limits share-access notifications to one per snippet per interval
+const shareNotifyInterval = time.Hour
+
 // NewSnippetService creates a new snippet service
 func NewSnippetService(repo *repository.SnippetRepository, logger *slog.Logger) *SnippetService {
 	return &SnippetService{
 		repo:               repo,
 		logger:             logger,
 		maxFilesPerSnippet: 10, // Default
+		maxPinned:          10, // Default
 	}
 }
 
@@ -57,6 +88,12 @@ func (s *SnippetService) WithFileRepo(fileRepo *repository.SnippetFileRepository
 	return s
 }
 
+// WithMetadataRepo adds custom metadata repository to the service
+func (s *SnippetService) WithMetadataRepo(metadataRepo *repository.MetadataRepository) *SnippetService {
+	s.metadataRepo = metadataRepo
+	return s
+}
+
 // WithHistoryRepo adds history repository to the service
 func (s *SnippetService) WithHistoryRepo(historyRepo *repository.HistoryRepository) *SnippetService {
 	s.historyRepo = historyRepo
@@ -75,6 +112,140 @@ func (s *SnippetService) WithMaxFiles(max int) *SnippetService {
 	return s
 }
 
+// WithMaxPinned sets the maximum number of pinned snippets
+func (s *SnippetService) WithMaxPinned(max int) *SnippetService {
+	s.maxPinned = max
+	return s
+}
+
+// WithAutoSlugs derives a unique slug from the title for snippets created without one
+func (s *SnippetService) WithAutoSlugs(enabled bool) *SnippetService {
+	s.autoSlugs = enabled
+	return s
+}
+
+// WithLifecycle sets the lifecycle manager used for background work
+func (s *SnippetService) WithLifecycle(lc *lifecycle.Manager) *SnippetService {
+	s.lifecycle = lc
+	return s
+}
+
+// WithSaveHook runs a hook, such as the automation rules, before snippets
+// are created or updated
+func (s *SnippetService) WithSaveHook(h SaveHook) *SnippetService {
+	s.saveHook = h
+	return s
+}
+
+// beforeSave runs the save hook and validates the input again, since the
+// hook may have changed it
+func (s *SnippetService) beforeSave(ctx context.Context, event string, existing *models.Snippet, input *models.SnippetInput) error {
+	if s.saveHook == nil {
+		return nil
+	}
+	if err := s.saveHook.BeforeSave(ctx, event, existing, input); err != nil {
+		return err
+	}
+	if errs := s.validate(ctx, input); errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// WithShareNotifier enables notifications when public snippets are viewed
+func (s *SnippetService) WithShareNotifier(n notify.Notifier) *SnippetService {
+	s.shareNotifier = n
+	s.shareNotified = make(map[string]time.Time)
+	return s
+}
+
+// notifyShareAccess reports a public snippet view, throttled per snippet
+func (s *SnippetService) notifyShareAccess(snippet *models.Snippet) {
+	if s.shareNotifier == nil {
+		return
+	}
+
+	now := time.Now()
+	s.shareMu.Lock()
+	if last, ok := s.shareNotified[snippet.ID]; ok && now.Sub(last) < shareNotifyInterval {
+		s.shareMu.Unlock()
+		return
+	}
+	s.shareNotified[snippet.ID] = now
+	// Drop stale entries so the map does not grow without bound
+	for id, last := range s.shareNotified {
+		if now.Sub(last) >= shareNotifyInterval {
+			delete(s.shareNotified, id)
+		}
+	}
+	s.shareMu.Unlock()
+
+	event := notify.Event{
+		Type:    notify.EventShareAccessed,
+		Title:   "Shared snippet viewed",
+		Message: fmt.Sprintf("Public snippet %q was viewed", snippet.Title),
+		Fields: map[string]string{
+			"snippet_id": snippet.ID,
+			"title":      snippet.Title,
+			"views":      fmt.Sprintf("%d", snippet.ViewCount+1),
+		},
+		Time: now.UTC(),
+	}
+	s.runBackground("share-notify", func(ctx context.Context) error {
+		return s.shareNotifier.Notify(ctx, event)
+	})
+}
+
+// WithReviewNotifier enables notifications for review requests and decisions
+func (s *SnippetService) WithReviewNotifier(n notify.Notifier) *SnippetService {
+	s.reviewNotifier = n
+	return s
+}
+
+// notifyReview reports a review action and its comment
+func (s *SnippetService) notifyReview(snippet *models.Snippet, action, comment string) {
+	if s.reviewNotifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Fields: map[string]string{
+			"snippet_id": snippet.ID,
+			"title":      snippet.Title,
+		},
+		Time: time.Now().UTC(),
+	}
+	switch action {
+	case models.ReviewActionSubmit:
+		event.Type = notify.EventReviewRequested
+		event.Title = "Review requested"
+		event.Message = fmt.Sprintf("Snippet %q was submitted for review", snippet.Title)
+	case models.ReviewActionApprove:
+		event.Type = notify.EventReviewApproved
+		event.Title = "Snippet approved"
+		event.Message = fmt.Sprintf("Snippet %q was approved", snippet.Title)
+	case models.ReviewActionReject:
+		event.Type = notify.EventReviewRejected
+		event.Title = "Snippet rejected"
+		event.Message = fmt.Sprintf("Snippet %q was sent back to draft", snippet.Title)
+	default:
+		return
+	}
+	if comment != "" {
+		event.Message += ": " + comment
+		event.Fields["comment"] = comment
+	}
+
+	s.runBackground("review-notify", func(ctx context.Context) error {
+		return s.reviewNotifier.Notify(ctx, event)
+	})
+}
+
+// runBackground runs fn outside the request, tracked by the lifecycle manager when configured
+func (s *SnippetService) runBackground(name string, fn func(ctx context.Context) error) {
+	runBackground(s.lifecycle, s.logger, name, fn)
+}
+
 // isHistoryEnabled checks if history tracking is enabled in settings
 func (s *SnippetService) isHistoryEnabled(ctx context.Context) bool {
 	if s.historyRepo == nil || s.settingsRepo == nil {
@@ -83,53 +254,192 @@ func (s *SnippetService) isHistoryEnabled(ctx context.Context) bool {
 
 	settings, err := s.settingsRepo.Get(ctx)
 	if err != nil {
-		s.logger.Warn("failed to get settings for history check", "error", err)
+		s.logger.WarnContext(ctx, "failed to get settings for history check", "error", err)
 		return false
 	}
 
 	return settings.HistoryEnabled
 }
 
-// saveHistory saves a snapshot of the current snippet to history
+// validate validates snippet input against the validation rules in the
+// settings, or the defaults when they cannot be read
+func (s *SnippetService) validate(ctx context.Context, input *models.SnippetInput) validation.ValidationErrors {
+	rules := validation.DefaultRules()
+	if s.settingsRepo != nil {
+		if settings, err := s.settingsRepo.Get(ctx); err != nil {
+			s.logger.WarnContext(ctx, "failed to get settings for validation rules", "error", err)
+		} else {
+			rules = settings.Validation
+		}
+	}
+	return validation.ValidateSnippetInputWithRules(input, rules)
+}
+
+// ReviewRequired reports whether public snippets must be approved before
+// they are served
+func (s *SnippetService) ReviewRequired(ctx context.Context) bool {
+	if s.settingsRepo == nil {
+		return false
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to get settings for review check", "error", err)
+		return false
+	}
+
+	return settings.ReviewRequired
+}
+
+// isPublished reports whether a snippet may be served publicly
+func (s *SnippetService) isPublished(ctx context.Context, snippet *models.Snippet) bool {
+	return snippet.IsPublic && !snippet.RequiresCheckout && (snippet.ReviewState == models.ReviewApproved || !s.ReviewRequired(ctx))
+}
+
+// resetApproval moves an approved snippet back to draft when an edit changed
+// what was approved, so the new version is reviewed before it is served
+func (s *SnippetService) resetApproval(ctx context.Context, before, after *models.Snippet) {
+	if before.ReviewState != models.ReviewApproved || !s.ReviewRequired(ctx) {
+		return
+	}
+	if before.Title == after.Title && before.Description == after.Description &&
+		before.Checksum != nil && after.Checksum != nil && *before.Checksum == *after.Checksum {
+		return
+	}
+
+	reset, err := s.repo.Review(ctx, after.ID, models.ReviewApproved, models.ReviewDraft, models.ReviewActionReset, "")
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to reset snippet approval", "id", after.ID, "error", err)
+		return
+	}
+	if reset != nil {
+		after.ReviewState = reset.ReviewState
+	}
+}
+
+// saveHistory saves a snapshot of the current snippet to history, along
+// with the caller making the change. With a lifecycle manager the snapshot
+// is written in the background; see historyQueue.
 func (s *SnippetService) saveHistory(ctx context.Context, snippet *models.Snippet, changeType string) error {
 	if !s.isHistoryEnabled(ctx) {
 		return nil
 	}
 
-	// Create history entry
-	historyID, err := s.historyRepo.CreateHistory(ctx, snippet, changeType)
-	if err != nil {
-		s.logger.Warn("failed to create snippet history", "id", snippet.ID, "error", err)
-		return err
+	// Copy the snippet, as the caller may change it once we return
+	snapshot := *snippet
+	snapshot.Files = slices.Clone(snippet.Files)
+	actor := auth.ActorFromContext(ctx)
+
+	return s.history.enqueue(ctx, s.lifecycle, snippet.ID, func(ctx context.Context) error {
+		// Create history entry
+		historyID, err := s.historyRepo.CreateHistory(ctx, &snapshot, changeType, actor.Name, actor.IP)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to create snippet history", "id", snapshot.ID, "error", err)
+			return err
+		}
+
+		// Save files if present
+		if len(snapshot.Files) > 0 {
+			if err := s.historyRepo.CreateFileHistory(ctx, historyID, snapshot.Files); err != nil {
+				s.logger.WarnContext(ctx, "failed to create file history", "id", snapshot.ID, "error", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// refreshChecksum recomputes and stores the checksum from the persisted content and files
+func (s *SnippetService) refreshChecksum(ctx context.Context, snippet *models.Snippet) {
+	var files []models.SnippetFile
+	if s.fileRepo != nil {
+		stored, err := s.fileRepo.GetBySnippetID(ctx, snippet.ID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to read files for checksum", "id", snippet.ID, "error", err)
+			return
+		}
+		files = stored
+	}
+
+	checksum := ContentChecksum(snippet.Content, files)
+	if err := s.repo.SetChecksum(ctx, snippet.ID, checksum); err != nil {
+		s.logger.WarnContext(ctx, "failed to store snippet checksum", "id", snippet.ID, "error", err)
+		return
 	}
+	snippet.Checksum = &checksum
+}
 
-	// Save files if present
-	if len(snippet.Files) > 0 {
-		if err := s.historyRepo.CreateFileHistory(ctx, historyID, snippet.Files); err != nil {
-			s.logger.Warn("failed to create file history", "id", snippet.ID, "error", err)
+// resolveSlug checks an explicit slug for conflicts or, when auto slugs are
+// enabled and the snippet has none, derives one from the title. existing is
+// nil for new snippets.
+func (s *SnippetService) resolveSlug(ctx context.Context, input *models.SnippetInput, existing *models.Snippet) error {
+	id := ""
+	if existing != nil {
+		id = existing.ID
+	}
+
+	if input.Slug != nil {
+		if *input.Slug == "" {
+			return nil
+		}
+		owner, err := s.repo.GetBySlug(ctx, *input.Slug)
+		if err != nil {
+			return err
+		}
+		if owner != nil && owner.ID != id {
+			return ErrSlugTaken
 		}
+		return nil
+	}
+
+	if !s.autoSlugs || (existing != nil && existing.Slug != nil) {
+		return nil
 	}
 
+	base := validation.Slugify(input.Title)
+	taken, err := s.repo.SlugsWithPrefix(ctx, base, id)
+	if err != nil {
+		return err
+	}
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	input.Slug = &slug
 	return nil
 }
 
 // Create creates a new snippet
 func (s *SnippetService) Create(ctx context.Context, input *models.SnippetInput) (*models.Snippet, error) {
+	suggestFilenames(input)
+
 	// Validate input
-	if errs := validation.ValidateSnippetInput(input); errs.HasErrors() {
+	if errs := s.validate(ctx, input); errs.HasErrors() {
 		return nil, errs
 	}
+	unscheduleIfPublic(input)
+
+	if err := s.beforeSave(ctx, models.RuleEventCreate, nil, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.resolveSlug(ctx, input, nil); err != nil {
+		return nil, err
+	}
 
 	snippet, err := s.repo.Create(ctx, input)
 	if err != nil {
-		s.logger.Error("failed to create snippet", "error", err)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSlugTaken
+		}
+		s.logger.ErrorContext(ctx, "failed to create snippet", "error", err)
 		return nil, err
 	}
 
 	// Set tags if provided
 	if s.tagRepo != nil && len(input.Tags) > 0 {
 		if err := s.tagRepo.SetSnippetTags(ctx, snippet.ID, input.Tags); err != nil {
-			s.logger.Warn("failed to set snippet tags", "id", snippet.ID, "error", err)
+			s.logger.WarnContext(ctx, "failed to set snippet tags", "id", snippet.ID, "error", err)
 		} else {
 			// Fetch tags to include in response
 			tags, _ := s.tagRepo.GetSnippetTags(ctx, snippet.ID)
@@ -140,7 +450,7 @@ func (s *SnippetService) Create(ctx context.Context, input *models.SnippetInput)
 	// Set folder if provided
 	if s.folderRepo != nil && input.FolderID != nil {
 		if err := s.folderRepo.SetSnippetFolder(ctx, snippet.ID, input.FolderID); err != nil {
-			s.logger.Warn("failed to set snippet folder", "id", snippet.ID, "error", err)
+			s.logger.WarnContext(ctx, "failed to set snippet folder", "id", snippet.ID, "error", err)
 		} else {
 			// Fetch folders to include in response
 			folders, _ := s.folderRepo.GetSnippetFolders(ctx, snippet.ID)
@@ -148,6 +458,15 @@ func (s *SnippetService) Create(ctx context.Context, input *models.SnippetInput)
 		}
 	}
 
+	// Set metadata if provided
+	if s.metadataRepo != nil && len(input.Metadata) > 0 {
+		if err := s.metadataRepo.SetSnippetMetadata(ctx, snippet.ID, input.Metadata); err != nil {
+			s.logger.WarnContext(ctx, "failed to set snippet metadata", "id", snippet.ID, "error", err)
+		} else {
+			snippet.Metadata = input.Metadata
+		}
+	}
+
 	// Create files if provided
 	if s.fileRepo != nil && len(input.Files) > 0 {
 		// Limit files
@@ -157,26 +476,60 @@ func (s *SnippetService) Create(ctx context.Context, input *models.SnippetInput)
 		}
 		createdFiles, err := s.fileRepo.SyncFiles(ctx, snippet.ID, files)
 		if err != nil {
-			s.logger.Warn("failed to create snippet files", "id", snippet.ID, "error", err)
+			s.logger.WarnContext(ctx, "failed to create snippet files", "id", snippet.ID, "error", err)
 		} else {
 			snippet.Files = createdFiles
 		}
 	}
 
+	s.syncAutoLinks(ctx, snippet)
+	s.refreshChecksum(ctx, snippet)
+
 	// Save to history if enabled
-	if err := s.saveHistory(ctx, snippet, "create"); err != nil {
-		s.logger.Warn("failed to save creation to history", "id", snippet.ID, "error", err)
+	if err := s.saveHistory(ctx, snippet, models.HistoryCreate); err != nil {
+		s.logger.WarnContext(ctx, "failed to save creation to history", "id", snippet.ID, "error", err)
 	}
 
-	s.logger.Info("snippet created", "id", snippet.ID, "title", snippet.Title)
+	s.logger.InfoContext(ctx, "snippet created", "id", snippet.ID, "title", snippet.Title)
+	s.notifyLifecycle(notify.EventSnippetCreated, snippet)
 	return snippet, nil
 }
 
+// suggestFilenames names files sent without a filename after the snippet
+// title and the file's language (falling back to the snippet's), e.g.
+// "docker-compose-redis.yaml". Names taken in the snippet get a counter.
+func suggestFilenames(input *models.SnippetInput) {
+	used := make(map[string]bool, len(input.Files))
+	for _, file := range input.Files {
+		if name := strings.TrimSpace(file.Filename); name != "" {
+			used[strings.ToLower(name)] = true
+		}
+	}
+
+	base := validation.Slugify(input.Title)
+	for i, file := range input.Files {
+		if strings.TrimSpace(file.Filename) != "" {
+			continue
+		}
+		lang := file.Language
+		if strings.TrimSpace(lang) == "" {
+			lang = input.Language
+		}
+		ext := "." + languages.Extension(lang)
+		name := base + ext
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		used[strings.ToLower(name)] = true
+		input.Files[i].Filename = name
+	}
+}
+
 // GetByID retrieves a snippet by ID
 func (s *SnippetService) GetByID(ctx context.Context, id string) (*models.Snippet, error) {
 	snippet, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("failed to get snippet", "id", id, "error", err)
+		s.logger.ErrorContext(ctx, "failed to get snippet", "id", id, "error", err)
 		return nil, err
 	}
 
@@ -202,9 +555,30 @@ func (s *SnippetService) GetByID(ctx context.Context, id string) (*models.Snippe
 		snippet.Files = files
 	}
 
+	// Fetch metadata
+	if s.metadataRepo != nil {
+		metadata, _ := s.metadataRepo.GetBySnippetID(ctx, id)
+		snippet.Metadata = metadata
+	}
+
+	snippet.Lock = s.activeLock(ctx, id)
+
 	return snippet, nil
 }
 
+// GetBySlug retrieves a snippet by slug
+func (s *SnippetService) GetBySlug(ctx context.Context, slug string) (*models.Snippet, error) {
+	snippet, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to get snippet by slug", "slug", slug, "error", err)
+		return nil, err
+	}
+	if snippet == nil {
+		return nil, ErrSnippetNotFound
+	}
+	return s.GetByID(ctx, snippet.ID)
+}
+
 // GetByIDPublic retrieves a public snippet by ID and increments view count
 func (s *SnippetService) GetByIDPublic(ctx context.Context, id string) (*models.Snippet, error) {
 	snippet, err := s.repo.GetByID(ctx, id)
@@ -212,16 +586,94 @@ func (s *SnippetService) GetByIDPublic(ctx context.Context, id string) (*models.
 		return nil, err
 	}
 
-	if snippet == nil || !snippet.IsPublic {
+	if snippet == nil || !s.isPublished(ctx, snippet) {
+		return nil, ErrSnippetNotFound
+	}
+
+	return s.publicView(ctx, snippet)
+}
+
+// GetBySlugPublic retrieves a public snippet by slug and increments view count
+func (s *SnippetService) GetBySlugPublic(ctx context.Context, slug string) (*models.Snippet, error) {
+	snippet, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if snippet == nil || !s.isPublished(ctx, snippet) {
 		return nil, ErrSnippetNotFound
 	}
 
+	return s.publicView(ctx, snippet)
+}
+
+// ListPublicInFolder lists a page of the published snippets in a folder,
+// most recently updated first. Snippets that must be checked out are not
+// published, so they are left out.
+func (s *SnippetService) ListPublicInFolder(ctx context.Context, folderID int64, page, limit int) (*models.SnippetListResponse, error) {
+	isPublic, requiresCheckout := true, false
+	filter := models.SnippetFilter{
+		FolderIDs:        []int64{folderID},
+		IsPublic:         &isPublic,
+		RequiresCheckout: &requiresCheckout,
+		Page:             page,
+		Limit:            limit,
+	}
+	if s.ReviewRequired(ctx) {
+		filter.ReviewState = models.ReviewApproved
+	}
+	list, err := s.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Data {
+		if err := s.Redact(ctx, &list.Data[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// NoIndex reports whether the snippet with an ID or slug asks search
+// engines not to index its public page. Views are not counted.
+func (s *SnippetService) NoIndex(ctx context.Context, idOrSlug string) bool {
+	snippet, err := s.repo.GetByID(ctx, idOrSlug)
+	if err == nil && snippet == nil && validation.IsValidSlug(idOrSlug) {
+		snippet, err = s.repo.GetBySlug(ctx, idOrSlug)
+	}
+	return err == nil && snippet != nil && snippet.NoIndex
+}
+
+// GetShared retrieves a snippet for a signed URL, which reaches private
+// snippets too, masked like a public one. Snippets that require a
+// check-out are never shared.
+func (s *SnippetService) GetShared(ctx context.Context, id string) (*models.Snippet, error) {
+	snippet, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if snippet.RequiresCheckout {
+		return nil, ErrSnippetNotFound
+	}
+	if err := s.Redact(ctx, snippet); err != nil {
+		return nil, err
+	}
+	return snippet, nil
+}
+
+// publicView records a view of a public snippet, loads its files and
+// masks them with the redaction rules
+func (s *SnippetService) publicView(ctx context.Context, snippet *models.Snippet) (*models.Snippet, error) {
+	id := snippet.ID
+
 	// Increment view count asynchronously
-	go func() {
-		if err := s.repo.IncrementViewCount(context.Background(), id); err != nil {
-			s.logger.Warn("failed to increment view count", "id", id, "error", err)
+	runBackgroundWrite(s.lifecycle, s.logger, "view-count", func(ctx context.Context) error {
+		if err := s.repo.IncrementViewCount(ctx, id); err != nil {
+			return fmt.Errorf("snippet %s: %w", id, err)
 		}
-	}()
+		return nil
+	})
+	s.notifyShareAccess(snippet)
 
 	// Fetch files for public view
 	if s.fileRepo != nil {
@@ -229,15 +681,21 @@ func (s *SnippetService) GetByIDPublic(ctx context.Context, id string) (*models.
 		snippet.Files = files
 	}
 
+	if err := s.Redact(ctx, snippet); err != nil {
+		return nil, err
+	}
 	return snippet, nil
 }
 
 // Update updates an existing snippet
 func (s *SnippetService) Update(ctx context.Context, id string, input *models.SnippetInput) (*models.Snippet, error) {
+	suggestFilenames(input)
+
 	// Validate input
-	if errs := validation.ValidateSnippetInput(input); errs.HasErrors() {
+	if errs := s.validate(ctx, input); errs.HasErrors() {
 		return nil, errs
 	}
+	unscheduleIfPublic(input)
 
 	// Check if snippet exists and get current state for history
 	existing, err := s.repo.GetByID(ctx, id)
@@ -247,6 +705,9 @@ func (s *SnippetService) Update(ctx context.Context, id string, input *models.Sn
 	if existing == nil {
 		return nil, ErrSnippetNotFound
 	}
+	if err := s.requireCheckout(ctx, existing); err != nil {
+		return nil, err
+	}
 
 	// Fetch existing files for history
 	if s.fileRepo != nil {
@@ -254,21 +715,47 @@ func (s *SnippetService) Update(ctx context.Context, id string, input *models.Sn
 		existing.Files = files
 	}
 
+	if s.saveHook != nil {
+		if s.tagRepo != nil {
+			existing.Tags, _ = s.tagRepo.GetSnippetTags(ctx, id)
+		}
+		if err := s.beforeSave(ctx, models.RuleEventUpdate, existing, input); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.resolveSlug(ctx, input, existing); err != nil {
+		return nil, err
+	}
+
 	// Save current state to history before updating
-	if err := s.saveHistory(ctx, existing, "update"); err != nil {
-		s.logger.Warn("failed to save pre-update state to history", "id", id, "error", err)
+	if err := s.saveHistory(ctx, existing, models.HistoryUpdate); err != nil {
+		s.logger.WarnContext(ctx, "failed to save pre-update state to history", "id", id, "error", err)
+	}
+
+	// Keep the current tags and folder to record changes to them
+	var oldTags []models.Tag
+	var oldFolders []models.Folder
+	if s.tagRepo != nil && input.Tags != nil {
+		oldTags, _ = s.tagRepo.GetSnippetTags(ctx, id)
+	}
+	if s.folderRepo != nil {
+		oldFolders, _ = s.folderRepo.GetSnippetFolders(ctx, id)
 	}
 
 	snippet, err := s.repo.Update(ctx, id, input)
 	if err != nil {
-		s.logger.Error("failed to update snippet", "id", id, "error", err)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSlugTaken
+		}
+		s.logger.ErrorContext(ctx, "failed to update snippet", "id", id, "error", err)
 		return nil, err
 	}
 
 	// Update tags if provided
 	if s.tagRepo != nil && input.Tags != nil {
 		if err := s.tagRepo.SetSnippetTags(ctx, id, input.Tags); err != nil {
-			s.logger.Warn("failed to update snippet tags", "id", id, "error", err)
+			s.logger.WarnContext(ctx, "failed to update snippet tags", "id", id, "error", err)
 		}
 		tags, _ := s.tagRepo.GetSnippetTags(ctx, id)
 		snippet.Tags = tags
@@ -277,12 +764,23 @@ func (s *SnippetService) Update(ctx context.Context, id string, input *models.Sn
 	// Update folder if provided
 	if s.folderRepo != nil {
 		if err := s.folderRepo.SetSnippetFolder(ctx, id, input.FolderID); err != nil {
-			s.logger.Warn("failed to update snippet folder", "id", id, "error", err)
+			s.logger.WarnContext(ctx, "failed to update snippet folder", "id", id, "error", err)
 		}
 		folders, _ := s.folderRepo.GetSnippetFolders(ctx, id)
 		snippet.Folders = folders
 	}
 
+	// Update metadata if provided
+	if s.metadataRepo != nil {
+		if input.Metadata != nil {
+			if err := s.metadataRepo.SetSnippetMetadata(ctx, id, input.Metadata); err != nil {
+				s.logger.WarnContext(ctx, "failed to update snippet metadata", "id", id, "error", err)
+			}
+		}
+		metadata, _ := s.metadataRepo.GetBySnippetID(ctx, id)
+		snippet.Metadata = metadata
+	}
+
 	// Update files if provided
 	if s.fileRepo != nil && input.Files != nil {
 		// Limit files
@@ -292,28 +790,46 @@ func (s *SnippetService) Update(ctx context.Context, id string, input *models.Sn
 		}
 		syncedFiles, err := s.fileRepo.SyncFiles(ctx, id, files)
 		if err != nil {
-			s.logger.Warn("failed to update snippet files", "id", id, "error", err)
+			s.logger.WarnContext(ctx, "failed to update snippet files", "id", id, "error", err)
 		} else {
 			snippet.Files = syncedFiles
 		}
 	}
 
-	s.logger.Info("snippet updated", "id", id)
+	s.syncAutoLinks(ctx, snippet)
+	s.refreshChecksum(ctx, snippet)
+	s.resetApproval(ctx, existing, snippet)
+	s.saveTagFolderHistory(ctx, snippet, input, oldTags, oldFolders)
+
+	s.logger.InfoContext(ctx, "snippet updated", "id", id)
+	s.notifyLifecycle(notify.EventSnippetUpdated, snippet)
 	return snippet, nil
 }
 
 // Delete removes a snippet
 func (s *SnippetService) Delete(ctx context.Context, id string) error {
+	// Keep the title for the event, which is sent after the row is gone
+	deleted := &models.Snippet{ID: id}
+	if s.eventNotifier != nil {
+		if snippet, err := s.repo.GetByID(ctx, id); err == nil && snippet != nil {
+			deleted = snippet
+		}
+	}
+
+	// Let queued history land first so the delete removes it too
+	s.history.flush(ctx, id)
+
 	err := s.repo.Delete(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrSnippetNotFound
 		}
-		s.logger.Error("failed to delete snippet", "id", id, "error", err)
+		s.logger.ErrorContext(ctx, "failed to delete snippet", "id", id, "error", err)
 		return err
 	}
 
-	s.logger.Info("snippet deleted", "id", id)
+	s.logger.InfoContext(ctx, "snippet deleted", "id", id)
+	s.notifyLifecycle(notify.EventSnippetDeleted, deleted)
 	return nil
 }
 
@@ -343,7 +859,46 @@ func (s *SnippetService) List(ctx context.Context, filter models.SnippetFilter)
 func (s *SnippetService) ToggleFavorite(ctx context.Context, id string) (*models.Snippet, error) {
 	snippet, err := s.repo.ToggleFavorite(ctx, id)
 	if err != nil {
-		s.logger.Error("failed to toggle favorite", "id", id, "error", err)
+		s.logger.ErrorContext(ctx, "failed to toggle favorite", "id", id, "error", err)
+		return nil, err
+	}
+
+	if snippet == nil {
+		return nil, ErrSnippetNotFound
+	}
+
+	s.saveMetadataHistory(ctx, snippet, models.HistoryFavorite, "")
+
+	s.logger.InfoContext(ctx, "snippet favorite toggled", "id", id, "is_favorite", snippet.IsFavorite)
+	return snippet, nil
+}
+
+// TogglePin pins or unpins a snippet. Pinning fails with ErrPinLimitReached
+// once the configured maximum is pinned.
+func (s *SnippetService) TogglePin(ctx context.Context, id string) (*models.Snippet, error) {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to get snippet", "id", id, "error", err)
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrSnippetNotFound
+	}
+
+	if !existing.IsPinned {
+		count, err := s.repo.CountPinned(ctx)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to count pinned snippets", "error", err)
+			return nil, err
+		}
+		if count >= s.maxPinned {
+			return nil, ErrPinLimitReached
+		}
+	}
+
+	snippet, err := s.repo.TogglePin(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to toggle pin", "id", id, "error", err)
 		return nil, err
 	}
 
@@ -351,15 +906,43 @@ func (s *SnippetService) ToggleFavorite(ctx context.Context, id string) (*models
 		return nil, ErrSnippetNotFound
 	}
 
-	s.logger.Info("snippet favorite toggled", "id", id, "is_favorite", snippet.IsFavorite)
+	s.logger.InfoContext(ctx, "snippet pin toggled", "id", id, "is_pinned", snippet.IsPinned)
 	return snippet, nil
 }
 
+// ListPinned returns the pinned snippets with their tags, oldest pin first
+func (s *SnippetService) ListPinned(ctx context.Context) ([]models.Snippet, error) {
+	snippets, err := s.repo.ListPinned(ctx, s.maxPinned)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to list pinned snippets", "error", err)
+		return nil, err
+	}
+
+	if s.tagRepo != nil {
+		for i := range snippets {
+			tags, _ := s.tagRepo.GetSnippetTags(ctx, snippets[i].ID)
+			snippets[i].Tags = tags
+		}
+	}
+
+	return snippets, nil
+}
+
+// MaxPinned returns the maximum number of pinned snippets
+func (s *SnippetService) MaxPinned() int {
+	return s.maxPinned
+}
+
+// MaxFiles returns the maximum number of files per snippet
+func (s *SnippetService) MaxFiles() int {
+	return s.maxFilesPerSnippet
+}
+
 // ToggleArchive toggles the archive status of a snippet
 func (s *SnippetService) ToggleArchive(ctx context.Context, id string) (*models.Snippet, error) {
 	snippet, err := s.repo.ToggleArchive(ctx, id)
 	if err != nil {
-		s.logger.Error("failed to toggle archive", "id", id, "error", err)
+		s.logger.ErrorContext(ctx, "failed to toggle archive", "id", id, "error", err)
 		return nil, err
 	}
 
@@ -367,7 +950,9 @@ func (s *SnippetService) ToggleArchive(ctx context.Context, id string) (*models.
 		return nil, ErrSnippetNotFound
 	}
 
-	s.logger.Info("snippet archive toggled", "id", id, "is_archived", snippet.IsArchived)
+	s.saveMetadataHistory(ctx, snippet, models.HistoryArchive, "")
+
+	s.logger.InfoContext(ctx, "snippet archive toggled", "id", id, "is_archived", snippet.IsArchived)
 	return snippet, nil
 }
 
@@ -379,7 +964,7 @@ func (s *SnippetService) Search(ctx context.Context, query string, limit int) ([
 
 	snippets, err := s.repo.Search(ctx, query, limit)
 	if err != nil {
-		s.logger.Error("failed to search snippets", "query", query, "error", err)
+		s.logger.ErrorContext(ctx, "failed to search snippets", "query", query, "error", err)
 		return nil, err
 	}
 
@@ -395,6 +980,9 @@ func (s *SnippetService) Duplicate(ctx context.Context, id string) (*models.Snip
 	if existing == nil {
 		return nil, ErrSnippetNotFound
 	}
+	if err := s.requireCheckout(ctx, existing); err != nil {
+		return nil, err
+	}
 
 	input := &models.SnippetInput{
 		Title:       existing.Title + " (copy)",
@@ -402,9 +990,80 @@ func (s *SnippetService) Duplicate(ctx context.Context, id string) (*models.Snip
 		Content:     existing.Content,
 		Language:    existing.Language,
 		IsPublic:    false, // Copies are private by default
+		SourceURL:   existing.SourceURL,
+		License:     existing.License,
+		Attribution: existing.Attribution,
+	}
+
+	snippet, err := s.repo.Create(ctx, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Create(ctx, input)
+	s.refreshChecksum(ctx, snippet)
+	return snippet, nil
+}
+
+// SubmitForReview moves a draft snippet to pending review
+func (s *SnippetService) SubmitForReview(ctx context.Context, id, comment string) (*models.Snippet, error) {
+	return s.review(ctx, id, models.ReviewActionSubmit, models.ReviewDraft, models.ReviewPending, comment)
+}
+
+// Approve approves a snippet pending review
+func (s *SnippetService) Approve(ctx context.Context, id, comment string) (*models.Snippet, error) {
+	return s.review(ctx, id, models.ReviewActionApprove, models.ReviewPending, models.ReviewApproved, comment)
+}
+
+// Reject returns a snippet pending review to draft
+func (s *SnippetService) Reject(ctx context.Context, id, comment string) (*models.Snippet, error) {
+	return s.review(ctx, id, models.ReviewActionReject, models.ReviewPending, models.ReviewDraft, comment)
+}
+
+// review applies a review action, failing with ErrReviewState unless the
+// snippet is in the from state
+func (s *SnippetService) review(ctx context.Context, id, action, from, to, comment string) (*models.Snippet, error) {
+	input := &models.ReviewInput{Comment: comment}
+	if errs := validation.ValidateReviewInput(input); errs.HasErrors() {
+		return nil, errs
+	}
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrSnippetNotFound
+	}
+	if existing.ReviewState != from {
+		return nil, ErrReviewState
+	}
+
+	snippet, err := s.repo.Review(ctx, id, from, to, action, input.Comment)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to review snippet", "id", id, "action", action, "error", err)
+		return nil, err
+	}
+	if snippet == nil {
+		// Changed state or was deleted since it was read
+		return nil, ErrReviewState
+	}
+
+	s.logger.InfoContext(ctx, "snippet reviewed", "id", id, "action", action, "review_state", snippet.ReviewState)
+	s.notifyReview(snippet, action, input.Comment)
+	return snippet, nil
+}
+
+// ListReviews returns the review log of a snippet, newest first
+func (s *SnippetService) ListReviews(ctx context.Context, id string) ([]models.SnippetReview, error) {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrSnippetNotFound
+	}
+
+	return s.repo.ListReviews(ctx, id)
 }
 
 // GetHistory retrieves the modification history for a snippet
@@ -421,10 +1080,14 @@ func (s *SnippetService) GetHistory(ctx context.Context, id string, limit int) (
 	if snippet == nil {
 		return nil, ErrSnippetNotFound
 	}
+	if err := s.requireCheckout(ctx, snippet); err != nil {
+		return nil, err
+	}
 
+	s.history.flush(ctx, id)
 	history, err := s.historyRepo.GetSnippetHistory(ctx, id, limit)
 	if err != nil {
-		s.logger.Error("failed to get snippet history", "id", id, "error", err)
+		s.logger.ErrorContext(ctx, "failed to get snippet history", "id", id, "error", err)
 		return nil, err
 	}
 
@@ -437,7 +1100,8 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 		return nil, fmt.Errorf("history repository not configured")
 	}
 
-	// Get the history entry
+	// Get the history entry, which may still be queued
+	s.history.flush(ctx, snippetID)
 	historyEntry, err := s.historyRepo.GetHistoryByID(ctx, historyID)
 	if err != nil {
 		return nil, err
@@ -450,6 +1114,9 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 	if historyEntry.SnippetID != snippetID {
 		return nil, fmt.Errorf("history entry does not belong to this snippet")
 	}
+	if models.IsMetadataChange(historyEntry.ChangeType) {
+		return nil, fmt.Errorf("history entry records a %s change and has no content to restore", historyEntry.ChangeType)
+	}
 
 	// Get current snippet for history before restore
 	existing, err := s.repo.GetByID(ctx, snippetID)
@@ -459,6 +1126,9 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 	if existing == nil {
 		return nil, ErrSnippetNotFound
 	}
+	if err := s.requireCheckout(ctx, existing); err != nil {
+		return nil, err
+	}
 
 	// Fetch existing files for history
 	if s.fileRepo != nil {
@@ -467,8 +1137,8 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 	}
 
 	// Save current state before restoring
-	if err := s.saveHistory(ctx, existing, "update"); err != nil {
-		s.logger.Warn("failed to save pre-restore state", "id", snippetID, "error", err)
+	if err := s.saveHistory(ctx, existing, models.HistoryUpdate); err != nil {
+		s.logger.WarnContext(ctx, "failed to save pre-restore state", "id", snippetID, "error", err)
 	}
 
 	// Create input from history entry
@@ -484,7 +1154,7 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 	// Restore the snippet
 	snippet, err := s.repo.Update(ctx, snippetID, input)
 	if err != nil {
-		s.logger.Error("failed to restore snippet from history", "id", snippetID, "history_id", historyID, "error", err)
+		s.logger.ErrorContext(ctx, "failed to restore snippet from history", "id", snippetID, "history_id", historyID, "error", err)
 		return nil, err
 	}
 
@@ -502,7 +1172,7 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 
 		restoredFiles, err := s.fileRepo.SyncFiles(ctx, snippetID, fileInputs)
 		if err != nil {
-			s.logger.Warn("failed to restore snippet files", "id", snippetID, "error", err)
+			s.logger.WarnContext(ctx, "failed to restore snippet files", "id", snippetID, "error", err)
 		} else {
 			snippet.Files = restoredFiles
 		}
@@ -518,6 +1188,10 @@ func (s *SnippetService) RestoreFromHistory(ctx context.Context, snippetID strin
 		snippet.Folders = folders
 	}
 
-	s.logger.Info("snippet restored from history", "id", snippetID, "history_id", historyID)
+	s.syncAutoLinks(ctx, snippet)
+	s.refreshChecksum(ctx, snippet)
+	s.resetApproval(ctx, existing, snippet)
+
+	s.logger.InfoContext(ctx, "snippet restored from history", "id", snippetID, "history_id", historyID)
 	return snippet, nil
 }
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// RecordUsage stores a view or copy of a snippet for the frecency sort
+func (s *SnippetService) RecordUsage(ctx context.Context, id string, input *models.UsageInput) error {
+	if errs := validation.ValidateUsageInput(input); errs.HasErrors() {
+		return errs
+	}
+
+	snippet, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if snippet == nil {
+		return ErrSnippetNotFound
+	}
+
+	return s.repo.RecordUsage(ctx, id, input.Event)
+}
+
+// TrackView records a view of a snippet in the background
+func (s *SnippetService) TrackView(id string) {
+	runBackgroundWrite(s.lifecycle, s.logger, "usage", func(ctx context.Context) error {
+		if err := s.repo.RecordUsage(ctx, id, models.UsageView); err != nil {
+			return fmt.Errorf("snippet %s: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// PruneUsage deletes usage events too old to affect the frecency sort. It
+// is run periodically by the scheduler.
+func (s *SnippetService) PruneUsage(ctx context.Context) error {
+	pruned, err := s.repo.PruneUsage(ctx)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.logger.InfoContext(ctx, "pruned snippet usage events", "count", pruned)
+	}
+	return nil
+}
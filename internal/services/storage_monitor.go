@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/notify"
+)
+
+// StorageMonitor warns when the database grows past a configured size
+type StorageMonitor struct {
+	db       *sql.DB
+	limit    int64
+	notifier notify.Notifier
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	warned bool
+}
+
+// NewStorageMonitor creates a monitor that warns above limitMB megabytes
+func NewStorageMonitor(db *sql.DB, limitMB int, notifier notify.Notifier, logger *slog.Logger) *StorageMonitor {
+	return &StorageMonitor{
+		db:       db,
+		limit:    int64(limitMB) * 1024 * 1024,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// Check measures the database size and sends a quota warning once per crossing
+func (m *StorageMonitor) Check(ctx context.Context) error {
+	var pageCount, pageSize int64
+	if err := m.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := m.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return fmt.Errorf("failed to read page size: %w", err)
+	}
+	size := pageCount * pageSize
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if size < m.limit {
+		// Re-arm so the next crossing is reported again
+		m.warned = false
+		return nil
+	}
+	if m.warned {
+		return nil
+	}
+
+	m.logger.WarnContext(ctx, "database size above quota", "size_bytes", size, "limit_bytes", m.limit)
+	event := notify.Event{
+		Type:    notify.EventQuotaWarning,
+		Title:   "Database size warning",
+		Message: fmt.Sprintf("The database is %s, above the configured limit of %s", formatMB(size), formatMB(m.limit)),
+		Fields: map[string]string{
+			"size_mb":  fmt.Sprintf("%.1f", float64(size)/(1024*1024)),
+			"limit_mb": fmt.Sprintf("%.1f", float64(m.limit)/(1024*1024)),
+		},
+		Time: time.Now().UTC(),
+	}
+	if err := m.notifier.Notify(ctx, event); err != nil {
+		return fmt.Errorf("failed to send quota warning: %w", err)
+	}
+	m.warned = true
+	return nil
+}
+
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// snippetCounter counts the stored snippets
+type snippetCounter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+// TelemetryService builds the anonymous usage report and, when telemetry
+// is enabled, posts it to the collector. The report can always be
+// previewed; it is only sent from Send, which is scheduled when enabled.
+type TelemetryService struct {
+	version  string
+	snippets snippetCounter
+	features []string
+	url      string // Empty when disabled; Send then does nothing
+	blocked  bool
+	client   *http.Client
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	lastSent *time.Time
+	lastErr  string
+}
+
+// NewTelemetryService creates a telemetry service for the running version
+// and the optional features that are turned on
+func NewTelemetryService(version string, snippets snippetCounter, features []string, logger *slog.Logger) *TelemetryService {
+	features = append([]string{}, features...)
+	slices.Sort(features)
+	return &TelemetryService{
+		version:  version,
+		snippets: snippets,
+		features: features,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		logger:   logger,
+	}
+}
+
+// WithURL enables sending the report to a collector
+func (s *TelemetryService) WithURL(u string) *TelemetryService {
+	s.url = u
+	return s
+}
+
+// WithBlocked marks telemetry as turned off by the hard off switch, for
+// the preview
+func (s *TelemetryService) WithBlocked(blocked bool) *TelemetryService {
+	s.blocked = blocked
+	return s
+}
+
+// Report builds the report as it would be sent now
+func (s *TelemetryService) Report(ctx context.Context) (*models.TelemetryReport, error) {
+	count, err := s.snippets.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TelemetryReport{
+		Version:  s.version,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Snippets: snippetBucket(count),
+		Features: s.features,
+	}, nil
+}
+
+// Preview returns the report along with whether and where it is sent
+func (s *TelemetryService) Preview(ctx context.Context) (*models.TelemetryStatus, error) {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &models.TelemetryStatus{
+		Enabled:    s.url != "",
+		Blocked:    s.blocked,
+		URL:        s.url,
+		LastSentAt: s.lastSent,
+		Error:      s.lastErr,
+		Report:     *report,
+	}, nil
+}
+
+// Send posts the report to the collector. It does nothing when telemetry
+// is disabled. Failures are kept for the preview.
+func (s *TelemetryService) Send(ctx context.Context) error {
+	if s.url == "" {
+		return nil
+	}
+
+	err := s.send(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastErr = err.Error()
+		return err
+	}
+	now := time.Now().UTC()
+	s.lastSent = &now
+	s.lastErr = ""
+	return nil
+}
+
+func (s *TelemetryService) send(ctx context.Context) error {
+	report, err := s.Report(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Snipo/"+s.version+" (telemetry)")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry report: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry collector returned status %d", resp.StatusCode)
+	}
+	s.logger.DebugContext(ctx, "telemetry report sent")
+	return nil
+}
+
+// snippetBucket reports a snippet count as a coarse range, so the report
+// does not reveal the exact count
+func snippetBucket(count int) string {
+	switch {
+	case count == 0:
+		return "0"
+	case count <= 10:
+		return "1-10"
+	case count <= 100:
+		return "11-100"
+	case count <= 1000:
+		return "101-1000"
+	case count <= 10000:
+		return "1001-10000"
+	default:
+		return "10001+"
+	}
+}
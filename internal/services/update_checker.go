@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/changelog"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/notify"
+)
+
+// UpdateChecker looks up the latest Snipo release on GitHub and reports
+// whether it is newer than the running version. It only contacts GitHub
+// from Check, which is scheduled when the checker is enabled.
+type UpdateChecker struct {
+	version  string
+	repo     string
+	apiURL   string
+	client   *http.Client
+	notifier notify.Notifier
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	status   models.UpdateStatus
+	notified string // Release already notified about
+}
+
+// NewUpdateChecker creates a checker for the running version against the
+// releases of a GitHub repository ("owner/name")
+func NewUpdateChecker(version, repo string, logger *slog.Logger) *UpdateChecker {
+	return &UpdateChecker{
+		version: version,
+		repo:    repo,
+		apiURL:  "https://api.github.com",
+		client:  &http.Client{Timeout: 15 * time.Second},
+		logger:  logger,
+		status:  models.UpdateStatus{CurrentVersion: version},
+	}
+}
+
+// WithAPIURL points the checker at another GitHub API endpoint
+func (c *UpdateChecker) WithAPIURL(u string) *UpdateChecker {
+	c.apiURL = strings.TrimSuffix(u, "/")
+	return c
+}
+
+// WithNotifier sends a notification the first time each newer release is found
+func (c *UpdateChecker) WithNotifier(n notify.Notifier) *UpdateChecker {
+	c.notifier = n
+	return c
+}
+
+// Status returns the outcome of the latest check
+func (c *UpdateChecker) Status() *models.UpdateStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := c.status
+	return &status
+}
+
+// githubRelease is the part of a GitHub release the checker uses
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Check fetches the latest release. Failures are kept in the status, so a
+// GitHub outage shows up there instead of only in the logs.
+func (c *UpdateChecker) Check(ctx context.Context) error {
+	release, err := c.latest(ctx)
+	c.mu.Lock()
+	if err != nil {
+		c.status.Error = err.Error()
+		c.mu.Unlock()
+		return err
+	}
+
+	now := time.Now().UTC()
+	published := release.PublishedAt
+	c.status = models.UpdateStatus{
+		CurrentVersion:  c.version,
+		LatestVersion:   release.TagName,
+		UpdateAvailable: changelog.Compare(release.TagName, c.version) > 0,
+		ReleaseURL:      release.HTMLURL,
+		PublishedAt:     &published,
+		CheckedAt:       &now,
+	}
+	available := c.status.UpdateAvailable
+	send := available && c.notifier != nil && c.notified != release.TagName
+	c.mu.Unlock()
+
+	if !available {
+		return nil
+	}
+	c.logger.InfoContext(ctx, "update available", "current", c.version, "latest", release.TagName)
+	if !send {
+		return nil
+	}
+	if err := c.notifier.Notify(ctx, updateEvent(release, c.version)); err != nil {
+		return fmt.Errorf("failed to send update notification: %w", err)
+	}
+	c.mu.Lock()
+	c.notified = release.TagName
+	c.mu.Unlock()
+	return nil
+}
+
+// latest asks the GitHub API for the latest release, which excludes
+// drafts and pre-releases
+func (c *UpdateChecker) latest(ctx context.Context) (*githubRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/repos/"+c.repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create update request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Snipo/"+c.version+" (update check)")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned status %d", resp.StatusCode)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if release.TagName == "" {
+		return nil, errors.New("release has no tag")
+	}
+	return &release, nil
+}
+
+// updateEvent builds the notification about a newer release
+func updateEvent(release *githubRelease, current string) notify.Event {
+	return notify.Event{
+		Type:    notify.EventUpdateAvailable,
+		Title:   "Snipo update available",
+		Message: fmt.Sprintf("Snipo %s is available; this instance runs %s", release.TagName, current),
+		Fields: map[string]string{
+			"current_version": current,
+			"latest_version":  release.TagName,
+			"release_url":     release.HTMLURL,
+		},
+		Time: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/urlimport"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// ErrBlockOutOfRange is returned when the requested code block does not exist
+var ErrBlockOutOfRange = errors.New("code block index out of range")
+
+// URLImportService creates snippets from code found on web pages
+type URLImportService struct {
+	fetcher    *urlimport.Fetcher
+	snippetSvc *SnippetService
+	logger     *slog.Logger
+}
+
+// NewURLImportService creates a new URL import service
+func NewURLImportService(fetcher *urlimport.Fetcher, snippetSvc *SnippetService, logger *slog.Logger) *URLImportService {
+	return &URLImportService{
+		fetcher:    fetcher,
+		snippetSvc: snippetSvc,
+		logger:     logger,
+	}
+}
+
+// Import fetches the URL, extracts its code blocks and creates a snippet.
+// A single block becomes a plain snippet; several blocks become a multi-file snippet.
+func (s *URLImportService) Import(ctx context.Context, input models.URLImportInput) (*models.Snippet, error) {
+	page, err := s.fetcher.Fetch(ctx, input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	extraction, err := urlimport.Extract(page)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := extraction.Blocks
+	if input.Block != nil {
+		if *input.Block < 1 || *input.Block > len(blocks) {
+			return nil, ErrBlockOutOfRange
+		}
+		blocks = blocks[*input.Block-1 : *input.Block]
+	}
+
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		title = extraction.Title
+	}
+	if utf8.RuneCountInString(title) > 200 {
+		title = string([]rune(title)[:197]) + "..."
+	}
+
+	sourceURL := page.URL.String()
+	snippetInput := &models.SnippetInput{
+		Title:       title,
+		Description: "Imported from " + page.URL.Hostname(),
+		Language:    snippetLanguage(blocks[0].Language),
+		Tags:        input.Tags,
+		FolderID:    input.FolderID,
+		IsPublic:    input.IsPublic,
+		SourceURL:   &sourceURL,
+	}
+
+	if len(blocks) == 1 {
+		snippetInput.Content = blocks[0].Content
+	} else {
+		if max := s.snippetSvc.maxFilesPerSnippet; max > 0 && len(blocks) > max {
+			s.logger.InfoContext(ctx, "truncating imported code blocks", "url", sourceURL, "blocks", len(blocks), "max", max)
+			blocks = blocks[:max]
+		}
+		for i, block := range blocks {
+			lang := snippetLanguage(block.Language)
+			snippetInput.Files = append(snippetInput.Files, models.SnippetFileInput{
+				Filename: fmt.Sprintf("block-%d.%s", i+1, languages.Extension(lang)),
+				Content:  block.Content,
+				Language: lang,
+			})
+		}
+	}
+
+	snippet, err := s.snippetSvc.Create(ctx, snippetInput)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "imported snippet from url", "id", snippet.ID, "url", sourceURL, "blocks", len(blocks))
+	return snippet, nil
+}
+
+// snippetLanguage returns lang when it is a supported snippet language, else plaintext
+func snippetLanguage(lang string) string {
+	for _, allowed := range validation.GetAllowedLanguages() {
+		if lang == allowed {
+			return lang
+		}
+	}
+	return "plaintext"
+}
@@ -0,0 +1,811 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/jobs"
+	"github.com/MohamedElashri/snipo/internal/languages"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/validation"
+	"github.com/MohamedElashri/snipo/internal/vault"
+)
+
+// VaultSyncJob is the job kind of background vault syncs
+const VaultSyncJob = "vault_sync"
+
+// fenceLanguages maps common code fence info strings to snippet languages
+var fenceLanguages = map[string]string{
+	"js": "javascript", "ts": "typescript", "py": "python", "golang": "go",
+	"rs": "rust", "rb": "ruby", "c++": "cpp", "cs": "csharp", "c#": "csharp",
+	"kt": "kotlin", "yml": "yaml", "md": "markdown", "sh": "bash", "zsh": "bash",
+	"console": "shell", "ps1": "powershell", "pwsh": "powershell",
+	"docker": "dockerfile", "make": "makefile", "tf": "terraform", "hcl": "terraform",
+	"proto": "protobuf", "text": "plaintext", "txt": "plaintext",
+}
+
+// invalidTagChars matches characters snippet tags cannot contain, such as
+// the slashes of Obsidian's nested tags
+var invalidTagChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// VaultSyncService keeps an Obsidian vault folder and the snippets in step.
+// Each note mirrors one snippet and each vault directory one folder. A sync
+// compares both sides with what they were after the previous sync, copies
+// changes across and settles conflicts with the configured strategy.
+type VaultSyncService struct {
+	vault      vault.Vault
+	snippetSvc *SnippetService
+	folderRepo *repository.FolderRepository
+	noteRepo   *repository.VaultNoteRepository
+	conflicts  string
+	schedule   time.Duration
+	logger     *slog.Logger
+
+	mu sync.Mutex // Held for the whole of a sync; syncs never overlap
+
+	lastMu   sync.Mutex
+	lastSync *models.VaultSyncResult
+}
+
+// NewVaultSyncService creates a new vault sync service
+func NewVaultSyncService(v vault.Vault, snippetSvc *SnippetService, folderRepo *repository.FolderRepository, noteRepo *repository.VaultNoteRepository, logger *slog.Logger) *VaultSyncService {
+	return &VaultSyncService{
+		vault:      v,
+		snippetSvc: snippetSvc,
+		folderRepo: folderRepo,
+		noteRepo:   noteRepo,
+		conflicts:  models.VaultConflictNewest,
+		logger:     logger,
+	}
+}
+
+// WithConflicts sets which side wins when a note and its snippet both
+// changed: newest, vault, snipo or skip
+func (s *VaultSyncService) WithConflicts(strategy string) *VaultSyncService {
+	if strategy != "" {
+		s.conflicts = strategy
+	}
+	return s
+}
+
+// WithSchedule records the sync interval for Status. Scheduling itself is
+// up to the caller.
+func (s *VaultSyncService) WithSchedule(interval time.Duration) *VaultSyncService {
+	s.schedule = interval
+	return s
+}
+
+// Status describes the vault and the last sync since startup
+func (s *VaultSyncService) Status(ctx context.Context) (*models.VaultStatus, error) {
+	count, err := s.noteRepo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.VaultStatus{
+		Enabled:   true,
+		Location:  s.vault.Location(),
+		Conflicts: s.conflicts,
+		Notes:     count,
+	}
+	if s.schedule > 0 {
+		status.Schedule = s.schedule.String()
+	}
+	s.lastMu.Lock()
+	status.LastSync = s.lastSync
+	s.lastMu.Unlock()
+	return status, nil
+}
+
+// RunSyncJob syncs the vault; it is the handler for VaultSyncJob
+func (s *VaultSyncService) RunSyncJob(ctx context.Context, _ json.RawMessage, p *jobs.Progress) (any, error) {
+	return s.sync(ctx, p)
+}
+
+// Sync copies changes between the vault and the snippets
+func (s *VaultSyncService) Sync(ctx context.Context) (*models.VaultSyncResult, error) {
+	return s.sync(ctx, nil)
+}
+
+func (s *VaultSyncService) sync(ctx context.Context, progress ImportProgress) (*models.VaultSyncResult, error) {
+	if progress == nil {
+		progress = noProgress{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := &vaultRun{
+		svc:    s,
+		result: &models.VaultSyncResult{StartedAt: time.Now().UTC()},
+	}
+	if err := run.load(ctx); err != nil {
+		return nil, err
+	}
+	run.reconcile(ctx, progress)
+	run.result.FinishedAt = time.Now().UTC()
+
+	s.lastMu.Lock()
+	s.lastSync = run.result
+	s.lastMu.Unlock()
+
+	r := run.result
+	s.logger.InfoContext(ctx, "vault synced",
+		"vault", s.vault.Location(),
+		"snippets_created", r.SnippetsCreated,
+		"snippets_updated", r.SnippetsUpdated,
+		"snippets_deleted", r.SnippetsDeleted,
+		"notes_written", r.NotesWritten,
+		"notes_deleted", r.NotesDeleted,
+		"conflicts", len(r.Conflicts),
+		"errors", len(r.Errors),
+		"duration", r.FinishedAt.Sub(r.StartedAt),
+	)
+	return r, nil
+}
+
+// vaultRun holds the state of one sync
+type vaultRun struct {
+	svc    *VaultSyncService
+	result *models.VaultSyncResult
+
+	notes    map[string]vault.Note        // Vault notes by path
+	states   map[string]models.VaultNote  // Sync state by note path
+	snippets map[string]*models.Snippet   // Snippets by ID
+	linked   map[string]bool              // Snippets with a note
+	taken    map[string]bool              // Lowercased note paths in use
+	folders  map[int64]string             // Folder directories by folder ID
+	children map[int64]map[string]int64   // Folder IDs by parent ID (0 for the root) and lowercased name
+	untrack  map[string]*vaultNoteVersion // Unlinked notes by path
+	byID     map[string]string            // Unlinked note paths by front matter ID
+	reads    map[string]*vaultNoteVersion // Linked notes read this run
+	unread   map[string]bool              // Notes that failed to read
+}
+
+// vaultNoteVersion is a note as read from the vault
+type vaultNoteVersion struct {
+	path     string
+	content  []byte
+	modTime  time.Time
+	checksum string
+	parsed   *markdownNote
+	parseErr error
+}
+
+// load lists the notes, sync state, snippets and folders
+func (r *vaultRun) load(ctx context.Context) error {
+	notes, err := r.svc.vault.List(ctx)
+	if err != nil {
+		return err
+	}
+	r.notes = make(map[string]vault.Note, len(notes))
+	r.taken = make(map[string]bool, len(notes))
+	for _, note := range notes {
+		r.notes[note.Path] = note
+		r.taken[strings.ToLower(note.Path)] = true
+	}
+
+	states, err := r.svc.noteRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	r.states = make(map[string]models.VaultNote, len(states))
+	for _, st := range states {
+		r.states[st.Path] = st
+	}
+
+	r.snippets = make(map[string]*models.Snippet)
+	for _, archived := range []bool{false, true} {
+		for page := 1; ; page++ {
+			list, err := r.svc.snippetSvc.List(ctx, models.SnippetFilter{
+				IsArchived: &archived,
+				Page:       page,
+				Limit:      100,
+				SortBy:     "created_at",
+				SortOrder:  "asc",
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list snippets: %w", err)
+			}
+			for _, item := range list.Data {
+				snippet, err := r.svc.snippetSvc.GetByID(ctx, item.ID)
+				if err != nil {
+					return fmt.Errorf("failed to get snippet %s: %w", item.ID, err)
+				}
+				r.snippets[snippet.ID] = snippet
+			}
+			if page >= list.Pagination.TotalPages {
+				break
+			}
+		}
+	}
+	r.linked = make(map[string]bool, len(states))
+
+	folders, err := r.svc.folderRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	r.indexFolders(folders)
+
+	// Linked notes are read only when their modification time moved; every
+	// unlinked note is read, since it may be new or a moved linked note
+	r.reads = make(map[string]*vaultNoteVersion)
+	r.untrack = make(map[string]*vaultNoteVersion)
+	r.byID = make(map[string]string)
+	r.unread = make(map[string]bool)
+	for _, note := range notes {
+		st, tracked := r.states[note.Path]
+		if tracked && st.NoteModTime != nil && !note.ModTime.IsZero() && note.ModTime.Equal(*st.NoteModTime) {
+			continue
+		}
+
+		version, err := r.read(ctx, note.Path)
+		if err != nil {
+			// Left alone this run rather than taken for deleted
+			r.fail("read %s: %v", note.Path, err)
+			r.unread[note.Path] = true
+			continue
+		}
+		if tracked {
+			r.reads[note.Path] = version
+			continue
+		}
+		r.untrack[note.Path] = version
+		if version.parsed != nil && version.parsed.ID != "" {
+			r.byID[version.parsed.ID] = note.Path
+		}
+	}
+	return nil
+}
+
+// read reads and parses a note
+func (r *vaultRun) read(ctx context.Context, p string) (*vaultNoteVersion, error) {
+	content, modTime, err := r.svc.vault.Read(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	version := &vaultNoteVersion{
+		path:     p,
+		content:  content,
+		modTime:  modTime,
+		checksum: checksumHex(content),
+	}
+	version.parsed, version.parseErr = parseMarkdownNote(p, content)
+	return version, nil
+}
+
+// indexFolders maps folders to vault directories
+func (r *vaultRun) indexFolders(folders []models.Folder) {
+	byID := make(map[int64]models.Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+
+	r.folders = make(map[int64]string, len(folders))
+	r.children = make(map[int64]map[string]int64)
+	var dir func(id int64, depth int) string
+	dir = func(id int64, depth int) string {
+		if d, ok := r.folders[id]; ok {
+			return d
+		}
+		f := byID[id]
+		name := folderDirName(f.Name)
+		if f.ParentID != nil && depth < 32 {
+			if _, ok := byID[*f.ParentID]; ok {
+				name = dir(*f.ParentID, depth+1) + "/" + name
+			}
+		}
+		r.folders[id] = name
+		return name
+	}
+
+	for _, f := range folders {
+		dir(f.ID, 0)
+		var parent int64
+		if f.ParentID != nil {
+			parent = *f.ParentID
+		}
+		if r.children[parent] == nil {
+			r.children[parent] = make(map[string]int64)
+		}
+		r.children[parent][strings.ToLower(folderDirName(f.Name))] = f.ID
+	}
+}
+
+// folderDirName is the vault directory name of a folder
+func folderDirName(name string) string {
+	name = strings.TrimLeft(strings.TrimSpace(sanitizeFilename(name)), ".")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// folderFor returns the folder mirroring a vault directory, creating the
+// missing folders along its path
+func (r *vaultRun) folderFor(ctx context.Context, dir string) (*int64, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var parent int64
+	for _, name := range strings.Split(dir, "/") {
+		key := strings.ToLower(folderDirName(name))
+		if id, ok := r.children[parent][key]; ok {
+			parent = id
+			continue
+		}
+
+		input := &models.FolderInput{Name: name}
+		if parent != 0 {
+			input.ParentID = &parent
+		}
+		if errs := validation.ValidateFolderInput(input.Name); errs.HasErrors() {
+			return nil, errs
+		}
+		folder, err := r.svc.folderRepo.Create(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.children[parent] == nil {
+			r.children[parent] = make(map[string]int64)
+		}
+		r.children[parent][key] = folder.ID
+		r.folders[folder.ID] = strings.TrimPrefix(r.folders[parent]+"/"+folderDirName(name), "/")
+		parent = folder.ID
+	}
+	return &parent, nil
+}
+
+// snippetDir returns the vault directory of a snippet's folder
+func (r *vaultRun) snippetDir(snippet *models.Snippet) string {
+	if len(snippet.Folders) == 0 {
+		return ""
+	}
+	return r.folders[snippet.Folders[0].ID]
+}
+
+// reconcile works through the linked notes, then the unlinked notes, then
+// the snippets without a note
+func (r *vaultRun) reconcile(ctx context.Context, progress ImportProgress) {
+	paths := make([]string, 0, len(r.states))
+	for p := range r.states {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var unlinkedSnippets []string
+	linkedIDs := make(map[string]bool, len(r.states))
+	for _, st := range r.states {
+		linkedIDs[st.SnippetID] = true
+	}
+	for id := range r.snippets {
+		if !linkedIDs[id] {
+			unlinkedSnippets = append(unlinkedSnippets, id)
+		}
+	}
+	sort.Slice(unlinkedSnippets, func(i, j int) bool {
+		a, b := r.snippets[unlinkedSnippets[i]], r.snippets[unlinkedSnippets[j]]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	progress.SetTotal(len(paths) + len(r.untrack) + len(unlinkedSnippets))
+
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			r.fail("sync interrupted: %v", err)
+			return
+		}
+		if err := r.syncLinked(ctx, r.states[p]); err != nil {
+			r.fail("%s: %v", p, err)
+			progress.Error(fmt.Sprintf("%s: %v", p, err))
+		}
+		progress.Advance(1)
+	}
+
+	untracked := make([]string, 0, len(r.untrack))
+	for p := range r.untrack {
+		untracked = append(untracked, p)
+	}
+	sort.Strings(untracked)
+	for _, p := range untracked {
+		version, ok := r.untrack[p]
+		if !ok {
+			// Claimed as the new path of a moved note
+			progress.Advance(1)
+			continue
+		}
+		if err := r.syncUnlinked(ctx, version); err != nil {
+			r.fail("%s: %v", p, err)
+			progress.Error(fmt.Sprintf("%s: %v", p, err))
+		}
+		progress.Advance(1)
+	}
+
+	for _, id := range unlinkedSnippets {
+		if !r.linked[id] {
+			if err := r.exportSnippet(ctx, r.snippets[id]); err != nil {
+				r.fail("snippet %s: %v", id, err)
+				progress.Error(fmt.Sprintf("snippet %s: %v", id, err))
+			}
+		}
+		progress.Advance(1)
+	}
+}
+
+// syncLinked syncs a note that was linked to a snippet by an earlier sync
+func (r *vaultRun) syncLinked(ctx context.Context, st models.VaultNote) error {
+	snippet := r.snippets[st.SnippetID]
+	if snippet != nil {
+		r.linked[snippet.ID] = true
+	}
+	if r.unread[st.Path] {
+		return nil
+	}
+
+	var version *vaultNoteVersion
+	if _, present := r.notes[st.Path]; !present {
+		// A note with the snippet's ID elsewhere was moved or renamed
+		if moved, ok := r.byID[st.SnippetID]; ok && snippet != nil {
+			version = r.untrack[moved]
+			delete(r.untrack, moved)
+			delete(r.byID, st.SnippetID)
+			if err := r.svc.noteRepo.Delete(ctx, st.Path); err != nil {
+				return err
+			}
+			st.Path = moved
+			// The directory may have changed, so the note is applied even
+			// when its content did not
+			st.NoteChecksum = ""
+		} else {
+			return r.noteDeleted(ctx, st, snippet)
+		}
+	} else {
+		version = r.reads[st.Path]
+	}
+
+	noteChanged := version != nil && version.checksum != st.NoteChecksum
+	if version != nil && !noteChanged {
+		// Same content with a new modification time; remember it so the
+		// next sync can skip reading the note
+		if err := r.saveModTime(ctx, st, version.modTime); err != nil {
+			return err
+		}
+	}
+
+	if snippet == nil {
+		if noteChanged {
+			// Edited in the vault after the snippet was deleted: the edit wins
+			return r.importNote(ctx, version, nil)
+		}
+		if err := r.svc.vault.Delete(ctx, st.Path); err != nil {
+			return err
+		}
+		r.result.NotesDeleted++
+		return r.svc.noteRepo.Delete(ctx, st.Path)
+	}
+
+	snippetChanged := renderChecksum(snippet) != st.SnippetChecksum
+	switch {
+	case !noteChanged && !snippetChanged:
+		r.result.Unchanged++
+		return nil
+	case noteChanged && !snippetChanged:
+		return r.importNote(ctx, version, snippet)
+	case !noteChanged && snippetChanged:
+		return r.writeNote(ctx, st.Path, snippet)
+	}
+
+	winner := r.resolve(version, snippet)
+	r.result.Conflicts = append(r.result.Conflicts, models.VaultConflict{Path: st.Path, SnippetID: snippet.ID, Winner: winner})
+	switch winner {
+	case models.VaultConflictVault:
+		return r.importNote(ctx, version, snippet)
+	case models.VaultConflictSnipo:
+		return r.writeNote(ctx, st.Path, snippet)
+	}
+	return nil
+}
+
+// noteDeleted handles a linked note that is gone from the vault
+func (r *vaultRun) noteDeleted(ctx context.Context, st models.VaultNote, snippet *models.Snippet) error {
+	switch {
+	case snippet == nil:
+		// Deleted on both sides
+	case renderChecksum(snippet) != st.SnippetChecksum:
+		// Edited in snipo after the note was deleted: the edit wins
+		return r.writeNote(ctx, st.Path, snippet)
+	default:
+		if err := r.svc.snippetSvc.Delete(ctx, snippet.ID); err != nil && !errors.Is(err, ErrSnippetNotFound) {
+			return err
+		}
+		r.result.SnippetsDeleted++
+	}
+	return r.svc.noteRepo.Delete(ctx, st.Path)
+}
+
+// syncUnlinked syncs a note without a link: a new note, or a Markdown export
+// of a snippet that has no note yet
+func (r *vaultRun) syncUnlinked(ctx context.Context, version *vaultNoteVersion) error {
+	if errors.Is(version.parseErr, errNoCodeBlocks) {
+		r.result.Skipped = append(r.result.Skipped, version.path)
+		return nil
+	}
+	if version.parseErr != nil {
+		return version.parseErr
+	}
+
+	snippet := r.snippets[version.parsed.ID]
+	if snippet == nil || r.linked[snippet.ID] {
+		return r.importNote(ctx, version, nil)
+	}
+
+	r.linked[snippet.ID] = true
+	if renderChecksum(snippet) == version.checksum {
+		r.result.Unchanged++
+		return r.saveState(ctx, version.path, snippet.ID, version.modTime, version.checksum, version.checksum)
+	}
+
+	winner := r.resolve(version, snippet)
+	r.result.Conflicts = append(r.result.Conflicts, models.VaultConflict{Path: version.path, SnippetID: snippet.ID, Winner: winner})
+	switch winner {
+	case models.VaultConflictVault:
+		return r.importNote(ctx, version, snippet)
+	case models.VaultConflictSnipo:
+		return r.writeNote(ctx, version.path, snippet)
+	}
+	return nil
+}
+
+// resolve picks the winner of a conflict
+func (r *vaultRun) resolve(version *vaultNoteVersion, snippet *models.Snippet) string {
+	switch r.svc.conflicts {
+	case models.VaultConflictVault, models.VaultConflictSnipo:
+		return r.svc.conflicts
+	case models.VaultConflictSkip:
+		return ""
+	}
+	// Without a modification time the note cannot be shown to be newer
+	if !version.modTime.IsZero() && version.modTime.After(snippet.UpdatedAt) {
+		return models.VaultConflictVault
+	}
+	return models.VaultConflictSnipo
+}
+
+// importNote creates a snippet from a note, or updates existing from it
+func (r *vaultRun) importNote(ctx context.Context, version *vaultNoteVersion, existing *models.Snippet) error {
+	if version.parseErr != nil {
+		return version.parseErr
+	}
+
+	input, err := r.noteInput(ctx, version, existing)
+	if err != nil {
+		return err
+	}
+
+	var saved *models.Snippet
+	if existing == nil {
+		saved, err = r.svc.snippetSvc.Create(ctx, input)
+	} else {
+		saved, err = r.svc.snippetSvc.Update(ctx, existing.ID, input)
+	}
+	if err != nil {
+		return err
+	}
+	// Reload so the checksum covers tags, folders and files as stored
+	if saved, err = r.svc.snippetSvc.GetByID(ctx, saved.ID); err != nil {
+		return err
+	}
+	r.snippets[saved.ID] = saved
+	r.linked[saved.ID] = true
+
+	if existing == nil {
+		r.result.SnippetsCreated++
+	} else {
+		r.result.SnippetsUpdated++
+	}
+	return r.saveState(ctx, version.path, saved.ID, version.modTime, version.checksum, renderChecksum(saved))
+}
+
+// noteInput builds the snippet input for a note, keeping what notes do not
+// carry (visibility, archiving, slug, metadata, file IDs) from existing
+func (r *vaultRun) noteInput(ctx context.Context, version *vaultNoteVersion, existing *models.Snippet) (*models.SnippetInput, error) {
+	note := version.parsed
+	folderID, err := r.folderFor(ctx, noteDir(version.path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	input := &models.SnippetInput{
+		Title:       note.Title,
+		Description: note.Description,
+		Content:     note.Blocks[0].Content,
+		Language:    noteLanguage(note.Blocks[0].Language),
+		Tags:        noteTagNames(note.Tags),
+		FolderID:    folderID,
+	}
+	if note.Source != "" || existing != nil {
+		// On update an empty value clears the one removed from the note
+		input.SourceURL = &note.Source
+	}
+	if note.License != "" || existing != nil {
+		input.License = &note.License
+	}
+	if note.Attribution != "" || existing != nil {
+		input.Attribution = &note.Attribution
+	}
+	if existing != nil {
+		input.IsPublic = existing.IsPublic
+		input.IsArchived = existing.IsArchived
+	}
+
+	used := make(map[string]bool, len(note.Blocks))
+	for i, block := range note.Blocks {
+		file := models.SnippetFileInput{
+			Filename: strings.TrimSpace(block.Filename),
+			Content:  block.Content,
+			Language: noteLanguage(block.Language),
+		}
+		// Single-file notes have no heading, so the name is kept
+		if existing != nil && i < len(existing.Files) {
+			file.ID = existing.Files[i].ID
+			if file.Filename == "" {
+				file.Filename = existing.Files[i].Filename
+			}
+		}
+		if file.Filename == "" || validation.ValidateFilename(file.Filename).HasErrors() || used[strings.ToLower(file.Filename)] {
+			file.Filename = fmt.Sprintf("file%d.%s", i+1, languages.Extension(file.Language))
+			if i == 0 {
+				file.Filename = "snippet." + languages.Extension(file.Language)
+			}
+		}
+		used[strings.ToLower(file.Filename)] = true
+		input.Files = append(input.Files, file)
+	}
+	return input, nil
+}
+
+// noteLanguage maps a code fence info string to a snippet language
+func noteLanguage(info string) string {
+	if lang, ok := fenceLanguages[info]; ok {
+		return lang
+	}
+	return snippetLanguage(info)
+}
+
+// noteTagNames turns note tags into valid snippet tag names
+func noteTagNames(tags []string) []string {
+	names := []string{} // Not nil: a note without tags clears them
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name := strings.Trim(invalidTagChars.ReplaceAllString(tag, "-"), "-")
+		if len(name) > 50 {
+			name = name[:50]
+		}
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// writeNote renders a snippet over its note. A snippet moved to another
+// folder takes its note to the matching directory, keeping the file name.
+func (r *vaultRun) writeNote(ctx context.Context, p string, snippet *models.Snippet) error {
+	content, err := snippetMarkdown(*snippet)
+	if err != nil {
+		return err
+	}
+
+	target := p
+	if dir := r.snippetDir(snippet); dir != noteDir(p) {
+		moved := path.Join(dir, path.Base(p))
+		if !r.taken[strings.ToLower(moved)] {
+			target = moved
+		}
+	}
+
+	if err := r.svc.vault.Write(ctx, target, content); err != nil {
+		return err
+	}
+	r.taken[strings.ToLower(target)] = true
+	r.result.NotesWritten++
+
+	if target != p {
+		if err := r.svc.vault.Delete(ctx, p); err != nil {
+			return err
+		}
+		delete(r.taken, strings.ToLower(p))
+		if err := r.svc.noteRepo.Delete(ctx, p); err != nil {
+			return err
+		}
+		r.result.NotesMoved++
+	}
+
+	r.linked[snippet.ID] = true
+	checksum := checksumHex(content)
+	// The modification time is learned on the next sync
+	return r.saveState(ctx, target, snippet.ID, time.Time{}, checksum, checksum)
+}
+
+// exportSnippet writes a note for a snippet that has none
+func (r *vaultRun) exportSnippet(ctx context.Context, snippet *models.Snippet) error {
+	dir := r.snippetDir(snippet)
+	used := make(map[string]bool)
+	for taken := range r.taken {
+		if noteDir(taken) == strings.ToLower(dir) {
+			used[path.Base(taken)] = true
+		}
+	}
+	return r.writeNote(ctx, path.Join(dir, uniqueNoteName(snippet.Title, used)), snippet)
+}
+
+// noteDir returns the directory of a note path, empty at the folder root
+func noteDir(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// saveModTime records a note's new modification time
+func (r *vaultRun) saveModTime(ctx context.Context, st models.VaultNote, modTime time.Time) error {
+	if modTime.IsZero() || (st.NoteModTime != nil && st.NoteModTime.Equal(modTime)) {
+		return nil
+	}
+	st.NoteModTime = &modTime
+	return r.svc.noteRepo.Save(ctx, &st)
+}
+
+// saveState links the note at p to a snippet
+func (r *vaultRun) saveState(ctx context.Context, p, snippetID string, modTime time.Time, noteChecksum, snippetChecksum string) error {
+	st := models.VaultNote{
+		Path:            p,
+		SnippetID:       snippetID,
+		NoteChecksum:    noteChecksum,
+		SnippetChecksum: snippetChecksum,
+		SyncedAt:        time.Now().UTC(),
+	}
+	if !modTime.IsZero() {
+		st.NoteModTime = &modTime
+	}
+	return r.svc.noteRepo.Save(ctx, &st)
+}
+
+// fail records a non-fatal error
+func (r *vaultRun) fail(format string, args ...any) {
+	r.result.Errors = append(r.result.Errors, fmt.Sprintf(format, args...))
+}
+
+// renderChecksum fingerprints a snippet by its note rendering, so it changes
+// exactly when the note would
+func renderChecksum(snippet *models.Snippet) string {
+	content, err := snippetMarkdown(*snippet)
+	if err != nil {
+		return ""
+	}
+	return checksumHex(content)
+}
+
+// checksumHex returns the hex SHA-256 of content
+func checksumHex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+// wikiLinkPattern matches [[Title]] and [[Title|label]] references
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|[^\[\]\n]*)?\]\]`)
+
+// wikiTitles returns the titles referenced as [[Title]] in markdown
+func wikiTitles(content string) []string {
+	var titles []string
+	for _, m := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		titles = append(titles, m[1])
+	}
+	return titles
+}
+
+// uniqueTitles trims titles and drops blanks and case-insensitive repeats
+func uniqueTitles(titles []string) []string {
+	seen := make(map[string]bool, len(titles))
+	var unique []string
+	for _, title := range titles {
+		title = strings.TrimSpace(title)
+		key := strings.ToLower(title)
+		if title == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, title)
+	}
+	return unique
+}
+
+// ResolveLinks maps wiki-style titles, given directly or as [[Title]]
+// references in markdown content, to the snippets with those titles. With
+// CreateStubs, unknown titles get a new markdown snippet to fill in later.
+func (s *SnippetService) ResolveLinks(ctx context.Context, input *models.ResolveLinksInput) (*models.ResolveLinksResult, error) {
+	titles := uniqueTitles(append(input.Titles, wikiTitles(input.Content)...))
+	if errs := validation.ValidateResolveTitles(titles); errs.HasErrors() {
+		return nil, errs
+	}
+
+	result := &models.ResolveLinksResult{Links: []models.ResolvedLink{}, Unresolved: []string{}}
+	for _, title := range titles {
+		snippet, err := s.repo.GetByTitle(ctx, title)
+		if err != nil {
+			return nil, err
+		}
+
+		created := false
+		if snippet == nil && input.CreateStubs {
+			snippet, err = s.Create(ctx, &models.SnippetInput{Title: title, Content: "# " + title + "\n", Language: "markdown"})
+			// Titles that are not valid snippet titles stay unresolved
+			var validationErrs validation.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				snippet, err = nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			created = snippet != nil
+		}
+
+		if snippet == nil {
+			result.Unresolved = append(result.Unresolved, title)
+			continue
+		}
+		result.Links = append(result.Links, models.ResolvedLink{Title: title, SnippetID: snippet.ID, Slug: snippet.Slug, Created: created})
+	}
+
+	return result, nil
+}
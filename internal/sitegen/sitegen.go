@@ -0,0 +1,386 @@
+// Package sitegen renders public snippets into a static HTML site.
+//
+// The generated site uses relative links only, so it can be served from any
+// path (e.g. a GitHub Pages project site) or opened straight from disk.
+package sitegen
+
+import (
+	"archive/zip"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+//go:embed templates/*.html templates/style.css templates/search.js
+var templatesFS embed.FS
+
+// Writer receives the generated files. Names use forward slashes.
+type Writer interface {
+	WriteFile(name string, data []byte) error
+}
+
+// Asset is an extra static file copied to assets/ and linked from every page.
+// Assets are included in order, so dependencies must come first.
+type Asset struct {
+	Name string
+	Data []byte
+}
+
+// Site describes the content to render
+type Site struct {
+	Title       string
+	Snippets    []models.Snippet
+	Assets      []Asset
+	GeneratedAt time.Time
+}
+
+// Result summarizes a render
+type Result struct {
+	Snippets int `json:"snippets"`
+	Tags     int `json:"tags"`
+	Files    int `json:"files"`
+}
+
+// SearchEntry is one record in search.json
+type SearchEntry struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Language    string   `json:"language"`
+	Tags        []string `json:"tags,omitempty"`
+	URL         string   `json:"url"`
+}
+
+type fileView struct {
+	Filename string
+	Language string
+	Content  string
+}
+
+type tagView struct {
+	Name  string
+	Color string
+	URL   string
+	Count int
+}
+
+type snippetView struct {
+	ID          string
+	Title       string
+	Description string
+	Language    string
+	URL         string
+	SourceURL   string
+	License     string
+	Attribution string
+	NoIndex     bool
+	Files       []fileView
+	Tags        []tagView
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// pageData is passed to every page template
+type pageData struct {
+	SiteTitle   string
+	PageTitle   string
+	Root        string // relative prefix back to the site root
+	Styles      []string
+	Scripts     []string
+	GeneratedAt time.Time
+	Snippets    []snippetView
+	Snippet     *snippetView
+	Tags        []tagView
+	Tag         *tagView
+	NoIndex     bool // Ask search engines not to index the page
+}
+
+// Render writes the site to w: index.html, snippets/<id>.html,
+// tags/<slug>.html, search.json and the stylesheet and assets.
+func Render(site Site, w Writer) (*Result, error) {
+	if site.Title == "" {
+		site.Title = "Snipo"
+	}
+	if site.GeneratedAt.IsZero() {
+		site.GeneratedAt = time.Now().UTC()
+	}
+
+	pages, err := parseTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	snippets, tags, byTag := buildViews(site.Snippets)
+	result := &Result{Snippets: len(snippets), Tags: len(tags)}
+
+	styles := []string{"assets/style.css"}
+	var scripts []string
+	for _, a := range site.Assets {
+		switch path.Ext(a.Name) {
+		case ".css":
+			styles = append(styles, "assets/"+a.Name)
+		case ".js":
+			scripts = append(scripts, "assets/"+a.Name)
+		}
+	}
+
+	emit := func(name string, data []byte) error {
+		if err := w.WriteFile(name, data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		result.Files++
+		return nil
+	}
+	page := func(name, tmpl string, data pageData) error {
+		data.SiteTitle = site.Title
+		data.Styles = styles
+		data.Scripts = scripts
+		data.GeneratedAt = site.GeneratedAt
+		var buf bytes.Buffer
+		if err := pages[tmpl].ExecuteTemplate(&buf, "layout.html", data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		return emit(name, buf.Bytes())
+	}
+
+	if err := page("index.html", "index.html", pageData{PageTitle: site.Title, Snippets: snippets, Tags: tags}); err != nil {
+		return nil, err
+	}
+	for i := range snippets {
+		s := &snippets[i]
+		if err := page(s.URL, "snippet.html", pageData{PageTitle: s.Title, Root: "../", Snippet: s, NoIndex: s.NoIndex}); err != nil {
+			return nil, err
+		}
+	}
+	for i := range tags {
+		t := &tags[i]
+		if err := page(t.URL, "tag.html", pageData{PageTitle: "#" + t.Name, Root: "../", Tag: t, Snippets: byTag[t.Name]}); err != nil {
+			return nil, err
+		}
+	}
+
+	index, err := json.MarshalIndent(searchIndex(snippets), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal search index: %w", err)
+	}
+	if err := emit("search.json", index); err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"style.css", "search.js"} {
+		data, err := templatesFS.ReadFile("templates/" + name)
+		if err != nil {
+			return nil, err
+		}
+		if err := emit("assets/"+name, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range site.Assets {
+		if err := emit("assets/"+a.Name, a.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Tell GitHub Pages not to run the site through Jekyll
+	if err := emit(".nojekyll", nil); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func parseTemplates() (map[string]*template.Template, error) {
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	}
+
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"index.html", "snippet.html", "tag.html"} {
+		tmpl, err := template.New(name).Funcs(funcs).ParseFS(templatesFS, "templates/layout.html", "templates/list.html", "templates/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		pages[name] = tmpl
+	}
+	return pages, nil
+}
+
+// buildViews converts snippets to template views, newest first, and groups them by tag
+func buildViews(in []models.Snippet) ([]snippetView, []tagView, map[string][]snippetView) {
+	sorted := append([]models.Snippet(nil), in...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].UpdatedAt.Equal(sorted[j].UpdatedAt) {
+			return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	tagsByName := make(map[string]*tagView)
+	slugs := make(map[string]bool)
+	tagFor := func(t models.Tag) *tagView {
+		if v, ok := tagsByName[t.Name]; ok {
+			return v
+		}
+		slug := uniqueSlug(Slugify(t.Name), slugs)
+		v := &tagView{Name: t.Name, Color: t.Color, URL: "tags/" + slug + ".html"}
+		tagsByName[t.Name] = v
+		return v
+	}
+
+	snippets := make([]snippetView, 0, len(sorted))
+	byTag := make(map[string][]snippetView)
+	for _, s := range sorted {
+		v := snippetView{
+			ID:          s.ID,
+			Title:       s.Title,
+			Description: s.Description,
+			Language:    s.Language,
+			URL:         "snippets/" + safeID(s.ID) + ".html",
+			NoIndex:     s.NoIndex,
+			CreatedAt:   s.CreatedAt,
+			UpdatedAt:   s.UpdatedAt,
+		}
+		if s.SourceURL != nil {
+			v.SourceURL = *s.SourceURL
+		}
+		if s.License != nil {
+			v.License = *s.License
+		}
+		if s.Attribution != nil {
+			v.Attribution = *s.Attribution
+		}
+		if len(s.Files) > 0 {
+			for _, f := range s.Files {
+				v.Files = append(v.Files, fileView{Filename: f.Filename, Language: f.Language, Content: f.Content})
+			}
+		} else {
+			v.Files = []fileView{{Language: s.Language, Content: s.Content}}
+		}
+		for _, t := range s.Tags {
+			tv := tagFor(t)
+			tv.Count++
+			v.Tags = append(v.Tags, *tv)
+		}
+		snippets = append(snippets, v)
+		for _, t := range s.Tags {
+			byTag[t.Name] = append(byTag[t.Name], v)
+		}
+	}
+
+	tags := make([]tagView, 0, len(tagsByName))
+	for _, t := range tagsByName {
+		tags = append(tags, *t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name) })
+
+	return snippets, tags, byTag
+}
+
+func searchIndex(snippets []snippetView) []SearchEntry {
+	entries := make([]SearchEntry, 0, len(snippets))
+	for _, s := range snippets {
+		e := SearchEntry{
+			ID:          s.ID,
+			Title:       s.Title,
+			Description: s.Description,
+			Language:    s.Language,
+			URL:         s.URL,
+		}
+		for _, t := range s.Tags {
+			e.Tags = append(e.Tags, t.Name)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Slugify lowercases s and replaces runs of non-alphanumeric characters with dashes
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "tag"
+	}
+	return slug
+}
+
+// uniqueSlug appends a counter when slug is already taken
+func uniqueSlug(slug string, taken map[string]bool) string {
+	candidate := slug
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// safeID keeps IDs usable as file names
+func safeID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// DirWriter writes files below a directory on disk
+type DirWriter string
+
+// WriteFile creates any parent directories and writes the file
+func (d DirWriter) WriteFile(name string, data []byte) error {
+	target := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+// ZipWriter writes files into a zip archive
+type ZipWriter struct {
+	zw *zip.Writer
+}
+
+// NewZipWriter creates a ZipWriter writing to w. Call Close to finish the archive.
+func NewZipWriter(w io.Writer) *ZipWriter {
+	return &ZipWriter{zw: zip.NewWriter(w)}
+}
+
+// WriteFile adds a file to the archive
+func (z *ZipWriter) WriteFile(name string, data []byte) error {
+	f, err := z.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// Close finalizes the archive
+func (z *ZipWriter) Close() error {
+	return z.zw.Close()
+}
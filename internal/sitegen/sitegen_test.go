@@ -0,0 +1,134 @@
+package sitegen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// memWriter collects generated files in memory
+type memWriter map[string][]byte
+
+func (m memWriter) WriteFile(name string, data []byte) error {
+	m[name] = data
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	license := "MIT"
+	site := Site{
+		Title: "Team Snippets",
+		Snippets: []models.Snippet{
+			{
+				ID:        "abc123",
+				Title:     "Hello <script>alert(1)</script>",
+				Language:  "go",
+				Content:   "fmt.Println(\"<b>\")",
+				Tags:      []models.Tag{{Name: "Go Lang"}, {Name: "cli"}},
+				UpdatedAt: now,
+			},
+			{
+				ID:       "def456",
+				Title:    "Multi file",
+				Language: "python",
+				Files: []models.SnippetFile{
+					{Filename: "main.py", Language: "python", Content: "print(1)"},
+					{Filename: "util.py", Language: "python", Content: "x = 2"},
+				},
+				Tags:      []models.Tag{{Name: "cli"}},
+				License:   &license,
+				NoIndex:   true,
+				UpdatedAt: now.Add(-time.Hour),
+			},
+		},
+		Assets:      []Asset{{Name: "prism.min.js", Data: []byte("//prism")}},
+		GeneratedAt: now,
+	}
+
+	w := memWriter{}
+	result, err := Render(site, w)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if result.Snippets != 2 || result.Tags != 2 {
+		t.Errorf("expected 2 snippets and 2 tags, got %+v", result)
+	}
+	for _, name := range []string{
+		"index.html", "snippets/abc123.html", "snippets/def456.html",
+		"tags/go-lang.html", "tags/cli.html", "search.json",
+		"assets/style.css", "assets/search.js", "assets/prism.min.js", ".nojekyll",
+	} {
+		if _, ok := w[name]; !ok {
+			t.Errorf("missing generated file %s", name)
+		}
+	}
+
+	page := string(w["snippets/abc123.html"])
+	if strings.Contains(page, "<script>alert(1)</script>") || strings.Contains(page, "<b>") {
+		t.Error("snippet title/content was not HTML-escaped")
+	}
+	if !strings.Contains(page, `href="../assets/style.css"`) || !strings.Contains(page, `src="../assets/prism.min.js"`) {
+		t.Error("snippet page should link assets relative to the site root")
+	}
+
+	multi := string(w["snippets/def456.html"])
+	if !strings.Contains(multi, "main.py") || !strings.Contains(multi, "util.py") {
+		t.Error("multi-file snippet should render every file")
+	}
+	if !strings.Contains(multi, "License: MIT") || strings.Contains(page, "License:") {
+		t.Error("only licensed snippets should show a license")
+	}
+	if !strings.Contains(multi, `<meta name="robots" content="noindex">`) || strings.Contains(page, `name="robots"`) {
+		t.Error("only noindex snippets should ask search engines not to index them")
+	}
+
+	tagPage := string(w["tags/cli.html"])
+	if !strings.Contains(tagPage, "2 snippets") {
+		t.Errorf("tag page should list both snippets:\n%s", tagPage)
+	}
+
+	var index []SearchEntry
+	if err := json.Unmarshal(w["search.json"], &index); err != nil {
+		t.Fatalf("search.json is not valid JSON: %v", err)
+	}
+	if len(index) != 2 || index[0].ID != "abc123" || index[0].URL != "snippets/abc123.html" {
+		t.Errorf("unexpected search index (should be newest first): %+v", index)
+	}
+}
+
+func TestRender_TagSlugCollision(t *testing.T) {
+	w := memWriter{}
+	_, err := Render(Site{Snippets: []models.Snippet{
+		{ID: "a", Title: "A", Tags: []models.Tag{{Name: "C++"}, {Name: "c"}}},
+	}}, w)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if _, ok := w["tags/c.html"]; !ok {
+		t.Error("expected tags/c.html")
+	}
+	if _, ok := w["tags/c-2.html"]; !ok {
+		t.Error("expected colliding slug to get a suffix (tags/c-2.html)")
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Go Lang":     "go-lang",
+		"  k8s  ":     "k8s",
+		"C++":         "c",
+		"node.js/npm": "node-js-npm",
+		"日本語":         "tag",
+	}
+	for in, want := range tests {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
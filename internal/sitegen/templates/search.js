@@ -0,0 +1,34 @@
+// Client-side filtering of the snippet list using search.json
+(function () {
+    var input = document.getElementById('search');
+    var list = document.getElementById('snippet-list');
+    if (!input || !list) {
+        return;
+    }
+
+    var items = list.querySelectorAll('li[data-id]');
+    var entries = {};
+
+    // Fall back to the rendered text when search.json cannot be fetched (e.g. file://)
+    items.forEach(function (item) {
+        entries[item.dataset.id] = item.textContent.toLowerCase();
+    });
+
+    fetch('search.json')
+        .then(function (res) { return res.ok ? res.json() : []; })
+        .then(function (index) {
+            index.forEach(function (e) {
+                entries[e.id] = [e.title, e.description, e.language].concat(e.tags || []).join(' ').toLowerCase();
+            });
+        })
+        .catch(function () {});
+
+    input.addEventListener('input', function () {
+        var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+        items.forEach(function (item) {
+            var text = entries[item.dataset.id] || '';
+            var match = terms.every(function (t) { return text.indexOf(t) !== -1; });
+            item.hidden = !match;
+        });
+    });
+})();
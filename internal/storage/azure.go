@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// AzureConfig holds Azure Blob Storage configuration
+type AzureConfig struct {
+	AccountName     string
+	AccountKey      string
+	Endpoint        string // Service URL; default https://<account>.blob.core.windows.net
+	Container       string
+	EncryptionScope string            // Optional encryption scope for uploads
+	Tags            map[string]string // Blob index tags applied to every upload
+	KeyPrefix       string            // Key prefix template; see ExpandPrefix
+}
+
+// AzureStorage provides Azure Blob Storage operations
+type AzureStorage struct {
+	keyTemplate
+	client    *container.Client
+	container string
+	scope     *blob.CPKScopeInfo
+	tags      map[string]string
+}
+
+// NewAzureStorage creates a new Azure Blob Storage client
+func NewAzureStorage(ctx context.Context, cfg AzureConfig) (*AzureStorage, error) {
+	if cfg.AccountName == "" || cfg.AccountKey == "" {
+		return nil, errors.New("azure storage requires an account name and key")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccountName)
+	}
+	containerURL := strings.TrimSuffix(endpoint, "/") + "/" + cfg.Container
+
+	cred, err := container.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure credentials: %w", err)
+	}
+	client, err := container.NewClientWithSharedKeyCredential(containerURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure client: %w", err)
+	}
+
+	// Ensure container exists
+	if _, err := client.Create(ctx, nil); err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	s := &AzureStorage{
+		keyTemplate: newKeyTemplate(cfg.KeyPrefix),
+		client:      client,
+		container:   cfg.Container,
+		tags:        cfg.Tags,
+	}
+	if cfg.EncryptionScope != "" {
+		s.scope = &blob.CPKScopeInfo{EncryptionScope: &cfg.EncryptionScope}
+	}
+	return s, nil
+}
+
+// Upload uploads content to a block blob
+func (s *AzureStorage) Upload(ctx context.Context, key string, content []byte, contentType string) error {
+	_, err := s.client.NewBlockBlobClient(key).Upload(ctx, streaming.NopCloser(bytes.NewReader(content)), &blockblob.UploadOptions{
+		HTTPHeaders:  &blob.HTTPHeaders{BlobContentType: &contentType},
+		Tags:         s.tags,
+		CPKScopeInfo: s.scope,
+	})
+	return err
+}
+
+// azureBlockID names a block after its position and content, so blocks
+// staged by an interrupted upload can be recognised and reused
+func azureBlockID(p part) string {
+	return base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%05d-%s", p.number, p.md5))
+}
+
+// UploadLarge uploads content as staged blocks, retrying failed blocks.
+// Blocks left uncommitted by an interrupted upload of the same content are
+// reused. The committed blob is verified against the content's MD5 and size.
+func (s *AzureStorage) UploadLarge(ctx context.Context, key string, content []byte, contentType string, opts UploadOptions) (*UploadResult, error) {
+	if opts.PartSize < minPartSize {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultPartRetries
+	}
+
+	sha := sha256Hex(content)
+	sum := md5.Sum(content)
+	result := &UploadResult{Key: key, Size: int64(len(content)), SHA256: sha}
+	client := s.client.NewBlockBlobClient(key)
+
+	parts := splitParts(content, opts.PartSize)
+	result.Parts = len(parts)
+
+	// Uncommitted blocks survive for a week; a missing blob has none
+	staged := map[string]int64{}
+	list, err := client.GetBlockList(ctx, blockblob.BlockListTypeUncommitted, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("failed to list staged blocks: %w", err)
+	}
+	if err == nil {
+		for _, b := range list.UncommittedBlocks {
+			if b.Name != nil && b.Size != nil {
+				staged[*b.Name] = *b.Size
+			}
+		}
+	}
+
+	var done int64
+	ids := make([]string, 0, len(parts))
+	for _, p := range parts {
+		id := azureBlockID(p)
+		ids = append(ids, id)
+		if size, ok := staged[id]; ok && size == int64(len(p.data)) {
+			result.ResumedParts++
+			done += size
+		}
+	}
+	if opts.Progress != nil {
+		opts.Progress(done, result.Size)
+	}
+
+	for i, p := range parts {
+		if size, ok := staged[ids[i]]; ok && size == int64(len(p.data)) {
+			continue
+		}
+
+		partMD5, _ := hex.DecodeString(p.md5)
+		err := retry(ctx, opts.Retries, func() error {
+			_, err := client.StageBlock(ctx, ids[i], streaming.NopCloser(bytes.NewReader(p.data)), &blockblob.StageBlockOptions{
+				CPKScopeInfo:            s.scope,
+				TransactionalValidation: blob.TransferValidationTypeMD5(partMD5),
+			})
+			return err
+		})
+		if err != nil {
+			// Staged blocks are kept so a later attempt can resume
+			return nil, fmt.Errorf("failed to upload block %d of %d: %w", p.number, len(parts), err)
+		}
+
+		done += int64(len(p.data))
+		if opts.Progress != nil {
+			opts.Progress(done, result.Size)
+		}
+	}
+
+	metadata := map[string]*string{}
+	for k, v := range uploadMetadata(opts, sha) {
+		metadata[k] = &v
+	}
+
+	err = retry(ctx, opts.Retries, func() error {
+		_, err := client.CommitBlockList(ctx, ids, &blockblob.CommitBlockListOptions{
+			HTTPHeaders:  &blob.HTTPHeaders{BlobContentType: &contentType, BlobContentMD5: sum[:]},
+			Metadata:     metadata,
+			Tags:         s.tags,
+			CPKScopeInfo: s.scope,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit blocks: %w", err)
+	}
+
+	props, err := client.GetProperties(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify upload: %w", err)
+	}
+	if props.ETag != nil {
+		result.ETag = strings.Trim(string(*props.ETag), `"`)
+	}
+	if size := deref(props.ContentLength); size != result.Size {
+		return nil, fmt.Errorf("stored blob is %d bytes, expected %d: %w", size, result.Size, ErrChecksumMismatch)
+	}
+	if props.ContentMD5 != nil && !bytes.Equal(props.ContentMD5, sum[:]) {
+		return nil, fmt.Errorf("stored blob MD5 does not match: %w", ErrChecksumMismatch)
+	}
+	return result, nil
+}
+
+// Download retrieves a blob
+func (s *AzureStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.client.NewBlobClient(key).DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("failed to close azure blob body", "error", err)
+		}
+	}()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+
+	// Blobs stored by UploadLarge carry their SHA-256
+	if err := checkSHA256(content, azureMetadata(resp.Metadata, checksumMetadata)); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Metadata returns the metadata of a blob, with lowercase keys
+func (s *AzureStorage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	props, err := s.client.NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob metadata: %w", err)
+	}
+
+	metadata := make(map[string]string, len(props.Metadata))
+	for k, v := range props.Metadata {
+		if v != nil {
+			metadata[strings.ToLower(k)] = *v
+		}
+	}
+	return metadata, nil
+}
+
+// Delete removes a blob
+func (s *AzureStorage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.NewBlobClient(key).Delete(ctx, nil)
+	return err
+}
+
+// List returns blobs with given prefix
+func (s *AzureStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	pager := s.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			info := ObjectInfo{Key: deref(item.Name)}
+			if item.Properties != nil {
+				info.Size = deref(item.Properties.ContentLength)
+				info.LastModified = deref(item.Properties.LastModified)
+			}
+			objects = append(objects, info)
+		}
+	}
+
+	return objects, nil
+}
+
+// GetPresignedURL generates a read-only SAS URL
+func (s *AzureStorage) GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.client.NewBlobClient(key).GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiry), nil)
+}
+
+// Exists checks if a blob exists
+func (s *AzureStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetBucket returns the container name
+func (s *AzureStorage) GetBucket() string {
+	return s.container
+}
+
+// Provider returns ProviderAzure
+func (s *AzureStorage) Provider() string {
+	return ProviderAzure
+}
+
+// azureMetadata looks up a metadata value; Azure returns keys with
+// header-style capitalisation
+func azureMetadata(metadata map[string]*string, key string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) && v != nil {
+			return *v
+		}
+	}
+	return ""
+}
+
+// deref returns the value p points to, or the zero value for nil
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
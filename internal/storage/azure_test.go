@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestAzureBlockID(t *testing.T) {
+	parts := splitParts(make([]byte, 25), 10)
+
+	first, _ := base64.StdEncoding.DecodeString(azureBlockID(parts[0]))
+	if string(first) != "00001-"+parts[0].md5 {
+		t.Errorf("unexpected block ID %q", first)
+	}
+
+	// Azure requires all block IDs of a blob to have the same length
+	for _, p := range parts[1:] {
+		if len(azureBlockID(p)) != len(azureBlockID(parts[0])) {
+			t.Errorf("block IDs differ in length: %q", azureBlockID(p))
+		}
+	}
+}
+
+func TestAzureMetadata(t *testing.T) {
+	sum := "abc"
+	metadata := map[string]*string{"Sha256": &sum}
+
+	if got := azureMetadata(metadata, checksumMetadata); got != sum {
+		t.Errorf("expected case-insensitive lookup, got %q", got)
+	}
+	if got := azureMetadata(metadata, "missing"); got != "" {
+		t.Errorf("expected empty value, got %q", got)
+	}
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// gcsChunkAlign is the granularity GCS requires for resumable upload chunks
+const gcsChunkAlign = 256 << 10
+
+// GCSConfig holds Google Cloud Storage configuration
+type GCSConfig struct {
+	Bucket          string
+	CredentialsFile string // Service account key; empty uses application default credentials
+	KMSKeyName      string // Optional Cloud KMS key for uploads
+	// Tags are stored as custom metadata, as GCS has no object tags
+	Tags      map[string]string
+	KeyPrefix string // Key prefix template; see ExpandPrefix
+}
+
+// GCSStorage provides Google Cloud Storage operations
+type GCSStorage struct {
+	keyTemplate
+	client     *gcs.Client
+	bucket     *gcs.BucketHandle
+	name       string
+	kmsKeyName string
+	tags       map[string]string
+}
+
+// NewGCSStorage creates a new Google Cloud Storage client. The bucket must
+// already exist, since creating one requires a project.
+func NewGCSStorage(ctx context.Context, cfg GCSConfig) (*GCSStorage, error) {
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	bucket := client.Bucket(cfg.Bucket)
+	if _, err := bucket.Attrs(ctx); err != nil {
+		_ = client.Close()
+		if errors.Is(err, gcs.ErrBucketNotExist) {
+			return nil, fmt.Errorf("bucket %q does not exist", cfg.Bucket)
+		}
+		return nil, fmt.Errorf("failed to check bucket: %w", err)
+	}
+
+	return &GCSStorage{
+		keyTemplate: newKeyTemplate(cfg.KeyPrefix),
+		client:      client,
+		bucket:      bucket,
+		name:        cfg.Bucket,
+		kmsKeyName:  cfg.KMSKeyName,
+		tags:        cfg.Tags,
+	}, nil
+}
+
+// newWriter creates an object writer with the configured encryption and tags
+func (s *GCSStorage) newWriter(ctx context.Context, key, contentType string) *gcs.Writer {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	w.KMSKeyName = s.kmsKeyName
+	w.Metadata = make(map[string]string, len(s.tags)+1)
+	for k, v := range s.tags {
+		w.Metadata[k] = v
+	}
+	return w
+}
+
+// Upload uploads content to GCS
+func (s *GCSStorage) Upload(ctx context.Context, key string, content []byte, contentType string) error {
+	w := s.newWriter(ctx, key, contentType)
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// UploadLarge uploads content with a resumable upload. The client retries
+// failed chunks itself; a failed upload is restarted as a whole, since GCS
+// upload sessions cannot be picked up by another process. GCS checks the
+// content's CRC32C on completion, and the stored object is verified against
+// its MD5 and size.
+func (s *GCSStorage) UploadLarge(ctx context.Context, key string, content []byte, contentType string, opts UploadOptions) (*UploadResult, error) {
+	if opts.PartSize < minPartSize {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultPartRetries
+	}
+
+	sum := md5.Sum(content)
+	result := &UploadResult{
+		Key:    key,
+		Size:   int64(len(content)),
+		SHA256: sha256Hex(content),
+		Parts:  int((int64(len(content)) + opts.PartSize - 1) / opts.PartSize),
+	}
+
+	var attrs *gcs.ObjectAttrs
+	err := retry(ctx, opts.Retries, func() error {
+		w := s.newWriter(ctx, key, contentType)
+		for k, v := range uploadMetadata(opts, result.SHA256) {
+			w.Metadata[k] = v
+		}
+		w.ChunkSize = int(opts.PartSize / gcsChunkAlign * gcsChunkAlign)
+		w.CRC32C = crc32.Checksum(content, crc32.MakeTable(crc32.Castagnoli))
+		w.SendCRC32C = true
+		if opts.Progress != nil {
+			w.ProgressFunc = func(n int64) { opts.Progress(n, result.Size) }
+		}
+
+		if _, err := io.Copy(w, bytes.NewReader(content)); err != nil {
+			_ = w.Close()
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		attrs = w.Attrs()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object: %w", err)
+	}
+	if opts.Progress != nil {
+		opts.Progress(result.Size, result.Size)
+	}
+
+	result.ETag = attrs.Etag
+	if attrs.Size != result.Size {
+		return nil, fmt.Errorf("stored object is %d bytes, expected %d: %w", attrs.Size, result.Size, ErrChecksumMismatch)
+	}
+	// Objects encrypted with a KMS key have no MD5
+	if attrs.MD5 != nil && !bytes.Equal(attrs.MD5, sum[:]) {
+		return nil, fmt.Errorf("stored object MD5 does not match: %w", ErrChecksumMismatch)
+	}
+	return result, nil
+}
+
+// Download retrieves content from GCS
+func (s *GCSStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	obj := s.bucket.Object(key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	// Read the generation the metadata belongs to
+	reader, err := obj.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			slog.Error("failed to close GCS object reader", "error", err)
+		}
+	}()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	// Objects stored by UploadLarge carry their SHA-256
+	if err := checkSHA256(content, attrs.Metadata[checksumMetadata]); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Metadata returns the custom metadata of an object
+func (s *GCSStorage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	attrs, err := s.bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	return attrs.Metadata, nil
+}
+
+// Delete removes an object from GCS
+func (s *GCSStorage) Delete(ctx context.Context, key string) error {
+	return s.bucket.Object(key).Delete(ctx)
+}
+
+// List returns objects with given prefix
+func (s *GCSStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	it := s.bucket.Objects(ctx, &gcs.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          attrs.Name,
+			Size:         attrs.Size,
+			LastModified: attrs.Updated,
+		})
+	}
+
+	return objects, nil
+}
+
+// GetPresignedURL generates a temporary download URL. Signing needs service
+// account credentials.
+func (s *GCSStorage) GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.bucket.SignedURL(key, &gcs.SignedURLOptions{
+		Scheme:  gcs.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(expiry),
+	})
+}
+
+// Exists checks if an object exists
+func (s *GCSStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetBucket returns the bucket name
+func (s *GCSStorage) GetBucket() string {
+	return s.name
+}
+
+// Provider returns ProviderGCS
+func (s *GCSStorage) Provider() string {
+	return ProviderGCS
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sidecar files stored next to each local backup
+const (
+	checksumSuffix = ".sha256" // The backup's SHA-256
+	metadataSuffix = ".meta"   // Upload metadata as a JSON object
+)
+
+// isSidecar reports whether name is a sidecar rather than a backup
+func isSidecar(name string) bool {
+	return strings.HasSuffix(name, checksumSuffix) || strings.HasSuffix(name, metadataSuffix)
+}
+
+// ErrNotSupported is returned for operations a backend cannot perform
+var ErrNotSupported = errors.New("not supported by this storage backend")
+
+// LocalConfig holds local filesystem storage configuration
+type LocalConfig struct {
+	Dir       string
+	KeyPrefix string // Key prefix template; see ExpandPrefix
+}
+
+// LocalStorage stores backups in a directory. Keys are slash-separated
+// paths below it.
+type LocalStorage struct {
+	keyTemplate
+	dir string
+}
+
+// NewLocalStorage creates the backup directory if needed
+func NewLocalStorage(cfg LocalConfig) (*LocalStorage, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("local storage requires a directory")
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	return &LocalStorage{keyTemplate: newKeyTemplate(cfg.KeyPrefix), dir: dir}, nil
+}
+
+// path maps a key to a file below the directory, rejecting keys that
+// would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	clean := path.Clean(key)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || isSidecar(clean) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// writeFile writes content atomically: readers never see a partial backup
+func writeFile(name string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Upload writes content to a file
+func (s *LocalStorage) Upload(ctx context.Context, key string, content []byte, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return writeFile(name, content)
+}
+
+// UploadLarge writes content with SHA-256 and metadata sidecar files, then
+// reads the file back to verify it. Local writes do not need parts or resuming.
+func (s *LocalStorage) UploadLarge(ctx context.Context, key string, content []byte, contentType string, opts UploadOptions) (*UploadResult, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UploadResult{
+		Key:    key,
+		Size:   int64(len(content)),
+		SHA256: sha256Hex(content),
+		Parts:  1,
+	}
+
+	if err := writeFile(name, content); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := writeFile(name+checksumSuffix, []byte(result.SHA256+"\n")); err != nil {
+		return nil, fmt.Errorf("failed to write checksum: %w", err)
+	}
+	if err := s.writeMetadata(name, opts.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+	if opts.Progress != nil {
+		opts.Progress(result.Size, result.Size)
+	}
+
+	stored, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify backup: %w", err)
+	}
+	if !bytes.Equal(stored, content) {
+		return nil, fmt.Errorf("stored file does not match: %w", ErrChecksumMismatch)
+	}
+	result.ETag = result.SHA256
+	return result, nil
+}
+
+// Download reads a file, checking it against its SHA-256 sidecar if present
+func (s *LocalStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	expected, err := os.ReadFile(name + checksumSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read checksum: %w", err)
+	}
+	if err := checkSHA256(content, strings.TrimSpace(string(expected))); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writeMetadata stores upload metadata next to a file, removing metadata
+// left by an earlier upload when there is none
+func (s *LocalStorage) writeMetadata(name string, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		if err := os.Remove(name + metadataSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return writeFile(name+metadataSuffix, data)
+}
+
+// Metadata returns the metadata stored with a file, including its SHA-256
+func (s *LocalStorage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(name); err != nil {
+		return nil, fmt.Errorf("failed to get backup metadata: %w", err)
+	}
+
+	metadata := map[string]string{}
+	data, err := os.ReadFile(name + metadataSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to read metadata: %w", err)
+		}
+	}
+
+	sum, err := os.ReadFile(name + checksumSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read checksum: %w", err)
+	}
+	if len(sum) > 0 {
+		metadata[checksumMetadata] = strings.TrimSpace(string(sum))
+	}
+	return metadata, nil
+}
+
+// Delete removes a file and its sidecars
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	for _, suffix := range []string{checksumSuffix, metadataSuffix} {
+		if err := os.Remove(name + suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns files whose key starts with prefix
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(s.dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || isSidecar(name) || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	return objects, nil
+}
+
+// GetPresignedURL is not available for local files
+func (s *LocalStorage) GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+// Exists checks if a file exists
+func (s *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(name); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetBucket returns the backup directory
+func (s *LocalStorage) GetBucket() string {
+	return s.dir
+}
+
+// Provider returns ProviderLocal
+func (s *LocalStorage) Provider() string {
+	return ProviderLocal
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	s, err := NewLocalStorage(LocalConfig{Dir: filepath.Join(t.TempDir(), "backups")})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %v", err)
+	}
+	return s
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	key := "backups/snipo-backup-20250101-000000.zip"
+
+	var progressed int64
+	result, err := s.UploadLarge(ctx, key, []byte("backup"), "application/zip", UploadOptions{
+		Progress: func(uploaded, total int64) { progressed = uploaded },
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if result.Size != 6 || result.SHA256 != sha256Hex([]byte("backup")) || progressed != 6 {
+		t.Errorf("unexpected result %+v, progress %d", result, progressed)
+	}
+
+	content, err := s.Download(ctx, key)
+	if err != nil || string(content) != "backup" {
+		t.Fatalf("download returned %q, %v", content, err)
+	}
+
+	objects, err := s.List(ctx, "backups/")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != key || objects[0].Size != 6 {
+		t.Errorf("expected only the backup to be listed, got %+v", objects)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if ok, _ := s.Exists(ctx, key); ok {
+		t.Error("expected backup to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(s.GetBucket(), "backups", "snipo-backup-20250101-000000.zip"+checksumSuffix)); !os.IsNotExist(err) {
+		t.Error("expected checksum file to be deleted")
+	}
+}
+
+func TestLocalStorageChecksum(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+
+	if _, err := s.UploadLarge(ctx, "backup.json", []byte("original"), "application/json", UploadOptions{}); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.GetBucket(), "backup.json"), []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Download(ctx, "backup.json"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+
+	for _, key := range []string{"", "../outside", "a/../../outside", "/etc/passwd", `..\outside`, "backup.sha256"} {
+		if err := s.Upload(ctx, key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// DefaultPartSize is the multipart chunk size; S3 requires at least 5 MiB
+	DefaultPartSize = 8 << 20
+	minPartSize     = 5 << 20
+
+	// DefaultPartRetries is how often a failed part upload is retried
+	DefaultPartRetries = 4
+)
+
+// UploadOptions controls large uploads
+type UploadOptions struct {
+	PartSize int64 // Default DefaultPartSize
+	Retries  int   // Per part; default DefaultPartRetries
+	// Progress is called after each part with the bytes stored so far
+	Progress func(uploaded, total int64)
+	// Metadata is stored with the object and returned by Metadata. Keys
+	// must be lowercase letters and digits to be valid on every backend.
+	Metadata map[string]string
+}
+
+// UploadResult describes a completed upload
+type UploadResult struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag"`
+	SHA256       string `json:"sha256"`
+	Parts        int    `json:"parts"`
+	ResumedParts int    `json:"resumed_parts,omitempty"` // Parts kept from an earlier interrupted upload
+}
+
+// part is one chunk of a multipart upload
+type part struct {
+	number int32
+	data   []byte
+	md5    string // Hex, as S3 reports it in part ETags
+	crc32  string // Base64, as S3 reports it in part checksums
+}
+
+// splitParts cuts content into numbered parts of partSize bytes
+func splitParts(content []byte, partSize int64) []part {
+	var parts []part
+	for offset, n := int64(0), int32(1); offset < int64(len(content)); offset, n = offset+partSize, n+1 {
+		end := min(offset+partSize, int64(len(content)))
+		data := content[offset:end]
+		sum := md5.Sum(data)
+		crc := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+		parts = append(parts, part{
+			number: n,
+			data:   data,
+			md5:    hex.EncodeToString(sum[:]),
+			crc32:  base64.StdEncoding.EncodeToString(crc),
+		})
+	}
+	return parts
+}
+
+// multipartETag computes the ETag S3 assigns to a completed multipart upload:
+// the MD5 of the concatenated part MD5s, followed by the part count
+func multipartETag(parts []part) string {
+	h := md5.New()
+	for _, p := range parts {
+		sum, _ := hex.DecodeString(p.md5)
+		h.Write(sum)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(h.Sum(nil)), len(parts))
+}
+
+// reusableParts returns the already uploaded parts whose content matches
+// ours. Parts are compared by CRC32 checksum when S3 reports one and by
+// ETag otherwise.
+func reusableParts(uploaded []types.Part, parts []part) map[int32]types.CompletedPart {
+	reuse := make(map[int32]types.CompletedPart)
+	for _, up := range uploaded {
+		n := aws.ToInt32(up.PartNumber)
+		if n < 1 || int(n) > len(parts) {
+			continue
+		}
+		p := parts[n-1]
+		if aws.ToInt64(up.Size) != int64(len(p.data)) {
+			continue
+		}
+		if crc := aws.ToString(up.ChecksumCRC32); crc != "" {
+			if crc != p.crc32 {
+				continue
+			}
+		} else if trimETag(aws.ToString(up.ETag)) != p.md5 {
+			continue
+		}
+		reuse[n] = types.CompletedPart{
+			PartNumber:    up.PartNumber,
+			ETag:          up.ETag,
+			ChecksumCRC32: up.ChecksumCRC32,
+		}
+	}
+	return reuse
+}
+
+// trimETag strips the quotes S3 puts around ETags
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// isMD5ETag reports whether an ETag has the shape of an MD5 based one.
+// Objects encrypted with SSE-KMS get opaque ETags of the same shape, so
+// callers must also check S3Storage.md5ETags.
+func isMD5ETag(etag string) bool {
+	sum, _, _ := strings.Cut(etag, "-")
+	if len(sum) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
+
+// retry runs fn until it succeeds, backing off between attempts
+func retry(ctx context.Context, retries int, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Second << attempt):
+		}
+	}
+}
+
+// UploadLarge uploads content in parts, retrying failed parts. If an earlier
+// upload of the same key was interrupted, its parts are kept and only the
+// rest is sent; an interrupted upload of different content is discarded.
+// The stored object is verified against the content's ETag and size after
+// completion, and its SHA-256 is recorded in the object metadata so
+// downloads can be checked.
+func (s *S3Storage) UploadLarge(ctx context.Context, key string, content []byte, contentType string, opts UploadOptions) (*UploadResult, error) {
+	if opts.PartSize < minPartSize {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultPartRetries
+	}
+
+	result := &UploadResult{
+		Key:    key,
+		Size:   int64(len(content)),
+		SHA256: sha256Hex(content),
+	}
+	metadata := uploadMetadata(opts, result.SHA256)
+
+	parts := splitParts(content, opts.PartSize)
+	if len(parts) <= 1 {
+		return s.uploadSingle(ctx, result, content, contentType, metadata, opts)
+	}
+	result.Parts = len(parts)
+
+	uploadID, uploaded, err := s.findUpload(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	completed := reusableParts(uploaded, parts)
+	if uploadID != "" && len(completed) < len(uploaded) {
+		// The earlier upload was for different content, so its metadata is stale too
+		if _, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(uploadID),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to abort stale multipart upload: %w", err)
+		}
+		uploadID, completed = "", map[int32]types.CompletedPart{}
+	}
+	if uploadID == "" {
+		created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:               aws.String(s.bucket),
+			Key:                  aws.String(key),
+			ContentType:          aws.String(contentType),
+			Metadata:             metadata,
+			ChecksumAlgorithm:    types.ChecksumAlgorithmCrc32,
+			ServerSideEncryption: s.sse,
+			SSEKMSKeyId:          s.kmsKeyID,
+			Tagging:              s.tagging,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+		uploadID = aws.ToString(created.UploadId)
+	}
+
+	result.ResumedParts = len(completed)
+
+	var done int64
+	for _, p := range parts {
+		if _, ok := completed[p.number]; ok {
+			done += int64(len(p.data))
+		}
+	}
+	if opts.Progress != nil {
+		opts.Progress(done, result.Size)
+	}
+
+	for _, p := range parts {
+		if _, ok := completed[p.number]; ok {
+			continue
+		}
+
+		err := retry(ctx, opts.Retries, func() error {
+			out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:            aws.String(s.bucket),
+				Key:               aws.String(key),
+				UploadId:          aws.String(uploadID),
+				PartNumber:        aws.Int32(p.number),
+				Body:              bytes.NewReader(p.data),
+				ContentLength:     aws.Int64(int64(len(p.data))),
+				ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+			})
+			if err != nil {
+				return err
+			}
+			if etag := trimETag(aws.ToString(out.ETag)); s.md5ETags && isMD5ETag(etag) && etag != p.md5 {
+				return fmt.Errorf("part %d: %w", p.number, ErrChecksumMismatch)
+			}
+			completed[p.number] = types.CompletedPart{
+				PartNumber:    aws.Int32(p.number),
+				ETag:          out.ETag,
+				ChecksumCRC32: out.ChecksumCRC32,
+			}
+			return nil
+		})
+		if err != nil {
+			// The upload is left open so a later attempt can resume it
+			return nil, fmt.Errorf("failed to upload part %d of %d: %w", p.number, len(parts), err)
+		}
+
+		done += int64(len(p.data))
+		if opts.Progress != nil {
+			opts.Progress(done, result.Size)
+		}
+	}
+
+	completedParts := make([]types.CompletedPart, 0, len(completed))
+	for _, p := range parts {
+		completedParts = append(completedParts, completed[p.number])
+	}
+
+	err = retry(ctx, opts.Retries, func() error {
+		_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(key),
+			UploadId:        aws.String(uploadID),
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	if err := s.verify(ctx, result, multipartETag(parts)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// uploadSingle stores content that fits in one part with a plain PUT
+func (s *S3Storage) uploadSingle(ctx context.Context, result *UploadResult, content []byte, contentType string, metadata map[string]string, opts UploadOptions) (*UploadResult, error) {
+	sum := md5.Sum(content)
+	result.Parts = 1
+
+	err := retry(ctx, opts.Retries, func() error {
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String(s.bucket),
+			Key:                  aws.String(result.Key),
+			Body:                 bytes.NewReader(content),
+			ContentType:          aws.String(contentType),
+			ContentMD5:           aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+			Metadata:             metadata,
+			ServerSideEncryption: s.sse,
+			SSEKMSKeyId:          s.kmsKeyID,
+			Tagging:              s.tagging,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object: %w", err)
+	}
+	if opts.Progress != nil {
+		opts.Progress(result.Size, result.Size)
+	}
+
+	if err := s.verify(ctx, result, hex.EncodeToString(sum[:])); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findUpload returns the newest unfinished multipart upload of key and its
+// parts, or an empty ID when there is none
+func (s *S3Storage) findUpload(ctx context.Context, key string) (string, []types.Part, error) {
+	var uploads []types.MultipartUpload
+	paginator := s3.NewListMultipartUploadsPaginator(s.client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(key),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+		for _, u := range page.Uploads {
+			if aws.ToString(u.Key) == key {
+				uploads = append(uploads, u)
+			}
+		}
+	}
+	if len(uploads) == 0 {
+		return "", nil, nil
+	}
+
+	sort.Slice(uploads, func(i, j int) bool {
+		return aws.ToTime(uploads[i].Initiated).After(aws.ToTime(uploads[j].Initiated))
+	})
+	uploadID := aws.ToString(uploads[0].UploadId)
+
+	var parts []types.Part
+	partPaginator := s3.NewListPartsPaginator(s.client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for partPaginator.HasMorePages() {
+		page, err := partPaginator.NextPage(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list uploaded parts: %w", err)
+		}
+		parts = append(parts, page.Parts...)
+	}
+
+	return uploadID, parts, nil
+}
+
+// verify checks the stored object's size and ETag against the upload
+func (s *S3Storage) verify(ctx context.Context, result *UploadResult, expectedETag string) error {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(result.Key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify upload: %w", err)
+	}
+
+	etag := trimETag(aws.ToString(head.ETag))
+	result.ETag = etag
+
+	if size := aws.ToInt64(head.ContentLength); size != result.Size {
+		return fmt.Errorf("stored object is %d bytes, expected %d: %w", size, result.Size, ErrChecksumMismatch)
+	}
+	if s.md5ETags && isMD5ETag(etag) && etag != expectedETag {
+		return fmt.Errorf("stored object ETag %s, expected %s: %w", etag, expectedETag, ErrChecksumMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestSplitParts(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 25)
+	parts := splitParts(content, 10)
+
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	if parts[0].number != 1 || parts[2].number != 3 || len(parts[2].data) != 5 {
+		t.Errorf("unexpected parts: %+v", parts)
+	}
+
+	sum := md5.Sum(content[:10])
+	if parts[0].md5 != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected part MD5 %x, got %s", sum, parts[0].md5)
+	}
+}
+
+func TestMultipartETag(t *testing.T) {
+	parts := splitParts([]byte("hello world"), 6)
+
+	a, b := md5.Sum([]byte("hello ")), md5.Sum([]byte("world"))
+	want := md5.Sum(append(a[:], b[:]...))
+	if got := multipartETag(parts); got != hex.EncodeToString(want[:])+"-2" {
+		t.Errorf("unexpected multipart ETag %s", got)
+	}
+}
+
+func TestReusableParts(t *testing.T) {
+	parts := splitParts([]byte("hello world"), 6)
+
+	uploaded := []types.Part{
+		{PartNumber: aws.Int32(1), ETag: aws.String(`"` + parts[0].md5 + `"`), Size: aws.Int64(6)},
+		{PartNumber: aws.Int32(2), ETag: aws.String(`"` + parts[0].md5 + `"`), Size: aws.Int64(5)},
+		{PartNumber: aws.Int32(3), ETag: aws.String(`"` + parts[1].md5 + `"`), Size: aws.Int64(5)},
+	}
+
+	reuse := reusableParts(uploaded, parts)
+	if len(reuse) != 1 {
+		t.Fatalf("expected only the matching part to be reused, got %d", len(reuse))
+	}
+	if _, ok := reuse[1]; !ok {
+		t.Errorf("expected part 1 to be reused, got %+v", reuse)
+	}
+
+	// With SSE-KMS the ETag is opaque and the CRC32 checksum decides
+	uploaded = []types.Part{
+		{PartNumber: aws.Int32(1), ETag: aws.String(`"opaque"`), ChecksumCRC32: aws.String(parts[0].crc32), Size: aws.Int64(6)},
+		{PartNumber: aws.Int32(2), ETag: aws.String(`"` + parts[1].md5 + `"`), ChecksumCRC32: aws.String(parts[0].crc32), Size: aws.Int64(5)},
+	}
+	reuse = reusableParts(uploaded, parts)
+	if _, ok := reuse[1]; !ok || len(reuse) != 1 {
+		t.Errorf("expected only part 1 to be reused by checksum, got %+v", reuse)
+	}
+}
+
+func TestIsMD5ETag(t *testing.T) {
+	tests := map[string]bool{
+		"5eb63bbbe01eeed093cb22bb8f5acdc3":    true,
+		"5eb63bbbe01eeed093cb22bb8f5acdc3-12": true,
+		"not-an-md5":                          false,
+		"":                                    false,
+	}
+	for etag, want := range tests {
+		if got := isMD5ETag(etag); got != want {
+			t.Errorf("isMD5ETag(%q) = %v, want %v", etag, got, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -24,12 +25,29 @@ type S3Config struct {
 	Bucket          string
 	Region          string
 	UseSSL          bool
+
+	SSE         string            // "s3" (SSE-S3) or "kms" (SSE-KMS); empty leaves it to the bucket
+	SSEKMSKeyID string            // KMS key for SSE-KMS; empty uses the account's default key
+	Tags        map[string]string // Object tags applied to every upload
+	KeyPrefix   string            // Key prefix template; see ExpandPrefix
 }
 
+// Server-side encryption modes for S3Config.SSE
+const (
+	SSENone = ""
+	SSES3   = "s3"
+	SSEKMS  = "kms"
+)
+
 // S3Storage provides S3-compatible object storage operations
 type S3Storage struct {
-	client *s3.Client
-	bucket string
+	keyTemplate
+	client   *s3.Client
+	bucket   string
+	sse      types.ServerSideEncryption
+	kmsKeyID *string
+	tagging  *string
+	md5ETags bool // False when SSE-KMS makes ETags opaque
 }
 
 // ObjectInfo represents information about an S3 object
@@ -41,6 +59,15 @@ type ObjectInfo struct {
 
 // NewS3Storage creates a new S3 storage client
 func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	storage := &S3Storage{
+		keyTemplate: newKeyTemplate(cfg.KeyPrefix),
+		bucket:      cfg.Bucket,
+		md5ETags:    true,
+	}
+	if err := storage.applyOptions(cfg); err != nil {
+		return nil, err
+	}
+
 	// Build endpoint URL
 	scheme := "https"
 	if !cfg.UseSSL {
@@ -87,16 +114,47 @@ func NewS3Storage(cfg S3Config) (*S3Storage, error) {
 		}
 	}
 
-	return &S3Storage{client: client, bucket: cfg.Bucket}, nil
+	storage.client = client
+	return storage, nil
+}
+
+// applyOptions sets encryption and tagging from cfg
+func (s *S3Storage) applyOptions(cfg S3Config) error {
+	switch cfg.SSE {
+	case SSENone:
+	case SSES3:
+		s.sse = types.ServerSideEncryptionAes256
+	case SSEKMS:
+		s.sse = types.ServerSideEncryptionAwsKms
+		if cfg.SSEKMSKeyID != "" {
+			s.kmsKeyID = aws.String(cfg.SSEKMSKeyID)
+		}
+		s.md5ETags = false
+	default:
+		return fmt.Errorf("unknown server-side encryption mode %q", cfg.SSE)
+	}
+
+	if len(cfg.Tags) > 0 {
+		tags := url.Values{}
+		for k, v := range cfg.Tags {
+			tags.Set(k, v)
+		}
+		s.tagging = aws.String(tags.Encode())
+	}
+
+	return nil
 }
 
 // Upload uploads content to S3
 func (s *S3Storage) Upload(ctx context.Context, key string, content []byte, contentType string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(content),
-		ContentType: aws.String(contentType),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(content),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: s.sse,
+		SSEKMSKeyId:          s.kmsKeyID,
+		Tagging:              s.tagging,
 	})
 	return err
 }
@@ -104,11 +162,14 @@ func (s *S3Storage) Upload(ctx context.Context, key string, content []byte, cont
 // UploadReader uploads content from a reader to S3
 func (s *S3Storage) UploadReader(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucket),
-		Key:           aws.String(key),
-		Body:          reader,
-		ContentLength: aws.Int64(size),
-		ContentType:   aws.String(contentType),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 reader,
+		ContentLength:        aws.Int64(size),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: s.sse,
+		SSEKMSKeyId:          s.kmsKeyID,
+		Tagging:              s.tagging,
 	})
 	return err
 }
@@ -133,9 +194,26 @@ func (s *S3Storage) Download(ctx context.Context, key string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read object: %w", err)
 	}
 
+	// Objects stored by UploadLarge carry their SHA-256
+	if err := checkSHA256(content, result.Metadata[checksumMetadata]); err != nil {
+		return nil, err
+	}
+
 	return content, nil
 }
 
+// Metadata returns the user metadata of an object
+func (s *S3Storage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	result, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	return result.Metadata, nil
+}
+
 // Delete removes an object from S3
 func (s *S3Storage) Delete(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -205,3 +283,8 @@ func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
 func (s *S3Storage) GetBucket() string {
 	return s.bucket
 }
+
+// Provider returns ProviderS3
+func (s *S3Storage) Provider() string {
+	return ProviderS3
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestApplyOptions(t *testing.T) {
+	s := &S3Storage{md5ETags: true}
+	err := s.applyOptions(S3Config{
+		SSE:         SSEKMS,
+		SSEKMSKeyID: "alias/backups",
+		Tags:        map[string]string{"team": "platform", "env": "prod & test"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.sse != types.ServerSideEncryptionAwsKms || aws.ToString(s.kmsKeyID) != "alias/backups" {
+		t.Errorf("expected SSE-KMS with key, got %q %q", s.sse, aws.ToString(s.kmsKeyID))
+	}
+	if s.md5ETags {
+		t.Error("expected ETag checks to be off with SSE-KMS")
+	}
+	if got := aws.ToString(s.tagging); got != "env=prod+%26+test&team=platform" {
+		t.Errorf("unexpected tagging %q", got)
+	}
+
+	if err := (&S3Storage{}).applyOptions(S3Config{SSE: "rot13"}); err == nil {
+		t.Error("expected error for unknown SSE mode")
+	}
+}
+
+func TestExpandPrefix(t *testing.T) {
+	s := &S3Storage{keyTemplate: keyTemplate{prefix: "snipo/{hostname}/{year}/{month}/{date}/", hostname: "web-1"}}
+	at := time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC)
+
+	if got := s.ExpandPrefix(at); got != "snipo/web-1/2024/03/2024-03-09/" {
+		t.Errorf("unexpected prefix %q", got)
+	}
+	if got := s.ListPrefix(); got != "snipo/" {
+		t.Errorf("unexpected list prefix %q", got)
+	}
+
+	if got := newKeyTemplate("").ExpandPrefix(at); got != DefaultKeyPrefix {
+		t.Errorf("expected default prefix, got %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Package storage stores backups in object storage. S3 (and S3-compatible
+// services), Azure Blob Storage, Google Cloud Storage and a local directory
+// are supported behind the ObjectStore interface.
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+// Object storage providers
+const (
+	ProviderS3    = "s3"
+	ProviderAzure = "azure"
+	ProviderGCS   = "gcs"
+	ProviderLocal = "local"
+)
+
+// DefaultKeyPrefix is where backups are stored when no prefix is configured
+const DefaultKeyPrefix = "backups/"
+
+// checksumMetadata is the object metadata key holding the content's SHA-256
+const checksumMetadata = "sha256"
+
+// ErrChecksumMismatch is returned when stored content does not match what was uploaded
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ObjectStore is an object storage backend for backups
+type ObjectStore interface {
+	// Upload stores small content in one request
+	Upload(ctx context.Context, key string, content []byte, contentType string) error
+	// UploadLarge stores content in retried parts, resuming an interrupted
+	// upload of the same key where the backend allows it, and verifies it
+	UploadLarge(ctx context.Context, key string, content []byte, contentType string, opts UploadOptions) (*UploadResult, error)
+	// Download retrieves content, checking it against its recorded SHA-256
+	Download(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	// Metadata returns the custom metadata stored with an object
+	Metadata(ctx context.Context, key string) (map[string]string, error)
+	// GetBucket returns the bucket (or container) name
+	GetBucket() string
+	Provider() string
+	ExpandPrefix(t time.Time) string
+	ListPrefix() string
+}
+
+// New connects to the object store selected by cfg.Provider
+func New(ctx context.Context, cfg config.S3Config) (ObjectStore, error) {
+	switch cfg.Provider {
+	case "", ProviderS3:
+		return NewS3Storage(S3Config{
+			Endpoint:        cfg.Endpoint,
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			Bucket:          cfg.Bucket,
+			Region:          cfg.Region,
+			UseSSL:          cfg.UseSSL,
+			SSE:             cfg.SSE,
+			SSEKMSKeyID:     cfg.SSEKMSKeyID,
+			Tags:            cfg.Tags,
+			KeyPrefix:       cfg.Prefix,
+		})
+	case ProviderAzure:
+		// Azure always encrypts at rest; a KMS key ID names an encryption scope
+		var scope string
+		if cfg.SSE == SSEKMS {
+			scope = cfg.SSEKMSKeyID
+		}
+		return NewAzureStorage(ctx, AzureConfig{
+			AccountName:     cfg.AzureAccountName,
+			AccountKey:      cfg.AzureAccountKey,
+			Endpoint:        cfg.AzureEndpoint,
+			Container:       cfg.Bucket,
+			EncryptionScope: scope,
+			Tags:            cfg.Tags,
+			KeyPrefix:       cfg.Prefix,
+		})
+	case ProviderGCS:
+		// GCS always encrypts at rest; a KMS key ID selects a Cloud KMS key
+		var kmsKey string
+		if cfg.SSE == SSEKMS {
+			kmsKey = cfg.SSEKMSKeyID
+		}
+		return NewGCSStorage(ctx, GCSConfig{
+			Bucket:          cfg.Bucket,
+			CredentialsFile: cfg.GCSCredentialsFile,
+			KMSKeyName:      kmsKey,
+			Tags:            cfg.Tags,
+			KeyPrefix:       cfg.Prefix,
+		})
+	case ProviderLocal:
+		return NewLocalStorage(LocalConfig{
+			Dir:       cfg.LocalDir,
+			KeyPrefix: cfg.Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage provider %q", cfg.Provider)
+	}
+}
+
+// keyTemplate expands the configured key prefix template
+type keyTemplate struct {
+	prefix   string
+	hostname string
+}
+
+// newKeyTemplate creates a key template, defaulting to DefaultKeyPrefix
+func newKeyTemplate(prefix string) keyTemplate {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "snipo"
+	}
+	return keyTemplate{prefix: prefix, hostname: hostname}
+}
+
+// ExpandPrefix fills in the key prefix template for an upload at t.
+// Placeholders: {hostname}, {date} (2006-01-02), {year}, {month} and {day}.
+func (k keyTemplate) ExpandPrefix(t time.Time) string {
+	t = t.UTC()
+	return strings.NewReplacer(
+		"{hostname}", k.hostname,
+		"{date}", t.Format("2006-01-02"),
+		"{year}", t.Format("2006"),
+		"{month}", t.Format("01"),
+		"{day}", t.Format("02"),
+	).Replace(k.prefix)
+}
+
+// ListPrefix returns the fixed part of the key prefix template, which all
+// expanded prefixes start with
+func (k keyTemplate) ListPrefix() string {
+	prefix, _, _ := strings.Cut(k.prefix, "{")
+	return prefix
+}
+
+// uploadMetadata returns the metadata UploadLarge stores: the caller's and
+// the content's SHA-256
+func uploadMetadata(opts UploadOptions, sha string) map[string]string {
+	metadata := make(map[string]string, len(opts.Metadata)+1)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata[checksumMetadata] = sha
+	return metadata
+}
+
+// sha256Hex returns the hex SHA-256 of content
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkSHA256 compares content with a recorded hex SHA-256; objects without
+// one pass
+func checkSHA256(content []byte, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	if sha256Hex(content) != expected {
+		return fmt.Errorf("downloaded object does not match its recorded SHA-256: %w", ErrChecksumMismatch)
+	}
+	return nil
+}
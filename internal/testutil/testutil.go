@@ -14,10 +14,10 @@ import (
 // TestDB creates an in-memory SQLite database for testing.
 // It runs migrations and returns the database connection.
 // The database is automatically closed when the test completes.
-func TestDB(t *testing.T) *sql.DB {
+func TestDB(t testing.TB) *sql.DB {
 	t.Helper()
 
-	db, err := sql.Open("sqlite", ":memory:?_foreign_keys=ON")
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
 	if err != nil {
 		t.Fatalf("failed to open test database: %v", err)
 	}
@@ -51,6 +51,16 @@ func runTestMigrations(db *sql.DB) error {
 			view_count INTEGER DEFAULT 0,
 			s3_key TEXT DEFAULT NULL,
 			checksum TEXT DEFAULT NULL,
+			source_url TEXT DEFAULT NULL,
+			is_pinned INTEGER DEFAULT 0,
+			pinned_at DATETIME DEFAULT NULL,
+			slug TEXT DEFAULT NULL,
+			license TEXT DEFAULT NULL,
+			attribution TEXT DEFAULT NULL,
+			review_state TEXT NOT NULL DEFAULT 'draft',
+			publish_at DATETIME DEFAULT NULL,
+			requires_checkout INTEGER NOT NULL DEFAULT 0,
+			noindex INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
@@ -58,7 +68,38 @@ func runTestMigrations(db *sql.DB) error {
 		-- Settings table
 		CREATE TABLE IF NOT EXISTS settings (
 			id INTEGER PRIMARY KEY CHECK (id = 1),
+			app_name TEXT DEFAULT 'snipo',
+			custom_css TEXT DEFAULT '',
+			theme TEXT DEFAULT 'auto',
+			default_language TEXT DEFAULT 'plaintext',
+			s3_enabled INTEGER DEFAULT 0,
+			s3_endpoint TEXT DEFAULT '',
+			s3_bucket TEXT DEFAULT '',
+			s3_region TEXT DEFAULT 'us-east-1',
+			backup_encryption_enabled INTEGER DEFAULT 0,
 			archive_enabled INTEGER DEFAULT 0,
+			history_enabled INTEGER DEFAULT 1,
+			disable_login INTEGER DEFAULT 0 NOT NULL,
+			review_required INTEGER DEFAULT 0 NOT NULL,
+			announcement_snippet_id TEXT NOT NULL DEFAULT '',
+			editor_font_size INTEGER DEFAULT 14,
+			editor_tab_size INTEGER DEFAULT 2,
+			editor_theme TEXT DEFAULT 'auto',
+			editor_word_wrap INTEGER DEFAULT 1,
+			editor_show_print_margin INTEGER DEFAULT 0,
+			editor_show_gutter INTEGER DEFAULT 1,
+			editor_show_indent_guides INTEGER DEFAULT 1,
+			editor_highlight_active_line INTEGER DEFAULT 1,
+			editor_use_soft_tabs INTEGER DEFAULT 1,
+			editor_enable_snippets INTEGER DEFAULT 1,
+			editor_enable_live_autocompletion INTEGER DEFAULT 1,
+			markdown_font_size INTEGER DEFAULT 14,
+			max_title_length INTEGER NOT NULL DEFAULT 200,
+			max_description_length INTEGER NOT NULL DEFAULT 1000,
+			max_content_size INTEGER NOT NULL DEFAULT 1048576,
+			max_tag_length INTEGER NOT NULL DEFAULT 50,
+			tag_pattern TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
 		INSERT OR IGNORE INTO settings (id, archive_enabled) VALUES (1, 0);
@@ -68,6 +109,7 @@ func runTestMigrations(db *sql.DB) error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT UNIQUE NOT NULL,
 			color TEXT DEFAULT '#6366f1',
+			text_color TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
 
@@ -80,17 +122,39 @@ func runTestMigrations(db *sql.DB) error {
 			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
 		);
 
+		-- Tag aliases and implications
+		CREATE TABLE IF NOT EXISTS tag_aliases (
+			alias TEXT PRIMARY KEY,
+			tag_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		);
+		CREATE TABLE IF NOT EXISTS tag_implications (
+			tag_id INTEGER NOT NULL,
+			implied_tag_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tag_id, implied_tag_id),
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+			FOREIGN KEY (implied_tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		);
+
 		-- Folders table
 		CREATE TABLE IF NOT EXISTS folders (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL,
 			parent_id INTEGER DEFAULT NULL,
 			icon TEXT DEFAULT 'folder',
+			color TEXT NOT NULL DEFAULT '',
 			sort_order INTEGER DEFAULT 0,
+			archive_after_days INTEGER NOT NULL DEFAULT 0,
+			is_public INTEGER NOT NULL DEFAULT 0,
+			slug TEXT DEFAULT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (parent_id) REFERENCES folders(id) ON DELETE CASCADE
 		);
 
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_slug ON folders(slug) WHERE slug IS NOT NULL;
+
 		-- Snippet-Folder relationship
 		CREATE TABLE IF NOT EXISTS snippet_folders (
 			snippet_id TEXT NOT NULL,
@@ -108,13 +172,30 @@ func runTestMigrations(db *sql.DB) error {
 			permissions TEXT DEFAULT 'read',
 			last_used_at DATETIME DEFAULT NULL,
 			expires_at DATETIME DEFAULT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			allowed_ips TEXT NOT NULL DEFAULT '',
+			allowed_referrers TEXT NOT NULL DEFAULT '',
+			folder_limited INTEGER NOT NULL DEFAULT 0,
+			provisioned INTEGER NOT NULL DEFAULT 0
+		);
+
+		-- Folders API tokens are limited to
+		CREATE TABLE IF NOT EXISTS token_folders (
+			token_id INTEGER NOT NULL,
+			folder_id INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			PRIMARY KEY (token_id, folder_id),
+			FOREIGN KEY (token_id) REFERENCES api_tokens(id) ON DELETE CASCADE,
+			FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
 		);
 
 		-- Sessions table
 		CREATE TABLE IF NOT EXISTS sessions (
 			id TEXT PRIMARY KEY,
 			token_hash TEXT UNIQUE NOT NULL,
+			key_id TEXT NOT NULL DEFAULT '',
+			reissue INTEGER NOT NULL DEFAULT 0,
+			changelog_seen TEXT NOT NULL DEFAULT '',
 			expires_at DATETIME NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
@@ -132,6 +213,276 @@ func runTestMigrations(db *sql.DB) error {
 			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
 		);
 
+		-- Custom snippet metadata
+		CREATE TABLE IF NOT EXISTS snippet_metadata (
+			snippet_id TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (snippet_id, key),
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Login audit events
+		CREATE TABLE IF NOT EXISTS login_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			success INTEGER NOT NULL DEFAULT 0,
+			ip_address TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		);
+
+		-- Background jobs
+		CREATE TABLE IF NOT EXISTS jobs (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			payload TEXT NOT NULL DEFAULT 'null',
+			processed INTEGER NOT NULL DEFAULT 0,
+			total INTEGER NOT NULL DEFAULT 0,
+			errors TEXT NOT NULL DEFAULT '[]',
+			result TEXT DEFAULT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL DEFAULT 1,
+			run_after DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			started_at DATETIME DEFAULT NULL,
+			finished_at DATETIME DEFAULT NULL
+		);
+
+		-- Snippet review log
+		CREATE TABLE IF NOT EXISTS snippet_reviews (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			state TEXT NOT NULL,
+			comment TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Notification center
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			title TEXT NOT NULL,
+			message TEXT NOT NULL DEFAULT '',
+			fields TEXT NOT NULL DEFAULT '{}',
+			read_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		-- Clipboard inbox
+		CREATE TABLE IF NOT EXISTS inbox_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			content TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		-- Snippet usage events for the frecency sort
+		CREATE TABLE IF NOT EXISTS snippet_usage (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			event TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Links between snippets
+		CREATE TABLE IF NOT EXISTS snippet_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source_id TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			relation TEXT NOT NULL,
+			auto INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(source_id, target_id, relation),
+			FOREIGN KEY (source_id) REFERENCES snippets(id) ON DELETE CASCADE,
+			FOREIGN KEY (target_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Snippet edit locks
+		CREATE TABLE IF NOT EXISTS snippet_locks (
+			snippet_id TEXT PRIMARY KEY,
+			lock_id TEXT NOT NULL,
+			holder TEXT NOT NULL DEFAULT '',
+			acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Snippet check-outs
+		CREATE TABLE IF NOT EXISTS snippet_checkouts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			snippet_title TEXT NOT NULL DEFAULT '',
+			holder TEXT NOT NULL DEFAULT '',
+			source_ip TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL,
+			checked_out_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			checked_in_at DATETIME DEFAULT NULL,
+			note TEXT NOT NULL DEFAULT ''
+		);
+
+		-- Redaction rules
+		CREATE TABLE IF NOT EXISTS redaction_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			replacement TEXT NOT NULL DEFAULT '',
+			enabled INTEGER NOT NULL DEFAULT 1,
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		-- Daily library snapshots
+		CREATE TABLE IF NOT EXISTS library_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			day TEXT NOT NULL UNIQUE,
+			snippet_count INTEGER NOT NULL DEFAULT 0,
+			manifest TEXT NOT NULL DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		-- Collaborative editing state
+		CREATE TABLE IF NOT EXISTS collab_documents (
+			snippet_id TEXT NOT NULL,
+			file_id INTEGER NOT NULL DEFAULT 0,
+			state TEXT NOT NULL,
+			base_hash TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (snippet_id, file_id),
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Abuse reports
+		CREATE TABLE IF NOT EXISTS snippet_reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			details TEXT NOT NULL DEFAULT '',
+			reporter_ip TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			resolved_by TEXT NOT NULL DEFAULT '',
+			resolved_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Burn-after-read share links
+		CREATE TABLE IF NOT EXISTS burn_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			delete_snippet INTEGER NOT NULL DEFAULT 0,
+			expires_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Share analytics
+		CREATE TABLE IF NOT EXISTS share_views (
+			snippet_id TEXT NOT NULL,
+			day TEXT NOT NULL,
+			referrer TEXT NOT NULL DEFAULT '',
+			client TEXT NOT NULL,
+			views INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (snippet_id, day, referrer, client),
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+		CREATE TABLE IF NOT EXISTS share_visitors (
+			snippet_id TEXT NOT NULL,
+			day TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			PRIMARY KEY (snippet_id, day, ip),
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- API usage
+		CREATE TABLE IF NOT EXISTS api_usage (
+			day TEXT NOT NULL,
+			token_id INTEGER NOT NULL DEFAULT 0,
+			token_name TEXT NOT NULL DEFAULT '',
+			method TEXT NOT NULL,
+			route TEXT NOT NULL,
+			requests INTEGER NOT NULL DEFAULT 0,
+			errors INTEGER NOT NULL DEFAULT 0,
+			rate_limited INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, token_id, method, route)
+		);
+
+		-- Automation rules
+		CREATE TABLE IF NOT EXISTS rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			events TEXT NOT NULL,
+			script TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS rule_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			snippet_id TEXT NOT NULL DEFAULT '',
+			snippet_title TEXT NOT NULL DEFAULT '',
+			event TEXT NOT NULL,
+			status TEXT NOT NULL,
+			message TEXT NOT NULL DEFAULT '',
+			actions TEXT NOT NULL DEFAULT '',
+			output TEXT NOT NULL DEFAULT '',
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (rule_id) REFERENCES rules(id) ON DELETE CASCADE
+		);
+
+		-- Snippet history
+		CREATE TABLE IF NOT EXISTS snippet_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id TEXT NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT DEFAULT '',
+			content TEXT NOT NULL,
+			language TEXT DEFAULT 'plaintext',
+			is_favorite INTEGER DEFAULT 0,
+			is_public INTEGER DEFAULT 0,
+			is_archived INTEGER DEFAULT 0,
+			change_type TEXT DEFAULT 'update',
+			details TEXT NOT NULL DEFAULT '',
+			changed_by TEXT NOT NULL DEFAULT '',
+			source_ip TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS snippet_files_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			history_id INTEGER NOT NULL,
+			snippet_id TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content TEXT NOT NULL,
+			language TEXT NOT NULL,
+			sort_order INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (history_id) REFERENCES snippet_history(id) ON DELETE CASCADE,
+			FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+		);
+
+		-- Obsidian vault sync state
+		CREATE TABLE IF NOT EXISTS vault_notes (
+			path TEXT PRIMARY KEY,
+			snippet_id TEXT NOT NULL UNIQUE,
+			note_mtime DATETIME DEFAULT NULL,
+			note_checksum TEXT NOT NULL,
+			snippet_checksum TEXT NOT NULL,
+			synced_at DATETIME NOT NULL
+		);
+
 		-- Indexes
 		CREATE INDEX IF NOT EXISTS idx_snippets_language ON snippets(language);
 		CREATE INDEX IF NOT EXISTS idx_snippets_favorite ON snippets(is_favorite);
@@ -139,10 +490,12 @@ func runTestMigrations(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_snippets_archived ON snippets(is_archived);
 		CREATE INDEX IF NOT EXISTS idx_snippets_created ON snippets(created_at DESC);
 		CREATE INDEX IF NOT EXISTS idx_snippets_updated ON snippets(updated_at DESC);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_snippets_slug ON snippets(slug) WHERE slug IS NOT NULL;
 		CREATE INDEX IF NOT EXISTS idx_tags_name ON tags(name);
 		CREATE INDEX IF NOT EXISTS idx_folders_parent ON folders(parent_id);
 		CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
 		CREATE INDEX IF NOT EXISTS idx_snippet_files_snippet ON snippet_files(snippet_id);
+		CREATE INDEX IF NOT EXISTS idx_snippet_metadata_key_value ON snippet_metadata(key, value);
 
 		-- Full-text search
 		CREATE VIRTUAL TABLE IF NOT EXISTS snippets_fts USING fts5(
@@ -0,0 +1,314 @@
+package urlimport
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoCode is returned when a page contains no code blocks
+var ErrNoCode = errors.New("no code blocks found")
+
+// CodeBlock is a piece of code found on a page
+type CodeBlock struct {
+	Language string // Lowercase language hint, empty when unknown
+	Content  string
+}
+
+// Extraction is the result of extracting code from a page
+type Extraction struct {
+	Title  string
+	Blocks []CodeBlock
+}
+
+// languageAliases normalizes common language hints and file extensions
+var languageAliases = map[string]string{
+	"js": "javascript", "jsx": "javascript", "mjs": "javascript", "node": "javascript",
+	"ts": "typescript", "tsx": "typescript", "py": "python", "py3": "python", "python3": "python",
+	"golang": "go", "rs": "rust", "rb": "ruby", "kt": "kotlin", "h": "c", "hs": "haskell",
+	"sh": "bash", "shell": "bash", "zsh": "bash", "console": "bash", "shell-session": "bash",
+	"ps1": "powershell", "pwsh": "powershell", "c++": "cpp", "cc": "cpp", "cxx": "cpp",
+	"hpp": "cpp", "c#": "csharp", "cs": "csharp", "yml": "yaml", "md": "markdown",
+	"htm": "html", "xhtml": "html", "ex": "elixir", "exs": "elixir", "clj": "clojure",
+	"pl": "perl", "tf": "terraform", "hcl": "terraform", "proto": "protobuf",
+	"docker": "dockerfile", "text": "plaintext", "txt": "plaintext", "plain": "plaintext",
+	"none": "plaintext",
+}
+
+// classLanguage matches language hints in class attributes as used by
+// Prism/highlight.js (language-x, lang-x), GitHub (highlight-source-x) and
+// SyntaxHighlighter (brush: x)
+var classLanguage = regexp.MustCompile(`(?:^|\s)(?:language-|lang-|highlight-source-|brush:\s*)([\w#+.-]+)`)
+
+// stackOverflowAnswer matches answer permalinks such as /a/123 or /questions/1/x/123
+var stackOverflowAnswer = regexp.MustCompile(`^/(?:a/(\d+)|questions/\d+/[^/]+/(\d+))`)
+
+// NormalizeLanguage maps a language hint or file extension to a canonical name
+func NormalizeLanguage(hint string) string {
+	hint = strings.ToLower(strings.TrimSpace(hint))
+	hint = strings.TrimPrefix(hint, ".")
+	if mapped, ok := languageAliases[hint]; ok {
+		return mapped
+	}
+	return hint
+}
+
+// Extract finds code blocks in a fetched page
+func Extract(page *Page) (*Extraction, error) {
+	var ext *Extraction
+	switch {
+	case page.ContentType == "text/html" || page.ContentType == "application/xhtml+xml":
+		var err error
+		ext, err = extractHTML(page)
+		if err != nil {
+			return nil, err
+		}
+	case page.ContentType == "text/markdown" || page.ContentType == "text/x-markdown" || hasExt(page, ".md"):
+		ext = extractMarkdown(page)
+	case strings.HasPrefix(page.ContentType, "text/") || isTextual(page.ContentType):
+		ext = extractPlain(page)
+	default:
+		return nil, ErrNoCode
+	}
+
+	if len(ext.Blocks) == 0 {
+		return nil, ErrNoCode
+	}
+	if ext.Title == "" && page.URL != nil {
+		ext.Title = path.Base(page.URL.Path)
+		if ext.Title == "/" || ext.Title == "." {
+			ext.Title = page.URL.Hostname()
+		}
+	}
+	return ext, nil
+}
+
+// extractPlain treats the whole document as a single block (raw gists, raw files)
+func extractPlain(page *Page) *Extraction {
+	content := strings.TrimRight(string(page.Body), "\n")
+	ext := &Extraction{}
+	if strings.TrimSpace(content) == "" {
+		return ext
+	}
+	lang := ""
+	if page.URL != nil {
+		lang = NormalizeLanguage(path.Ext(page.URL.Path))
+	}
+	ext.Blocks = []CodeBlock{{Language: lang, Content: content}}
+	return ext
+}
+
+func extractMarkdown(page *Page) *Extraction {
+	ext := &Extraction{}
+	body := strings.ReplaceAll(string(page.Body), "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			ext.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			break
+		}
+	}
+
+	var fence, lang string
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				info := strings.TrimLeft(trimmed, trimmed[:1])
+				fence = trimmed[:len(trimmed)-len(info)]
+				lang = ""
+				if fields := strings.Fields(info); len(fields) > 0 {
+					lang = NormalizeLanguage(strings.Trim(fields[0], "{}"))
+				}
+				lines = lines[:0]
+			}
+			continue
+		}
+		// A closing fence uses the same character and at least as many of them
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == "" {
+			if content := strings.Join(lines, "\n"); strings.TrimSpace(content) != "" {
+				ext.Blocks = append(ext.Blocks, CodeBlock{Language: lang, Content: content})
+			}
+			fence = ""
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return ext
+}
+
+func extractHTML(page *Page) (*Extraction, error) {
+	doc, err := html.Parse(bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	ext := &Extraction{Title: pageTitle(doc)}
+
+	root := doc
+	if answer := stackOverflowRoot(doc, page); answer != nil {
+		root = answer
+	}
+
+	walk(root, func(n *html.Node) bool {
+		if n.DataAtom != atom.Pre {
+			return true
+		}
+		content := strings.TrimRight(textContent(n), "\n")
+		if strings.TrimSpace(content) != "" {
+			ext.Blocks = append(ext.Blocks, CodeBlock{Language: preLanguage(n), Content: content})
+		}
+		// Nested <pre> elements are part of this block
+		return false
+	})
+
+	return ext, nil
+}
+
+// stackOverflowRoot returns the answer element a StackExchange answer link points to
+func stackOverflowRoot(doc *html.Node, page *Page) *html.Node {
+	if page.URL == nil {
+		return nil
+	}
+	id := strings.TrimPrefix(page.URL.Fragment, "answer-")
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		id = ""
+		if m := stackOverflowAnswer.FindStringSubmatch(page.URL.Path); m != nil {
+			id = m[1] + m[2]
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	return findByID(doc, "answer-"+id)
+}
+
+// preLanguage reads a language hint from a <pre> element or its <code> child
+func preLanguage(pre *html.Node) string {
+	if lang := classHint(pre); lang != "" {
+		return lang
+	}
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Code {
+			if lang := classHint(c); lang != "" {
+				return lang
+			}
+		}
+	}
+	// GitHub and highlight.js put the hint on a wrapping element
+	if p := pre.Parent; p != nil {
+		if lang := classHint(p); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+func classHint(n *html.Node) string {
+	if lang := attr(n, "data-lang"); lang != "" {
+		return NormalizeLanguage(lang)
+	}
+	if lang := attr(n, "data-language"); lang != "" {
+		return NormalizeLanguage(lang)
+	}
+	if m := classLanguage.FindStringSubmatch(attr(n, "class")); m != nil {
+		return NormalizeLanguage(strings.TrimSuffix(m[1], ";"))
+	}
+	return ""
+}
+
+// pageTitle prefers og:title over <title>
+func pageTitle(doc *html.Node) string {
+	var title, og string
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Meta:
+			if og == "" && attr(n, "property") == "og:title" {
+				og = strings.TrimSpace(attr(n, "content"))
+			}
+		case atom.Title:
+			if title == "" {
+				title = strings.TrimSpace(textContent(n))
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+	if og != "" {
+		return og
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// walk visits nodes depth-first; returning false skips a node's children
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if n.Type == html.ElementNode && !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func findByID(doc *html.Node, id string) *html.Node {
+	var found *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if attr(n, "id") == id {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			if n.DataAtom == atom.Br {
+				b.WriteByte('\n')
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasExt(page *Page, ext string) bool {
+	return page.URL != nil && strings.EqualFold(path.Ext(page.URL.Path), ext)
+}
+
+func isTextual(contentType string) bool {
+	switch contentType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/x-sh", "application/x-yaml", "application/yaml", "application/toml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package urlimport
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func page(t *testing.T, rawURL, contentType, body string) *Page {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Page{URL: u, ContentType: contentType, Body: []byte(body)}
+}
+
+func TestExtract_HTML(t *testing.T) {
+	body := `<html><head><title>Ignored</title><meta property="og:title" content="Reading files in Go"></head>
+<body>
+<p>Some prose with <code>inline code</code>.</p>
+<pre><code class="language-go">data, err := os.ReadFile("x")
+if err != nil {
+	return err
+}</code></pre>
+<div class="highlight highlight-source-shell"><pre>go run . &amp;&amp; echo ok</pre></div>
+<pre class="brush: js;">console.log(1)</pre>
+<pre>plain block</pre>
+<pre>   </pre>
+</body></html>`
+
+	ext, err := Extract(page(t, "https://blog.example.com/post", "text/html", body))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if ext.Title != "Reading files in Go" {
+		t.Errorf("expected og:title, got %q", ext.Title)
+	}
+
+	want := []CodeBlock{
+		{Language: "go", Content: "data, err := os.ReadFile(\"x\")\nif err != nil {\n\treturn err\n}"},
+		{Language: "bash", Content: "go run . && echo ok"},
+		{Language: "javascript", Content: "console.log(1)"},
+		{Language: "", Content: "plain block"},
+	}
+	if len(ext.Blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %d: %+v", len(want), len(ext.Blocks), ext.Blocks)
+	}
+	for i, b := range want {
+		if ext.Blocks[i] != b {
+			t.Errorf("block %d = %+v, want %+v", i, ext.Blocks[i], b)
+		}
+	}
+}
+
+func TestExtract_StackOverflowAnswer(t *testing.T) {
+	body := `<html><head><title>How to reverse a list</title></head><body>
+<div id="question"><pre><code>question code</code></pre></div>
+<div id="answer-111" class="answer"><pre class="lang-py"><code>first answer</code></pre></div>
+<div id="answer-222" class="answer"><pre class="lang-py s-code-block"><code>xs[::-1]</code></pre></div>
+</body></html>`
+
+	for _, u := range []string{
+		"https://stackoverflow.com/a/222",
+		"https://stackoverflow.com/questions/1/how-to-reverse/222#222",
+		"https://stackoverflow.com/questions/1/how-to-reverse#answer-222",
+	} {
+		ext, err := Extract(page(t, u, "text/html", body))
+		if err != nil {
+			t.Fatalf("%s: Extract failed: %v", u, err)
+		}
+		if len(ext.Blocks) != 1 || ext.Blocks[0].Content != "xs[::-1]" || ext.Blocks[0].Language != "python" {
+			t.Errorf("%s: expected only the linked answer, got %+v", u, ext.Blocks)
+		}
+	}
+
+	// Without an answer link the whole page is used
+	ext, err := Extract(page(t, "https://stackoverflow.com/questions/1/how-to-reverse", "text/html", body))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(ext.Blocks) != 3 {
+		t.Errorf("expected 3 blocks, got %d", len(ext.Blocks))
+	}
+}
+
+func TestExtract_Markdown(t *testing.T) {
+	body := "# Setup notes\n\nInstall:\n\n```sh\nmake install\n```\n\n~~~~yml title=\"config\"\nkey: value\n```\nstill yaml\n~~~~\n\n```\n\n```\n"
+
+	ext, err := Extract(page(t, "https://example.com/README.md", "text/plain", body))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if ext.Title != "Setup notes" {
+		t.Errorf("unexpected title %q", ext.Title)
+	}
+	if len(ext.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", ext.Blocks)
+	}
+	if ext.Blocks[0] != (CodeBlock{Language: "bash", Content: "make install"}) {
+		t.Errorf("unexpected first block %+v", ext.Blocks[0])
+	}
+	if ext.Blocks[1] != (CodeBlock{Language: "yaml", Content: "key: value\n```\nstill yaml"}) {
+		t.Errorf("unexpected second block %+v", ext.Blocks[1])
+	}
+}
+
+func TestExtract_RawGist(t *testing.T) {
+	ext, err := Extract(page(t, "https://gist.githubusercontent.com/u/abc/raw/def/backup.py", "text/plain", "import os\nprint(os.getcwd())\n"))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if ext.Title != "backup.py" {
+		t.Errorf("expected filename title, got %q", ext.Title)
+	}
+	if len(ext.Blocks) != 1 || ext.Blocks[0].Language != "python" || ext.Blocks[0].Content != "import os\nprint(os.getcwd())" {
+		t.Errorf("unexpected blocks %+v", ext.Blocks)
+	}
+}
+
+func TestExtract_NoCode(t *testing.T) {
+	cases := []*Page{
+		page(t, "https://example.com/", "text/html", "<p>No code here</p>"),
+		page(t, "https://example.com/logo.png", "image/png", "\x89PNG"),
+		page(t, "https://example.com/empty.txt", "text/plain", "  \n"),
+	}
+	for _, p := range cases {
+		if _, err := Extract(p); !errors.Is(err, ErrNoCode) {
+			t.Errorf("%s: expected ErrNoCode, got %v", p.URL, err)
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"JS":         "javascript",
+		".py":        "python",
+		"golang":     "go",
+		"C++":        "cpp",
+		"c#":         "csharp",
+		"yml":        "yaml",
+		"rust":       "rust",
+		"Dockerfile": "dockerfile",
+	}
+	for in, want := range tests {
+		if got := NormalizeLanguage(in); got != want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package urlimport fetches web pages and extracts code blocks from them.
+//
+// Fetching is hardened against SSRF: only http and https are allowed, every
+// connection (including redirects) is checked against private, loopback and
+// other non-public address ranges after DNS resolution, and responses are
+// bounded in size and time.
+package urlimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Errors returned by Fetch
+var (
+	ErrInvalidURL     = errors.New("invalid url: only absolute http and https URLs are allowed")
+	ErrBlockedAddress = errors.New("url resolves to a private or reserved address")
+	ErrTooLarge       = errors.New("response too large")
+	ErrTooManyHops    = errors.New("too many redirects")
+	ErrFetchFailed    = errors.New("failed to fetch url")
+)
+
+// Config controls fetch limits
+type Config struct {
+	Timeout      time.Duration // Total time for the request including redirects
+	MaxBytes     int64         // Maximum response body size
+	MaxRedirects int
+	AllowPrivate bool // Permit private and loopback addresses (trusted networks only)
+	UserAgent    string
+}
+
+// Page is a fetched document
+type Page struct {
+	URL         *url.URL // Final URL after redirects
+	ContentType string   // Media type without parameters
+	Body        []byte
+}
+
+// Fetcher retrieves pages with SSRF protections
+type Fetcher struct {
+	cfg    Config
+	client *http.Client
+}
+
+// blockedNets are non-public ranges not covered by the net.IP helpers
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"192.0.2.0/24",  // documentation
+	"198.18.0.0/15", // benchmarking
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",   // reserved
+	"64:ff9b::/96",  // NAT64
+	"2001:db8::/32", // documentation
+)
+
+// NewFetcher creates a fetcher, applying defaults for zero values
+func NewFetcher(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 2 << 20
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "Snipo URL Importer"
+	}
+
+	f := &Fetcher{cfg: cfg}
+
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		// Control runs after DNS resolution for every connection attempt, so it
+		// also covers redirects and DNS rebinding
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return f.checkIP(net.ParseIP(host))
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // A proxy would hide the real destination from the address check
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          4,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return ErrTooManyHops
+			}
+			return validateURL(req.URL)
+		},
+	}
+
+	return f
+}
+
+// Fetch downloads rawURL
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+	// Reject literal addresses early for a clearer error
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if err := f.checkIP(ip); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		for _, known := range []error{ErrBlockedAddress, ErrTooManyHops, ErrInvalidURL} {
+			if errors.Is(err, known) {
+				return nil, known
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrFetchFailed, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%w: server returned %d", ErrFetchFailed, resp.StatusCode)
+	}
+	if resp.ContentLength > f.cfg.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response: %v", ErrFetchFailed, err)
+	}
+	if int64(len(body)) > f.cfg.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+		contentType, _, _ = mime.ParseMediaType(contentType)
+	}
+
+	return &Page{URL: resp.Request.URL, ContentType: contentType, Body: body}, nil
+}
+
+// checkIP rejects addresses that are not publicly routable
+func (f *Fetcher) checkIP(ip net.IP) error {
+	if ip == nil {
+		return ErrBlockedAddress
+	}
+	if f.cfg.AllowPrivate {
+		return nil
+	}
+	if IsPublicIP(ip) {
+		return nil
+	}
+	return ErrBlockedAddress
+}
+
+// IsPublicIP reports whether ip is a globally routable unicast address
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateURL(u *url.URL) error {
+	if u == nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
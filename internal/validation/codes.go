@@ -0,0 +1,143 @@
+package validation
+
+// Validation error codes. Each rule has a stable code that clients can
+// branch on or translate; the message is English text for people and may
+// change. Length and range rules carry their limits in the error's params:
+// "max" (and "min") plus the "actual" value, counted in characters unless
+// the rule says bytes.
+const (
+	// Snippets
+	CodeTitleRequired        = "TITLE_REQUIRED"
+	CodeTitleTooLong         = "TITLE_TOO_LONG"
+	CodeContentRequired      = "CONTENT_REQUIRED"
+	CodeContentTooLarge      = "CONTENT_TOO_LARGE" // Bytes
+	CodeDescriptionTooLong   = "DESCRIPTION_TOO_LONG"
+	CodeLanguageInvalid      = "LANGUAGE_INVALID"
+	CodeSourceURLTooLong     = "SOURCE_URL_TOO_LONG" // Bytes
+	CodeSourceURLInvalid     = "SOURCE_URL_INVALID"
+	CodeSlugInvalid          = "SLUG_INVALID"
+	CodeLicenseInvalid       = "LICENSE_INVALID"
+	CodeAttributionTooLong   = "ATTRIBUTION_TOO_LONG"
+	CodeMetadataTooMany      = "METADATA_TOO_MANY_FIELDS"
+	CodeMetadataKeyRequired  = "METADATA_KEY_REQUIRED"
+	CodeMetadataKeyTooLong   = "METADATA_KEY_TOO_LONG" // Bytes
+	CodeMetadataKeyInvalid   = "METADATA_KEY_INVALID"
+	CodeMetadataValueTooLong = "METADATA_VALUE_TOO_LONG"
+	CodePublishAtInvalid     = "PUBLISH_AT_INVALID"
+	CodeUsageEventInvalid    = "USAGE_EVENT_INVALID"
+
+	// Files
+	CodeFilenameRequired = "FILENAME_REQUIRED"
+	CodeFilenameTooLong  = "FILENAME_TOO_LONG"
+	CodeFilenameInvalid  = "FILENAME_INVALID"
+	CodeFileTooLarge     = "FILE_TOO_LARGE" // Bytes
+
+	// Tags
+	CodeTagRequired     = "TAG_REQUIRED"
+	CodeTagTooLong      = "TAG_TOO_LONG" // Bytes
+	CodeTagInvalid      = "TAG_INVALID"
+	CodeTagColorInvalid = "TAG_COLOR_INVALID"
+
+	// Folders
+	CodeFolderNameRequired = "FOLDER_NAME_REQUIRED"
+	CodeFolderNameTooLong  = "FOLDER_NAME_TOO_LONG"
+	CodeFolderIconInvalid  = "FOLDER_ICON_INVALID"
+	CodeFolderColorInvalid = "FOLDER_COLOR_INVALID"
+	CodeParentNotFound     = "PARENT_FOLDER_NOT_FOUND"
+	CodeParentIsSelf       = "PARENT_FOLDER_IS_SELF"
+	CodeParentCycle        = "PARENT_FOLDER_CYCLE"
+
+	CodeFolderArchiveDaysOutOfRange = "FOLDER_ARCHIVE_DAYS_OUT_OF_RANGE"
+
+	// Links
+	CodeLinkTargetRequired  = "LINK_TARGET_REQUIRED"
+	CodeLinkTargetNotFound  = "LINK_TARGET_NOT_FOUND"
+	CodeLinkTargetIsSelf    = "LINK_TARGET_IS_SELF"
+	CodeLinkRelationInvalid = "LINK_RELATION_INVALID"
+	CodeResolveTooManyLinks = "RESOLVE_TOO_MANY_LINKS"
+
+	// Edit locks
+	CodeLockTTLOutOfRange = "LOCK_TTL_OUT_OF_RANGE"
+	CodeLockHolderTooLong = "LOCK_HOLDER_TOO_LONG"
+
+	// Check-outs
+	CodeCheckoutReasonRequired = "CHECKOUT_REASON_REQUIRED"
+	CodeCheckoutReasonTooLong  = "CHECKOUT_REASON_TOO_LONG"
+	CodeCheckoutTTLOutOfRange  = "CHECKOUT_TTL_OUT_OF_RANGE"
+	CodeCheckinNoteTooLong     = "CHECKIN_NOTE_TOO_LONG"
+
+	// Signed URLs
+	CodeSignedURLTTLOutOfRange = "SIGNED_URL_TTL_OUT_OF_RANGE"
+
+	// Burn-after-read links
+	CodeBurnLinkExpiryOutOfRange = "BURN_LINK_EXPIRY_OUT_OF_RANGE"
+
+	// Abuse reports
+	CodeReportReasonInvalid   = "REPORT_REASON_INVALID"
+	CodeReportDetailsTooLong  = "REPORT_DETAILS_TOO_LONG"
+	CodeReportChallengeFailed = "REPORT_CHALLENGE_FAILED"
+
+	// Reviews
+	CodeReviewCommentTooLong = "REVIEW_COMMENT_TOO_LONG"
+
+	// API tokens
+	CodeTokenNameRequired           = "TOKEN_NAME_REQUIRED"
+	CodeTokenNameTooLong            = "TOKEN_NAME_TOO_LONG"
+	CodeTokenPermissionInvalid      = "TOKEN_PERMISSIONS_INVALID"
+	CodeTokenAllowedIPInvalid       = "TOKEN_ALLOWED_IP_INVALID"
+	CodeTokenAllowedReferrerInvalid = "TOKEN_ALLOWED_REFERRER_INVALID"
+	CodeTokenFoldersInvalid         = "TOKEN_FOLDERS_INVALID"
+
+	// Settings
+	CodeAppNameTooLong         = "APP_NAME_TOO_LONG"
+	CodeThemeInvalid           = "THEME_INVALID"
+	CodeEditorThemeInvalid     = "EDITOR_THEME_INVALID"
+	CodeFontSizeOutOfRange     = "FONT_SIZE_OUT_OF_RANGE"
+	CodeTabSizeOutOfRange      = "TAB_SIZE_OUT_OF_RANGE"
+	CodeDefaultLanguageInvalid = "DEFAULT_LANGUAGE_INVALID"
+	CodeS3EndpointRequired     = "S3_ENDPOINT_REQUIRED"
+	CodeS3BucketRequired       = "S3_BUCKET_REQUIRED"
+	CodeS3RegionRequired       = "S3_REGION_REQUIRED"
+	CodeAnnouncementNotFound   = "ANNOUNCEMENT_SNIPPET_NOT_FOUND"
+	CodeLimitOutOfRange        = "VALIDATION_LIMIT_OUT_OF_RANGE"
+	CodeTagPatternInvalid      = "TAG_PATTERN_INVALID"
+	CodeTagPatternTooLong      = "TAG_PATTERN_TOO_LONG"
+
+	// Imports
+	CodeURLRequired = "URL_REQUIRED"
+
+	// Inbox
+	CodeInboxSourceTooLong = "INBOX_SOURCE_TOO_LONG"
+
+	// Automation rules
+	CodeRuleNameRequired   = "RULE_NAME_REQUIRED"
+	CodeRuleNameTooLong    = "RULE_NAME_TOO_LONG"
+	CodeRuleEventsInvalid  = "RULE_EVENTS_INVALID"
+	CodeRuleScriptRequired = "RULE_SCRIPT_REQUIRED"
+	CodeRuleScriptTooLarge = "RULE_SCRIPT_TOO_LARGE" // Bytes
+	CodeRuleScriptInvalid  = "RULE_SCRIPT_INVALID"
+	CodeRuleRejected       = "RULE_REJECTED" // A rule rejected a snippet save
+
+	// History search
+	CodeHistoryQueryRequired = "HISTORY_QUERY_REQUIRED"
+	CodeHistoryQueryTooLong  = "HISTORY_QUERY_TOO_LONG"
+	CodeHistoryQueryInvalid  = "HISTORY_QUERY_INVALID"
+
+	// Redaction rules
+	CodeRedactionNameRequired       = "REDACTION_NAME_REQUIRED"
+	CodeRedactionNameTooLong        = "REDACTION_NAME_TOO_LONG"
+	CodeRedactionPatternRequired    = "REDACTION_PATTERN_REQUIRED"
+	CodeRedactionPatternTooLong     = "REDACTION_PATTERN_TOO_LONG"
+	CodeRedactionPatternInvalid     = "REDACTION_PATTERN_INVALID"
+	CodeRedactionReplacementTooLong = "REDACTION_REPLACEMENT_TOO_LONG"
+)
+
+// TooLong returns an error for a value longer than max
+func TooLong(field, code, message string, max, actual int) ValidationError {
+	return ValidationError{Field: field, Code: code, Message: message, Params: map[string]any{"max": max, "actual": actual}}
+}
+
+// OutOfRange returns an error for a number outside [min, max]
+func OutOfRange(field, code, message string, min, max, actual int) ValidationError {
+	return ValidationError{Field: field, Code: code, Message: message, Params: map[string]any{"min": min, "max": max, "actual": actual}}
+}
@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// tagPalette is the curated set of tag colors offered by clients. Any other
+// hex color is accepted too.
+var tagPalette = []models.PaletteColor{
+	{Name: "slate", Color: "#64748b"},
+	{Name: "gray", Color: "#6b7280"},
+	{Name: "red", Color: "#ef4444"},
+	{Name: "orange", Color: "#f97316"},
+	{Name: "amber", Color: "#f59e0b"},
+	{Name: "yellow", Color: "#eab308"},
+	{Name: "lime", Color: "#84cc16"},
+	{Name: "green", Color: "#22c55e"},
+	{Name: "emerald", Color: "#10b981"},
+	{Name: "teal", Color: "#14b8a6"},
+	{Name: "cyan", Color: "#06b6d4"},
+	{Name: "sky", Color: "#0ea5e9"},
+	{Name: "blue", Color: "#3b82f6"},
+	{Name: "indigo", Color: models.DefaultTagColor},
+	{Name: "violet", Color: "#8b5cf6"},
+	{Name: "purple", Color: "#a855f7"},
+	{Name: "fuchsia", Color: "#d946ef"},
+	{Name: "pink", Color: "#ec4899"},
+	{Name: "rose", Color: "#f43f5e"},
+}
+
+// GetTagPalette returns the tag color palette in display order, each color
+// with the text color to draw labels in
+func GetTagPalette() []models.PaletteColor {
+	palette := make([]models.PaletteColor, len(tagPalette))
+	for i, c := range tagPalette {
+		c.TextColor = models.TagTextColor(c.Color)
+		palette[i] = c
+	}
+	return palette
+}
+
+// ValidateTagColor validates and normalizes a tag's color. An empty color
+// means the default one.
+func ValidateTagColor(input *models.TagInput) ValidationErrors {
+	input.Color = strings.ToLower(strings.TrimSpace(input.Color))
+	if input.Color == "" {
+		input.Color = models.DefaultTagColor
+		return nil
+	}
+	if !colorRegex.MatchString(input.Color) {
+		return ValidationErrors{{Field: "color", Code: CodeTagColorInvalid, Message: "Color must be a hex value like #3b82f6"}}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// folderIcons is the catalog of supported folder icons. Names follow the
+// Feather/Lucide icon sets so clients can render them with either library.
+var folderIcons = []models.Icon{
+	{Name: "folder", Category: "general"},
+	{Name: "folder-open", Category: "general"},
+	{Name: "archive", Category: "general"},
+	{Name: "bookmark", Category: "general"},
+	{Name: "star", Category: "general"},
+	{Name: "heart", Category: "general"},
+	{Name: "flag", Category: "general"},
+	{Name: "inbox", Category: "general"},
+	{Name: "tag", Category: "general"},
+	{Name: "zap", Category: "general"},
+	{Name: "code", Category: "development"},
+	{Name: "terminal", Category: "development"},
+	{Name: "git-branch", Category: "development"},
+	{Name: "git-merge", Category: "development"},
+	{Name: "github", Category: "development"},
+	{Name: "package", Category: "development"},
+	{Name: "box", Category: "development"},
+	{Name: "cpu", Category: "development"},
+	{Name: "tool", Category: "development"},
+	{Name: "settings", Category: "development"},
+	{Name: "database", Category: "infrastructure"},
+	{Name: "server", Category: "infrastructure"},
+	{Name: "cloud", Category: "infrastructure"},
+	{Name: "globe", Category: "infrastructure"},
+	{Name: "layers", Category: "infrastructure"},
+	{Name: "hard-drive", Category: "infrastructure"},
+	{Name: "monitor", Category: "infrastructure"},
+	{Name: "smartphone", Category: "infrastructure"},
+	{Name: "lock", Category: "security"},
+	{Name: "key", Category: "security"},
+	{Name: "shield", Category: "security"},
+	{Name: "book", Category: "documents"},
+	{Name: "book-open", Category: "documents"},
+	{Name: "file", Category: "documents"},
+	{Name: "file-text", Category: "documents"},
+	{Name: "clipboard", Category: "documents"},
+	{Name: "edit", Category: "documents"},
+}
+
+// colorRegex validates hex colors (#rgb or #rrggbb)
+var colorRegex = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// GetFolderIcons returns the supported folder icons in catalog order
+func GetFolderIcons() []models.Icon {
+	icons := make([]models.Icon, len(folderIcons))
+	copy(icons, folderIcons)
+	return icons
+}
+
+// IsValidFolderIcon reports whether name is in the icon catalog
+func IsValidFolderIcon(name string) bool {
+	for _, icon := range folderIcons {
+		if icon.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFolderStyle validates and normalizes a folder's icon and color.
+// Empty values are allowed and mean the default icon and color.
+func ValidateFolderStyle(input *models.FolderInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Icon = strings.ToLower(strings.TrimSpace(input.Icon))
+	if input.Icon != "" && !IsValidFolderIcon(input.Icon) {
+		errs = append(errs, ValidationError{Field: "icon", Code: CodeFolderIconInvalid, Message: "Unknown icon; see GET /api/v1/icons for supported icons"})
+	}
+
+	input.Color = strings.ToLower(strings.TrimSpace(input.Color))
+	if input.Color != "" && !colorRegex.MatchString(input.Color) {
+		errs = append(errs, ValidationError{Field: "color", Code: CodeFolderColorInvalid, Message: "Color must be a hex value like #3b82f6"})
+	}
+
+	return errs
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// MaxAttributionLength is the longest attribution accepted from clients
+const MaxAttributionLength = 500
+
+// licenses is the catalog of accepted snippet licenses: the SPDX
+// identifiers code is commonly shared under
+var licenses = []models.License{
+	{ID: "0BSD", Name: "BSD Zero Clause License"},
+	{ID: "AGPL-3.0-only", Name: "GNU Affero General Public License v3.0 only"},
+	{ID: "AGPL-3.0-or-later", Name: "GNU Affero General Public License v3.0 or later"},
+	{ID: "Apache-2.0", Name: "Apache License 2.0"},
+	{ID: "Artistic-2.0", Name: "Artistic License 2.0"},
+	{ID: "BSD-2-Clause", Name: "BSD 2-Clause \"Simplified\" License"},
+	{ID: "BSD-3-Clause", Name: "BSD 3-Clause \"New\" or \"Revised\" License"},
+	{ID: "BSL-1.0", Name: "Boost Software License 1.0"},
+	{ID: "CC-BY-4.0", Name: "Creative Commons Attribution 4.0 International"},
+	{ID: "CC-BY-SA-4.0", Name: "Creative Commons Attribution Share Alike 4.0 International"},
+	{ID: "CC-BY-NC-4.0", Name: "Creative Commons Attribution Non Commercial 4.0 International"},
+	{ID: "CC-BY-NC-SA-4.0", Name: "Creative Commons Attribution Non Commercial Share Alike 4.0 International"},
+	{ID: "CC0-1.0", Name: "Creative Commons Zero v1.0 Universal"},
+	{ID: "EPL-2.0", Name: "Eclipse Public License 2.0"},
+	{ID: "EUPL-1.2", Name: "European Union Public License 1.2"},
+	{ID: "GPL-2.0-only", Name: "GNU General Public License v2.0 only"},
+	{ID: "GPL-2.0-or-later", Name: "GNU General Public License v2.0 or later"},
+	{ID: "GPL-3.0-only", Name: "GNU General Public License v3.0 only"},
+	{ID: "GPL-3.0-or-later", Name: "GNU General Public License v3.0 or later"},
+	{ID: "ISC", Name: "ISC License"},
+	{ID: "LGPL-2.1-only", Name: "GNU Lesser General Public License v2.1 only"},
+	{ID: "LGPL-2.1-or-later", Name: "GNU Lesser General Public License v2.1 or later"},
+	{ID: "LGPL-3.0-only", Name: "GNU Lesser General Public License v3.0 only"},
+	{ID: "LGPL-3.0-or-later", Name: "GNU Lesser General Public License v3.0 or later"},
+	{ID: "MIT", Name: "MIT License"},
+	{ID: "MIT-0", Name: "MIT No Attribution"},
+	{ID: "MPL-2.0", Name: "Mozilla Public License 2.0"},
+	{ID: "Unlicense", Name: "The Unlicense"},
+	{ID: "WTFPL", Name: "Do What The F*ck You Want To Public License"},
+	{ID: "Zlib", Name: "zlib License"},
+}
+
+// licenseRefRegex matches SPDX custom license references, for terms outside
+// the catalog
+var licenseRefRegex = regexp.MustCompile(`^LicenseRef-[A-Za-z0-9.-]{1,64}$`)
+
+// GetLicenses returns the license catalog
+func GetLicenses() []models.License {
+	list := make([]models.License, len(licenses))
+	copy(list, licenses)
+	return list
+}
+
+// NormalizeLicense returns the canonical spelling of an SPDX identifier from
+// the catalog ("mit" gives "MIT") or a LicenseRef- reference, and reports
+// whether the identifier is accepted
+func NormalizeLicense(id string) (string, bool) {
+	for _, license := range licenses {
+		if strings.EqualFold(license.ID, id) {
+			return license.ID, true
+		}
+	}
+	if ref, ok := cutPrefixFold(id, "LicenseRef-"); ok && licenseRefRegex.MatchString("LicenseRef-"+ref) {
+		return "LicenseRef-" + ref, true
+	}
+	return "", false
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring case
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// Default snippet limits, used unless the settings change them
+const (
+	DefaultMaxTitleLength       = 200
+	DefaultMaxDescriptionLength = 1000
+	DefaultMaxContentSize       = 1024 * 1024
+	DefaultMaxTagLength         = 50
+	DefaultTagPattern           = `[a-zA-Z0-9_-]+`
+)
+
+// Bounds the settings can move the limits within. Content beyond
+// MaxContentSizeLimit would not fit in an API request body anyway.
+const (
+	MaxTitleLengthLimit       = 1000
+	MaxDescriptionLengthLimit = 20000
+	MinContentSizeLimit       = 1024
+	MaxContentSizeLimit       = 2 * 1024 * 1024
+	MaxTagLengthLimit         = 100
+	MaxTagPatternLength       = 200
+)
+
+// DefaultRules returns the built-in snippet limits
+func DefaultRules() models.ValidationRules {
+	return models.ValidationRules{
+		MaxTitleLength:       DefaultMaxTitleLength,
+		MaxDescriptionLength: DefaultMaxDescriptionLength,
+		MaxContentSize:       DefaultMaxContentSize,
+		MaxTagLength:         DefaultMaxTagLength,
+	}
+}
+
+// withDefaults fills limits left at zero with their defaults
+func withDefaults(rules models.ValidationRules) models.ValidationRules {
+	defaults := DefaultRules()
+	if rules.MaxTitleLength <= 0 {
+		rules.MaxTitleLength = defaults.MaxTitleLength
+	}
+	if rules.MaxDescriptionLength <= 0 {
+		rules.MaxDescriptionLength = defaults.MaxDescriptionLength
+	}
+	if rules.MaxContentSize <= 0 {
+		rules.MaxContentSize = defaults.MaxContentSize
+	}
+	if rules.MaxTagLength <= 0 {
+		rules.MaxTagLength = defaults.MaxTagLength
+	}
+	return rules
+}
+
+// ValidateRules validates validation rules set through the settings
+func ValidateRules(rules *models.ValidationRules) ValidationErrors {
+	var errs ValidationErrors
+
+	if rules.MaxTitleLength < 1 || rules.MaxTitleLength > MaxTitleLengthLimit {
+		errs = append(errs, OutOfRange("validation.max_title_length", CodeLimitOutOfRange, "Maximum title length must be between 1 and 1000", 1, MaxTitleLengthLimit, rules.MaxTitleLength))
+	}
+	if rules.MaxDescriptionLength < 1 || rules.MaxDescriptionLength > MaxDescriptionLengthLimit {
+		errs = append(errs, OutOfRange("validation.max_description_length", CodeLimitOutOfRange, "Maximum description length must be between 1 and 20000", 1, MaxDescriptionLengthLimit, rules.MaxDescriptionLength))
+	}
+	if rules.MaxContentSize < MinContentSizeLimit || rules.MaxContentSize > MaxContentSizeLimit {
+		errs = append(errs, OutOfRange("validation.max_content_size", CodeLimitOutOfRange, "Maximum content size must be between 1KB and 2MB", MinContentSizeLimit, MaxContentSizeLimit, rules.MaxContentSize))
+	}
+	if rules.MaxTagLength < 1 || rules.MaxTagLength > MaxTagLengthLimit {
+		errs = append(errs, OutOfRange("validation.max_tag_length", CodeLimitOutOfRange, "Maximum tag length must be between 1 and 100", 1, MaxTagLengthLimit, rules.MaxTagLength))
+	}
+
+	rules.TagPattern = strings.TrimSpace(rules.TagPattern)
+	if len(rules.TagPattern) > MaxTagPatternLength {
+		errs = append(errs, TooLong("validation.tag_pattern", CodeTagPatternTooLong, "Tag pattern must be at most 200 characters", MaxTagPatternLength, len(rules.TagPattern)))
+	} else if re, err := tagPatternRegexp(rules.TagPattern); err != nil {
+		errs = append(errs, ValidationError{Field: "validation.tag_pattern", Code: CodeTagPatternInvalid, Message: "Tag pattern is not a valid regular expression"})
+	} else {
+		// Tags are listed and searched comma-separated, and blank ones are dropped
+		for _, unsafe := range []string{"", " ", ",", "a b", "a,b"} {
+			if re.MatchString(unsafe) {
+				errs = append(errs, ValidationError{Field: "validation.tag_pattern", Code: CodeTagPatternInvalid, Message: "Tag pattern must not allow empty tags, spaces or commas"})
+				break
+			}
+		}
+	}
+
+	return errs
+}
+
+// tagPatterns caches compiled tag patterns by their source
+var tagPatterns sync.Map
+
+// tagPatternRegexp compiles a tag pattern to match whole tag names, with the
+// default pattern for an empty one
+func tagPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" || pattern == DefaultTagPattern {
+		return tagRegex, nil
+	}
+	if re, ok := tagPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	tagPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// formatSize formats a byte limit the way messages state it
+func formatSize(bytes int) string {
+	switch {
+	case bytes%(1024*1024) == 0:
+		return fmt.Sprintf("%dMB", bytes/(1024*1024))
+	case bytes%1024 == 0:
+		return fmt.Sprintf("%dKB", bytes/1024)
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
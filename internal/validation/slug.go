@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxSlugLength is the longest slug accepted from clients
+const MaxSlugLength = 100
+
+// maxDerivedSlugLength leaves room for a conflict suffix on derived slugs
+const maxDerivedSlugLength = 80
+
+// slugRegex matches lowercase words separated by single hyphens
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// snippetIDRegex matches generated snippet IDs, which slugs must not resemble
+var snippetIDRegex = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// slugFolds transliterates common accented Latin letters
+var slugFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// IsValidSlug reports whether slug is well formed
+func IsValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugRegex.MatchString(slug) && !snippetIDRegex.MatchString(slug)
+}
+
+// Slugify derives a slug from a title, e.g. "Docker Compose: Redis!" becomes
+// "docker-compose-redis". Common accents are folded; titles without any
+// usable characters yield "snippet".
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		word, ok := slugFolds[r]
+		if !ok && r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word, ok = string(r), true
+		}
+		if !ok {
+			hyphen = true
+			continue
+		}
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(word)
+	}
+
+	slug := b.String()
+	if len(slug) > maxDerivedSlugLength {
+		slug = strings.TrimRight(slug[:maxDerivedSlugLength], "-")
+	}
+	if slug == "" {
+		return "snippet"
+	}
+	if snippetIDRegex.MatchString(slug) {
+		slug += "-snippet"
+	}
+	return slug
+}
+
+// FolderSlug derives a folder's slug from its name like Slugify, keeping
+// it from reading as a folder ID
+func FolderSlug(name string) string {
+	slug := Slugify(name)
+	if isDigits(slug) {
+		slug += "-collection"
+	}
+	return slug
+}
+
+// isDigits reports whether s is made of ASCII digits only
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
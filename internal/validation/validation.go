@@ -1,17 +1,24 @@
 package validation
 
 import (
+	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
 
+	"github.com/MohamedElashri/snipo/internal/lua"
 	"github.com/MohamedElashri/snipo/internal/models"
 )
 
 // ValidationError represents a field validation error
 type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`             // Stable rule code, see codes.go
+	Message string         `json:"message"`          // English text for people
+	Params  map[string]any `json:"params,omitempty"` // Limits such as max and actual
 }
 
 // ValidationErrors is a collection of validation errors
@@ -46,36 +53,55 @@ var allowedLanguages = map[string]bool{
 	"clojure": true, "graphql": true, "protobuf": true, "terraform": true,
 }
 
-// tagRegex validates tag names
-var tagRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+// tagRegex validates tag names against DefaultTagPattern
+var tagRegex = regexp.MustCompile(`^(?:` + DefaultTagPattern + `)$`)
 
-// ValidateSnippetInput validates snippet input
+// metadataKeyRegex validates custom metadata keys
+var metadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Custom metadata limits
+const (
+	MaxMetadataFields      = 32
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 1000
+)
+
+// ValidateSnippetInput validates snippet input against the default rules
 func ValidateSnippetInput(input *models.SnippetInput) ValidationErrors {
+	return ValidateSnippetInputWithRules(input, DefaultRules())
+}
+
+// ValidateSnippetInputWithRules validates snippet input against rules from
+// the settings. Limits left at zero use their defaults.
+func ValidateSnippetInputWithRules(input *models.SnippetInput, rules models.ValidationRules) ValidationErrors {
 	var errs ValidationErrors
+	rules = withDefaults(rules)
 
 	// Title validation
 	input.Title = strings.TrimSpace(input.Title)
 	if input.Title == "" {
-		errs = append(errs, ValidationError{Field: "title", Message: "Title is required"})
-	} else if utf8.RuneCountInString(input.Title) > 200 {
-		errs = append(errs, ValidationError{Field: "title", Message: "Title must be less than 200 characters"})
+		errs = append(errs, ValidationError{Field: "title", Code: CodeTitleRequired, Message: "Title is required"})
+	} else if n := utf8.RuneCountInString(input.Title); n > rules.MaxTitleLength {
+		errs = append(errs, TooLong("title", CodeTitleTooLong, fmt.Sprintf("Title must be less than %d characters", rules.MaxTitleLength), rules.MaxTitleLength, n))
 	}
 
 	// Content validation (skip if multi-file snippet with files)
 	hasFiles := len(input.Files) > 0
 	if !hasFiles && strings.TrimSpace(input.Content) == "" {
-		errs = append(errs, ValidationError{Field: "content", Message: "Content is required"})
-	} else if len(input.Content) > 1024*1024 { // 1MB limit
-		errs = append(errs, ValidationError{Field: "content", Message: "Content must be less than 1MB"})
+		errs = append(errs, ValidationError{Field: "content", Code: CodeContentRequired, Message: "Content is required"})
+	} else if len(input.Content) > rules.MaxContentSize {
+		errs = append(errs, TooLong("content", CodeContentTooLarge, "Content must be less than "+formatSize(rules.MaxContentSize), rules.MaxContentSize, len(input.Content)))
 	}
 
 	// Validate files if present
 	for i, file := range input.Files {
 		if strings.TrimSpace(file.Filename) == "" {
-			errs = append(errs, ValidationError{Field: "files", Message: "Filename is required for all files"})
+			errs = append(errs, ValidationError{Field: "files", Code: CodeFilenameRequired, Message: "Filename is required for all files", Params: map[string]any{"index": i}})
 		}
-		if len(file.Content) > 1024*1024 { // 1MB limit per file
-			errs = append(errs, ValidationError{Field: "files", Message: "File content must be less than 1MB each"})
+		if len(file.Content) > rules.MaxContentSize {
+			err := TooLong("files", CodeFileTooLarge, "File content must be less than "+formatSize(rules.MaxContentSize)+" each", rules.MaxContentSize, len(file.Content))
+			err.Params["index"] = i
+			errs = append(errs, err)
 		}
 		// Validate file language
 		lang := strings.ToLower(strings.TrimSpace(file.Language))
@@ -91,26 +117,120 @@ func ValidateSnippetInput(input *models.SnippetInput) ValidationErrors {
 	if input.Language == "" {
 		input.Language = "plaintext"
 	} else if !allowedLanguages[input.Language] {
-		errs = append(errs, ValidationError{Field: "language", Message: "Invalid language"})
+		errs = append(errs, ValidationError{Field: "language", Code: CodeLanguageInvalid, Message: "Invalid language", Params: map[string]any{"value": input.Language}})
 	}
 
 	// Description length
 	input.Description = strings.TrimSpace(input.Description)
-	if utf8.RuneCountInString(input.Description) > 1000 {
-		errs = append(errs, ValidationError{Field: "description", Message: "Description must be less than 1000 characters"})
+	if n := utf8.RuneCountInString(input.Description); n > rules.MaxDescriptionLength {
+		errs = append(errs, TooLong("description", CodeDescriptionTooLong, fmt.Sprintf("Description must be less than %d characters", rules.MaxDescriptionLength), rules.MaxDescriptionLength, n))
+	}
+
+	// Source URL validation (nil means unchanged, empty clears it)
+	if input.SourceURL != nil {
+		trimmed := strings.TrimSpace(*input.SourceURL)
+		input.SourceURL = &trimmed
+		if trimmed != "" {
+			if len(trimmed) > 2048 {
+				errs = append(errs, TooLong("source_url", CodeSourceURLTooLong, "Source URL must be less than 2048 characters", 2048, len(trimmed)))
+			} else if u, err := url.Parse(trimmed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, ValidationError{Field: "source_url", Code: CodeSourceURLInvalid, Message: "Source URL must be an absolute http or https URL"})
+			}
+		}
+	}
+
+	// Publish time validation (nil means unchanged, empty clears it). Times
+	// are stored in UTC in SQLite's CURRENT_TIMESTAMP format so the
+	// scheduler can compare them as text.
+	if input.PublishAt != nil {
+		trimmed := strings.TrimSpace(*input.PublishAt)
+		if trimmed != "" {
+			if t, err := time.Parse(time.RFC3339, trimmed); err != nil {
+				errs = append(errs, ValidationError{Field: "publish_at", Code: CodePublishAtInvalid, Message: "Publish time must be an RFC 3339 timestamp"})
+			} else {
+				trimmed = t.UTC().Format(time.DateTime)
+			}
+		}
+		input.PublishAt = &trimmed
 	}
 
-	// Tag validation
+	// Slug validation (nil means unchanged, empty clears it)
+	if input.Slug != nil {
+		trimmed := strings.ToLower(strings.TrimSpace(*input.Slug))
+		input.Slug = &trimmed
+		if trimmed != "" && !IsValidSlug(trimmed) {
+			errs = append(errs, ValidationError{Field: "slug", Code: CodeSlugInvalid, Message: "Slug must be at most 100 lowercase letters, digits and single hyphens, and must not look like a snippet ID"})
+		}
+	}
+
+	// License validation (nil means unchanged, empty clears it)
+	if input.License != nil {
+		trimmed := strings.TrimSpace(*input.License)
+		if trimmed != "" {
+			if id, ok := NormalizeLicense(trimmed); ok {
+				trimmed = id
+			} else {
+				errs = append(errs, ValidationError{Field: "license", Code: CodeLicenseInvalid, Message: "Unknown license; use an SPDX identifier from GET /api/v1/licenses or a LicenseRef- reference", Params: map[string]any{"value": trimmed}})
+			}
+		}
+		input.License = &trimmed
+	}
+
+	// Attribution validation (nil means unchanged, empty clears it)
+	if input.Attribution != nil {
+		trimmed := strings.TrimSpace(*input.Attribution)
+		input.Attribution = &trimmed
+		if n := utf8.RuneCountInString(trimmed); n > MaxAttributionLength {
+			errs = append(errs, TooLong("attribution", CodeAttributionTooLong, "Attribution must be at most 500 characters", MaxAttributionLength, n))
+		}
+	}
+
+	// Metadata validation (nil means unchanged, empty clears it)
+	if input.Metadata != nil {
+		if len(input.Metadata) > MaxMetadataFields {
+			errs = append(errs, TooLong("metadata", CodeMetadataTooMany, "Maximum 32 metadata fields allowed", MaxMetadataFields, len(input.Metadata)))
+		}
+		normalized := make(map[string]string, len(input.Metadata))
+		for key, value := range input.Metadata {
+			key = strings.TrimSpace(key)
+			field := "metadata." + key
+			if key == "" {
+				errs = append(errs, ValidationError{Field: "metadata", Code: CodeMetadataKeyRequired, Message: "Metadata keys cannot be empty"})
+			} else if len(key) > MaxMetadataKeyLength {
+				errs = append(errs, TooLong(field, CodeMetadataKeyTooLong, "Metadata keys must be at most 64 characters", MaxMetadataKeyLength, len(key)))
+			} else if !metadataKeyRegex.MatchString(key) {
+				errs = append(errs, ValidationError{Field: field, Code: CodeMetadataKeyInvalid, Message: "Metadata keys can only contain letters, numbers, dots, hyphens, and underscores"})
+			}
+			if n := utf8.RuneCountInString(value); n > MaxMetadataValueLength {
+				errs = append(errs, TooLong(field, CodeMetadataValueTooLong, "Metadata values must be at most 1000 characters", MaxMetadataValueLength, n))
+			}
+			normalized[key] = value
+		}
+		input.Metadata = normalized
+	}
+
+	// Tag validation; a pattern that no longer compiles falls back to the default
+	tagPattern, err := tagPatternRegexp(rules.TagPattern)
+	if err != nil {
+		tagPattern = tagRegex
+	}
 	for i, tag := range input.Tags {
 		tag = strings.TrimSpace(tag)
 		input.Tags[i] = tag
 		if tag == "" {
 			continue
 		}
-		if len(tag) > 50 {
-			errs = append(errs, ValidationError{Field: "tags", Message: "Tag name must be less than 50 characters"})
-		} else if !tagRegex.MatchString(tag) {
-			errs = append(errs, ValidationError{Field: "tags", Message: "Tag can only contain letters, numbers, underscores, and hyphens"})
+		if len(tag) > rules.MaxTagLength {
+			err := TooLong("tags", CodeTagTooLong, fmt.Sprintf("Tag name must be less than %d characters", rules.MaxTagLength), rules.MaxTagLength, len(tag))
+			err.Params["value"] = tag
+			errs = append(errs, err)
+		} else if !tagPattern.MatchString(tag) {
+			err := ValidationError{Field: "tags", Code: CodeTagInvalid, Message: "Tag can only contain letters, numbers, underscores, and hyphens", Params: map[string]any{"value": tag}}
+			if tagPattern != tagRegex {
+				err.Message = "Tag does not match the allowed pattern"
+				err.Params["pattern"] = rules.TagPattern
+			}
+			errs = append(errs, err)
 		}
 	}
 
@@ -159,41 +279,46 @@ func ValidateSettingsInput(input *models.SettingsInput) ValidationErrors {
 
 	// App name validation
 	input.AppName = strings.TrimSpace(input.AppName)
-	if input.AppName != "" && utf8.RuneCountInString(input.AppName) > 100 {
-		errs = append(errs, ValidationError{Field: "app_name", Message: "App name must be less than 100 characters"})
+	if n := utf8.RuneCountInString(input.AppName); n > 100 {
+		errs = append(errs, TooLong("app_name", CodeAppNameTooLong, "App name must be less than 100 characters", 100, n))
 	}
 
 	// Theme validation (UI theme)
 	input.Theme = strings.ToLower(strings.TrimSpace(input.Theme))
 	if input.Theme != "" && !allowedUIThemes[input.Theme] {
-		errs = append(errs, ValidationError{Field: "theme", Message: "Theme must be 'light' or 'dark'"})
+		errs = append(errs, ValidationError{Field: "theme", Code: CodeThemeInvalid, Message: "Theme must be 'light' or 'dark'"})
 	}
 
 	// Editor theme validation
 	input.EditorTheme = strings.ToLower(strings.TrimSpace(input.EditorTheme))
 	if input.EditorTheme != "" && !allowedEditorThemes[input.EditorTheme] {
-		errs = append(errs, ValidationError{Field: "editor_theme", Message: "Invalid editor theme"})
+		errs = append(errs, ValidationError{Field: "editor_theme", Code: CodeEditorThemeInvalid, Message: "Invalid editor theme"})
 	}
 
 	// Editor font size validation (8-32)
 	if input.EditorFontSize != 0 && (input.EditorFontSize < 8 || input.EditorFontSize > 32) {
-		errs = append(errs, ValidationError{Field: "editor_font_size", Message: "Editor font size must be between 8 and 32"})
+		errs = append(errs, OutOfRange("editor_font_size", CodeFontSizeOutOfRange, "Editor font size must be between 8 and 32", 8, 32, input.EditorFontSize))
 	}
 
 	// Editor tab size validation (1-8)
 	if input.EditorTabSize != 0 && (input.EditorTabSize < 1 || input.EditorTabSize > 8) {
-		errs = append(errs, ValidationError{Field: "editor_tab_size", Message: "Editor tab size must be between 1 and 8"})
+		errs = append(errs, OutOfRange("editor_tab_size", CodeTabSizeOutOfRange, "Editor tab size must be between 1 and 8", 1, 8, input.EditorTabSize))
 	}
 
 	// Markdown font size validation (8-32)
 	if input.MarkdownFontSize != 0 && (input.MarkdownFontSize < 8 || input.MarkdownFontSize > 32) {
-		errs = append(errs, ValidationError{Field: "markdown_font_size", Message: "Markdown font size must be between 8 and 32"})
+		errs = append(errs, OutOfRange("markdown_font_size", CodeFontSizeOutOfRange, "Markdown font size must be between 8 and 32", 8, 32, input.MarkdownFontSize))
 	}
 
 	// Default language validation
 	input.DefaultLanguage = strings.ToLower(strings.TrimSpace(input.DefaultLanguage))
 	if input.DefaultLanguage != "" && !allowedLanguages[input.DefaultLanguage] {
-		errs = append(errs, ValidationError{Field: "default_language", Message: "Invalid default language"})
+		errs = append(errs, ValidationError{Field: "default_language", Code: CodeDefaultLanguageInvalid, Message: "Invalid default language"})
+	}
+
+	// Validation rules (nil keeps the current ones)
+	if input.Validation != nil {
+		errs = append(errs, ValidateRules(input.Validation)...)
 	}
 
 	// S3 configuration validation
@@ -203,13 +328,13 @@ func ValidateSettingsInput(input *models.SettingsInput) ValidationErrors {
 		input.S3Region = strings.TrimSpace(input.S3Region)
 
 		if input.S3Endpoint == "" {
-			errs = append(errs, ValidationError{Field: "s3_endpoint", Message: "S3 endpoint is required when S3 is enabled"})
+			errs = append(errs, ValidationError{Field: "s3_endpoint", Code: CodeS3EndpointRequired, Message: "S3 endpoint is required when S3 is enabled"})
 		}
 		if input.S3Bucket == "" {
-			errs = append(errs, ValidationError{Field: "s3_bucket", Message: "S3 bucket is required when S3 is enabled"})
+			errs = append(errs, ValidationError{Field: "s3_bucket", Code: CodeS3BucketRequired, Message: "S3 bucket is required when S3 is enabled"})
 		}
 		if input.S3Region == "" {
-			errs = append(errs, ValidationError{Field: "s3_region", Message: "S3 region is required when S3 is enabled"})
+			errs = append(errs, ValidationError{Field: "s3_region", Code: CodeS3RegionRequired, Message: "S3 region is required when S3 is enabled"})
 		}
 	}
 
@@ -222,11 +347,27 @@ func ValidateTagInput(name string) ValidationErrors {
 
 	name = strings.TrimSpace(name)
 	if name == "" {
-		errs = append(errs, ValidationError{Field: "name", Message: "Tag name is required"})
+		errs = append(errs, ValidationError{Field: "name", Code: CodeTagRequired, Message: "Tag name is required"})
 	} else if len(name) > 50 {
-		errs = append(errs, ValidationError{Field: "name", Message: "Tag name must be less than 50 characters"})
+		errs = append(errs, TooLong("name", CodeTagTooLong, "Tag name must be less than 50 characters", 50, len(name)))
 	} else if !tagRegex.MatchString(name) {
-		errs = append(errs, ValidationError{Field: "name", Message: "Tag can only contain letters, numbers, underscores, and hyphens"})
+		errs = append(errs, ValidationError{Field: "name", Code: CodeTagInvalid, Message: "Tag can only contain letters, numbers, underscores, and hyphens"})
+	}
+
+	return errs
+}
+
+// ValidateTagAlias validates a tag alias. Aliases only name existing
+// tags, so any characters but spaces and commas are allowed.
+func ValidateTagAlias(alias string) ValidationErrors {
+	var errs ValidationErrors
+
+	if alias == "" {
+		errs = append(errs, ValidationError{Field: "alias", Code: CodeTagRequired, Message: "Alias is required"})
+	} else if len(alias) > MaxTagLengthLimit {
+		errs = append(errs, TooLong("alias", CodeTagTooLong, "Alias must be at most 100 characters", MaxTagLengthLimit, len(alias)))
+	} else if strings.ContainsFunc(alias, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		errs = append(errs, ValidationError{Field: "alias", Code: CodeTagInvalid, Message: "Alias cannot contain spaces or commas"})
 	}
 
 	return errs
@@ -238,9 +379,200 @@ func ValidateFolderInput(name string) ValidationErrors {
 
 	name = strings.TrimSpace(name)
 	if name == "" {
-		errs = append(errs, ValidationError{Field: "name", Message: "Folder name is required"})
-	} else if utf8.RuneCountInString(name) > 100 {
-		errs = append(errs, ValidationError{Field: "name", Message: "Folder name must be less than 100 characters"})
+		errs = append(errs, ValidationError{Field: "name", Code: CodeFolderNameRequired, Message: "Folder name is required"})
+	} else if n := utf8.RuneCountInString(name); n > 100 {
+		errs = append(errs, TooLong("name", CodeFolderNameTooLong, "Folder name must be less than 100 characters", 100, n))
+	}
+
+	return errs
+}
+
+// MaxFolderArchiveDays is the longest auto-archive period a folder can set
+const MaxFolderArchiveDays = 3650
+
+// ValidateFolderArchiveRule validates a folder's auto-archive period
+func ValidateFolderArchiveRule(input *models.FolderInput) ValidationErrors {
+	var errs ValidationErrors
+
+	if days := input.ArchiveAfterDays; days != nil && (*days < 0 || *days > MaxFolderArchiveDays) {
+		errs = append(errs, OutOfRange("archive_after_days", CodeFolderArchiveDaysOutOfRange, "Auto-archive period must be between 0 and 3650 days", 0, MaxFolderArchiveDays, *days))
+	}
+
+	return errs
+}
+
+// ValidateFolderSlug normalizes and validates a folder's slug (nil means
+// unchanged, empty clears it). Slugs of only digits would read as folder IDs.
+func ValidateFolderSlug(input *models.FolderInput) ValidationErrors {
+	if input.Slug == nil {
+		return nil
+	}
+	trimmed := strings.ToLower(strings.TrimSpace(*input.Slug))
+	input.Slug = &trimmed
+	if trimmed != "" && (!IsValidSlug(trimmed) || isDigits(trimmed)) {
+		return ValidationErrors{{Field: "slug", Code: CodeSlugInvalid, Message: "Slug must be at most 100 lowercase letters, digits and single hyphens, and must not be only digits"}}
+	}
+	return nil
+}
+
+// ValidateLinkInput validates a link to another snippet, defaulting its
+// relation to "related"
+func ValidateLinkInput(input *models.LinkInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.TargetID = strings.TrimSpace(input.TargetID)
+	if input.TargetID == "" {
+		errs = append(errs, ValidationError{Field: "target_id", Code: CodeLinkTargetRequired, Message: "Target snippet is required"})
+	}
+
+	if input.Relation == "" {
+		input.Relation = models.LinkRelated
+	} else if !models.IsLinkRelation(input.Relation) {
+		errs = append(errs, ValidationError{Field: "relation", Code: CodeLinkRelationInvalid, Message: "Relation must be related, uses, supersedes or references"})
+	}
+
+	return errs
+}
+
+// MaxResolveLinks is the most titles resolved in one request
+const MaxResolveLinks = 100
+
+// ValidateResolveTitles validates the titles collected for resolution
+func ValidateResolveTitles(titles []string) ValidationErrors {
+	var errs ValidationErrors
+
+	if n := len(titles); n > MaxResolveLinks {
+		errs = append(errs, TooLong("titles", CodeResolveTooManyLinks, "At most 100 titles can be resolved at once", MaxResolveLinks, n))
+	}
+
+	return errs
+}
+
+// MaxLockHolderLength is the longest holder name on an edit lock
+const MaxLockHolderLength = 100
+
+// ValidateLockInput validates an edit lock request, trimming its holder
+// and defaulting its TTL
+func ValidateLockInput(input *models.LockInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.LockID = strings.TrimSpace(input.LockID)
+	input.Holder = strings.TrimSpace(input.Holder)
+	if n := utf8.RuneCountInString(input.Holder); n > MaxLockHolderLength {
+		errs = append(errs, TooLong("holder", CodeLockHolderTooLong, "Holder must be at most 100 characters", MaxLockHolderLength, n))
+	}
+
+	if input.TTL == 0 {
+		input.TTL = models.DefaultLockTTL
+	} else if input.TTL < models.MinLockTTL || input.TTL > models.MaxLockTTL {
+		errs = append(errs, OutOfRange("ttl", CodeLockTTLOutOfRange, "TTL must be between 10 and 600 seconds", models.MinLockTTL, models.MaxLockTTL, input.TTL))
+	}
+
+	return errs
+}
+
+// MaxCheckoutTextLength is the longest check-out reason or check-in note
+const MaxCheckoutTextLength = 1000
+
+// ValidateCheckoutInput validates a check-out, trimming its reason and
+// defaulting its TTL
+func ValidateCheckoutInput(input *models.CheckoutInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" {
+		errs = append(errs, ValidationError{Field: "reason", Code: CodeCheckoutReasonRequired, Message: "A reason is required to check out this snippet"})
+	} else if n := utf8.RuneCountInString(input.Reason); n > MaxCheckoutTextLength {
+		errs = append(errs, TooLong("reason", CodeCheckoutReasonTooLong, "Reason must be at most 1000 characters", MaxCheckoutTextLength, n))
+	}
+
+	if input.TTL == 0 {
+		input.TTL = models.DefaultCheckoutTTL
+	} else if input.TTL < models.MinCheckoutTTL || input.TTL > models.MaxCheckoutTTL {
+		errs = append(errs, OutOfRange("ttl", CodeCheckoutTTLOutOfRange, "TTL must be between 60 seconds and 24 hours", models.MinCheckoutTTL, models.MaxCheckoutTTL, input.TTL))
+	}
+
+	return errs
+}
+
+// ValidateCheckinInput validates a check-in, trimming its note
+func ValidateCheckinInput(input *models.CheckinInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Note = strings.TrimSpace(input.Note)
+	if n := utf8.RuneCountInString(input.Note); n > MaxCheckoutTextLength {
+		errs = append(errs, TooLong("note", CodeCheckinNoteTooLong, "Note must be at most 1000 characters", MaxCheckoutTextLength, n))
+	}
+
+	return errs
+}
+
+// ValidateSignedURLInput validates a signed URL request, defaulting its TTL
+func ValidateSignedURLInput(input *models.SignedURLInput) ValidationErrors {
+	var errs ValidationErrors
+
+	if input.TTL == 0 {
+		input.TTL = models.DefaultSignedURLTTL
+	} else if input.TTL < models.MinSignedURLTTL || input.TTL > models.MaxSignedURLTTL {
+		errs = append(errs, OutOfRange("ttl", CodeSignedURLTTLOutOfRange, "TTL must be between 60 seconds and 7 days", models.MinSignedURLTTL, models.MaxSignedURLTTL, input.TTL))
+	}
+
+	return errs
+}
+
+// ValidateBurnLinkInput validates a burn link request, defaulting its expiry
+func ValidateBurnLinkInput(input *models.BurnLinkInput) ValidationErrors {
+	var errs ValidationErrors
+
+	if input.ExpiresInDays == 0 {
+		input.ExpiresInDays = models.DefaultBurnLinkDays
+	} else if input.ExpiresInDays < 1 || input.ExpiresInDays > models.MaxBurnLinkDays {
+		errs = append(errs, OutOfRange("expires_in_days", CodeBurnLinkExpiryOutOfRange, "Expiry must be between 1 and 30 days", 1, models.MaxBurnLinkDays, input.ExpiresInDays))
+	}
+
+	return errs
+}
+
+// MaxReportDetailsLength is the longest abuse report explanation accepted
+const MaxReportDetailsLength = 2000
+
+// ValidateReportInput validates an abuse report, trimming its details
+func ValidateReportInput(input *models.ReportInput) ValidationErrors {
+	var errs ValidationErrors
+
+	if !models.IsReportReason(input.Reason) {
+		errs = append(errs, ValidationError{Field: "reason", Code: CodeReportReasonInvalid, Message: "Reason must be spam, malware, abuse, illegal, copyright or other"})
+	}
+
+	input.Details = strings.TrimSpace(input.Details)
+	if n := utf8.RuneCountInString(input.Details); n > MaxReportDetailsLength {
+		errs = append(errs, TooLong("details", CodeReportDetailsTooLong, "Details must be at most 2000 characters", MaxReportDetailsLength, n))
+	}
+
+	return errs
+}
+
+// MaxReviewCommentLength is the longest review comment accepted
+const MaxReviewCommentLength = 1000
+
+// ValidateReviewInput validates and trims a review comment
+func ValidateReviewInput(input *models.ReviewInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Comment = strings.TrimSpace(input.Comment)
+	if n := utf8.RuneCountInString(input.Comment); n > MaxReviewCommentLength {
+		errs = append(errs, TooLong("comment", CodeReviewCommentTooLong, "Comment must be at most 1000 characters", MaxReviewCommentLength, n))
+	}
+
+	return errs
+}
+
+// ValidateUsageInput validates a reported usage event
+func ValidateUsageInput(input *models.UsageInput) ValidationErrors {
+	var errs ValidationErrors
+
+	if !models.IsUsageEvent(input.Event) {
+		errs = append(errs, ValidationError{Field: "event", Code: CodeUsageEventInvalid, Message: "Event must be view or copy"})
 	}
 
 	return errs
@@ -252,9 +584,142 @@ func ValidateTokenInput(name string) ValidationErrors {
 
 	name = strings.TrimSpace(name)
 	if name == "" {
-		errs = append(errs, ValidationError{Field: "name", Message: "Token name is required"})
-	} else if utf8.RuneCountInString(name) > 100 {
-		errs = append(errs, ValidationError{Field: "name", Message: "Token name must be less than 100 characters"})
+		errs = append(errs, ValidationError{Field: "name", Code: CodeTokenNameRequired, Message: "Token name is required"})
+	} else if n := utf8.RuneCountInString(name); n > 100 {
+		errs = append(errs, TooLong("name", CodeTokenNameTooLong, "Token name must be less than 100 characters", 100, n))
+	}
+
+	return errs
+}
+
+// MaxInboxSourceLength is the longest source label on an inbox item
+const MaxInboxSourceLength = 100
+
+// ValidateInboxItem validates text sent to the inbox and trims its source
+func ValidateInboxItem(item *models.InboxItem) ValidationErrors {
+	var errs ValidationErrors
+
+	if strings.TrimSpace(item.Content) == "" {
+		errs = append(errs, ValidationError{Field: "content", Code: CodeContentRequired, Message: "Content is required"})
+	} else if len(item.Content) > DefaultMaxContentSize {
+		errs = append(errs, TooLong("content", CodeContentTooLarge, "Content must be less than 1MB", DefaultMaxContentSize, len(item.Content)))
+	}
+
+	item.Source = strings.TrimSpace(item.Source)
+	if n := utf8.RuneCountInString(item.Source); n > MaxInboxSourceLength {
+		errs = append(errs, TooLong("source", CodeInboxSourceTooLong, "Source must be at most 100 characters", MaxInboxSourceLength, n))
+	}
+
+	return errs
+}
+
+// Limits on automation rules
+const (
+	MaxRuleNameLength = 100
+	MaxRuleScriptSize = 64 * 1024
+)
+
+// ValidateRuleInput validates and trims a rule, checking the script's syntax
+func ValidateRuleInput(input *models.RuleInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Code: CodeRuleNameRequired, Message: "Rule name is required"})
+	} else if n := utf8.RuneCountInString(input.Name); n > MaxRuleNameLength {
+		errs = append(errs, TooLong("name", CodeRuleNameTooLong, "Rule name must be at most 100 characters", MaxRuleNameLength, n))
+	}
+
+	seen := make(map[string]bool)
+	events := input.Events[:0:0]
+	valid := len(input.Events) > 0
+	for _, event := range input.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !models.IsRuleEvent(event) {
+			valid = false
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	if !valid {
+		errs = append(errs, ValidationError{Field: "events", Code: CodeRuleEventsInvalid, Message: "Events must be create, update or both"})
+	}
+	input.Events = events
+
+	switch {
+	case strings.TrimSpace(input.Script) == "":
+		errs = append(errs, ValidationError{Field: "script", Code: CodeRuleScriptRequired, Message: "Script is required"})
+	case len(input.Script) > MaxRuleScriptSize:
+		errs = append(errs, TooLong("script", CodeRuleScriptTooLarge, "Script must be at most 64KB", MaxRuleScriptSize, len(input.Script)))
+	default:
+		if _, err := lua.Compile(input.Script); err != nil {
+			errs = append(errs, ValidationError{Field: "script", Code: CodeRuleScriptInvalid, Message: "Script has a syntax error", Params: map[string]any{"error": err.Error()}})
+		}
+	}
+
+	return errs
+}
+
+// MaxHistoryQueryLength is the longest history search query
+const MaxHistoryQueryLength = 200
+
+// ValidateHistorySearch validates a history search and clamps its context
+// to the allowed range
+func ValidateHistorySearch(opts *models.HistorySearchOptions) ValidationErrors {
+	var errs ValidationErrors
+
+	switch {
+	case strings.TrimSpace(opts.Query) == "":
+		errs = append(errs, ValidationError{Field: "q", Code: CodeHistoryQueryRequired, Message: "Search query is required"})
+	case utf8.RuneCountInString(opts.Query) > MaxHistoryQueryLength:
+		errs = append(errs, TooLong("q", CodeHistoryQueryTooLong, "Search query must be at most 200 characters", MaxHistoryQueryLength, utf8.RuneCountInString(opts.Query)))
+	case opts.Regex:
+		if _, err := regexp.Compile(opts.Query); err != nil {
+			errs = append(errs, ValidationError{Field: "q", Code: CodeHistoryQueryInvalid, Message: "Search query is not a valid regular expression", Params: map[string]any{"error": err.Error()}})
+		}
+	}
+
+	opts.Context = min(max(opts.Context, 0), models.MaxHistorySearchContext)
+	return errs
+}
+
+// Limits on redaction rules
+const (
+	MaxRedactionNameLength        = 100
+	MaxRedactionPatternLength     = 500
+	MaxRedactionReplacementLength = 200
+)
+
+// ValidateRedactionRuleInput validates and trims a redaction rule, checking
+// that its pattern compiles and cannot match empty text, which would insert
+// the replacement between every character
+func ValidateRedactionRuleInput(input *models.RedactionRuleInput) ValidationErrors {
+	var errs ValidationErrors
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Code: CodeRedactionNameRequired, Message: "Rule name is required"})
+	} else if n := utf8.RuneCountInString(input.Name); n > MaxRedactionNameLength {
+		errs = append(errs, TooLong("name", CodeRedactionNameTooLong, "Rule name must be at most 100 characters", MaxRedactionNameLength, n))
+	}
+
+	switch {
+	case input.Pattern == "":
+		errs = append(errs, ValidationError{Field: "pattern", Code: CodeRedactionPatternRequired, Message: "Pattern is required"})
+	case len(input.Pattern) > MaxRedactionPatternLength:
+		errs = append(errs, TooLong("pattern", CodeRedactionPatternTooLong, "Pattern must be at most 500 characters", MaxRedactionPatternLength, len(input.Pattern)))
+	default:
+		if re, err := regexp.Compile(input.Pattern); err != nil {
+			errs = append(errs, ValidationError{Field: "pattern", Code: CodeRedactionPatternInvalid, Message: "Pattern is not a valid regular expression", Params: map[string]any{"error": err.Error()}})
+		} else if re.MatchString("") {
+			errs = append(errs, ValidationError{Field: "pattern", Code: CodeRedactionPatternInvalid, Message: "Pattern must not match empty text"})
+		}
+	}
+
+	if n := utf8.RuneCountInString(input.Replacement); n > MaxRedactionReplacementLength {
+		errs = append(errs, TooLong("replacement", CodeRedactionReplacementTooLong, "Replacement must be at most 200 characters", MaxRedactionReplacementLength, n))
 	}
 
 	return errs
@@ -266,12 +731,18 @@ func ValidateFilename(filename string) ValidationErrors {
 
 	filename = strings.TrimSpace(filename)
 	if filename == "" {
-		errs = append(errs, ValidationError{Field: "filename", Message: "Filename is required"})
-	} else if utf8.RuneCountInString(filename) > 255 {
-		errs = append(errs, ValidationError{Field: "filename", Message: "Filename must be less than 255 characters"})
-	} else if strings.Contains(filename, "..") || strings.Contains(filename, "/") || strings.Contains(filename, "\\") {
-		errs = append(errs, ValidationError{Field: "filename", Message: "Filename contains invalid characters"})
+		errs = append(errs, ValidationError{Field: "filename", Code: CodeFilenameRequired, Message: "Filename is required"})
+	} else if n := utf8.RuneCountInString(filename); n > 255 {
+		errs = append(errs, TooLong("filename", CodeFilenameTooLong, "Filename must be less than 255 characters", 255, n))
+	} else if strings.Contains(filename, "..") || strings.Contains(filename, "/") || strings.Contains(filename, "\\") || strings.ContainsFunc(filename, isHiddenControl) {
+		errs = append(errs, ValidationError{Field: "filename", Code: CodeFilenameInvalid, Message: "Filename contains invalid characters"})
 	}
 
 	return errs
 }
+
+// isHiddenControl reports whether r is a control or bidi control character,
+// which would make a file name display differently from what it is
+func isHiddenControl(r rune) bool {
+	return unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r)
+}
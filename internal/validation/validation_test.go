@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -340,6 +341,98 @@ func TestValidateSnippetInput_TrimWhitespace(t *testing.T) {
 	}
 }
 
+func TestValidateSnippetInput_SourceURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://stackoverflow.com/a/123", false},
+		{"  http://example.com/post  ", false},
+		{"", false},
+		{"ftp://example.com/file", true},
+		{"javascript:alert(1)", true},
+		{"/relative/path", true},
+		{"https://example.com/" + strings.Repeat("a", 2048), true},
+	}
+
+	for _, tt := range tests {
+		sourceURL := tt.url
+		input := &models.SnippetInput{
+			Title:     "Imported",
+			Content:   "content",
+			Language:  "plaintext",
+			SourceURL: &sourceURL,
+		}
+		errs := ValidateSnippetInput(input)
+		if errs.HasErrors() != tt.wantErr {
+			t.Errorf("source_url %q: expected error=%v, got %v", tt.url, tt.wantErr, errs)
+		}
+	}
+}
+
+func TestValidateSnippetInput_License(t *testing.T) {
+	tests := []struct {
+		license string
+		want    string
+		wantErr bool
+	}{
+		{"MIT", "MIT", false},
+		{" gpl-3.0-or-later ", "GPL-3.0-or-later", false},
+		{"licenseref-Acme-1.0", "LicenseRef-Acme-1.0", false},
+		{"", "", false},
+		{"GPL", "", true},
+		{"LicenseRef-", "", true},
+		{"LicenseRef-has space", "", true},
+	}
+
+	for _, tt := range tests {
+		license := tt.license
+		input := &models.SnippetInput{
+			Title:    "Licensed",
+			Content:  "content",
+			Language: "plaintext",
+			License:  &license,
+		}
+		errs := ValidateSnippetInput(input)
+		if errs.HasErrors() != tt.wantErr {
+			t.Errorf("license %q: expected error=%v, got %v", tt.license, tt.wantErr, errs)
+		}
+		if !tt.wantErr && *input.License != tt.want {
+			t.Errorf("license %q: expected %q, got %q", tt.license, tt.want, *input.License)
+		}
+	}
+}
+
+func TestValidateSnippetInput_Codes(t *testing.T) {
+	input := &models.SnippetInput{
+		Title:    strings.Repeat("a", 201),
+		Content:  "content",
+		Language: "cobol",
+		Tags:     []string{"bad tag"},
+	}
+	errs := ValidateSnippetInput(input)
+
+	codes := map[string]ValidationError{}
+	for _, e := range errs {
+		codes[e.Code] = e
+	}
+	title, ok := codes[CodeTitleTooLong]
+	if !ok || title.Field != "title" || title.Params["max"] != 200 || title.Params["actual"] != 201 {
+		t.Errorf("expected TITLE_TOO_LONG with limits, got %+v", errs)
+	}
+	if _, ok := codes[CodeLanguageInvalid]; !ok {
+		t.Errorf("expected LANGUAGE_INVALID, got %+v", errs)
+	}
+	if tag, ok := codes[CodeTagInvalid]; !ok || tag.Params["value"] != "bad tag" {
+		t.Errorf("expected TAG_INVALID naming the tag, got %+v", errs)
+	}
+
+	settingsErrs := ValidateSettingsInput(&models.SettingsInput{EditorTabSize: 12})
+	if len(settingsErrs) != 1 || settingsErrs[0].Code != CodeTabSizeOutOfRange || settingsErrs[0].Params["min"] != 1 || settingsErrs[0].Params["actual"] != 12 {
+		t.Errorf("expected TAB_SIZE_OUT_OF_RANGE with limits, got %+v", settingsErrs)
+	}
+}
+
 func TestValidationErrors_Error(t *testing.T) {
 	errs := ValidationErrors{
 		{Field: "title", Message: "Title is required"},
@@ -633,6 +726,172 @@ func TestValidateFolderInput(t *testing.T) {
 	}
 }
 
+// TestValidateFolderStyle tests folder icon and color validation
+func TestValidateFolderStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		icon    string
+		color   string
+		wantErr bool
+	}{
+		{"empty", "", "", false},
+		{"valid icon", "code", "", false},
+		{"valid short color", "", "#fff", false},
+		{"valid long color", "database", "#3B82F6", false},
+		{"icon is normalized", " Terminal ", "", false},
+		{"unknown icon", "unicorn", "", true},
+		{"color without hash", "", "3b82f6", true},
+		{"named color", "", "red", true},
+		{"color too long", "", "#3b82f6ff", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &models.FolderInput{Name: "Test", Icon: tt.icon, Color: tt.color}
+			errs := ValidateFolderStyle(input)
+			if tt.wantErr && !errs.HasErrors() {
+				t.Errorf("expected error for icon %q color %q", tt.icon, tt.color)
+			}
+			if !tt.wantErr && errs.HasErrors() {
+				t.Errorf("unexpected error for icon %q color %q: %v", tt.icon, tt.color, errs)
+			}
+		})
+	}
+
+	input := &models.FolderInput{Icon: " Code ", Color: "#ABCDEF"}
+	ValidateFolderStyle(input)
+	if input.Icon != "code" || input.Color != "#abcdef" {
+		t.Errorf("expected normalized icon and color, got %q %q", input.Icon, input.Color)
+	}
+}
+
+// TestValidateTagColor tests tag color validation and contrast text colors
+func TestValidateTagColor(t *testing.T) {
+	for _, color := range []string{"red", "3b82f6", "#3b82f6ff", "#ggg"} {
+		if errs := ValidateTagColor(&models.TagInput{Name: "go", Color: color}); !errs.HasErrors() {
+			t.Errorf("expected error for color %q", color)
+		}
+	}
+
+	input := &models.TagInput{Name: "go", Color: " #ABCDEF "}
+	if errs := ValidateTagColor(input); errs.HasErrors() || input.Color != "#abcdef" {
+		t.Errorf("expected normalized color, got %q %v", input.Color, errs)
+	}
+	input = &models.TagInput{Name: "go"}
+	if errs := ValidateTagColor(input); errs.HasErrors() || input.Color != models.DefaultTagColor {
+		t.Errorf("expected the default color, got %q %v", input.Color, errs)
+	}
+
+	tests := map[string]string{
+		"#ffffff": models.TagTextDark,
+		"#fff":    models.TagTextDark,
+		"#eab308": models.TagTextDark,
+		"#000080": models.TagTextLight,
+		"#1e293b": models.TagTextLight,
+		"red":     models.TagTextLight,
+	}
+	for color, want := range tests {
+		if got := models.TagTextColor(color); got != want {
+			t.Errorf("TagTextColor(%q) = %q, want %q", color, got, want)
+		}
+	}
+
+	for _, c := range GetTagPalette() {
+		if !colorRegex.MatchString(c.Color) || c.TextColor != models.TagTextColor(c.Color) {
+			t.Errorf("unexpected palette entry %+v", c)
+		}
+	}
+}
+
+// TestSlugify tests slug derivation from titles
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Docker Compose: Redis!", "docker-compose-redis"},
+		{"  Hello   World  ", "hello-world"},
+		{"Café crème", "cafe-creme"},
+		{"C++ & Go", "c-go"},
+		{"日本語", "snippet"},
+		{"deadbeefdeadbeef", "deadbeefdeadbeef-snippet"},
+		{strings.Repeat("a", 120), strings.Repeat("a", 80)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := Slugify(tt.title)
+			if got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+			if !IsValidSlug(got) {
+				t.Errorf("Slugify(%q) produced invalid slug %q", tt.title, got)
+			}
+		})
+	}
+}
+
+// TestIsValidSlug tests slug format validation
+func TestIsValidSlug(t *testing.T) {
+	tests := []struct {
+		slug  string
+		valid bool
+	}{
+		{"my-snippet", true},
+		{"v2", true},
+		{"", false},
+		{"My-Snippet", false},
+		{"double--hyphen", false},
+		{"-leading", false},
+		{"under_score", false},
+		{"0123456789abcdef", false},
+		{strings.Repeat("a", 101), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidSlug(tt.slug); got != tt.valid {
+			t.Errorf("IsValidSlug(%q) = %v, want %v", tt.slug, got, tt.valid)
+		}
+	}
+}
+
+// TestValidateSnippetInput_Metadata tests custom metadata validation
+func TestValidateSnippetInput_Metadata(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxMetadataFields; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{"nil", nil, false},
+		{"empty clears", map[string]string{}, false},
+		{"valid", map[string]string{"ticket": "JIRA-123", "env.region": "eu-west_1"}, false},
+		{"empty value", map[string]string{"reviewed": ""}, false},
+		{"empty key", map[string]string{" ": "x"}, true},
+		{"key with spaces", map[string]string{"my key": "x"}, true},
+		{"key too long", map[string]string{strings.Repeat("k", 65): "x"}, true},
+		{"value too long", map[string]string{"notes": strings.Repeat("v", 1001)}, true},
+		{"too many fields", tooMany, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &models.SnippetInput{Title: "Test", Content: "x", Language: "plaintext", Metadata: tt.metadata}
+			errs := ValidateSnippetInput(input)
+			if tt.wantErr && !errs.HasErrors() {
+				t.Errorf("expected error for metadata %v", tt.metadata)
+			}
+			if !tt.wantErr && errs.HasErrors() {
+				t.Errorf("unexpected error for metadata %v: %v", tt.metadata, errs)
+			}
+		})
+	}
+}
+
 // TestValidateTokenInput tests token name validation
 func TestValidateTokenInput(t *testing.T) {
 	tests := []struct {
@@ -676,6 +935,10 @@ func TestValidateFilename(t *testing.T) {
 		{"forward slash", "path/to/file.txt", true},
 		{"backslash", "path\\to\\file.txt", true},
 		{"double dots", "file..txt", true},
+		{"arabic", "ملاحظات.md", false},
+		{"emoji", "🚀 deploy.sh", false},
+		{"bidi override", "invoice\u202egpj.exe", true},
+		{"control character", "file\x00.txt", true},
 	}
 
 	for _, tt := range tests {
@@ -799,3 +1062,88 @@ func TestSnippetInput_DescriptionLengthUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSnippetInputWithRules(t *testing.T) {
+	rules := models.ValidationRules{
+		MaxTitleLength:       10,
+		MaxDescriptionLength: 2000,
+		MaxContentSize:       2048,
+		MaxTagLength:         8,
+		TagPattern:           `[a-z0-9_.-]+`,
+	}
+
+	input := &models.SnippetInput{
+		Title:       "Deploy",
+		Description: strings.Repeat("a", 1500),
+		Content:     "x",
+		Language:    "bash",
+		Tags:        []string{"k8s.prod"},
+	}
+	if errs := ValidateSnippetInputWithRules(input, rules); errs.HasErrors() {
+		t.Errorf("expected longer description and dotted tag to pass, got %v", errs)
+	}
+
+	input = &models.SnippetInput{
+		Title:    "Deploy script",
+		Content:  strings.Repeat("x", 4096),
+		Language: "bash",
+		Tags:     []string{"Prod", "long-tag-name"},
+	}
+	codes := map[string]ValidationError{}
+	for _, e := range ValidateSnippetInputWithRules(input, rules) {
+		codes[e.Code] = e
+	}
+	if e, ok := codes[CodeTitleTooLong]; !ok || e.Message != "Title must be less than 10 characters" || e.Params["max"] != 10 {
+		t.Errorf("expected title error against the custom limit, got %+v", e)
+	}
+	if e, ok := codes[CodeContentTooLarge]; !ok || e.Message != "Content must be less than 2KB" {
+		t.Errorf("expected content error against the custom limit, got %+v", e)
+	}
+	if e, ok := codes[CodeTagInvalid]; !ok || e.Params["pattern"] != rules.TagPattern {
+		t.Errorf("expected tag pattern error, got %+v", e)
+	}
+	if _, ok := codes[CodeTagTooLong]; !ok {
+		t.Error("expected tag length error")
+	}
+
+	// Zero limits fall back to the defaults
+	input = &models.SnippetInput{Title: strings.Repeat("a", 201), Content: "x", Language: "bash", Tags: []string{"a.b"}}
+	errs := ValidateSnippetInputWithRules(input, models.ValidationRules{})
+	if len(errs) != 2 || errs[0].Message != "Title must be less than 200 characters" || errs[1].Code != CodeTagInvalid {
+		t.Errorf("expected default title and tag errors, got %v", errs)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	valid := DefaultRules()
+	if errs := ValidateRules(&valid); errs.HasErrors() {
+		t.Errorf("expected default rules to be valid, got %v", errs)
+	}
+
+	tests := []struct {
+		name  string
+		rules func(r *models.ValidationRules)
+		code  string
+	}{
+		{"title zero", func(r *models.ValidationRules) { r.MaxTitleLength = 0 }, CodeLimitOutOfRange},
+		{"description too high", func(r *models.ValidationRules) { r.MaxDescriptionLength = MaxDescriptionLengthLimit + 1 }, CodeLimitOutOfRange},
+		{"content too small", func(r *models.ValidationRules) { r.MaxContentSize = 100 }, CodeLimitOutOfRange},
+		{"content too large", func(r *models.ValidationRules) { r.MaxContentSize = MaxContentSizeLimit + 1 }, CodeLimitOutOfRange},
+		{"tag too long", func(r *models.ValidationRules) { r.MaxTagLength = 101 }, CodeLimitOutOfRange},
+		{"pattern invalid", func(r *models.ValidationRules) { r.TagPattern = "[a-z" }, CodeTagPatternInvalid},
+		{"pattern allows spaces", func(r *models.ValidationRules) { r.TagPattern = `[a-z ]+` }, CodeTagPatternInvalid},
+		{"pattern allows commas", func(r *models.ValidationRules) { r.TagPattern = `\S+` }, CodeTagPatternInvalid},
+		{"pattern allows empty", func(r *models.ValidationRules) { r.TagPattern = `[a-z]*` }, CodeTagPatternInvalid},
+		{"pattern too long", func(r *models.ValidationRules) { r.TagPattern = strings.Repeat("a", 201) }, CodeTagPatternTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules()
+			tt.rules(&rules)
+			errs := ValidateRules(&rules)
+			if len(errs) != 1 || errs[0].Code != tt.code {
+				t.Errorf("expected %s, got %v", tt.code, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local is a vault on this machine's filesystem
+type Local struct {
+	dir string
+}
+
+// NewLocal opens the folder below the vault directory root. The folder is
+// created on the first write.
+func NewLocal(root, folder string) (*Local, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("vault %s is not a directory", root)
+	}
+
+	dir, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(folder)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault folder: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// file maps a note path to its file
+func (v *Local) file(p string) (string, error) {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(v.dir, filepath.FromSlash(clean)), nil
+}
+
+// List walks the folder for notes
+func (v *Local) List(ctx context.Context) ([]Note, error) {
+	var notes []Note
+	err := filepath.WalkDir(v.dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && name == v.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name == v.dir {
+			return nil
+		}
+		if isHidden(d.Name()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !isNote(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(v.dir, name)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, Note{Path: filepath.ToSlash(rel), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vault: %w", err)
+	}
+	return notes, nil
+}
+
+// Read reads a note file
+func (v *Local) Read(ctx context.Context, p string) ([]byte, time.Time, error) {
+	name, err := v.file(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	content, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return content, info.ModTime(), nil
+}
+
+// Write replaces a note atomically, so Obsidian never sees a partial file
+func (v *Local) Write(ctx context.Context, p string, content []byte) error {
+	name, err := v.file(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Delete removes a note file
+func (v *Local) Delete(ctx context.Context, p string) error {
+	name, err := v.file(p)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Location returns the folder's directory
+func (v *Local) Location() string {
+	return v.dir
+}
@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	root := t.TempDir()
+	ctx := context.Background()
+
+	v, err := NewLocal(root, "Snipo")
+	if err != nil {
+		t.Fatalf("NewLocal failed: %v", err)
+	}
+
+	// The folder does not exist until the first write
+	notes, err := v.List(ctx)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("expected an empty vault, got %v (%v)", notes, err)
+	}
+
+	if err := v.Write(ctx, "Go/http.md", []byte("# HTTP\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := v.Write(ctx, "sql.md", []byte("# SQL\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for name, content := range map[string]string{
+		"Snipo/.obsidian/workspace.md": "hidden",
+		"Snipo/.trash/old.md":          "hidden",
+		"Snipo/image.png":              "not a note",
+	} {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notes, err = v.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Path != "Go/http.md" || notes[1].Path != "sql.md" {
+		t.Fatalf("unexpected notes: %+v", notes)
+	}
+	if notes[0].ModTime.IsZero() {
+		t.Error("expected local notes to have a modification time")
+	}
+
+	content, modTime, err := v.Read(ctx, "Go/http.md")
+	if err != nil || string(content) != "# HTTP\n" || modTime.IsZero() {
+		t.Fatalf("unexpected read: %q %v %v", content, modTime, err)
+	}
+
+	if err := v.Delete(ctx, "sql.md"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, _, err := v.Read(ctx, "sql.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := v.Delete(ctx, "sql.md"); err != nil {
+		t.Errorf("deleting a missing note should succeed, got %v", err)
+	}
+
+	for _, p := range []string{"../outside.md", "/etc/passwd.md", "Go/../../outside.md"} {
+		if err := v.Write(ctx, p, []byte("x")); err == nil {
+			t.Errorf("expected %q to be rejected", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside.md")); !os.IsNotExist(err) {
+		t.Error("a note was written outside the vault folder")
+	}
+}
@@ -0,0 +1,221 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// noteJSON is the plugin's media type for a note with its metadata
+const noteJSON = "application/vnd.olrapi.note+json"
+
+// maxNoteSize bounds the notes read from the plugin
+const maxNoteSize = 10 << 20
+
+// RESTConfig configures a vault reached through the Local REST API plugin
+type RESTConfig struct {
+	URL      string // e.g. https://127.0.0.1:27124
+	APIKey   string
+	Folder   string // Synced folder inside the vault; empty for the whole vault
+	Insecure bool   // Accept the plugin's self-signed certificate
+	Timeout  time.Duration
+}
+
+// REST is a vault served by the Obsidian Local REST API plugin, for vaults
+// that live on another machine than snipo
+type REST struct {
+	baseURL string
+	apiKey  string
+	folder  string
+	client  *http.Client
+}
+
+// NewREST creates a client for the plugin
+func NewREST(cfg RESTConfig) (*REST, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid vault URL %q", cfg.URL)
+	}
+	if cfg.APIKey == "" {
+		return nil, errors.New("the vault REST API requires an API key")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	folder := strings.Trim(cfg.Folder, "/")
+	if folder != "" {
+		folder += "/"
+	}
+	return &REST{
+		baseURL: strings.TrimSuffix(u.String(), "/"),
+		apiKey:  cfg.APIKey,
+		folder:  folder,
+		client:  &http.Client{Timeout: cfg.Timeout, Transport: transport},
+	}, nil
+}
+
+// do sends a request for a vault path (relative to the vault root) and
+// returns the response for 2xx statuses. 404s become ErrNotFound.
+func (v *REST) do(ctx context.Context, method, vaultPath, accept string, body []byte) (*http.Response, error) {
+	segments := strings.Split(vaultPath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, v.baseURL+"/vault/"+strings.Join(segments, "/"), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+v.apiKey)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+	if apiErr.Message == "" {
+		apiErr.Message = resp.Status
+	}
+	return nil, fmt.Errorf("vault %s %s: %s", method, vaultPath, apiErr.Message)
+}
+
+// List walks the folder's directory listings. The plugin does not report
+// modification times in listings, so notes come back without one.
+func (v *REST) List(ctx context.Context) ([]Note, error) {
+	var notes []Note
+	if err := v.list(ctx, "", &notes); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to list vault: %w", err)
+	}
+	return notes, nil
+}
+
+// list appends the notes below dir (relative to the folder, ending in a
+// slash unless empty)
+func (v *REST) list(ctx context.Context, dir string, notes *[]Note) error {
+	resp, err := v.do(ctx, http.MethodGet, v.folder+dir, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var listing struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return fmt.Errorf("invalid vault listing: %w", err)
+	}
+
+	for _, name := range listing.Files {
+		if isHidden(name) {
+			continue
+		}
+		if strings.HasSuffix(name, "/") {
+			if err := v.list(ctx, dir+name, notes); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			continue
+		}
+		if isNote(name) {
+			*notes = append(*notes, Note{Path: dir + name})
+		}
+	}
+	return nil
+}
+
+// Read fetches a note with its metadata
+func (v *REST) Read(ctx context.Context, p string) ([]byte, time.Time, error) {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := v.do(ctx, http.MethodGet, v.folder+clean, noteJSON, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var note struct {
+		Content string `json:"content"`
+		Stat    struct {
+			MTime int64 `json:"mtime"` // Unix milliseconds
+		} `json:"stat"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxNoteSize)).Decode(&note); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid note %s: %w", p, err)
+	}
+
+	var modTime time.Time
+	if note.Stat.MTime > 0 {
+		modTime = time.UnixMilli(note.Stat.MTime)
+	}
+	return []byte(note.Content), modTime, nil
+}
+
+// Write creates or replaces a note
+func (v *REST) Write(ctx context.Context, p string, content []byte) error {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	resp, err := v.do(ctx, http.MethodPut, v.folder+clean, "", content)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete removes a note
+func (v *REST) Delete(ctx context.Context, p string) error {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	resp, err := v.do(ctx, http.MethodDelete, v.folder+clean, "", nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Location returns the plugin URL and folder
+func (v *REST) Location() string {
+	return v.baseURL + "/vault/" + v.folder
+}
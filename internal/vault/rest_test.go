@@ -0,0 +1,135 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePlugin emulates the vault endpoints of the Local REST API plugin
+type fakePlugin struct {
+	mu    sync.Mutex
+	files map[string]string // Vault path -> content
+}
+
+func (f *fakePlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": "Authorization required", "errorCode": 40101})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/vault/")
+	switch {
+	case r.Method == http.MethodGet && (p == "" || strings.HasSuffix(p, "/")):
+		entries := map[string]bool{}
+		for name := range f.files {
+			if rest, ok := strings.CutPrefix(name, p); ok {
+				if i := strings.Index(rest, "/"); i >= 0 {
+					entries[rest[:i+1]] = true
+				} else {
+					entries[rest] = true
+				}
+			}
+		}
+		if len(entries) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		files := make([]string, 0, len(entries))
+		for name := range entries {
+			files = append(files, name)
+		}
+		sort.Strings(files)
+		_ = json.NewEncoder(w).Encode(map[string]any{"files": files})
+	case r.Method == http.MethodGet:
+		content, ok := f.files[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"content": content,
+			"path":    p,
+			"stat":    map[string]any{"mtime": int64(1767225600000)},
+		})
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.files[p] = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.files[p]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.files, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestREST(t *testing.T) {
+	plugin := &fakePlugin{files: map[string]string{
+		"Daily/2026-01-01.md":         "not synced",
+		"Snipo/Go/http.md":            "# HTTP\n",
+		"Snipo/.obsidian/settings.md": "hidden",
+		"Snipo/diagram.png":           "not a note",
+	}}
+	server := httptest.NewServer(plugin)
+	defer server.Close()
+	ctx := context.Background()
+
+	v, err := NewREST(RESTConfig{URL: server.URL, APIKey: "secret", Folder: "/Snipo/"})
+	if err != nil {
+		t.Fatalf("NewREST failed: %v", err)
+	}
+
+	notes, err := v.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Path != "Go/http.md" {
+		t.Fatalf("unexpected notes: %+v", notes)
+	}
+
+	content, modTime, err := v.Read(ctx, "Go/http.md")
+	if err != nil || string(content) != "# HTTP\n" {
+		t.Fatalf("unexpected read: %q %v", content, err)
+	}
+	if !modTime.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected modification time %v", modTime)
+	}
+
+	if err := v.Write(ctx, "SQL Notes/join.md", []byte("# Join\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if plugin.files["Snipo/SQL Notes/join.md"] != "# Join\n" {
+		t.Errorf("expected the note under the folder, got %v", plugin.files)
+	}
+
+	if err := v.Delete(ctx, "Go/http.md"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := v.Delete(ctx, "Go/http.md"); err != nil {
+		t.Errorf("deleting a missing note should succeed, got %v", err)
+	}
+	if _, _, err := v.Read(ctx, "Go/http.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	bad, _ := NewREST(RESTConfig{URL: server.URL, APIKey: "wrong"})
+	if _, err := bad.List(ctx); err == nil || !strings.Contains(err.Error(), "Authorization required") {
+		t.Errorf("expected the plugin's error message, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package vault reads and writes the Markdown notes of an Obsidian vault,
+// either directly on disk or through the Local REST API community plugin.
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/config"
+)
+
+// ErrNotFound is returned when a note does not exist
+var ErrNotFound = errors.New("note not found")
+
+// Note is a Markdown note in the synced folder
+type Note struct {
+	Path    string    // Slash-separated, relative to the synced folder
+	ModTime time.Time // Zero when the backend cannot list it
+}
+
+// Vault is a folder of Markdown notes
+type Vault interface {
+	// List returns the .md notes below the folder, skipping hidden
+	// directories such as .obsidian and .trash
+	List(ctx context.Context) ([]Note, error)
+	// Read returns a note's content and modification time
+	Read(ctx context.Context, path string) ([]byte, time.Time, error)
+	// Write creates or replaces a note, creating its directories
+	Write(ctx context.Context, path string, content []byte) error
+	// Delete removes a note; missing notes are not an error
+	Delete(ctx context.Context, path string) error
+	// Location describes the synced folder for logs and status
+	Location() string
+}
+
+// New opens the vault selected by cfg: a local directory when Path is set,
+// otherwise the Local REST API plugin at URL
+func New(cfg config.VaultConfig) (Vault, error) {
+	folder := strings.Trim(cfg.Folder, "/")
+	switch {
+	case cfg.Path != "":
+		return NewLocal(cfg.Path, folder)
+	case cfg.URL != "":
+		return NewREST(RESTConfig{
+			URL:      cfg.URL,
+			APIKey:   cfg.APIKey,
+			Folder:   folder,
+			Insecure: cfg.Insecure,
+		})
+	default:
+		return nil, errors.New("no vault path or URL configured")
+	}
+}
+
+// cleanPath validates a note path, rejecting paths that would escape the
+// synced folder
+func cleanPath(p string) (string, error) {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") {
+		return "", fmt.Errorf("invalid note path %q", p)
+	}
+	clean := path.Clean(p)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || !isNote(clean) {
+		return "", fmt.Errorf("invalid note path %q", p)
+	}
+	return clean, nil
+}
+
+// isNote reports whether a file name is a Markdown note
+func isNote(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+// isHidden reports whether a file or directory name is hidden, like the
+// .obsidian settings and .trash directories
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
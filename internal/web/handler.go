@@ -3,13 +3,22 @@ package web
 import (
 	"context"
 	"embed"
+	"fmt"
 	"html/template"
 	"io/fs"
 	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 
 	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
 	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/sitegen"
 )
 
 //go:embed templates/*.html templates/components/*.html
@@ -20,9 +29,24 @@ var staticFS embed.FS
 
 // Handler handles web page requests
 type Handler struct {
-	templates    *template.Template
-	authService  *auth.Service
-	settingsRepo *repository.SettingsRepository
+	templates      *template.Template
+	authService    *auth.Service
+	settingsRepo   *repository.SettingsRepository
+	publicCacheAge time.Duration
+	noIndex        noIndexChecker
+	previews       sharePreviewer
+}
+
+// noIndexChecker reports whether a snippet asks search engines not to
+// index its public page
+type noIndexChecker interface {
+	NoIndex(ctx context.Context, idOrSlug string) bool
+}
+
+// sharePreviewer looks up the published snippet a share page shows,
+// without counting a view
+type sharePreviewer interface {
+	SharePreview(ctx context.Context, idOrSlug string) (*models.Snippet, error)
 }
 
 // NewHandler creates a new web handler
@@ -40,6 +64,29 @@ func NewHandler(authService *auth.Service, settingsRepo *repository.SettingsRepo
 	}, nil
 }
 
+// WithPublicCache lets caches keep the public snippet page for maxAge. The
+// page is the same for every snippet (it loads the snippet itself), so it
+// needs no validators.
+func (h *Handler) WithPublicCache(maxAge time.Duration) *Handler {
+	h.publicCacheAge = maxAge
+	return h
+}
+
+// WithNoIndex marks share pages of snippets flagged noindex for search
+// engines, with an X-Robots-Tag header and a robots meta tag
+func (h *Handler) WithNoIndex(checker noIndexChecker) *Handler {
+	h.noIndex = checker
+	return h
+}
+
+// WithSharePreviews adds Open Graph and Twitter card tags to share pages
+// of published snippets, so links to them unfurl with a title, a
+// description and a preview image
+func (h *Handler) WithSharePreviews(previews sharePreviewer) *Handler {
+	h.previews = previews
+	return h
+}
+
 // StaticHandler returns a handler for static files
 func StaticHandler() http.Handler {
 	staticContent, _ := fs.Sub(staticFS, "static")
@@ -48,7 +95,17 @@ func StaticHandler() http.Handler {
 
 // PageData holds data passed to templates
 type PageData struct {
-	Title string
+	Title   string
+	NoIndex bool         // Ask search engines not to index the page
+	Preview *LinkPreview // Link preview tags; nil for pages without them
+}
+
+// LinkPreview is what a share page tells link unfurlers about its snippet
+type LinkPreview struct {
+	Title       string
+	Description string
+	URL         string // Absolute URL of the page
+	ImageURL    string // Absolute URL of the preview card
 }
 
 // Index serves the main application page
@@ -112,6 +169,59 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 // PublicSnippet serves the public snippet view page (no auth required)
 func (h *Handler) PublicSnippet(w http.ResponseWriter, r *http.Request) {
 	data := PageData{Title: "Shared Snippet"}
+	if h.noIndex != nil && h.noIndex.NoIndex(r.Context(), chi.URLParam(r, "id")) {
+		data.NoIndex = true
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+	if h.previews != nil {
+		data.Preview = h.linkPreview(r)
+	}
+	if h.publicCacheAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.publicCacheAge.Seconds())))
+	}
+	h.render(w, "layout.html", "public.html", data)
+}
+
+// linkPreview describes the snippet on a share page, or returns nil when
+// the snippet is not published. Pages for signed URLs get no preview, so
+// private snippets are never described.
+func (h *Handler) linkPreview(r *http.Request) *LinkPreview {
+	snippet, err := h.previews.SharePreview(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return nil
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto // Behind a reverse proxy
+	}
+	page := scheme + "://" + r.Host + "/s/" + url.PathEscape(chi.URLParam(r, "id"))
+	return &LinkPreview{
+		Title:       snippet.Title,
+		Description: services.PreviewDescription(snippet),
+		URL:         page,
+		ImageURL:    page + "/preview.png",
+	}
+}
+
+// PublicCollection serves the read-only listing of a public folder (no
+// auth required). Like the snippet page, it loads the folder itself.
+func (h *Handler) PublicCollection(w http.ResponseWriter, r *http.Request) {
+	data := PageData{Title: "Shared Collection"}
+	if h.publicCacheAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.publicCacheAge.Seconds())))
+	}
+	h.render(w, "layout.html", "collection.html", data)
+}
+
+// BurnSnippet serves the burn-after-read share page. The page only asks
+// before revealing the snippet, so loading it never uses up the link.
+func (h *Handler) BurnSnippet(w http.ResponseWriter, r *http.Request) {
+	data := PageData{Title: "Shared Snippet"}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer") // Keep the token out of Referer headers
 	h.render(w, "layout.html", "public.html", data)
 }
 
@@ -133,3 +243,32 @@ func (h *Handler) render(w http.ResponseWriter, layout, content string, data int
 		http.Error(w, "Template execute error: "+err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// siteAssetFiles are the vendored syntax highlighting files bundled into static
+// site exports, in load order
+var siteAssetFiles = []string{
+	"vendor/css/prism-tomorrow.min.css",
+	"vendor/js/prism.min.js",
+	"vendor/js/prism-bash.min.js",
+	"vendor/js/prism-cuda.min.js",
+	"vendor/js/prism-go.min.js",
+	"vendor/js/prism-javascript.min.js",
+	"vendor/js/prism-json.min.js",
+	"vendor/js/prism-markdown.min.js",
+	"vendor/js/prism-python.min.js",
+	"vendor/js/prism-sql.min.js",
+	"vendor/js/prism-yaml.min.js",
+}
+
+// SiteAssets returns the static files a static site export needs for syntax highlighting
+func SiteAssets() ([]sitegen.Asset, error) {
+	assets := make([]sitegen.Asset, 0, len(siteAssetFiles))
+	for _, name := range siteAssetFiles {
+		data, err := staticFS.ReadFile("static/" + name)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, sitegen.Asset{Name: path.Base(name), Data: data})
+	}
+	return assets, nil
+}
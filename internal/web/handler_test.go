@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func TestHandler_PublicSnippetPreview(t *testing.T) {
+	db := testutil.TestDB(t)
+	logger := testutil.TestLogger()
+	snippetSvc := services.NewSnippetService(repository.NewSnippetRepository(db), logger)
+	authService := auth.NewService(db, "web-password", "web-session-secret-0123456789abcdef", time.Hour, logger, false)
+	handler, err := NewHandler(authService, repository.NewSettingsRepository(db))
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	handler.WithSharePreviews(snippetSvc)
+
+	ctx := context.Background()
+	shared, err := snippetSvc.Create(ctx, &models.SnippetInput{Title: `Quote "me" <now>`, Description: "Restarts  the\nworkers", Content: "x", Language: "go", IsPublic: true})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	private, err := snippetSvc.Create(ctx, &models.SnippetInput{Title: "Private", Content: "x", Language: "go"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	page := func(id string) string {
+		req := httptest.NewRequest(http.MethodGet, "/s/"+id, nil)
+		req.Host = "snippets.example.com"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		handler.PublicSnippet(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	body := page(shared.ID)
+	for _, want := range []string{
+		`<meta property="og:title" content="Quote &#34;me&#34; &lt;now&gt;">`,
+		`<meta property="og:description" content="Restarts the workers">`,
+		`<meta property="og:url" content="https://snippets.example.com/s/` + shared.ID + `">`,
+		`<meta property="og:image" content="https://snippets.example.com/s/` + shared.ID + `/preview.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in:\n%s", want, body)
+		}
+	}
+	if body := page(private.ID); strings.Contains(body, "og:title") || strings.Contains(body, "Private") {
+		t.Errorf("expected no preview of a private snippet:\n%s", body)
+	}
+}
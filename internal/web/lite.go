@@ -0,0 +1,392 @@
+package web
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/contracts"
+	"github.com/MohamedElashri/snipo/internal/i18n"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/validation"
+)
+
+//go:embed templates/lite/*.html
+var liteFS embed.FS
+
+// litePageSize is the number of snippets per list page
+const litePageSize = 25
+
+// maxLiteFormSize bounds the create form, matching the JSON API's body limit
+const maxLiteFormSize = 2 * 1024 * 1024
+
+// LiteHandler serves /lite: server-rendered pages with plain HTML forms and
+// no JavaScript, for slow links, text browsers and when the main UI breaks.
+// Routes sit behind the same authentication as the API; the session cookie
+// is SameSite=Strict, which keeps the forms safe from cross-site posts.
+type LiteHandler struct {
+	pages        map[string]*template.Template
+	snippetSvc   contracts.SnippetService
+	authService  contracts.Authenticator
+	settingsRepo contracts.SettingsRepository
+	audit        contracts.LoginAudit
+	languages    []string
+	captchaGate  func() bool // Reports whether logging in needs a captcha
+}
+
+// liteFuncs are the template functions of the lite pages
+var liteFuncs = template.FuncMap{
+	"t":  i18n.T,
+	"tf": i18n.Sprintf,
+}
+
+// liteData holds data passed to the lite templates
+type liteData struct {
+	Lang     string // Locale negotiated from Accept-Language
+	Dir      string // Text direction of the locale
+	Title    string
+	Nav      bool // Show the navigation header
+	LoggedIn bool // Show the logout button
+	Errors   []string
+
+	// List page
+	Query      string
+	Snippets   []models.Snippet
+	Page       int
+	TotalPages int
+	PrevURL    string
+	NextURL    string
+
+	// View page
+	Snippet *models.Snippet
+
+	// New page
+	Form      liteForm
+	Languages []string
+}
+
+// liteForm holds the values of the create form
+type liteForm struct {
+	Title       string
+	Description string
+	Language    string
+	Filename    string
+	Tags        string
+	Content     string
+	IsPublic    bool
+}
+
+// NewLiteHandler creates a new lite UI handler
+func NewLiteHandler(snippetSvc contracts.SnippetService, authService contracts.Authenticator, settingsRepo contracts.SettingsRepository) (*LiteHandler, error) {
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"list", "view", "new", "login", "error"} {
+		tmpl, err := template.New(name).Funcs(liteFuncs).ParseFS(liteFS, "templates/lite/layout.html", "templates/lite/"+name+".html")
+		if err != nil {
+			return nil, err
+		}
+		pages[name] = tmpl
+	}
+
+	languages := validation.GetAllowedLanguages()
+	sort.Strings(languages)
+
+	return &LiteHandler{
+		pages:        pages,
+		snippetSvc:   snippetSvc,
+		authService:  authService,
+		settingsRepo: settingsRepo,
+		languages:    languages,
+	}, nil
+}
+
+// WithAudit enables recording of login attempts
+func (h *LiteHandler) WithAudit(audit contracts.LoginAudit) *LiteHandler {
+	h.audit = audit
+	return h
+}
+
+// WithCaptchaGate refuses lite logins while gate reports that logging in
+// needs a captcha, which the pages cannot show without JavaScript
+func (h *LiteHandler) WithCaptchaGate(gate func() bool) *LiteHandler {
+	h.captchaGate = gate
+	return h
+}
+
+// List serves GET /lite
+// Query params: q (search), page
+func (h *LiteHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	result, err := h.snippetSvc.List(r.Context(), models.SnippetFilter{
+		Query:     query,
+		Page:      page,
+		Limit:     litePageSize,
+		SortBy:    "updated_at",
+		SortOrder: "desc",
+	})
+	if err != nil {
+		h.renderError(w, r, http.StatusInternalServerError, "Something went wrong", "The snippets could not be loaded.")
+		return
+	}
+
+	for i := range result.Data {
+		h.snippetSvc.WithholdContent(r.Context(), &result.Data[i])
+	}
+
+	data := h.data(r, "Snippets")
+	data.Query = query
+	data.Snippets = result.Data
+	data.Page = page
+	data.TotalPages = result.Pagination.TotalPages
+	if page > 1 {
+		data.PrevURL = litePageURL(query, page-1)
+	}
+	if page < data.TotalPages {
+		data.NextURL = litePageURL(query, page+1)
+	}
+	h.render(w, http.StatusOK, "list", data)
+}
+
+// View serves GET /lite/s/{id}
+func (h *LiteHandler) View(w http.ResponseWriter, r *http.Request) {
+	snippet, err := h.snippetSvc.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSnippetNotFound) {
+			h.renderError(w, r, http.StatusNotFound, "Not found", "This snippet does not exist.")
+			return
+		}
+		h.renderError(w, r, http.StatusInternalServerError, "Something went wrong", "The snippet could not be loaded.")
+		return
+	}
+
+	// Snippets that require a check-out show their content only to whoever
+	// checked them out, as in the API
+	h.snippetSvc.WithholdContent(r.Context(), snippet)
+
+	data := h.data(r, "")
+	data.Title = snippet.Title
+	data.Snippet = snippet
+	h.render(w, http.StatusOK, "view", data)
+}
+
+// New serves GET /lite/new
+func (h *LiteHandler) New(w http.ResponseWriter, r *http.Request) {
+	data := h.data(r, "New snippet")
+	data.Form = liteForm{Language: "plaintext"}
+	data.Languages = h.languages
+	h.render(w, http.StatusOK, "new", data)
+}
+
+// Create serves POST /lite/new
+func (h *LiteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLiteFormSize)
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, r, http.StatusBadRequest, "Invalid form", "The form could not be read.")
+		return
+	}
+
+	form := liteForm{
+		Title:       r.PostForm.Get("title"),
+		Description: r.PostForm.Get("description"),
+		Language:    r.PostForm.Get("language"),
+		Filename:    strings.TrimSpace(r.PostForm.Get("filename")),
+		Tags:        r.PostForm.Get("tags"),
+		// Browsers submit textarea line breaks as CRLF
+		Content:  strings.ReplaceAll(r.PostForm.Get("content"), "\r\n", "\n"),
+		IsPublic: r.PostForm.Get("is_public") == "true",
+	}
+
+	filename := form.Filename
+	if filename == "" {
+		filename = "snippet.txt"
+	}
+	input := &models.SnippetInput{
+		Title:       form.Title,
+		Description: form.Description,
+		Content:     form.Content,
+		Language:    form.Language,
+		Tags:        strings.FieldsFunc(form.Tags, func(c rune) bool { return c == ',' || c == ' ' }),
+		IsPublic:    form.IsPublic,
+		Files:       []models.SnippetFileInput{{Filename: filename, Content: form.Content, Language: form.Language}},
+	}
+
+	snippet, err := h.snippetSvc.Create(r.Context(), input)
+	if err != nil {
+		var validationErrs validation.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			h.renderError(w, r, http.StatusInternalServerError, "Something went wrong", "The snippet could not be saved.")
+			return
+		}
+
+		data := h.data(r, "New snippet")
+		data.Form = form
+		data.Languages = h.languages
+		for _, e := range validationErrs {
+			data.Errors = append(data.Errors, i18n.T(data.Lang, e.Message))
+		}
+		h.render(w, http.StatusUnprocessableEntity, "new", data)
+		return
+	}
+
+	http.Redirect(w, r, "/lite/s/"+url.PathEscape(snippet.ID), http.StatusSeeOther)
+}
+
+// Login serves GET /lite/login
+func (h *LiteHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !h.loginRequired(r) || h.loggedIn(r) {
+		http.Redirect(w, r, "/lite", http.StatusSeeOther)
+		return
+	}
+	h.render(w, http.StatusOK, "login", h.loginData(r, "Log in"))
+}
+
+// LoginSubmit serves POST /lite/login
+func (h *LiteHandler) LoginSubmit(w http.ResponseWriter, r *http.Request) {
+	if !h.loginRequired(r) {
+		http.Redirect(w, r, "/lite", http.StatusSeeOther)
+		return
+	}
+
+	data := h.loginData(r, "Log in")
+	password := r.PostFormValue("password")
+	if password == "" {
+		data.Errors = []string{i18n.T(data.Lang, "Password is required")}
+		h.render(w, http.StatusBadRequest, "login", data)
+		return
+	}
+
+	if h.captchaGate != nil && h.captchaGate() {
+		data.Errors = []string{i18n.T(data.Lang, "Too many failed logins. Log in from the full interface, which asks for a captcha.")}
+		h.render(w, http.StatusForbidden, "login", data)
+		return
+	}
+
+	clientIP := clientIPForAuth(r)
+	valid, delay := h.authService.VerifyPasswordWithDelay(password, clientIP)
+	if delay > 0 {
+		h.recordLogin(r, false, clientIP, models.LoginReasonRateLimited)
+		seconds := int(delay.Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		data.Errors = []string{i18n.Sprintf(data.Lang, "Too many failed attempts. Please wait %d seconds.", seconds)}
+		h.render(w, http.StatusTooManyRequests, "login", data)
+		return
+	}
+	if !valid {
+		h.recordLogin(r, false, clientIP, models.LoginReasonInvalidPassword)
+		data.Errors = []string{i18n.T(data.Lang, "Invalid password")}
+		h.render(w, http.StatusUnauthorized, "login", data)
+		return
+	}
+
+	token, err := h.authService.CreateSession()
+	if err != nil {
+		h.renderError(w, r, http.StatusInternalServerError, "Something went wrong", "The session could not be created.")
+		return
+	}
+	h.recordLogin(r, true, clientIP, "")
+	h.authService.SetSessionCookie(w, token)
+	http.Redirect(w, r, "/lite", http.StatusSeeOther)
+}
+
+// Logout serves POST /lite/logout
+func (h *LiteHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if token := auth.GetSessionFromRequest(r); token != "" {
+		_ = h.authService.InvalidateSession(token)
+	}
+	h.authService.ClearSessionCookie(w)
+	http.Redirect(w, r, "/lite/login", http.StatusSeeOther)
+}
+
+// loginRequired reports whether sessions are in use: auth is not disabled
+// and the login page is not turned off in settings
+func (h *LiteHandler) loginRequired(r *http.Request) bool {
+	if h.authService.IsAuthDisabled() {
+		return false
+	}
+	settings, err := h.settingsRepo.Get(r.Context())
+	return err != nil || !settings.DisableLogin
+}
+
+// loggedIn reports whether the request carries a valid session
+func (h *LiteHandler) loggedIn(r *http.Request) bool {
+	token := auth.GetSessionFromRequest(r)
+	return token != "" && h.authService.ValidateSession(token)
+}
+
+// recordLogin stores a login attempt when auditing is enabled
+func (h *LiteHandler) recordLogin(r *http.Request, success bool, ip, reason string) {
+	if h.audit != nil {
+		h.audit.Record(r.Context(), success, ip, r.UserAgent(), reason)
+	}
+}
+
+// data returns the common page data in the request's locale, with the
+// title translated
+func (h *LiteHandler) data(r *http.Request, title string) liteData {
+	data := h.loginData(r, title)
+	data.Nav = true
+	data.LoggedIn = h.loggedIn(r)
+	return data
+}
+
+// loginData returns page data without navigation, for the login page
+func (h *LiteHandler) loginData(r *http.Request, title string) liteData {
+	locale := i18n.Lookup(i18n.FromRequest(r))
+	return liteData{Lang: locale.Code, Dir: locale.Direction, Title: i18n.T(locale.Code, title)}
+}
+
+// renderError renders an error page, translating the message
+func (h *LiteHandler) renderError(w http.ResponseWriter, r *http.Request, status int, title, message string) {
+	data := h.data(r, title)
+	data.Errors = []string{i18n.T(data.Lang, message)}
+	h.render(w, status, "error", data)
+}
+
+// render renders a lite page
+func (h *LiteHandler) render(w http.ResponseWriter, status int, page string, data liteData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Language", data.Lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	_ = h.pages[page].ExecuteTemplate(w, "layout", data)
+}
+
+// litePageURL returns the list URL for a search result page
+func litePageURL(query string, page int) string {
+	values := url.Values{}
+	if query != "" {
+		values.Set("q", query)
+	}
+	values.Set("page", strconv.Itoa(page))
+	return "/lite?" + values.Encode()
+}
+
+// clientIPForAuth returns the client IP the way the API login does, so both
+// logins share the failed-attempt delays
+func clientIPForAuth(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.TrimSpace(strings.Split(xff, ",")[0])
+	}
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return xri
+	}
+	ip := r.RemoteAddr
+	if idx := strings.LastIndex(ip, ":"); idx != -1 {
+		ip = ip[:idx]
+	}
+	return ip
+}
@@ -0,0 +1,235 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/services"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+func setupLiteHandler(t *testing.T) (*LiteHandler, *services.SnippetService) {
+	t.Helper()
+	db := testutil.TestDB(t)
+	logger := testutil.TestLogger()
+	snippetSvc := services.NewSnippetService(repository.NewSnippetRepository(db), logger).
+		WithTagRepo(repository.NewTagRepository(db)).
+		WithFolderRepo(repository.NewFolderRepository(db)).
+		WithFileRepo(repository.NewSnippetFileRepository(db)).
+		WithMaxFiles(10)
+	authService := auth.NewService(db, "lite-password", "lite-session-secret-0123456789abcdef", time.Hour, logger, false)
+
+	handler, err := NewLiteHandler(snippetSvc, authService, repository.NewSettingsRepository(db))
+	if err != nil {
+		t.Fatalf("NewLiteHandler failed: %v", err)
+	}
+	return handler, snippetSvc
+}
+
+func postForm(handler http.HandlerFunc, target string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestLiteHandler_ListAndView(t *testing.T) {
+	handler, snippetSvc := setupLiteHandler(t)
+	ctx := context.Background()
+
+	snippet, err := snippetSvc.Create(ctx, &models.SnippetInput{
+		Title:    "Escape <me>",
+		Content:  "if a < b { return }",
+		Language: "go",
+		Files:    []models.SnippetFileInput{{Filename: "main.go", Content: "if a < b { return }", Language: "go"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := snippetSvc.Create(ctx, &models.SnippetInput{Title: "Other", Content: "echo hi", Language: "bash"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.List(rec, httptest.NewRequest(http.MethodGet, "/lite?q=Escape", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `href="/lite/s/`+snippet.ID+`"`) || strings.Contains(body, "Other") {
+		t.Fatalf("expected only the matching snippet, got %d:\n%s", rec.Code, body)
+	}
+	if strings.Contains(body, "<script") || strings.Contains(body, "<me>") {
+		t.Errorf("expected escaped output without scripts:\n%s", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/lite/s/"+snippet.ID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", snippet.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	handler.View(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<pre><code>if a &lt; b { return }</code></pre>") {
+		t.Errorf("expected the file content, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rctx.URLParams = chi.RouteParams{}
+	rctx.URLParams.Add("id", "missing")
+	rec = httptest.NewRecorder()
+	handler.View(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestLiteHandler_RequiresCheckout(t *testing.T) {
+	db := testutil.TestDB(t)
+	snippetSvc := services.NewSnippetService(repository.NewSnippetRepository(db), testutil.TestLogger()).
+		WithFileRepo(repository.NewSnippetFileRepository(db)).
+		WithCheckoutRepo(repository.NewCheckoutRepository(db))
+	handler, err := NewLiteHandler(snippetSvc, nil, repository.NewSettingsRepository(db))
+	if err != nil {
+		t.Fatalf("NewLiteHandler failed: %v", err)
+	}
+
+	requiresCheckout := true
+	session := auth.WithActor(context.Background(), auth.Actor{Name: "session"})
+	snippet, err := snippetSvc.Create(session, &models.SnippetInput{
+		Title:            "Runbook",
+		Content:          "rotate-root-password",
+		Language:         "bash",
+		RequiresCheckout: &requiresCheckout,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	view := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/lite/s/"+snippet.ID, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", snippet.ID)
+		req = req.WithContext(context.WithValue(session, chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		handler.View(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	if body := view(); strings.Contains(body, "rotate-root-password") || !strings.Contains(body, "must be checked out first") {
+		t.Errorf("expected the content withheld before a check-out:\n%s", body)
+	}
+	rec := httptest.NewRecorder()
+	handler.List(rec, httptest.NewRequest(http.MethodGet, "/lite", nil).WithContext(session))
+	if strings.Contains(rec.Body.String(), "rotate-root-password") {
+		t.Errorf("expected the content withheld from the list:\n%s", rec.Body.String())
+	}
+
+	if _, err := snippetSvc.Checkout(session, snippet.ID, &models.CheckoutInput{Reason: "INC-42"}); err != nil {
+		t.Fatalf("Checkout failed: %v", err)
+	}
+	if body := view(); !strings.Contains(body, "rotate-root-password") {
+		t.Errorf("expected the content once checked out:\n%s", body)
+	}
+}
+
+func TestLiteHandler_Create(t *testing.T) {
+	handler, snippetSvc := setupLiteHandler(t)
+
+	rec := postForm(handler.Create, "/lite/new", url.Values{
+		"title":    {"From a text browser"},
+		"language": {"python"},
+		"filename": {"hello.py"},
+		"tags":     {"lite, cli"},
+		"content":  {"print('hi')\r\nprint('bye')\r\n"},
+	})
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/lite/s/") {
+		t.Fatalf("expected a redirect to the snippet, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	snippet, err := snippetSvc.GetByID(context.Background(), strings.TrimPrefix(rec.Header().Get("Location"), "/lite/s/"))
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if len(snippet.Files) != 1 || snippet.Files[0].Filename != "hello.py" || snippet.Files[0].Content != "print('hi')\nprint('bye')\n" {
+		t.Errorf("unexpected files: %+v", snippet.Files)
+	}
+	if len(snippet.Tags) != 2 {
+		t.Errorf("expected 2 tags, got %+v", snippet.Tags)
+	}
+
+	// Validation errors re-render the form with the entered values
+	rec = postForm(handler.Create, "/lite/new", url.Values{"title": {""}, "content": {"kept"}})
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "Title is required") || !strings.Contains(rec.Body.String(), ">kept</textarea>") {
+		t.Errorf("expected the form with errors, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestLiteHandler_Login(t *testing.T) {
+	handler, _ := setupLiteHandler(t)
+
+	rec := postForm(handler.LoginSubmit, "/lite/login", url.Values{"password": {"lite-password"}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/lite" {
+		t.Fatalf("expected a redirect after login, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("expected a session cookie")
+	}
+
+	// Logged-in visitors skip the login page
+	req := httptest.NewRequest(http.MethodGet, "/lite/login", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	handler.Login(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect for a logged-in visitor, got %d", rec.Code)
+	}
+
+	rec = postForm(handler.LoginSubmit, "/lite/login", url.Values{"password": {"wrong"}})
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Invalid password") {
+		t.Errorf("expected a failed login, got %d", rec.Code)
+	}
+
+	// Lite pages cannot show a captcha, so they refuse logins that need one
+	handler.WithCaptchaGate(func() bool { return true })
+	rec = postForm(handler.LoginSubmit, "/lite/login", url.Values{"password": {"lite-password"}})
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "captcha") {
+		t.Errorf("expected the login refused, got %d", rec.Code)
+	}
+}
+
+func TestLiteHandler_Localized(t *testing.T) {
+	handler, _ := setupLiteHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/lite", nil)
+	req.Header.Set("Accept-Language", "ar,en;q=0.5")
+	rec := httptest.NewRecorder()
+	handler.List(rec, req)
+	body := rec.Body.String()
+	if !strings.Contains(body, `<html lang="ar" dir="rtl">`) || !strings.Contains(body, "لم يتم العثور على مقتطفات.") {
+		t.Errorf("expected an Arabic right-to-left page, got:\n%s", body)
+	}
+	if rec.Header().Get("Content-Language") != "ar" {
+		t.Errorf("expected Content-Language ar, got %q", rec.Header().Get("Content-Language"))
+	}
+
+	// Validation messages are translated too
+	req = httptest.NewRequest(http.MethodPost, "/lite/new", strings.NewReader(url.Values{"content": {"x"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "de")
+	rec = httptest.NewRecorder()
+	handler.Create(rec, req)
+	if !strings.Contains(rec.Body.String(), "Titel ist erforderlich") || !strings.Contains(rec.Body.String(), "<h1>Neues Snippet</h1>") {
+		t.Errorf("expected a German form, got:\n%s", rec.Body.String())
+	}
+}
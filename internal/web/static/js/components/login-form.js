@@ -1,9 +1,33 @@
 // Login form component
+import { CaptchaWidget, loadCaptchaConfig } from '../modules/captcha.js';
+
 export function initLoginForm(Alpine) {
   Alpine.data('loginForm', () => ({
     password: '',
     error: '',
     loading: false,
+    captcha: null,
+    showCaptcha: false,
+
+    // After many failed logins the server asks for a captcha; show it
+    // straight away rather than after another failed attempt
+    async init() {
+      const config = await loadCaptchaConfig();
+      if (config?.login) await this.startCaptcha(config);
+    },
+
+    async startCaptcha(config) {
+      config = config || await loadCaptchaConfig();
+      if (!config) return;
+      this.captcha = this.captcha || new CaptchaWidget(config);
+      this.showCaptcha = true;
+      await this.$nextTick();
+      try {
+        await this.captcha.render(this.$refs.captcha);
+      } catch (err) {
+        this.error = 'The captcha could not be loaded';
+      }
+    },
 
     async login() {
       this.loading = true;
@@ -12,16 +36,20 @@ export function initLoginForm(Alpine) {
       try {
         const response = await fetch('/api/v1/auth/login', {
           method: 'POST',
-          headers: { 'Content-Type': 'application/json' },
+          headers: { 'Content-Type': 'application/json', ...(this.captcha?.headers() || {}) },
           credentials: 'include',
           body: JSON.stringify({ password: this.password })
         });
+        this.captcha?.reset();
 
         const json = await response.json();
 
         // Handle error response format: { error: { code, message } }
         if (json.error) {
           this.error = json.error.message || 'Invalid password';
+          if (json.error.code === 'CAPTCHA_REQUIRED' || json.error.code === 'CAPTCHA_FAILED') {
+            await this.startCaptcha();
+          }
           return;
         }
 
@@ -34,9 +62,9 @@ export function initLoginForm(Alpine) {
         }
       } catch (err) {
         this.error = 'Connection error';
+      } finally {
+        this.loading = false;
       }
-
-      this.loading = false;
     }
   }));
 }
@@ -0,0 +1,60 @@
+// Public folder collection component
+import { getLanguageColor } from '../utils/helpers.js';
+
+export function initPublicCollection(Alpine) {
+  Alpine.data('publicCollection', () => ({
+    slug: '',
+    folder: null,
+    snippets: [],
+    pagination: null,
+    loading: true,
+    error: false,
+    errorMessage: '',
+
+    async init() {
+      const match = window.location.pathname.match(/^\/c\/([a-zA-Z0-9-]+)$/);
+      if (!match) {
+        this.error = true;
+        this.errorMessage = 'Invalid collection URL';
+        this.loading = false;
+        return;
+      }
+      this.slug = match[1];
+      await this.load(1);
+    },
+
+    async load(page) {
+      this.loading = true;
+      try {
+        const response = await fetch(`/api/v1/public/folders/${encodeURIComponent(this.slug)}?page=${page}`);
+        const json = await response.json();
+        if (!response.ok || !json.data) {
+          this.error = true;
+          this.errorMessage = json.error?.message || 'This collection is not available or not public';
+          return;
+        }
+        this.folder = json.data.folder;
+        this.snippets = json.data.snippets || [];
+        this.pagination = json.data.pagination;
+        document.title = `${this.folder.name} - Snipo`;
+      } catch (err) {
+        this.error = true;
+        this.errorMessage = 'Failed to load collection';
+      } finally {
+        this.loading = false;
+      }
+    },
+
+    // Share pages use the snippet's slug when it has one
+    snippetURL(snippet) {
+      return `/s/${encodeURIComponent(snippet.slug || snippet.id)}`;
+    },
+
+    getLanguageColor,
+
+    formatDate(dateStr) {
+      if (!dateStr) return '';
+      return new Date(dateStr).toLocaleDateString();
+    }
+  }));
+}
@@ -1,17 +1,36 @@
 // Public snippet view component
 import { showToast } from '../modules/toast.js';
 import { getLanguageColor } from '../utils/helpers.js';
+import { sha256, leadingZeroBits } from '../utils/sha256.js';
+import { CaptchaWidget, loadCaptchaConfig } from '../modules/captcha.js';
 
 export function initPublicSnippet(Alpine) {
   Alpine.data('publicSnippet', () => ({
     snippet: null,
+    announcement: null,
     loading: true,
     error: false,
     errorMessage: '',
+    report: { open: false, reason: 'spam', details: '', sending: false, sent: false, error: '' },
+    reportCaptcha: null,
+    // Burn-after-read links (/b/{token}) wait for the visitor to reveal the
+    // snippet, since that uses up the link
+    burn: { token: '', revealing: false, revealed: false },
+    // ?print=1 shows every file without the page chrome and opens the
+    // print dialog once the snippet is highlighted
+    print: new URLSearchParams(window.location.search).get('print') === '1',
 
     async init() {
       const path = window.location.pathname;
-      const match = path.match(/\/s\/([a-zA-Z0-9]+)/);
+      const burnMatch = path.match(/^\/b\/([a-f0-9]+)$/);
+      if (burnMatch) {
+        this.burn.token = burnMatch[1];
+        this.loading = false;
+        this.loadAnnouncement();
+        return;
+      }
+
+      const match = path.match(/\/s\/([a-zA-Z0-9-]+)/);
 
       if (!match) {
         this.error = true;
@@ -21,9 +40,14 @@ export function initPublicSnippet(Alpine) {
       }
 
       const snippetId = match[1];
+      if (!this.print) {
+        this.loadAnnouncement();
+      }
 
       try {
-        const response = await fetch(`/api/v1/snippets/public/${snippetId}`);
+        // Pass on where the visitor came from for the share analytics
+        const headers = document.referrer ? { 'X-Share-Referrer': document.referrer } : {};
+        const response = await fetch(`/api/v1/snippets/public/${encodeURIComponent(snippetId)}`, { headers });
         const json = await response.json();
 
         // Handle error response format: { error: { code, message } }
@@ -40,6 +64,9 @@ export function initPublicSnippet(Alpine) {
             if (typeof Prism !== 'undefined') {
               Prism.highlightAll();
             }
+            if (this.print) {
+              setTimeout(() => window.print(), 100);
+            }
           });
         } else {
           this.error = true;
@@ -53,6 +80,50 @@ export function initPublicSnippet(Alpine) {
       this.loading = false;
     },
 
+    async reveal() {
+      if (this.burn.revealing) return;
+      this.burn.revealing = true;
+
+      try {
+        const response = await fetch(`/api/v1/burn/${encodeURIComponent(this.burn.token)}`, { method: 'POST' });
+        const json = await response.json();
+        if (!response.ok || !json.data) {
+          this.error = true;
+          this.errorMessage = json.error?.message || 'This link was already opened or has expired';
+          return;
+        }
+        this.snippet = json.data;
+        this.burn.revealed = true;
+        this.$nextTick(() => {
+          if (typeof Prism !== 'undefined') {
+            Prism.highlightAll();
+          }
+        });
+      } catch (err) {
+        this.error = true;
+        this.errorMessage = 'Failed to load snippet';
+      } finally {
+        this.burn.revealing = false;
+      }
+    },
+
+    // The announcement is optional: 204 (none set) and errors leave it hidden
+    async loadAnnouncement() {
+      try {
+        const response = await fetch('/api/v1/announcement');
+        if (response.status !== 200) return;
+        const json = await response.json();
+        this.announcement = json.data || null;
+        this.$nextTick(() => {
+          if (typeof Prism !== 'undefined') {
+            Prism.highlightAll();
+          }
+        });
+      } catch (err) {
+        this.announcement = null;
+      }
+    },
+
     async copyCode() {
       if (this.snippet?.content) {
         await navigator.clipboard.writeText(this.snippet.content);
@@ -60,6 +131,64 @@ export function initPublicSnippet(Alpine) {
       }
     },
 
+    // Opens the report form, showing the captcha when reports need one
+    async toggleReport() {
+      this.report.open = !this.report.open;
+      if (!this.report.open || this.reportCaptcha) return;
+      const config = await loadCaptchaConfig();
+      if (!config?.reports) return;
+      this.reportCaptcha = new CaptchaWidget(config);
+      try {
+        await this.reportCaptcha.render(this.$refs.reportCaptcha);
+      } catch (err) {
+        this.report.error = 'The captcha could not be loaded';
+      }
+    },
+
+    async submitReport() {
+      const match = window.location.pathname.match(/\/s\/([a-zA-Z0-9-]+)/);
+      if (!match || this.report.sending) return;
+      const base = `/s/${encodeURIComponent(match[1])}/report`;
+      this.report.sending = true;
+      this.report.error = '';
+
+      try {
+        // 204 means the server takes reports without a challenge
+        let challenge;
+        const challengeResponse = await fetch(`${base}/challenge`);
+        if (challengeResponse.status === 200) {
+          challenge = (await challengeResponse.json()).data;
+          challenge.nonce = await this.solveChallenge(challenge.salt, challenge.bits);
+        }
+
+        const response = await fetch(base, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json', ...(this.reportCaptcha?.headers() || {}) },
+          body: JSON.stringify({ reason: this.report.reason, details: this.report.details, challenge })
+        });
+        this.reportCaptcha?.reset();
+        if (response.ok) {
+          this.report.sent = true;
+          return;
+        }
+        const json = await response.json().catch(() => ({}));
+        this.report.error = json.error?.details?.[0]?.message || json.error?.message || 'Failed to send report';
+      } catch (err) {
+        this.report.error = 'Failed to send report';
+      } finally {
+        this.report.sending = false;
+      }
+    },
+
+    // Finds a nonce that gives the salt's hash enough leading zero bits,
+    // yielding now and then so the page stays responsive
+    async solveChallenge(salt, bits) {
+      for (let nonce = 0; ; nonce++) {
+        if (leadingZeroBits(sha256(salt + nonce)) >= bits) return String(nonce);
+        if (nonce % 5000 === 4999) await new Promise(resolve => setTimeout(resolve));
+      }
+    },
+
     getLanguageColor,
 
     formatDate(dateStr) {
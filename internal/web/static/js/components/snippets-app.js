@@ -25,6 +25,8 @@ import { backupMixin } from './snippets/backup-mixin.js';
 import { historyMixin } from './snippets/history-mixin.js';
 import { draftMixin } from './snippets/draft-mixin.js';
 import { settingsMixin } from './snippets/settings-mixin.js';
+import { lockMixin } from './snippets/lock-mixin.js';
+import { collabMixin } from './snippets/collab-mixin.js';
 
 export function initSnippetsApp(Alpine) {
   Alpine.data('snippetsApp', () => ({
@@ -72,17 +74,22 @@ export function initSnippetsApp(Alpine) {
     pagination: { page: 1, limit: 20, total: 0, totalPages: 0 },
     totalSnippets: 0,
     favoritesCount: 0,
+    pinnedSnippets: [],
     loading: true,
     viewMode: localStorage.getItem('snipo-view-mode') || 'grid',
     sortBy: localStorage.getItem('snipo-sort-by') || 'updated_at',
     showEditor: false,
     isEditing: false,
+    wikiLinks: {},
     showDeleteModal: false,
     deleteTarget: null,
     showSearchHelp: false,
+    releaseNotes: [],
+    showReleaseNotes: false,
     
     foldersCollapsed: false,
     tagsCollapsed: false,
+    pinnedCollapsed: false,
     
     aceEditor: null,
     aceIgnoreChange: false,
@@ -95,6 +102,7 @@ export function initSnippetsApp(Alpine) {
         this.loadTags(),
         this.loadFolders(),
         this.loadFavoritesCount(),
+        this.loadPinned(),
         this.loadSettings()
       ]);
       
@@ -105,6 +113,21 @@ export function initSnippetsApp(Alpine) {
       await this.restoreFromUrl();
       window.addEventListener('popstate', () => this.restoreFromUrl());
       this.loadDraft();
+      this.loadReleaseNotes();
+    },
+
+    // Release notes of versions this session has not seen, after an upgrade
+    async loadReleaseNotes() {
+      const result = await api.get('/api/v1/changelog');
+      if (result && !result.error && result.new && result.new.length > 0) {
+        this.releaseNotes = result.new;
+        this.showReleaseNotes = true;
+      }
+    },
+
+    async dismissReleaseNotes() {
+      this.showReleaseNotes = false;
+      await api.post('/api/v1/changelog/seen');
     },
 
     // Sidebar resize functionality
@@ -210,6 +233,13 @@ export function initSnippetsApp(Alpine) {
       }
     },
 
+    async loadPinned() {
+      const result = await api.get('/api/v1/snippets/pinned');
+      if (Array.isArray(result)) {
+        this.pinnedSnippets = result;
+      }
+    },
+
     async search() {
       this.pagination.page = 1;
       await this.loadSnippets();
@@ -342,6 +372,11 @@ export function initSnippetsApp(Alpine) {
           this.activeFileIndex = 0;
           this.showEditor = true;
           this.isEditing = isEdit;
+          this.resolveWikiLinks();
+          if (isEdit) {
+            this.acquireEditLock();
+            this.startCollab();
+          }
           this.$nextTick(() => {
             if (isEdit) this.updateAceEditor();
             highlightAll();
@@ -405,6 +440,8 @@ export function initSnippetsApp(Alpine) {
     ...backupMixin,
     ...historyMixin,
     ...draftMixin,
-    ...settingsMixin
+    ...settingsMixin,
+    ...lockMixin,
+    ...collabMixin
   }));
 }
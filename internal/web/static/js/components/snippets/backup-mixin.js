@@ -8,6 +8,7 @@ export const backupMixin = {
   backupFile: null,
   backupLoading: false,
   importResult: null,
+  importProgress: null,
   s3Status: { enabled: false },
   s3Backups: [],
 
@@ -62,11 +63,13 @@ export const backupMixin = {
 
     this.backupLoading = true;
     this.importResult = null;
+    this.importProgress = null;
 
     try {
       const formData = new FormData();
       formData.append('file', this.backupFile);
       formData.append('strategy', this.importOptions.strategy);
+      formData.append('async', 'true');
       if (this.importOptions.password) {
         formData.append('password', this.importOptions.password);
       }
@@ -83,7 +86,12 @@ export const backupMixin = {
         throw new Error(result.error?.message || 'Import failed');
       }
 
-      this.importResult = result;
+      const job = await this.watchJob(result.data.id);
+      if (job.status !== 'succeeded') {
+        throw new Error(job.error || 'Import failed');
+      }
+
+      this.importResult = job.result;
       this.backupFile = null;
 
       await Promise.all([
@@ -96,9 +104,34 @@ export const backupMixin = {
     } catch (err) {
       showToast(err.message || 'Failed to import backup', 'error');
     }
+    this.importProgress = null;
     this.backupLoading = false;
   },
 
+  // Follows a background job's event stream, resolving with the finished job
+  watchJob(id) {
+    return new Promise((resolve, reject) => {
+      const source = new EventSource(`/api/v1/jobs/${id}/events`);
+      source.addEventListener('progress', (e) => {
+        const job = JSON.parse(e.data);
+        this.importProgress = { processed: job.processed, total: job.total };
+      });
+      source.addEventListener('done', (e) => {
+        source.close();
+        resolve(JSON.parse(e.data));
+      });
+      source.onerror = () => {
+        source.close();
+        // The stream dropped; the job keeps running, so fall back to its status
+        api.get(`/api/v1/jobs/${id}`)
+          .then((job) => job?.status === 'running' || job?.status === 'pending'
+            ? this.watchJob(id).then(resolve, reject)
+            : resolve(job))
+          .catch(reject);
+      };
+    });
+  },
+
   async loadS3Status() {
     try {
       const result = await api.get('/api/v1/backup/s3/status');
@@ -126,26 +159,52 @@ export const backupMixin = {
 
   async syncToS3() {
     this.backupLoading = true;
+    this.importProgress = null;
     try {
-      const result = await api.post('/api/v1/backup/s3/sync', {
+      const result = await api.post('/api/v1/backup/s3/sync?async=true', {
         format: this.backupOptions.format,
         password: this.backupOptions.password
       });
 
-      if (result && !result.error) {
-        await this.loadS3Backups();
-        showToast('Backup synced to S3 successfully');
-      } else {
+      if (!result || result.error) {
         throw new Error(result?.error?.message || 'Sync failed');
       }
+
+      // Progress of uploads is reported in bytes
+      const job = await this.watchJob(result.id);
+      if (job.status !== 'succeeded') {
+        throw new Error(job.error || 'Sync failed');
+      }
+
+      await this.loadS3Backups();
+      showToast('Backup synced to S3 successfully');
     } catch (err) {
       showToast(err.message || 'Failed to sync to S3', 'error');
     }
+    this.importProgress = null;
     this.backupLoading = false;
   },
 
   async restoreFromS3(key) {
-    if (!confirm('Restore from this backup? This will import the backup data.')) return;
+    let message = 'Restore from this backup? This will import the backup data.';
+    try {
+      const preview = await api.get(`/api/v1/backup/s3/preview?key=${encodeURIComponent(key)}`);
+      if (preview && !preview.error) {
+        const m = preview.manifest;
+        if (m && m.version) {
+          message += `\n\nContains ${m.snippets} snippets, ${m.tags} tags and ${m.folders} folders.`;
+        }
+        if (this.importOptions.strategy === 'replace') {
+          message += '\n\nAll current data will be replaced.';
+        }
+        if (preview.warnings?.length) {
+          message += '\n\nWarning:\n- ' + preview.warnings.join('\n- ');
+        }
+      }
+    } catch (err) {
+      console.error('Failed to preview S3 backup:', err);
+    }
+    if (!confirm(message)) return;
 
     this.backupLoading = true;
     try {
@@ -161,7 +220,9 @@ export const backupMixin = {
           this.loadTags(),
           this.loadFolders()
         ]);
-        showToast('Backup restored successfully');
+        showToast(result.safety_snapshot
+          ? `Backup restored; previous data saved to ${result.safety_snapshot}`
+          : 'Backup restored successfully');
       } else {
         throw new Error(result?.error?.message || 'Restore failed');
       }
@@ -0,0 +1,103 @@
+// Collab mixin - live collaborative editing when the server enables it
+import { CollabSession } from '../../modules/collab.js';
+
+export const collabMixin = {
+  collabEnabled: null,
+  collabSession: null,
+  collabPeers: 0,
+
+  async loadCollabFeature() {
+    try {
+      const response = await fetch('/health', { credentials: 'include' });
+      const json = await response.json();
+      this.collabEnabled = !!json?.data?.features?.collab;
+    } catch (e) {
+      this.collabEnabled = false;
+    }
+  },
+
+  // Joins the document of the file being edited, leaving any other one
+  async startCollab() {
+    if (this.collabEnabled === null) await this.loadCollabFeature();
+    const id = this.editingSnippet?.id;
+    if (!this.collabEnabled || !id || !this.isEditing) return;
+
+    const files = this.editingSnippet.files || [];
+    const fileId = files.length > 0 ? (this.activeFile?.id || 0) : 0;
+    const current = this.collabSession;
+    if (current && current.snippetId === id && current.fileId === fileId) return;
+    this.stopCollab();
+    if (files.length > 0 && !fileId) return; // New file, not saved yet
+
+    const session = new CollabSession(id, fileId, {
+      onSync: (text) => this.applyCollabText(session, text),
+      onChanges: (changes) => this.applyCollabChanges(session, changes),
+      onPeers: (count) => { if (this.collabSession === session) this.collabPeers = count; },
+      onClose: () => {
+        if (this.collabSession !== session) return;
+        this.collabSession = null;
+        this.collabPeers = 0;
+      }
+    });
+    this.collabSession = session;
+  },
+
+  stopCollab() {
+    const session = this.collabSession;
+    this.collabSession = null;
+    this.collabPeers = 0;
+    session?.close();
+  },
+
+  // Sends a change made in the editor (an Ace delta) to the other editors
+  collabLocalChange(delta) {
+    const session = this.collabSession;
+    if (!session?.ready || !this.aceEditor) return;
+    const offset = this.aceEditor.session.doc.positionToIndex(delta.start);
+    const text = delta.lines.join('\n');
+    if (delta.action === 'insert') {
+      session.localInsert(offset, text);
+    } else {
+      session.localRemove(offset, text);
+    }
+  },
+
+  // Replaces the text with the document's, which may carry edits not yet
+  // written back to the snippet
+  applyCollabText(session, text) {
+    if (this.collabSession !== session) return;
+    if (!this.aceEditor) {
+      this.updateActiveFileContent(text);
+      return;
+    }
+    if (this.aceEditor.getValue() === text) return;
+    const cursor = this.aceEditor.getCursorPosition();
+    this.aceIgnoreChange = true;
+    try {
+      this.aceEditor.setValue(text, -1);
+      this.aceEditor.moveCursorToPosition(cursor);
+    } finally {
+      this.aceIgnoreChange = false;
+    }
+    this.updateActiveFileContent(this.aceEditor.getValue());
+  },
+
+  applyCollabChanges(session, changes) {
+    if (this.collabSession !== session || !this.aceEditor) return;
+    const doc = this.aceEditor.session.doc;
+    this.aceIgnoreChange = true;
+    try {
+      for (const change of changes) {
+        const start = doc.indexToPosition(change.offset);
+        if (change.text !== undefined) {
+          doc.insert(start, change.text);
+        } else {
+          doc.remove({ start, end: doc.indexToPosition(change.offset + change.length) });
+        }
+      }
+    } finally {
+      this.aceIgnoreChange = false;
+    }
+    this.updateActiveFileContent(this.aceEditor.getValue());
+  }
+};
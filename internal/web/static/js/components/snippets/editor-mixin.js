@@ -21,11 +21,31 @@ export const editorMixin = {
       this.activeFileIndex = 0;
       this.showEditor = true;
       this.isEditing = false;
+      this.releaseEditLock();
+      this.stopCollab();
+      this.otherEditorLock = result.lock || null;
+      this.resolveWikiLinks();
       this.updateUrl({ snippet: snippet.id });
       this.$nextTick(() => this.highlightAll());
     }
   },
 
+  // Looks up the snippets behind [[Title]] references for the markdown preview
+  async resolveWikiLinks() {
+    const snippet = this.editingSnippet;
+    this.wikiLinks = {};
+    const content = [snippet, ...(snippet.files || [])]
+      .filter(s => s.language === 'markdown' && s.content?.includes('[['))
+      .map(s => s.content)
+      .join('\n');
+    if (!content) return;
+
+    const result = await api.post('/api/v1/resolve-links', { content });
+    if (result && !result.error && snippet === this.editingSnippet) {
+      this.wikiLinks = Object.fromEntries(result.links.map(l => [l.title.toLowerCase(), l.snippet_id]));
+    }
+  },
+
   newSnippet() {
     this.editingSnippet = {
       id: null,
@@ -71,6 +91,9 @@ export const editorMixin = {
       this.activeFileIndex = 0;
       this.showEditor = true;
       this.isEditing = true;
+      this.resolveWikiLinks();
+      this.acquireEditLock();
+      this.startCollab();
       this.updateUrl({ snippet: snippet.id, edit: true });
       this.$nextTick(() => {
         this.updateAceEditor();
@@ -83,6 +106,8 @@ export const editorMixin = {
     this.isEditing = true;
     if (this.editingSnippet?.id) {
       this.updateUrl({ snippet: this.editingSnippet.id, edit: true });
+      this.acquireEditLock();
+      this.startCollab();
     }
     this.$nextTick(() => {
       this.updateAceEditor();
@@ -132,6 +157,10 @@ export const editorMixin = {
       is_public: this.editingSnippet.is_public || false,
       files: files
     };
+    // Omitting the slug keeps the current one (or lets the server derive one)
+    if (this.editingSnippet.slug !== undefined) {
+      data.slug = this.editingSnippet.slug.trim();
+    }
 
     let result;
     if (this.editingSnippet.id) {
@@ -142,6 +171,8 @@ export const editorMixin = {
 
     if (result && !result.error) {
       showToast(this.editingSnippet.id ? 'Snippet updated' : 'Snippet created');
+      this.releaseEditLock();
+      this.stopCollab();
       this.showEditor = false;
       this.isEditing = false;
       this.destroyAceEditor();
@@ -157,6 +188,8 @@ export const editorMixin = {
   },
 
   cancelEdit() {
+    this.releaseEditLock();
+    this.stopCollab();
     this.showEditor = false;
     this.isEditing = false;
     this.destroyAceEditor();
@@ -213,7 +246,8 @@ export const editorMixin = {
       this.loadSnippets(),
       this.loadTags(),
       this.loadFolders(),
-      this.loadFavoritesCount()
+      this.loadFavoritesCount(),
+      this.loadPinned()
     ]);
 
     this.totalSnippets = this.snippets.length;
@@ -227,6 +261,19 @@ export const editorMixin = {
     }
   },
 
+  async togglePin(snippet) {
+    const result = await api.post(`/api/v1/snippets/${snippet.id}/pin`);
+    if (result?.error) {
+      showToast(result.error.message, 'error');
+      return;
+    }
+    if (result) {
+      snippet.is_pinned = result.is_pinned;
+      showToast(result.is_pinned ? 'Pinned to dashboard' : 'Unpinned');
+      await this.loadPinned();
+    }
+  },
+
   async duplicateSnippet(snippet) {
     const result = await api.post(`/api/v1/snippets/${snippet.id}/duplicate`);
     if (result && !result.error) {
@@ -239,6 +286,9 @@ export const editorMixin = {
     try {
       await navigator.clipboard.writeText(snippet.content);
       showToast('Copied to clipboard');
+      if (snippet.id) {
+        api.post(`/api/v1/snippets/${snippet.id}/usage`, { event: 'copy' });
+      }
     } catch (err) {
       showToast('Failed to copy', 'error');
     }
@@ -250,7 +300,7 @@ export const editorMixin = {
       return;
     }
     try {
-      const shareUrl = `${window.location.origin}/s/${snippet.id}`;
+      const shareUrl = `${window.location.origin}/s/${snippet.slug || snippet.id}`;
       await navigator.clipboard.writeText(shareUrl);
       showToast('Share link copied to clipboard');
     } catch (err) {
@@ -348,8 +398,9 @@ export const editorMixin = {
         this.applyEditorSettings();
 
         const self = this;
-        this.aceEditor.session.on('change', () => {
+        this.aceEditor.session.on('change', (delta) => {
           if (self.aceIgnoreChange) return;
+          self.collabLocalChange(delta);
 
           const value = self.aceEditor.getValue();
           if (self.editingSnippet.files && self.editingSnippet.files.length > 0) {
@@ -53,6 +53,7 @@ export const fileManagerMixin = {
     this.$nextTick(() => {
       this._loadFileToEditor(newFileIndex);
       this.fileManagerState.operationInProgress = false;
+      this.startCollab();
     });
   },
 
@@ -4,15 +4,23 @@ import { showToast } from '../../modules/toast.js';
 
 export const foldersMixin = {
   showFolderModal: false,
-  editingFolder: { name: '', parent_id: '' },
+  editingFolder: { name: '', parent_id: '', icon: '', color: '', is_public: false },
 
   showNewFolderModal() {
-    this.editingFolder = { name: '', parent_id: '' };
+    this.editingFolder = { name: '', parent_id: '', icon: '', color: '', is_public: false };
     this.showFolderModal = true;
   },
 
   renameFolder(folder) {
-    this.editingFolder = { id: folder.id, name: folder.name, parent_id: folder.parent_id || '' };
+    this.editingFolder = {
+      id: folder.id,
+      name: folder.name,
+      parent_id: folder.parent_id || '',
+      icon: folder.icon || '',
+      color: folder.color || '',
+      is_public: !!folder.is_public,
+      slug: folder.slug || ''
+    };
     this.showFolderModal = true;
   },
 
@@ -24,7 +32,10 @@ export const foldersMixin = {
 
     const data = {
       name: this.editingFolder.name,
-      parent_id: this.editingFolder.parent_id ? parseInt(this.editingFolder.parent_id) : null
+      parent_id: this.editingFolder.parent_id ? parseInt(this.editingFolder.parent_id) : null,
+      icon: this.editingFolder.icon || '',
+      color: this.editingFolder.color || '',
+      is_public: !!this.editingFolder.is_public
     };
 
     let result;
@@ -0,0 +1,62 @@
+// Lock mixin - advisory edit locks warning about concurrent editors
+import { api } from '../../modules/api.js';
+
+const LOCK_TTL = 120;
+const LOCK_RENEW_MS = 60 * 1000;
+
+export const lockMixin = {
+  editLock: null,
+  editLockTimer: null,
+  otherEditorLock: null,
+
+  // Takes the lock of the snippet being edited and keeps renewing it. While
+  // someone else holds it, their lock is shown in the editor instead.
+  async acquireEditLock() {
+    const id = this.editingSnippet?.id;
+    if (!id || this.editLock?.snippet_id === id) return;
+    this.releaseEditLock();
+
+    const result = await api.post(`/api/v1/snippets/${id}/lock`, { ttl: LOCK_TTL });
+    if (result?.error) {
+      if (result.error.code === 'SNIPPET_LOCKED') await this.loadOtherEditorLock(id);
+      return;
+    }
+    if (!result) return;
+    if (this.editingSnippet?.id !== id || !this.isEditing) {
+      api.delete(`/api/v1/snippets/${id}/lock?lock_id=${result.lock_id}`);
+      return;
+    }
+
+    this.editLock = result;
+    this.otherEditorLock = null;
+    this.editLockTimer = setInterval(async () => {
+      const renewed = await api.post(`/api/v1/snippets/${id}/lock`, { lock_id: result.lock_id, ttl: LOCK_TTL });
+      if (renewed?.error?.code === 'SNIPPET_LOCKED') {
+        this.stopEditLock();
+        await this.loadOtherEditorLock(id);
+      }
+    }, LOCK_RENEW_MS);
+  },
+
+  async loadOtherEditorLock(id) {
+    const snippet = await api.get(`/api/v1/snippets/${id}`);
+    if (this.editingSnippet?.id === id) {
+      this.otherEditorLock = snippet?.lock || null;
+    }
+  },
+
+  releaseEditLock() {
+    const lock = this.editLock;
+    this.stopEditLock();
+    this.otherEditorLock = null;
+    if (lock) {
+      api.delete(`/api/v1/snippets/${lock.snippet_id}/lock?lock_id=${lock.lock_id}`);
+    }
+  },
+
+  stopEditLock() {
+    clearInterval(this.editLockTimer);
+    this.editLockTimer = null;
+    this.editLock = null;
+  }
+};
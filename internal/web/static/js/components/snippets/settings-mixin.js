@@ -3,11 +3,16 @@ import { api } from '../../modules/api.js';
 import { showToast } from '../../modules/toast.js';
 import { theme } from '../../modules/theme.js';
 
+// Splits a comma- or whitespace-separated token restriction field
+function splitTokenList(value) {
+  return (value || '').split(/[\s,]+/).filter(Boolean);
+}
+
 export const settingsMixin = {
   showSettings: false,
   settingsTab: 'password',
   apiTokens: [],
-  newToken: { name: '', permissions: 'read', expires_in_days: 30 },
+  newToken: { name: '', permissions: 'read', expires_in_days: 30, allowed_ips: '', allowed_referrers: '' },
   createdToken: null,
   tokenPasswordAction: null, // 'create' or 'delete'
   tokenPassword: '',
@@ -94,7 +99,9 @@ export const settingsMixin = {
     this.pendingTokenData = {
       name: this.newToken.name,
       permissions: this.newToken.permissions,
-      expires_in_days: parseInt(this.newToken.expires_in_days) || null
+      expires_in_days: parseInt(this.newToken.expires_in_days) || null,
+      allowed_ips: splitTokenList(this.newToken.allowed_ips),
+      allowed_referrers: splitTokenList(this.newToken.allowed_referrers)
     };
   },
 
@@ -142,7 +149,7 @@ export const settingsMixin = {
 
     if (result && !result.error) {
       this.createdToken = result.token;
-      this.newToken = { name: '', permissions: 'read', expires_in_days: 30 };
+      this.newToken = { name: '', permissions: 'read', expires_in_days: 30, allowed_ips: '', allowed_referrers: '' };
       await this.loadApiTokens();
       showToast('API token created');
     } else {
@@ -11,6 +11,7 @@ import { initAppStore } from './stores/app-store.js';
 import { initSnippetsApp } from './components/snippets-app.js';
 import { initLoginForm } from './components/login-form.js';
 import { initPublicSnippet } from './components/public-snippet.js';
+import { initPublicCollection } from './components/public-collection.js';
 
 // Import utilities
 import { initKeyboardShortcuts } from './utils/keyboard.js';
@@ -27,6 +28,7 @@ document.addEventListener('alpine:init', () => {
   initSnippetsApp(Alpine);
   initLoginForm(Alpine);
   initPublicSnippet(Alpine);
+  initPublicCollection(Alpine);
 });
 
 // Initialize keyboard shortcuts after DOM is loaded
@@ -0,0 +1,73 @@
+// Captcha module - shows the hCaptcha or Turnstile widget the server is
+// configured with (GET /api/v1/captcha)
+
+// Global each provider's script defines
+const providerGlobals = { hcaptcha: 'hcaptcha', turnstile: 'turnstile' };
+
+const scripts = {};
+
+// Returns the captcha settings, or null when the server has no captcha
+export async function loadCaptchaConfig() {
+  try {
+    const response = await fetch('/api/v1/captcha');
+    if (response.status !== 200) return null;
+    const json = await response.json();
+    return json.data || null;
+  } catch (err) {
+    return null;
+  }
+}
+
+// Loads a provider script once, resolving to the provider's API
+function loadProvider(config) {
+  const name = providerGlobals[config.provider];
+  if (!name) return Promise.reject(new Error('Unknown captcha provider'));
+  if (window[name]) return Promise.resolve(window[name]);
+  if (!scripts[config.script]) {
+    scripts[config.script] = new Promise((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = config.script;
+      script.async = true;
+      script.onload = () => window[name] ? resolve(window[name]) : reject(new Error('Captcha failed to load'));
+      script.onerror = () => {
+        delete scripts[config.script];
+        reject(new Error('Captcha failed to load'));
+      };
+      document.head.appendChild(script);
+    });
+  }
+  return scripts[config.script];
+}
+
+// A widget rendered into an element. token holds the solved token until it
+// is used or expires; tokens are single-use, so call reset after sending one.
+// The provider API is looked up on window each time rather than kept here,
+// so Alpine never wraps it in a reactive proxy.
+export class CaptchaWidget {
+  constructor(config) {
+    this.config = config;
+    this.id = null;
+    this.token = '';
+  }
+
+  async render(element) {
+    if (this.id !== null) return;
+    const api = await loadProvider(this.config);
+    this.id = api.render(element, {
+      sitekey: this.config.site_key,
+      callback: (token) => { this.token = token; },
+      'expired-callback': () => { this.token = ''; }
+    });
+  }
+
+  reset() {
+    this.token = '';
+    const api = window[providerGlobals[this.config.provider]];
+    if (api && this.id !== null) api.reset(this.id);
+  }
+
+  // Headers to send the token with
+  headers() {
+    return this.token ? { 'X-Captcha-Token': this.token } : {};
+  }
+}
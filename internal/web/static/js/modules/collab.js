@@ -0,0 +1,211 @@
+// Collaborative editing client - mirrors the server's RGA document
+// (internal/collab) and exchanges edits over a WebSocket.
+// Positions are counted in code points, like the server; the editor's
+// UTF-16 offsets are converted on the way in and out.
+
+const PING_MS = 30 * 1000;
+
+const key = (id) => `${id.site}:${id.clock}`;
+const isStart = (id) => !id || (!id.site && !id.clock);
+const after = (a, b) => a.clock !== b.clock ? a.clock > b.clock : a.site > b.site;
+
+export class CollabDoc {
+  constructor() {
+    this.chars = [];
+    this.known = new Set();
+    this.clock = 0;
+  }
+
+  static fromState(state) {
+    const doc = new CollabDoc();
+    doc.clock = state.clock;
+    for (const run of state.runs) {
+      let clock = run.clock;
+      for (const ch of run.text) {
+        const id = { site: run.site, clock: clock++ };
+        doc.known.add(key(id));
+        doc.chars.push({ id, ch, deleted: !!run.deleted });
+      }
+    }
+    return doc;
+  }
+
+  text() {
+    return this.chars.filter(c => !c.deleted).map(c => c.ch).join('');
+  }
+
+  // Applies a remote edit, returning where the visible text changed:
+  // { index, text } for an insert, { index, count } for each deleted character
+  apply(op) {
+    if (!op.text) return this._delete(op.delete || []);
+
+    if (this.known.has(key(op.id))) return [];
+    let pos = 0;
+    if (!isStart(op.after)) {
+      pos = this.chars.findIndex(c => key(c.id) === key(op.after)) + 1;
+      if (pos === 0) throw new Error('edit refers to an unknown character');
+    }
+    while (pos < this.chars.length && after(this.chars[pos].id, op.id)) pos++;
+
+    const chars = [...op.text].map((ch, i) => ({ id: { site: op.id.site, clock: op.id.clock + i }, ch, deleted: false }));
+    chars.forEach(c => this.known.add(key(c.id)));
+    this.chars.splice(pos, 0, ...chars);
+    this.clock = Math.max(this.clock, op.id.clock + chars.length - 1);
+    return [{ index: this._visibleBefore(pos), text: op.text }];
+  }
+
+  _delete(ids) {
+    const remove = new Set(ids.map(key));
+    const changes = [];
+    let visible = 0;
+    for (const c of this.chars) {
+      if (c.deleted) continue;
+      if (remove.has(key(c.id))) {
+        c.deleted = true;
+        changes.push({ index: visible, count: 1 });
+      } else {
+        visible++;
+      }
+    }
+    return changes;
+  }
+
+  _visibleBefore(pos) {
+    let n = 0;
+    for (let i = 0; i < pos; i++) if (!this.chars[i].deleted) n++;
+    return n;
+  }
+
+  _visible(n) {
+    for (let i = 0; i < this.chars.length; i++) {
+      if (this.chars[i].deleted) continue;
+      if (n-- === 0) return i;
+    }
+    return -1;
+  }
+
+  // Inserts text at a visible position, returning the edit to send
+  insert(site, pos, text) {
+    const op = { id: { site, clock: this.clock + 1 }, after: { site: '', clock: 0 }, text };
+    if (pos > 0) op.after = this.chars[this._visible(pos - 1)].id;
+    this.apply(op);
+    return op;
+  }
+
+  // Deletes count characters at a visible position, returning the edit to send
+  delete(pos, count) {
+    const op = { id: { site: '', clock: 0 }, after: { site: '', clock: 0 }, delete: [] };
+    for (; count > 0; count--) {
+      const i = this._visible(pos);
+      if (i < 0) break;
+      this.chars[i].deleted = true;
+      op.delete.push(this.chars[i].id);
+    }
+    return op;
+  }
+
+  // Converts a UTF-16 offset into the visible text to code points
+  toCodePoints(utf16) {
+    let n = 0;
+    for (const c of this.chars) {
+      if (c.deleted) continue;
+      if (utf16 <= 0) break;
+      utf16 -= c.ch.length;
+      n++;
+    }
+    return n;
+  }
+}
+
+// CollabSession connects to a snippet file's document. Callbacks:
+// onSync(text) replaces the editor text, onChanges(changes) applies remote
+// changes (UTF-16 offsets), onPeers(count), onClose()
+export class CollabSession {
+  constructor(snippetId, fileId, handlers) {
+    this.snippetId = snippetId;
+    this.fileId = fileId;
+    this.handlers = handlers;
+    this.doc = null;
+    this.site = null;
+
+    const scheme = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    this.ws = new WebSocket(`${scheme}//${location.host}/api/v1/snippets/${snippetId}/collab?file_id=${fileId}`);
+    this.ws.onmessage = (e) => this._receive(JSON.parse(e.data));
+    this.ws.onclose = () => this.close();
+    this.ping = setInterval(() => this._send({ type: 'ping' }), PING_MS);
+  }
+
+  get ready() {
+    return this.doc !== null;
+  }
+
+  // Record a local editor change at a UTF-16 offset into the text as it
+  // was before the change
+  localInsert(offset, text) {
+    if (!this.ready) return;
+    const op = this.doc.insert(this.site, this.doc.toCodePoints(offset), text);
+    this._send({ type: 'ops', ops: [op] });
+  }
+
+  localRemove(offset, removed) {
+    if (!this.ready) return;
+    const op = this.doc.delete(this.doc.toCodePoints(offset), [...removed].length);
+    if (op.delete.length) this._send({ type: 'ops', ops: [op] });
+  }
+
+  close() {
+    if (!this.ws) return;
+    clearInterval(this.ping);
+    const ws = this.ws;
+    this.ws = null;
+    ws.close();
+    this.handlers.onClose?.();
+  }
+
+  _send(msg) {
+    if (this.ws?.readyState === WebSocket.OPEN) this.ws.send(JSON.stringify(msg));
+  }
+
+  _receive(msg) {
+    switch (msg.type) {
+      case 'sync':
+        this.site = msg.site;
+        this.doc = CollabDoc.fromState(msg.state);
+        this.handlers.onSync?.(this.doc.text());
+        break;
+      case 'ops':
+        if (!this.ready) return;
+        try {
+          for (const op of msg.ops) {
+            const before = this.doc.text();
+            this.handlers.onChanges?.(toUTF16Changes(before, this.doc.apply(op)));
+          }
+        } catch (e) {
+          console.warn('Collab edit could not be applied:', e);
+          this.close();
+        }
+        break;
+      case 'peers':
+        this.handlers.onPeers?.(msg.peers || 0);
+        break;
+      case 'error':
+        this.close();
+        break;
+    }
+  }
+}
+
+// toUTF16Changes converts changes made to text, in the order they apply, to
+// UTF-16 offsets and lengths
+function toUTF16Changes(text, changes) {
+  const chars = [...text];
+  return changes.map(change => {
+    const offset = chars.slice(0, change.index).join('').length;
+    if (change.text !== undefined) {
+      chars.splice(change.index, 0, ...change.text);
+      return { offset, text: change.text };
+    }
+    const removed = chars.splice(change.index, change.count).join('');
+    return { offset, length: removed.length };
+  });
+}
@@ -92,9 +92,12 @@ export function highlightAll() {
   }
 }
 
-// Render markdown
-export function renderMarkdown(content) {
+// Render markdown. wikiLinks maps lower-cased titles to snippet IDs and
+// turns [[Title]] and [[Title|label]] references into links; references
+// without a snippet are marked as missing.
+export function renderMarkdown(content, wikiLinks = null) {
   if (!content) return '';
+  if (wikiLinks) content = renderWikiLinks(content, wikiLinks);
   if (typeof marked !== 'undefined') {
     marked.setOptions({
       breaks: true,
@@ -105,6 +108,22 @@ export function renderMarkdown(content) {
   return content;
 }
 
+function renderWikiLinks(content, wikiLinks) {
+  return content.replace(/\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]*))?\]\]/g, (_, title, label) => {
+    const text = escapeHtml((label || title).trim());
+    const id = wikiLinks[title.trim().toLowerCase()];
+    return id
+      ? `<a class="wiki-link" href="/?snippet=${encodeURIComponent(id)}">${text}</a>`
+      : `<span class="wiki-link wiki-link-missing" title="No snippet with this title">${text}</span>`;
+  });
+}
+
+function escapeHtml(text) {
+  const div = document.createElement('div');
+  div.textContent = text;
+  return div.innerHTML;
+}
+
 // Expose helpers globally
 window.autoResizeInput = autoResizeInput;
 window.autoResizeSelect = autoResizeSelect;
@@ -0,0 +1,3 @@
+-- Remember where imported snippets came from
+
+ALTER TABLE snippets ADD COLUMN source_url TEXT DEFAULT NULL;
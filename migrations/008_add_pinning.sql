@@ -0,0 +1,6 @@
+-- Pinned snippets are shown on the dashboard, independent of favorites
+
+ALTER TABLE snippets ADD COLUMN is_pinned INTEGER DEFAULT 0;
+ALTER TABLE snippets ADD COLUMN pinned_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_snippets_pinned ON snippets(is_pinned, pinned_at);
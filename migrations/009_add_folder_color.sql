@@ -0,0 +1,3 @@
+-- Optional display color for folders (hex, empty = default)
+
+ALTER TABLE folders ADD COLUMN color TEXT NOT NULL DEFAULT '';
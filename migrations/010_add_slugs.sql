@@ -0,0 +1,5 @@
+-- Human-readable share identifiers, unique when set
+
+ALTER TABLE snippets ADD COLUMN slug TEXT DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snippets_slug ON snippets(slug) WHERE slug IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Free-form key/value context attached to snippets (tickets, environments, ...)
+
+CREATE TABLE IF NOT EXISTS snippet_metadata (
+    snippet_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (snippet_id, key),
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_snippet_metadata_key_value ON snippet_metadata(key, value);
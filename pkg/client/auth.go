@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Login starts a session with the master password. Later requests use the
+// session until Logout; an API token set with WithToken is still sent too.
+func (c *Client) Login(ctx context.Context, password string) error {
+	resp, err := c.send(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/login",
+		body:   map[string]string{"password": password},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == sessionCookie && cookie.Value != "" {
+			c.session = cookie.Value
+			return nil
+		}
+	}
+	return fmt.Errorf("snipo: login response did not include a session")
+}
+
+// Logout ends the session started by Login
+func (c *Client) Logout(ctx context.Context) error {
+	if c.session == "" {
+		return nil
+	}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/auth/logout"}, nil)
+	c.session = ""
+	return err
+}
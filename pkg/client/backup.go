@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ExportOptions configures a backup export
+type ExportOptions struct {
+	Format   string // "json" (default) or "zip"
+	Password string // Encrypts the backup when set
+}
+
+// ExportBackup downloads a backup of all snippets, tags and folders
+func (c *Client) ExportBackup(ctx context.Context, opts ExportOptions) ([]byte, error) {
+	q := url.Values{}
+	if opts.Format != "" {
+		q.Set("format", opts.Format)
+	}
+	if opts.Password != "" {
+		q.Set("password", opts.Password)
+	}
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/api/v1/backup/export", query: q})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("snipo: read backup: %w", err)
+	}
+	return content, nil
+}
+
+// ImportOptions configures a backup restore
+type ImportOptions struct {
+	Strategy string // "merge" (default), "replace" or "skip"
+	Password string // Decrypts an encrypted backup
+}
+
+// ImportBackup restores a backup produced by ExportBackup and waits for the
+// result
+func (c *Client) ImportBackup(ctx context.Context, content []byte, opts ImportOptions) (*ImportResult, error) {
+	req, err := importRequest(content, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	var result ImportResult
+	if _, err := c.do(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ImportBackupAsync validates a backup and restores it in a background job.
+// Follow the job with GetJob or WaitForJob.
+func (c *Client) ImportBackupAsync(ctx context.Context, content []byte, opts ImportOptions) (*Job, error) {
+	req, err := importRequest(content, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if _, err := c.do(ctx, req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// importRequest builds the multipart upload for POST /api/v1/backup/import
+func importRequest(content []byte, opts ImportOptions, async bool) (request, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", "backup")
+	if err != nil {
+		return request{}, fmt.Errorf("snipo: build import form: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return request{}, fmt.Errorf("snipo: build import form: %w", err)
+	}
+	fields := map[string]string{"strategy": opts.Strategy, "password": opts.Password}
+	if async {
+		fields["async"] = "true"
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return request{}, fmt.Errorf("snipo: build import form: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return request{}, fmt.Errorf("snipo: build import form: %w", err)
+	}
+
+	return request{
+		method:      http.MethodPost,
+		path:        "/api/v1/backup/import",
+		body:        body.Bytes(),
+		contentType: form.FormDataContentType(),
+	}, nil
+}
+
+// GetJob returns the current state of a background job
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/jobs/" + url.PathEscape(id)}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitForJob polls a job every interval until it has finished, successfully
+// or not, and returns its final state
+func (c *Client) WaitForJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	for {
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() {
+			return job, nil
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
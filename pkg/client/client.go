@@ -0,0 +1,297 @@
+// Package client is a typed Go client for the snipo v1 HTTP API.
+//
+// Authenticate with an API token or by logging in with the master password:
+//
+//	c := client.New("https://snipo.example.com").WithToken(os.Getenv("SNIPO_TOKEN"))
+//	snippet, err := c.CreateSnippet(ctx, &client.SnippetInput{Title: "hello", Content: "echo hi"})
+//
+// Requests that were rate limited, or that failed with a gateway error or a
+// network error and are safe to repeat, are retried with backoff.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionCookie is the cookie the server keeps login sessions in
+const sessionCookie = "snipo_session"
+
+// Client talks to one snipo server. It is safe for concurrent use, except
+// that Login and Logout must not race other requests.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	session    string
+	userAgent  string
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// New creates a client for the server at baseURL, such as
+// "http://localhost:8080"
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "snipo-go-client",
+		maxRetries: 3,
+		retryWait:  500 * time.Millisecond,
+	}
+}
+
+// WithToken authenticates requests with an API token
+func (c *Client) WithToken(token string) *Client {
+	c.token = token
+	return c
+}
+
+// WithHTTPClient replaces the default HTTP client, which has a 30s timeout
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func (c *Client) WithUserAgent(userAgent string) *Client {
+	c.userAgent = userAgent
+	return c
+}
+
+// WithRetries sets how often a failed request is retried and the wait before
+// the first retry, which doubles on each further attempt. A Retry-After
+// header from the server takes precedence. Zero retries disables retrying.
+func (c *Client) WithRetries(maxRetries int, wait time.Duration) *Client {
+	c.maxRetries = max(0, maxRetries)
+	c.retryWait = wait
+	return c
+}
+
+// APIError is an error response from the server
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+	Details    []FieldError
+}
+
+// FieldError describes one invalid field of a rejected request
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"` // Stable rule code such as TITLE_TOO_LONG
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("snipo: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	for _, detail := range e.Details {
+		msg += "; " + detail.Field + ": " + detail.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 from the server
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// envelope is the standard response body
+type envelope struct {
+	Data       json.RawMessage `json:"data"`
+	Pagination *Pagination     `json:"pagination,omitempty"`
+	Error      *struct {
+		Code      string       `json:"code"`
+		Message   string       `json:"message"`
+		RequestID string       `json:"request_id"`
+		Details   []FieldError `json:"details"`
+	} `json:"error,omitempty"`
+}
+
+// request describes one API call. A []byte body is sent as is with
+// contentType; any other body is JSON encoded.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any
+	contentType string
+}
+
+// do sends req and decodes the data of the response envelope into out,
+// which may be nil. It returns the pagination of list responses.
+func (c *Client) do(ctx context.Context, req request, out any) (*Pagination, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("snipo: decode response: %w", err)
+	}
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, fmt.Errorf("snipo: decode response data: %w", err)
+		}
+	}
+	return env.Pagination, nil
+}
+
+// send performs req with retries and returns a successful response, whose
+// body the caller must close. Error responses are returned as *APIError.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var payload []byte
+	contentType := req.contentType
+	switch body := req.body.(type) {
+	case nil:
+	case []byte:
+		payload = body
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("snipo: encode request: %w", err)
+		}
+		payload = encoded
+		contentType = "application/json"
+	}
+
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, target, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("snipo: build request: %w", err)
+		}
+		if contentType != "" {
+			httpReq.Header.Set("Content-Type", contentType)
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set("User-Agent", c.userAgent)
+		if c.token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if c.session != "" {
+			httpReq.AddCookie(&http.Cookie{Name: sessionCookie, Value: c.session})
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if attempt < c.maxRetries && idempotent(req.method) && ctx.Err() == nil {
+				if err := sleep(ctx, wait); err != nil {
+					return nil, err
+				}
+				wait *= 2
+				continue
+			}
+			return nil, fmt.Errorf("snipo: %s %s: %w", req.method, req.path, err)
+		}
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		apiErr := decodeError(resp)
+		if attempt < c.maxRetries && retryable(req.method, resp.StatusCode) {
+			delay := wait
+			if after := retryAfter(resp); after > 0 {
+				delay = after
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			wait *= 2
+			continue
+		}
+		return nil, apiErr
+	}
+}
+
+// decodeError reads an error response and closes its body
+func decodeError(resp *http.Response) *APIError {
+	defer func() { _ = resp.Body.Close() }()
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       http.StatusText(resp.StatusCode),
+		Message:    http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	var env envelope
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(body, &env) == nil && env.Error != nil {
+		apiErr.Code = env.Error.Code
+		apiErr.Message = env.Error.Message
+		apiErr.Details = env.Error.Details
+		if env.Error.RequestID != "" {
+			apiErr.RequestID = env.Error.RequestID
+		}
+	}
+	return apiErr
+}
+
+// idempotent reports whether a request may be repeated after an unknown outcome
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a failed request should be retried. Rate limited
+// requests were never processed, so they are safe to repeat for any method.
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MohamedElashri/snipo/internal/api"
+	"github.com/MohamedElashri/snipo/internal/auth"
+	"github.com/MohamedElashri/snipo/internal/config"
+	"github.com/MohamedElashri/snipo/internal/database"
+	"github.com/MohamedElashri/snipo/internal/lifecycle"
+	"github.com/MohamedElashri/snipo/internal/models"
+	"github.com/MohamedElashri/snipo/internal/repository"
+	"github.com/MohamedElashri/snipo/internal/testutil"
+)
+
+const testPassword = "client-contract-password"
+
+// newTestServer runs the real router on a fresh database, so these tests
+// fail whenever the client and the API disagree
+func newTestServer(t *testing.T) (*httptest.Server, *repository.TokenRepository) {
+	t.Helper()
+	t.Setenv("SNIPO_MASTER_PASSWORD", testPassword)
+	t.Setenv("SNIPO_SESSION_SECRET", "client-contract-session-secret-32")
+	dir := t.TempDir()
+	t.Setenv("SNIPO_DB_PATH", filepath.Join(dir, "snipo.db"))
+	t.Setenv("SNIPO_SAFETY_SNAPSHOT_DIR", filepath.Join(dir, "snapshots"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	logger := testutil.TestLogger()
+
+	db, err := database.New(database.Config{
+		Path:            cfg.Database.Path,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+		JournalMode:     cfg.Database.JournalMode,
+		SynchronousMode: cfg.Database.SynchronousMode,
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	lc := lifecycle.New(logger)
+	authService := auth.NewService(db.DB, cfg.Auth.MasterPassword, cfg.Auth.SessionSecret, cfg.Auth.SessionDuration, logger, false)
+	server := httptest.NewServer(api.NewRouter(api.RouterConfig{
+		DB:                 db.DB,
+		Logger:             logger,
+		AuthService:        authService,
+		Config:             cfg,
+		RateLimit:          cfg.Auth.RateLimit,
+		RateLimitWindow:    int(cfg.Auth.RateLimitWindow.Seconds()),
+		MaxFilesPerSnippet: cfg.Server.MaxFilesPerSnippet,
+		MaxPinnedSnippets:  cfg.Server.MaxPinnedSnippets,
+		Lifecycle:          lc,
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		_ = lc.Shutdown(context.Background())
+		_ = db.Close()
+	})
+
+	return server, repository.NewTokenRepository(db.DB)
+}
+
+func TestContract_SnippetCRUD(t *testing.T) {
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+	c := New(server.URL)
+
+	if _, err := c.ListSnippets(ctx, ListOptions{}); err == nil {
+		t.Fatal("expected an error before logging in")
+	} else if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusUnauthorized || apiErr.RequestID == "" {
+		t.Fatalf("expected a 401 APIError with a request ID, got %#v", err)
+	}
+
+	if err := c.Login(ctx, testPassword); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	created, err := c.CreateSnippet(ctx, &SnippetInput{
+		Title:    "Contract",
+		Content:  "package main",
+		Language: "go",
+		Tags:     []string{"sdk"},
+		Metadata: map[string]string{"ticket": "SDK-1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSnippet failed: %v", err)
+	}
+	if created.ID == "" || created.Title != "Contract" || len(created.Tags) != 1 || created.Metadata["ticket"] != "SDK-1" {
+		t.Fatalf("unexpected created snippet: %+v", created)
+	}
+
+	got, err := c.GetSnippet(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetSnippet failed: %v", err)
+	}
+	if got.Content != "package main" {
+		t.Errorf("expected content to round-trip, got %q", got.Content)
+	}
+
+	updated, err := c.UpdateSnippet(ctx, created.ID, &SnippetInput{Title: "Contract v2", Content: "package client", Language: "go"})
+	if err != nil {
+		t.Fatalf("UpdateSnippet failed: %v", err)
+	}
+	if updated.Title != "Contract v2" {
+		t.Errorf("expected updated title, got %q", updated.Title)
+	}
+
+	results, err := c.SearchSnippets(ctx, "client", 5)
+	if err != nil {
+		t.Fatalf("SearchSnippets failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != created.ID {
+		t.Errorf("expected search to find the snippet, got %d results", len(results))
+	}
+
+	if err := c.DeleteSnippet(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteSnippet failed: %v", err)
+	}
+	if _, err := c.GetSnippet(ctx, created.ID); !IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := c.ListSnippets(ctx, ListOptions{}); err == nil {
+		t.Error("expected an error after logging out")
+	}
+}
+
+func TestContract_IterSnippets(t *testing.T) {
+	server, tokens := newTestServer(t)
+	ctx := context.Background()
+
+	token, err := tokens.Create(ctx, &models.APITokenInput{Name: "sdk", Permissions: "write"})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	c := New(server.URL).WithToken(token.Token)
+
+	for _, title := range []string{"one", "two", "three", "four", "five"} {
+		if _, err := c.CreateSnippet(ctx, &SnippetInput{Title: title, Content: title, Language: "plaintext"}); err != nil {
+			t.Fatalf("CreateSnippet failed: %v", err)
+		}
+	}
+
+	page, err := c.ListSnippets(ctx, ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListSnippets failed: %v", err)
+	}
+	if len(page.Snippets) != 2 || page.Pagination.Total != 5 || page.Pagination.TotalPages != 3 {
+		t.Fatalf("unexpected first page: %d snippets, %+v", len(page.Snippets), page.Pagination)
+	}
+
+	seen := map[string]bool{}
+	for snippet, err := range c.IterSnippets(ctx, ListOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("IterSnippets failed: %v", err)
+		}
+		seen[snippet.ID] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected to iterate 5 distinct snippets, got %d", len(seen))
+	}
+}
+
+func TestContract_Backup(t *testing.T) {
+	server, tokens := newTestServer(t)
+	ctx := context.Background()
+
+	token, err := tokens.Create(ctx, &models.APITokenInput{Name: "backup", Permissions: "admin"})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	c := New(server.URL).WithToken(token.Token)
+
+	if _, err := c.CreateSnippet(ctx, &SnippetInput{Title: "Backed up", Content: "x", Language: "plaintext", Tags: []string{"keep"}}); err != nil {
+		t.Fatalf("CreateSnippet failed: %v", err)
+	}
+
+	backup, err := c.ExportBackup(ctx, ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+
+	result, err := c.ImportBackup(ctx, backup, ImportOptions{Strategy: "replace"})
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if result.SnippetsImported != 1 || result.SafetySnapshot == "" {
+		t.Errorf("unexpected import result: %+v", result)
+	}
+
+	job, err := c.ImportBackupAsync(ctx, backup, ImportOptions{Strategy: "replace"})
+	if err != nil {
+		t.Fatalf("ImportBackupAsync failed: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	job, err = c.WaitForJob(waitCtx, job.ID, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForJob failed: %v", err)
+	}
+	if job.Status != JobStatusSucceeded {
+		t.Errorf("expected the import job to succeed, got %s (%s)", job.Status, job.Error)
+	}
+
+	if _, err := c.ImportBackup(ctx, []byte("not a backup"), ImportOptions{}); err == nil {
+		t.Error("expected an invalid backup to be rejected")
+	} else if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 APIError, got %v", err)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"code":"RATE_LIMIT_EXCEEDED","message":"slow down"}}`))
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{"data":{"id":"abc","title":"retried"}}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL).WithRetries(3, time.Millisecond)
+	snippet, err := c.GetSnippet(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("expected the request to succeed after retries, got %v", err)
+	}
+	if snippet.Title != "retried" || calls.Load() != 3 {
+		t.Errorf("expected 3 attempts and the final snippet, got %d attempts and %+v", calls.Load(), snippet)
+	}
+
+	// Creates are not repeated after a server error, which may have applied them
+	calls.Store(1)
+	if _, err := c.CreateSnippet(context.Background(), &SnippetInput{Title: "once"}); err == nil {
+		t.Fatal("expected the 503 to be returned")
+	} else if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected a 503 APIError, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a single attempt, got %d", calls.Load()-1)
+	}
+
+	// Without retries the rate limit error comes straight back
+	calls.Store(0)
+	_, err = New(server.URL).WithRetries(0, 0).GetSnippet(context.Background(), "abc")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != "RATE_LIMIT_EXCEEDED" || apiErr.Message != "slow down" {
+		t.Errorf("expected the rate limit error, got %v", err)
+	}
+}
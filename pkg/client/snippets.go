@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListOptions filters and pages GET /api/v1/snippets. Zero values are left
+// to the server defaults (page 1, 20 per page, newest first).
+type ListOptions struct {
+	Query     string
+	Language  string
+	License   string // SPDX identifier, e.g. "MIT"
+	Review    string // Review state: "draft", "pending" or "approved"
+	TagIDs    []int64
+	FolderIDs []int64
+	Favorite  *bool
+	Archived  *bool
+	Metadata  map[string]string // Exact matches on custom fields
+	Sort      string            // e.g. "updated_at", "created_at", "title"
+	Order     string            // "asc" or "desc"
+	Page      int
+	Limit     int
+}
+
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Language != "" {
+		q.Set("language", o.Language)
+	}
+	if o.License != "" {
+		q.Set("license", o.License)
+	}
+	if o.Review != "" {
+		q.Set("review_state", o.Review)
+	}
+	if len(o.TagIDs) > 0 {
+		q.Set("tag_ids", joinIDs(o.TagIDs))
+	}
+	if len(o.FolderIDs) > 0 {
+		q.Set("folder_ids", joinIDs(o.FolderIDs))
+	}
+	if o.Favorite != nil {
+		q.Set("favorite", strconv.FormatBool(*o.Favorite))
+	}
+	if o.Archived != nil {
+		q.Set("is_archived", strconv.FormatBool(*o.Archived))
+	}
+	for key, value := range o.Metadata {
+		q.Set("metadata."+key, value)
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	if o.Order != "" {
+		q.Set("order", o.Order)
+	}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return q
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SnippetPage is one page of snippets
+type SnippetPage struct {
+	Snippets   []Snippet
+	Pagination Pagination
+}
+
+// ListSnippets returns one page of snippets
+func (c *Client) ListSnippets(ctx context.Context, opts ListOptions) (*SnippetPage, error) {
+	var snippets []Snippet
+	pagination, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/snippets", query: opts.values()}, &snippets)
+	if err != nil {
+		return nil, err
+	}
+	page := &SnippetPage{Snippets: snippets}
+	if pagination != nil {
+		page.Pagination = *pagination
+	}
+	return page, nil
+}
+
+// IterSnippets yields every snippet matching opts, fetching pages as needed
+// from opts.Page onwards. Iteration stops at the first error, which is
+// yielded with a zero Snippet.
+func (c *Client) IterSnippets(ctx context.Context, opts ListOptions) iter.Seq2[Snippet, error] {
+	return func(yield func(Snippet, error) bool) {
+		if opts.Page < 1 {
+			opts.Page = 1
+		}
+		for {
+			page, err := c.ListSnippets(ctx, opts)
+			if err != nil {
+				yield(Snippet{}, err)
+				return
+			}
+			for _, snippet := range page.Snippets {
+				if !yield(snippet, nil) {
+					return
+				}
+			}
+			if len(page.Snippets) == 0 || page.Pagination.Page >= page.Pagination.TotalPages {
+				return
+			}
+			opts.Page = page.Pagination.Page + 1
+		}
+	}
+}
+
+// GetSnippet returns a snippet by ID
+func (c *Client) GetSnippet(ctx context.Context, id string) (*Snippet, error) {
+	var snippet Snippet
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/snippets/" + url.PathEscape(id)}, &snippet); err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// GetSnippetBySlug returns a snippet by its share slug
+func (c *Client) GetSnippetBySlug(ctx context.Context, slug string) (*Snippet, error) {
+	var snippet Snippet
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/snippets/by-slug/" + url.PathEscape(slug)}, &snippet); err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// CreateSnippet creates a snippet
+func (c *Client) CreateSnippet(ctx context.Context, input *SnippetInput) (*Snippet, error) {
+	var snippet Snippet
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/snippets", body: input}, &snippet); err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// UpdateSnippet replaces a snippet
+func (c *Client) UpdateSnippet(ctx context.Context, id string, input *SnippetInput) (*Snippet, error) {
+	var snippet Snippet
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/snippets/" + url.PathEscape(id), body: input}, &snippet); err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// DeleteSnippet deletes a snippet
+func (c *Client) DeleteSnippet(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/snippets/" + url.PathEscape(id)}, nil)
+	return err
+}
+
+// SearchSnippets runs a full-text search, returning up to limit results
+// (the server default when limit is 0)
+func (c *Client) SearchSnippets(ctx context.Context, query string, limit int) ([]Snippet, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var snippets []Snippet
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/snippets/search", query: q}, &snippets); err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"github.com/MohamedElashri/snipo/internal/models"
+)
+
+// The API's JSON types are shared with the server, so the client cannot
+// drift from what the handlers send and accept.
+type (
+	// Snippet is a stored snippet with its files, tags and folders
+	Snippet = models.Snippet
+	// SnippetFile is one file of a multi-file snippet
+	SnippetFile = models.SnippetFile
+	// SnippetInput creates or replaces a snippet
+	SnippetInput = models.SnippetInput
+	// SnippetFileInput is one file of a SnippetInput
+	SnippetFileInput = models.SnippetFileInput
+	// Tag labels snippets
+	Tag = models.Tag
+	// Folder groups snippets
+	Folder = models.Folder
+	// ImportResult summarizes a finished backup restore
+	ImportResult = models.ImportResult
+	// Job is a background job, such as an async backup restore
+	Job = models.Job
+	// JobStatus is the state of a Job
+	JobStatus = models.JobStatus
+)
+
+// Job states
+const (
+	JobStatusPending   = models.JobStatusPending
+	JobStatusRunning   = models.JobStatusRunning
+	JobStatusSucceeded = models.JobStatusSucceeded
+	JobStatusFailed    = models.JobStatusFailed
+)
+
+// Pagination describes one page of a list response
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}